@@ -77,7 +77,7 @@ func (service *Service) NewAccount(payload NewAccountPayload, privateKey crypto.
 	}
 
 	// url to post to
-	url := service.dir.NewAccount
+	url := service.directory().NewAccount
 
 	// real ACME payload
 	acmePayload := acmeNewAccountPayload{
@@ -177,7 +177,7 @@ func (service *Service) DeactivateAccount(accountKey AccountKey) (acct Account,
 // retires the old key from the account and substitutes the new key in its place.
 func (service *Service) RolloverAccountKey(newKey crypto.PrivateKey, oldAccountKey AccountKey) (err error) {
 	// if directory doesn't contain a keyChange URL, return error
-	if service.dir.KeyChange == "" {
+	if service.directory().KeyChange == "" {
 		return errors.New("acme: account key rollover failed (acme directory does not contain a keyChange url)")
 	}
 
@@ -208,7 +208,7 @@ func (service *Service) RolloverAccountKey(newKey crypto.PrivateKey, oldAccountK
 	// omit nonce
 
 	// url
-	innerHeader.Url = service.dir.KeyChange
+	innerHeader.Url = service.directory().KeyChange
 
 	// encode and add to payload
 	payload.ProtectedHeader, err = encodeJson(innerHeader)
@@ -249,7 +249,7 @@ func (service *Service) RolloverAccountKey(newKey crypto.PrivateKey, oldAccountK
 
 	// post key change
 	// no response/headers expected on key roll (see rfc 8555 s 7.3.5)
-	_, _, err = service.postToUrlSigned(payload, service.dir.KeyChange, oldAccountKey)
+	_, _, err = service.postToUrlSigned(payload, service.directory().KeyChange, oldAccountKey)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,330 @@
+package acmetest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// accountResponse is the ACME account object (RFC 8555 7.1.2)
+type accountResponse struct {
+	Status  string   `json:"status"`
+	Contact []string `json:"contact,omitempty"`
+	Orders  string   `json:"orders"`
+}
+
+// writeAccount writes the account object and its Location
+func (server *Server) writeAccount(w http.ResponseWriter, status int, acct *account) *problem {
+	server.mu.Lock()
+	response := accountResponse{
+		Status:  acct.status,
+		Contact: acct.contact,
+		Orders:  server.url("/account/" + acct.id + "/orders"),
+	}
+	server.mu.Unlock()
+
+	w.Header().Set("Location", server.url("/account/"+acct.id))
+	return writeJSON(w, status, response)
+}
+
+// accountByThumbprint returns the account with the key thumbprint, or nil if there is
+// no such account. The caller must hold server.mu.
+func (server *Server) accountByThumbprint(thumbprint string) *account {
+	for _, acct := range server.accounts {
+		if acct.thumbprint == thumbprint {
+			return acct
+		}
+	}
+
+	return nil
+}
+
+// validateContact verifies all contacts are mailto urls
+func validateContact(contact []string) *problem {
+	for _, c := range contact {
+		if !strings.HasPrefix(c, "mailto:") || strings.Contains(c, ",") || len(c) <= len("mailto:") {
+			return newProblem(http.StatusBadRequest, errTypeInvalidContact, "contact must be a single mailto address")
+		}
+	}
+
+	return nil
+}
+
+// verifyEab verifies an external account binding (RFC 8555 7.3.4) of the account key
+// with the specified thumbprint
+func (server *Server) verifyEab(raw json.RawMessage, thumbprint string) *problem {
+	errBadEab := errUnauthorized("external account binding is invalid")
+
+	jws, err := decodeJws(raw)
+	if err != nil {
+		return errMalformed("external account binding is not a valid flattened jws")
+	}
+
+	var newHash func() hash.Hash
+	switch jws.header.Algorithm {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "external account binding algorithm is not supported")
+	}
+	if jws.header.Nonce != "" || jws.header.Url != server.url("/new-account") {
+		return errBadEab
+	}
+
+	// payload must be the account's key
+	var innerJwk jsonWebKey
+	err = json.Unmarshal(jws.payload, &innerJwk)
+	if err != nil {
+		return errBadEab
+	}
+	innerThumbprint, err := innerJwk.thumbprint()
+	if err != nil || innerThumbprint != thumbprint {
+		return errBadEab
+	}
+
+	encodedKey, exists := server.config.ExternalAccountKeys[jws.header.KeyId]
+	if !exists {
+		return errBadEab
+	}
+	hmacKey, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return errServerInternal("external account key is not base64url encoded")
+	}
+
+	mac := hmac.New(newHash, hmacKey)
+	_, _ = mac.Write([]byte(jws.message.Protected + "." + jws.message.Payload))
+	if !hmac.Equal(mac.Sum(nil), jws.signature) {
+		return errBadEab
+	}
+
+	return nil
+}
+
+// newAccountRequest is the payload of a new-account request
+type newAccountRequest struct {
+	Contact                []string        `json:"contact"`
+	TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+	OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+}
+
+// newAccount creates an account, or returns the existing account for the key (RFC 8555 7.3)
+func (server *Server) newAccount(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByJwk)
+	if prob != nil {
+		return prob
+	}
+
+	var payload newAccountRequest
+	err := json.Unmarshal(request.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse new account request")
+	}
+
+	thumbprint, err := request.jwk.thumbprint()
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadPublicKey, err.Error())
+	}
+
+	server.mu.Lock()
+	existing := server.accountByThumbprint(thumbprint)
+	server.mu.Unlock()
+	if existing != nil {
+		return server.writeAccount(w, http.StatusOK, existing)
+	}
+
+	if payload.OnlyReturnExisting {
+		return newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "account does not exist")
+	}
+	if server.config.TermsOfService != "" && !payload.TermsOfServiceAgreed {
+		return newProblem(http.StatusForbidden, errTypeUserActionRequired, "terms of service must be agreed to")
+	}
+	prob = validateContact(payload.Contact)
+	if prob != nil {
+		return prob
+	}
+
+	if len(payload.ExternalAccountBinding) > 0 {
+		prob = server.verifyEab(payload.ExternalAccountBinding, thumbprint)
+		if prob != nil {
+			return prob
+		}
+	} else if server.config.RequireEAB {
+		return newProblem(http.StatusUnauthorized, errTypeExternalAccountRequired, "external account binding is required")
+	}
+
+	pub, _ := request.jwk.publicKey()
+	acct := &account{
+		id:         randomId(),
+		key:        pub,
+		thumbprint: thumbprint,
+		status:     statusValid,
+		contact:    payload.Contact,
+	}
+
+	server.mu.Lock()
+	server.accounts[acct.id] = acct
+	server.mu.Unlock()
+
+	return server.writeAccount(w, http.StatusCreated, acct)
+}
+
+// verifyAccountParam verifies the request is signed by the account in the url
+func (server *Server) verifyAccountParam(r *http.Request) (*signedRequest, *problem) {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return nil, prob
+	}
+
+	if httprouter.ParamsFromContext(r.Context()).ByName("id") != request.account.id {
+		return nil, errUnauthorized("account does not match the request signer")
+	}
+
+	return request, nil
+}
+
+// accountUpdateRequest is the payload of an account update request
+type accountUpdateRequest struct {
+	Status  *string   `json:"status"`
+	Contact *[]string `json:"contact"`
+}
+
+// postAccount returns the account (POST-as-GET) or updates its contact or deactivates
+// it (RFC 8555 7.3.2 & 7.3.6)
+func (server *Server) postAccount(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyAccountParam(r)
+	if prob != nil {
+		return prob
+	}
+
+	if len(request.payload) > 0 {
+		var payload accountUpdateRequest
+		err := json.Unmarshal(request.payload, &payload)
+		if err != nil {
+			return errMalformed("failed to parse account update request")
+		}
+
+		if payload.Status != nil && *payload.Status != statusDeactivated {
+			return errMalformed("account status may only be updated to deactivated")
+		}
+		if payload.Contact != nil {
+			prob = validateContact(*payload.Contact)
+			if prob != nil {
+				return prob
+			}
+		}
+
+		server.mu.Lock()
+		if payload.Status != nil {
+			request.account.status = *payload.Status
+		}
+		if payload.Contact != nil {
+			request.account.contact = *payload.Contact
+		}
+		server.mu.Unlock()
+	}
+
+	return server.writeAccount(w, http.StatusOK, request.account)
+}
+
+// accountOrdersResponse is the ACME orders list (RFC 8555 7.1.2.1)
+type accountOrdersResponse struct {
+	Orders []string `json:"orders"`
+}
+
+// postAccountOrders returns the list of the account's orders
+func (server *Server) postAccountOrders(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyAccountParam(r)
+	if prob != nil {
+		return prob
+	}
+
+	response := accountOrdersResponse{
+		Orders: []string{},
+	}
+
+	server.mu.Lock()
+	for _, orderId := range request.account.orderIds {
+		response.Orders = append(response.Orders, server.url("/order/"+orderId))
+	}
+	server.mu.Unlock()
+
+	return writeJSON(w, http.StatusOK, response)
+}
+
+// keyChangeRequest is the payload of the inner jws of a key change request
+type keyChangeRequest struct {
+	Account string     `json:"account"`
+	OldKey  jsonWebKey `json:"oldKey"`
+}
+
+// keyChange rolls the account over to a new key (RFC 8555 7.3.5)
+func (server *Server) keyChange(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	// inner jws is signed by the new key
+	inner, err := decodeJws(request.payload)
+	if err != nil {
+		return errMalformed("key change payload is not a valid flattened jws")
+	}
+	if inner.header.Jwk == nil || inner.header.KeyId != "" || inner.header.Nonce != "" {
+		return errMalformed("key change inner jws must be signed with a jwk and have no nonce")
+	}
+	if inner.header.Url != request.url {
+		return errMalformed("key change inner jws url does not match")
+	}
+	newKey, err := inner.header.Jwk.publicKey()
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadPublicKey, err.Error())
+	}
+	prob = inner.verify(newKey)
+	if prob != nil {
+		return prob
+	}
+
+	var payload keyChangeRequest
+	err = json.Unmarshal(inner.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse key change request")
+	}
+	if payload.Account != server.url("/account/"+request.account.id) {
+		return errMalformed("key change account does not match the request signer")
+	}
+	oldThumbprint, err := payload.OldKey.thumbprint()
+	if err != nil || oldThumbprint != request.account.thumbprint {
+		return errMalformed("key change old key is not the account's key")
+	}
+	newThumbprint, err := inner.header.Jwk.thumbprint()
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadPublicKey, err.Error())
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	existing := server.accountByThumbprint(newThumbprint)
+	if existing != nil {
+		w.Header().Set("Location", server.url("/account/"+existing.id))
+		return newProblem(http.StatusConflict, errTypeConflict, "new key is already in use by an account")
+	}
+
+	request.account.key = newKey
+	request.account.thumbprint = newThumbprint
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
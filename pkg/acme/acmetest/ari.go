@@ -0,0 +1,82 @@
+package acmetest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// renewalInfoRetryAfter is the Retry-After of renewal info responses, in seconds
+const renewalInfoRetryAfter = "21600"
+
+// certificateByAriId returns the issued certificate with the ARI certificate identifier
+// (draft-ietf-acme-ari 4.1), or nil if there is no such certificate. The caller must
+// hold server.mu.
+func (server *Server) certificateByAriId(ariId string) *certificate {
+	encodedAki, encodedSerial, found := strings.Cut(ariId, ".")
+	if !found {
+		return nil
+	}
+	aki, err := base64.RawURLEncoding.DecodeString(encodedAki)
+	if err != nil {
+		return nil
+	}
+	serial, err := base64.RawURLEncoding.DecodeString(encodedSerial)
+	if err != nil {
+		return nil
+	}
+
+	for _, cert := range server.certificates {
+		if bytes.Equal(cert.leaf.AuthorityKeyId, aki) && bytes.Equal(cert.leaf.SerialNumber.Bytes(), serial) {
+			return cert
+		}
+	}
+
+	return nil
+}
+
+// renewalInfoResponse is the ARI RenewalInfo object (draft-ietf-acme-ari 4.2)
+type renewalInfoResponse struct {
+	SuggestedWindow struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"suggestedWindow"`
+}
+
+// getRenewalInfo returns the suggested renewal window of a certificate. The window
+// starts two thirds of the way through the certificate's lifetime, unless the
+// certificate was revoked in which case the window is already open.
+func (server *Server) getRenewalInfo(w http.ResponseWriter, r *http.Request) *problem {
+	server.mu.Lock()
+	cert := server.certificateByAriId(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	var revoked bool
+	if cert != nil {
+		revoked = cert.revoked
+	}
+	server.mu.Unlock()
+
+	if cert == nil {
+		return errNotFound("certificate does not exist")
+	}
+
+	var start, end time.Time
+	if revoked {
+		start = time.Now().Add(-1 * time.Hour)
+		end = time.Now()
+	} else {
+		lifetime := cert.leaf.NotAfter.Sub(cert.leaf.NotBefore)
+		start = cert.leaf.NotBefore.Add(lifetime * 2 / 3)
+		end = start.Add(lifetime / 9)
+	}
+
+	response := renewalInfoResponse{}
+	response.SuggestedWindow.Start = formatTime(start)
+	response.SuggestedWindow.End = formatTime(end)
+
+	w.Header().Set("Retry-After", renewalInfoRetryAfter)
+	return writeJSON(w, http.StatusOK, response)
+}
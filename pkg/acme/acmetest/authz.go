@@ -0,0 +1,149 @@
+package acmetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// challengeResponse is the ACME challenge object (RFC 8555 7.1.5)
+type challengeResponse struct {
+	Type      string   `json:"type"`
+	Url       string   `json:"url"`
+	Status    string   `json:"status"`
+	Token     string   `json:"token"`
+	Validated string   `json:"validated,omitempty"`
+	Error     *problem `json:"error,omitempty"`
+}
+
+// challengeResponse returns the response object for the challenge. The caller must
+// hold server.mu.
+func (server *Server) challengeResponse(chall *challenge) challengeResponse {
+	response := challengeResponse{
+		Type:   chall.challType,
+		Url:    server.url("/chall/" + chall.id),
+		Status: chall.status,
+		Token:  chall.token,
+		Error:  chall.err,
+	}
+	if !chall.validated.IsZero() {
+		response.Validated = formatTime(chall.validated)
+	}
+
+	return response
+}
+
+// authorizationResponse is the ACME authorization object (RFC 8555 7.1.4)
+type authorizationResponse struct {
+	Status     string              `json:"status"`
+	Expires    string              `json:"expires"`
+	Identifier identifier          `json:"identifier"`
+	Challenges []challengeResponse `json:"challenges"`
+	Wildcard   bool                `json:"wildcard,omitempty"`
+}
+
+// accountAuthorization returns the authorization in the url, if it belongs to the
+// account
+func (server *Server) accountAuthorization(r *http.Request, acct *account) (*authorization, *problem) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	authz, exists := server.authzs[httprouter.ParamsFromContext(r.Context()).ByName("id")]
+	if !exists || authz.accountId != acct.id {
+		return nil, errNotFound("authorization does not exist")
+	}
+
+	return authz, nil
+}
+
+// authorizationUpdateRequest is the payload of an authorization update request
+type authorizationUpdateRequest struct {
+	Status string `json:"status"`
+}
+
+// postAuthorization returns the authorization (POST-as-GET) or deactivates it
+// (RFC 8555 7.5 & 7.5.2)
+func (server *Server) postAuthorization(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	authz, prob := server.accountAuthorization(r, request.account)
+	if prob != nil {
+		return prob
+	}
+
+	var update authorizationUpdateRequest
+	if len(request.payload) > 0 {
+		err := json.Unmarshal(request.payload, &update)
+		if err != nil || update.Status != statusDeactivated {
+			return errMalformed("authorization status may only be updated to deactivated")
+		}
+	}
+
+	server.mu.Lock()
+	if update.Status == statusDeactivated {
+		authz.status = statusDeactivated
+	}
+
+	if authz.status == statusPending && time.Now().After(authz.expires) {
+		authz.status = statusInvalid
+	}
+
+	response := authorizationResponse{
+		Status:     authz.status,
+		Expires:    formatTime(authz.expires),
+		Identifier: authz.identifier,
+		Challenges: []challengeResponse{},
+		Wildcard:   authz.wildcard,
+	}
+	for _, challId := range authz.challengeIds {
+		response.Challenges = append(response.Challenges, server.challengeResponse(server.challenges[challId]))
+	}
+	server.mu.Unlock()
+
+	return writeJSON(w, http.StatusOK, response)
+}
+
+// postChallenge returns the challenge (POST-as-GET) or, if the client is responding to
+// it, starts validating it in the background (RFC 8555 7.5.1)
+func (server *Server) postChallenge(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	server.mu.Lock()
+	chall, exists := server.challenges[httprouter.ParamsFromContext(r.Context()).ByName("id")]
+	if !exists || server.authzs[chall.authzId].accountId != request.account.id {
+		server.mu.Unlock()
+		return errNotFound("challenge does not exist")
+	}
+	authz := server.authzs[chall.authzId]
+
+	// any payload other than POST-as-GET is a response to the challenge
+	if len(request.payload) > 0 && chall.status == statusPending {
+		if authz.status != statusPending {
+			server.mu.Unlock()
+			return errMalformed("authorization is " + authz.status)
+		}
+
+		chall.status = statusProcessing
+		keyAuth := chall.token + "." + request.account.thumbprint
+
+		server.validations.Add(1)
+		go func() {
+			defer server.validations.Done()
+			server.validateChallenge(chall, authz, keyAuth)
+		}()
+	}
+
+	response := server.challengeResponse(chall)
+	w.Header().Add("Link", `<`+server.url("/authz/"+authz.id)+`>;rel="up"`)
+	server.mu.Unlock()
+
+	return writeJSON(w, http.StatusOK, response)
+}
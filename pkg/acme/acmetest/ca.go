@@ -0,0 +1,177 @@
+package acmetest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// intermediateCommonName is the subject of the issuing intermediate in every chain
+const intermediateCommonName = "acmetest intermediate"
+
+// certificateAuthority is an ephemeral CA with one issuing key. The intermediate is
+// cross signed by several roots, each of which terminates a different chain.
+type certificateAuthority struct {
+	roots           []*x509.Certificate
+	intermediates   []*x509.Certificate
+	intermediateKey crypto.Signer
+}
+
+// rootCommonName returns the subject common name of root i
+func rootCommonName(i int) string {
+	return fmt.Sprintf("acmetest root %d", i)
+}
+
+// randomSerial returns a random positive 128 bit serial number
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	// avoid a 0 serial
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// newCertificateAuthority makes a CA with the specified number of roots (i.e. chains)
+func newCertificateAuthority(rootCount int) (*certificateAuthority, error) {
+	ca := &certificateAuthority{}
+
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ca.intermediateKey = intermediateKey
+
+	notBefore := time.Now().Add(-1 * time.Hour).Truncate(time.Second)
+	notAfter := notBefore.Add(10 * 365 * 24 * time.Hour)
+
+	for i := range rootCount {
+		rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		serial, err := randomSerial()
+		if err != nil {
+			return nil, err
+		}
+		rootTemplate := &x509.Certificate{
+			SerialNumber:          serial,
+			Subject:               pkix.Name{CommonName: rootCommonName(i)},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		rootDer, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+		if err != nil {
+			return nil, err
+		}
+		root, err := x509.ParseCertificate(rootDer)
+		if err != nil {
+			return nil, err
+		}
+
+		serial, err = randomSerial()
+		if err != nil {
+			return nil, err
+		}
+		intermediateTemplate := &x509.Certificate{
+			SerialNumber:          serial,
+			Subject:               pkix.Name{CommonName: intermediateCommonName},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+			MaxPathLenZero:        true,
+		}
+		intermediateDer, err := x509.CreateCertificate(rand.Reader, intermediateTemplate, root, intermediateKey.Public(), rootKey)
+		if err != nil {
+			return nil, err
+		}
+		intermediate, err := x509.ParseCertificate(intermediateDer)
+		if err != nil {
+			return nil, err
+		}
+
+		ca.roots = append(ca.roots, root)
+		ca.intermediates = append(ca.intermediates, intermediate)
+	}
+
+	return ca, nil
+}
+
+// issue signs a certificate for the csr, which must already be validated, and returns
+// it along with each of its pem chains
+func (ca *certificateAuthority) issue(csr *x509.CertificateRequest, notBefore time.Time, notAfter time.Time) (*x509.Certificate, []string, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commonName := csr.Subject.CommonName
+	if commonName == "" && len(csr.DNSNames) > 0 {
+		commonName = csr.DNSNames[0]
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	// every intermediate has the same key and subject, so any of them can be the parent
+	leafDer, err := x509.CreateCertificate(rand.Reader, template, ca.intermediates[0], csr.PublicKey, ca.intermediateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	leaf, err := x509.ParseCertificate(leafDer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leafPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDer})
+	chains := []string{}
+	for _, intermediate := range ca.intermediates {
+		intermediatePem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: intermediate.Raw})
+		chains = append(chains, string(leafPem)+string(intermediatePem))
+	}
+
+	return leaf, chains, nil
+}
+
+// RootCommonNames returns the subject common names of the roots that terminate the
+// chains of issued certificates. The first is the root of the default chain.
+func (server *Server) RootCommonNames() []string {
+	names := []string{}
+	for _, root := range server.ca.roots {
+		names = append(names, root.Subject.CommonName)
+	}
+
+	return names
+}
+
+// Roots returns a pool containing all of the server's roots
+func (server *Server) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, root := range server.ca.roots {
+		pool.AddCert(root)
+	}
+
+	return pool
+}
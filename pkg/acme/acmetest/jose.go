@@ -0,0 +1,408 @@
+package acmetest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxRequestBodySize limits how much of a request body is read
+const maxRequestBodySize = 1 << 20
+
+// randomId returns a random base64url string, used for nonces and object ids
+func randomId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// makeNonce creates and saves a new nonce
+func (server *Server) makeNonce() string {
+	nonce := randomId()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.nonces[nonce] = struct{}{}
+
+	return nonce
+}
+
+// useNonce consumes the nonce and returns true if it was valid
+func (server *Server) useNonce(nonce string) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	_, exists := server.nonces[nonce]
+	delete(server.nonces, nonce)
+
+	return exists
+}
+
+// jsonWebKey is a public key (RFC 7517)
+type jsonWebKey struct {
+	KeyType        string `json:"kty"`
+	PublicExponent string `json:"e,omitempty"`   // RSA
+	Modulus        string `json:"n,omitempty"`   // RSA
+	CurveName      string `json:"crv,omitempty"` // EC & OKP
+	CurvePointX    string `json:"x,omitempty"`   // EC & OKP
+	CurvePointY    string `json:"y,omitempty"`   // EC
+}
+
+// thumbprint returns the base64url encoded SHA-256 thumbprint of the jwk (RFC 7638)
+func (jwk jsonWebKey) thumbprint() (string, error) {
+	// members are alphabetical in each struct to produce the canonical form
+	var canonical []byte
+	var err error
+	switch jwk.KeyType {
+	case "RSA":
+		canonical, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.PublicExponent, jwk.KeyType, jwk.Modulus})
+	case "EC":
+		canonical, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.CurveName, jwk.KeyType, jwk.CurvePointX, jwk.CurvePointY})
+	case "OKP":
+		canonical, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.CurveName, jwk.KeyType, jwk.CurvePointX})
+	default:
+		err = errors.New("unsupported jwk key type")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// publicKey returns the crypto.PublicKey the jwk represents
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode(jwk.Modulus)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.PublicExponent)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || n.BitLen() < 2048 {
+			return nil, errors.New("rsa key is not acceptable")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.CurveName {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("unsupported ec curve")
+		}
+
+		x, err := decode(jwk.CurvePointX)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.CurvePointY)
+		if err != nil {
+			return nil, err
+		}
+
+		pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// ECDH conversion validates the point is on the curve
+		_, err = pub.ECDH()
+		if err != nil {
+			return nil, err
+		}
+
+		return pub, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.CurvePointX)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.CurveName != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported okp key")
+		}
+
+		return ed25519.PublicKey(x), nil
+
+	default:
+		// break to final error return
+	}
+
+	return nil, errors.New("unsupported jwk key type")
+}
+
+// samePublicKey returns true if a and b are the same key
+func samePublicKey(a, b crypto.PublicKey) bool {
+	equaler, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && equaler.Equal(b)
+}
+
+// jwsMessage is a flattened JWS (RFC 7515 7.2.2)
+type jwsMessage struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// jwsProtectedHeader is the decoded protected header of a jwsMessage
+type jwsProtectedHeader struct {
+	Algorithm string      `json:"alg"`
+	Nonce     string      `json:"nonce"`
+	Url       string      `json:"url"`
+	Jwk       *jsonWebKey `json:"jwk"`
+	KeyId     string      `json:"kid"`
+}
+
+// decodedJws is a jwsMessage with its pieces decoded. Nothing has been verified.
+type decodedJws struct {
+	message   jwsMessage
+	header    jwsProtectedHeader
+	payload   []byte
+	signature []byte
+}
+
+// decodeJws unmarshals and decodes the pieces of a flattened JWS
+func decodeJws(data []byte) (*decodedJws, error) {
+	jws := &decodedJws{}
+
+	err := json.Unmarshal(data, &jws.message)
+	if err != nil {
+		return nil, err
+	}
+
+	headerJson, err := base64.RawURLEncoding.DecodeString(jws.message.Protected)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(headerJson, &jws.header)
+	if err != nil {
+		return nil, err
+	}
+
+	jws.payload, err = base64.RawURLEncoding.DecodeString(jws.message.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	jws.signature, err = base64.RawURLEncoding.DecodeString(jws.message.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return jws, nil
+}
+
+// verify verifies the jws signature with pub
+func (jws *decodedJws) verify(pub crypto.PublicKey) *problem {
+	errBadSig := errMalformed("jws signature is invalid")
+	signingInput := []byte(jws.message.Protected + "." + jws.message.Payload)
+
+	switch jws.header.Algorithm {
+	case "RS256":
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		hashed := sha256.Sum256(signingInput)
+		if rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, hashed[:], jws.signature) != nil {
+			return errBadSig
+		}
+
+		return nil
+
+	case "ES256", "ES384", "ES512":
+		ecPub, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		// hash must correspond to the curve
+		var hashed []byte
+		switch {
+		case jws.header.Algorithm == "ES256" && ecPub.Curve == elliptic.P256():
+			h := sha256.Sum256(signingInput)
+			hashed = h[:]
+		case jws.header.Algorithm == "ES384" && ecPub.Curve == elliptic.P384():
+			h := sha512.Sum384(signingInput)
+			hashed = h[:]
+		case jws.header.Algorithm == "ES512" && ecPub.Curve == elliptic.P521():
+			h := sha512.Sum512(signingInput)
+			hashed = h[:]
+		default:
+			return errBadSig
+		}
+
+		// signature is r || s, each zero padded to the curve's octet length
+		octetLength := (ecPub.Curve.Params().BitSize + 7) >> 3
+		if len(jws.signature) != 2*octetLength {
+			return errBadSig
+		}
+		r := new(big.Int).SetBytes(jws.signature[:octetLength])
+		s := new(big.Int).SetBytes(jws.signature[octetLength:])
+
+		if !ecdsa.Verify(ecPub, hashed, r, s) {
+			return errBadSig
+		}
+
+		return nil
+
+	case "EdDSA":
+		edPub, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		if !ed25519.Verify(edPub, signingInput, jws.signature) {
+			return errBadSig
+		}
+
+		return nil
+
+	default:
+		// break to final error return
+	}
+
+	return newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "jws algorithm "+jws.header.Algorithm+" is not supported")
+}
+
+// signedRequest is an ACME request whose signature has been verified
+type signedRequest struct {
+	// payload is empty for POST-as-GET
+	payload []byte
+	url     string
+
+	// exactly one of the following is set, depending on if the request was
+	// signed with a jwk or an account's kid
+	jwk     *jsonWebKey
+	account *account
+}
+
+// signerMode specifies how a request must be signed
+type signerMode int
+
+const (
+	signedByKid signerMode = iota
+	signedByJwk
+	signedByKidOrJwk
+)
+
+// verifyRequest reads and verifies the JWS body of the request (RFC 8555 6.2 - 6.5). The
+// request must be signed with a jwk and/or a valid account's kid, depending on mode.
+func (server *Server) verifyRequest(r *http.Request, mode signerMode) (*signedRequest, *problem) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/jose+json" {
+		return nil, newProblem(http.StatusUnsupportedMediaType, errTypeMalformed, "content-type must be application/jose+json")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		return nil, errMalformed("failed to read request body")
+	}
+
+	jws, err := decodeJws(body)
+	if err != nil {
+		return nil, errMalformed("request body is not a valid flattened jws")
+	}
+
+	if !server.useNonce(jws.header.Nonce) {
+		return nil, newProblem(http.StatusBadRequest, errTypeBadNonce, "jws nonce is invalid")
+	}
+
+	if jws.header.Url != server.url(r.URL.Path) {
+		return nil, errUnauthorized("jws url does not match the request url")
+	}
+
+	if jws.header.Algorithm == "" || jws.header.Algorithm == "none" || strings.HasPrefix(jws.header.Algorithm, "HS") {
+		return nil, newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "jws algorithm is not acceptable")
+	}
+
+	request := &signedRequest{
+		payload: jws.payload,
+		url:     jws.header.Url,
+	}
+
+	// key (jwk XOR kid)
+	if (jws.header.Jwk == nil) == (jws.header.KeyId == "") {
+		return nil, errMalformed("jws must contain exactly one of jwk and kid")
+	}
+	if mode == signedByJwk && jws.header.Jwk == nil {
+		return nil, errMalformed("jws must be signed with a jwk")
+	}
+	if mode == signedByKid && jws.header.KeyId == "" {
+		return nil, errMalformed("jws must be signed with an account kid")
+	}
+
+	var pub crypto.PublicKey
+	if jws.header.Jwk != nil {
+		pub, err = jws.header.Jwk.publicKey()
+		if err != nil {
+			return nil, newProblem(http.StatusBadRequest, errTypeBadPublicKey, err.Error())
+		}
+
+		request.jwk = jws.header.Jwk
+	} else {
+		acct := server.accountByUrl(jws.header.KeyId)
+		if acct == nil {
+			return nil, newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "account does not exist")
+		}
+
+		server.mu.Lock()
+		status := acct.status
+		pub = acct.key
+		server.mu.Unlock()
+		if status != statusValid {
+			return nil, errUnauthorized("account is not valid")
+		}
+
+		request.account = acct
+	}
+
+	prob := jws.verify(pub)
+	if prob != nil {
+		return nil, prob
+	}
+
+	return request, nil
+}
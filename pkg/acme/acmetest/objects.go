@@ -0,0 +1,143 @@
+package acmetest
+
+import (
+	"crypto"
+	"crypto/x509"
+	"strings"
+	"time"
+)
+
+// ACME object statuses (RFC 8555 7.1.6)
+const (
+	statusPending     = "pending"
+	statusReady       = "ready"
+	statusProcessing  = "processing"
+	statusValid       = "valid"
+	statusInvalid     = "invalid"
+	statusDeactivated = "deactivated"
+)
+
+// timeFormat is the format of timestamps in responses (RFC 3339, UTC, whole seconds)
+const timeFormat = "2006-01-02T15:04:05Z"
+
+// formatTime formats t for a response
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// objectLifetime is how long orders and authorizations are valid for
+const objectLifetime = 24 * time.Hour
+
+// identifier is an ACME identifier (RFC 8555 9.7.7 & RFC 8738)
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// account is an ACME account
+type account struct {
+	id         string
+	key        crypto.PublicKey
+	thumbprint string
+	status     string
+	contact    []string
+	orderIds   []string
+}
+
+// order is an ACME order
+type order struct {
+	id          string
+	accountId   string
+	status      string
+	expires     time.Time
+	identifiers []identifier
+	authzIds    []string
+	notBefore   time.Time
+	notAfter    time.Time
+	profile     string
+	replaces    string
+	err         *problem
+	certId      string
+}
+
+// authorization is an ACME authorization
+type authorization struct {
+	id           string
+	accountId    string
+	identifier   identifier
+	wildcard     bool
+	status       string
+	expires      time.Time
+	challengeIds []string
+}
+
+// challenge is an ACME challenge
+type challenge struct {
+	id        string
+	authzId   string
+	challType string
+	token     string
+	status    string
+	validated time.Time
+	err       *problem
+}
+
+// certificate is an issued certificate
+type certificate struct {
+	id        string
+	accountId string
+	leaf      *x509.Certificate
+
+	// chains are the pem chains offered for the certificate; the first is the default
+	chains []string
+
+	revoked          bool
+	revocationReason int
+	replacedBy       string
+}
+
+// accountByUrl returns the account whose url is accountUrl, or nil if there is no such
+// account
+func (server *Server) accountByUrl(accountUrl string) *account {
+	id, found := strings.CutPrefix(accountUrl, server.url("/account/"))
+	if !found {
+		return nil
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.accounts[id]
+}
+
+// updateOrderStatus updates the status of a pending order from its authorizations.
+// The caller must hold server.mu.
+func (server *Server) updateOrderStatus(o *order) {
+	if o.status != statusPending {
+		return
+	}
+
+	if time.Now().After(o.expires) {
+		o.status = statusInvalid
+		o.err = errMalformed("order expired")
+		return
+	}
+
+	allValid := true
+	for _, authzId := range o.authzIds {
+		switch server.authzs[authzId].status {
+		case statusValid:
+			// no-op
+		case statusInvalid, statusDeactivated:
+			o.status = statusInvalid
+			o.err = errUnauthorized("authorization for " + server.authzs[authzId].identifier.Value + " failed")
+			return
+		default:
+			allValid = false
+		}
+	}
+
+	if allValid {
+		o.status = statusReady
+	}
+}
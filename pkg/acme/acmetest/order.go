@@ -0,0 +1,455 @@
+package acmetest
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// orderResponse is the ACME order object (RFC 8555 7.1.3)
+type orderResponse struct {
+	Status         string       `json:"status"`
+	Expires        string       `json:"expires"`
+	Identifiers    []identifier `json:"identifiers"`
+	Authorizations []string     `json:"authorizations"`
+	Finalize       string       `json:"finalize"`
+	Certificate    string       `json:"certificate,omitempty"`
+	NotBefore      string       `json:"notBefore,omitempty"`
+	NotAfter       string       `json:"notAfter,omitempty"`
+	Error          *problem     `json:"error,omitempty"`
+	Profile        string       `json:"profile,omitempty"`
+	Replaces       string       `json:"replaces,omitempty"`
+}
+
+// writeOrder writes the order object and its Location
+func (server *Server) writeOrder(w http.ResponseWriter, status int, o *order) *problem {
+	server.mu.Lock()
+	server.updateOrderStatus(o)
+	response := orderResponse{
+		Status:         o.status,
+		Expires:        formatTime(o.expires),
+		Identifiers:    o.identifiers,
+		Authorizations: []string{},
+		Finalize:       server.url("/order/" + o.id + "/finalize"),
+		Error:          o.err,
+		Profile:        o.profile,
+		Replaces:       o.replaces,
+	}
+	for _, authzId := range o.authzIds {
+		response.Authorizations = append(response.Authorizations, server.url("/authz/"+authzId))
+	}
+	if o.certId != "" {
+		response.Certificate = server.url("/cert/" + o.certId)
+	}
+	if !o.notBefore.IsZero() {
+		response.NotBefore = formatTime(o.notBefore)
+	}
+	if !o.notAfter.IsZero() {
+		response.NotAfter = formatTime(o.notAfter)
+	}
+	server.mu.Unlock()
+
+	w.Header().Set("Location", server.url("/order/"+o.id))
+	if response.Status == statusProcessing {
+		w.Header().Set("Retry-After", "1")
+	}
+
+	return writeJSON(w, status, response)
+}
+
+// normalizeIdentifier validates the identifier and returns its canonical form
+func normalizeIdentifier(id identifier) (identifier, *problem) {
+	switch id.Type {
+	case "dns":
+		value := strings.ToLower(id.Value)
+		labels := strings.Split(strings.TrimPrefix(value, "*."), ".")
+		if len(labels) < 2 {
+			return identifier{}, newProblem(http.StatusBadRequest, errTypeRejectedIdentifier, "dns identifier "+id.Value+" is not a fully qualified domain")
+		}
+		for _, label := range labels {
+			if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") ||
+				strings.ContainsFunc(label, func(r rune) bool { return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') }) {
+				return identifier{}, newProblem(http.StatusBadRequest, errTypeRejectedIdentifier, "dns identifier "+id.Value+" is not a valid domain")
+			}
+		}
+
+		return identifier{Type: "dns", Value: value}, nil
+
+	case "ip":
+		addr, err := netip.ParseAddr(id.Value)
+		if err != nil || addr.Zone() != "" {
+			return identifier{}, errMalformed("ip identifier " + id.Value + " is not a valid ip address")
+		}
+
+		return identifier{Type: "ip", Value: addr.Unmap().String()}, nil
+
+	default:
+		// break to final error return
+	}
+
+	return identifier{}, newProblem(http.StatusBadRequest, errTypeUnsupportedIdentifier, "identifier type "+id.Type+" is not supported")
+}
+
+// challengeTypesFor returns the challenge types offered for the identifier
+func challengeTypesFor(id identifier, wildcard bool) []string {
+	if id.Type == "ip" {
+		// dns-01 cannot validate ip addresses (RFC 8738 7)
+		return []string{"http-01", "tls-alpn-01"}
+	}
+	if wildcard {
+		return []string{"dns-01"}
+	}
+
+	return []string{"http-01", "dns-01", "tls-alpn-01"}
+}
+
+// newOrderRequest is the payload of a new-order request
+type newOrderRequest struct {
+	Identifiers []identifier `json:"identifiers"`
+	NotBefore   string       `json:"notBefore"`
+	NotAfter    string       `json:"notAfter"`
+	Profile     string       `json:"profile"`
+	Replaces    string       `json:"replaces"`
+}
+
+// parseRequestedValidity parses the optional notBefore and notAfter of a new order
+// request. Either may be omitted, in which case the zero time is returned for it.
+func parseRequestedValidity(notBeforeValue string, notAfterValue string) (notBefore time.Time, notAfter time.Time, prob *problem) {
+	var err error
+	if notBeforeValue != "" {
+		notBefore, err = time.Parse(time.RFC3339, notBeforeValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, errMalformed("notBefore is not a valid timestamp")
+		}
+	}
+	if notAfterValue != "" {
+		notAfter, err = time.Parse(time.RFC3339, notAfterValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, errMalformed("notAfter is not a valid timestamp")
+		}
+		if notAfter.Before(time.Now()) {
+			return time.Time{}, time.Time{}, errMalformed("notAfter is in the past")
+		}
+		if !notBefore.IsZero() && !notAfter.After(notBefore) {
+			return time.Time{}, time.Time{}, errMalformed("notAfter must be after notBefore")
+		}
+	}
+
+	return notBefore, notAfter, nil
+}
+
+// newOrder creates an order and its authorizations (RFC 8555 7.4)
+func (server *Server) newOrder(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	var payload newOrderRequest
+	err := json.Unmarshal(request.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse new order request")
+	}
+
+	notBefore, notAfter, prob := parseRequestedValidity(payload.NotBefore, payload.NotAfter)
+	if prob != nil {
+		return prob
+	}
+	if len(payload.Identifiers) == 0 {
+		return errMalformed("order must contain at least one identifier")
+	}
+	if payload.Profile != "" {
+		if _, exists := server.config.Profiles[payload.Profile]; !exists {
+			return newProblem(http.StatusBadRequest, errTypeInvalidProfile, "profile "+payload.Profile+" does not exist")
+		}
+	}
+
+	identifiers := []identifier{}
+	for _, id := range payload.Identifiers {
+		normalized, prob := normalizeIdentifier(id)
+		if prob != nil {
+			return prob
+		}
+		if !slices.Contains(identifiers, normalized) {
+			identifiers = append(identifiers, normalized)
+		}
+	}
+
+	o := &order{
+		id:          randomId(),
+		accountId:   request.account.id,
+		status:      statusPending,
+		expires:     time.Now().Add(objectLifetime),
+		identifiers: identifiers,
+		notBefore:   notBefore,
+		notAfter:    notAfter,
+		profile:     payload.Profile,
+		replaces:    payload.Replaces,
+	}
+
+	server.mu.Lock()
+
+	// ARI replacement (draft-ietf-acme-ari 5)
+	if payload.Replaces != "" {
+		cert := server.certificateByAriId(payload.Replaces)
+		if cert == nil || cert.accountId != request.account.id {
+			server.mu.Unlock()
+			return errMalformed("replaced certificate does not exist")
+		}
+		if cert.replacedBy != "" {
+			server.mu.Unlock()
+			return newProblem(http.StatusConflict, errTypeAlreadyReplaced, "certificate has already been replaced")
+		}
+		cert.replacedBy = o.id
+	}
+
+	for _, id := range identifiers {
+		authz := &authorization{
+			id:         randomId(),
+			accountId:  request.account.id,
+			identifier: id,
+			status:     statusPending,
+			expires:    o.expires,
+		}
+		if value, found := strings.CutPrefix(id.Value, "*."); found {
+			authz.identifier.Value = value
+			authz.wildcard = true
+		}
+
+		for _, challType := range challengeTypesFor(authz.identifier, authz.wildcard) {
+			chall := &challenge{
+				id:        randomId(),
+				authzId:   authz.id,
+				challType: challType,
+				token:     randomId(),
+				status:    statusPending,
+			}
+			server.challenges[chall.id] = chall
+			authz.challengeIds = append(authz.challengeIds, chall.id)
+		}
+
+		server.authzs[authz.id] = authz
+		o.authzIds = append(o.authzIds, authz.id)
+	}
+
+	server.orders[o.id] = o
+	request.account.orderIds = append(request.account.orderIds, o.id)
+	server.mu.Unlock()
+
+	return server.writeOrder(w, http.StatusCreated, o)
+}
+
+// accountOrder returns the order in the url, if it belongs to the account
+func (server *Server) accountOrder(r *http.Request, acct *account) (*order, *problem) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	o, exists := server.orders[httprouter.ParamsFromContext(r.Context()).ByName("id")]
+	if !exists || o.accountId != acct.id {
+		return nil, errNotFound("order does not exist")
+	}
+
+	return o, nil
+}
+
+// postOrder returns the order (POST-as-GET)
+func (server *Server) postOrder(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	o, prob := server.accountOrder(r, request.account)
+	if prob != nil {
+		return prob
+	}
+
+	return server.writeOrder(w, http.StatusOK, o)
+}
+
+// finalizeRequest is the payload of a finalize request
+type finalizeRequest struct {
+	Csr string `json:"csr"`
+}
+
+// verifyCsr verifies the csr is acceptable for the order's identifiers
+func verifyCsr(csr *x509.CertificateRequest, o *order, acct *account) *problem {
+	err := csr.CheckSignature()
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr signature is invalid")
+	}
+
+	if len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr may only contain dns and ip names")
+	}
+
+	if rsaPub, ok := csr.PublicKey.(*rsa.PublicKey); ok && rsaPub.N.BitLen() < 2048 {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr rsa key is too small")
+	}
+	if samePublicKey(csr.PublicKey, acct.key) {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr key must not be the account key")
+	}
+
+	// names must be exactly the order's identifiers
+	names := []identifier{}
+	if csr.Subject.CommonName != "" {
+		cn, prob := normalizeIdentifier(identifier{Type: "ip", Value: csr.Subject.CommonName})
+		if prob != nil {
+			cn, prob = normalizeIdentifier(identifier{Type: "dns", Value: csr.Subject.CommonName})
+		}
+		if prob != nil {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr common name is not a valid name")
+		}
+		names = append(names, cn)
+	}
+	for _, name := range csr.DNSNames {
+		names = append(names, identifier{Type: "dns", Value: strings.ToLower(name)})
+	}
+	for _, ip := range csr.IPAddresses {
+		addr, _ := netip.AddrFromSlice(ip)
+		names = append(names, identifier{Type: "ip", Value: addr.Unmap().String()})
+	}
+
+	for _, name := range names {
+		if !slices.Contains(o.identifiers, name) {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr name "+name.Value+" is not in the order")
+		}
+	}
+	for _, id := range o.identifiers {
+		if !slices.Contains(names, id) {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr is missing order identifier "+id.Value)
+		}
+	}
+
+	return nil
+}
+
+// finalizeOrder issues the order's certificate (RFC 8555 7.4)
+func (server *Server) finalizeOrder(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	o, prob := server.accountOrder(r, request.account)
+	if prob != nil {
+		return prob
+	}
+
+	var payload finalizeRequest
+	err := json.Unmarshal(request.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse finalize request")
+	}
+	csrDer, err := base64.RawURLEncoding.DecodeString(payload.Csr)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr is not base64url encoded")
+	}
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeBadCSR, "failed to parse csr")
+	}
+
+	// claim the order for processing
+	server.mu.Lock()
+	server.updateOrderStatus(o)
+	if o.status != statusReady {
+		status := o.status
+		server.mu.Unlock()
+		return newProblem(http.StatusForbidden, errTypeOrderNotReady, "order is "+status)
+	}
+	prob = verifyCsr(csr, o, request.account)
+	if prob != nil {
+		server.mu.Unlock()
+		return prob
+	}
+	o.status = statusProcessing
+	server.mu.Unlock()
+
+	// honor the requested validity, if any
+	notBefore := time.Now().Truncate(time.Second)
+	notAfter := notBefore.Add(server.config.CertificateValidity)
+	if !o.notBefore.IsZero() {
+		notBefore = o.notBefore
+		notAfter = notBefore.Add(server.config.CertificateValidity)
+	}
+	if !o.notAfter.IsZero() {
+		notAfter = o.notAfter
+	}
+
+	leaf, chains, err := server.ca.issue(csr, notBefore, notAfter)
+
+	server.mu.Lock()
+	if err != nil {
+		o.status = statusInvalid
+		o.err = errServerInternal("failed to issue certificate: " + err.Error())
+	} else {
+		cert := &certificate{
+			id:        randomId(),
+			accountId: request.account.id,
+			leaf:      leaf,
+			chains:    chains,
+		}
+		server.certificates[cert.id] = cert
+
+		o.status = statusValid
+		o.certId = cert.id
+	}
+	server.mu.Unlock()
+
+	return server.writeOrder(w, http.StatusOK, o)
+}
+
+// postCertificate returns a chain of the certificate and links to its alternate
+// chains (RFC 8555 7.4.2)
+func (server *Server) postCertificate(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKid)
+	if prob != nil {
+		return prob
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+
+	server.mu.Lock()
+	cert, exists := server.certificates[params.ByName("id")]
+	server.mu.Unlock()
+	if !exists || cert.accountId != request.account.id {
+		return errNotFound("certificate does not exist")
+	}
+
+	chainIndex := 0
+	if params.ByName("chain") != "" {
+		var err error
+		chainIndex, err = strconv.Atoi(params.ByName("chain"))
+		if err != nil || chainIndex < 1 || chainIndex >= len(cert.chains) {
+			return errNotFound("certificate chain does not exist")
+		}
+	}
+
+	for i := range cert.chains {
+		if i == chainIndex {
+			continue
+		}
+
+		chainUrl := server.url("/cert/" + cert.id)
+		if i > 0 {
+			chainUrl += "/" + strconv.Itoa(i)
+		}
+		w.Header().Add("Link", `<`+chainUrl+`>;rel="alternate"`)
+	}
+
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(cert.chains[chainIndex]))
+
+	return nil
+}
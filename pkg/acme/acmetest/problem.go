@@ -0,0 +1,70 @@
+package acmetest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ACME error types (RFC 8555 6.7 & extensions)
+const (
+	errTypeAccountDoesNotExist     = "accountDoesNotExist"
+	errTypeAlreadyReplaced         = "alreadyReplaced"
+	errTypeAlreadyRevoked          = "alreadyRevoked"
+	errTypeBadCSR                  = "badCSR"
+	errTypeBadNonce                = "badNonce"
+	errTypeBadPublicKey            = "badPublicKey"
+	errTypeBadRevocationReason     = "badRevocationReason"
+	errTypeBadSignatureAlgorithm   = "badSignatureAlgorithm"
+	errTypeConflict                = "conflict"
+	errTypeConnection              = "connection"
+	errTypeDns                     = "dns"
+	errTypeExternalAccountRequired = "externalAccountRequired"
+	errTypeIncorrectResponse       = "incorrectResponse"
+	errTypeInvalidContact          = "invalidContact"
+	errTypeInvalidProfile          = "invalidProfile"
+	errTypeMalformed               = "malformed"
+	errTypeOrderNotReady           = "orderNotReady"
+	errTypeRejectedIdentifier      = "rejectedIdentifier"
+	errTypeServerInternal          = "serverInternal"
+	errTypeTls                     = "tls"
+	errTypeUnauthorized            = "unauthorized"
+	errTypeUnsupportedIdentifier   = "unsupportedIdentifier"
+	errTypeUserActionRequired      = "userActionRequired"
+)
+
+// problem is an ACME problem document (RFC 7807)
+type problem struct {
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+	Status int    `json:"status"`
+}
+
+// Error implements the error interface
+func (p *problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Type, p.Detail)
+}
+
+// newProblem returns a problem of the specified ACME error type
+func newProblem(status int, errType string, detail string) *problem {
+	return &problem{
+		Type:   "urn:ietf:params:acme:error:" + errType,
+		Detail: detail,
+		Status: status,
+	}
+}
+
+func errMalformed(detail string) *problem {
+	return newProblem(http.StatusBadRequest, errTypeMalformed, detail)
+}
+
+func errUnauthorized(detail string) *problem {
+	return newProblem(http.StatusForbidden, errTypeUnauthorized, detail)
+}
+
+func errNotFound(detail string) *problem {
+	return newProblem(http.StatusNotFound, errTypeMalformed, detail)
+}
+
+func errServerInternal(detail string) *problem {
+	return newProblem(http.StatusInternalServerError, errTypeServerInternal, detail)
+}
@@ -0,0 +1,99 @@
+package acmetest
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+// revokeCertRequest is the payload of a revoke-cert request
+type revokeCertRequest struct {
+	Certificate string `json:"certificate"`
+	Reason      *int   `json:"reason"`
+}
+
+// certificateByDer returns the issued certificate with the der encoding, or nil if it
+// was not issued by the server. The caller must hold server.mu.
+func (server *Server) certificateByDer(der []byte) *certificate {
+	for _, cert := range server.certificates {
+		if bytes.Equal(cert.leaf.Raw, der) {
+			return cert
+		}
+	}
+
+	return nil
+}
+
+// revokeCertificate revokes a certificate (RFC 8555 7.6). The request must be signed
+// by the account that issued the certificate or by the certificate's key.
+func (server *Server) revokeCertificate(w http.ResponseWriter, r *http.Request) *problem {
+	request, prob := server.verifyRequest(r, signedByKidOrJwk)
+	if prob != nil {
+		return prob
+	}
+
+	var payload revokeCertRequest
+	err := json.Unmarshal(request.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse revoke cert request")
+	}
+
+	// reason (RFC 5280 5.3.1; 7 is unused)
+	reason := 0
+	if payload.Reason != nil {
+		reason = *payload.Reason
+	}
+	if reason < 0 || reason > 10 || reason == 7 {
+		return newProblem(http.StatusBadRequest, errTypeBadRevocationReason, "revocation reason is invalid")
+	}
+
+	der, err := base64.RawURLEncoding.DecodeString(payload.Certificate)
+	if err != nil {
+		return errMalformed("certificate is not base64url encoded")
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	cert := server.certificateByDer(der)
+	if cert == nil {
+		return errNotFound("certificate was not issued by this server")
+	}
+
+	if request.account != nil {
+		if cert.accountId != request.account.id {
+			return errUnauthorized("account did not issue the certificate")
+		}
+	} else {
+		pub, _ := request.jwk.publicKey()
+		if !samePublicKey(pub, cert.leaf.PublicKey) {
+			return errUnauthorized("jwk is not the certificate's key")
+		}
+	}
+
+	if cert.revoked {
+		return newProblem(http.StatusBadRequest, errTypeAlreadyRevoked, "certificate is already revoked")
+	}
+
+	cert.revoked = true
+	cert.revocationReason = reason
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// RevocationStatus returns whether the certificate issued by the server has been
+// revoked and, if it has, the revocation reason
+func (server *Server) RevocationStatus(cert *x509.Certificate) (revoked bool, reason int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	issued := server.certificateByDer(cert.Raw)
+	if issued == nil || !issued.revoked {
+		return false, 0
+	}
+
+	return true, issued.revocationReason
+}
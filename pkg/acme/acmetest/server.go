@@ -0,0 +1,234 @@
+// Package acmetest provides an in-process ACME server (RFC 8555) for tests. It is
+// loosely modeled on Pebble: requests are fully verified and the server follows the
+// protocol closely, but the CA is ephemeral and challenge validation never leaves
+// the local machine, so no network access is required.
+package acmetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// defaultCertificateValidity is the lifetime of issued certificates if the Config
+// does not specify one
+const defaultCertificateValidity = 90 * 24 * time.Hour
+
+// Config configures the test server. The zero value is a usable server that does
+// not require external account binding and validates challenges for real.
+type Config struct {
+	// ExternalAccountKeys maps external account binding key ids to base64url
+	// encoded hmac keys
+	ExternalAccountKeys map[string]string
+	// RequireEAB makes external account binding mandatory for new accounts
+	RequireEAB bool
+
+	// TermsOfService is advertised in the directory. If set, new accounts must
+	// agree to it.
+	TermsOfService string
+
+	// Profiles are the ACME profiles (name -> description) advertised in the
+	// directory
+	Profiles map[string]string
+
+	// AlwaysValid skips challenge validation; any challenge a client responds to
+	// becomes valid
+	AlwaysValid bool
+
+	// HTTP01Port and TLSALPN01Port are the ports on 127.0.0.1 that http-01 and
+	// tls-alpn-01 challenges are validated against (regardless of the identifier)
+	HTTP01Port    int
+	TLSALPN01Port int
+	// DNS01Lookup returns the TXT records of fqdn. If nil, dns-01 challenges fail.
+	DNS01Lookup func(fqdn string) ([]string, error)
+
+	// AlternateChains is the number of chains offered for each certificate in
+	// addition to the default chain. Each chain terminates at a different root.
+	AlternateChains int
+
+	// CertificateValidity is the lifetime of issued certificates (default 90 days)
+	// when the order does not request notBefore and notAfter
+	CertificateValidity time.Duration
+}
+
+// Server is a running in-process ACME server
+type Server struct {
+	config     Config
+	httpServer *httptest.Server
+	ca         *certificateAuthority
+
+	// validations tracks in flight challenge validations
+	validations sync.WaitGroup
+
+	mu           sync.Mutex
+	nonces       map[string]struct{}
+	accounts     map[string]*account
+	orders       map[string]*order
+	authzs       map[string]*authorization
+	challenges   map[string]*challenge
+	certificates map[string]*certificate
+}
+
+// NewServer starts a test server which is closed when the test completes
+func NewServer(t testing.TB, config Config) *Server {
+	t.Helper()
+
+	if config.CertificateValidity <= 0 {
+		config.CertificateValidity = defaultCertificateValidity
+	}
+
+	ca, err := newCertificateAuthority(config.AlternateChains + 1)
+	if err != nil {
+		t.Fatalf("acmetest: failed to make certificate authority (%s)", err)
+	}
+
+	server := &Server{
+		config: config,
+		ca:     ca,
+
+		nonces:       make(map[string]struct{}),
+		accounts:     make(map[string]*account),
+		orders:       make(map[string]*order),
+		authzs:       make(map[string]*authorization),
+		challenges:   make(map[string]*challenge),
+		certificates: make(map[string]*certificate),
+	}
+
+	server.httpServer = httptest.NewTLSServer(server.routes())
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// Close shuts down the server after any in flight challenge validations finish
+func (server *Server) Close() {
+	server.validations.Wait()
+	server.httpServer.Close()
+}
+
+// DirectoryURL returns the url of the server's ACME directory
+func (server *Server) DirectoryURL() string {
+	return server.url("/directory")
+}
+
+// Client returns an http client that trusts the server's tls certificate
+func (server *Server) Client() *http.Client {
+	return server.httpServer.Client()
+}
+
+// url returns the absolute url of path on the server
+func (server *Server) url(path string) string {
+	return server.httpServer.URL + path
+}
+
+// routes returns the server's router
+func (server *Server) routes() http.Handler {
+	router := httprouter.New()
+
+	router.HandlerFunc(http.MethodGet, "/directory", server.getDirectory)
+	router.HandlerFunc(http.MethodHead, "/new-nonce", server.newNonce)
+	router.HandlerFunc(http.MethodGet, "/new-nonce", server.newNonce)
+
+	router.HandlerFunc(http.MethodPost, "/new-account", server.serve(server.newAccount))
+	router.HandlerFunc(http.MethodPost, "/account/:id", server.serve(server.postAccount))
+	router.HandlerFunc(http.MethodPost, "/account/:id/orders", server.serve(server.postAccountOrders))
+	router.HandlerFunc(http.MethodPost, "/key-change", server.serve(server.keyChange))
+
+	router.HandlerFunc(http.MethodPost, "/new-order", server.serve(server.newOrder))
+	router.HandlerFunc(http.MethodPost, "/order/:id", server.serve(server.postOrder))
+	router.HandlerFunc(http.MethodPost, "/order/:id/finalize", server.serve(server.finalizeOrder))
+	router.HandlerFunc(http.MethodPost, "/authz/:id", server.serve(server.postAuthorization))
+	router.HandlerFunc(http.MethodPost, "/chall/:id", server.serve(server.postChallenge))
+
+	router.HandlerFunc(http.MethodPost, "/cert/:id", server.serve(server.postCertificate))
+	router.HandlerFunc(http.MethodPost, "/cert/:id/:chain", server.serve(server.postCertificate))
+	router.HandlerFunc(http.MethodPost, "/revoke-cert", server.serve(server.revokeCertificate))
+
+	router.HandlerFunc(http.MethodGet, "/renewal-info/:id", server.serve(server.getRenewalInfo))
+
+	return router
+}
+
+// directoryResponse is the ACME directory object (RFC 8555 7.1.1)
+type directoryResponse struct {
+	NewNonce    string `json:"newNonce"`
+	NewAccount  string `json:"newAccount"`
+	NewOrder    string `json:"newOrder"`
+	RevokeCert  string `json:"revokeCert"`
+	KeyChange   string `json:"keyChange"`
+	RenewalInfo string `json:"renewalInfo"`
+	Meta        struct {
+		TermsOfService          string            `json:"termsOfService,omitempty"`
+		ExternalAccountRequired bool              `json:"externalAccountRequired"`
+		Profiles                map[string]string `json:"profiles,omitempty"`
+	} `json:"meta"`
+}
+
+// getDirectory writes the directory
+func (server *Server) getDirectory(w http.ResponseWriter, r *http.Request) {
+	response := directoryResponse{
+		NewNonce:    server.url("/new-nonce"),
+		NewAccount:  server.url("/new-account"),
+		NewOrder:    server.url("/new-order"),
+		RevokeCert:  server.url("/revoke-cert"),
+		KeyChange:   server.url("/key-change"),
+		RenewalInfo: server.url("/renewal-info"),
+	}
+	response.Meta.TermsOfService = server.config.TermsOfService
+	response.Meta.ExternalAccountRequired = server.config.RequireEAB
+	response.Meta.Profiles = server.config.Profiles
+
+	_ = writeJSON(w, http.StatusOK, response)
+}
+
+// newNonce writes a fresh nonce (RFC 8555 7.2)
+func (server *Server) newNonce(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", server.makeNonce())
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Add("Link", `<`+server.DirectoryURL()+`>;rel="index"`)
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handlerFunc is the signature of the ACME handlers. A returned problem is written
+// as the response.
+type handlerFunc func(w http.ResponseWriter, r *http.Request) *problem
+
+// serve wraps an ACME handler. All responses get a fresh nonce and a link to the
+// directory.
+func (server *Server) serve(handler handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", server.makeNonce())
+		w.Header().Add("Link", `<`+server.DirectoryURL()+`>;rel="index"`)
+
+		prob := handler(w, r)
+		if prob != nil {
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(prob.Status)
+			_ = json.NewEncoder(w).Encode(prob)
+		}
+	}
+}
+
+// writeJSON writes response as json with the specified status code
+func writeJSON(w http.ResponseWriter, status int, response any) *problem {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return errServerInternal(err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package acmetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validationTimeout limits how long a single validation attempt may take
+const validationTimeout = 10 * time.Second
+
+// tlsAlpn01Protocol is the ALPN protocol used for tls-alpn-01 validation (RFC 8737 6.2)
+const tlsAlpn01Protocol = "acme-tls/1"
+
+// idPeAcmeIdentifier is the OID of the acmeIdentifier extension (RFC 8737 6.1)
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// validateChallenge validates the challenge and updates it and its authorization
+// with the result
+func (server *Server) validateChallenge(chall *challenge, authz *authorization, keyAuth string) {
+	var prob *problem
+	if !server.config.AlwaysValid {
+		switch chall.challType {
+		case "http-01":
+			prob = server.validateHttp01(authz.identifier, chall.token, keyAuth)
+		case "tls-alpn-01":
+			prob = server.validateTlsAlpn01(authz.identifier, keyAuth)
+		case "dns-01":
+			prob = server.validateDns01(authz.identifier, keyAuth)
+		default:
+			prob = errServerInternal("unknown challenge type " + chall.challType)
+		}
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if prob != nil {
+		chall.status = statusInvalid
+		chall.err = prob
+		authz.status = statusInvalid
+		return
+	}
+
+	chall.status = statusValid
+	chall.validated = time.Now()
+	authz.status = statusValid
+}
+
+// validateHttp01 validates an http-01 challenge (RFC 8555 8.3) against the configured
+// port on the loopback address
+func (server *Server) validateHttp01(id identifier, token string, keyAuth string) *problem {
+	if server.config.HTTP01Port == 0 {
+		return newProblem(http.StatusBadRequest, errTypeConnection, "http-01 validation is not configured")
+	}
+
+	client := &http.Client{
+		Timeout: validationTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(server.config.HTTP01Port))
+	req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/.well-known/acme-challenge/"+token, nil)
+	if err != nil {
+		return errServerInternal(err.Error())
+	}
+
+	// the host is the identifier, even though the connection is always to loopback
+	req.Host = id.Value
+	if id.Type == "ip" && strings.Contains(id.Value, ":") {
+		req.Host = "[" + id.Value + "]"
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeConnection, fmt.Sprintf("http-01 request failed (%s)", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newProblem(http.StatusForbidden, errTypeUnauthorized, fmt.Sprintf("http-01 response status was %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeConnection, fmt.Sprintf("http-01 response failed to read (%s)", err))
+	}
+	if strings.TrimSpace(string(body)) != keyAuth {
+		return newProblem(http.StatusForbidden, errTypeIncorrectResponse, "http-01 response did not match the key authorization")
+	}
+
+	return nil
+}
+
+// reverseDnsName returns the reverse mapping domain of the ip address, which is the
+// tls-alpn-01 SNI value for ip identifiers (RFC 8738 6)
+func reverseDnsName(addr netip.Addr) string {
+	if addr.Is4() {
+		octets := addr.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", octets[3], octets[2], octets[1], octets[0])
+	}
+
+	const hexDigits = "0123456789abcdef"
+	b := addr.As16()
+	labels := []string{}
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, string(hexDigits[b[i]&0x0f]), string(hexDigits[b[i]>>4]))
+	}
+
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
+
+// validateTlsAlpn01 validates a tls-alpn-01 challenge (RFC 8737 3) against the
+// configured port on the loopback address
+func (server *Server) validateTlsAlpn01(id identifier, keyAuth string) *problem {
+	if server.config.TLSALPN01Port == 0 {
+		return newProblem(http.StatusBadRequest, errTypeConnection, "tls-alpn-01 validation is not configured")
+	}
+
+	serverName := id.Value
+	if id.Type == "ip" {
+		addr, err := netip.ParseAddr(id.Value)
+		if err != nil {
+			return errServerInternal(err.Error())
+		}
+		serverName = reverseDnsName(addr)
+	}
+
+	dialer := &net.Dialer{Timeout: validationTimeout}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(server.config.TLSALPN01Port))
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName:         serverName,
+		NextProtos:         []string{tlsAlpn01Protocol},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeTls, fmt.Sprintf("tls-alpn-01 handshake failed (%s)", err))
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	if state.NegotiatedProtocol != tlsAlpn01Protocol {
+		return newProblem(http.StatusForbidden, errTypeUnauthorized, "tls-alpn-01 server did not negotiate "+tlsAlpn01Protocol)
+	}
+	if len(state.PeerCertificates) != 1 {
+		return newProblem(http.StatusForbidden, errTypeUnauthorized, "tls-alpn-01 server must present exactly one certificate")
+	}
+	cert := state.PeerCertificates[0]
+
+	// the identifier must be the certificate's only name
+	switch {
+	case id.Type == "dns" && slices.Equal(cert.DNSNames, []string{id.Value}) && len(cert.IPAddresses) == 0:
+	case id.Type == "ip" && len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 1 && cert.IPAddresses[0].String() == id.Value:
+	default:
+		return newProblem(http.StatusForbidden, errTypeUnauthorized, "tls-alpn-01 certificate name does not match the identifier")
+	}
+
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPeAcmeIdentifier) {
+			continue
+		}
+
+		var value []byte
+		rest, err := asn1.Unmarshal(ext.Value, &value)
+		if err != nil || len(rest) > 0 || !ext.Critical {
+			return newProblem(http.StatusForbidden, errTypeUnauthorized, "tls-alpn-01 acmeIdentifier extension is malformed")
+		}
+		if !bytes.Equal(value, keyAuthDigest[:]) {
+			return newProblem(http.StatusForbidden, errTypeIncorrectResponse, "tls-alpn-01 acmeIdentifier extension did not match the key authorization")
+		}
+
+		return nil
+	}
+
+	return newProblem(http.StatusForbidden, errTypeUnauthorized, "tls-alpn-01 certificate is missing the acmeIdentifier extension")
+}
+
+// validateDns01 validates a dns-01 challenge (RFC 8555 8.4) using the configured lookup
+func (server *Server) validateDns01(id identifier, keyAuth string) *problem {
+	if server.config.DNS01Lookup == nil {
+		return newProblem(http.StatusBadRequest, errTypeDns, "dns-01 validation is not configured")
+	}
+
+	records, err := server.config.DNS01Lookup("_acme-challenge." + id.Value)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errTypeDns, fmt.Sprintf("dns-01 lookup failed (%s)", err))
+	}
+
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	expected := base64.RawURLEncoding.EncodeToString(keyAuthDigest[:])
+	if !slices.Contains(records, expected) {
+		return newProblem(http.StatusForbidden, errTypeIncorrectResponse, "dns-01 txt record not found")
+	}
+
+	return nil
+}
@@ -10,8 +10,9 @@ type ChallengeType string
 const (
 	UnknownChallengeType ChallengeType = ""
 
-	ChallengeTypeHttp01 ChallengeType = "http-01"
-	ChallengeTypeDns01  ChallengeType = "dns-01"
+	ChallengeTypeHttp01    ChallengeType = "http-01"
+	ChallengeTypeDns01     ChallengeType = "dns-01"
+	ChallengeTypeTlsAlpn01 ChallengeType = "tls-alpn-01" // RFC 8737
 )
 
 // ACME challenge object
@@ -1,7 +1,17 @@
 package acme
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"time"
 )
 
 // ValidationResourceDns01 returns the dnsRecord name and value to provision
@@ -19,3 +29,71 @@ func ValidationResourceDns01(domain string, keyAuth KeyAuth) (dnsRecordName stri
 
 	return dnsRecordName, dnsRecordValue
 }
+
+// TlsAlpn01Protocol is the ALPN protocol name used for tls-alpn-01 validation
+// (RFC 8737 s 6.2)
+const TlsAlpn01Protocol = "acme-tls/1"
+
+// idPeAcmeIdentifier is the OID of the acmeIdentifier x509 extension (RFC 8737 s 6.1)
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// ValidationResourceTlsAlpn01 returns the self-signed validation certificate to
+// serve in response to a TlsAlpn01 challenge for a given domain and keyAuth.
+// The certificate contains the domain (or ip) as its only SAN and the critical
+// acmeIdentifier extension containing the sha256 of the key authorization.
+func ValidationResourceTlsAlpn01(domain string, keyAuth KeyAuth) (*tls.Certificate, error) {
+	// extension value is the DER encoded OCTET STRING of the sha256 of key authorization
+	keyAuthDigest := sha256.Sum256([]byte(keyAuth))
+	extValue, err := asn1.Marshal(keyAuthDigest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// throwaway key for the validation cert
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: "Cert Warden tls-alpn-01 Validation Certificate",
+		},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{
+				Id:       idPeAcmeIdentifier,
+				Critical: true,
+				Value:    extValue,
+			},
+		},
+	}
+
+	// SAN is an ip address for ip identifiers (RFC 8738 s 6)
+	ip := net.ParseIP(domain)
+	if ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{domain}
+	}
+
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{certDer},
+		PrivateKey:  key,
+	}, nil
+}
@@ -98,6 +98,9 @@ func (service *Service) updateAcmeServiceDirectory() error {
 		return err
 	}
 
+	service.dirMu.Lock()
+	defer service.dirMu.Unlock()
+
 	// Only update if the fetched directory content is different than current
 	if reflect.DeepEqual(fetchedDir, *service.dir) {
 		// directory already up to date
@@ -182,32 +185,41 @@ func (service *Service) backgroundDirManager(shutdownCtx context.Context, wg *sy
 	}()
 }
 
+// directory returns a copy of the Service's current directory; it is safe to call
+// while the directory is being refreshed
+func (service *Service) directory() directory {
+	service.dirMu.RLock()
+	defer service.dirMu.RUnlock()
+
+	return *service.dir
+}
+
 // TosUrl returns the string for the url where the ToS are located
 func (service *Service) TosUrl() string {
-	return service.dir.Meta.TermsOfService
+	return service.directory().Meta.TermsOfService
 }
 
 // RequiresEAB returns if the acme server requires External Account Binding
 func (service *Service) RequiresEAB() bool {
-	return service.dir.Meta.ExternalAccountRequired
+	return service.directory().Meta.ExternalAccountRequired
 }
 
 // DirectoryRawResponse returns the ACME Service's raw directory response
 func (service *Service) DirectoryRawResponse() json.RawMessage {
-	return service.dir.raw
+	return service.directory().raw
 }
 
 // Profiles returns the map of available profiles for the ACME service, or nil if
 // the profiles extension is not implemented (per the directory's meta response)
 func (service *Service) Profiles() map[string]string {
-	return service.dir.Meta.Profiles
+	return service.directory().Meta.Profiles
 }
 
 // ProfileValidate returns true if the specified profileName exists in the ACME
 // service's meta profile map. If the profile does not exist (including if there
 // is no profile map at all), false is returned.
 func (service *Service) ProfileValidate(profileName string) bool {
-	for k := range service.dir.Meta.Profiles {
+	for k := range service.directory().Meta.Profiles {
 		if k == profileName {
 			return true
 		}
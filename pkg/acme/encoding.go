@@ -53,6 +53,15 @@ func encodeBigInt(bigInt *big.Int, keyBitSize int) (encodedProp string) {
 // what RFC8555 says to use)
 type timeString string
 
+// timeStringLayout is the RFC3339 layout used for timeStrings
+const timeStringLayout = "2006-01-02T15:04:05Z"
+
+// newTimeString returns the timeString of the specified time
+func newTimeString(t time.Time) *timeString {
+	ts := timeString(t.UTC().Format(timeStringLayout))
+	return &ts
+}
+
 // ToUnixTime returns the unix time for a timeString. If the timeString is
 // nil or fails to parse, 0 is returned.
 func (ats *timeString) ToUnixTime() (unixTime *int) {
@@ -60,11 +69,8 @@ func (ats *timeString) ToUnixTime() (unixTime *int) {
 		return nil
 	}
 
-	// RFC3339
-	layout := "2006-01-02T15:04:05Z"
-
 	// Parse
-	t, err := time.Parse(layout, string(*ats))
+	t, err := time.Parse(timeStringLayout, string(*ats))
 	if err != nil {
 		return new(int)
 	}
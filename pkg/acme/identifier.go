@@ -1,5 +1,7 @@
 package acme
 
+import "net/netip"
+
 // Identifier is the ACME Identifier object
 type Identifier struct {
 	Type  IdentifierType `json:"type"`
@@ -13,8 +15,21 @@ const (
 	UnknownIdentifierType IdentifierType = ""
 
 	IdentifierTypeDns = "dns"
+	IdentifierTypeIp  = "ip" // RFC 8738
 )
 
+// NewIdentifier returns an Identifier for the specified value. If the value is an
+// IP address, the ip type is used and the value is converted to its canonical
+// textual form (RFC 8738 s 3). Otherwise the dns type is used.
+func NewIdentifier(value string) Identifier {
+	ip, err := netip.ParseAddr(value)
+	if err == nil && ip.Zone() == "" {
+		return Identifier{Type: IdentifierTypeIp, Value: ip.Unmap().String()}
+	}
+
+	return Identifier{Type: IdentifierTypeDns, Value: value}
+}
+
 // IdentifierSlice is a slice of Identifier
 type IdentifierSlice []Identifier
 
@@ -30,3 +45,16 @@ func (ids *IdentifierSlice) DnsIdentifiers() []string {
 
 	return s
 }
+
+// IpIdentifiers returns a slice of the ip value strings for a slice of Identifiers
+func (ids *IdentifierSlice) IpIdentifiers() []string {
+	var s []string
+
+	for _, id := range *ids {
+		if id.Type == IdentifierTypeIp {
+			s = append(s, id.Value)
+		}
+	}
+
+	return s
+}
@@ -3,6 +3,7 @@ package acme
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/sha256"
 	"errors"
@@ -14,8 +15,8 @@ type jsonWebKey struct {
 	KeyType        string `json:"kty,omitempty"`
 	PublicExponent string `json:"e,omitempty"`   // RSA
 	Modulus        string `json:"n,omitempty"`   // RSA
-	CurveName      string `json:"crv,omitempty"` // EC
-	CurvePointX    string `json:"x,omitempty"`   // EC
+	CurveName      string `json:"crv,omitempty"` // EC, OKP
+	CurvePointX    string `json:"x,omitempty"`   // EC, OKP
 	CurvePointY    string `json:"y,omitempty"`   // EC
 }
 
@@ -47,6 +48,15 @@ func (accountKey *AccountKey) jwk() (jwk *jsonWebKey, err error) {
 
 		return jwk, nil
 
+	case ed25519.PrivateKey:
+		// RFC 8037 s2
+		jwk.KeyType = "OKP"
+
+		jwk.CurveName = "Ed25519"
+		jwk.CurvePointX = encodeString(privateKey.Public().(ed25519.PublicKey))
+
+		return jwk, nil
+
 	default:
 		// break to final error return
 	}
@@ -78,6 +88,12 @@ func (jwk *jsonWebKey) encodedSHA256PublicThumbprint() (thumbprint string, err e
 		_, _ = buf.WriteString(`","y":"`)
 		_, _ = buf.WriteString(jwk.CurvePointY)
 		_, _ = buf.WriteString(`"}`)
+	case "OKP":
+		_, _ = buf.WriteString(`{"crv":"`)
+		_, _ = buf.WriteString(jwk.CurveName)
+		_, _ = buf.WriteString(`","kty":"OKP","x":"`)
+		_, _ = buf.WriteString(jwk.CurvePointX)
+		_, _ = buf.WriteString(`"}`)
 	default:
 		return "", errors.New("acme: jwk thumbprint: unsupported private key type")
 	}
@@ -19,12 +19,12 @@ type Manager struct {
 	httpClient      *http.Client
 	shutdownContext context.Context
 
-	newNonceUrl *string
+	newNonceUrl func() string
 	nonces      *ringbuffer.RingBuffer[string]
 }
 
-// NewManager creates a new nonce manager
-func NewManager(client *http.Client, shutdownCtx context.Context, nonceUrl *string) *Manager {
+// NewManager creates a new nonce manager; nonceUrl returns the current newNonce url
+func NewManager(client *http.Client, shutdownCtx context.Context, nonceUrl func() string) *Manager {
 	manager := new(Manager)
 
 	manager.httpClient = client
@@ -47,7 +47,7 @@ func (manager *Manager) fetchNonce() (string, error) {
 	nonce := ""
 
 	for range maxRetries {
-		response, err := manager.httpClient.Head(*manager.newNonceUrl)
+		response, err := manager.httpClient.Head(manager.newNonceUrl())
 		if err != nil {
 			return "", err
 		}
@@ -4,15 +4,19 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 )
 
 // NewOrderPayload is the payload to post to ACME newOrder
 type NewOrderPayload struct {
-	// notBefore and notAfter are optional and not implemented
 	Identifiers IdentifierSlice `json:"identifiers"`
 
+	// optional requested validity period of the certificate (see: SetValidity)
+	NotBefore *timeString `json:"notBefore,omitempty"`
+	NotAfter  *timeString `json:"notAfter,omitempty"`
+
 	// ACME Profiles Extension
 	Profile *string `json:"profile,omitempty"`
 
@@ -20,6 +24,13 @@ type NewOrderPayload struct {
 	Replaces *string `json:"replaces,omitempty"`
 }
 
+// SetValidity sets the requested notBefore and notAfter of the certificate. ACME
+// servers are not required to honor the request (RFC8555 7.4).
+func (payload *NewOrderPayload) SetValidity(notBefore time.Time, notAfter time.Time) {
+	payload.NotBefore = newTimeString(notBefore)
+	payload.NotAfter = newTimeString(notAfter)
+}
+
 // LE response with order information
 type Order struct {
 	Status         string          `json:"status"`
@@ -61,7 +72,7 @@ func (service *Service) NewOrder(payload NewOrderPayload, accountKey AccountKey)
 	}
 
 	// post new-order
-	jsonResp, headers, err := service.postToUrlSigned(payload, service.dir.NewOrder, accountKey)
+	jsonResp, headers, err := service.postToUrlSigned(payload, service.directory().NewOrder, accountKey)
 	// if there is an acme.Error of type `malformed` or `alreadyReplaced` AND the `replaces` field is
 	// set, strip the replaces filed and do exactly 1 retry
 	acmeErr, isAcmeErr := err.(*Error)
@@ -69,7 +80,7 @@ func (service *Service) NewOrder(payload NewOrderPayload, accountKey AccountKey)
 		(acmeErr.Type == "urn:ietf:params:acme:error:malformed" || acmeErr.Type == "urn:ietf:params:acme:error:alreadyReplaced") {
 
 		payload.Replaces = nil
-		jsonResp, headers, err = service.postToUrlSigned(payload, service.dir.NewOrder, accountKey)
+		jsonResp, headers, err = service.postToUrlSigned(payload, service.directory().NewOrder, accountKey)
 	}
 	if err != nil {
 		return Order{}, err
@@ -107,8 +118,8 @@ func (service *Service) FinalizeOrder(finalizeUrl string, derCsr []byte, account
 	if service.logger.Level() == zapcore.DebugLevel {
 		csr, prettyErr := x509.ParseCertificateRequest(derCsr)
 		if prettyErr == nil {
-			// log CN and DNS names
-			service.logger.Debugf("attempting finalize using csr with common name: %s ; and dns name(s): %s", csr.Subject.CommonName, csr.DNSNames)
+			// log CN, DNS names, and IP addresses
+			service.logger.Debugf("attempting finalize using csr with common name: %s ; dns name(s): %s ; and ip address(es): %s", csr.Subject.CommonName, csr.DNSNames, csr.IPAddresses)
 
 			// Log full CSR
 			// prettyBytes, prettyErr := json.MarshalIndent(csr, "", "\t")
@@ -84,7 +84,7 @@ func unmarshalACMERenewalInfo(jsonResp json.RawMessage, headers http.Header) (ar
 
 // SupportsARIExtension returns true if the ACME Service supports ARI (ACME Renewal Info) extension
 func (service *Service) SupportsARIExtension() bool {
-	return service.dir.RenewalInfo != nil
+	return service.directory().RenewalInfo != nil
 }
 
 // ACMERenewalInfoIdentifier returns the unique identifer for the passed in cert
@@ -122,7 +122,7 @@ func (service *Service) GetACMERenewalInfo(certPem string) (*ACMERenewalInfo, er
 	}
 
 	// create link and do GET
-	url := *service.dir.RenewalInfo + "/" + ACMERenewalInfoIdentifier(cert)
+	url := *service.directory().RenewalInfo + "/" + ACMERenewalInfoIdentifier(cert)
 	resp, headers, err := service.get(url)
 	if err != nil {
 		return nil, fmt.Errorf("acme: ari get failed (%v)", err)
@@ -43,6 +43,14 @@ func responseToCertificate(bodyBytes []byte, headers http.Header) (*Certificate,
 		return nil, errors.New("certificate content type is not application/pem-certificate-chain")
 	}
 
+	return NewCertificateFromPem(bodyBytes)
+}
+
+// NewCertificateFromPem validates and parses a pem certificate chain into a Certificate.
+// The chain must contain only CERTIFICATE blocks with the leaf first. This is used for
+// ACME download responses and also for chains that were issued by some other means
+// (e.g., a private CA).
+func NewCertificateFromPem(bodyBytes []byte) (*Certificate, error) {
 	// validate ACME server didn't return malicious pem (see: RFC8555 s 11.4)
 	pemCheck := string(bodyBytes)
 	beginString := "-----BEGIN"
@@ -23,6 +23,7 @@ type Service struct {
 	logger       *zap.SugaredLogger
 	httpClient   *http.Client
 	dirUri       string
+	dirMu        sync.RWMutex
 	dir          *directory
 	nonceManager *nonces.Manager
 }
@@ -48,7 +49,7 @@ func NewService(app App, dirUri string) (*Service, error) {
 	service.backgroundDirManager(app.GetShutdownContext(), app.GetShutdownWaitGroup())
 
 	// nonce manager
-	service.nonceManager = nonces.NewManager(service.httpClient, app.GetShutdownContext(), &service.dir.NewNonce)
+	service.nonceManager = nonces.NewManager(service.httpClient, app.GetShutdownContext(), func() string { return service.directory().NewNonce })
 
 	return service, nil
 }
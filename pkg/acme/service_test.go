@@ -0,0 +1,699 @@
+package acme
+
+import (
+	"certwarden-backend/pkg/acme/acmetest"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// testApp implements App for tests
+type testApp struct {
+	logger     *zap.SugaredLogger
+	httpClient *http.Client
+	ctx        context.Context
+	wg         *sync.WaitGroup
+}
+
+func (app *testApp) GetLogger() *zap.SugaredLogger         { return app.logger }
+func (app *testApp) GetHttpClient() *http.Client           { return app.httpClient }
+func (app *testApp) GetShutdownContext() context.Context   { return app.ctx }
+func (app *testApp) GetShutdownWaitGroup() *sync.WaitGroup { return app.wg }
+
+// newTestService returns a Service for the test server once its directory has loaded
+func newTestService(t *testing.T, server *acmetest.Server) *Service {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := &testApp{
+		logger:     zaptest.NewLogger(t).Sugar(),
+		httpClient: server.Client(),
+		ctx:        ctx,
+		wg:         new(sync.WaitGroup),
+	}
+	t.Cleanup(func() {
+		cancel()
+		app.wg.Wait()
+	})
+
+	service, err := NewService(app, server.DirectoryURL())
+	if err != nil {
+		t.Fatalf("failed to make acme service (%s)", err)
+	}
+
+	// directory loads in the background
+	for range 100 {
+		if service.DirectoryRawResponse() != nil {
+			return service
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatal("acme service directory did not load")
+	return nil
+}
+
+// newTestAccount registers a new account with a new key of the specified type
+func newTestAccount(t *testing.T, service *Service, keyType string) AccountKey {
+	t.Helper()
+
+	var key crypto.PrivateKey
+	var err error
+	switch keyType {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ec384":
+		key, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ec521":
+		key, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acct, err := service.NewAccount(NewAccountPayload{Contact: []string{"mailto:test@example.com"}, TosAgreed: true}, key)
+	if err != nil {
+		t.Fatalf("failed to create account (%s)", err)
+	}
+	if acct.Status != "valid" || acct.Location == nil {
+		t.Fatalf("new account status %s or location missing", acct.Status)
+	}
+
+	return AccountKey{Key: key, Kid: *acct.Location}
+}
+
+// makeTestCsr returns a der csr for the names, using a new key
+func makeTestCsr(t *testing.T, commonName string, dnsNames []string, ips []net.IP) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return csr
+}
+
+// waitForChallenge polls the challenge until it is no longer pending or processing
+func waitForChallenge(t *testing.T, service *Service, challUrl string, key AccountKey) Challenge {
+	t.Helper()
+
+	for range 100 {
+		chall, err := service.GetChallenge(challUrl, key)
+		if err != nil {
+			t.Fatalf("failed to get challenge (%s)", err)
+		}
+		if chall.Status != "pending" && chall.Status != "processing" {
+			return chall
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatal("challenge validation did not finish")
+	return Challenge{}
+}
+
+// issueTestCertificate orders and downloads a certificate for the identifiers. Any
+// optional fields of payload are included in the order. The server must be configured
+// with AlwaysValid.
+func issueTestCertificate(t *testing.T, service *Service, key AccountKey, payload NewOrderPayload, preferredChain string) (Order, *Certificate) {
+	t.Helper()
+
+	payload.Identifiers = IdentifierSlice{NewIdentifier("example.com"), NewIdentifier("www.example.com"), NewIdentifier("192.0.2.1")}
+	order, err := service.NewOrder(payload, key)
+	if err != nil {
+		t.Fatalf("failed to create order (%s)", err)
+	}
+	if order.Status != "pending" || len(order.Authorizations) != 3 || order.Location == "" {
+		t.Fatalf("new order is not pending with 3 authorizations (status: %s; authorizations: %d)", order.Status, len(order.Authorizations))
+	}
+
+	for _, authUrl := range order.Authorizations {
+		auth, err := service.GetAuth(authUrl, key)
+		if err != nil {
+			t.Fatalf("failed to get authorization (%s)", err)
+		}
+
+		i := slices.IndexFunc(auth.Challenges, func(c Challenge) bool { return c.Type == ChallengeTypeHttp01 })
+		if i == -1 {
+			t.Fatalf("authorization for %s has no http-01 challenge", auth.Identifier.Value)
+		}
+
+		_, err = service.InstructServerToValidateChallenge(auth.Challenges[i].Url, key)
+		if err != nil {
+			t.Fatalf("failed to respond to challenge (%s)", err)
+		}
+		chall := waitForChallenge(t, service, auth.Challenges[i].Url, key)
+		if chall.Status != "valid" {
+			t.Fatalf("challenge status %s (%s)", chall.Status, chall.Error)
+		}
+	}
+
+	order, err = service.GetOrder(order.Location, key)
+	if err != nil {
+		t.Fatalf("failed to get order (%s)", err)
+	}
+	if order.Status != "ready" {
+		t.Fatalf("order status %s, expected ready", order.Status)
+	}
+
+	csr := makeTestCsr(t, "example.com", []string{"example.com", "www.example.com"}, []net.IP{net.ParseIP("192.0.2.1")})
+	order, err = service.FinalizeOrder(order.Finalize, csr, key)
+	if err != nil {
+		t.Fatalf("failed to finalize order (%s)", err)
+	}
+	if order.Status != "valid" || order.Certificate == nil {
+		t.Fatalf("finalized order status %s or certificate missing", order.Status)
+	}
+
+	cert, err := service.DownloadCertificate(*order.Certificate, key, preferredChain)
+	if err != nil {
+		t.Fatalf("failed to download certificate (%s)", err)
+	}
+
+	return order, cert
+}
+
+// parseLeaf returns the leaf of the certificate's chain
+func parseLeaf(t *testing.T, cert *Certificate) *x509.Certificate {
+	t.Helper()
+
+	block, _ := pem.Decode([]byte(cert.PEM()))
+	if block == nil {
+		t.Fatal("certificate pem did not decode")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf
+}
+
+func TestService_Account(t *testing.T) {
+	server := acmetest.NewServer(t, acmetest.Config{TermsOfService: "https://example.com/tos"})
+	service := newTestService(t, server)
+
+	if service.TosUrl() != "https://example.com/tos" {
+		t.Errorf("tos url %s does not match the server", service.TosUrl())
+	}
+
+	for _, keyType := range []string{"rsa", "ec256", "ec384", "ec521", "ed25519"} {
+		key := newTestAccount(t, service, keyType)
+
+		// existing account is returned for the same key
+		acct, err := service.NewAccount(NewAccountPayload{TosAgreed: true}, key.Key)
+		if err != nil || acct.Location == nil || *acct.Location != key.Kid {
+			t.Errorf("%s: new account with existing key did not return the existing account (%s)", keyType, err)
+		}
+
+		// update
+		acct, err = service.UpdateAccount(UpdateAccountPayload{Contact: []string{"mailto:new@example.com"}}, key)
+		if err != nil || acct.Email() != "new@example.com" {
+			t.Errorf("%s: account update failed (email: %s) (%s)", keyType, acct.Email(), err)
+		}
+
+		// rollover
+		newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = service.RolloverAccountKey(newKey, key)
+		if err != nil {
+			t.Errorf("%s: key rollover failed (%s)", keyType, err)
+		}
+		_, err = service.GetAccount(key)
+		if err == nil {
+			t.Errorf("%s: old key still works after rollover", keyType)
+		}
+		key.Key = newKey
+		acct, err = service.GetAccount(key)
+		if err != nil || acct.Status != "valid" {
+			t.Errorf("%s: new key does not work after rollover (%s)", keyType, err)
+		}
+
+		// deactivate
+		acct, err = service.DeactivateAccount(key)
+		if err != nil || acct.Status != "deactivated" {
+			t.Errorf("%s: deactivation failed (status: %s) (%s)", keyType, acct.Status, err)
+		}
+		_, err = service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}}, key)
+		if err == nil {
+			t.Errorf("%s: deactivated account created an order", keyType)
+		}
+	}
+
+	// tos must be agreed
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.NewAccount(NewAccountPayload{TosAgreed: false}, key)
+	if err == nil {
+		t.Error("account was created without agreeing to the tos")
+	}
+}
+
+func TestService_AccountEAB(t *testing.T) {
+	hmacKey := base64.RawURLEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	wrongKey := base64.RawURLEncoding.EncodeToString([]byte("fedcba9876543210fedcba9876543210"))
+
+	server := acmetest.NewServer(t, acmetest.Config{
+		ExternalAccountKeys: map[string]string{"kid-1": hmacKey},
+		RequireEAB:          true,
+	})
+	service := newTestService(t, server)
+
+	if !service.RequiresEAB() {
+		t.Error("service does not report eab is required")
+	}
+
+	testCases := []struct {
+		kid     string
+		hmacKey string
+		valid   bool
+	}{
+		{"", "", false},
+		{"kid-1", wrongKey, false},
+		{"kid-2", hmacKey, false},
+		{"kid-1", hmacKey, true},
+	}
+
+	for _, tc := range testCases {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = service.NewAccount(NewAccountPayload{
+			ExternalAccountBindingKid:     tc.kid,
+			ExternalAccountBindingHmacKey: tc.hmacKey,
+		}, key)
+		if tc.valid && err != nil {
+			t.Errorf("eab kid '%s' failed (%s)", tc.kid, err)
+		} else if !tc.valid && err == nil {
+			t.Errorf("invalid eab kid '%s' succeeded", tc.kid)
+		}
+	}
+}
+
+func TestService_OrderAndDownload(t *testing.T) {
+	server := acmetest.NewServer(t, acmetest.Config{AlwaysValid: true, AlternateChains: 2})
+	service := newTestService(t, server)
+	key := newTestAccount(t, service, "ec256")
+
+	roots := server.RootCommonNames()
+	if len(roots) != 3 {
+		t.Fatalf("server has %d roots, expected 3", len(roots))
+	}
+
+	testCases := []struct {
+		preferredChain string
+		expectedRoot   string
+	}{
+		{"", roots[0]},
+		{roots[1], roots[1]},
+		{strings.ToUpper(roots[2]), roots[2]},
+		{"not a root", roots[0]},
+	}
+
+	for _, tc := range testCases {
+		_, cert := issueTestCertificate(t, service, key, NewOrderPayload{}, tc.preferredChain)
+
+		if cert.ChainRootCN() != tc.expectedRoot {
+			t.Errorf("preferred chain '%s' returned chain with root '%s', expected '%s'", tc.preferredChain, cert.ChainRootCN(), tc.expectedRoot)
+		}
+
+		leaf := parseLeaf(t, cert)
+		if !slices.Equal(leaf.DNSNames, []string{"example.com", "www.example.com"}) || len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "192.0.2.1" {
+			t.Errorf("certificate names %s %s do not match the order", leaf.DNSNames, leaf.IPAddresses)
+		}
+		if !cert.NotBefore().Equal(leaf.NotBefore) || !cert.NotAfter().Equal(leaf.NotAfter) {
+			t.Error("certificate validity does not match the leaf")
+		}
+
+		// chain must verify to the root it claims
+		intermediates := x509.NewCertPool()
+		rest := []byte(cert.PEM())
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			intermediates.AddCert(c)
+		}
+		chains, err := leaf.Verify(x509.VerifyOptions{Roots: server.Roots(), Intermediates: intermediates, DNSName: "www.example.com"})
+		if err != nil {
+			t.Errorf("certificate chain did not verify (%s)", err)
+		} else if chains[0][len(chains[0])-1].Subject.CommonName != tc.expectedRoot {
+			t.Errorf("certificate chain verified to root %s, expected %s", chains[0][len(chains[0])-1].Subject.CommonName, tc.expectedRoot)
+		}
+	}
+
+	// csr names must match the order
+	order, err := service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.org")}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.FinalizeOrder(order.Finalize, makeTestCsr(t, "example.org", []string{"example.org"}, nil), key)
+	if err == nil {
+		t.Error("order that is not ready was finalized")
+	}
+	auth, err := service.GetAuth(order.Authorizations[0], key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.InstructServerToValidateChallenge(auth.Challenges[0].Url, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForChallenge(t, service, auth.Challenges[0].Url, key)
+	_, err = service.FinalizeOrder(order.Finalize, makeTestCsr(t, "example.org", []string{"example.org", "www.example.org"}, nil), key)
+	if err == nil {
+		t.Error("order was finalized with a csr that has extra names")
+	}
+}
+
+func TestService_Profiles(t *testing.T) {
+	server := acmetest.NewServer(t, acmetest.Config{Profiles: map[string]string{"shortlived": "short lived certificates"}})
+	service := newTestService(t, server)
+	key := newTestAccount(t, service, "ec256")
+
+	if !service.ProfileValidate("shortlived") || service.ProfileValidate("classic") {
+		t.Errorf("service profiles %v do not match the server", service.Profiles())
+	}
+
+	profile := "shortlived"
+	order, err := service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}, Profile: &profile}, key)
+	if err != nil || order.Profile == nil || *order.Profile != profile {
+		t.Errorf("order with profile failed (%s)", err)
+	}
+
+	profile = "classic"
+	_, err = service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}, Profile: &profile}, key)
+	if err == nil {
+		t.Error("order with a nonexistent profile succeeded")
+	}
+}
+
+func TestService_OrderValidity(t *testing.T) {
+	server := acmetest.NewServer(t, acmetest.Config{AlwaysValid: true})
+	service := newTestService(t, server)
+	key := newTestAccount(t, service, "ec256")
+
+	notBefore := time.Now().Truncate(time.Second)
+	notAfter := notBefore.Add(24 * time.Hour)
+	payload := NewOrderPayload{}
+	payload.SetValidity(notBefore, notAfter)
+
+	order, cert := issueTestCertificate(t, service, key, payload, "")
+	if order.NotAfter.ToUnixTime() == nil || *order.NotAfter.ToUnixTime() != int(notAfter.Unix()) {
+		t.Errorf("order notAfter %v, expected %s", order.NotAfter, notAfter)
+	}
+	if !cert.NotBefore().Equal(notBefore) || !cert.NotAfter().Equal(notAfter) {
+		t.Errorf("certificate valid %s to %s, expected %s to %s", cert.NotBefore(), cert.NotAfter(), notBefore, notAfter)
+	}
+
+	// notAfter must be after notBefore
+	payload.SetValidity(notAfter, notBefore)
+	_, err := service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}, NotBefore: payload.NotBefore, NotAfter: payload.NotAfter}, key)
+	if err == nil {
+		t.Error("order with notAfter before notBefore succeeded")
+	}
+}
+
+func TestService_RenewalInfoAndRevocation(t *testing.T) {
+	validity := 30 * 24 * time.Hour
+	server := acmetest.NewServer(t, acmetest.Config{AlwaysValid: true, CertificateValidity: validity})
+	service := newTestService(t, server)
+	key := newTestAccount(t, service, "rsa")
+
+	if !service.SupportsARIExtension() {
+		t.Fatal("service does not report ari support")
+	}
+
+	_, cert := issueTestCertificate(t, service, key, NewOrderPayload{}, "")
+	leaf := parseLeaf(t, cert)
+
+	// renewal info
+	ari, err := service.GetACMERenewalInfo(cert.PEM())
+	if err != nil {
+		t.Fatalf("failed to get renewal info (%s)", err)
+	}
+	if !ari.SuggestedWindow.Start.After(leaf.NotBefore.Add(validity/2)) || !ari.SuggestedWindow.End.Before(leaf.NotAfter) {
+		t.Errorf("renewal window %s - %s is not late in the certificate's lifetime", ari.SuggestedWindow.Start, ari.SuggestedWindow.End)
+	}
+	if !ari.RetryAfter.After(time.Now()) {
+		t.Error("renewal info retry after is not in the future")
+	}
+
+	// replacement order
+	ariId := ACMERenewalInfoIdentifier(leaf)
+	order, err := service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}, Replaces: &ariId}, key)
+	if err != nil || order.Replaces == nil || *order.Replaces != ariId {
+		t.Errorf("replacement order failed (%s)", err)
+	}
+
+	// second replacement is rejected, so the client retries without replaces
+	order, err = service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier("example.com")}, Replaces: &ariId}, key)
+	if err != nil || order.Replaces != nil {
+		t.Errorf("second replacement order was not retried without replaces (%s)", err)
+	}
+
+	// revocation by another account is rejected
+	otherKey := newTestAccount(t, service, "ec256")
+	err = service.RevokeCertificate(cert.PEM(), 4, otherKey)
+	if err == nil {
+		t.Error("certificate was revoked by an account that did not issue it")
+	}
+
+	// revoke
+	err = service.RevokeCertificate(cert.PEM(), 4, key)
+	if err != nil {
+		t.Fatalf("failed to revoke certificate (%s)", err)
+	}
+	revoked, reason := server.RevocationStatus(leaf)
+	if !revoked || reason != 4 {
+		t.Errorf("certificate revocation status %t reason %d, expected revoked reason 4", revoked, reason)
+	}
+
+	err = service.RevokeCertificate(cert.PEM(), 4, key)
+	var acmeErr *Error
+	if !errors.As(err, &acmeErr) || acmeErr.Type != "urn:ietf:params:acme:error:alreadyRevoked" {
+		t.Errorf("second revocation did not return alreadyRevoked (%s)", err)
+	}
+
+	// revoked certificate should be renewed now
+	ari, err = service.GetACMERenewalInfo(cert.PEM())
+	if err != nil {
+		t.Fatalf("failed to get renewal info (%s)", err)
+	}
+	if ari.SuggestedWindow.Start.After(time.Now()) {
+		t.Errorf("renewal window of revoked certificate starts in the future (%s)", ari.SuggestedWindow.Start)
+	}
+}
+
+// challengeResponder serves the resources for all three challenge types from loopback
+// listeners
+type challengeResponder struct {
+	mu       sync.Mutex
+	http01   map[string]KeyAuth
+	tlsAlpn  map[string]*tls.Certificate
+	dnsTxt   map[string][]string
+	httpPort int
+	tlsPort  int
+}
+
+// newChallengeResponder starts the responder's listeners, which are closed when the test
+// completes
+func newChallengeResponder(t *testing.T) *challengeResponder {
+	t.Helper()
+
+	responder := &challengeResponder{
+		http01:  make(map[string]KeyAuth),
+		tlsAlpn: make(map[string]*tls.Certificate),
+		dnsTxt:  make(map[string][]string),
+	}
+
+	// http-01
+	httpLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder.httpPort = httpLn.Addr().(*net.TCPAddr).Port
+	httpServer := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, "/.well-known/acme-challenge/")
+		responder.mu.Lock()
+		keyAuth, exists := responder.http01[token]
+		responder.mu.Unlock()
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(keyAuth))
+	})}
+	go func() { _ = httpServer.Serve(httpLn) }()
+	t.Cleanup(func() { _ = httpServer.Close() })
+
+	// tls-alpn-01
+	tlsLn, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		NextProtos: []string{TlsAlpn01Protocol},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			responder.mu.Lock()
+			defer responder.mu.Unlock()
+			cert, exists := responder.tlsAlpn[hello.ServerName]
+			if !exists {
+				return nil, errors.New("no certificate for server name")
+			}
+			return cert, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	responder.tlsPort = tlsLn.Addr().(*net.TCPAddr).Port
+	go func() {
+		for {
+			conn, err := tlsLn.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	t.Cleanup(func() { _ = tlsLn.Close() })
+
+	return responder
+}
+
+// lookupTxt implements acmetest.Config.DNS01Lookup
+func (responder *challengeResponder) lookupTxt(fqdn string) ([]string, error) {
+	responder.mu.Lock()
+	defer responder.mu.Unlock()
+
+	return responder.dnsTxt[fqdn], nil
+}
+
+func TestService_ChallengeValidation(t *testing.T) {
+	responder := newChallengeResponder(t)
+	server := acmetest.NewServer(t, acmetest.Config{
+		HTTP01Port:    responder.httpPort,
+		TLSALPN01Port: responder.tlsPort,
+		DNS01Lookup:   responder.lookupTxt,
+	})
+	service := newTestService(t, server)
+	key := newTestAccount(t, service, "ec256")
+
+	testCases := []struct {
+		identifier    string
+		challengeType ChallengeType
+		sniName       string
+		provision     bool
+		expected      string
+	}{
+		{"http.example.com", ChallengeTypeHttp01, "", true, "valid"},
+		{"192.0.2.10", ChallengeTypeHttp01, "", true, "valid"},
+		{"http-missing.example.com", ChallengeTypeHttp01, "", false, "invalid"},
+		{"dns.example.com", ChallengeTypeDns01, "", true, "valid"},
+		{"*.wildcard.example.com", ChallengeTypeDns01, "", true, "valid"},
+		{"dns-missing.example.com", ChallengeTypeDns01, "", false, "invalid"},
+		{"tls.example.com", ChallengeTypeTlsAlpn01, "tls.example.com", true, "valid"},
+		{"192.0.2.11", ChallengeTypeTlsAlpn01, "11.2.0.192.in-addr.arpa", true, "valid"},
+		{"2001:db8::1", ChallengeTypeTlsAlpn01, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa", true, "valid"},
+		{"tls-missing.example.com", ChallengeTypeTlsAlpn01, "", false, "invalid"},
+	}
+
+	for _, tc := range testCases {
+		order, err := service.NewOrder(NewOrderPayload{Identifiers: IdentifierSlice{NewIdentifier(tc.identifier)}}, key)
+		if err != nil {
+			t.Fatalf("%s: failed to create order (%s)", tc.identifier, err)
+		}
+		auth, err := service.GetAuth(order.Authorizations[0], key)
+		if err != nil {
+			t.Fatalf("%s: failed to get authorization (%s)", tc.identifier, err)
+		}
+
+		i := slices.IndexFunc(auth.Challenges, func(c Challenge) bool { return c.Type == tc.challengeType })
+		if i == -1 {
+			t.Fatalf("%s: authorization has no %s challenge", tc.identifier, tc.challengeType)
+		}
+		chall := auth.Challenges[i]
+
+		keyAuth, err := key.KeyAuthorization(chall.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tc.provision {
+			responder.mu.Lock()
+			switch tc.challengeType {
+			case ChallengeTypeHttp01:
+				responder.http01[chall.Token] = keyAuth
+			case ChallengeTypeDns01:
+				name, value := ValidationResourceDns01(auth.Identifier.Value, keyAuth)
+				responder.dnsTxt[name] = append(responder.dnsTxt[name], value)
+			case ChallengeTypeTlsAlpn01:
+				cert, err := ValidationResourceTlsAlpn01(auth.Identifier.Value, keyAuth)
+				if err != nil {
+					t.Fatal(err)
+				}
+				responder.tlsAlpn[tc.sniName] = cert
+			}
+			responder.mu.Unlock()
+		}
+
+		_, err = service.InstructServerToValidateChallenge(chall.Url, key)
+		if err != nil {
+			t.Fatalf("%s: failed to respond to challenge (%s)", tc.identifier, err)
+		}
+
+		chall = waitForChallenge(t, service, chall.Url, key)
+		if chall.Status != tc.expected {
+			t.Errorf("%s: %s challenge status %s, expected %s (%s)", tc.identifier, tc.challengeType, chall.Status, tc.expected, chall.Error)
+		}
+
+		auth, err = service.GetAuth(order.Authorizations[0], key)
+		if err != nil || auth.Status != tc.expected {
+			t.Errorf("%s: authorization status %s, expected %s (%v)", tc.identifier, auth.Status, tc.expected, err)
+		}
+	}
+}
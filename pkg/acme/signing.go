@@ -3,6 +3,7 @@ package acme
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/rsa"
@@ -17,7 +18,7 @@ import (
 func (accountKey *AccountKey) signingAlg() (signatureAlgorithm string, err error) {
 	switch privateKey := accountKey.Key.(type) {
 	case *rsa.PrivateKey:
-		// all rsa use RS256
+		// all rsa use RS256 (including keys that are used for rsa-pss elsewhere)
 		return "RS256", nil
 
 	case *ecdsa.PrivateKey:
@@ -26,10 +27,16 @@ func (accountKey *AccountKey) signingAlg() (signatureAlgorithm string, err error
 			return "ES256", nil
 		case "P-384":
 			return "ES384", nil
+		case "P-521":
+			return "ES512", nil
 		default:
 			return "", errors.New("acme: signature algorithm: unsupported ecdsa curve")
 		}
 
+	case ed25519.PrivateKey:
+		// RFC 8037
+		return "EdDSA", nil
+
 	default:
 		// break to final error return
 	}
@@ -77,6 +84,10 @@ func (asm *acmeSignedMessage) Sign(accountKey AccountKey) error {
 			hashed384 := sha512.Sum384(toSign)
 			hashed = hashed384[:]
 
+		case 521:
+			hashed512 := sha512.Sum512(toSign)
+			hashed = hashed512[:]
+
 		default:
 			return errors.New("acme: failed to sign (unsupported ec bit size)")
 		}
@@ -94,6 +105,10 @@ func (asm *acmeSignedMessage) Sign(accountKey AccountKey) error {
 		// combine the buffers and encode
 		encodedSignature = encodeString(append(rPadded, sPadded...))
 
+	case ed25519.PrivateKey:
+		// EdDSA signs the message itself (no separate hash)
+		encodedSignature = encodeString(ed25519.Sign(privateKey, toSign))
+
 	default:
 		// not supported
 		return errors.New("acme: sign: unsupported private key type")
@@ -0,0 +1,110 @@
+package apikeyhash
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// API keys are stored as a salted hash so the database (and backups of it) don't
+// contain usable credentials. The plaintext key is only available when it is
+// generated.
+
+// keyPrefix identifies api keys that contain their id; the key's id follows the prefix
+// so the matching hash can be found without checking every key
+const keyPrefix = "cwak_"
+
+const (
+	hashScheme     = "pbkdf2_sha256"
+	hashIterations = 10_000
+	hashSaltLen    = 16
+	hashLen        = 32
+)
+
+// Hash returns the salted hash of apiKey, in the format:
+// pbkdf2_sha256$<iterations>$<salt>$<hash>
+func Hash(apiKey string) (string, error) {
+	salt := make([]byte, hashSaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := pbkdf2.Key(sha256.New, apiKey, salt, hashIterations, hashLen)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s$%d$%s$%s", hashScheme, hashIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// Matches returns true if apiKey is the key that was hashed to produce hash. The
+// comparison is constant-time. A blank apiKey or hash never matches.
+func Matches(apiKey string, hash string) bool {
+	if apiKey == "" || hash == "" {
+		return false
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != hashScheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	actual, err := pbkdf2.Key(sha256.New, apiKey, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(actual, expected) == 1
+}
+
+// IsHash returns true if value is in the format returned by Hash
+func IsHash(value string) bool {
+	return strings.HasPrefix(value, hashScheme+"$") && strings.Count(value, "$") == 3
+}
+
+// Format returns the plaintext api key a client uses for the api key with the specified
+// id and secret. Only the secret is hashed.
+func Format(id int, secret string) string {
+	return keyPrefix + strconv.Itoa(id) + "_" + secret
+}
+
+// Parse returns the id and secret contained in a plaintext api key. If apiKey does not
+// contain an id (e.g., it was created before ids were included), ok is false.
+func Parse(apiKey string) (id int, secret string, ok bool) {
+	idAndSecret, found := strings.CutPrefix(apiKey, keyPrefix)
+	if !found {
+		return -1, "", false
+	}
+
+	idStr, secret, found := strings.Cut(idAndSecret, "_")
+	if !found || secret == "" {
+		return -1, "", false
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 0 {
+		return -1, "", false
+	}
+
+	return id, secret, true
+}
@@ -0,0 +1,57 @@
+package apikeyhash
+
+import "testing"
+
+func TestApiKeyHash_HashAndMatches(t *testing.T) {
+	hash, err := Hash("abcdefghij0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsHash(hash) {
+		t.Errorf("hash %s not recognized as a hash", hash)
+	}
+
+	if !Matches("abcdefghij0123456789", hash) {
+		t.Error("key does not match its hash")
+	}
+	for _, wrong := range []string{"", "abcdefghij012345678", "abcdefghij0123456789x", hash} {
+		if Matches(wrong, hash) {
+			t.Errorf("key '%s' matches hash", wrong)
+		}
+	}
+
+	// salted, so the same key hashes differently
+	hash2, err := Hash("abcdefghij0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == hash2 {
+		t.Error("hashes of the same key are identical")
+	}
+
+	// blank and malformed hashes never match
+	for _, bad := range []string{"", "abcdefghij0123456789", "pbkdf2_sha256$x$y$z", "pbkdf2_sha256$0$AAAA$AAAA"} {
+		if Matches("abcdefghij0123456789", bad) {
+			t.Errorf("key matches malformed hash '%s'", bad)
+		}
+	}
+}
+
+func TestApiKeyHash_FormatAndParse(t *testing.T) {
+	key := Format(12, "abcdefghij0123456789")
+	if key != "cwak_12_abcdefghij0123456789" {
+		t.Errorf("formatted key %s", key)
+	}
+
+	id, secret, ok := Parse(key)
+	if !ok || id != 12 || secret != "abcdefghij0123456789" {
+		t.Errorf("parsed id %d and secret %s (ok: %t)", id, secret, ok)
+	}
+
+	// keys without an id, and malformed keys
+	for _, bad := range []string{"", "abcdefghij0123456789", "cwak_", "cwak_12", "cwak_12_", "cwak_x_abc", "cwak_-1_abc", "cwpat_12_abc"} {
+		if _, _, ok := Parse(bad); ok {
+			t.Errorf("key '%s' parsed", bad)
+		}
+	}
+}
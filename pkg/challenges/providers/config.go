@@ -7,6 +7,7 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 )
 
 // internal base config
@@ -22,6 +23,11 @@ type ConfigManagerHttp01Internal struct {
 	*http01internal.Config `yaml:",inline"`
 }
 
+type ConfigManagerTlsAlpn01Internal struct {
+	InternalConfig            `yaml:",inline"`
+	*tlsalpn01internal.Config `yaml:",inline"`
+}
+
 type ConfigManagerDns01Manual struct {
 	InternalConfig      `yaml:",inline"`
 	*dns01manual.Config `yaml:",inline"`
@@ -49,17 +55,19 @@ type ConfigManagerDns01GoAcme struct {
 
 // Config contains configurations for all provider types with domains
 type Config struct {
-	Http01InternalConfigs  []ConfigManagerHttp01Internal  `yaml:"http_01_internal,omitempty"`
-	Dns01ManualConfigs     []ConfigManagerDns01Manual     `yaml:"dns_01_manual,omitempty"`
-	Dns01AcmeDnsConfigs    []ConfigManagerDns01AcmeDns    `yaml:"dns_01_acme_dns,omitempty"`
-	Dns01AcmeShConfigs     []ConfigManagerDns01AcmeSh     `yaml:"dns_01_acme_sh,omitempty"`
-	Dns01CloudflareConfigs []ConfigManagerDns01Cloudflare `yaml:"dns_01_cloudflare,omitempty"`
-	Dns01GoAcmeConfigs     []ConfigManagerDns01GoAcme     `yaml:"dns_01_go_acme,omitempty"`
+	Http01InternalConfigs    []ConfigManagerHttp01Internal    `yaml:"http_01_internal,omitempty"`
+	TlsAlpn01InternalConfigs []ConfigManagerTlsAlpn01Internal `yaml:"tls_alpn_01_internal,omitempty"`
+	Dns01ManualConfigs       []ConfigManagerDns01Manual       `yaml:"dns_01_manual,omitempty"`
+	Dns01AcmeDnsConfigs      []ConfigManagerDns01AcmeDns      `yaml:"dns_01_acme_dns,omitempty"`
+	Dns01AcmeShConfigs       []ConfigManagerDns01AcmeSh       `yaml:"dns_01_acme_sh,omitempty"`
+	Dns01CloudflareConfigs   []ConfigManagerDns01Cloudflare   `yaml:"dns_01_cloudflare,omitempty"`
+	Dns01GoAcmeConfigs       []ConfigManagerDns01GoAcme       `yaml:"dns_01_go_acme,omitempty"`
 }
 
 // Len returns the total number of Provider Configs, regardless of type.
 func (cfg Config) Len() int {
 	return len(cfg.Http01InternalConfigs) +
+		len(cfg.TlsAlpn01InternalConfigs) +
 		len(cfg.Dns01ManualConfigs) +
 		len(cfg.Dns01AcmeDnsConfigs) +
 		len(cfg.Dns01AcmeShConfigs) +
@@ -107,6 +115,12 @@ func (cfg Config) All() []managerProviderConfig {
 			providerCfg: mgrCfg.Config,
 		})
 	}
+	for _, mgrCfg := range cfg.TlsAlpn01InternalConfigs {
+		all = append(all, managerProviderConfig{
+			internalCfg: mgrCfg.InternalConfig,
+			providerCfg: mgrCfg.Config,
+		})
+	}
 	for _, mgrCfg := range cfg.Dns01GoAcmeConfigs {
 		all = append(all, managerProviderConfig{
 			internalCfg: mgrCfg.InternalConfig,
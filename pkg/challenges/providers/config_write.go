@@ -7,6 +7,7 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"errors"
 	"io/fs"
 	"os"
@@ -36,6 +37,18 @@ func (mgr *Manager) unsafeWriteProvidersConfig() error {
 				},
 			)
 
+		case *tlsalpn01internal.Config:
+			mgrCfg.TlsAlpn01InternalConfigs = append(mgrCfg.TlsAlpn01InternalConfigs,
+				ConfigManagerTlsAlpn01Internal{
+					InternalConfig: InternalConfig{
+						Domains:              p.Domains,
+						PreCheckWaitSeconds:  p.PreCheckWaitSeconds,
+						PostCheckWaitSeconds: p.PostCheckWaitSeconds,
+					},
+					Config: realCfg,
+				},
+			)
+
 		case *dns01manual.Config:
 			mgrCfg.Dns01ManualConfigs = append(mgrCfg.Dns01ManualConfigs,
 				ConfigManagerDns01Manual{
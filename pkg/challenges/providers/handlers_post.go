@@ -7,6 +7,7 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"fmt"
@@ -23,12 +24,13 @@ type newPayload struct {
 	PostCheckWaitSeconds *int `json:"postcheck_wait"`
 
 	// + mandatory, only one of these
-	Http01InternalConfig  *http01internal.Config  `json:"http_01_internal,omitempty"`
-	Dns01ManualConfig     *dns01manual.Config     `json:"dns_01_manual,omitempty"`
-	Dns01AcmeDnsConfig    *dns01acmedns.Config    `json:"dns_01_acme_dns,omitempty"`
-	Dns01AcmeShConfig     *dns01acmesh.Config     `json:"dns_01_acme_sh,omitempty"`
-	Dns01CloudflareConfig *dns01cloudflare.Config `json:"dns_01_cloudflare,omitempty"`
-	Dns01GoAcmeConfig     *dns01goacme.Config     `json:"dns_01_go_acme,omitempty"`
+	Http01InternalConfig    *http01internal.Config    `json:"http_01_internal,omitempty"`
+	TlsAlpn01InternalConfig *tlsalpn01internal.Config `json:"tls_alpn_01_internal,omitempty"`
+	Dns01ManualConfig       *dns01manual.Config       `json:"dns_01_manual,omitempty"`
+	Dns01AcmeDnsConfig      *dns01acmedns.Config      `json:"dns_01_acme_dns,omitempty"`
+	Dns01AcmeShConfig       *dns01acmesh.Config       `json:"dns_01_acme_sh,omitempty"`
+	Dns01CloudflareConfig   *dns01cloudflare.Config   `json:"dns_01_cloudflare,omitempty"`
+	Dns01GoAcmeConfig       *dns01goacme.Config       `json:"dns_01_go_acme,omitempty"`
 }
 
 // CreateProvider creates a new provider using the specified configuration.
@@ -49,6 +51,9 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 	if payload.Http01InternalConfig != nil {
 		configCount++
 	}
+	if payload.TlsAlpn01InternalConfig != nil {
+		configCount++
+	}
 	if payload.Dns01ManualConfig != nil {
 		configCount++
 	}
@@ -90,6 +95,9 @@ func (mgr *Manager) CreateProvider(w http.ResponseWriter, r *http.Request) *outp
 	if payload.Http01InternalConfig != nil {
 		p, err = mgr.unsafeAddProvider(internalCfg, payload.Http01InternalConfig)
 
+	} else if payload.TlsAlpn01InternalConfig != nil {
+		p, err = mgr.unsafeAddProvider(internalCfg, payload.TlsAlpn01InternalConfig)
+
 	} else if payload.Dns01ManualConfig != nil {
 		p, err = mgr.unsafeAddProvider(internalCfg, payload.Dns01ManualConfig)
 
@@ -7,6 +7,7 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"errors"
@@ -29,12 +30,13 @@ type modifyPayload struct {
 	PostCheckWaitSeconds *int     `json:"postcheck_wait"`
 
 	// plus only one of these
-	Http01InternalConfig  *http01internal.Config  `json:"http_01_internal,omitempty"`
-	Dns01ManualConfig     *dns01manual.Config     `json:"dns_01_manual,omitempty"`
-	Dns01AcmeDnsConfig    *dns01acmedns.Config    `json:"dns_01_acme_dns,omitempty"`
-	Dns01AcmeShConfig     *dns01acmesh.Config     `json:"dns_01_acme_sh,omitempty"`
-	Dns01CloudflareConfig *dns01cloudflare.Config `json:"dns_01_cloudflare,omitempty"`
-	Dns01GoAcmeConfig     *dns01goacme.Config     `json:"dns_01_go_acme,omitempty"`
+	Http01InternalConfig    *http01internal.Config    `json:"http_01_internal,omitempty"`
+	TlsAlpn01InternalConfig *tlsalpn01internal.Config `json:"tls_alpn_01_internal,omitempty"`
+	Dns01ManualConfig       *dns01manual.Config       `json:"dns_01_manual,omitempty"`
+	Dns01AcmeDnsConfig      *dns01acmedns.Config      `json:"dns_01_acme_dns,omitempty"`
+	Dns01AcmeShConfig       *dns01acmesh.Config       `json:"dns_01_acme_sh,omitempty"`
+	Dns01CloudflareConfig   *dns01cloudflare.Config   `json:"dns_01_cloudflare,omitempty"`
+	Dns01GoAcmeConfig       *dns01goacme.Config       `json:"dns_01_go_acme,omitempty"`
 }
 
 // ModifyProvider modifies the provider specified by the ID in manager with the specified
@@ -100,6 +102,10 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 		configCount++
 		pCfg = payload.Http01InternalConfig
 	}
+	if payload.TlsAlpn01InternalConfig != nil {
+		configCount++
+		pCfg = payload.TlsAlpn01InternalConfig
+	}
 	if payload.Dns01ManualConfig != nil {
 		configCount++
 		pCfg = payload.Dns01ManualConfig
@@ -137,6 +143,14 @@ func (mgr *Manager) ModifyProvider(w http.ResponseWriter, r *http.Request) *outp
 			}
 			err = pServ.UpdateService(mgr.childApp, payload.Http01InternalConfig)
 
+		case *tlsalpn01internal.Service:
+			if payload.TlsAlpn01InternalConfig == nil {
+				err = errors.New("update provider wrong config received")
+				mgr.logger.Debug(err)
+				return output.JsonErrValidationFailed(err)
+			}
+			err = pServ.UpdateService(mgr.childApp, payload.TlsAlpn01InternalConfig)
+
 		case *dns01manual.Service:
 			if payload.Dns01ManualConfig == nil {
 				err = errors.New("update provider wrong config received")
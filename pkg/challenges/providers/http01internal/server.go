@@ -52,6 +52,7 @@ func (service *Service) startServer() (err error) {
 		service.logger.Error(fmt.Errorf("failed to start http-01 challenge server, cannot bind to %s (%s)", servAddr, err))
 		return err
 	}
+	service.addr = ln.Addr()
 
 	// start server
 	service.shutdownWaitgroup.Add(1)
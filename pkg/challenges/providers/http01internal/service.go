@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
@@ -32,6 +33,7 @@ type Service struct {
 	stopServerFunc    context.CancelFunc
 	stopErrChan       chan error
 	port              int
+	addr              net.Addr
 	// map[token]keyAuth - token is the http resource and keyAuth is the data served
 	provisionedResources *safemap.SafeMap[acme.KeyAuth]
 }
@@ -75,6 +77,12 @@ func (service *Service) Stop() (err error) {
 	return nil
 }
 
+// Addr returns the address the http-01 challenge server is listening on. If the port
+// is configured as 0, the server listens on any available port.
+func (service *Service) Addr() net.Addr {
+	return service.addr
+}
+
 // Configuration options
 type Config struct {
 	Port *int `yaml:"port" json:"port"`
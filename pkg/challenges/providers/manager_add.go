@@ -7,6 +7,7 @@ import (
 	"certwarden-backend/pkg/challenges/providers/dns01goacme"
 	"certwarden-backend/pkg/challenges/providers/dns01manual"
 	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
 	"certwarden-backend/pkg/randomness"
 	"errors"
 	"reflect"
@@ -31,6 +32,9 @@ func (mgr *Manager) unsafeAddProvider(internalCfg InternalConfig, cfg providerCo
 	case *http01internal.Config:
 		serv, err = http01internal.NewService(mgr.childApp, realCfg)
 
+	case *tlsalpn01internal.Config:
+		serv, err = tlsalpn01internal.NewService(mgr.childApp, realCfg)
+
 	case *dns01manual.Config:
 		serv, err = dns01manual.NewService(mgr.childApp, realCfg)
 
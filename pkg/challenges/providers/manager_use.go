@@ -2,6 +2,7 @@ package providers
 
 import (
 	"fmt"
+	"net/netip"
 	"strings"
 )
 
@@ -17,6 +18,20 @@ func (mgr *Manager) ProviderFor(fqdn string) (*provider, error) {
 		return p, nil
 	}
 
+	// if fqdn is actually an ip address, check for an equivalent ip (textual
+	// representation may differ), then skip to wildcard since subdomains don't apply
+	fqdnIP, err := netip.ParseAddr(fqdn)
+	if err == nil {
+		for domain := range mgr.dP {
+			domainIP, err := netip.ParseAddr(domain)
+			if err == nil && domainIP.Unmap() == fqdnIP.Unmap() {
+				return mgr.dP[domain], nil
+			}
+		}
+
+		return mgr.wildcardProviderFor(fqdn)
+	}
+
 	// find best match from options (if there is a provider for a more specific subdomain, choose that one)
 	providerDomain := ""
 	for domain := range mgr.dP {
@@ -35,7 +50,14 @@ func (mgr *Manager) ProviderFor(fqdn string) (*provider, error) {
 	}
 
 	// if domain was not found, return wild provider if it exists
-	p, exists = mgr.dP["*"]
+	return mgr.wildcardProviderFor(fqdn)
+}
+
+// wildcardProviderFor returns the wildcard provider, if there is one. If there
+// isn't, an error is returned. It MUST be called from a thread that is already
+// at minimum RLocked.
+func (mgr *Manager) wildcardProviderFor(fqdn string) (*provider, error) {
+	p, exists := mgr.dP["*"]
 	if exists {
 		return p, nil
 	}
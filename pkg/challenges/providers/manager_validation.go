@@ -6,7 +6,7 @@ import (
 	"fmt"
 )
 
-// unsafeValidateDomains verifies that the domains are all valid
+// unsafeValidateDomains verifies that the domains (or ip addresses) are all valid
 // and also that they're available in manager. p is optional and if specified
 // domains will also be condidered valid if they're not available but are
 // currently assigned to p.  If validation succeeds, nil is returned, if it
@@ -22,12 +22,12 @@ func (mgr *Manager) unsafeValidateDomains(domains []string, p *provider) error {
 
 	// validate domain names
 	for _, domain := range domains {
-		// check validity -or- wildcard
-		if !validation.DomainValid(domain, false) && !(len(domains) == 1 && domains[0] == "*") {
+		// check validity -or- ip address -or- wildcard
+		if !validation.DomainValid(domain, false) && !validation.IPAddressValid(domain) && !(len(domains) == 1 && domains[0] == "*") {
 			if domain == "*" {
 				return errors.New("when using wildcard domain * it must be the only specified domain on the provider")
 			}
-			return fmt.Errorf("domain %s is not a validly formatted domain", domain)
+			return fmt.Errorf("domain %s is not a validly formatted domain or ip address", domain)
 		}
 
 		// check manager availability
@@ -0,0 +1,35 @@
+package tlsalpn01internal
+
+import (
+	"certwarden-backend/pkg/acme"
+	"crypto/tls"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// getCertificate responds to the ACME tls-alpn-01 challenge handshake. If the
+// client negotiated the acme-tls/1 protocol and the SNI exists in this service's
+// resources, the validation certificate is returned. Otherwise an error is
+// returned which aborts the handshake.
+func (service *Service) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	// only respond to acme-tls/1 (RFC 8737 s 3)
+	if !slices.Contains(hello.SupportedProtos, acme.TlsAlpn01Protocol) {
+		service.logger.Debugf("tls-alpn-01 client (%s) did not offer %s protocol", hello.Conn.RemoteAddr(), acme.TlsAlpn01Protocol)
+		return nil, fmt.Errorf("tls-alpn-01 server only supports %s", acme.TlsAlpn01Protocol)
+	}
+
+	// try to read resource
+	cert, exists := service.provisionedResources.Read(strings.ToLower(hello.ServerName))
+
+	// resource not available
+	if !exists {
+		service.logger.Debugf("tls-alpn-01 challenge resource %s not found", hello.ServerName)
+		return nil, fmt.Errorf("tls-alpn-01 resource %s not found", hello.ServerName)
+	}
+
+	// found, serve it
+	service.logger.Debugf("writing resource (name: %s) to tls-alpn-01 client", hello.ServerName)
+
+	return cert, nil
+}
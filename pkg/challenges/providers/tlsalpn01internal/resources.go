@@ -0,0 +1,74 @@
+package tlsalpn01internal
+
+import (
+	"certwarden-backend/pkg/acme"
+	"crypto/tls"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// serverName returns the SNI value the ACME server will send when validating
+// domain. For an ip address this is the reverse dns name (RFC 8738 s 6). SNI
+// is case insensitive so the value is always lowercase.
+func serverName(domain string) string {
+	addr, err := netip.ParseAddr(domain)
+	if err != nil {
+		return strings.ToLower(domain)
+	}
+	addr = addr.Unmap()
+
+	// ipv4: reversed octets + in-addr.arpa
+	if addr.Is4() {
+		b := addr.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", b[3], b[2], b[1], b[0])
+	}
+
+	// ipv6: reversed nibbles + ip6.arpa
+	b := addr.As16()
+	sb := strings.Builder{}
+	for i := len(b) - 1; i >= 0; i-- {
+		fmt.Fprintf(&sb, "%x.%x.", b[i]&0x0f, b[i]>>4)
+	}
+	sb.WriteString("ip6.arpa")
+
+	return sb.String()
+}
+
+// Provision creates the validation certificate for the domain and adds it to
+// the resources being hosted
+func (service *Service) Provision(domain string, _ string, keyAuth acme.KeyAuth) error {
+	// make the validation cert
+	cert, err := acme.ValidationResourceTlsAlpn01(domain, keyAuth)
+	if err != nil {
+		return fmt.Errorf("tls-alpn-01 failed to make validation certificate for %s (%s)", domain, err)
+	}
+
+	// add new entry
+	exists, _ := service.provisionedResources.Add(serverName(domain), cert)
+
+	// if it already exists, log an error and fail (should never happen if challenges is working
+	// properly)
+	if exists {
+		err := fmt.Errorf("tls-alpn-01 resource for %s already in use, this should never happen", domain)
+		service.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Deprovision removes the validation certificate for domain from those being hosted
+func (service *Service) Deprovision(domain string, _ string, _ acme.KeyAuth) error {
+	// delete entry
+	delFunc := func(domainKey string, _ *tls.Certificate) bool {
+		return domainKey == serverName(domain)
+	}
+
+	deleteOk := service.provisionedResources.DeleteFunc(delFunc)
+	if !deleteOk {
+		return fmt.Errorf("tls-alpn-01 resource %s failed to delete", domain)
+	}
+
+	return nil
+}
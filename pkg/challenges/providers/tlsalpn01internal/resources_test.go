@@ -0,0 +1,140 @@
+package tlsalpn01internal
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/datatypes/safemap"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"net"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// id-pe-acmeIdentifier (RFC 8737 s 6.1)
+var testIdPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+func TestTlsAlpn01_ServerName(t *testing.T) {
+	tests := map[string]string{
+		"Example.COM":      "example.com",
+		"192.0.2.1":        "1.2.0.192.in-addr.arpa",
+		"::ffff:192.0.2.1": "1.2.0.192.in-addr.arpa",
+		"2001:db8::1":      "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+	}
+
+	for domain, want := range tests {
+		got := serverName(domain)
+		if got != want {
+			t.Errorf("server name of '%s' is '%s', expected '%s'", domain, got, want)
+		}
+	}
+}
+
+func TestTlsAlpn01_ProvisionAndHandshake(t *testing.T) {
+	service := &Service{
+		logger:               zap.NewNop().Sugar(),
+		provisionedResources: safemap.NewSafeMap[*tls.Certificate](),
+	}
+
+	tests := []struct {
+		domain string
+		sni    string
+	}{
+		{domain: "www.example.com", sni: "WWW.example.com"},
+		{domain: "192.0.2.1", sni: "1.2.0.192.in-addr.arpa"},
+	}
+
+	for _, test := range tests {
+		keyAuth := acme.KeyAuth("token." + test.domain)
+
+		err := service.Provision(test.domain, "", keyAuth)
+		if err != nil {
+			t.Fatalf("%s: failed to provision (%s)", test.domain, err)
+		}
+
+		// handshake as the ACME server would
+		cert, err := testHandshake(service, test.sni, acme.TlsAlpn01Protocol)
+		if err != nil {
+			t.Fatalf("%s: handshake failed (%s)", test.domain, err)
+		}
+
+		// acmeIdentifier extension
+		found := false
+		for _, ext := range cert.Extensions {
+			if !ext.Id.Equal(testIdPeAcmeIdentifier) {
+				continue
+			}
+			found = true
+
+			if !ext.Critical {
+				t.Errorf("%s: acmeIdentifier extension is not critical", test.domain)
+			}
+
+			var digest []byte
+			rest, err := asn1.Unmarshal(ext.Value, &digest)
+			if err != nil || len(rest) != 0 {
+				t.Fatalf("%s: acmeIdentifier extension value is not an octet string", test.domain)
+			}
+			expected := sha256.Sum256([]byte(keyAuth))
+			if !bytes.Equal(digest, expected[:]) {
+				t.Errorf("%s: acmeIdentifier digest does not match key authorization", test.domain)
+			}
+		}
+		if !found {
+			t.Errorf("%s: validation certificate has no acmeIdentifier extension", test.domain)
+		}
+
+		// san is the identifier
+		err = cert.VerifyHostname(test.domain)
+		if err != nil {
+			t.Errorf("%s: validation certificate san is wrong (%s)", test.domain, err)
+		}
+
+		// other protocols are refused
+		_, err = testHandshake(service, test.sni, "http/1.1")
+		if err == nil {
+			t.Errorf("%s: handshake without %s protocol succeeded", test.domain, acme.TlsAlpn01Protocol)
+		}
+
+		// deprovisioned resource is no longer served
+		err = service.Deprovision(test.domain, "", keyAuth)
+		if err != nil {
+			t.Fatalf("%s: failed to deprovision (%s)", test.domain, err)
+		}
+		_, err = testHandshake(service, test.sni, acme.TlsAlpn01Protocol)
+		if err == nil {
+			t.Errorf("%s: handshake succeeded after deprovisioning", test.domain)
+		}
+	}
+}
+
+// testHandshake completes a tls handshake with service using the specified sni and alpn protocol
+// and returns the certificate served
+func testHandshake(service *Service, sni string, proto string) (*x509.Certificate, error) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	go func() {
+		defer serverConn.Close()
+		_ = tls.Server(serverConn, &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			NextProtos:     []string{acme.TlsAlpn01Protocol},
+			GetCertificate: service.getCertificate,
+		}).Handshake()
+	}()
+
+	client := tls.Client(clientConn, &tls.Config{
+		ServerName:         sni,
+		NextProtos:         []string{proto},
+		InsecureSkipVerify: true,
+	})
+	err := client.Handshake()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.ConnectionState().PeerCertificates[0], nil
+}
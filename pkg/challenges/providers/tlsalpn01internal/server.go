@@ -0,0 +1,99 @@
+package tlsalpn01internal
+
+import (
+	"certwarden-backend/pkg/acme"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// tls server timeouts
+const tlsHandshakeTimeout = 10 * time.Second
+
+func (service *Service) startServer() (err error) {
+	// make child context for stopping server
+	ctx, stopServer := context.WithCancel(service.shutdownContext)
+	service.stopServerFunc = stopServer
+
+	// err chan for stop
+	service.stopErrChan = make(chan error)
+
+	// configure server (all interfaces)
+	servAddr := fmt.Sprintf(":%d", service.port)
+	tlsConf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{acme.TlsAlpn01Protocol},
+		GetCertificate: service.getCertificate,
+	}
+
+	// launch server
+	service.logger.Infof("attempting to start tls-alpn-01 challenge server on %s.", servAddr)
+	if service.port != 443 {
+		service.logger.Warnf("tls-alpn-01 challenge server is not configured on port 443; internet "+
+			"facing port 443 must be proxied (tcp passthrough) to port %d to function.", service.port)
+	}
+
+	// create listener for server
+	ln, err := net.Listen("tcp", servAddr)
+	if err != nil {
+		service.logger.Error(fmt.Errorf("failed to start tls-alpn-01 challenge server, cannot bind to %s (%s)", servAddr, err))
+		return err
+	}
+	service.addr = ln.Addr()
+
+	// start server
+	service.shutdownWaitgroup.Add(1)
+	go func() {
+		defer service.shutdownWaitgroup.Done()
+
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					break
+				}
+				service.logger.Errorf("tlsalpn01internal server accept returned error (%s)", err)
+				continue
+			}
+
+			go service.handleConn(ctx, conn, tlsConf)
+		}
+		service.logger.Infof("tls-alpn-01 challenge server (%s) shutdown complete", servAddr)
+	}()
+
+	// monitor shutdown context
+	go func() {
+		<-ctx.Done()
+
+		err := ln.Close()
+		if err != nil {
+			service.logger.Errorf("error shutting down tls-alpn-01 challenge server %s (%s)", servAddr, err)
+		}
+
+		// send shutdown result to err chan
+		service.stopErrChan <- err
+	}()
+
+	return nil
+}
+
+// handleConn completes the tls handshake with the client (which is all that is
+// needed for validation) and then closes the connection
+func (service *Service) handleConn(ctx context.Context, conn net.Conn, tlsConf *tls.Config) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, tlsHandshakeTimeout)
+	defer cancel()
+
+	tlsConn := tls.Server(conn, tlsConf)
+	err := tlsConn.HandshakeContext(ctx)
+	if err != nil {
+		service.logger.Debugf("tls-alpn-01 handshake with %s failed (%s)", conn.RemoteAddr(), err)
+		return
+	}
+
+	service.logger.Debugf("tls-alpn-01 handshake with %s complete", conn.RemoteAddr())
+}
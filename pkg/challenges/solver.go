@@ -13,29 +13,40 @@ import (
 var (
 	errDnsDidntPropagate         = errors.New("challenges: solving failed: dns record didn't propagate")
 	errChallengeRetriesExhausted = errors.New("challenges: solving failed: challenge failed to move to final state (timeout)")
-	errChallengeTypeNotFound     = errors.New("challenges: solving failed: provider's challenge type not found in challenges array (possibly trying to use a wildcard with http-01)")
+	errChallengeTypeNotFound     = errors.New("challenges: solving failed: provider's challenge type not found in challenges array (possibly trying to use a wildcard with http-01 or tls-alpn-01)")
 )
 
 // Solve accepts an ACME identifier and a slice of challenges and then solves the challenge using a provider
-// for the specific domain. If no provider exists or solving otherwise fails, an error is returned.
+// for the specific domain (or ip address). If no provider exists or solving otherwise fails, an error is returned.
 func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Challenge, key acme.AccountKey, acmeService *acme.Service) (err error) {
-	// confirm Type is correct (only dns is supported)
-	if identifier.Type != acme.IdentifierTypeDns {
-		return fmt.Errorf("challenges: acme identifier is type (%s); only 'dns' is supported", string(identifier.Type))
-	}
+	// identifier value -> fqdn (or ip)
+	var domain string
+	switch identifier.Type {
+	case acme.IdentifierTypeDns:
+		domain = service.dnsIDValuetoDomain(identifier.Value)
+		if domain != identifier.Value {
+			service.logger.Debugf("challenges: alias exists for acme identifier `%s` and will provision to `%s`", identifier.Value, domain)
+		}
 
-	// identifier value -> fqdn
-	domain := service.dnsIDValuetoDomain(identifier.Value)
-	if domain != identifier.Value {
-		service.logger.Debugf("challenges: alias exists for acme identifier `%s` and will provision to `%s`", identifier.Value, domain)
+	case acme.IdentifierTypeIp:
+		// aliases do not apply to ip identifiers
+		domain = identifier.Value
+
+	default:
+		return fmt.Errorf("challenges: acme identifier is type (%s); only 'dns' and 'ip' are supported", string(identifier.Type))
 	}
 
-	// get provider for fqdn
+	// get provider for fqdn (or ip)
 	provider, err := service.DNSIdentifierProviders.ProviderFor(domain)
 	if err != nil {
 		return err
 	}
 
+	// ip identifiers cannot be validated with dns-01 (RFC 8738 s 7)
+	if identifier.Type == acme.IdentifierTypeIp && provider.AcmeChallengeType() == acme.ChallengeTypeDns01 {
+		return fmt.Errorf("challenges: provider for ip identifier %s is dns-01 which cannot validate ip addresses", identifier.Value)
+	}
+
 	// range to the correct challenge to solve based on ACME Challenge Type (from provider)
 	challengeType := provider.AcmeChallengeType()
 	var challenge acme.Challenge
@@ -157,7 +168,7 @@ func (service *Service) Solve(identifier acme.Identifier, challenges []acme.Chal
 	}
 
 	// sleep a little before first check
-	time.Sleep(7 * time.Second)
+	time.Sleep(randomness.ACMEPollInterval)
 
 	// monitor challenge status using exponential backoff
 	challCheckFunc := func() error {
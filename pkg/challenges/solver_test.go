@@ -0,0 +1,160 @@
+package challenges
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/acme/acmetest"
+	"certwarden-backend/pkg/challenges/dns_checker"
+	"certwarden-backend/pkg/challenges/providers"
+	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/challenges/providers/tlsalpn01internal"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// testApp implements application (and acme.App) for tests
+type testApp struct {
+	logger     *zap.SugaredLogger
+	httpClient *http.Client
+	ctx        context.Context
+	wg         *sync.WaitGroup
+}
+
+func (app *testApp) GetConfigFilenameWithPath() string     { return "" }
+func (app *testApp) GetLogger() *zap.SugaredLogger         { return app.logger }
+func (app *testApp) GetShutdownContext() context.Context   { return app.ctx }
+func (app *testApp) GetShutdownWaitGroup() *sync.WaitGroup { return app.wg }
+func (app *testApp) GetOutputter() *output.Service         { return nil }
+func (app *testApp) GetHttpClient() *http.Client           { return app.httpClient }
+
+// providerPort returns the port the internal provider for domain is listening on
+func providerPort(t *testing.T, service *Service, domain string) int {
+	t.Helper()
+
+	p, err := service.DNSIdentifierProviders.ProviderFor(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, ok := p.Service.(interface{ Addr() net.Addr })
+	if !ok {
+		t.Fatalf("provider for %s is not an internal server", domain)
+	}
+
+	return server.Addr().(*net.TCPAddr).Port
+}
+
+func TestChallenges_Solve(t *testing.T) {
+	// poll the local acme server quickly
+	pollInterval := randomness.ACMEPollInterval
+	randomness.ACMEPollInterval = 50 * time.Millisecond
+	t.Cleanup(func() { randomness.ACMEPollInterval = pollInterval })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := &testApp{
+		logger: zaptest.NewLogger(t).Sugar(),
+		ctx:    ctx,
+		wg:     new(sync.WaitGroup),
+	}
+	t.Cleanup(func() {
+		cancel()
+		app.wg.Wait()
+	})
+
+	// challenges service with an internal provider of each type (no dns checking), each
+	// listening on any available port
+	skipCheckWait := 0
+	anyPort := 0
+	service, err := NewService(app, &Config{
+		DnsCheckerConfig: dns_checker.Config{SkipCheckWaitSeconds: &skipCheckWait},
+		ProviderConfigs: providers.Config{
+			Http01InternalConfigs: []providers.ConfigManagerHttp01Internal{{
+				InternalConfig: providers.InternalConfig{Domains: []string{"http.example.com", "192.0.2.20"}},
+				Config:         &http01internal.Config{Port: &anyPort},
+			}},
+			TlsAlpn01InternalConfigs: []providers.ConfigManagerTlsAlpn01Internal{{
+				InternalConfig: providers.InternalConfig{Domains: []string{"tls.example.com", "192.0.2.21"}},
+				Config:         &tlsalpn01internal.Config{Port: &anyPort},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to make challenges service (%s)", err)
+	}
+
+	server := acmetest.NewServer(t, acmetest.Config{
+		HTTP01Port:    providerPort(t, service, "http.example.com"),
+		TLSALPN01Port: providerPort(t, service, "tls.example.com"),
+	})
+	app.httpClient = server.Client()
+
+	// acme service and account
+	acmeService, err := acme.NewService(app, server.DirectoryURL())
+	if err != nil {
+		t.Fatalf("failed to make acme service (%s)", err)
+	}
+	for i := 0; acmeService.DirectoryRawResponse() == nil; i++ {
+		if i >= 100 {
+			t.Fatal("acme service directory did not load")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct, err := acmeService.NewAccount(acme.NewAccountPayload{TosAgreed: true}, privateKey)
+	if err != nil {
+		t.Fatalf("failed to create account (%s)", err)
+	}
+	key := acme.AccountKey{Key: privateKey, Kid: *acct.Location}
+
+	for _, value := range []string{"http.example.com", "192.0.2.20", "tls.example.com", "192.0.2.21"} {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			order, err := acmeService.NewOrder(acme.NewOrderPayload{Identifiers: acme.IdentifierSlice{acme.NewIdentifier(value)}}, key)
+			if err != nil {
+				t.Fatalf("failed to create order (%s)", err)
+			}
+			auth, err := acmeService.GetAuth(order.Authorizations[0], key)
+			if err != nil {
+				t.Fatalf("failed to get authorization (%s)", err)
+			}
+
+			err = service.Solve(auth.Identifier, auth.Challenges, key, acmeService)
+			if err != nil {
+				t.Fatalf("solve failed (%s)", err)
+			}
+
+			auth, err = acmeService.GetAuth(order.Authorizations[0], key)
+			if err != nil {
+				t.Fatalf("failed to get authorization (%s)", err)
+			}
+			if auth.Status != "valid" {
+				for _, chall := range auth.Challenges {
+					if chall.Error != nil {
+						t.Logf("%s challenge error: %s", chall.Type, chall.Error)
+					}
+				}
+				t.Errorf("authorization status %s after solve, expected valid", auth.Status)
+			}
+
+			order, err = acmeService.GetOrder(order.Location, key)
+			if err != nil || order.Status != "ready" {
+				t.Errorf("order status %s after solve, expected ready (%v)", order.Status, err)
+			}
+		})
+	}
+}
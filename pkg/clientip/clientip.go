@@ -0,0 +1,32 @@
+package clientip
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// The app's trusted proxy middleware replaces a forwarded request's RemoteAddr with
+// the client's address before any handler runs, so RemoteAddr is all that is needed
+// to find the client.
+
+// Addr returns the ip address of the client that sent r. If it cannot be determined,
+// an invalid (zero) address is returned.
+func Addr(r *http.Request) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addrPort.Addr().Unmap()
+}
+
+// String returns the ip address of the client that sent r, as a string. If the address
+// cannot be determined, r's RemoteAddr is returned as-is.
+func String(r *http.Request) string {
+	addr := Addr(r)
+	if !addr.IsValid() {
+		return r.RemoteAddr
+	}
+
+	return addr.String()
+}
@@ -0,0 +1,34 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantAddr   bool
+		want       string
+	}{
+		{"ipv4", "192.0.2.1:1234", true, "192.0.2.1"},
+		{"ipv6", "[2001:db8::1]:1234", true, "2001:db8::1"},
+		{"ipv4 mapped ipv6", "[::ffff:192.0.2.1]:1234", true, "192.0.2.1"},
+		{"no port", "192.0.2.1", false, "192.0.2.1"},
+		{"not an address", "pipe", false, "pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{RemoteAddr: tt.remoteAddr}
+
+			if got := Addr(r); got.IsValid() != tt.wantAddr {
+				t.Errorf("Addr() = %s, expected valid %t", got, tt.wantAddr)
+			}
+			if got := String(r); got != tt.want {
+				t.Errorf("String() = '%s', expected '%s'", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,31 @@
 package job_manager
 
 // do updates the job to assign it to a worker and then executes the internal 'real'
-// job
-func (mgr *Manager[V]) doJob(job V, workerID int) {
+// job. If the job is no longer waiting (i.e., it was removed), it is not executed and
+// false is returned.
+func (mgr *Manager[V]) doJob(job V, workerID int) bool {
 	// move job from waiting to working
 	mgr.Lock()
+	found := false
 	for i, waitingJ := range mgr.waitingJobs {
 		if waitingJ.Equal(job) {
 			// remove from waiting
 			mgr.waitingJobs[i] = mgr.waitingJobs[len(mgr.waitingJobs)-1]
 			mgr.waitingJobs = mgr.waitingJobs[:len(mgr.waitingJobs)-1]
 
-			// add to worker
+			// add to worker (the waiting job, in case job was removed and an Equal job re-added)
+			job = waitingJ
 			mgr.workingJobs[workerID] = job
+			found = true
+			break
 		}
-
 	}
 	mgr.Unlock()
 
+	if !found {
+		return false
+	}
+
 	// run job
 	job.Do(workerID)
 
@@ -26,4 +34,6 @@ func (mgr *Manager[V]) doJob(job V, workerID int) {
 	var zeroVal V
 	mgr.workingJobs[workerID] = zeroVal
 	mgr.Unlock()
+
+	return true
 }
@@ -74,8 +74,11 @@ func NewManager[V Job[V]](workerCount int, workLabel string, shutdownCtx context
 
 				case highJob := <-mgr.highJobsChan:
 					logger.Debugf("%s worker %d: start high priority job (%s)", workLabel, workerId, highJob.Description())
-					mgr.doJob(highJob, workerId)
-					logger.Debugf("%s worker %d: end high priority job (%s)", workLabel, workerId, highJob.Description())
+					if mgr.doJob(highJob, workerId) {
+						logger.Debugf("%s worker %d: end high priority job (%s)", workLabel, workerId, highJob.Description())
+					} else {
+						logger.Debugf("%s worker %d: skipped high priority job (%s) (removed from queue)", workLabel, workerId, highJob.Description())
+					}
 
 				case lowJob := <-mgr.lowJobsChan:
 				lower:
@@ -87,8 +90,11 @@ func NewManager[V Job[V]](workerCount int, workLabel string, shutdownCtx context
 
 						case highJob := <-mgr.highJobsChan:
 							logger.Debugf("%s worker %d: start high priority job (%s)", workLabel, workerId, highJob.Description())
-							mgr.doJob(highJob, workerId)
-							logger.Debugf("%s worker %d: end high priority job (%s)", workLabel, workerId, highJob.Description())
+							if mgr.doJob(highJob, workerId) {
+								logger.Debugf("%s worker %d: end high priority job (%s)", workLabel, workerId, highJob.Description())
+							} else {
+								logger.Debugf("%s worker %d: skipped high priority job (%s) (removed from queue)", workLabel, workerId, highJob.Description())
+							}
 
 						default:
 							break lower
@@ -96,8 +102,11 @@ func NewManager[V Job[V]](workerCount int, workLabel string, shutdownCtx context
 					}
 
 					logger.Debugf("%s worker %d: start low priority job (%s)", workLabel, workerId, lowJob.Description())
-					mgr.doJob(lowJob, workerId)
-					logger.Debugf("%s worker %d: end low priority job (%s)", workLabel, workerId, lowJob.Description())
+					if mgr.doJob(lowJob, workerId) {
+						logger.Debugf("%s worker %d: end low priority job (%s)", workLabel, workerId, lowJob.Description())
+					} else {
+						logger.Debugf("%s worker %d: skipped low priority job (%s) (removed from queue)", workLabel, workerId, lowJob.Description())
+					}
 				}
 			}
 
@@ -0,0 +1,24 @@
+package job_manager
+
+import (
+	"errors"
+)
+
+var ErrRemoveJobNotWaiting = errors.New("job manager: cant remove job (not waiting in queue)")
+
+// RemoveJob removes the Equal job from the manager's queue so that it is never worked.
+// Jobs that a worker is already working can't be removed.
+func (mgr *Manager[V]) RemoveJob(job V) error {
+	mgr.Lock()
+	defer mgr.Unlock()
+
+	for i, waitingJ := range mgr.waitingJobs {
+		if waitingJ.Equal(job) {
+			// remove from waiting (workers skip jobs that are no longer waiting)
+			mgr.waitingJobs = append(mgr.waitingJobs[:i], mgr.waitingJobs[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrRemoveJobNotWaiting
+}
@@ -0,0 +1,76 @@
+package job_manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// testJob blocks until release is closed (if set) and records that it was done
+type testJob struct {
+	id      int
+	release chan struct{}
+	done    chan int
+}
+
+func (j *testJob) Description() string    { return "test job" }
+func (j *testJob) IsHighPriority() bool   { return false }
+func (j *testJob) Equal(j2 *testJob) bool { return j != nil && j2 != nil && j.id == j2.id }
+func (j *testJob) Do(workerID int) {
+	if j.release != nil {
+		<-j.release
+	}
+	j.done <- j.id
+}
+
+func TestRemoveJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	mgr := NewManager[*testJob](1, "test", ctx, wg, zap.NewNop().Sugar())
+	done := make(chan int, 3)
+
+	// occupy the only worker so the other jobs wait
+	release := make(chan struct{})
+	_ = mgr.AddJob(&testJob{id: 1, release: release, done: done})
+	for mgr.AllCurrentJobs().WorkingJobs[0] == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	_ = mgr.AddJob(&testJob{id: 2, done: done})
+	_ = mgr.AddJob(&testJob{id: 3, done: done})
+
+	if err := mgr.RemoveJob(&testJob{id: 2}); err != nil {
+		t.Fatalf("failed to remove waiting job (%s)", err)
+	}
+	if err := mgr.RemoveJob(&testJob{id: 1}); err != ErrRemoveJobNotWaiting {
+		t.Fatalf("removed working job (err: %v)", err)
+	}
+
+	close(release)
+
+	for _, want := range []int{1, 3} {
+		select {
+		case got := <-done:
+			if got != want {
+				t.Fatalf("job %d done, want job %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for job %d", want)
+		}
+	}
+
+	// removed job never runs
+	select {
+	case got := <-done:
+		t.Fatalf("job %d done after all expected jobs", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
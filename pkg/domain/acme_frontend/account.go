@@ -0,0 +1,83 @@
+package acme_frontend
+
+// Account statuses (RFC 8555 7.1.6)
+const (
+	accountStatusValid       = "valid"
+	accountStatusDeactivated = "deactivated"
+)
+
+// Account is an account registered by a client of the ACME front-end. Each
+// account is bound to exactly one certificate and may only order names that
+// are part of that certificate.
+type Account struct {
+	ID            int
+	CertificateID int
+	Jwk           string
+	JwkThumbprint string
+	Status        string
+	Contact       []string
+	CreatedAt     int
+	UpdatedAt     int
+}
+
+// accountSummaryResponse is the app API (not ACME) response for an account
+type accountSummaryResponse struct {
+	ID        int      `json:"id"`
+	Status    string   `json:"status"`
+	Contact   []string `json:"contact"`
+	CreatedAt int      `json:"created_at"`
+	UpdatedAt int      `json:"updated_at"`
+}
+
+func (acct Account) summaryResponse() accountSummaryResponse {
+	return accountSummaryResponse{
+		ID:        acct.ID,
+		Status:    acct.Status,
+		Contact:   acct.Contact,
+		CreatedAt: acct.CreatedAt,
+		UpdatedAt: acct.UpdatedAt,
+	}
+}
+
+// NewAccountPayload is used to save a new account to storage
+type NewAccountPayload struct {
+	CertificateID int
+	Jwk           string
+	JwkThumbprint string
+	Contact       []string
+	CreatedAt     int
+	UpdatedAt     int
+}
+
+// AccountUpdatePayload is used to update an existing account in storage
+type AccountUpdatePayload struct {
+	ID        int
+	Status    *string
+	Contact   *[]string
+	UpdatedAt int
+}
+
+// EabKey is an external account binding key that permits a client to register
+// an account bound to the key's certificate
+type EabKey struct {
+	ID            int
+	CertificateID int
+	KeyID         string
+	HmacKey       string
+	CreatedAt     int
+}
+
+// eabKeyResponse is the app API (not ACME) response for an eab key
+type eabKeyResponse struct {
+	KeyID     string `json:"key_id"`
+	HmacKey   string `json:"hmac_key"`
+	CreatedAt int    `json:"created_at"`
+}
+
+func (eab EabKey) response() *eabKeyResponse {
+	return &eabKeyResponse{
+		KeyID:     eab.KeyID,
+		HmacKey:   eab.HmacKey,
+		CreatedAt: eab.CreatedAt,
+	}
+}
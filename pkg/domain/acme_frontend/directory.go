@@ -0,0 +1,146 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/clientip"
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/output"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// absoluteUrl returns the absolute url of the specified path, relative to the front-end's
+// base path. The scheme and host are taken from the request so the urls match however
+// the client reached the server. If a trusted proxy forwarded the request, the scheme
+// is the one the proxy reported (the trusted proxy middleware sets it on r's URL).
+func (service *Service) absoluteUrl(r *http.Request, path string) string {
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+
+	return scheme + "://" + r.Host + service.urlPath + path
+}
+
+// apiKeyPlaceholder is used in place of the api key in urls shown to the admin
+const apiKeyPlaceholder = "{api_key}"
+
+// apiKeyModePath returns the path (relative to the front-end's base path) of the api
+// key mode routes for the named certificate. Since the api key is part of the url, it
+// may be recorded by proxies and in access logs (this app redacts it in its own logs).
+// EAB should be preferred; this mode is for clients that don't support it and only
+// works with api keys that permit use in the url.
+func apiKeyModePath(certName, apiKey string) string {
+	return "/certificates/" + certName + "/" + apiKey
+}
+
+// certFromApiKeyParams returns the certificate named in the request's params, if the
+// params' api key is one of the certificate's api keys and that api key permits use in
+// the url (and by the client's source ip).
+func (service *Service) certFromApiKeyParams(r *http.Request) (certificates.Certificate, *acme.Error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	cert, err := service.storage.GetOneCertByName(params.ByName("name"))
+	if err != nil {
+		service.logger.Debugf("acme front-end: %s", err)
+		return certificates.Certificate{}, errUnauthorized("certificate name or api key is incorrect")
+	}
+
+	keys, err := api_keys.Candidates(service.storage, api_keys.Owner{Type: api_keys.OwnerCertificate, ID: cert.ID}, params.ByName("apikey"))
+	if err != nil {
+		service.logger.Errorf("acme front-end: %s", err)
+		return certificates.Certificate{}, errUnauthorized("certificate name or api key is incorrect")
+	}
+
+	source := clientip.Addr(r)
+	now := time.Now()
+	key, err := api_keys.Authenticate(keys, params.ByName("apikey"), true, source, now)
+	if err != nil {
+		service.logger.Debugf("acme front-end: %s", err)
+		return certificates.Certificate{}, errUnauthorized("certificate name or api key is incorrect")
+	}
+
+	// record use (failure is only logged)
+	sourceString := ""
+	if source.IsValid() {
+		sourceString = source.String()
+	}
+	err = service.storage.PutApiKeyLastUsed(key.ID, now.Unix(), sourceString)
+	if err != nil {
+		service.logger.Errorf("acme front-end: failed to update api key (id: %d) last used (%s)", key.ID, err)
+	}
+
+	return cert, nil
+}
+
+// directoryMeta is the meta field of the directory
+type directoryMeta struct {
+	ExternalAccountRequired bool `json:"externalAccountRequired"`
+}
+
+// directoryResponse is the ACME directory (RFC 8555 7.1.1)
+type directoryResponse struct {
+	NewNonce   string        `json:"newNonce"`
+	NewAccount string        `json:"newAccount"`
+	NewOrder   string        `json:"newOrder"`
+	RevokeCert string        `json:"revokeCert"`
+	Meta       directoryMeta `json:"meta"`
+}
+
+// directory returns the directory. newAccountPath is relative to the front-end's base
+// path and varies depending on if the client is using eab or an api key.
+func (service *Service) directory(r *http.Request, newAccountPath string, eabRequired bool) directoryResponse {
+	return directoryResponse{
+		NewNonce:   service.absoluteUrl(r, "/new-nonce"),
+		NewAccount: service.absoluteUrl(r, newAccountPath),
+		NewOrder:   service.absoluteUrl(r, "/new-order"),
+		RevokeCert: service.absoluteUrl(r, "/revoke-cert"),
+		Meta: directoryMeta{
+			ExternalAccountRequired: eabRequired,
+		},
+	}
+}
+
+// GetDirectory returns the directory for clients using external account binding
+func (service *Service) GetDirectory(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		return writeJSON(w, http.StatusOK, service.directory(r, "/new-account", true))
+	})
+}
+
+// GetApiKeyDirectory returns the directory for clients that authenticate with a
+// certificate's api key in the url
+func (service *Service) GetApiKeyDirectory(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		cert, problem := service.certFromApiKeyParams(r)
+		if problem != nil {
+			return problem
+		}
+
+		params := httprouter.ParamsFromContext(r.Context())
+		newAccountPath := apiKeyModePath(cert.Name, params.ByName("apikey")) + "/new-account"
+
+		return writeJSON(w, http.StatusOK, service.directory(r, newAccountPath, false))
+	})
+}
+
+// NewNonce returns a fresh nonce (RFC 8555 7.2); serve sets the nonce on every response
+func (service *Service) NewNonce(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		w.Header().Set("Cache-Control", "no-store")
+
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		return nil
+	})
+}
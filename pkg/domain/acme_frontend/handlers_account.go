@@ -0,0 +1,330 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/storage"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// accountResponse is the ACME account object (RFC 8555 7.1.2)
+type accountResponse struct {
+	Status  string   `json:"status"`
+	Contact []string `json:"contact,omitempty"`
+	Orders  string   `json:"orders"`
+}
+
+// accountUrl returns the account's url, which is also its kid
+func (service *Service) accountUrl(r *http.Request, accountId int) string {
+	return service.absoluteUrl(r, "/account/"+strconv.Itoa(accountId))
+}
+
+// writeAccount writes the account object and its Location
+func (service *Service) writeAccount(w http.ResponseWriter, r *http.Request, status int, acct Account) *acme.Error {
+	w.Header().Set("Location", service.accountUrl(r, acct.ID))
+
+	return writeJSON(w, status, accountResponse{
+		Status:  acct.Status,
+		Contact: acct.Contact,
+		Orders:  service.accountUrl(r, acct.ID) + "/orders",
+	})
+}
+
+// validateContact verifies all contacts are mailto urls
+func validateContact(contact []string) *acme.Error {
+	for _, c := range contact {
+		if !strings.HasPrefix(c, "mailto:") || strings.Contains(c, ",") || len(c) <= len("mailto:") {
+			return newProblem(http.StatusBadRequest, errTypeInvalidContact, "contact must be a single mailto address")
+		}
+	}
+
+	return nil
+}
+
+// verifyEab verifies an external account binding (RFC 8555 7.3.4) and returns the id
+// of the certificate its key is bound to
+func (service *Service) verifyEab(raw json.RawMessage, outerJwk jsonWebKey, newAccountUrl string) (certId int, problem *acme.Error) {
+	errBadEab := newProblem(http.StatusUnauthorized, errTypeUnauthorized, "external account binding is invalid")
+
+	msg, header, payload, signature, err := decodeJws(raw)
+	if err != nil {
+		return -1, errMalformed("external account binding is not a valid flattened jws")
+	}
+
+	// header
+	var newHash func() hash.Hash
+	switch header.Algorithm {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	default:
+		return -1, newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "external account binding algorithm is not supported")
+	}
+	if header.KeyId == "" || header.Nonce != "" || header.Url != newAccountUrl {
+		return -1, errBadEab
+	}
+
+	// payload must be the account's key
+	innerJwk, err := parseJwk(payload)
+	if err != nil {
+		return -1, errBadEab
+	}
+	innerThumbprint, err := innerJwk.thumbprint()
+	if err != nil {
+		return -1, errBadEab
+	}
+	outerThumbprint, err := outerJwk.thumbprint()
+	if err != nil || innerThumbprint != outerThumbprint {
+		return -1, errBadEab
+	}
+
+	// key
+	eabKey, err := service.storage.GetAcmeFrontendEabKeyByKeyId(header.KeyId)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Error(err)
+			return -1, errServerInternal()
+		}
+		service.logger.Debugf("acme front-end: eab key id %s does not exist", header.KeyId)
+		return -1, errBadEab
+	}
+	hmacKey, err := base64.RawURLEncoding.DecodeString(eabKey.HmacKey)
+	if err != nil {
+		service.logger.Error(err)
+		return -1, errServerInternal()
+	}
+
+	// signature
+	mac := hmac.New(newHash, hmacKey)
+	_, _ = mac.Write([]byte(msg.Protected + "." + msg.Payload))
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return -1, errBadEab
+	}
+
+	return eabKey.CertificateID, nil
+}
+
+// newAccountRequest is the payload of a new-account request
+type newAccountRequest struct {
+	Contact                []string        `json:"contact"`
+	OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
+}
+
+// newAccount creates an account (RFC 8555 7.3). If apiKeyCert is nil, the request
+// must contain an external account binding, which determines the account's
+// certificate.
+func (service *Service) newAccount(w http.ResponseWriter, r *http.Request, apiKeyCert *certificates.Certificate) *acme.Error {
+	request, problem := service.verifyRequest(r, true)
+	if problem != nil {
+		return problem
+	}
+
+	var payload newAccountRequest
+	err := json.Unmarshal(request.payload, &payload)
+	if err != nil {
+		return errMalformed("failed to parse new account request")
+	}
+
+	thumbprint, err := request.jwk.thumbprint()
+	if err != nil {
+		return errMalformed("jwk is invalid")
+	}
+
+	// return existing account, if there is one
+	existing, err := service.storage.GetAcmeFrontendAccountByThumbprint(thumbprint)
+	if err == nil {
+		if existing.Status != accountStatusValid {
+			return errUnauthorized("account is not valid")
+		}
+		return service.writeAccount(w, r, http.StatusOK, existing)
+	} else if !errors.Is(err, storage.ErrNoRecord) {
+		service.logger.Error(err)
+		return errServerInternal()
+	}
+
+	if payload.OnlyReturnExisting {
+		return newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "account does not exist")
+	}
+
+	problem = validateContact(payload.Contact)
+	if problem != nil {
+		return problem
+	}
+
+	// determine the certificate the account is bound to
+	var certId int
+	if apiKeyCert != nil {
+		certId = apiKeyCert.ID
+	} else {
+		if len(payload.ExternalAccountBinding) == 0 {
+			return newProblem(http.StatusUnauthorized, errTypeExternalAccountRequired, "external account binding is required")
+		}
+
+		certId, problem = service.verifyEab(payload.ExternalAccountBinding, *request.jwk, request.url)
+		if problem != nil {
+			return problem
+		}
+	}
+
+	// save
+	canonicalJwk, err := request.jwk.canonicalJson()
+	if err != nil {
+		return errMalformed("jwk is invalid")
+	}
+
+	contact := payload.Contact
+	if contact == nil {
+		contact = []string{}
+	}
+
+	now := int(time.Now().Unix())
+	acct, err := service.storage.PostNewAcmeFrontendAccount(NewAccountPayload{
+		CertificateID: certId,
+		Jwk:           string(canonicalJwk),
+		JwkThumbprint: thumbprint,
+		Contact:       contact,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return errServerInternal()
+	}
+
+	service.logger.Infof("acme front-end: new account %d registered for certificate %d", acct.ID, acct.CertificateID)
+
+	return service.writeAccount(w, r, http.StatusCreated, acct)
+}
+
+// NewAccount creates an account for a client using external account binding
+func (service *Service) NewAccount(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		return service.newAccount(w, r, nil)
+	})
+}
+
+// NewApiKeyAccount creates an account for a client that authenticated with a
+// certificate's api key in the url
+func (service *Service) NewApiKeyAccount(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		cert, problem := service.certFromApiKeyParams(r)
+		if problem != nil {
+			return problem
+		}
+
+		return service.newAccount(w, r, &cert)
+	})
+}
+
+// verifyAccountParam verifies the request is signed by the account in the url
+func (service *Service) verifyAccountParam(r *http.Request) (*signedRequest, *acme.Error) {
+	request, problem := service.verifyRequest(r, false)
+	if problem != nil {
+		return nil, problem
+	}
+
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if idParam != strconv.Itoa(request.account.ID) {
+		return nil, errUnauthorized("account does not match the request signer")
+	}
+
+	return request, nil
+}
+
+// accountUpdateRequest is the payload of an account update request
+type accountUpdateRequest struct {
+	Status  *string   `json:"status"`
+	Contact *[]string `json:"contact"`
+}
+
+// PostAccount returns the account (POST-as-GET) or updates its contact or
+// deactivates it (RFC 8555 7.3.2 & 7.3.6)
+func (service *Service) PostAccount(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyAccountParam(r)
+		if problem != nil {
+			return problem
+		}
+
+		// POST-as-GET
+		if len(request.payload) == 0 {
+			return service.writeAccount(w, r, http.StatusOK, *request.account)
+		}
+
+		var payload accountUpdateRequest
+		err := json.Unmarshal(request.payload, &payload)
+		if err != nil {
+			return errMalformed("failed to parse account update request")
+		}
+
+		if payload.Status != nil && *payload.Status != accountStatusDeactivated {
+			return errMalformed("account status may only be updated to deactivated")
+		}
+		if payload.Contact != nil {
+			problem = validateContact(*payload.Contact)
+			if problem != nil {
+				return problem
+			}
+		}
+
+		// nothing to update
+		if payload.Status == nil && payload.Contact == nil {
+			return service.writeAccount(w, r, http.StatusOK, *request.account)
+		}
+
+		acct, err := service.storage.PutAcmeFrontendAccount(AccountUpdatePayload{
+			ID:        request.account.ID,
+			Status:    payload.Status,
+			Contact:   payload.Contact,
+			UpdatedAt: int(time.Now().Unix()),
+		})
+		if err != nil {
+			service.logger.Error(err)
+			return errServerInternal()
+		}
+
+		return service.writeAccount(w, r, http.StatusOK, acct)
+	})
+}
+
+// accountOrdersResponse is the ACME orders list (RFC 8555 7.1.2.1)
+type accountOrdersResponse struct {
+	Orders []string `json:"orders"`
+}
+
+// PostAccountOrders returns the list of the account's orders. Only orders still held
+// in memory are included.
+func (service *Service) PostAccountOrders(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyAccountParam(r)
+		if problem != nil {
+			return problem
+		}
+
+		response := accountOrdersResponse{
+			Orders: []string{},
+		}
+		for _, orderId := range service.state.getAccountOrderIds(request.account.ID) {
+			response.Orders = append(response.Orders, service.absoluteUrl(r, "/order/"+orderId))
+		}
+
+		return writeJSON(w, http.StatusOK, response)
+	})
+}
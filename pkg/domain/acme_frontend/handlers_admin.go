@@ -0,0 +1,189 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"certwarden-backend/pkg/storage"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// certFromIdParam fetches the certificate specified by the certid param
+func (service *Service) certFromIdParam(r *http.Request) (certificates.Certificate, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("certid")
+	certId, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return certificates.Certificate{}, output.JsonErrValidationFailed(err)
+	}
+
+	cert, err := service.storage.GetOneCertById(certId)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return certificates.Certificate{}, output.JsonErrNotFound(err)
+		}
+		service.logger.Error(err)
+		return certificates.Certificate{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return cert, nil
+}
+
+// certAcmeFrontendResponse is the front-end configuration of a certificate
+type certAcmeFrontendResponse struct {
+	output.JsonResponse
+	AcmeFrontend struct {
+		DirectoryURL       string                   `json:"directory_url"`
+		ApiKeyDirectoryURL string                   `json:"api_key_directory_url,omitempty"`
+		ExternalAccountKey *eabKeyResponse          `json:"external_account_binding"`
+		Accounts           []accountSummaryResponse `json:"accounts"`
+	} `json:"acme_frontend"`
+}
+
+// GetCertAcmeFrontend returns the certificate's external account binding key (if any) and
+// the accounts that are bound to the certificate
+func (service *Service) GetCertAcmeFrontend(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	cert, outErr := service.certFromIdParam(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	eabKey, err := service.storage.GetAcmeFrontendEabKeyByCert(cert.ID)
+	if err != nil && !errors.Is(err, storage.ErrNoRecord) {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	accounts, err := service.storage.GetAcmeFrontendAccountsByCert(cert.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	apiKeys, err := service.storage.GetApiKeysByOwner(api_keys.Owner{Type: api_keys.OwnerCertificate, ID: cert.ID})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	// the api key directory is only usable if at least one api key permits the url
+	viaUrl := false
+	for _, apiKey := range apiKeys {
+		if apiKey.ViaUrl {
+			viaUrl = true
+			break
+		}
+	}
+
+	// write response
+	response := &certAcmeFrontendResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.AcmeFrontend.DirectoryURL = service.absoluteUrl(r, "/directory")
+	if viaUrl {
+		// api keys are only stored hashed, so the client fills in the placeholder
+		response.AcmeFrontend.ApiKeyDirectoryURL = service.absoluteUrl(r, apiKeyModePath(cert.Name, apiKeyPlaceholder)+"/directory")
+	}
+	if eabKey.KeyID != "" {
+		response.AcmeFrontend.ExternalAccountKey = eabKey.response()
+	}
+	response.AcmeFrontend.Accounts = []accountSummaryResponse{}
+	for _, acct := range accounts {
+		response.AcmeFrontend.Accounts = append(response.AcmeFrontend.Accounts, acct.summaryResponse())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type eabKeyPostResponse struct {
+	output.JsonResponse
+	ExternalAccountKey *eabKeyResponse `json:"external_account_binding"`
+}
+
+// PostNewCertEabKey generates a new external account binding key for the certificate. If the
+// certificate already has a key, it is replaced (accounts that were already registered are
+// not affected).
+func (service *Service) PostNewCertEabKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	cert, outErr := service.certFromIdParam(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	keyId, err := randomness.GenerateApiKey()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	hmacKey, err := randomness.GenerateRandomByteSlice(32)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	eabKey, err := service.storage.PostAcmeFrontendEabKey(EabKey{
+		CertificateID: cert.ID,
+		KeyID:         keyId,
+		HmacKey:       base64.RawURLEncoding.EncodeToString(hmacKey),
+		CreatedAt:     int(time.Now().Unix()),
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &eabKeyPostResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = fmt.Sprintf("created acme front-end eab key for certificate (id: %d)", cert.ID)
+	response.ExternalAccountKey = eabKey.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteCertAcmeFrontend removes the certificate's external account binding key and
+// all of the accounts bound to the certificate
+func (service *Service) DeleteCertAcmeFrontend(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	cert, outErr := service.certFromIdParam(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	err := service.storage.DeleteAcmeFrontendByCert(cert.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &output.JsonResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = fmt.Sprintf("deleted acme front-end eab key and accounts for certificate (id: %d)", cert.ID)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
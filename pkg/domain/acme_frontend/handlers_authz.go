@@ -0,0 +1,51 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/output"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// authzResponse is the ACME authorization object (RFC 8555 7.1.4)
+type authzResponse struct {
+	Status     string          `json:"status"`
+	Expires    string          `json:"expires"`
+	Identifier acme.Identifier `json:"identifier"`
+	Challenges []struct{}      `json:"challenges"`
+	Wildcard   bool            `json:"wildcard,omitempty"`
+}
+
+// PostAuthorization returns the authorization (POST-as-GET). Authorizations are always
+// valid and have no challenges since Cert Warden validates the identifiers itself.
+func (service *Service) PostAuthorization(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		a, o, exists := service.state.getAuthz(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+		if !exists || o.accountId != request.account.ID {
+			return errNotFound("authorization does not exist")
+		}
+
+		response := authzResponse{
+			Status:     "valid",
+			Expires:    o.expires.UTC().Format(time.RFC3339),
+			Identifier: a.identifier,
+			Challenges: []struct{}{},
+		}
+
+		// wildcard authz identifier omits the wildcard prefix (RFC 8555 7.1.4)
+		if value, found := strings.CutPrefix(a.identifier.Value, "*."); found {
+			response.Identifier.Value = value
+			response.Wildcard = true
+		}
+
+		return writeJSON(w, http.StatusOK, response)
+	})
+}
@@ -0,0 +1,311 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/output"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// certIdentifiers returns the identifiers for all of the names on cert
+func certIdentifiers(cert certificates.Certificate) acme.IdentifierSlice {
+	identifiers := acme.IdentifierSlice{acme.NewIdentifier(strings.ToLower(cert.Subject))}
+	for _, name := range cert.SubjectAltNames {
+		identifiers = append(identifiers, acme.NewIdentifier(strings.ToLower(name)))
+	}
+
+	return identifiers
+}
+
+// sameIdentifiers returns true if both slices contain the same identifiers, ignoring
+// order and duplicates
+func sameIdentifiers(a, b acme.IdentifierSlice) bool {
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			return false
+		}
+	}
+	for _, id := range b {
+		if !slices.Contains(a, id) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// orderResponse is the ACME order object (RFC 8555 7.1.3)
+type orderResponse struct {
+	Status         string               `json:"status"`
+	Expires        string               `json:"expires"`
+	Identifiers    acme.IdentifierSlice `json:"identifiers"`
+	Authorizations []string             `json:"authorizations"`
+	Finalize       string               `json:"finalize"`
+	Certificate    string               `json:"certificate,omitempty"`
+	Error          *acme.Error          `json:"error,omitempty"`
+}
+
+// writeOrder writes the order object. If location is true, the Location header is
+// also set (for new-order and finalize).
+func (service *Service) writeOrder(w http.ResponseWriter, r *http.Request, status int, o order, location bool) *acme.Error {
+	orderUrl := service.absoluteUrl(r, "/order/"+o.id)
+	if location {
+		w.Header().Set("Location", orderUrl)
+	}
+	if o.status == orderStatusProcessing {
+		w.Header().Set("Retry-After", "5")
+	}
+
+	response := orderResponse{
+		Status:         o.status,
+		Expires:        o.expires.UTC().Format(time.RFC3339),
+		Identifiers:    o.identifiers,
+		Authorizations: []string{},
+		Finalize:       orderUrl + "/finalize",
+		Error:          o.err,
+	}
+	for _, authzId := range o.authzIds {
+		response.Authorizations = append(response.Authorizations, service.absoluteUrl(r, "/authz/"+authzId))
+	}
+	if o.status == orderStatusValid {
+		response.Certificate = service.absoluteUrl(r, "/cert/"+o.id)
+	}
+
+	return writeJSON(w, status, response)
+}
+
+// newOrderRequest is the payload of a new-order request
+type newOrderRequest struct {
+	Identifiers []acme.Identifier `json:"identifiers"`
+	NotBefore   string            `json:"notBefore"`
+	NotAfter    string            `json:"notAfter"`
+}
+
+// NewOrder creates an order (RFC 8555 7.4). All identifiers must be names on the
+// account's certificate. Since Cert Warden solves any challenges itself when the
+// order is finalized, the new order is immediately ready.
+func (service *Service) NewOrder(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		var payload newOrderRequest
+		err := json.Unmarshal(request.payload, &payload)
+		if err != nil {
+			return errMalformed("failed to parse new order request")
+		}
+
+		if payload.NotBefore != "" || payload.NotAfter != "" {
+			return errMalformed("notBefore and notAfter are not supported")
+		}
+		if len(payload.Identifiers) == 0 {
+			return errMalformed("order must contain at least one identifier")
+		}
+
+		cert, err := service.storage.GetOneCertById(request.account.CertificateID)
+		if err != nil {
+			service.logger.Error(err)
+			return errServerInternal()
+		}
+		permitted := certIdentifiers(cert)
+
+		identifiers := acme.IdentifierSlice{}
+		for _, id := range payload.Identifiers {
+			if id.Type != acme.IdentifierTypeDns && id.Type != acme.IdentifierTypeIp {
+				return newProblem(http.StatusBadRequest, errTypeUnsupportedIdentifier, "identifier type "+string(id.Type)+" is not supported")
+			}
+
+			normalized := acme.NewIdentifier(strings.ToLower(id.Value))
+			if normalized.Type != id.Type {
+				return errMalformed("identifier " + id.Value + " does not match its type")
+			}
+			if !slices.Contains(permitted, normalized) {
+				return newProblem(http.StatusForbidden, errTypeRejectedIdentifier, "identifier "+id.Value+" is not permitted for this account")
+			}
+
+			if !slices.Contains(identifiers, normalized) {
+				identifiers = append(identifiers, normalized)
+			}
+		}
+
+		o, err := service.state.newOrder(request.account.ID, identifiers)
+		if err != nil {
+			service.logger.Error(err)
+			return errServerInternal()
+		}
+
+		return service.writeOrder(w, r, http.StatusCreated, o, true)
+	})
+}
+
+// accountOrder returns the order in the url, if it belongs to the account
+func (service *Service) accountOrder(r *http.Request, acct *Account) (order, *acme.Error) {
+	o, exists := service.state.getOrder(httprouter.ParamsFromContext(r.Context()).ByName("id"))
+	if !exists || o.accountId != acct.ID {
+		return order{}, errNotFound("order does not exist")
+	}
+
+	return o, nil
+}
+
+// PostOrder returns the order (POST-as-GET)
+func (service *Service) PostOrder(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		o, problem := service.accountOrder(r, request.account)
+		if problem != nil {
+			return problem
+		}
+
+		return service.writeOrder(w, r, http.StatusOK, o, false)
+	})
+}
+
+// finalizeRequest is the payload of a finalize request
+type finalizeRequest struct {
+	Csr string `json:"csr"`
+}
+
+// csrIdentifiers returns the identifiers for the names in csr, or a problem if the
+// csr contains names that cannot be identifiers
+func csrIdentifiers(csr *x509.CertificateRequest) (acme.IdentifierSlice, *acme.Error) {
+	if len(csr.EmailAddresses) > 0 || len(csr.URIs) > 0 {
+		return nil, newProblem(http.StatusBadRequest, errTypeBadCSR, "csr may only contain dns and ip names")
+	}
+
+	identifiers := acme.IdentifierSlice{}
+	if csr.Subject.CommonName != "" {
+		identifiers = append(identifiers, acme.NewIdentifier(strings.ToLower(csr.Subject.CommonName)))
+	}
+	for _, name := range csr.DNSNames {
+		identifiers = append(identifiers, acme.NewIdentifier(strings.ToLower(name)))
+	}
+	for _, ip := range csr.IPAddresses {
+		identifiers = append(identifiers, acme.NewIdentifier(ip.String()))
+	}
+
+	return identifiers, nil
+}
+
+// FinalizeOrder finalizes an order with the client's csr (RFC 8555 7.4). The certificate
+// is obtained from the account's certificate's issuer by a queued order fulfilling job
+// and the client polls the order until it is valid.
+func (service *Service) FinalizeOrder(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		o, problem := service.accountOrder(r, request.account)
+		if problem != nil {
+			return problem
+		}
+		if o.status != orderStatusReady {
+			return newProblem(http.StatusForbidden, errTypeOrderNotReady, "order is "+o.status)
+		}
+
+		// csr
+		var payload finalizeRequest
+		err := json.Unmarshal(request.payload, &payload)
+		if err != nil {
+			return errMalformed("failed to parse finalize request")
+		}
+		csrDer, err := base64.RawURLEncoding.DecodeString(payload.Csr)
+		if err != nil {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr is not base64url encoded")
+		}
+		csr, err := x509.ParseCertificateRequest(csrDer)
+		if err != nil {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "failed to parse csr")
+		}
+		err = csr.CheckSignature()
+		if err != nil {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr signature is invalid")
+		}
+		csrIds, problem := csrIdentifiers(csr)
+		if problem != nil {
+			return problem
+		}
+		if !sameIdentifiers(csrIds, o.identifiers) {
+			return newProblem(http.StatusBadRequest, errTypeBadCSR, "csr names do not match the order's identifiers")
+		}
+
+		// cert (for its issuer)
+		cert, err := service.storage.GetOneCertById(request.account.CertificateID)
+		if err != nil {
+			service.logger.Error(err)
+			return errServerInternal()
+		}
+
+		if !service.state.startProcessing(o.id) {
+			return newProblem(http.StatusForbidden, errTypeOrderNotReady, "order is not ready")
+		}
+
+		err = service.orders.QueueCertificateForCsr("acme front-end order "+o.id, cert, csrDer, func(acmeCert *acme.Certificate, err error) {
+			if err != nil {
+				service.logger.Errorf("acme front-end: failed to issue certificate for order %s of account %d (%s)", o.id, o.accountId, err)
+				service.state.finishProcessing(o.id, "", errServerInternal())
+				return
+			}
+
+			service.logger.Infof("acme front-end: issued certificate for order %s of account %d", o.id, o.accountId)
+			service.state.finishProcessing(o.id, acmeCert.PEM(), nil)
+		})
+		if err != nil {
+			// not queued, so the client may finalize again later
+			service.state.cancelProcessing(o.id)
+			if errors.Is(err, orders.ErrMaintenanceWindowClosed) {
+				service.logger.Infof("acme front-end: order %s of account %d not finalized (%s)", o.id, o.accountId, err)
+				return newProblem(http.StatusForbidden, errTypeOrderNotReady, err.Error())
+			}
+
+			service.logger.Errorf("acme front-end: failed to queue order %s of account %d (%s)", o.id, o.accountId, err)
+			return errServerInternal()
+		}
+
+		// return updated order
+		o, _ = service.state.getOrder(o.id)
+		return service.writeOrder(w, r, http.StatusOK, o, true)
+	})
+}
+
+// PostCertificate returns the order's certificate chain (RFC 8555 7.4.2)
+func (service *Service) PostCertificate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		o, problem := service.accountOrder(r, request.account)
+		if problem != nil {
+			return problem
+		}
+		if o.status != orderStatusValid {
+			return errNotFound("certificate does not exist")
+		}
+
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(o.certPem))
+
+		return nil
+	})
+}
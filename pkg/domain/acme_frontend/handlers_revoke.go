@@ -0,0 +1,93 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/output"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// revokeCertRequest is the payload of a revoke-cert request
+type revokeCertRequest struct {
+	Certificate string `json:"certificate"`
+	Reason      *int   `json:"reason"`
+}
+
+// RevokeCertificate revokes a certificate (RFC 8555 7.6). Only requests signed by an
+// account are accepted and all of the certificate's names must be permitted for the
+// account.
+func (service *Service) RevokeCertificate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	return service.serve(w, r, func(w http.ResponseWriter, r *http.Request) *acme.Error {
+		request, problem := service.verifyRequest(r, false)
+		if problem != nil {
+			return problem
+		}
+
+		var payload revokeCertRequest
+		err := json.Unmarshal(request.payload, &payload)
+		if err != nil {
+			return errMalformed("failed to parse revoke cert request")
+		}
+
+		// reason (RFC 5280 5.3.1; 7 is unused)
+		reason := 0
+		if payload.Reason != nil {
+			reason = *payload.Reason
+		}
+		if reason < 0 || reason > 10 || reason == 7 {
+			return newProblem(http.StatusBadRequest, errTypeBadRevocationReason, "revocation reason is invalid")
+		}
+
+		certDer, err := base64.RawURLEncoding.DecodeString(payload.Certificate)
+		if err != nil {
+			return errMalformed("certificate is not base64url encoded")
+		}
+		x509Cert, err := x509.ParseCertificate(certDer)
+		if err != nil {
+			return errMalformed("failed to parse certificate")
+		}
+
+		// all names must be permitted for the account
+		cert, err := service.storage.GetOneCertById(request.account.CertificateID)
+		if err != nil {
+			service.logger.Error(err)
+			return errServerInternal()
+		}
+		permitted := certIdentifiers(cert)
+
+		names := append([]string{x509Cert.Subject.CommonName}, x509Cert.DNSNames...)
+		for _, ip := range x509Cert.IPAddresses {
+			names = append(names, ip.String())
+		}
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(permitted, acme.NewIdentifier(strings.ToLower(name))) {
+				return errUnauthorized("certificate contains names that are not permitted for this account")
+			}
+		}
+
+		err = service.orders.RevokeExternalCertificate(cert, certDer, reason)
+		if err != nil {
+			service.logger.Errorf("acme front-end: failed to revoke certificate for account %d (%s)", request.account.ID, err)
+
+			// pass through problems from the upstream acme server
+			var acmeErr *acme.Error
+			if errors.As(err, &acmeErr) {
+				return acmeErr
+			}
+			return errServerInternal()
+		}
+
+		service.logger.Infof("acme front-end: account %d revoked certificate serial %s", request.account.ID, x509Cert.SerialNumber.Text(16))
+
+		w.WriteHeader(http.StatusOK)
+		return nil
+	})
+}
@@ -0,0 +1,162 @@
+package acme_frontend
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+var errJwkUnsupported = errors.New("jwk key type or curve is not supported")
+
+// jsonWebKey is a client's public key (RFC 7517)
+type jsonWebKey struct {
+	KeyType        string `json:"kty"`
+	PublicExponent string `json:"e,omitempty"`   // RSA
+	Modulus        string `json:"n,omitempty"`   // RSA
+	CurveName      string `json:"crv,omitempty"` // EC & OKP
+	CurvePointX    string `json:"x,omitempty"`   // EC & OKP
+	CurvePointY    string `json:"y,omitempty"`   // EC
+}
+
+// parseJwk unmarshals the raw json jwk
+func parseJwk(raw []byte) (jsonWebKey, error) {
+	var jwk jsonWebKey
+	err := json.Unmarshal(raw, &jwk)
+	if err != nil {
+		return jsonWebKey{}, err
+	}
+
+	return jwk, nil
+}
+
+// canonicalJson returns the json object containing only the required members of
+// the jwk, ordered lexicographically with no whitespace (RFC 7638 3). This is the
+// input for the thumbprint and is also used as the stored form of the jwk.
+func (jwk jsonWebKey) canonicalJson() ([]byte, error) {
+	// members are alphabetical in each struct to ensure the proper order
+	switch jwk.KeyType {
+	case "RSA":
+		return json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.PublicExponent, jwk.KeyType, jwk.Modulus})
+
+	case "EC":
+		return json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.CurveName, jwk.KeyType, jwk.CurvePointX, jwk.CurvePointY})
+
+	case "OKP":
+		return json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.CurveName, jwk.KeyType, jwk.CurvePointX})
+
+	default:
+		// break to final error return
+	}
+
+	return nil, errJwkUnsupported
+}
+
+// thumbprint returns the base64url encoded SHA-256 thumbprint of the jwk (RFC 7638)
+func (jwk jsonWebKey) thumbprint() (string, error) {
+	canonical, err := jwk.canonicalJson()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// publicKey returns the crypto.PublicKey the jwk represents
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode(jwk.Modulus)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.PublicExponent)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || n.BitLen() < 2048 {
+			return nil, errors.New("jwk rsa key is not acceptable")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.CurveName {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errJwkUnsupported
+		}
+
+		x, err := decode(jwk.CurvePointX)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.CurvePointY)
+		if err != nil {
+			return nil, err
+		}
+
+		pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// ECDH conversion validates the point is on the curve
+		_, err = pub.ECDH()
+		if err != nil {
+			return nil, err
+		}
+
+		return pub, nil
+
+	case "OKP":
+		if jwk.CurveName != "Ed25519" {
+			return nil, errJwkUnsupported
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.CurvePointX)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("jwk ed25519 key is the wrong size")
+		}
+
+		return ed25519.PublicKey(x), nil
+
+	default:
+		// break to final error return
+	}
+
+	return nil, errJwkUnsupported
+}
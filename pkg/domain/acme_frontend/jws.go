@@ -0,0 +1,262 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/storage"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxRequestBodySize limits how much of a request body is read
+const maxRequestBodySize = 1 << 20
+
+// jwsMessage is a flattened JWS (RFC 7515 7.2.2), which is what ACME requires
+type jwsMessage struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// jwsProtectedHeader is the decoded protected header of a jwsMessage
+type jwsProtectedHeader struct {
+	Algorithm string          `json:"alg"`
+	Nonce     string          `json:"nonce"`
+	Url       string          `json:"url"`
+	Jwk       json.RawMessage `json:"jwk"`
+	KeyId     string          `json:"kid"`
+}
+
+// signedRequest is an ACME request whose signature has been verified
+type signedRequest struct {
+	// payload is empty for POST-as-GET
+	payload []byte
+	// url is the url the client signed
+	url string
+
+	// exactly one of the following is set, depending on if the request was
+	// signed with a jwk or an account's kid
+	jwk     *jsonWebKey
+	account *Account
+}
+
+// decodeJws unmarshals and decodes the pieces of a JWS. It does not verify anything.
+func decodeJws(data []byte) (msg jwsMessage, header jwsProtectedHeader, payload []byte, signature []byte, err error) {
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return
+	}
+
+	headerJson, err := base64.RawURLEncoding.DecodeString(msg.Protected)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(headerJson, &header)
+	if err != nil {
+		return
+	}
+
+	payload, err = base64.RawURLEncoding.DecodeString(msg.Payload)
+	if err != nil {
+		return
+	}
+
+	signature, err = base64.RawURLEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// verifySignature verifies the signature of signingInput using the specified
+// alg and public key
+func verifySignature(alg string, pub crypto.PublicKey, signingInput []byte, signature []byte) *acme.Error {
+	errBadSig := errMalformed("jws signature is invalid")
+
+	switch alg {
+	case "RS256":
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		hashed := sha256.Sum256(signingInput)
+		if rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, hashed[:], signature) != nil {
+			return errBadSig
+		}
+
+		return nil
+
+	case "ES256", "ES384", "ES512":
+		ecPub, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		// hash must correspond to the curve
+		var hashed []byte
+		switch {
+		case alg == "ES256" && ecPub.Curve.Params().Name == "P-256":
+			h := sha256.Sum256(signingInput)
+			hashed = h[:]
+		case alg == "ES384" && ecPub.Curve.Params().Name == "P-384":
+			h := sha512.Sum384(signingInput)
+			hashed = h[:]
+		case alg == "ES512" && ecPub.Curve.Params().Name == "P-521":
+			h := sha512.Sum512(signingInput)
+			hashed = h[:]
+		default:
+			return errBadSig
+		}
+
+		// signature is r || s, each zero padded to the curve's octet length
+		octetLength := (ecPub.Curve.Params().BitSize + 7) >> 3
+		if len(signature) != 2*octetLength {
+			return errBadSig
+		}
+		r := new(big.Int).SetBytes(signature[:octetLength])
+		s := new(big.Int).SetBytes(signature[octetLength:])
+
+		if !ecdsa.Verify(ecPub, hashed, r, s) {
+			return errBadSig
+		}
+
+		return nil
+
+	case "EdDSA":
+		edPub, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return errBadSig
+		}
+
+		if !ed25519.Verify(edPub, signingInput, signature) {
+			return errBadSig
+		}
+
+		return nil
+
+	default:
+		// break to final error return
+	}
+
+	return newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "jws algorithm "+alg+" is not supported")
+}
+
+// verifyRequest reads and verifies the JWS body of the request (RFC 8555 6.2 - 6.5). If
+// useJwk is true, the request must be signed with a jwk (i.e., new-account), otherwise it
+// must be signed by a valid account's kid.
+func (service *Service) verifyRequest(r *http.Request, useJwk bool) (*signedRequest, *acme.Error) {
+	// content type
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/jose+json" {
+		return nil, newProblem(http.StatusUnsupportedMediaType, errTypeMalformed, "content-type must be application/jose+json")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		return nil, errMalformed("failed to read request body")
+	}
+
+	msg, header, payload, signature, err := decodeJws(body)
+	if err != nil {
+		return nil, errMalformed("request body is not a valid flattened jws")
+	}
+
+	// nonce
+	if !service.nonces.useNonce(header.Nonce) {
+		return nil, newProblem(http.StatusBadRequest, errTypeBadNonce, "jws nonce is invalid")
+	}
+
+	// url must match the request
+	if header.Url != service.absoluteUrl(r, strings.TrimPrefix(r.URL.Path, service.urlPath)) {
+		return nil, errUnauthorized("jws url does not match the request url")
+	}
+
+	// alg
+	if header.Algorithm == "" || header.Algorithm == "none" || strings.HasPrefix(header.Algorithm, "HS") {
+		return nil, newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, "jws algorithm is not acceptable")
+	}
+
+	request := &signedRequest{
+		payload: payload,
+		url:     header.Url,
+	}
+
+	// key (jwk XOR kid)
+	var pub crypto.PublicKey
+	if useJwk {
+		if len(header.Jwk) == 0 || header.KeyId != "" {
+			return nil, errMalformed("jws must be signed with a jwk")
+		}
+
+		jwk, err := parseJwk(header.Jwk)
+		if err != nil {
+			return nil, errMalformed("jws jwk is invalid")
+		}
+		pub, err = jwk.publicKey()
+		if err != nil {
+			return nil, newProblem(http.StatusBadRequest, errTypeBadSignatureAlgorithm, err.Error())
+		}
+
+		request.jwk = &jwk
+	} else {
+		if len(header.Jwk) != 0 || header.KeyId == "" {
+			return nil, errMalformed("jws must be signed with an account kid")
+		}
+
+		acctIdString, found := strings.CutPrefix(header.KeyId, service.absoluteUrl(r, "/account/"))
+		if !found {
+			return nil, newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "jws kid is not an account url")
+		}
+		acctId, err := strconv.Atoi(acctIdString)
+		if err != nil {
+			return nil, newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "jws kid is not an account url")
+		}
+
+		account, err := service.storage.GetAcmeFrontendAccountById(acctId)
+		if err != nil {
+			if errors.Is(err, storage.ErrNoRecord) {
+				return nil, newProblem(http.StatusBadRequest, errTypeAccountDoesNotExist, "account does not exist")
+			}
+			service.logger.Error(err)
+			return nil, errServerInternal()
+		}
+		if account.Status != accountStatusValid {
+			return nil, errUnauthorized("account is not valid")
+		}
+
+		jwk, err := parseJwk([]byte(account.Jwk))
+		if err != nil {
+			service.logger.Error(err)
+			return nil, errServerInternal()
+		}
+		pub, err = jwk.publicKey()
+		if err != nil {
+			service.logger.Error(err)
+			return nil, errServerInternal()
+		}
+
+		request.account = &account
+	}
+
+	// signature
+	problem := verifySignature(header.Algorithm, pub, []byte(msg.Protected+"."+msg.Payload), signature)
+	if problem != nil {
+		return nil, problem
+	}
+
+	return request, nil
+}
@@ -0,0 +1,395 @@
+package acme_frontend
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/storage"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testUrlPath = "/certwarden/api/acme"
+
+// testFrontendStorage holds accounts by id
+type testFrontendStorage struct {
+	Storage
+	accounts map[int]Account
+}
+
+func (s *testFrontendStorage) GetAcmeFrontendAccountById(id int) (Account, error) {
+	acct, ok := s.accounts[id]
+	if !ok {
+		return Account{}, storage.ErrNoRecord
+	}
+	return acct, nil
+}
+
+// testJwk returns the jwk json of an ecdsa or ed25519 public key
+func testJwk(t *testing.T, pub crypto.PublicKey) []byte {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var jwk jsonWebKey
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk = jsonWebKey{
+			KeyType:     "EC",
+			CurveName:   pub.Curve.Params().Name,
+			CurvePointX: b64(pub.X.FillBytes(make([]byte, size))),
+			CurvePointY: b64(pub.Y.FillBytes(make([]byte, size))),
+		}
+	case ed25519.PublicKey:
+		jwk = jsonWebKey{KeyType: "OKP", CurveName: "Ed25519", CurvePointX: b64(pub)}
+	default:
+		t.Fatal("unsupported test key")
+	}
+
+	raw, err := jwk.canonicalJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// testSign returns the signature of signingInput (ecdsa keys always use sha256, so keys
+// can be signed with regardless of the header's alg)
+func testSign(t *testing.T, key crypto.Signer, signingInput []byte) []byte {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		hashed := sha256.Sum256(signingInput)
+		r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, signingInput)
+	}
+
+	t.Fatal("unsupported test key")
+	return nil
+}
+
+// testJwsRequest makes a flattened jws request
+func testJwsRequest(t *testing.T, key crypto.Signer, header map[string]any, payload []byte, url string) *http.Request {
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protected := base64.RawURLEncoding.EncodeToString(headerJson)
+	payloadB64 := base64.RawURLEncoding.EncodeToString(payload)
+	signature := testSign(t, key, []byte(protected+"."+payloadB64))
+
+	body, err := json.Marshal(jwsMessage{
+		Protected: protected,
+		Payload:   payloadB64,
+		Signature: base64.RawURLEncoding.EncodeToString(signature),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/jose+json")
+	return r
+}
+
+func TestAcmeFrontend_VerifyRequest(t *testing.T) {
+	nonces, err := newNonceStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acctKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &Service{
+		logger:  zap.NewNop().Sugar(),
+		urlPath: testUrlPath,
+		nonces:  nonces,
+		storage: &testFrontendStorage{accounts: map[int]Account{
+			1: {ID: 1, Jwk: string(testJwk(t, acctKey.Public())), Status: accountStatusValid},
+			2: {ID: 2, Jwk: string(testJwk(t, acctKey.Public())), Status: accountStatusDeactivated},
+		}},
+	}
+
+	const base = "http://example.com" + testUrlPath
+	newNonce := func() string {
+		nonce, err := nonces.newNonce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nonce
+	}
+
+	// a nonce that is used by a valid request, to then be replayed
+	usedNonce := newNonce()
+
+	tests := []struct {
+		name     string
+		key      crypto.Signer
+		header   map[string]any
+		url      string
+		useJwk   bool
+		wantType string // blank for success
+	}{
+		{
+			name:   "jwk",
+			key:    acctKey,
+			header: map[string]any{"alg": "ES256", "nonce": usedNonce, "url": base + "/new-account", "jwk": json.RawMessage(testJwk(t, acctKey.Public()))},
+			url:    base + "/new-account",
+			useJwk: true,
+		},
+		{
+			name:   "kid",
+			key:    acctKey,
+			header: map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:    base + "/new-order",
+		},
+		{
+			name:   "eddsa jwk",
+			key:    edKey,
+			header: map[string]any{"alg": "EdDSA", "nonce": newNonce(), "url": base + "/new-account", "jwk": json.RawMessage(testJwk(t, edKey.Public()))},
+			url:    base + "/new-account",
+			useJwk: true,
+		},
+		{
+			name:     "replayed nonce",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": usedNonce, "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeBadNonce,
+		},
+		{
+			name:     "bogus nonce",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": "bm90LWEtbm9uY2U", "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeBadNonce,
+		},
+		{
+			name:     "wrong url",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-account", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeUnauthorized,
+		},
+		{
+			name:     "alg none",
+			key:      acctKey,
+			header:   map[string]any{"alg": "none", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeBadSignatureAlgorithm,
+		},
+		{
+			name:     "alg hmac",
+			key:      acctKey,
+			header:   map[string]any{"alg": "HS256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeBadSignatureAlgorithm,
+		},
+		{
+			name:     "alg does not match curve",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES384", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "alg does not match key type",
+			key:      acctKey,
+			header:   map[string]any{"alg": "EdDSA", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "kid when jwk required",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-account", "kid": base + "/account/1"},
+			url:      base + "/new-account",
+			useJwk:   true,
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "jwk when kid required",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "jwk": json.RawMessage(testJwk(t, acctKey.Public()))},
+			url:      base + "/new-order",
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "jwk and kid",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-account", "kid": base + "/account/1", "jwk": json.RawMessage(testJwk(t, acctKey.Public()))},
+			url:      base + "/new-account",
+			useJwk:   true,
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "kid not an account url",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": "http://other.example.com/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeAccountDoesNotExist,
+		},
+		{
+			name:     "kid account does not exist",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/9"},
+			url:      base + "/new-order",
+			wantType: errTypeAccountDoesNotExist,
+		},
+		{
+			name:     "kid account deactivated",
+			key:      acctKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/2"},
+			url:      base + "/new-order",
+			wantType: errTypeUnauthorized,
+		},
+		{
+			name:     "signed by a different key than kid",
+			key:      otherKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"},
+			url:      base + "/new-order",
+			wantType: errTypeMalformed,
+		},
+		{
+			name:     "signed by a different key than jwk",
+			key:      otherKey,
+			header:   map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-account", "jwk": json.RawMessage(testJwk(t, acctKey.Public()))},
+			url:      base + "/new-account",
+			useJwk:   true,
+			wantType: errTypeMalformed,
+		},
+	}
+
+	for _, test := range tests {
+		r := testJwsRequest(t, test.key, test.header, []byte(`{}`), test.url)
+		request, problem := service.verifyRequest(r, test.useJwk)
+
+		if test.wantType == "" {
+			if problem != nil {
+				t.Errorf("%s: unexpected problem (%s)", test.name, problem)
+				continue
+			}
+			if test.useJwk && (request.jwk == nil || request.account != nil) {
+				t.Errorf("%s: request should have a jwk and no account", test.name)
+			}
+			if !test.useJwk && (request.account == nil || request.account.ID != 1 || request.jwk != nil) {
+				t.Errorf("%s: request should have account 1 and no jwk", test.name)
+			}
+			if string(request.payload) != `{}` || request.url != test.url {
+				t.Errorf("%s: request payload or url is wrong", test.name)
+			}
+			continue
+		}
+
+		if problem == nil {
+			t.Errorf("%s: expected %s problem, request was accepted", test.name, test.wantType)
+		} else if !strings.HasSuffix(problem.Type, ":"+test.wantType) {
+			t.Errorf("%s: expected %s problem, got %s", test.name, test.wantType, problem.Type)
+		}
+	}
+
+	// content type
+	r := testJwsRequest(t, acctKey, map[string]any{"alg": "ES256", "nonce": newNonce(), "url": base + "/new-order", "kid": base + "/account/1"}, []byte(`{}`), base+"/new-order")
+	r.Header.Set("Content-Type", "application/json")
+	_, problem := service.verifyRequest(r, false)
+	if problem == nil || problem.Status != http.StatusUnsupportedMediaType {
+		t.Errorf("wrong content type was not rejected with %d", http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestAcmeFrontend_Nonces(t *testing.T) {
+	nonces, err := newNonceStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// single use
+	nonce, err := nonces.newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nonces.useNonce(nonce) {
+		t.Fatal("new nonce rejected")
+	}
+	if nonces.useNonce(nonce) {
+		t.Error("nonce accepted twice")
+	}
+
+	// tampered
+	nonce, _ = nonces.newNonce()
+	raw, _ := base64.RawURLEncoding.DecodeString(nonce)
+	raw[nonceIssuedLen] ^= 0xff
+	if nonces.useNonce(base64.RawURLEncoding.EncodeToString(raw)) {
+		t.Error("tampered nonce accepted")
+	}
+
+	// from another store (e.g., before a restart)
+	otherNonces, _ := newNonceStore()
+	nonce, _ = otherNonces.newNonce()
+	if nonces.useNonce(nonce) {
+		t.Error("nonce from another store accepted")
+	}
+
+	// expired
+	raw = binary.BigEndian.AppendUint64(nil, uint64(time.Now().Add(-nonceLifetime-time.Minute).UnixNano()))
+	raw = append(raw, make([]byte, nonceRandomLen)...)
+	raw = append(raw, nonces.nonceMac(raw)...)
+	if nonces.useNonce(base64.RawURLEncoding.EncodeToString(raw)) {
+		t.Error("expired nonce accepted")
+	}
+
+	// used nonces are bounded, and evicted ones still can't be replayed
+	unused, _ := nonces.newNonce()
+	first, _ := nonces.newNonce()
+	if !nonces.useNonce(first) {
+		t.Fatal("new nonce rejected")
+	}
+	for range maxUsedNonces {
+		nonce, _ := nonces.newNonce()
+		if !nonces.useNonce(nonce) {
+			t.Fatal("new nonce rejected")
+		}
+	}
+	if len(nonces.used) > maxUsedNonces {
+		t.Errorf("%d used nonces stored, max is %d", len(nonces.used), maxUsedNonces)
+	}
+	if nonces.useNonce(first) {
+		t.Error("evicted nonce accepted again")
+	}
+	if nonces.useNonce(unused) {
+		t.Error("nonce issued before the evicted nonce was accepted")
+	}
+	nonce, _ = nonces.newNonce()
+	if !nonces.useNonce(nonce) {
+		t.Error("new nonce rejected after eviction")
+	}
+}
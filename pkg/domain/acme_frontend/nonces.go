@@ -0,0 +1,131 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/randomness"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Nonces are stateless so that clients can't consume memory by requesting them. Each
+// nonce is its issue time and some random bytes, authenticated with an hmac using a
+// secret that is generated on start. Only nonces that have been used are stored (to
+// prevent replay), and that store has a fixed size.
+
+const (
+	// nonceLifetime is how long an issued nonce remains usable
+	nonceLifetime = 1 * time.Hour
+
+	// maxUsedNonces is the number of used nonces remembered; once full, the oldest used
+	// nonce is forgotten and all nonces issued at or before it are rejected
+	maxUsedNonces = 10_000
+
+	// nonce lengths: issued at (unix nano) || random || truncated hmac
+	nonceIssuedLen = 8
+	nonceRandomLen = 8
+	nonceMacLen    = 16
+	nonceLen       = nonceIssuedLen + nonceRandomLen + nonceMacLen
+)
+
+// usedNonce is a nonce that has been used and the time it was issued
+type usedNonce struct {
+	nonce    string
+	issuedAt int64
+}
+
+// nonceStore issues nonces and tracks which have been used; each nonce may only be used once
+type nonceStore struct {
+	secret []byte
+
+	mu   sync.Mutex
+	used map[string]struct{}
+	// ring of used nonces in the order they were used, to evict the oldest
+	ring     []usedNonce
+	ringNext int
+	// nonces issued at or before this time (unix nano) are rejected
+	issuedFloor int64
+}
+
+// newNonceStore creates an empty nonceStore with a new secret
+func newNonceStore() (*nonceStore, error) {
+	secret, err := randomness.Generate32ByteSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &nonceStore{
+		secret: secret,
+		used:   make(map[string]struct{}),
+		ring:   make([]usedNonce, 0, maxUsedNonces),
+	}, nil
+}
+
+// nonceMac returns the truncated hmac of the nonce's issued at and random bytes
+func (ns *nonceStore) nonceMac(issuedAndRandom []byte) []byte {
+	mac := hmac.New(sha256.New, ns.secret)
+	mac.Write(issuedAndRandom)
+	return mac.Sum(nil)[:nonceMacLen]
+}
+
+// newNonce generates and returns a new nonce
+func (ns *nonceStore) newNonce() (string, error) {
+	random, err := randomness.GenerateRandomByteSlice(nonceRandomLen)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, 0, nonceLen)
+	nonce = binary.BigEndian.AppendUint64(nonce, uint64(time.Now().UnixNano()))
+	nonce = append(nonce, random...)
+	nonce = append(nonce, ns.nonceMac(nonce)...)
+
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+// useNonce returns true if the nonce was issued by this store, has not expired, and has
+// not been used before. The nonce is then recorded as used so it cannot be used again.
+func (ns *nonceStore) useNonce(nonce string) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(raw) != nonceLen {
+		return false
+	}
+
+	// authentic and not expired
+	mac := raw[nonceIssuedLen+nonceRandomLen:]
+	if !hmac.Equal(mac, ns.nonceMac(raw[:nonceIssuedLen+nonceRandomLen])) {
+		return false
+	}
+	issuedAt := int64(binary.BigEndian.Uint64(raw[:nonceIssuedLen]))
+	if time.Since(time.Unix(0, issuedAt)) > nonceLifetime {
+		return false
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+
+	// not used
+	if issuedAt <= ns.issuedFloor {
+		return false
+	}
+	if _, used := ns.used[nonce]; used {
+		return false
+	}
+
+	// record use, evicting the oldest use if full
+	if len(ns.ring) < maxUsedNonces {
+		ns.ring = append(ns.ring, usedNonce{nonce: nonce, issuedAt: issuedAt})
+	} else {
+		evicted := ns.ring[ns.ringNext]
+		delete(ns.used, evicted.nonce)
+		ns.issuedFloor = max(ns.issuedFloor, evicted.issuedAt)
+
+		ns.ring[ns.ringNext] = usedNonce{nonce: nonce, issuedAt: issuedAt}
+		ns.ringNext = (ns.ringNext + 1) % maxUsedNonces
+	}
+	ns.used[nonce] = struct{}{}
+
+	return true
+}
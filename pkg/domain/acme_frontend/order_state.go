@@ -0,0 +1,204 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/randomness"
+	"sync"
+	"time"
+)
+
+// orderLifetime is how long an order (and its certificate) is kept after creation
+const orderLifetime = 24 * time.Hour
+
+// Order statuses (RFC 8555 7.1.6)
+const (
+	orderStatusReady      = "ready"
+	orderStatusProcessing = "processing"
+	orderStatusValid      = "valid"
+	orderStatusInvalid    = "invalid"
+)
+
+// order is a client's order. Since Cert Warden solves the challenges itself, orders
+// are created in the ready state (i.e., all authorizations are already valid).
+type order struct {
+	id          string
+	accountId   int
+	identifiers acme.IdentifierSlice
+	authzIds    []string
+	status      string
+	expires     time.Time
+	err         *acme.Error
+	certPem     string
+}
+
+// authz is one of an order's authorizations
+type authz struct {
+	id         string
+	orderId    string
+	identifier acme.Identifier
+}
+
+// orderState holds the front-end's orders and authorizations in memory. Clients
+// that are mid-order when the app restarts will need to place a new order.
+type orderState struct {
+	mu     sync.RWMutex
+	orders map[string]*order
+	authzs map[string]*authz
+}
+
+// newOrderState creates an empty orderState
+func newOrderState() *orderState {
+	return &orderState{
+		orders: make(map[string]*order),
+		authzs: make(map[string]*authz),
+	}
+}
+
+// prune removes expired orders and their authorizations; the caller must
+// hold the write lock
+func (state *orderState) prune() {
+	now := time.Now()
+	for id, o := range state.orders {
+		if now.After(o.expires) {
+			for _, authzId := range o.authzIds {
+				delete(state.authzs, authzId)
+			}
+			delete(state.orders, id)
+		}
+	}
+}
+
+// newOrder creates and saves a new ready order with a valid authorization for
+// each identifier. A copy of the new order is returned.
+func (state *orderState) newOrder(accountId int, identifiers acme.IdentifierSlice) (order, error) {
+	orderId, err := randomness.GenerateApiKey()
+	if err != nil {
+		return order{}, err
+	}
+
+	o := &order{
+		id:          orderId,
+		accountId:   accountId,
+		identifiers: identifiers,
+		status:      orderStatusReady,
+		expires:     time.Now().Add(orderLifetime),
+	}
+
+	newAuthzs := []*authz{}
+	for _, identifier := range identifiers {
+		authzId, err := randomness.GenerateApiKey()
+		if err != nil {
+			return order{}, err
+		}
+		newAuthzs = append(newAuthzs, &authz{id: authzId, orderId: orderId, identifier: identifier})
+		o.authzIds = append(o.authzIds, authzId)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.prune()
+
+	state.orders[o.id] = o
+	for _, a := range newAuthzs {
+		state.authzs[a.id] = a
+	}
+
+	return *o, nil
+}
+
+// getOrder returns a copy of the specified order
+func (state *orderState) getOrder(id string) (order, bool) {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	o, exists := state.orders[id]
+	if !exists || time.Now().After(o.expires) {
+		return order{}, false
+	}
+
+	return *o, true
+}
+
+// getAccountOrderIds returns the ids of all of the account's orders
+func (state *orderState) getAccountOrderIds(accountId int) []string {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	ids := []string{}
+	for id, o := range state.orders {
+		if o.accountId == accountId && time.Now().Before(o.expires) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// getAuthz returns a copy of the specified authorization and its order
+func (state *orderState) getAuthz(id string) (authz, order, bool) {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	a, exists := state.authzs[id]
+	if !exists {
+		return authz{}, order{}, false
+	}
+
+	o, exists := state.orders[a.orderId]
+	if !exists || time.Now().After(o.expires) {
+		return authz{}, order{}, false
+	}
+
+	return *a, *o, true
+}
+
+// startProcessing moves the order from ready to processing. It returns false if
+// the order was not ready.
+func (state *orderState) startProcessing(id string) bool {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	o, exists := state.orders[id]
+	if !exists || o.status != orderStatusReady {
+		return false
+	}
+
+	o.status = orderStatusProcessing
+	return true
+}
+
+// cancelProcessing moves the order from processing back to ready (e.g., if it could
+// not be queued for issuance)
+func (state *orderState) cancelProcessing(id string) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	o, exists := state.orders[id]
+	if !exists || o.status != orderStatusProcessing {
+		return
+	}
+
+	o.status = orderStatusReady
+}
+
+// finishProcessing sets the result of processing the order. If problem is nil,
+// the order is valid, otherwise it is invalid.
+func (state *orderState) finishProcessing(id string, certPem string, problem *acme.Error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	o, exists := state.orders[id]
+	if !exists {
+		return
+	}
+
+	if problem != nil {
+		o.status = orderStatusInvalid
+		o.err = problem
+		return
+	}
+
+	o.status = orderStatusValid
+	o.certPem = certPem
+}
@@ -0,0 +1,96 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"net/http"
+)
+
+// ACME error types (RFC 8555 6.7)
+const (
+	errTypeAccountDoesNotExist     = "accountDoesNotExist"
+	errTypeBadCSR                  = "badCSR"
+	errTypeBadNonce                = "badNonce"
+	errTypeBadRevocationReason     = "badRevocationReason"
+	errTypeBadSignatureAlgorithm   = "badSignatureAlgorithm"
+	errTypeExternalAccountRequired = "externalAccountRequired"
+	errTypeInvalidContact          = "invalidContact"
+	errTypeMalformed               = "malformed"
+	errTypeOrderNotReady           = "orderNotReady"
+	errTypeRejectedIdentifier      = "rejectedIdentifier"
+	errTypeServerInternal          = "serverInternal"
+	errTypeUnauthorized            = "unauthorized"
+	errTypeUnsupportedIdentifier   = "unsupportedIdentifier"
+)
+
+// newProblem returns an ACME problem document
+func newProblem(status int, errType string, detail string) *acme.Error {
+	return &acme.Error{
+		Status: status,
+		Type:   "urn:ietf:params:acme:error:" + errType,
+		Detail: detail,
+	}
+}
+
+func errMalformed(detail string) *acme.Error {
+	return newProblem(http.StatusBadRequest, errTypeMalformed, detail)
+}
+
+func errUnauthorized(detail string) *acme.Error {
+	return newProblem(http.StatusForbidden, errTypeUnauthorized, detail)
+}
+
+func errNotFound(detail string) *acme.Error {
+	return newProblem(http.StatusNotFound, errTypeMalformed, detail)
+}
+
+func errServerInternal() *acme.Error {
+	return newProblem(http.StatusInternalServerError, errTypeServerInternal, "internal server error")
+}
+
+// acmeHandlerFunc is the signature of the ACME protocol handlers. Errors are returned
+// as problem documents instead of the app's usual json error.
+type acmeHandlerFunc func(w http.ResponseWriter, r *http.Request) *acme.Error
+
+// serve executes the ACME handler and writes any problem document it returns. All
+// responses get a fresh nonce and a link to the directory.
+func (service *Service) serve(w http.ResponseWriter, r *http.Request, handler acmeHandlerFunc) *output.JsonError {
+	nonce, err := service.nonces.newNonce()
+	if err != nil {
+		service.logger.Errorf("acme front-end: failed to generate nonce (%s)", err)
+	} else {
+		w.Header().Set("Replay-Nonce", nonce)
+	}
+	w.Header().Add("Link", `<`+service.absoluteUrl(r, "/directory")+`>;rel="index"`)
+
+	problem := handler(w, r)
+	if problem == nil {
+		return nil
+	}
+
+	service.logger.Debugf("acme front-end: client %s: %s %s: %s", r.RemoteAddr, r.Method, r.URL.Path, problem)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	err = json.NewEncoder(w).Encode(problem)
+	if err != nil {
+		service.logger.Errorf("acme front-end: failed to write problem (%s)", err)
+	}
+
+	return nil
+}
+
+// writeJSON writes obj as a json response with the specified status code
+func writeJSON(w http.ResponseWriter, status int, obj any) *acme.Error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return errServerInternal()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+
+	return nil
+}
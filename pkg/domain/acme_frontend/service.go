@@ -0,0 +1,99 @@
+package acme_frontend
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/output"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary acme front-end service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetAcmeFrontendStorage() Storage
+	GetOrdersService() *orders.Service
+	APIURLPath() string
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	GetOneCertById(id int) (cert certificates.Certificate, err error)
+	GetOneCertByName(name string) (cert certificates.Certificate, err error)
+
+	GetOneApiKeyById(id int) (api_keys.ApiKey, error)
+	GetApiKeysByOwner(owner api_keys.Owner) ([]api_keys.ApiKey, error)
+	PutApiKeyLastUsed(id int, unixLastUsedTime int64, lastUsedIP string) error
+
+	GetAcmeFrontendEabKeyByCert(certId int) (EabKey, error)
+	GetAcmeFrontendEabKeyByKeyId(keyId string) (EabKey, error)
+	PostAcmeFrontendEabKey(EabKey) (EabKey, error)
+
+	GetAcmeFrontendAccountById(id int) (Account, error)
+	GetAcmeFrontendAccountByThumbprint(thumbprint string) (Account, error)
+	GetAcmeFrontendAccountsByCert(certId int) ([]Account, error)
+	PostNewAcmeFrontendAccount(NewAccountPayload) (Account, error)
+	PutAcmeFrontendAccount(AccountUpdatePayload) (Account, error)
+
+	DeleteAcmeFrontendByCert(certId int) error
+}
+
+// Service struct for the ACME server front-end
+type Service struct {
+	logger  *zap.SugaredLogger
+	output  *output.Service
+	storage Storage
+	orders  *orders.Service
+
+	// urlPath is the path that all front-end routes are relative to
+	urlPath string
+
+	nonces *nonceStore
+	state  *orderState
+}
+
+// NewService creates a new acme front-end service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetAcmeFrontendStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	// orders (for issuance)
+	service.orders = app.GetOrdersService()
+	if service.orders == nil {
+		return nil, errServiceComponent
+	}
+
+	service.urlPath = app.APIURLPath() + "/acme"
+
+	// in memory state
+	var err error
+	service.nonces, err = newNonceStore()
+	if err != nil {
+		return nil, err
+	}
+	service.state = newOrderState()
+
+	return service, nil
+}
@@ -0,0 +1,107 @@
+package api_keys
+
+import (
+	"net/netip"
+	"time"
+)
+
+// OwnerType is the type of object an api key grants download access to
+type OwnerType int
+
+const (
+	OwnerCertificate OwnerType = iota
+	OwnerPrivateKey
+)
+
+// Owner is the certificate or private key that an api key belongs to
+type Owner struct {
+	Type OwnerType
+	ID   int
+}
+
+// ApiKey is a single named api key that permits downloading its owner via the
+// download api. Only a salted hash of the key is stored.
+type ApiKey struct {
+	ID             int
+	Owner          Owner
+	Name           string
+	KeyHash        string
+	ViaUrl         bool
+	AllowedSources []netip.Prefix
+	ExpiresAt      *time.Time
+	LastUsedAt     time.Time
+	LastUsedIP     string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Expired returns true if the api key has an expiration that is not after now
+func (key ApiKey) Expired(now time.Time) bool {
+	return key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)
+}
+
+// SourceAllowed returns true if the api key may be used by a client at source. If the
+// key has no allowed sources, any source is allowed.
+func (key ApiKey) SourceAllowed(source netip.Addr) bool {
+	if len(key.AllowedSources) == 0 {
+		return true
+	}
+
+	source = source.Unmap()
+	for _, prefix := range key.AllowedSources {
+		if prefix.Contains(source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiKeyResponse is the JSON response for an api key
+type apiKeyResponse struct {
+	ID             int      `json:"id"`
+	CertificateID  *int     `json:"certificate_id,omitempty"`
+	PrivateKeyID   *int     `json:"private_key_id,omitempty"`
+	Name           string   `json:"name"`
+	ViaUrl         bool     `json:"via_url"`
+	AllowedSources []string `json:"allowed_sources"`
+	ExpiresAt      *int64   `json:"expires_at"`
+	LastUsedAt     int64    `json:"last_used_at"`
+	LastUsedIP     string   `json:"last_used_ip"`
+	CreatedAt      int64    `json:"created_at"`
+	UpdatedAt      int64    `json:"updated_at"`
+	// api keys are only stored hashed, so the plaintext is only populated
+	// in the response to the request that generated the key
+	ApiKey string `json:"api_key,omitempty"`
+}
+
+func (key ApiKey) response() apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:             key.ID,
+		Name:           key.Name,
+		ViaUrl:         key.ViaUrl,
+		AllowedSources: []string{},
+		LastUsedAt:     key.LastUsedAt.Unix(),
+		LastUsedIP:     key.LastUsedIP,
+		CreatedAt:      key.CreatedAt.Unix(),
+		UpdatedAt:      key.UpdatedAt.Unix(),
+	}
+
+	switch key.Owner.Type {
+	case OwnerCertificate:
+		resp.CertificateID = &key.Owner.ID
+	case OwnerPrivateKey:
+		resp.PrivateKeyID = &key.Owner.ID
+	}
+
+	for _, prefix := range key.AllowedSources {
+		resp.AllowedSources = append(resp.AllowedSources, prefix.String())
+	}
+
+	if key.ExpiresAt != nil {
+		expiresAt := key.ExpiresAt.Unix()
+		resp.ExpiresAt = &expiresAt
+	}
+
+	return resp
+}
@@ -0,0 +1,82 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/storage"
+	"errors"
+	"net/netip"
+	"time"
+)
+
+var (
+	ErrApiKeyWrong            = errors.New("apikey is incorrect")
+	ErrApiKeyExpired          = errors.New("apikey is expired")
+	ErrApiKeyViaUrlDisallowed = errors.New("apikey found in url but not allowed")
+	ErrApiKeySourceDisallowed = errors.New("apikey is not allowed from client's source ip")
+)
+
+// AuthenticateStorage is the storage needed to find the api keys a client's key may be
+type AuthenticateStorage interface {
+	GetOneApiKeyById(id int) (ApiKey, error)
+	GetApiKeysByOwner(owner Owner) ([]ApiKey, error)
+}
+
+// Candidates returns the api keys of owner that apiKey may be. If apiKey contains its
+// id, this is at most the one key with that id, so only one hash needs to be checked.
+// Keys without an id (created before ids were included) may be any of owner's keys.
+func Candidates(store AuthenticateStorage, owner Owner, apiKey string) ([]ApiKey, error) {
+	id, _, ok := apikeyhash.Parse(apiKey)
+	if !ok {
+		return store.GetApiKeysByOwner(owner)
+	}
+
+	key, err := store.GetOneApiKeyById(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// keys of other owners are treated as not existing
+	if key.Owner != owner {
+		return nil, nil
+	}
+
+	return []ApiKey{key}, nil
+}
+
+// Authenticate returns the key in keys that apiKey matches, if that key may be used at
+// time now by a client at source. viaUrl indicates the client sent apiKey in the url
+// instead of a header. If apiKey contains an id, only the key with that id is checked.
+// If apiKey doesn't match any key, ErrApiKeyWrong is returned; otherwise the error
+// indicates why the matching key may not be used.
+func Authenticate(keys []ApiKey, apiKey string, viaUrl bool, source netip.Addr, now time.Time) (ApiKey, error) {
+	id, secret, hasId := apikeyhash.Parse(apiKey)
+
+	for _, key := range keys {
+		if hasId {
+			if key.ID != id || !apikeyhash.Matches(secret, key.KeyHash) {
+				continue
+			}
+		} else if !apikeyhash.Matches(apiKey, key.KeyHash) {
+			continue
+		}
+
+		if key.Expired(now) {
+			return ApiKey{}, ErrApiKeyExpired
+		}
+
+		if viaUrl && !key.ViaUrl {
+			return ApiKey{}, ErrApiKeyViaUrlDisallowed
+		}
+
+		if !key.SourceAllowed(source) {
+			return ApiKey{}, ErrApiKeySourceDisallowed
+		}
+
+		return key, nil
+	}
+
+	return ApiKey{}, ErrApiKeyWrong
+}
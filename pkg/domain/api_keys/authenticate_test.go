@@ -0,0 +1,181 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/storage"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestApiKeys_Authenticate(t *testing.T) {
+	hash, err := apikeyhash.Hash("abcdefghij0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherHash, err := apikeyhash.Hash("0123456789abcdefghij")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	lan := netip.MustParsePrefix("192.168.1.0/24")
+	inLan := netip.MustParseAddr("192.168.1.10")
+	outLan := netip.MustParseAddr("10.0.0.1")
+
+	tests := []struct {
+		name    string
+		key     ApiKey
+		viaUrl  bool
+		source  netip.Addr
+		wantErr error
+	}{
+		{"match", ApiKey{ID: 2, KeyHash: hash}, false, outLan, nil},
+		{"no expiry yet", ApiKey{ID: 2, KeyHash: hash, ExpiresAt: &future}, false, outLan, nil},
+		{"expired", ApiKey{ID: 2, KeyHash: hash, ExpiresAt: &past}, false, outLan, ErrApiKeyExpired},
+		{"via url allowed", ApiKey{ID: 2, KeyHash: hash, ViaUrl: true}, true, outLan, nil},
+		{"via url disallowed", ApiKey{ID: 2, KeyHash: hash}, true, outLan, ErrApiKeyViaUrlDisallowed},
+		{"source allowed", ApiKey{ID: 2, KeyHash: hash, AllowedSources: []netip.Prefix{lan}}, false, inLan, nil},
+		{"source mapped v6", ApiKey{ID: 2, KeyHash: hash, AllowedSources: []netip.Prefix{lan}}, false, netip.MustParseAddr("::ffff:192.168.1.10"), nil},
+		{"source disallowed", ApiKey{ID: 2, KeyHash: hash, AllowedSources: []netip.Prefix{lan}}, false, outLan, ErrApiKeySourceDisallowed},
+		{"source unknown", ApiKey{ID: 2, KeyHash: hash, AllowedSources: []netip.Prefix{lan}}, false, netip.Addr{}, ErrApiKeySourceDisallowed},
+		{"wrong", ApiKey{ID: 2, KeyHash: otherHash}, false, outLan, ErrApiKeyWrong},
+	}
+
+	for _, test := range tests {
+		keys := []ApiKey{{ID: 1, KeyHash: otherHash}, test.key}
+		key, err := Authenticate(keys, "abcdefghij0123456789", test.viaUrl, test.source, now)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: got error '%v', want '%v'", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && key.ID != 2 {
+			t.Errorf("%s: matched key id %d, want 2", test.name, key.ID)
+		}
+	}
+}
+
+func TestApiKeys_AuthenticateWithId(t *testing.T) {
+	// only the secret is hashed
+	hash, err := apikeyhash.Hash("abcdefghij0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []ApiKey{{ID: 1, KeyHash: hash}, {ID: 2, KeyHash: hash}}
+	now := time.Now()
+	source := netip.MustParseAddr("10.0.0.1")
+
+	key, err := Authenticate(keys, apikeyhash.Format(2, "abcdefghij0123456789"), false, source, now)
+	if err != nil || key.ID != 2 {
+		t.Errorf("key with id matched key id %d (%v), want 2", key.ID, err)
+	}
+
+	// the secret must match the key with the id
+	for _, wrong := range []string{
+		apikeyhash.Format(3, "abcdefghij0123456789"),
+		apikeyhash.Format(2, "0123456789abcdefghij"),
+		"cwak_2_",
+	} {
+		_, err = Authenticate(keys, wrong, false, source, now)
+		if !errors.Is(err, ErrApiKeyWrong) {
+			t.Errorf("key '%s': got error '%v', want '%v'", wrong, err, ErrApiKeyWrong)
+		}
+	}
+}
+
+// testCandidateStorage implements AuthenticateStorage for tests
+type testCandidateStorage struct {
+	keys []ApiKey
+	// byOwnerCalls counts lookups of all of an owner's keys
+	byOwnerCalls int
+}
+
+func (s *testCandidateStorage) GetOneApiKeyById(id int) (ApiKey, error) {
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return ApiKey{}, storage.ErrNoRecord
+}
+
+func (s *testCandidateStorage) GetApiKeysByOwner(owner Owner) ([]ApiKey, error) {
+	s.byOwnerCalls++
+	keys := []ApiKey{}
+	for _, key := range s.keys {
+		if key.Owner == owner {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func TestApiKeys_Candidates(t *testing.T) {
+	cert := Owner{Type: OwnerCertificate, ID: 1}
+	otherCert := Owner{Type: OwnerCertificate, ID: 2}
+	privateKey := Owner{Type: OwnerPrivateKey, ID: 1}
+	store := &testCandidateStorage{keys: []ApiKey{
+		{ID: 1, Owner: cert},
+		{ID: 2, Owner: cert},
+		{ID: 3, Owner: otherCert},
+		{ID: 4, Owner: privateKey},
+	}}
+
+	tests := []struct {
+		name   string
+		owner  Owner
+		apiKey string
+		want   []int
+	}{
+		{"id", cert, apikeyhash.Format(2, "secret"), []int{2}},
+		{"id of other owner", cert, apikeyhash.Format(3, "secret"), []int{}},
+		{"id of other owner type", cert, apikeyhash.Format(4, "secret"), []int{}},
+		{"id doesn't exist", cert, apikeyhash.Format(9, "secret"), []int{}},
+		{"without id", cert, "abcdefghij0123456789", []int{1, 2}},
+	}
+
+	for _, test := range tests {
+		store.byOwnerCalls = 0
+		keys, err := Candidates(store, test.owner, test.apiKey)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		ids := []int{}
+		for _, key := range keys {
+			ids = append(ids, key.ID)
+		}
+		if !slices.Equal(ids, test.want) {
+			t.Errorf("%s: got key ids %v, want %v", test.name, ids, test.want)
+		}
+
+		// keys with an id don't need all of the owner's keys
+		if _, _, hasId := apikeyhash.Parse(test.apiKey); hasId && store.byOwnerCalls > 0 {
+			t.Errorf("%s: all of the owner's keys were fetched for a key with an id", test.name)
+		}
+	}
+}
+
+func TestApiKeys_StandardizeAllowedSources(t *testing.T) {
+	got, err := standardizeAllowedSources([]string{"192.168.1.10", "10.1.2.3/8", "2001:db8::1", "::ffff:172.16.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"192.168.1.10/32", "10.0.0.0/8", "2001:db8::1/128", "172.16.0.1/32"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("source %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"", "example.com", "10.0.0.0/33", "fe80::1%eth0"} {
+		_, err = standardizeAllowedSources([]string{bad})
+		if err == nil {
+			t.Errorf("source '%s' accepted", bad)
+		}
+	}
+}
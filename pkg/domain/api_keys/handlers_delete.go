@@ -0,0 +1,57 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+)
+
+// DeleteCertApiKey deletes (revokes) one of a certificate's api keys
+func (service *Service) DeleteCertApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.certOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.deleteApiKey(w, r, owner)
+}
+
+// DeleteKeyApiKey deletes (revokes) one of a private key's api keys
+func (service *Service) DeleteKeyApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.keyOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.deleteApiKey(w, r, owner)
+}
+
+// deleteApiKey deletes one of owner's api keys
+func (service *Service) deleteApiKey(w http.ResponseWriter, r *http.Request, owner Owner) *output.JsonError {
+	// validate key exists and belongs to owner
+	key, outErr := service.getApiKey(r, owner)
+	if outErr != nil {
+		return outErr
+	}
+
+	// delete from storage
+	err := service.storage.DeleteApiKey(key.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("deleted api key (id: %d)", key.ID),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
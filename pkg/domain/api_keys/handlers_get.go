@@ -0,0 +1,59 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/output"
+	"net/http"
+)
+
+// apiKeysResponse provides the json response struct
+// to answer a query for an owner's api keys
+type apiKeysResponse struct {
+	output.JsonResponse
+	ApiKeys []apiKeyResponse `json:"api_keys"`
+}
+
+// GetCertApiKeys returns all of a certificate's api keys as JSON
+func (service *Service) GetCertApiKeys(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.certOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.getApiKeys(w, owner)
+}
+
+// GetKeyApiKeys returns all of a private key's api keys as JSON
+func (service *Service) GetKeyApiKeys(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.keyOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.getApiKeys(w, owner)
+}
+
+// getApiKeys writes all of owner's api keys as JSON
+func (service *Service) getApiKeys(w http.ResponseWriter, owner Owner) *output.JsonError {
+	keys, err := service.storage.GetApiKeysByOwner(owner)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &apiKeysResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.ApiKeys = []apiKeyResponse{}
+	for _, key := range keys {
+		response.ApiKeys = append(response.ApiKeys, key.response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// NewPayload is the struct for creating a new api key
+type NewPayload struct {
+	Owner          Owner     `json:"-"`
+	Name           *string   `json:"name"`
+	ViaUrl         *bool     `json:"via_url"`
+	AllowedSources *[]string `json:"allowed_sources"`
+	ExpiresAt      *int      `json:"expires_at"`
+	KeyHash        string    `json:"-"`
+	CreatedAt      int       `json:"-"`
+	UpdatedAt      int       `json:"-"`
+}
+
+// apiKeyResponseWrapper is the json response for a single api key
+type apiKeyResponseWrapper struct {
+	output.JsonResponse
+	ApiKey apiKeyResponse `json:"api_key"`
+}
+
+// PostNewCertApiKey creates a new api key for a certificate
+func (service *Service) PostNewCertApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.certOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.postNewApiKey(w, r, owner)
+}
+
+// PostNewKeyApiKey creates a new api key for a private key
+func (service *Service) PostNewKeyApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.keyOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.postNewApiKey(w, r, owner)
+}
+
+// postNewApiKey generates a new api key for owner, saves its hash to storage, and
+// writes the key to the client (the only time the plaintext key is available)
+func (service *Service) postNewApiKey(w http.ResponseWriter, r *http.Request, owner Owner) *output.JsonError {
+	var payload NewPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	payload.Owner = owner
+
+	// do validation
+	// name
+	if payload.Name == nil || !service.nameValid(owner, *payload.Name, nil) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// via url (default false)
+	if payload.ViaUrl == nil {
+		payload.ViaUrl = new(bool)
+	}
+	// allowed sources (default any)
+	if payload.AllowedSources == nil {
+		payload.AllowedSources = &[]string{}
+	}
+	*payload.AllowedSources, err = standardizeAllowedSources(*payload.AllowedSources)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	// expiration (0 or none = never)
+	if payload.ExpiresAt != nil && *payload.ExpiresAt < 0 {
+		service.logger.Debug(ErrExpiresAtBad)
+		return output.JsonErrValidationFailed(ErrExpiresAtBad)
+	}
+	// end validation
+
+	// generate the api key secret (the id is added once saved)
+	secret, err := randomness.GenerateApiKey()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.KeyHash, err = apikeyhash.Hash(secret)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save
+	newKey, err := service.storage.PostNewApiKey(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &apiKeyResponseWrapper{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created api key"
+	response.ApiKey = newKey.response()
+	// only time the plaintext api key is available
+	response.ApiKey.ApiKey = apikeyhash.Format(newKey.ID, secret)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// UpdatePayload is the struct for editing an existing api key. The key
+// itself cannot be changed, a new api key should be created instead.
+type UpdatePayload struct {
+	ID             int       `json:"-"`
+	Name           *string   `json:"name"`
+	ViaUrl         *bool     `json:"via_url"`
+	AllowedSources *[]string `json:"allowed_sources"`
+	ExpiresAt      *int      `json:"expires_at"`
+	UpdatedAt      int       `json:"-"`
+}
+
+// PutCertApiKey updates one of a certificate's api keys
+func (service *Service) PutCertApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.certOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.putApiKeyUpdate(w, r, owner)
+}
+
+// PutKeyApiKey updates one of a private key's api keys
+func (service *Service) PutKeyApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.keyOwnerFromParams(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.putApiKeyUpdate(w, r, owner)
+}
+
+// putApiKeyUpdate updates one of owner's api keys. Only fields received in the
+// payload (non-nil) are updated. An expires_at of 0 removes the key's expiration.
+func (service *Service) putApiKeyUpdate(w http.ResponseWriter, r *http.Request, owner Owner) *output.JsonError {
+	// parse payload
+	var payload UpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// id
+	key, outErr := service.getApiKey(r, owner)
+	if outErr != nil {
+		return outErr
+	}
+	payload.ID = key.ID
+	// name (optional)
+	if payload.Name != nil && !service.nameValid(owner, *payload.Name, &payload.ID) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// allowed sources (optional)
+	if payload.AllowedSources != nil {
+		*payload.AllowedSources, err = standardizeAllowedSources(*payload.AllowedSources)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// expiration (optional)
+	if payload.ExpiresAt != nil && *payload.ExpiresAt < 0 {
+		service.logger.Debug(ErrExpiresAtBad)
+		return output.JsonErrValidationFailed(ErrExpiresAtBad)
+	}
+	// ViaUrl does not need validation
+	// end validation
+
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	// save
+	updatedKey, err := service.storage.PutApiKeyUpdate(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &apiKeyResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated api key"
+	response.ApiKey = updatedKey.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/output"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary api keys service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetApiKeysStorage() Storage
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	GetOneCertById(id int) (cert certificates.Certificate, err error)
+	GetOneKeyById(id int) (private_keys.Key, error)
+
+	GetApiKeysByOwner(owner Owner) ([]ApiKey, error)
+	GetOneApiKeyById(id int) (ApiKey, error)
+
+	PostNewApiKey(NewPayload) (ApiKey, error)
+
+	PutApiKeyUpdate(UpdatePayload) (ApiKey, error)
+
+	DeleteApiKey(id int) error
+}
+
+// Service struct for api keys
+type Service struct {
+	logger  *zap.SugaredLogger
+	output  *output.Service
+	storage Storage
+}
+
+// NewService creates a new api keys service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetApiKeysStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	return service, nil
+}
@@ -0,0 +1,140 @@
+package api_keys
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ErrIdBad             = errors.New("api key id is invalid")
+	ErrOwnerIdBad        = errors.New("api key owner id is invalid")
+	ErrNameBad           = errors.New("api key name is not valid (or is already in use)")
+	ErrAllowedSourcesBad = errors.New("api key allowed sources are not valid (each must be an ip address or cidr)")
+	ErrExpiresAtBad      = errors.New("api key expiration is not valid (must be a unix time)")
+)
+
+// certOwnerFromParams returns the certificate Owner specified by the certid param
+func (service *Service) certOwnerFromParams(r *http.Request) (Owner, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("certid")
+	return service.owner(OwnerCertificate, idParam)
+}
+
+// keyOwnerFromParams returns the private key Owner specified by the id param
+func (service *Service) keyOwnerFromParams(r *http.Request) (Owner, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	return service.owner(OwnerPrivateKey, idParam)
+}
+
+// owner returns the Owner of ownerType with the id idParam, after confirming the
+// owner exists
+func (service *Service) owner(ownerType OwnerType, idParam string) (Owner, *output.JsonError) {
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrOwnerIdBad)
+		return Owner{}, output.JsonErrValidationFailed(ErrOwnerIdBad)
+	}
+
+	switch ownerType {
+	case OwnerCertificate:
+		_, err = service.storage.GetOneCertById(id)
+	case OwnerPrivateKey:
+		_, err = service.storage.GetOneKeyById(id)
+	default:
+		err = ErrOwnerIdBad
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return Owner{}, output.JsonErrNotFound(err)
+		}
+		service.logger.Error(err)
+		return Owner{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return Owner{Type: ownerType, ID: id}, nil
+}
+
+// getApiKey returns the api key specified by the apikeyid param, if it belongs to owner
+func (service *Service) getApiKey(r *http.Request, owner Owner) (ApiKey, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("apikeyid")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrIdBad)
+		return ApiKey{}, output.JsonErrValidationFailed(ErrIdBad)
+	}
+
+	key, err := service.storage.GetOneApiKeyById(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return ApiKey{}, output.JsonErrNotFound(fmt.Errorf("api key id %d not found", id))
+		}
+		service.logger.Error(err)
+		return ApiKey{}, output.JsonErrStorageGeneric(err)
+	}
+
+	// keys of other owners are treated as not existing
+	if key.Owner != owner {
+		service.logger.Debug(fmt.Errorf("api key id %d does not belong to the specified owner", id))
+		return ApiKey{}, output.JsonErrNotFound(fmt.Errorf("api key id %d not found", id))
+	}
+
+	return key, nil
+}
+
+// nameValid returns true if name is acceptable and not in use by another of owner's
+// keys. If apiKeyId is specified, the name is also accepted if that key is using it.
+func (service *Service) nameValid(owner Owner, name string, apiKeyId *int) bool {
+	// basic character/length check
+	if !validation.NameValid(name) {
+		return false
+	}
+
+	keys, err := service.storage.GetApiKeysByOwner(owner)
+	if err != nil {
+		return false
+	}
+
+	for _, key := range keys {
+		if key.Name == name && (apiKeyId == nil || key.ID != *apiKeyId) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// standardizeAllowedSources validates sources (ip addresses and/or cidrs) and returns
+// them in cidr form (a single address becomes a cidr containing only that address)
+func standardizeAllowedSources(sources []string) ([]string, error) {
+	prefixes := []string{}
+	for _, source := range sources {
+		// single address
+		addr, err := netip.ParseAddr(source)
+		if err == nil {
+			if addr.Zone() != "" {
+				return nil, ErrAllowedSourcesBad
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()).String())
+			continue
+		}
+
+		// cidr
+		prefix, err := netip.ParsePrefix(source)
+		if err != nil {
+			return nil, ErrAllowedSourcesBad
+		}
+		prefixes = append(prefixes, prefix.Masked().String())
+	}
+
+	return prefixes, nil
+}
@@ -4,19 +4,24 @@ import (
 	"certwarden-backend/pkg/challenges"
 	"certwarden-backend/pkg/datatypes/safecert"
 	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/acme_frontend"
 	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/domain/download"
+	"certwarden-backend/pkg/domain/maintenance_windows"
 	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/domain/private_cas"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage/sqlite"
 	"context"
 	"net/http"
+	"net/netip"
 	"sync"
 	"time"
 
@@ -53,28 +58,33 @@ type appLogger struct {
 
 // Application is the main app struct
 type Application struct {
-	restart           bool
-	config            *config
-	logger            *appLogger
-	output            *output.Service
-	backup            *backup.Service
-	shutdownContext   context.Context
-	shutdown          func(restart bool)
-	shutdownWaitgroup *sync.WaitGroup
-	httpsCert         *safecert.SafeCert
-	httpClient        *http.Client
-	router            http.Handler
-	storage           *sqlite.Storage
-	acmeServers       *acme_servers.Service
-	challenges        *challenges.Service
-	updater           *updater.Service
-	auth              *auth.Service
-	keys              *private_keys.Service
-	accounts          *acme_accounts.Service
-	authorizations    *authorizations.Service
-	orders            *orders.Service
-	certificates      *certificates.Service
-	download          *download.Service
+	restart            bool
+	config             *config
+	logger             *appLogger
+	output             *output.Service
+	backup             *backup.Service
+	shutdownContext    context.Context
+	shutdown           func(restart bool)
+	shutdownWaitgroup  *sync.WaitGroup
+	httpsCert          *safecert.SafeCert
+	httpClient         *http.Client
+	router             http.Handler
+	trustedProxies     []netip.Prefix
+	storage            *sqlite.Storage
+	acmeServers        *acme_servers.Service
+	challenges         *challenges.Service
+	updater            *updater.Service
+	auth               *auth.Service
+	keys               *private_keys.Service
+	accounts           *acme_accounts.Service
+	privateCAs         *private_cas.Service
+	maintenanceWindows *maintenance_windows.Service
+	authorizations     *authorizations.Service
+	orders             *orders.Service
+	certificates       *certificates.Service
+	download           *download.Service
+	apiKeys            *api_keys.Service
+	acmeFrontend       *acme_frontend.Service
 }
 
 // return various app parts which are used as needed by services
@@ -160,6 +170,12 @@ func (app *Application) GetAcmeServerStorage() acme_servers.Storage {
 func (app *Application) GetAccountStorage() acme_accounts.Storage {
 	return app.storage
 }
+func (app *Application) GetPrivateCAStorage() private_cas.Storage {
+	return app.storage
+}
+func (app *Application) GetMaintenanceWindowStorage() maintenance_windows.Storage {
+	return app.storage
+}
 func (app *Application) GetCertificatesStorage() certificates.Storage {
 	return app.storage
 }
@@ -169,6 +185,12 @@ func (app *Application) GetOrderStorage() orders.Storage {
 func (app *Application) GetDownloadStorage() download.Storage {
 	return app.storage
 }
+func (app *Application) GetApiKeysStorage() api_keys.Storage {
+	return app.storage
+}
+func (app *Application) GetAcmeFrontendStorage() acme_frontend.Storage {
+	return app.storage
+}
 
 //
 
@@ -184,6 +206,14 @@ func (app *Application) GetAcctsService() *acme_accounts.Service {
 	return app.accounts
 }
 
+func (app *Application) GetPrivateCAsService() *private_cas.Service {
+	return app.privateCAs
+}
+
+func (app *Application) GetMaintenanceWindowsService() *maintenance_windows.Service {
+	return app.maintenanceWindows
+}
+
 func (app *Application) GetAuthsService() *authorizations.Service {
 	return app.authorizations
 }
@@ -192,6 +222,10 @@ func (app *Application) GetCertificatesService() *certificates.Service {
 	return app.certificates
 }
 
+func (app *Application) GetOrdersService() *orders.Service {
+	return app.orders
+}
+
 // shutdown related
 func (app *Application) GetShutdownContext() context.Context {
 	return app.shutdownContext
@@ -4,14 +4,18 @@ import (
 	"certwarden-backend/pkg/challenges"
 	"certwarden-backend/pkg/datatypes/safecert"
 	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/acme_frontend"
 	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/domain/download"
+	"certwarden-backend/pkg/domain/maintenance_windows"
 	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/domain/private_cas"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage/sqlite"
@@ -145,7 +149,7 @@ func create() (*Application, error) {
 	}
 
 	// storage
-	app.storage, err = sqlite.OpenStorage(app)
+	app.storage, err = sqlite.OpenStorage(app, &app.config.Storage)
 	if err != nil {
 		app.logger.Errorf("failed to configure app storage (%s)", err)
 		return app, err
@@ -203,6 +207,20 @@ func create() (*Application, error) {
 		return app, err
 	}
 
+	// private cas service
+	app.privateCAs, err = private_cas.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app private cas (%s)", err)
+		return app, err
+	}
+
+	// maintenance windows service
+	app.maintenanceWindows, err = maintenance_windows.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app maintenance windows (%s)", err)
+		return app, err
+	}
+
 	// authorizations service
 	app.authorizations, err = authorizations.NewService(app)
 	if err != nil {
@@ -218,7 +236,7 @@ func create() (*Application, error) {
 	}
 
 	// orders service
-	app.orders, err = orders.NewService(app)
+	app.orders, err = orders.NewService(app, &app.config.Orders)
 	if err != nil {
 		app.logger.Errorf("failed to configure app orders (%s)", err)
 		return app, err
@@ -231,6 +249,27 @@ func create() (*Application, error) {
 		return app, err
 	}
 
+	// api keys service
+	app.apiKeys, err = api_keys.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app api keys (%s)", err)
+		return app, err
+	}
+
+	// acme front-end service
+	app.acmeFrontend, err = acme_frontend.NewService(app)
+	if err != nil {
+		app.logger.Errorf("failed to configure app acme front-end (%s)", err)
+		return app, err
+	}
+
+	// trusted proxies (for client addresses)
+	app.trustedProxies, err = parseTrustedProxies(app.config.TrustedProxies)
+	if err != nil {
+		app.logger.Errorf("failed to configure trusted proxies (%s)", err)
+		return app, err
+	}
+
 	// make router
 	app.makeRouterAndRoutes()
 
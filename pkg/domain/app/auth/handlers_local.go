@@ -1,12 +1,16 @@
 package auth
 
 import (
+	"certwarden-backend/pkg/clientip"
 	"certwarden-backend/pkg/domain/app/auth/session_manager"
 	"certwarden-backend/pkg/output"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -15,6 +19,9 @@ import (
 type localLoginPayload struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// TotpCode is required if the user is enrolled in totp (a recovery code
+	// may be used instead)
+	TotpCode string `json:"totp_code"`
 }
 
 // LocalPostLogin takes the loginPayload, looks up the username in storage
@@ -39,10 +46,23 @@ func (service *Service) LocalPostLogin(w http.ResponseWriter, r *http.Request) *
 			return output.JsonErrUnauthorized
 		}
 
+		// too many recent failures for this username or from this client (the attempt is
+		// reserved until it is evaluated)
+		clientIP := clientip.String(r)
+		retryAt, ok := service.local.loginLimiter.reserve(payload.Username, clientIP, time.Now())
+		if !ok {
+			wait := time.Until(retryAt)
+			service.logger.Infof("client %s: login failed (too many failed attempts, retry in %s)", r.RemoteAddr, wait.Round(time.Second))
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return output.JsonErrTooManyRequests
+		}
+		defer service.local.loginLimiter.release(payload.Username, clientIP)
+
 		// fetch the password hash from storage
-		user, err := service.local.storage.GetOneUserByName(payload.Username)
+		user, err := service.storage.GetOneUserByTypeAndName(session_manager.UserTypeLocal, payload.Username)
 		if err != nil {
 			service.logger.Infof("client %s: login failed (bad username: %s)", r.RemoteAddr, err)
+			service.loginFailed(payload.Username, clientIP)
 			return output.JsonErrUnauthorized
 		}
 
@@ -50,18 +70,49 @@ func (service *Service) LocalPostLogin(w http.ResponseWriter, r *http.Request) *
 		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(payload.Password))
 		if err != nil {
 			service.logger.Infof("client %s: login failed (bad password: %s)", r.RemoteAddr, err)
+			service.loginFailed(payload.Username, clientIP)
 			return output.JsonErrUnauthorized
 		}
 
-		// user and password now verified
+		// second factor (if enrolled)
+		if user.Totp.Enabled {
+			if payload.TotpCode == "" {
+				service.logger.Infof("client %s: login failed (totp code required for user '%s')", r.RemoteAddr, user.Username)
+				return jsonErrTotpRequired
+			}
+
+			used, ok := user.Totp.verify(payload.TotpCode, time.Now())
+			if !ok {
+				service.logger.Infof("client %s: login failed (bad totp code for user '%s')", r.RemoteAddr, user.Username)
+				service.loginFailed(payload.Username, clientIP)
+				return output.JsonErrUnauthorized
+			}
+
+			// save so the code can't be used again (fails if a parallel login used it first)
+			ok, err = service.saveTotpCodeUse(user.ID, used)
+			if err != nil {
+				service.logger.Errorf("client %s: login failed (storage error: %s)", r.RemoteAddr, err)
+				return output.JsonErrInternal(nil)
+			}
+			if !ok {
+				service.logger.Infof("client %s: login failed (totp code for user '%s' was already used)", r.RemoteAddr, user.Username)
+				service.loginFailed(payload.Username, clientIP)
+				return output.JsonErrUnauthorized
+			}
+		}
+
+		// user and password (and second factor) now verified
+		service.local.loginLimiter.recordSuccess(payload.Username)
+
 		// make extra func obj
 		extraFuncs := &localExtraFuncs{
 			dbUsername:     payload.Username,
-			storageService: service.local.storage,
+			role:           user.Role,
+			storageService: service.storage,
 		}
 
 		//make new session
-		auth, err := service.sessionManager.NewSession(user.Username, session_manager.UserTypeLocal, extraFuncs)
+		auth, err := service.sessionManager.NewSession(r, user.Username, session_manager.UserTypeLocal, string(user.Role), extraFuncs)
 		if err != nil {
 			service.logger.Errorf("client %s: login failed (internal error: %s)", r.RemoteAddr, err)
 			return output.JsonErrInternal(nil)
@@ -85,6 +136,12 @@ func (service *Service) LocalPostLogin(w http.ResponseWriter, r *http.Request) *
 		// log success
 		service.logger.Infof("client %s: user '%s' logged in", r.RemoteAddr, auth.UserTypeAndName())
 
+		// record login (failure shouldn't prevent login)
+		err = service.storage.PutUserLastLogin(user.ID, int(time.Now().Unix()))
+		if err != nil {
+			service.logger.Errorf("failed to record last login of user '%s' (%s)", auth.UserTypeAndName(), err)
+		}
+
 		return nil
 	}()
 
@@ -138,7 +195,7 @@ func (service *Service) LocalChangePassword(w http.ResponseWriter, r *http.Reque
 	}
 
 	// fetch the password hash from storage
-	user, err := service.local.storage.GetOneUserByName(auth.Username)
+	user, err := service.storage.GetOneUserByTypeAndName(session_manager.UserTypeLocal, auth.Username)
 	if err != nil {
 		// shouldn't be possible since header was valid
 		err = fmt.Errorf("client %s: password change for user '%s' failed (bad username: %s)", r.RemoteAddr, auth.UserTypeAndName(), err)
@@ -179,7 +236,7 @@ func (service *Service) LocalChangePassword(w http.ResponseWriter, r *http.Reque
 	}
 
 	// update password in storage
-	userId, err := service.local.storage.UpdateUserPassword(auth.Username, string(newPasswordHash))
+	userId, err := service.storage.UpdateUserPassword(auth.Username, string(newPasswordHash))
 	if err != nil {
 		err = fmt.Errorf("client %s: password change for user '%s' failed (storage error: %s)", r.RemoteAddr, auth.UserTypeAndName(), err)
 		service.logger.Error(err)
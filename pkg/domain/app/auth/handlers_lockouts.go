@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/output"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var ErrLockoutTypeBad = errors.New("lockout type is not valid (must be username or ip)")
+
+// loginFailuresResponse is the JSON response for a username's or IP's failed logins
+type loginFailuresResponse struct {
+	Type           loginSubjectType `json:"type"`
+	Subject        string           `json:"subject"`
+	FailedAttempts int              `json:"failed_attempts"`
+	LastFailureAt  int              `json:"last_failure_at"`
+	// RetryAt is when the next login attempt will be evaluated (0 if now)
+	RetryAt int `json:"retry_at"`
+	// LockedUntil is 0 if not locked out
+	LockedUntil int `json:"locked_until"`
+}
+
+func (f loginFailures) response(now time.Time) loginFailuresResponse {
+	response := loginFailuresResponse{
+		Type:           f.subjectType,
+		Subject:        f.subject,
+		FailedAttempts: f.count,
+		LastFailureAt:  int(f.lastFailureAt.Unix()),
+	}
+
+	if f.notBefore.After(now) {
+		response.RetryAt = int(f.notBefore.Unix())
+	}
+	if f.lockedUntil.After(now) {
+		response.LockedUntil = int(f.lockedUntil.Unix())
+	}
+
+	return response
+}
+
+// loginLockoutsResponse is the JSON response containing all failed login tracking
+type loginLockoutsResponse struct {
+	output.JsonResponse
+	Lockouts []loginFailuresResponse `json:"lockouts"`
+}
+
+// GetLoginLockouts returns all usernames and IPs with recent failed local logins,
+// including any that are currently delayed or locked out
+func (service *Service) GetLoginLockouts(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errors.New("auth: local login is not configured"))
+	}
+
+	now := time.Now()
+
+	// write response
+	response := &loginLockoutsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Lockouts = []loginFailuresResponse{}
+	for _, f := range service.local.loginLimiter.list(now) {
+		response.Lockouts = append(response.Lockouts, f.response(now))
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteLoginLockouts clears the failed login tracking (and any lockouts) of all
+// usernames and IPs
+func (service *Service) DeleteLoginLockouts(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errors.New("auth: local login is not configured"))
+	}
+
+	count := service.local.loginLimiter.clearAll()
+
+	service.logger.Infof("client %s: cleared all login lockouts (%d usernames and ips)", r.RemoteAddr, count)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("cleared login lockouts (%d)", count),
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteLoginLockout clears the failed login tracking (and any lockout) of the username
+// or IP specified by the type and subject params
+func (service *Service) DeleteLoginLockout(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	if !service.methodLocalEnabled() {
+		return output.JsonErrNotFound(errors.New("auth: local login is not configured"))
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	subjectType := loginSubjectType(params.ByName("type"))
+	subject := params.ByName("subject")
+
+	// validation
+	if subjectType != loginSubjectUsername && subjectType != loginSubjectIP {
+		service.logger.Debug(ErrLockoutTypeBad)
+		return output.JsonErrValidationFailed(ErrLockoutTypeBad)
+	}
+
+	if !service.local.loginLimiter.clear(subjectType, subject) {
+		return output.JsonErrNotFound(fmt.Errorf("no failed logins for %s '%s'", subjectType, subject))
+	}
+
+	service.logger.Infof("client %s: cleared login lockout of %s '%s'", r.RemoteAddr, subjectType, subject)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("cleared login lockout of %s '%s'", subjectType, subject),
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -203,6 +203,19 @@ func (service *Service) OIDCGetCallback(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Determine the user's role from the id token's claims; users without a role are rejected
+	oidcStateObj.role, err = idTokenRole(oidcStateObj.oidcIDToken, service.oidc.roleMapper)
+	if err != nil {
+		service.logger.Infof("client %s: oidc user '%s' is not authorized (%s)", r.RemoteAddr, oidcStateObj.oidcIDToken.Subject, err)
+		// redirect to frontend to try again
+		http.Redirect(w, r, oidcUnauthorizedErrorURL(oidcStateObj.callerRedirectUrl).String(), http.StatusFound)
+		return nil
+	}
+
+	claims := make(map[string]any)
+	_ = oidcStateObj.oidcIDToken.Claims(&claims)
+	oidcStateObj.displayName = oidcDisplayName(claims)
+
 	// update redirect uri to contain the state and code
 	queryValues := oidcStateObj.callerRedirectUrl.Query()
 	queryValues.Set("state", qStateString)
@@ -257,6 +270,8 @@ func (service *Service) OIDCLoginFinalize(w http.ResponseWriter, r *http.Request
 		ctxWithHttpClient: service.oidc.ctxWithHttpClient,
 		cfg:               service.oidc.oauth2Config,
 		idTokenVerifier:   service.oidc.idTokenVerifier,
+		roleMapper:        service.oidc.roleMapper,
+		role:              oidcStateObj.role,
 		token: &expectedToken{
 			AccessToken:  oidcStateObj.oauth2Token.AccessToken,
 			RefreshToken: oidcStateObj.oauth2Token.RefreshToken,
@@ -265,8 +280,20 @@ func (service *Service) OIDCLoginFinalize(w http.ResponseWriter, r *http.Request
 		},
 	}
 
+	// record the user (creating them if this is their first login)
+	_, err := service.storage.PutOIDCUserLogin(OIDCUserLoginPayload{
+		Username:    oidcStateObj.oidcIDToken.Subject,
+		DisplayName: oidcStateObj.displayName,
+		Role:        oidcStateObj.role,
+		LoginAt:     int(time.Now().Unix()),
+	})
+	if err != nil {
+		service.logger.Errorf("client %s: login failed (storage error: %s)", r.RemoteAddr, err)
+		return output.JsonErrInternal(nil)
+	}
+
 	// make new session
-	auth, err := service.sessionManager.NewSession(oidcStateObj.oidcIDToken.Subject, session_manager.UserTypeOIDC, extraFuncs)
+	auth, err := service.sessionManager.NewSession(r, oidcStateObj.oidcIDToken.Subject, session_manager.UserTypeOIDC, string(oidcStateObj.role), extraFuncs)
 	if err != nil {
 		service.logger.Errorf("client %s: login failed (internal error: %s)", r.RemoteAddr, err)
 		return output.JsonErrInternal(nil)
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// sessionsResponse is the JSON response containing active sessions
+type sessionsResponse struct {
+	output.JsonResponse
+	Sessions []session_manager.SessionInfo `json:"sessions"`
+}
+
+// GetAllSessions returns all of the active sessions
+func (service *Service) GetAllSessions(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// write response
+	response := &sessionsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Sessions = service.sessionManager.ListSessions(r)
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteSession revokes the session specified by the id param
+func (service *Service) DeleteSession(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+
+	info, found := service.sessionManager.DeleteSessionByID(id)
+	if !found {
+		service.logger.Debugf("session id %s not found", id)
+		return output.JsonErrNotFound(fmt.Errorf("session id %s not found", id))
+	}
+
+	service.logger.Infof("client %s: revoked session %s of user '%s|%s'", r.RemoteAddr, info.ID, info.UserType, info.Username)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("revoked session (id: %s)", info.ID),
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteUserSessions revokes all of the sessions of the user specified by the id param
+func (service *Service) DeleteUserSessions(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	count := service.sessionManager.DeleteUserSessions(user.Type, user.Username)
+
+	service.logger.Infof("client %s: revoked %d session(s) of user '%s|%s'", r.RemoteAddr, count, user.Type, user.Username)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("revoked %d session(s) of user (id: %d)", count, user.ID),
+	}
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ErrTokenIdBad        = errors.New("token id is invalid")
+	ErrTokenNameBad      = errors.New("token name is not valid (or is already in use)")
+	ErrTokenRoleBad      = errors.New("token role is not valid (it can't be higher than the user's role)")
+	ErrTokenExpiresAtBad = errors.New("token expiration is not valid (must be a future unix time)")
+	ErrTokenViaToken     = errors.New("personal access tokens can't be created with a personal access token")
+)
+
+// tokensResponse is the JSON response containing a user's personal access tokens
+type tokensResponse struct {
+	output.JsonResponse
+	Tokens []tokenResponse `json:"tokens"`
+}
+
+// tokenResponseWrapper is the JSON response for a single personal access token
+type tokenResponseWrapper struct {
+	output.JsonResponse
+	Token tokenResponse `json:"token"`
+}
+
+// currentUser returns the stored user that sent r
+func (service *Service) currentUser(r *http.Request, w http.ResponseWriter) (User, requestUser, *output.JsonError) {
+	reqUser, err := service.authenticate(r, w, "personal access tokens")
+	if err != nil {
+		return User{}, requestUser{}, output.JsonErrUnauthorized
+	}
+
+	user, err := service.storage.GetOneUserByTypeAndName(reqUser.userType, reqUser.username)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return User{}, requestUser{}, output.JsonErrNotFound(fmt.Errorf("user '%s' not found", reqUser.typeAndName()))
+		}
+		service.logger.Error(err)
+		return User{}, requestUser{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return user, reqUser, nil
+}
+
+// getUserToken returns the token specified by the idParamName param, if it belongs
+// to the user with userId
+func (service *Service) getUserToken(r *http.Request, userId int, idParamName string) (Token, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName(idParamName)
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrTokenIdBad)
+		return Token{}, output.JsonErrValidationFailed(ErrTokenIdBad)
+	}
+
+	token, err := service.storage.GetOneTokenById(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return Token{}, output.JsonErrNotFound(fmt.Errorf("token id %d not found", id))
+		}
+		service.logger.Error(err)
+		return Token{}, output.JsonErrStorageGeneric(err)
+	}
+
+	// tokens of other users are treated as not existing
+	if token.UserID != userId {
+		service.logger.Debug(fmt.Errorf("token id %d does not belong to user id %d", id, userId))
+		return Token{}, output.JsonErrNotFound(fmt.Errorf("token id %d not found", id))
+	}
+
+	return token, nil
+}
+
+// writeTokens writes the response containing all of the personal access tokens of the
+// user with userId
+func (service *Service) writeTokens(w http.ResponseWriter, userId int) *output.JsonError {
+	tokens, err := service.storage.GetUserTokens(userId)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	response := &tokensResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Tokens = []tokenResponse{}
+	for _, token := range tokens {
+		response.Tokens = append(response.Tokens, token.response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// GetMyTokens returns the personal access tokens of the logged in user
+func (service *Service) GetMyTokens(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, _, outErr := service.currentUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.writeTokens(w, user.ID)
+}
+
+// GetUserTokens returns the personal access tokens of the user specified by the id param
+func (service *Service) GetUserTokens(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.writeTokens(w, user.ID)
+}
+
+// NewTokenPayload is the payload to create a new personal access token
+type NewTokenPayload struct {
+	UserID    int     `json:"-"`
+	Name      *string `json:"name"`
+	Role      *Role   `json:"role"`
+	ExpiresAt *int    `json:"expires_at"`
+	TokenHash string  `json:"-"`
+	CreatedAt int     `json:"-"`
+}
+
+// PostNewToken creates a new personal access token for the logged in user. The token
+// is only returned in this response.
+func (service *Service) PostNewToken(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewTokenPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// user (a leaked token must not be able to create more tokens)
+	user, reqUser, outErr := service.currentUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+	if reqUser.token != nil {
+		service.logger.Debug(ErrTokenViaToken)
+		return output.JsonErrValidationFailed(ErrTokenViaToken)
+	}
+	payload.UserID = user.ID
+	// name
+	if payload.Name == nil || !service.tokenNameValid(user.ID, *payload.Name) {
+		service.logger.Debug(ErrTokenNameBad)
+		return output.JsonErrValidationFailed(ErrTokenNameBad)
+	}
+	// role (optional - defaults to user's role)
+	if payload.Role == nil {
+		payload.Role = &reqUser.role
+	} else if !payload.Role.Valid() || !reqUser.role.Permits(*payload.Role) {
+		service.logger.Debug(ErrTokenRoleBad)
+		return output.JsonErrValidationFailed(ErrTokenRoleBad)
+	}
+	// expires_at (optional)
+	now := time.Now()
+	if payload.ExpiresAt != nil && int64(*payload.ExpiresAt) <= now.Unix() {
+		service.logger.Debug(ErrTokenExpiresAtBad)
+		return output.JsonErrValidationFailed(ErrTokenExpiresAtBad)
+	}
+	// end validation
+
+	// generate secret
+	secret, err := randomness.GenerateApiKey()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.TokenHash, err = apikeyhash.Hash(secret)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(now.Unix())
+
+	// save to storage
+	newToken, err := service.storage.PostNewToken(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: user '%s' created personal access token '%s' (id: %d)", r.RemoteAddr, reqUser.typeAndName(), newToken.Name, newToken.ID)
+
+	// write response
+	response := &tokenResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "created personal access token"
+	response.Token = newToken.response()
+	response.Token.Token = formatToken(newToken.ID, secret)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// tokenNameValid returns true if name is acceptable and not in use by another of the
+// user's tokens
+func (service *Service) tokenNameValid(userId int, name string) bool {
+	// basic character/length check
+	if !validation.NameValid(name) {
+		return false
+	}
+
+	tokens, err := service.storage.GetUserTokens(userId)
+	if err != nil {
+		return false
+	}
+
+	for _, token := range tokens {
+		if token.Name == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deleteToken revokes (deletes) token and writes the response
+func (service *Service) deleteToken(w http.ResponseWriter, r *http.Request, token Token) *output.JsonError {
+	err := service.storage.DeleteToken(token.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: revoked personal access token '%s' (id: %d) of user id %d", r.RemoteAddr, token.Name, token.ID, token.UserID)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("revoked personal access token (id: %d)", token.ID),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteMyToken revokes the logged in user's token specified by the id param
+func (service *Service) DeleteMyToken(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, _, outErr := service.currentUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+
+	token, outErr := service.getUserToken(r, user.ID, "id")
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.deleteToken(w, r, token)
+}
+
+// DeleteUserToken revokes the token specified by the tokenid param of the user
+// specified by the id param
+func (service *Service) DeleteUserToken(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	token, outErr := service.getUserToken(r, user.ID, "tokenid")
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.deleteToken(w, r, token)
+}
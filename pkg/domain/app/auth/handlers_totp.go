@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrTotpNotLocal       = errors.New("two-factor authentication is only available to local users")
+	ErrTotpViaToken       = errors.New("two-factor authentication can't be managed with a personal access token")
+	ErrTotpPasswordBad    = errors.New("current password is incorrect")
+	ErrTotpCodeBad        = errors.New("two-factor code is incorrect")
+	ErrTotpAlreadyEnabled = errors.New("two-factor authentication is already enabled (disable it first)")
+	ErrTotpNotPending     = errors.New("two-factor authentication enrollment has not been started")
+	ErrTotpNotEnabled     = errors.New("two-factor authentication is not enabled")
+)
+
+// totpStatusResponse is the JSON response containing the logged in user's 2FA status
+type totpStatusResponse struct {
+	output.JsonResponse
+	Totp struct {
+		Enabled                bool `json:"enabled"`
+		Pending                bool `json:"pending"`
+		RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	} `json:"totp"`
+}
+
+// totpEnrollResponse is the JSON response to starting enrollment
+type totpEnrollResponse struct {
+	output.JsonResponse
+	Totp struct {
+		Secret string `json:"secret"`
+		// ProvisioningURI is the otpauth:// uri (to render as a QR code for
+		// authenticator apps)
+		ProvisioningURI string `json:"provisioning_uri"`
+	} `json:"totp"`
+}
+
+// totpRecoveryCodesResponse is the JSON response to completing enrollment. The
+// recovery codes are only stored hashed, so this is the only time they are output.
+type totpRecoveryCodesResponse struct {
+	output.JsonResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// currentTotpUser returns the logged in local user; 2FA can only be managed from a
+// session (not a personal access token)
+func (service *Service) currentTotpUser(r *http.Request, w http.ResponseWriter) (User, *output.JsonError) {
+	user, reqUser, outErr := service.currentUser(r, w)
+	if outErr != nil {
+		return User{}, outErr
+	}
+
+	if reqUser.token != nil {
+		service.logger.Debug(ErrTotpViaToken)
+		return User{}, output.JsonErrValidationFailed(ErrTotpViaToken)
+	}
+
+	if user.Type != session_manager.UserTypeLocal {
+		service.logger.Debug(ErrTotpNotLocal)
+		return User{}, output.JsonErrValidationFailed(ErrTotpNotLocal)
+	}
+
+	return user, nil
+}
+
+// GetMyTotp returns the logged in user's 2FA status
+func (service *Service) GetMyTotp(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.currentTotpUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &totpStatusResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Totp.Enabled = user.Totp.Enabled
+	response.Totp.Pending = !user.Totp.Enabled && user.Totp.Secret != ""
+	response.Totp.RecoveryCodesRemaining = len(user.Totp.RecoveryCodeHashes)
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// totpEnrollPayload is the payload to start 2FA enrollment
+type totpEnrollPayload struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+// PostMyTotpEnroll starts 2FA enrollment for the logged in user by generating a new
+// secret. 2FA isn't enabled until a code from the secret is verified.
+func (service *Service) PostMyTotpEnroll(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload totpEnrollPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// user
+	user, outErr := service.currentTotpUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+	// current password
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(payload.CurrentPassword))
+	if err != nil {
+		service.logger.Debug(ErrTotpPasswordBad)
+		return output.JsonErrValidationFailed(ErrTotpPasswordBad)
+	}
+	// not already enabled
+	if user.Totp.Enabled {
+		service.logger.Debug(ErrTotpAlreadyEnabled)
+		return output.JsonErrValidationFailed(ErrTotpAlreadyEnabled)
+	}
+	// end validation
+
+	// generate secret
+	key, err := newTotpKey(user.Username)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	// save (pending)
+	err = service.storage.PutUserTotp(user.ID, UserTotp{Secret: key.Secret()})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: user '%s' started two-factor enrollment", r.RemoteAddr, user.Username)
+
+	// write response
+	response := &totpEnrollResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "two-factor enrollment started (verify a code to enable)"
+	response.Totp.Secret = key.Secret()
+	response.Totp.ProvisioningURI = key.URL()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// totpVerifyPayload is the payload to complete 2FA enrollment
+type totpVerifyPayload struct {
+	Code string `json:"code"`
+}
+
+// PostMyTotpVerify completes 2FA enrollment for the logged in user if the code is
+// valid for the pending secret, and returns new recovery codes
+func (service *Service) PostMyTotpVerify(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload totpVerifyPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// user
+	user, outErr := service.currentTotpUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+	// must be pending
+	if user.Totp.Enabled || user.Totp.Secret == "" {
+		service.logger.Debug(ErrTotpNotPending)
+		return output.JsonErrValidationFailed(ErrTotpNotPending)
+	}
+	// code
+	counter, ok := validateTotpCode(user.Totp.Secret, payload.Code, user.Totp.LastCounter, time.Now())
+	if !ok {
+		service.logger.Debug(ErrTotpCodeBad)
+		return output.JsonErrValidationFailed(ErrTotpCodeBad)
+	}
+	// end validation
+
+	// recovery codes
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	// save (enabled)
+	err = service.storage.PutUserTotp(user.ID, UserTotp{
+		Secret:             user.Totp.Secret,
+		Enabled:            true,
+		RecoveryCodeHashes: hashes,
+		LastCounter:        counter,
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: user '%s' enabled two-factor authentication", r.RemoteAddr, user.Username)
+
+	// write response
+	response := &totpRecoveryCodesResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "two-factor authentication enabled (save the recovery codes, they will not be shown again)"
+	response.RecoveryCodes = codes
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// totpDisablePayload is the payload to disable 2FA
+type totpDisablePayload struct {
+	CurrentPassword string `json:"current_password"`
+	// Code is a totp code or recovery code
+	Code string `json:"code"`
+}
+
+// PostMyTotpDisable disables 2FA for the logged in user (or cancels a pending enrollment)
+func (service *Service) PostMyTotpDisable(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload totpDisablePayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// user
+	user, outErr := service.currentTotpUser(r, w)
+	if outErr != nil {
+		return outErr
+	}
+	// current password
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(payload.CurrentPassword))
+	if err != nil {
+		service.logger.Debug(ErrTotpPasswordBad)
+		return output.JsonErrValidationFailed(ErrTotpPasswordBad)
+	}
+	// code (only if enabled; a pending enrollment can be cancelled without one)
+	if user.Totp.Enabled {
+		_, ok := user.Totp.verify(payload.Code, time.Now())
+		if !ok {
+			service.logger.Debug(ErrTotpCodeBad)
+			return output.JsonErrValidationFailed(ErrTotpCodeBad)
+		}
+	} else if user.Totp.Secret == "" {
+		service.logger.Debug(ErrTotpNotEnabled)
+		return output.JsonErrValidationFailed(ErrTotpNotEnabled)
+	}
+	// end validation
+
+	return service.resetTotp(w, r, user)
+}
+
+// DeleteUserTotp resets (disables) 2FA for the user specified by the id param (e.g.,
+// if they lost their authenticator and recovery codes)
+func (service *Service) DeleteUserTotp(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	if user.Type != session_manager.UserTypeLocal {
+		service.logger.Debug(ErrTotpNotLocal)
+		return output.JsonErrValidationFailed(ErrTotpNotLocal)
+	}
+
+	return service.resetTotp(w, r, user)
+}
+
+// resetTotp removes all of user's 2FA config and writes the response
+func (service *Service) resetTotp(w http.ResponseWriter, r *http.Request, user User) *output.JsonError {
+	err := service.storage.PutUserTotp(user.ID, UserTotp{})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: two-factor authentication reset for user '%s'", r.RemoteAddr, user.Username)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("two-factor authentication disabled for user (id: %d)", user.ID),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usersResponse is the JSON response to a query for a portion of the users
+type usersResponse struct {
+	output.JsonResponse
+	TotalUsers int            `json:"total_records"`
+	Users      []userResponse `json:"users"`
+}
+
+// GetAllUsers returns all of the users
+func (service *Service) GetAllUsers(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get from storage
+	users, totalRows, err := service.storage.GetAllUsers(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &usersResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalUsers = totalRows
+	response.Users = []userResponse{}
+	for _, user := range users {
+		response.Users = append(response.Users, user.response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// userResponseWrapper is the JSON response for a single user
+type userResponseWrapper struct {
+	output.JsonResponse
+	User userResponse `json:"user"`
+}
+
+// GetOneUser returns the user specified by the id param
+func (service *Service) GetOneUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &userResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.User = user.response()
+
+	err := service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// NewUserPayload is the payload to create a new local user
+type NewUserPayload struct {
+	Username     *string `json:"username"`
+	Password     *string `json:"password"`
+	Role         *Role   `json:"role"`
+	PasswordHash string  `json:"-"`
+	CreatedAt    int     `json:"-"`
+	UpdatedAt    int     `json:"-"`
+}
+
+// PostNewUser creates a new local user (oidc users are created when they first log in)
+func (service *Service) PostNewUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewUserPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// username
+	if payload.Username == nil || !service.usernameValid(*payload.Username) {
+		service.logger.Debug(ErrUsernameBad)
+		return output.JsonErrValidationFailed(ErrUsernameBad)
+	}
+	// password (don't enforce any requirements other than it needs to exist)
+	if payload.Password == nil || len(*payload.Password) < 1 {
+		service.logger.Debug(ErrPasswordBad)
+		return output.JsonErrValidationFailed(ErrPasswordBad)
+	}
+	// role
+	if payload.Role == nil || !payload.Role.Valid() {
+		service.logger.Debug(ErrRoleBad)
+		return output.JsonErrValidationFailed(ErrRoleBad)
+	}
+	// end validation
+
+	// hash password
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(*payload.Password), BcryptCost)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.PasswordHash = string(passwordHash)
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save to storage
+	newUser, err := service.storage.PostNewUser(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	service.logger.Infof("client %s: created user '%s' with role '%s'", r.RemoteAddr, newUser.Username, newUser.Role)
+
+	// write response
+	response := &userResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "created user"
+	response.User = newUser.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// UpdateUserPayload is the payload to change a local user's role and/or reset their
+// password
+type UpdateUserPayload struct {
+	ID           int     `json:"-"`
+	Password     *string `json:"password"`
+	Role         *Role   `json:"role"`
+	PasswordHash *string `json:"-"`
+	UpdatedAt    int     `json:"-"`
+}
+
+// PutUserUpdate updates the role and/or password of the user specified by the id param.
+// Any sessions the user has are terminated so the change takes effect immediately.
+func (service *Service) PutUserUpdate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload UpdateUserPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// do validation
+	// id
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+	payload.ID = user.ID
+	// only local users are editable
+	if user.Type != session_manager.UserTypeLocal {
+		service.logger.Debug(ErrUserOIDC)
+		return output.JsonErrValidationFailed(ErrUserOIDC)
+	}
+	// password (optional)
+	if payload.Password != nil && len(*payload.Password) < 1 {
+		service.logger.Debug(ErrPasswordBad)
+		return output.JsonErrValidationFailed(ErrPasswordBad)
+	}
+	// role (optional)
+	if payload.Role != nil {
+		if !payload.Role.Valid() {
+			service.logger.Debug(ErrRoleBad)
+			return output.JsonErrValidationFailed(ErrRoleBad)
+		}
+
+		// don't allow demoting the last admin
+		if *payload.Role != RoleAdmin {
+			isLastAdmin, err := service.lastAdmin(user)
+			if err != nil {
+				service.logger.Error(err)
+				return output.JsonErrStorageGeneric(err)
+			}
+			if isLastAdmin {
+				service.logger.Debug(ErrLastAdmin)
+				return output.JsonErrValidationFailed(ErrLastAdmin)
+			}
+		}
+	}
+	// end validation
+
+	// hash password
+	if payload.Password != nil {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(*payload.Password), BcryptCost)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+		payload.PasswordHash = new(string)
+		*payload.PasswordHash = string(passwordHash)
+	}
+
+	// add additional details to the payload before saving
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	// save to storage
+	updatedUser, err := service.storage.PutUserUpdate(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// log the user out everywhere
+	service.sessionManager.DeleteUserSessions(updatedUser.Type, updatedUser.Username)
+
+	service.logger.Infof("client %s: updated user '%s' (role: '%s')", r.RemoteAddr, updatedUser.Username, updatedUser.Role)
+
+	// write response
+	response := &userResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated user"
+	response.User = updatedUser.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user specified by the id param and terminates any of
+// their sessions. Deleted oidc users are recreated if they log in again.
+func (service *Service) DeleteUser(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// validation
+	user, outErr := service.getUser(r)
+	if outErr != nil {
+		return outErr
+	}
+
+	// don't allow deleting self
+	reqUser, err := service.authenticate(r, w, "delete user")
+	if err != nil {
+		return output.JsonErrUnauthorized
+	}
+	if reqUser.userType == user.Type && reqUser.username == user.Username {
+		service.logger.Debug(ErrDeleteSelf)
+		return output.JsonErrValidationFailed(ErrDeleteSelf)
+	}
+
+	// don't allow deleting the last admin
+	isLastAdmin, err := service.lastAdmin(user)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	if isLastAdmin {
+		service.logger.Debug(ErrLastAdmin)
+		return output.JsonErrValidationFailed(ErrLastAdmin)
+	}
+	// end validation
+
+	// delete from storage
+	err = service.storage.DeleteUser(user.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// log the user out everywhere
+	service.sessionManager.DeleteUserSessions(user.Type, user.Username)
+
+	service.logger.Infof("client %s: deleted user '%s'", r.RemoteAddr, user.Username)
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("deleted user (id: %d)", user.ID),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -1,18 +1,57 @@
 package auth
 
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"errors"
+	"fmt"
+)
+
 // localExtraFuncs implements session manager's extraFuncs interface
 type localExtraFuncs struct {
 	dbUsername     string
+	role           Role
 	storageService Storage
 }
 
-// RefreshCheck for local users just queries the DB to confirm no-error
+// RefreshCheck for local users just queries the DB to confirm the user still exists and
+// their role has not changed since the session was created
 func (lef *localExtraFuncs) RefreshCheck() error {
 	// get user must work
-	_, err := lef.storageService.GetOneUserByName(lef.dbUsername)
+	user, err := lef.storageService.GetOneUserByTypeAndName(session_manager.UserTypeLocal, lef.dbUsername)
 	if err != nil {
 		return err
 	}
 
+	// role change requires a new login
+	if user.Role != lef.role {
+		return fmt.Errorf("user role changed from '%s' to '%s'", lef.role, user.Role)
+	}
+
 	return nil
 }
+
+// SessionData for local users is empty since everything needed is in the session
+func (lef *localExtraFuncs) SessionData() (string, error) {
+	return "", nil
+}
+
+// restoreLocalExtraFuncs recreates the extra funcs of a saved local user session, if
+// local login is still enabled and the user's role hasn't changed
+func (service *Service) restoreLocalExtraFuncs(ss session_manager.StoredSession) (session_manager.ExtraFuncs, error) {
+	if !service.methodLocalEnabled() {
+		return nil, errors.New("local login is not enabled")
+	}
+
+	lef := &localExtraFuncs{
+		dbUsername:     ss.Username,
+		role:           Role(ss.Role),
+		storageService: service.storage,
+	}
+
+	err := lef.RefreshCheck()
+	if err != nil {
+		return nil, err
+	}
+
+	return lef, nil
+}
@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failed local logins are tracked per username and per client IP. After a few free
+// attempts, each further failure requires an exponentially increasing wait before the
+// next attempt is evaluated, and after enough failures the username or IP is locked
+// out for a while. Attempts are reserved before they are evaluated, so parallel
+// attempts can't all pass the check before any of them is recorded as a failure.
+// Tracking is in memory, so a restart clears it.
+
+const (
+	// loginFreeAttempts is how many failures are allowed before delays start
+	loginFreeAttempts = 3
+	// loginBaseDelay is the delay after the first failure beyond the free attempts,
+	// doubling with each subsequent failure
+	loginBaseDelay = 1 * time.Second
+	// loginMaxDelay caps the exponential delay
+	loginMaxDelay = 1 * time.Minute
+
+	// loginLockoutAttempts is how many failures cause a lockout
+	loginLockoutAttempts = 10
+	// loginLockoutDuration is how long a lockout lasts
+	loginLockoutDuration = 15 * time.Minute
+
+	// loginFailureMemory is how long after the last failure (and the end of any lockout)
+	// failures are forgotten
+	loginFailureMemory = 1 * time.Hour
+)
+
+// loginSubjectType is the kind of thing failures are tracked for
+type loginSubjectType string
+
+const (
+	loginSubjectUsername loginSubjectType = "username"
+	loginSubjectIP       loginSubjectType = "ip"
+)
+
+// loginFailures is the failed login history of a username or IP
+type loginFailures struct {
+	subjectType   loginSubjectType
+	subject       string
+	count         int
+	lastFailureAt time.Time
+	// notBefore is when the next attempt will be evaluated
+	notBefore time.Time
+	// lockedUntil is set (non-zero) when the failures caused a lockout
+	lockedUntil time.Time
+	// pending is how many reserved attempts are still being evaluated
+	pending int
+}
+
+// loginDelay returns how long after the failure the next attempt must wait, given the
+// total failure count
+func loginDelay(count int) time.Duration {
+	if count < loginFreeAttempts {
+		return 0
+	}
+
+	exp := count - loginFreeAttempts
+	// avoid overflow
+	if exp > 30 {
+		return loginMaxDelay
+	}
+
+	return time.Duration(math.Min(float64(loginBaseDelay)*math.Pow(2, float64(exp)), float64(loginMaxDelay)))
+}
+
+// loginLimiter tracks failed local logins
+type loginLimiter struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+// newLoginLimiter creates a loginLimiter
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{
+		failures: make(map[string]*loginFailures),
+	}
+}
+
+// loginLimiterKey returns the map key for the specified subject
+func loginLimiterKey(subjectType loginSubjectType, subject string) string {
+	// usernames are case sensitive, but attempts against case variants are the same attack
+	if subjectType == loginSubjectUsername {
+		subject = strings.ToLower(subject)
+	}
+
+	return string(subjectType) + "|" + subject
+}
+
+// get returns the failures of the subject, creating them (or forgetting them if they
+// are old enough) as needed. Pending attempts are kept. ll.mu must be locked by the
+// caller.
+func (ll *loginLimiter) get(subjectType loginSubjectType, subject string, now time.Time) *loginFailures {
+	key := loginLimiterKey(subjectType, subject)
+	f, exists := ll.failures[key]
+	if !exists || f.expired(now) {
+		pending := 0
+		if exists {
+			pending = f.pending
+		}
+
+		f = &loginFailures{
+			subjectType: subjectType,
+			subject:     subject,
+			pending:     pending,
+		}
+		ll.failures[key] = f
+	}
+
+	return f
+}
+
+// nextAttemptAt returns when another attempt may be evaluated. Pending attempts count
+// as failures that happen now, so once delays apply, only one attempt at a time is
+// evaluated.
+func (f *loginFailures) nextAttemptAt(now time.Time) time.Time {
+	next := f.notBefore
+	if f.pending == 0 {
+		return next
+	}
+
+	pendingNext := now.Add(loginDelay(f.count + f.pending))
+	if pendingNext.After(next) {
+		next = pendingNext
+	}
+
+	return next
+}
+
+// reserve reserves an attempt for the username from the IP, if it may be evaluated at
+// now. If it may not, the time when the next attempt may be made is returned and ok is
+// false. A reservation must be released once the attempt has been evaluated (and
+// recorded as a failure or success).
+func (ll *loginLimiter) reserve(username string, clientIP string, now time.Time) (retryAt time.Time, ok bool) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	subjects := []*loginFailures{
+		ll.get(loginSubjectUsername, username, now),
+		ll.get(loginSubjectIP, clientIP, now),
+	}
+
+	for _, f := range subjects {
+		if next := f.nextAttemptAt(now); next.After(retryAt) {
+			retryAt = next
+		}
+	}
+	if retryAt.After(now) {
+		return retryAt, false
+	}
+
+	for _, f := range subjects {
+		f.pending++
+	}
+
+	return time.Time{}, true
+}
+
+// release ends a reservation made with reserve
+func (ll *loginLimiter) release(username string, clientIP string) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	for _, key := range []string{loginLimiterKey(loginSubjectUsername, username), loginLimiterKey(loginSubjectIP, clientIP)} {
+		f, exists := ll.failures[key]
+		if exists && f.pending > 0 {
+			f.pending--
+		}
+	}
+}
+
+// recordFailure records a failed login for the username and IP, and returns any new
+// lockouts it caused
+func (ll *loginLimiter) recordFailure(username string, clientIP string, now time.Time) (newLockouts []loginFailures) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	subjects := []struct {
+		subjectType loginSubjectType
+		subject     string
+	}{
+		{loginSubjectUsername, username},
+		{loginSubjectIP, clientIP},
+	}
+
+	for _, s := range subjects {
+		f := ll.get(s.subjectType, s.subject, now)
+
+		f.count++
+		f.lastFailureAt = now
+		f.notBefore = now.Add(loginDelay(f.count))
+
+		// lock out (again, if already locked out previously and still failing)
+		if f.count >= loginLockoutAttempts && !f.lockedUntil.After(now) {
+			f.lockedUntil = now.Add(loginLockoutDuration)
+			f.notBefore = f.lockedUntil
+			newLockouts = append(newLockouts, *f)
+		}
+	}
+
+	return newLockouts
+}
+
+// recordSuccess clears the failures of the username. The IP's failures are kept so a
+// client can't reset its count by periodically logging in to an account it controls.
+// Attempts for the username that are still pending are kept too.
+func (ll *loginLimiter) recordSuccess(username string) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	key := loginLimiterKey(loginSubjectUsername, username)
+	f, exists := ll.failures[key]
+	if !exists {
+		return
+	}
+
+	if f.pending > 0 {
+		ll.failures[key] = &loginFailures{
+			subjectType: f.subjectType,
+			subject:     f.subject,
+			pending:     f.pending,
+		}
+	} else {
+		delete(ll.failures, key)
+	}
+}
+
+// expired returns true if the failures are old enough to be forgotten
+func (f *loginFailures) expired(now time.Time) bool {
+	forgetAt := f.lastFailureAt.Add(loginFailureMemory)
+	if f.lockedUntil.After(forgetAt) {
+		forgetAt = f.lockedUntil
+	}
+
+	return !now.Before(forgetAt)
+}
+
+// list returns all of the tracked (unexpired) failures, sorted by most recent failure
+func (ll *loginLimiter) list(now time.Time) []loginFailures {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	all := []loginFailures{}
+	for _, f := range ll.failures {
+		if !f.expired(now) {
+			all = append(all, *f)
+		}
+	}
+
+	slices.SortFunc(all, func(a, b loginFailures) int {
+		return b.lastFailureAt.Compare(a.lastFailureAt)
+	})
+
+	return all
+}
+
+// clear removes the tracked failures of the specified subject and returns true if there
+// were any
+func (ll *loginLimiter) clear(subjectType loginSubjectType, subject string) bool {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	key := loginLimiterKey(subjectType, subject)
+	_, exists := ll.failures[key]
+	delete(ll.failures, key)
+
+	return exists
+}
+
+// clearAll removes all tracked failures and returns how many there were
+func (ll *loginLimiter) clearAll() int {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	count := len(ll.failures)
+	clear(ll.failures)
+
+	return count
+}
+
+// deleteExpired removes failures that are old enough to be forgotten
+func (ll *loginLimiter) deleteExpired(now time.Time) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	for key, f := range ll.failures {
+		if f.expired(now) && f.pending == 0 {
+			delete(ll.failures, key)
+		}
+	}
+}
+
+// loginFailed records a failed login and logs any resulting lockouts
+func (service *Service) loginFailed(username string, clientIP string) {
+	for _, lockout := range service.local.loginLimiter.recordFailure(username, clientIP, time.Now()) {
+		service.logger.Warnf("security event: login %s '%s' locked out until %s after %d failed attempts", lockout.subjectType, lockout.subject, lockout.lockedUntil.Format(time.RFC3339), lockout.count)
+	}
+}
+
+// startLoginLimiterCleanerService starts a goroutine to periodically remove login
+// failures that are old enough to be forgotten
+func (service *Service) startLoginLimiterCleanerService(ctx context.Context, wg *sync.WaitGroup) {
+	// log start and update wg
+	service.logger.Info("starting login failure cleaner service")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-ctx.Done():
+				// exit
+				service.logger.Info("login failure cleaner service shutdown complete")
+				return
+
+			case <-time.After(loginFailureMemory / 4):
+				// continue and run
+			}
+
+			service.local.loginLimiter.deleteExpired(time.Now())
+		}
+	}()
+}
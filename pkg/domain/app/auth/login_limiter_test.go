@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		count int
+		want  time.Duration
+	}{
+		{0, 0},
+		{loginFreeAttempts - 1, 0},
+		{loginFreeAttempts, loginBaseDelay},
+		{loginFreeAttempts + 1, 2 * loginBaseDelay},
+		{loginFreeAttempts + 2, 4 * loginBaseDelay},
+		{loginFreeAttempts + 100, loginMaxDelay},
+	}
+
+	for _, tt := range tests {
+		if got := loginDelay(tt.count); got != tt.want {
+			t.Errorf("loginDelay(%d) = %s, want %s", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLimiter(t *testing.T) {
+	ll := newLoginLimiter()
+	now := time.Unix(1_700_000_000, 0)
+
+	// free attempts
+	for range loginFreeAttempts - 1 {
+		ll.recordFailure("admin", "192.0.2.1", now)
+	}
+	if retry, ok := ll.reserve("admin", "192.0.2.99", now); !ok {
+		t.Fatalf("delayed before free attempts were used (retry at %s)", retry)
+	}
+	ll.release("admin", "192.0.2.99")
+
+	// lockout applies to both username (any case) and ip
+	var lockouts []loginFailures
+	for range loginLockoutAttempts - loginFreeAttempts + 1 {
+		lockouts = append(lockouts, ll.recordFailure("admin", "192.0.2.1", now)...)
+	}
+	if len(lockouts) != 2 {
+		t.Fatalf("expected 2 lockouts, got %d", len(lockouts))
+	}
+	if retry, ok := ll.reserve("ADMIN", "192.0.2.99", now); ok || !retry.Equal(now.Add(loginLockoutDuration)) {
+		t.Errorf("username not locked out (retry at %s)", retry)
+	}
+	if retry, ok := ll.reserve("other", "192.0.2.1", now); ok || !retry.Equal(now.Add(loginLockoutDuration)) {
+		t.Errorf("ip not locked out (retry at %s)", retry)
+	}
+
+	// success only clears the username
+	ll.recordSuccess("admin")
+	if _, ok := ll.reserve("admin", "192.0.2.99", now); !ok {
+		t.Errorf("username still locked out after success")
+	}
+	ll.release("admin", "192.0.2.99")
+	if len(ll.list(now)) != 1 {
+		t.Errorf("expected ip failures to remain after success")
+	}
+
+	// clear
+	if !ll.clear(loginSubjectIP, "192.0.2.1") || len(ll.list(now)) != 0 {
+		t.Errorf("ip failures not cleared")
+	}
+
+	// forgotten after a while
+	ll.recordFailure("admin", "192.0.2.1", now)
+	if len(ll.list(now.Add(loginFailureMemory))) != 0 {
+		t.Errorf("failures not forgotten")
+	}
+}
+
+func TestLoginLimiter_Reserve(t *testing.T) {
+	ll := newLoginLimiter()
+	now := time.Unix(1_700_000_000, 0)
+
+	// parallel attempts may use the free attempts, but no more
+	for i := range loginFreeAttempts {
+		if retry, ok := ll.reserve("admin", "192.0.2.1", now); !ok {
+			t.Fatalf("parallel attempt %d delayed (retry at %s)", i+1, retry)
+		}
+	}
+	if _, ok := ll.reserve("admin", "192.0.2.1", now); ok {
+		t.Fatal("parallel attempt beyond the free attempts was not delayed")
+	}
+
+	// once they fail, attempts are delayed as usual
+	for range loginFreeAttempts {
+		ll.recordFailure("admin", "192.0.2.1", now)
+		ll.release("admin", "192.0.2.1")
+	}
+	if retry, ok := ll.reserve("admin", "192.0.2.2", now); ok || !retry.Equal(now.Add(loginBaseDelay)) {
+		t.Errorf("username not delayed after failures (retry at %s)", retry)
+	}
+
+	// once delays apply, only one attempt is evaluated at a time
+	later := now.Add(loginBaseDelay)
+	if _, ok := ll.reserve("admin", "192.0.2.1", later); !ok {
+		t.Fatal("attempt after the delay was not allowed")
+	}
+	if _, ok := ll.reserve("admin", "192.0.2.2", later); ok {
+		t.Error("parallel attempt for the username was allowed while delays apply")
+	}
+	if _, ok := ll.reserve("other", "192.0.2.1", later); ok {
+		t.Error("parallel attempt from the ip was allowed while delays apply")
+	}
+
+	// a success releases the username, but the ip's failures remain
+	ll.recordSuccess("admin")
+	ll.release("admin", "192.0.2.1")
+	if _, ok := ll.reserve("admin", "192.0.2.2", later); !ok {
+		t.Error("username still delayed after success")
+	}
+	ll.release("admin", "192.0.2.2")
+	if len(ll.list(later)) != 1 {
+		t.Errorf("expected ip failures to remain after success")
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
 	"context"
 	"encoding/json"
 	"errors"
@@ -30,6 +31,17 @@ type oidcPendingSession struct {
 	oauth2Token       *oauth2.Token
 	oidcIDToken       *oidc.IDToken
 	idpCode           string
+	role              Role
+	displayName       string
+}
+
+// OIDCUserLoginPayload records an oidc user's login in storage, creating the user if
+// they do not already exist
+type OIDCUserLoginPayload struct {
+	Username    string
+	DisplayName string
+	Role        Role
+	LoginAt     int
 }
 
 // oidcErrorURL copies a URL and removes the OIDC param values from the
@@ -72,6 +84,8 @@ type oidcExtraFuncs struct {
 	ctxWithHttpClient context.Context
 	cfg               *oauth2.Config
 	idTokenVerifier   *oidc.IDTokenVerifier
+	roleMapper        *oidcRoleMapper
+	role              Role
 	token             *expectedToken
 
 	mu sync.Mutex
@@ -135,11 +149,20 @@ func (oef *oidcExtraFuncs) RefreshCheck() error {
 		return errors.New("oidc refresh failed, new refresh token empty")
 	}
 
-	_, err = oef.idTokenVerifier.Verify(oef.ctxWithHttpClient, t.IDToken)
+	idToken, err := oef.idTokenVerifier.Verify(oef.ctxWithHttpClient, t.IDToken)
 	if err != nil {
 		return fmt.Errorf("oidc refresh failed, id token failed verification (%s)", err)
 	}
 
+	// role must not have changed
+	role, err := idTokenRole(idToken, oef.roleMapper)
+	if err != nil {
+		return fmt.Errorf("oidc refresh failed, %s", err)
+	}
+	if role != oef.role {
+		return fmt.Errorf("oidc refresh failed, user role changed from '%s' to '%s'", oef.role, role)
+	}
+
 	// Validate the required scopes were granted
 	if t.Scope != "" {
 		responseScopes := strings.Split(t.Scope, " ")
@@ -163,6 +186,56 @@ func (oef *oidcExtraFuncs) RefreshCheck() error {
 	return nil
 }
 
+// SessionData for oidc users is the idp token (needed for the refresh check)
+func (oef *oidcExtraFuncs) SessionData() (string, error) {
+	oef.mu.Lock()
+	defer oef.mu.Unlock()
+
+	data, err := json.Marshal(oef.token)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// restoreOidcExtraFuncs recreates the extra funcs of a saved oidc user session, if oidc
+// is still enabled
+func (service *Service) restoreOidcExtraFuncs(ss session_manager.StoredSession) (session_manager.ExtraFuncs, error) {
+	if !service.methodOIDCEnabled() {
+		return nil, errors.New("oidc is not enabled")
+	}
+
+	var token expectedToken
+	err := json.Unmarshal([]byte(ss.ExtraData), &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal saved oidc token (%s)", err)
+	}
+	if token.RefreshToken == "" {
+		return nil, errors.New("saved oidc refresh token is empty")
+	}
+
+	return &oidcExtraFuncs{
+		ctxWithHttpClient: service.oidc.ctxWithHttpClient,
+		cfg:               service.oidc.oauth2Config,
+		idTokenVerifier:   service.oidc.idTokenVerifier,
+		roleMapper:        service.oidc.roleMapper,
+		role:              Role(ss.Role),
+		token:             &token,
+	}, nil
+}
+
+// idTokenRole returns the role that mapper grants the user with idToken
+func idTokenRole(idToken *oidc.IDToken, mapper *oidcRoleMapper) (Role, error) {
+	claims := make(map[string]any)
+	err := idToken.Claims(&claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse id token claims (%s)", err)
+	}
+
+	return mapper.userRole(claims)
+}
+
 // startOidcCleanerService starts a goroutine to remove pending sessions that were abandoned;
 // the expiration limit is not a hard fail, it just provides an eventual backstop to purge
 // pending sessions (as opposed to a hard fail if the time limit is broached by even 1 second)
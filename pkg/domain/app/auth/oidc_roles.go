@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errOidcNoMappedRole = errors.New("no role is mapped to any of the user's claim values")
+
+// oidcDefaultRoleClaim is the id token claim used for role mapping if one isn't configured
+const oidcDefaultRoleClaim = "groups"
+
+// oidcRoleMapper determines the role of an oidc user from the claims in their id token
+type oidcRoleMapper struct {
+	// role is granted to all users when there is no mapping
+	role Role
+	// claim is the name of the claim (nested claims are separated with a .)
+	claim string
+	// mapping is the claim values that grant each role
+	mapping map[Role][]string
+}
+
+// newOidcRoleMapper creates the role mapper from the oidc config
+func newOidcRoleMapper(role string, claim string, mapping map[string][]string) (*oidcRoleMapper, error) {
+	mapper := &oidcRoleMapper{
+		role:    Role(role),
+		claim:   claim,
+		mapping: make(map[Role][]string),
+	}
+
+	if mapper.claim == "" {
+		mapper.claim = oidcDefaultRoleClaim
+	}
+
+	for roleName, values := range mapping {
+		mappedRole := Role(roleName)
+		if !mappedRole.Valid() {
+			return nil, fmt.Errorf("auth: oidc role mapping role '%s' is not valid", roleName)
+		}
+		if len(values) > 0 {
+			mapper.mapping[mappedRole] = values
+		}
+	}
+
+	// fixed role is only needed when there is no mapping
+	if len(mapper.mapping) == 0 && !mapper.role.Valid() {
+		return nil, fmt.Errorf("auth: oidc role '%s' is not valid", role)
+	}
+
+	return mapper, nil
+}
+
+// userRole returns the role for the user with the specified id token claims. If a mapping
+// is configured, the highest ranked role with a value matching one of the claim's values
+// is returned; if there is no match an error is returned. Without a mapping, all users
+// get the configured role.
+func (mapper *oidcRoleMapper) userRole(claims map[string]any) (Role, error) {
+	if len(mapper.mapping) == 0 {
+		return mapper.role, nil
+	}
+
+	userValues := claimValues(claims, mapper.claim)
+	for _, role := range []Role{RoleAdmin, RoleOperator, RoleViewer} {
+		for _, mappedValue := range mapper.mapping[role] {
+			for _, userValue := range userValues {
+				if userValue == mappedValue {
+					return role, nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%w (claim '%s': %v)", errOidcNoMappedRole, mapper.claim, userValues)
+}
+
+// claimValues returns the string value(s) of the specified claim. The claim may be a
+// string or an array of strings, and nested claims are specified by separating each
+// level's name with a . (e.g., `realm_access.roles`).
+func claimValues(claims map[string]any, claim string) []string {
+	var value any = claims
+	for _, name := range strings.Split(claim, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[name]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+
+	case []any:
+		values := []string{}
+		for i := range v {
+			s, ok := v[i].(string)
+			if ok {
+				values = append(values, s)
+			}
+		}
+		return values
+
+	default:
+		return nil
+	}
+}
+
+// oidcDisplayName returns a human readable name for the user with the specified id token
+// claims (the subject is not human readable for many idps)
+func oidcDisplayName(claims map[string]any) string {
+	for _, claim := range []string{"preferred_username", "email", "name"} {
+		value, _ := claims[claim].(string)
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
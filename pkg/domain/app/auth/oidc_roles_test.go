@@ -0,0 +1,51 @@
+package auth
+
+import "testing"
+
+func TestOidcRoleMapper_UserRole(t *testing.T) {
+	mapper, err := newOidcRoleMapper("", "realm_access.roles", map[string][]string{
+		"admin":    {"cw-admins"},
+		"operator": {"cw-ops"},
+		"viewer":   {"cw-view", "helpdesk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		claims  map[string]any
+		want    Role
+		wantErr bool
+	}{
+		{"admin", map[string]any{"realm_access": map[string]any{"roles": []any{"cw-admins"}}}, RoleAdmin, false},
+		{"highest role wins", map[string]any{"realm_access": map[string]any{"roles": []any{"helpdesk", "cw-ops"}}}, RoleOperator, false},
+		{"string claim", map[string]any{"realm_access": map[string]any{"roles": "cw-view"}}, RoleViewer, false},
+		{"no mapped value", map[string]any{"realm_access": map[string]any{"roles": []any{"other"}}}, "", true},
+		{"missing claim", map[string]any{"groups": []any{"cw-admins"}}, "", true},
+	}
+
+	for _, test := range tests {
+		got, err := mapper.userRole(test.claims)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Errorf("%s: got role '%s' (err: %v), want '%s' (err: %t)", test.name, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestOidcRoleMapper_NoMapping(t *testing.T) {
+	mapper, err := newOidcRoleMapper("viewer", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := mapper.userRole(map[string]any{})
+	if err != nil || got != RoleViewer {
+		t.Errorf("got role '%s' (err: %v), want '%s'", got, err, RoleViewer)
+	}
+
+	_, err = newOidcRoleMapper("", "", map[string][]string{"superadmin": {"x"}})
+	if err == nil {
+		t.Error("invalid mapped role should fail")
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var ErrForbidden = errors.New("user's role does not permit this action")
+
+// Role is a user's role, which dictates what the user is permitted to do
+type Role string
+
+const (
+	// RoleViewer can view everything but can't change anything
+	RoleViewer Role = "viewer"
+	// RoleOperator can also order, renew, and download certificates and keys
+	RoleOperator Role = "operator"
+	// RoleAdmin can do everything (including managing users, backups, and the app)
+	RoleAdmin Role = "admin"
+)
+
+// roleRank orders the roles; each role can do everything the lower ranked roles can
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Valid returns true if role is one of the defined roles
+func (role Role) Valid() bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// Permits returns true if role can do everything the required role can
+func (role Role) Permits(required Role) bool {
+	rank, ok := roleRank[role]
+	if !ok {
+		return false
+	}
+
+	return rank >= roleRank[required]
+}
+
+// roleContextKey is the request context key of the authenticated user's role
+type roleContextKey struct{}
+
+// ContextWithRole returns a copy of ctx that records the authenticated user's role
+func ContextWithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleContextKey{}, role)
+}
+
+// RequestPermits returns true if the authenticated user that sent r has a role that
+// permits required. If r's context has no role (e.g., the request wasn't authenticated
+// with an access token), false is returned.
+func RequestPermits(r *http.Request, required Role) bool {
+	role, ok := r.Context().Value(roleContextKey{}).(Role)
+	return ok && role.Permits(required)
+}
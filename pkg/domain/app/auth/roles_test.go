@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRole_Permits(t *testing.T) {
+	tests := []struct {
+		role     Role
+		required Role
+		want     bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleOperator, true},
+		{RoleAdmin, RoleViewer, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleOperator, RoleOperator, true},
+		{RoleOperator, RoleViewer, true},
+		{RoleViewer, RoleAdmin, false},
+		{RoleViewer, RoleOperator, false},
+		{RoleViewer, RoleViewer, true},
+		{Role(""), RoleViewer, false},
+		{Role("superadmin"), RoleViewer, false},
+	}
+
+	for _, test := range tests {
+		got := test.role.Permits(test.required)
+		if got != test.want {
+			t.Errorf("role '%s' permits '%s' = %t (want %t)", test.role, test.required, got, test.want)
+		}
+	}
+}
+
+func TestRole_RequestPermits(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if RequestPermits(r, RoleViewer) {
+		t.Error("request without a role permits viewer")
+	}
+
+	r = r.WithContext(ContextWithRole(r.Context(), RoleOperator))
+	if !RequestPermits(r, RoleOperator) {
+		t.Error("operator request doesn't permit operator")
+	}
+	if RequestPermits(r, RoleAdmin) {
+		t.Error("operator request permits admin")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"certwarden-backend/pkg/datatypes/safemap"
 	"certwarden-backend/pkg/domain/app/auth/session_manager"
 	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
 	"context"
 	"errors"
 	"fmt"
@@ -37,15 +38,42 @@ type App interface {
 
 type User struct {
 	ID           int
+	Type         string
 	Username     string
+	DisplayName  string
 	PasswordHash string
+	Role         Role
+	Totp         UserTotp
+	LastLoginAt  int
 	CreatedAt    int
 	UpdatedAt    int
 }
 
 type Storage interface {
-	GetOneUserByName(username string) (User, error)
+	session_manager.Storage
+
+	GetAllUsers(q pagination_sort.Query) (users []User, totalRowCount int, err error)
+	GetOneUserById(id int) (User, error)
+	GetOneUserByTypeAndName(userType string, username string) (User, error)
+	CountUsersWithRole(role Role) (int, error)
+
+	PostNewUser(payload NewUserPayload) (User, error)
+	PutOIDCUserLogin(payload OIDCUserLoginPayload) (User, error)
+
 	UpdateUserPassword(username string, newPasswordHash string) (userId int, err error)
+	PutUserUpdate(payload UpdateUserPayload) (User, error)
+	PutUserLastLogin(id int, lastLoginAt int) error
+	PutUserTotp(id int, userTotp UserTotp) error
+	PutUserTotpCounterUsed(id int, counter int) (bool, error)
+	DeleteUserTotpRecoveryCode(id int, recoveryCodeHash string) (bool, error)
+
+	DeleteUser(id int) error
+
+	GetUserTokens(userId int) ([]Token, error)
+	GetOneTokenById(id int) (Token, error)
+	PostNewToken(payload NewTokenPayload) (Token, error)
+	PutTokenLastUsed(id int, lastUsedAt int, lastUsedIP string) error
+	DeleteToken(id int) error
 }
 
 type Config struct {
@@ -53,10 +81,13 @@ type Config struct {
 		Enabled *bool `yaml:"enabled"`
 	} `yaml:"local"`
 	OIDC struct {
-		IssuerURL      string `yaml:"issuer_url"`
-		ClientID       string `yaml:"client_id"`
-		ClientSecret   string `yaml:"client_secret"`
-		APIRedirectURI string `yaml:"api_redirect_uri"`
+		IssuerURL      string              `yaml:"issuer_url"`
+		ClientID       string              `yaml:"client_id"`
+		ClientSecret   string              `yaml:"client_secret"`
+		APIRedirectURI string              `yaml:"api_redirect_uri"`
+		Role           string              `yaml:"role"`
+		RoleClaim      string              `yaml:"role_claim"`
+		RoleMapping    map[string][]string `yaml:"role_mapping"`
 	} `yaml:"oidc"`
 }
 
@@ -68,10 +99,13 @@ type Service struct {
 	frontendURLPath           string
 	apiURLPath                string
 	sessionManager            *session_manager.SessionManager
+	storage                   Storage
 	local                     struct {
-		storage Storage
+		enabled      bool
+		loginLimiter *loginLimiter
 	}
 	oidc struct {
+		roleMapper        *oidcRoleMapper
 		ctxWithHttpClient context.Context
 		pendingSessions   *safemap.SafeMap[*oidcPendingSession]
 		oauth2Config      *oauth2.Config
@@ -100,17 +134,22 @@ func NewService(app App, cfg *Config) (*Service, error) {
 	service.frontendURLPath = app.FrontendURLPath()
 	service.apiURLPath = app.APIURLPath()
 
+	// storage
+	service.storage = app.GetAuthStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
 	// create session manager
-	service.sessionManager = session_manager.NewSessionManager(app.IsHttps(), len(app.CORSPermittedCrossOrigins()) > 0, service.logger)
+	service.sessionManager = session_manager.NewSessionManager(app.IsHttps(), len(app.CORSPermittedCrossOrigins()) > 0, service.logger, service.storage)
 	// start cleaner
 	service.sessionManager.StartCleanerService(app.GetShutdownContext(), app.GetShutdownWaitGroup())
 
-	// storage
-	if cfg.Local.Enabled != nil && *cfg.Local.Enabled {
-		service.local.storage = app.GetAuthStorage()
-		if service.local.storage == nil {
-			return nil, errServiceComponent
-		}
+	// local
+	service.local.enabled = cfg.Local.Enabled != nil && *cfg.Local.Enabled
+	if service.local.enabled {
+		service.local.loginLimiter = newLoginLimiter()
+		service.startLoginLimiterCleanerService(app.GetShutdownContext(), app.GetShutdownWaitGroup())
 	}
 
 	// OIDC (optional)
@@ -137,6 +176,12 @@ func NewService(app App, cfg *Config) (*Service, error) {
 				return nil, errors.New("auth: when using OIDC, config must speficy client id, client secret, and api redirect uri")
 			}
 
+			// role(s) granted to oidc users
+			service.oidc.roleMapper, err = newOidcRoleMapper(cfg.OIDC.Role, cfg.OIDC.RoleClaim, cfg.OIDC.RoleMapping)
+			if err != nil {
+				return nil, err
+			}
+
 			// oidc oauth2 config
 			service.oidc.oauth2Config = &oauth2.Config{
 				ClientID:     cfg.OIDC.ClientID,
@@ -163,19 +208,49 @@ func NewService(app App, cfg *Config) (*Service, error) {
 		}
 	}
 
+	// restore sessions from before the app was last stopped (after the login methods
+	// are configured so the sessions' extra funcs can be recreated)
+	err := service.sessionManager.LoadSessions(service.restoreSessionExtraFuncs)
+	if err != nil {
+		service.logger.Errorf("auth: failed to restore saved sessions (%s)", err)
+	}
+
 	return service, nil
 }
 
-// make ValidateAuthHeader available to App
-func (service *Service) ValidateAuthHeader(r *http.Request, w http.ResponseWriter, logTaskName string) error {
-	_, err := service.sessionManager.ValidateAuthHeader(r, w, logTaskName)
-	return err
+// restoreSessionExtraFuncs recreates the extra funcs of a saved session
+func (service *Service) restoreSessionExtraFuncs(ss session_manager.StoredSession) (session_manager.ExtraFuncs, error) {
+	switch ss.UserType {
+	case session_manager.UserTypeLocal:
+		return service.restoreLocalExtraFuncs(ss)
+
+	case session_manager.UserTypeOIDC:
+		return service.restoreOidcExtraFuncs(ss)
+
+	default:
+		return nil, fmt.Errorf("unknown user type '%s'", ss.UserType)
+	}
+}
+
+// make ValidateAuthHeader available to App; it also confirms the user's role permits the
+// requiredRole and returns ErrForbidden if it does not. The user's role is returned.
+func (service *Service) ValidateAuthHeader(r *http.Request, w http.ResponseWriter, logTaskName string, requiredRole Role) (Role, error) {
+	user, err := service.authenticate(r, w, logTaskName)
+	if err != nil {
+		return "", err
+	}
+
+	if !user.role.Permits(requiredRole) {
+		service.logger.Infof("client %s: %s failed (user '%s' with role '%s' requires role '%s')", r.RemoteAddr, logTaskName, user.typeAndName(), user.role, requiredRole)
+		return "", ErrForbidden
+	}
+
+	return user.role, nil
 }
 
 // auth method enabled checks
 func (service *Service) methodLocalEnabled() bool {
-	// storage is an interface - may need to re-evaluate this
-	return service.local.storage != nil
+	return service.local.enabled
 }
 
 func (service *Service) methodOIDCEnabled() bool {
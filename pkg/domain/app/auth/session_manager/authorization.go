@@ -49,6 +49,7 @@ type AuthResponse struct {
 type authorization struct {
 	Username              string       `json:"username"`
 	UserType              userType     `json:"user_type"`
+	Role                  string       `json:"role"`
 	AccessToken           string       `json:"access_token"`
 	AccessTokenExpiration jsonTime     `json:"access_token_exp"`
 	SessionExpiration     jsonTime     `json:"session_exp"`
@@ -62,7 +63,7 @@ func (auth *authorization) UserTypeAndName() string {
 }
 
 // newAuthorization creates a new authorization
-func (sm *SessionManager) newAuthorization(username string, usertype userType) (*authorization, error) {
+func (sm *SessionManager) newAuthorization(username string, usertype userType, role string) (*authorization, error) {
 	// access token
 	accessTokenBytes, err := randomness.Generate32ByteSecret()
 	if err != nil {
@@ -82,6 +83,7 @@ func (sm *SessionManager) newAuthorization(username string, usertype userType) (
 	return &authorization{
 		Username:              username,
 		UserType:              usertype,
+		Role:                  role,
 		AccessToken:           accessToken,
 		AccessTokenExpiration: jsonTime(now.Add(accessTokenExp)),
 		SessionExpiration:     jsonTime(now.Add(sessionExp)),
@@ -1,6 +1,7 @@
 package session_manager
 
 import (
+	"certwarden-backend/pkg/clientip"
 	"errors"
 	"fmt"
 	"net/http"
@@ -24,8 +25,9 @@ func (sm *SessionManager) RefreshSession(r *http.Request, w http.ResponseWriter)
 
 		// check for matching session token
 		var session *session
+		clientSessionTokenHash := hashToken(clientSessionCookie.Value)
 		for _, s := range sm.sessions {
-			if s.authorization.sessionCookie.Value == clientSessionCookie.Value {
+			if s.sessionTokenHash == clientSessionTokenHash {
 				session = s
 				break
 			}
@@ -59,10 +61,16 @@ func (sm *SessionManager) RefreshSession(r *http.Request, w http.ResponseWriter)
 	}
 
 	// session was found, update it and return username and new auth
-	session.authorization, err = sm.newAuthorization(session.authorization.Username, userType(session.authorization.UserType))
+	session.authorization, err = sm.newAuthorization(session.authorization.Username, userType(session.authorization.UserType), session.authorization.Role)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't make new auth: %s", err)
 	}
+	session.accessTokenHash = hashToken(session.authorization.AccessToken)
+	session.sessionTokenHash = hashToken(session.authorization.sessionCookie.Value)
+	session.clientIP = clientip.String(r)
+	session.userAgent = r.UserAgent()
+	session.lastRefreshAt = time.Now()
+	sm.saveSession(session)
 
 	return session.authorization, nil
 }
@@ -1,6 +1,7 @@
 package session_manager
 
 import (
+	"certwarden-backend/pkg/clientip"
 	"context"
 	"errors"
 	"net/http"
@@ -14,15 +15,27 @@ import (
 var errInvalidSessionID = errors.New("invalid session id")
 var errAddExisting = errors.New("cannot add session (duplicate id)")
 
-type extraFuncs interface {
+// ExtraFuncs are session functions that are specific to the type of user
+type ExtraFuncs interface {
 	// RefreshCheck performs additional validation prior to returning a succesful refresh
 	RefreshCheck() error
+	// SessionData returns any data needed to restore the ExtraFuncs after a restart
+	SessionData() (string, error)
 }
 
 // session contains information about a given session
 type session struct {
+	id            string
 	authorization *authorization
-	extraFuncs    extraFuncs
+	// only hashes of the tokens are kept after they're sent to the client (the
+	// plaintext in authorization is not restored after a restart)
+	accessTokenHash  string
+	sessionTokenHash string
+	clientIP         string
+	userAgent        string
+	createdAt        time.Time
+	lastRefreshAt    time.Time
+	extraFuncs       ExtraFuncs
 }
 
 // SessionManager stores and manages session data
@@ -30,17 +43,20 @@ type SessionManager struct {
 	https         bool
 	corsPermitted bool
 	logger        *zap.SugaredLogger
+	storage       Storage
 
 	sessions map[string]*session // map[uuid]*session
 	mu       sync.RWMutex
 }
 
-// newSessionManager creates a new sessionManager
-func NewSessionManager(https bool, corsPermitted bool, logger *zap.SugaredLogger) *SessionManager {
+// newSessionManager creates a new sessionManager. If storage is not nil, sessions are
+// saved to it so they can be restored (with LoadSessions) after a restart.
+func NewSessionManager(https bool, corsPermitted bool, logger *zap.SugaredLogger, storage Storage) *SessionManager {
 	sm := &SessionManager{
 		https:         https,
 		corsPermitted: corsPermitted,
 		logger:        logger,
+		storage:       storage,
 
 		sessions: make(map[string]*session),
 	}
@@ -48,25 +64,33 @@ func NewSessionManager(https bool, corsPermitted bool, logger *zap.SugaredLogger
 	return sm
 }
 
-// NewSession creates a new session for the specified username and returns
-// the newly created authorization.
-func (sm *SessionManager) NewSession(username string, usertype userType, extraFuncs extraFuncs) (*authorization, error) {
-	auth, err := sm.newAuthorization(username, usertype)
+// NewSession creates a new session for the specified username with the specified role
+// and returns the newly created authorization. r is the login request.
+func (sm *SessionManager) NewSession(r *http.Request, username string, usertype userType, role string, extraFuncs ExtraFuncs) (*authorization, error) {
+	auth, err := sm.newAuthorization(username, usertype, role)
 	if err != nil {
 		return nil, err
 	}
 
+	// make a session id
+	uuid := uuid.New()
+	uuidString := uuid.String()
+
+	now := time.Now()
 	session := &session{
-		authorization: auth,
+		id:               uuidString,
+		authorization:    auth,
+		accessTokenHash:  hashToken(auth.AccessToken),
+		sessionTokenHash: hashToken(auth.sessionCookie.Value),
+		clientIP:         clientip.String(r),
+		userAgent:        r.UserAgent(),
+		createdAt:        now,
+		lastRefreshAt:    now,
 	}
 	if extraFuncs != nil {
 		session.extraFuncs = extraFuncs
 	}
 
-	// make a session id
-	uuid := uuid.New()
-	uuidString := uuid.String()
-
 	// add session
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -79,6 +103,7 @@ func (sm *SessionManager) NewSession(username string, usertype userType, extraFu
 
 	// if not, add the key and value
 	sm.sessions[uuidString] = session
+	sm.saveSession(session)
 
 	return session.authorization, nil
 }
@@ -99,8 +124,9 @@ func (sm *SessionManager) DeleteSession(r *http.Request) (_deletedAuthorization
 	defer sm.mu.Unlock()
 
 	var sessionID string
+	clientAccessTokenHash := hashToken(clientAccessToken)
 	for sid, session := range sm.sessions {
-		if session.authorization.AccessToken == clientAccessToken {
+		if session.accessTokenHash == clientAccessTokenHash {
 			sessionID = sid
 			break
 		}
@@ -112,10 +138,30 @@ func (sm *SessionManager) DeleteSession(r *http.Request) (_deletedAuthorization
 	// found, delete it
 	deletedAuthorization := sm.sessions[sessionID].authorization
 	delete(sm.sessions, sessionID)
+	sm.deleteSavedSession(sessionID)
 
 	return deletedAuthorization, nil
 }
 
+// DeleteUserSessions deletes all of the sessions for the specified user and returns
+// how many were deleted. This is used to immediately log out a user whose access
+// was changed (e.g., a user that was deleted or had their role changed).
+func (sm *SessionManager) DeleteUserSessions(usertype string, username string) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	deleted := 0
+	for sid, session := range sm.sessions {
+		if string(session.authorization.UserType) == usertype && session.authorization.Username == username {
+			delete(sm.sessions, sid)
+			sm.deleteSavedSession(sid)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
 // StartCleanerService starts a goroutine that is an indefinite for loop
 // that checks for expired sessions and removes them. This is to
 // prevent the accumulation of expired sessions that were never
@@ -147,6 +193,7 @@ func (sm *SessionManager) StartCleanerService(ctx context.Context, wg *sync.Wait
 			for k, v := range sm.sessions {
 				if now.After(time.Time(v.authorization.SessionExpiration)) {
 					delete(sm.sessions, k)
+					sm.deleteSavedSession(k)
 				}
 			}
 
@@ -0,0 +1,80 @@
+package session_manager
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+// SessionInfo describes an active session (without any secrets)
+type SessionInfo struct {
+	ID                string   `json:"id"`
+	UserType          string   `json:"user_type"`
+	Username          string   `json:"username"`
+	Role              string   `json:"role"`
+	ClientIP          string   `json:"client_ip"`
+	UserAgent         string   `json:"user_agent"`
+	CreatedAt         jsonTime `json:"created_at"`
+	LastRefreshAt     jsonTime `json:"last_refresh_at"`
+	SessionExpiration jsonTime `json:"session_exp"`
+	// Current is true for the session of the client that requested the info
+	Current bool `json:"current"`
+}
+
+func (s *session) info() SessionInfo {
+	return SessionInfo{
+		ID:                s.id,
+		UserType:          string(s.authorization.UserType),
+		Username:          s.authorization.Username,
+		Role:              s.authorization.Role,
+		ClientIP:          s.clientIP,
+		UserAgent:         s.userAgent,
+		CreatedAt:         jsonTime(s.createdAt),
+		LastRefreshAt:     jsonTime(s.lastRefreshAt),
+		SessionExpiration: s.authorization.SessionExpiration,
+	}
+}
+
+// ListSessions returns all of the unexpired sessions, most recently refreshed first. The
+// session of the client that sent r is marked as current.
+func (sm *SessionManager) ListSessions(r *http.Request) []SessionInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	clientAccessTokenHash := hashToken(r.Header.Get(authHeader))
+
+	now := time.Now()
+	infos := []SessionInfo{}
+	for _, s := range sm.sessions {
+		if now.After(time.Time(s.authorization.SessionExpiration)) {
+			continue
+		}
+
+		info := s.info()
+		info.Current = s.accessTokenHash == clientAccessTokenHash
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(a, b SessionInfo) int {
+		return time.Time(b.LastRefreshAt).Compare(time.Time(a.LastRefreshAt))
+	})
+
+	return infos
+}
+
+// DeleteSessionByID deletes the session with the specified id and returns its info. If
+// there is no such session, false is returned.
+func (sm *SessionManager) DeleteSessionByID(id string) (SessionInfo, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	s, exists := sm.sessions[id]
+	if !exists {
+		return SessionInfo{}, false
+	}
+
+	delete(sm.sessions, id)
+	sm.deleteSavedSession(id)
+
+	return s.info(), true
+}
@@ -0,0 +1,147 @@
+package session_manager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Sessions are saved to storage so a restart of the app doesn't log everyone out. The
+// in memory sessions are authoritative; storage is only read when the app starts.
+
+// StoredSession is a session as it is saved in storage
+type StoredSession struct {
+	ID                    string
+	UserType              string
+	Username              string
+	Role                  string
+	AccessTokenHash       string
+	AccessTokenExpiration int
+	SessionTokenHash      string
+	SessionExpiration     int
+	ClientIP              string
+	UserAgent             string
+	ExtraData             string
+	CreatedAt             int
+	LastRefreshAt         int
+}
+
+// Storage saves sessions
+type Storage interface {
+	GetAllSessions() ([]StoredSession, error)
+	PutSession(session StoredSession) error
+	DeleteSession(id string) error
+}
+
+// hashToken returns the hash of an access or session token. The tokens are random
+// 32 byte values, so a salt isn't needed.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// saveSession saves s to storage. Failure is logged but otherwise ignored since the
+// session still works until the app restarts. sm.mu must be locked by the caller.
+func (sm *SessionManager) saveSession(s *session) {
+	if sm.storage == nil {
+		return
+	}
+
+	extraData := ""
+	if s.extraFuncs != nil {
+		var err error
+		extraData, err = s.extraFuncs.SessionData()
+		if err != nil {
+			sm.logger.Errorf("auth: failed to save session %s (%s)", s.id, err)
+			return
+		}
+	}
+
+	err := sm.storage.PutSession(StoredSession{
+		ID:                    s.id,
+		UserType:              string(s.authorization.UserType),
+		Username:              s.authorization.Username,
+		Role:                  s.authorization.Role,
+		AccessTokenHash:       s.accessTokenHash,
+		AccessTokenExpiration: int(time.Time(s.authorization.AccessTokenExpiration).Unix()),
+		SessionTokenHash:      s.sessionTokenHash,
+		SessionExpiration:     int(time.Time(s.authorization.SessionExpiration).Unix()),
+		ClientIP:              s.clientIP,
+		UserAgent:             s.userAgent,
+		ExtraData:             extraData,
+		CreatedAt:             int(s.createdAt.Unix()),
+		LastRefreshAt:         int(s.lastRefreshAt.Unix()),
+	})
+	if err != nil {
+		sm.logger.Errorf("auth: failed to save session %s (%s)", s.id, err)
+	}
+}
+
+// deleteSavedSession deletes the session with the specified id from storage
+func (sm *SessionManager) deleteSavedSession(id string) {
+	if sm.storage == nil {
+		return
+	}
+
+	err := sm.storage.DeleteSession(id)
+	if err != nil {
+		sm.logger.Errorf("auth: failed to delete saved session %s (%s)", id, err)
+	}
+}
+
+// LoadSessions restores the unexpired sessions saved in storage. restoreExtraFuncs is
+// called for each session to recreate its ExtraFuncs from the saved data; sessions it
+// returns an error for (and expired sessions) are deleted.
+func (sm *SessionManager) LoadSessions(restoreExtraFuncs func(StoredSession) (ExtraFuncs, error)) error {
+	if sm.storage == nil {
+		return nil
+	}
+
+	stored, err := sm.storage.GetAllSessions()
+	if err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	restored := 0
+	for _, ss := range stored {
+		sessionExpiration := time.Unix(int64(ss.SessionExpiration), 0)
+		if now.After(sessionExpiration) {
+			sm.deleteSavedSession(ss.ID)
+			continue
+		}
+
+		extraFuncs, err := restoreExtraFuncs(ss)
+		if err != nil {
+			sm.logger.Infof("auth: not restoring session %s of user '%s|%s' (%s)", ss.ID, ss.UserType, ss.Username, err)
+			sm.deleteSavedSession(ss.ID)
+			continue
+		}
+
+		sm.sessions[ss.ID] = &session{
+			id: ss.ID,
+			authorization: &authorization{
+				Username:              ss.Username,
+				UserType:              userType(ss.UserType),
+				Role:                  ss.Role,
+				AccessTokenExpiration: jsonTime(time.Unix(int64(ss.AccessTokenExpiration), 0)),
+				SessionExpiration:     jsonTime(sessionExpiration),
+			},
+			accessTokenHash:  ss.AccessTokenHash,
+			sessionTokenHash: ss.SessionTokenHash,
+			clientIP:         ss.ClientIP,
+			userAgent:        ss.UserAgent,
+			createdAt:        time.Unix(int64(ss.CreatedAt), 0),
+			lastRefreshAt:    time.Unix(int64(ss.LastRefreshAt), 0),
+			extraFuncs:       extraFuncs,
+		}
+		restored++
+	}
+
+	sm.logger.Infof("auth: restored %d saved session(s)", restored)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package session_manager
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testStorage is an in memory Storage
+type testStorage struct {
+	mu       sync.Mutex
+	sessions map[string]StoredSession
+}
+
+func newTestStorage() *testStorage {
+	return &testStorage{sessions: make(map[string]StoredSession)}
+}
+
+func (store *testStorage) GetAllSessions() ([]StoredSession, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	sessions := []StoredSession{}
+	for _, s := range store.sessions {
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+func (store *testStorage) PutSession(session StoredSession) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.sessions[session.ID] = session
+	return nil
+}
+
+func (store *testStorage) DeleteSession(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.sessions, id)
+	return nil
+}
+
+// testExtraFuncs implements ExtraFuncs
+type testExtraFuncs struct {
+	data string
+}
+
+func (ef *testExtraFuncs) RefreshCheck() error          { return nil }
+func (ef *testExtraFuncs) SessionData() (string, error) { return ef.data, nil }
+
+// restoreTestExtraFuncs restores testExtraFuncs, or errors if there is no data
+func restoreTestExtraFuncs(ss StoredSession) (ExtraFuncs, error) {
+	if ss.ExtraData == "" {
+		return nil, errors.New("no extra data")
+	}
+
+	return &testExtraFuncs{data: ss.ExtraData}, nil
+}
+
+// restartedManager returns a new SessionManager that has loaded the sessions in store,
+// as if the app was restarted
+func restartedManager(t *testing.T, store Storage) *SessionManager {
+	t.Helper()
+
+	sm := NewSessionManager(true, false, zap.NewNop().Sugar(), store)
+	err := sm.LoadSessions(restoreTestExtraFuncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sm
+}
+
+// newTestSession logs in username and returns the authorization
+func newTestSession(t *testing.T, sm *SessionManager, username string) *authorization {
+	t.Helper()
+
+	auth, err := sm.NewSession(httptest.NewRequest(http.MethodPost, "/login", nil), username, UserTypeLocal, "admin", &testExtraFuncs{data: "data"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return auth
+}
+
+// authenticates returns if the access token is valid in sm
+func authenticates(sm *SessionManager, accessToken string) bool {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(authHeader, accessToken)
+
+	_, err := sm.ValidateAuthHeader(r, httptest.NewRecorder(), "")
+	return err == nil
+}
+
+// refreshes returns if the session token is valid in sm
+func refreshes(sm *SessionManager, sessionToken string) bool {
+	r := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: sessionToken})
+
+	_, err := sm.RefreshSession(r, httptest.NewRecorder())
+	return err == nil
+}
+
+func TestSessionManager_Restore(t *testing.T) {
+	store := newTestStorage()
+	sm := NewSessionManager(true, false, zap.NewNop().Sugar(), store)
+
+	auth := newTestSession(t, sm, "user")
+
+	// only hashes of the tokens are saved
+	saved := store.sessions
+	if len(saved) != 1 {
+		t.Fatalf("%d sessions saved, expected 1", len(saved))
+	}
+	for _, ss := range saved {
+		if ss.AccessTokenHash != hashToken(auth.AccessToken) || ss.SessionTokenHash != hashToken(auth.sessionCookie.Value) {
+			t.Error("saved session doesn't contain the token hashes")
+		}
+		if ss.ExtraData != "data" {
+			t.Errorf("saved extra data '%s', expected 'data'", ss.ExtraData)
+		}
+	}
+
+	// restored session authenticates and refreshes
+	restored := restartedManager(t, store)
+	if !authenticates(restored, auth.AccessToken) {
+		t.Error("restored session access token doesn't authenticate")
+	}
+	if authenticates(restored, hashToken(auth.AccessToken)) {
+		t.Error("access token hash authenticates")
+	}
+	if !refreshes(restored, auth.sessionCookie.Value) {
+		t.Fatal("restored session token doesn't refresh")
+	}
+
+	// old tokens no longer work after refresh, even after another restart
+	restored = restartedManager(t, store)
+	if authenticates(restored, auth.AccessToken) || refreshes(restored, auth.sessionCookie.Value) {
+		t.Error("tokens from before refresh still valid")
+	}
+}
+
+func TestSessionManager_RestoreRevoked(t *testing.T) {
+	store := newTestStorage()
+	sm := NewSessionManager(true, false, zap.NewNop().Sugar(), store)
+
+	loggedOut := newTestSession(t, sm, "logged-out")
+	revoked := newTestSession(t, sm, "revoked")
+	noExtra := newTestSession(t, sm, "no-extra")
+	kept := newTestSession(t, sm, "kept")
+
+	// log out, revoke user, and a session that can't be restored
+	r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	r.Header.Set(authHeader, loggedOut.AccessToken)
+	_, err := sm.DeleteSession(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted := sm.DeleteUserSessions(UserTypeLocal, "revoked"); deleted != 1 {
+		t.Fatalf("deleted %d sessions, expected 1", deleted)
+	}
+	for id, ss := range store.sessions {
+		if ss.Username == "no-extra" {
+			ss.ExtraData = ""
+			store.sessions[id] = ss
+		}
+	}
+
+	restored := restartedManager(t, store)
+	for _, auth := range []*authorization{loggedOut, revoked, noExtra} {
+		if authenticates(restored, auth.AccessToken) || refreshes(restored, auth.sessionCookie.Value) {
+			t.Errorf("session of '%s' restored", auth.Username)
+		}
+	}
+	if !authenticates(restored, kept.AccessToken) {
+		t.Error("kept session not restored")
+	}
+	if len(store.sessions) != 1 {
+		t.Errorf("%d sessions saved, expected 1", len(store.sessions))
+	}
+}
@@ -35,8 +35,9 @@ func (sm *SessionManager) ValidateAuthHeader(r *http.Request, w http.ResponseWri
 	defer sm.mu.RUnlock()
 
 	var session *session
+	clientAccessTokenHash := hashToken(clientAccessToken)
 	for _, s := range sm.sessions {
-		if s.authorization.AccessToken == clientAccessToken {
+		if s.accessTokenHash == clientAccessTokenHash {
 			session = s
 			break
 		}
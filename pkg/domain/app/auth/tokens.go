@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/clientip"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Personal access tokens allow a user to authenticate to the management API (e.g., from
+// scripts) without the browser session flow. Clients send them as a bearer token in the
+// auth header. Only a salted hash of each token is stored.
+
+// tokenPrefix identifies personal access tokens; the token's id follows the prefix
+// so the matching hash can be found without checking every token
+const tokenPrefix = "cwpat_"
+
+const bearerPrefix = "Bearer "
+
+var (
+	errTokenMalformed = errors.New("personal access token is malformed")
+	errTokenWrong     = errors.New("personal access token is incorrect")
+	errTokenExpired   = errors.New("personal access token is expired")
+)
+
+// Token is a user's personal access token
+type Token struct {
+	ID         int
+	UserID     int
+	Name       string
+	TokenHash  string
+	Role       Role
+	ExpiresAt  *int
+	LastUsedAt int
+	LastUsedIP string
+	CreatedAt  int
+}
+
+// Expired returns true if the token has an expiration that is not after now
+func (token Token) Expired(now time.Time) bool {
+	return token.ExpiresAt != nil && now.Unix() >= int64(*token.ExpiresAt)
+}
+
+// tokenResponse is the JSON response for a personal access token
+type tokenResponse struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	Name       string `json:"name"`
+	Role       Role   `json:"role"`
+	ExpiresAt  *int   `json:"expires_at"`
+	LastUsedAt int    `json:"last_used_at"`
+	LastUsedIP string `json:"last_used_ip"`
+	CreatedAt  int    `json:"created_at"`
+	// tokens are only stored hashed, so the plaintext is only populated
+	// in the response to the request that generated the token
+	Token string `json:"token,omitempty"`
+}
+
+func (token Token) response() tokenResponse {
+	return tokenResponse{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		Name:       token.Name,
+		Role:       token.Role,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// formatToken returns the plaintext token a client uses for the token with the
+// specified id and secret
+func formatToken(id int, secret string) string {
+	return tokenPrefix + strconv.Itoa(id) + "_" + secret
+}
+
+// parseToken returns the id and secret contained in a plaintext token
+func parseToken(token string) (id int, secret string, err error) {
+	idAndSecret, found := strings.CutPrefix(token, tokenPrefix)
+	if !found {
+		return -1, "", errTokenMalformed
+	}
+
+	idStr, secret, found := strings.Cut(idAndSecret, "_")
+	if !found || secret == "" {
+		return -1, "", errTokenMalformed
+	}
+
+	id, err = strconv.Atoi(idStr)
+	if err != nil {
+		return -1, "", errTokenMalformed
+	}
+
+	return id, secret, nil
+}
+
+// requestUser is the authenticated user that sent a request
+type requestUser struct {
+	userType string
+	username string
+	role     Role
+	// token is set if the user authenticated with a personal access token
+	token *Token
+}
+
+// typeAndName returns the user's type and name, separated by a | (the same format
+// the session manager uses)
+func (user requestUser) typeAndName() string {
+	return user.userType + "|" + user.username
+}
+
+// authenticate returns the user that sent r. The auth header must either contain a
+// session access token or a personal access token (as a bearer token).
+func (service *Service) authenticate(r *http.Request, w http.ResponseWriter, logTaskName string) (requestUser, error) {
+	// personal access token
+	headerVal := r.Header.Get("Authorization")
+	if strings.HasPrefix(headerVal, bearerPrefix) {
+		w.Header().Add("Vary", "Authorization")
+
+		user, err := service.authenticateToken(r, strings.TrimPrefix(headerVal, bearerPrefix))
+		if err != nil {
+			err = fmt.Errorf("client %s: %s failed (%s)", r.RemoteAddr, logTaskName, err)
+			service.logger.Debug(err)
+			return requestUser{}, err
+		}
+
+		return user, nil
+	}
+
+	// session
+	auth, err := service.sessionManager.ValidateAuthHeader(r, w, logTaskName)
+	if err != nil {
+		return requestUser{}, err
+	}
+
+	return requestUser{
+		userType: string(auth.UserType),
+		username: auth.Username,
+		role:     Role(auth.Role),
+	}, nil
+}
+
+// authenticateToken returns the user that plaintextToken belongs to, if the token is
+// valid. The user's role is the token's role, unless the user's current role is lower.
+func (service *Service) authenticateToken(r *http.Request, plaintextToken string) (requestUser, error) {
+	id, secret, err := parseToken(plaintextToken)
+	if err != nil {
+		return requestUser{}, err
+	}
+
+	token, err := service.storage.GetOneTokenById(id)
+	if err != nil || !apikeyhash.Matches(secret, token.TokenHash) {
+		return requestUser{}, errTokenWrong
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		return requestUser{}, errTokenExpired
+	}
+
+	user, err := service.storage.GetOneUserById(token.UserID)
+	if err != nil {
+		return requestUser{}, fmt.Errorf("personal access token user not found (%s)", err)
+	}
+
+	role := token.Role
+	if !user.Role.Permits(role) {
+		role = user.Role
+	}
+
+	// record use (failure shouldn't prevent access)
+	err = service.storage.PutTokenLastUsed(token.ID, int(now.Unix()), clientip.String(r))
+	if err != nil {
+		service.logger.Errorf("failed to record use of personal access token %d (%s)", token.ID, err)
+	}
+
+	return requestUser{
+		userType: user.Type,
+		username: user.Username,
+		role:     role,
+		token:    &token,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokens_FormatAndParse(t *testing.T) {
+	token := formatToken(42, "abcDEF123")
+	if token != "cwpat_42_abcDEF123" {
+		t.Fatalf("unexpected token format '%s'", token)
+	}
+
+	id, secret, err := parseToken(token)
+	if err != nil || id != 42 || secret != "abcDEF123" {
+		t.Errorf("parse of '%s' returned id %d secret '%s' (err: %v)", token, id, secret, err)
+	}
+
+	for _, bad := range []string{"", "42_abc", "cwpat_", "cwpat_42", "cwpat_42_", "cwpat_x_abc", "xcwpat_42_abc"} {
+		_, _, err = parseToken(bad)
+		if err == nil {
+			t.Errorf("parse of '%s' should fail", bad)
+		}
+	}
+}
+
+func TestTokens_Expired(t *testing.T) {
+	now := time.Unix(1000, 0)
+	past, future := 999, 1001
+
+	if (Token{}).Expired(now) {
+		t.Error("token without expiration should not expire")
+	}
+	if !(Token{ExpiresAt: &past}).Expired(now) {
+		t.Error("token with past expiration should be expired")
+	}
+	if (Token{ExpiresAt: &future}).Expired(now) {
+		t.Error("token with future expiration should not be expired")
+	}
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/output"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Local users can enroll in TOTP (RFC 6238) two-factor authentication. Once enrolled,
+// login requires a current TOTP code or one of the user's one-time recovery codes.
+
+const (
+	totpIssuer = "Cert Warden"
+	// totpPeriod is the life of each code, in seconds
+	totpPeriod = 30
+	// totpSkew is how many periods before and after the current one are also accepted
+	// (to account for clock drift)
+	totpSkew = 1
+
+	totpRecoveryCodeCount = 10
+)
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// jsonErrTotpRequired is returned when a user's password was correct but they are
+// enrolled in TOTP and didn't send a code
+var jsonErrTotpRequired = &output.JsonError{StatusCode: 401, Message: "unauthorized (totp code required)"}
+
+// UserTotp is a local user's TOTP two-factor authentication config
+type UserTotp struct {
+	// Secret is the base32 encoded shared secret (blank if not enrolled)
+	Secret string
+	// Enabled is false until the user confirms enrollment with a valid code
+	Enabled bool
+	// RecoveryCodeHashes are the salted hashes of the unused recovery codes
+	RecoveryCodeHashes []string
+	// LastCounter is the time step of the last accepted code, so codes can't be reused
+	LastCounter int
+}
+
+// newTotpKey generates a new secret for username
+func newTotpKey(username string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: username,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+}
+
+// validateTotpCode returns the time step of code if it is a valid code for secret at time
+// now and it is for a time step after lastCounter
+func validateTotpCode(secret string, code string, lastCounter int, now time.Time) (counter int, ok bool) {
+	code = strings.TrimSpace(code)
+	if secret == "" || code == "" {
+		return -1, false
+	}
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		t := now.Add(time.Duration(i*totpPeriod) * time.Second)
+		counter = int(t.Unix() / totpPeriod)
+		if counter <= lastCounter {
+			continue
+		}
+
+		expected, err := totp.GenerateCodeCustom(secret, t, totpOpts)
+		if err != nil {
+			return -1, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return -1, false
+}
+
+// totpCodeUse is a code accepted by verify
+type totpCodeUse struct {
+	// counter is the time step of an accepted totp code
+	counter int
+	// recoveryCodeHash is the hash of an accepted recovery code (blank if a totp code
+	// was accepted)
+	recoveryCodeHash string
+}
+
+// verify returns the use if code is a valid totp code or unused recovery code; the use
+// must be saved with saveTotpCodeUse so the code can't be used again
+func (userTotp UserTotp) verify(code string, now time.Time) (totpCodeUse, bool) {
+	if !userTotp.Enabled {
+		return totpCodeUse{}, false
+	}
+
+	// totp code
+	counter, ok := validateTotpCode(userTotp.Secret, code, userTotp.LastCounter, now)
+	if ok {
+		return totpCodeUse{counter: counter}, true
+	}
+
+	// recovery code
+	code = normalizeRecoveryCode(code)
+	for _, hash := range userTotp.RecoveryCodeHashes {
+		if apikeyhash.Matches(code, hash) {
+			return totpCodeUse{recoveryCodeHash: hash}, true
+		}
+	}
+
+	return totpCodeUse{}, false
+}
+
+// saveTotpCodeUse saves that the user used the code. The save is conditional on the code
+// still being unused, so if a parallel login already used it, false is returned and the
+// code must be rejected.
+func (service *Service) saveTotpCodeUse(userId int, use totpCodeUse) (bool, error) {
+	if use.recoveryCodeHash != "" {
+		return service.storage.DeleteUserTotpRecoveryCode(userId, use.recoveryCodeHash)
+	}
+
+	return service.storage.PutUserTotpCounterUsed(userId, use.counter)
+}
+
+// recoveryCodeEncoding is used for recovery codes since it is case insensitive and
+// easy to type
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns new recovery codes and their hashes
+func newRecoveryCodes() (codes []string, hashes []string, err error) {
+	for range totpRecoveryCodeCount {
+		random := make([]byte, 10)
+		_, err = rand.Read(random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// 16 chars, formatted as xxxx-xxxx-xxxx-xxxx
+		encoded := recoveryCodeEncoding.EncodeToString(random)
+		code := encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16]
+
+		hash, err := apikeyhash.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode removes formatting from a recovery code so users can enter
+// it with or without dashes and in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
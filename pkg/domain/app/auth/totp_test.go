@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTotpCode(t *testing.T) {
+	key, err := newTotpKey("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	code, err := totp.GenerateCodeCustom(key.Secret(), now, totpOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counter, ok := validateTotpCode(key.Secret(), code, 0, now)
+	if !ok || counter != int(now.Unix()/totpPeriod) {
+		t.Fatalf("valid code rejected (counter %d, ok %t)", counter, ok)
+	}
+
+	// previous period is accepted (clock drift)
+	_, ok = validateTotpCode(key.Secret(), code, 0, now.Add(totpPeriod*time.Second))
+	if !ok {
+		t.Error("code from previous period rejected")
+	}
+
+	// replay rejected
+	_, ok = validateTotpCode(key.Secret(), code, counter, now)
+	if ok {
+		t.Error("reused code accepted")
+	}
+
+	// too old
+	_, ok = validateTotpCode(key.Secret(), code, 0, now.Add(5*totpPeriod*time.Second))
+	if ok {
+		t.Error("expired code accepted")
+	}
+}
+
+func TestUserTotp_VerifyRecoveryCode(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != totpRecoveryCodeCount || len(hashes) != totpRecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", totpRecoveryCodeCount, len(codes))
+	}
+
+	userTotp := UserTotp{Secret: "JBSWY3DPEHPK3PXP", Enabled: true, RecoveryCodeHashes: hashes}
+
+	// formatting doesn't matter
+	used, ok := userTotp.verify(" "+codes[3][:9]+"-"+codes[3][10:]+" ", time.Now())
+	if !ok {
+		t.Fatal("valid recovery code rejected")
+	}
+	if used.recoveryCodeHash != hashes[3] {
+		t.Fatal("wrong recovery code hash used")
+	}
+
+	// single use (once removed by saving the use)
+	userTotp.RecoveryCodeHashes = append(hashes[:3:3], hashes[4:]...)
+	_, ok = userTotp.verify(codes[3], time.Now())
+	if ok {
+		t.Error("used recovery code accepted")
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ErrUserIdBad   = errors.New("user id is invalid")
+	ErrUsernameBad = errors.New("username is not valid (or is already in use)")
+	ErrPasswordBad = errors.New("password is not specified")
+	ErrRoleBad     = errors.New("role is not valid (must be admin, operator, or viewer)")
+	ErrLastAdmin   = errors.New("at least one admin user must remain")
+	ErrDeleteSelf  = errors.New("users can't delete themself")
+	ErrUserOIDC    = errors.New("oidc users can't be edited (their role is set by the oidc role config)")
+)
+
+// userResponse is the JSON response for a user (the password hash is never output)
+type userResponse struct {
+	ID          int    `json:"id"`
+	UserType    string `json:"user_type"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Role        Role   `json:"role"`
+	TotpEnabled bool   `json:"totp_enabled"`
+	LastLoginAt int    `json:"last_login_at"`
+	CreatedAt   int    `json:"created_at"`
+	UpdatedAt   int    `json:"updated_at"`
+}
+
+func (user User) response() userResponse {
+	return userResponse{
+		ID:          user.ID,
+		UserType:    user.Type,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Role:        user.Role,
+		TotpEnabled: user.Totp.Enabled,
+		LastLoginAt: user.LastLoginAt,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
+
+// getUser returns the user specified by the id param
+func (service *Service) getUser(r *http.Request) (User, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrUserIdBad)
+		return User{}, output.JsonErrValidationFailed(ErrUserIdBad)
+	}
+
+	user, err := service.storage.GetOneUserById(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return User{}, output.JsonErrNotFound(fmt.Errorf("user id %d not found", id))
+		}
+		service.logger.Error(err)
+		return User{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return user, nil
+}
+
+// usernameValid returns true if username is acceptable and not already in use by
+// another local user
+func (service *Service) usernameValid(username string) bool {
+	// basic character/length check
+	if !validation.NameValid(username) {
+		return false
+	}
+
+	// must not exist
+	_, err := service.storage.GetOneUserByTypeAndName(session_manager.UserTypeLocal, username)
+	return errors.Is(err, storage.ErrNoRecord)
+}
+
+// lastAdmin returns true if user is the only remaining admin
+func (service *Service) lastAdmin(user User) (bool, error) {
+	if user.Role != RoleAdmin {
+		return false, nil
+	}
+
+	count, err := service.storage.CountUsersWithRole(RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+
+	return count <= 1, nil
+}
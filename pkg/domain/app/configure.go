@@ -8,6 +8,9 @@ import (
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/domain/app/backup"
 	"certwarden-backend/pkg/domain/app/updater"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/storage/sqlite"
 	"errors"
 	"fmt"
 	"io"
@@ -41,6 +44,7 @@ type config struct {
 	EnableHttpRedirect        *bool             `yaml:"enable_http_redirect"`
 	FrontendServe             *bool             `yaml:"serve_frontend"`
 	CORSPermittedCrossOrigins []string          `yaml:"cors_permitted_crossorigins"`
+	TrustedProxies            []string          `yaml:"trusted_proxies"`
 	CertificateName           *string           `yaml:"certificate_name"`
 	DisableHSTS               *bool             `yaml:"disable_hsts"`
 	LogLevel                  *string           `yaml:"log_level"`
@@ -50,7 +54,9 @@ type config struct {
 	Auth                      auth.Config       `yaml:"auth"`
 	Backup                    backup.Config     `yaml:"backup"`
 	Updater                   updater.Config    `yaml:"updater"`
+	Orders                    orders.Config     `yaml:"orders"`
 	Challenges                challenges.Config `yaml:"challenges"`
+	Storage                   sqlite.Config     `yaml:"storage"`
 }
 
 // httpAddress() returns formatted http server address string
@@ -267,6 +273,9 @@ func (app *Application) setDefaultConfigValues() {
 		app.config.Auth.Local.Enabled = new(bool)
 		*app.config.Auth.Local.Enabled = true
 	}
+	if app.config.Auth.OIDC.Role == "" {
+		app.config.Auth.OIDC.Role = string(auth.RoleAdmin)
+	}
 
 	// backup
 	if app.config.Backup.Enabled == nil {
@@ -296,6 +305,21 @@ func (app *Application) setDefaultConfigValues() {
 		*app.config.Updater.Channel = updater.ChannelBeta
 	}
 
+	// orders
+	if app.config.Orders.AutoOrderIntervalMinutes == nil {
+		app.config.Orders.AutoOrderIntervalMinutes = new(int)
+		*app.config.Orders.AutoOrderIntervalMinutes = orders.DefaultAutoOrderIntervalMinutes
+	}
+	app.config.Orders.RenewalPolicy = certificates.DefaultRenewalPolicy().Merge(app.config.Orders.RenewalPolicy)
+	if app.config.Orders.FailedOrderRetry.MaxAttempts == nil {
+		app.config.Orders.FailedOrderRetry.MaxAttempts = new(int)
+		*app.config.Orders.FailedOrderRetry.MaxAttempts = orders.DefaultFailedOrderMaxAttempts
+	}
+	if app.config.Orders.FailedOrderRetry.InitialDelayMinutes == nil {
+		app.config.Orders.FailedOrderRetry.InitialDelayMinutes = new(int)
+		*app.config.Orders.FailedOrderRetry.InitialDelayMinutes = orders.DefaultFailedOrderInitialDelayMinutes
+	}
+
 	// challenge dns checker services
 	if len(app.config.Challenges.DnsCheckerConfig.DnsServices) <= 0 {
 		app.config.Challenges.DnsCheckerConfig.DnsServices = []dns_checker.DnsServiceIPPair{
@@ -3,25 +3,29 @@ package app
 import (
 	"certwarden-backend/pkg/domain/app/auth"
 	"certwarden-backend/pkg/output"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
 // middlewareApplyAuthJWT applies middleware that validates the jwt access token
-// contained in the auth header. If it is not valid, an error is returned instead of
-// executing next.
-func middlewareApplyAuthJWT(next handlerFunc, auth *auth.Service) handlerFunc {
+// contained in the auth header and confirms the user's role permits requiredRole. If
+// either check fails, an error is returned instead of executing next.
+func middlewareApplyAuthJWT(next handlerFunc, authService *auth.Service, requiredRole auth.Role) handlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) *output.JsonError {
 		// shorten URI for logging
 		trimmedURI := loggableRequestURI(r)
 
-		err := auth.ValidateAuthHeader(r, w, fmt.Sprintf("%s %s", r.Method, trimmedURI))
+		role, err := authService.ValidateAuthHeader(r, w, fmt.Sprintf("%s %s", r.Method, trimmedURI), requiredRole)
 		if err != nil {
 			// Note: Do NOT send detailed error since unauthorized
+			if errors.Is(err, auth.ErrForbidden) {
+				return output.JsonErrForbidden
+			}
 			return output.JsonErrUnauthorized
 		}
 
-		// if valid, do next
-		return next(w, r)
+		// if valid, do next (with the user's role, for handlers that vary by role)
+		return next(w, r.WithContext(auth.ContextWithRole(r.Context(), role)))
 	}
 }
@@ -34,6 +34,17 @@ func loggableRequestURI(r *http.Request) string {
 
 		// add api key download prefix back
 		uri = apiKeyDownloadUrlPath + uri
+	} else if strings.HasPrefix(uri, acmeFrontendUrlPath+"/certificates/") {
+		// acme front-end api key URI: /certificates/:name/:apikey/...
+		uri = strings.TrimPrefix(uri, acmeFrontendUrlPath+"/certificates/")
+
+		redactedURIPieces := strings.SplitN(uri, "/", 2)
+		uri = redactedURIPieces[0]
+		if len(redactedURIPieces) >= 2 {
+			uri += "/" + output.RedactString(redactedURIPieces[1])
+		}
+
+		uri = acmeFrontendUrlPath + "/certificates/" + uri
 	}
 
 	// always remove base path
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// parseTrustedProxies parses the configured trusted proxies (ip addresses and/or cidrs)
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, proxy := range proxies {
+		addr, err := netip.ParseAddr(proxy)
+		if err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy '%s' is not a valid ip address or cidr", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// isTrustedProxy returns true if addr is within any of trustedProxies
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// forwardedClientAddr returns the address of the client that sent r, as reported by
+// trusted proxies. If r's peer is not a trusted proxy, ok is false. X-Forwarded-For is
+// read right to left, skipping trusted proxies, so a client can't spoof its address by
+// sending the header itself. X-Real-IP is used if X-Forwarded-For is not present.
+func forwardedClientAddr(r *http.Request, trustedProxies []netip.Prefix) (addr netip.Addr, ok bool) {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !isTrustedProxy(peer.Addr(), trustedProxies) {
+		return netip.Addr{}, false
+	}
+
+	// X-Forwarded-For (may be specified multiple times)
+	forwardedFor := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwardedFor) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwardedFor[i])
+		if hop == "" {
+			continue
+		}
+
+		addr, err = netip.ParseAddr(hop)
+		if err != nil {
+			// malformed, can't trust anything further left
+			return netip.Addr{}, false
+		}
+		addr = addr.Unmap()
+
+		if !isTrustedProxy(addr, trustedProxies) || i == 0 {
+			return addr, true
+		}
+	}
+
+	// X-Real-IP
+	addr, err = netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if err == nil {
+		return addr.Unmap(), true
+	}
+
+	return netip.Addr{}, false
+}
+
+// forwardedProto returns the scheme (http or https) the client used to reach the proxy
+// that forwarded r, as reported in X-Forwarded-Proto. If r's peer is not a trusted proxy
+// or the scheme isn't reported, blank is returned. If multiple proxies appended to the
+// header, the first (client facing) proxy's value is used.
+func forwardedProto(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !isTrustedProxy(peer.Addr(), trustedProxies) {
+		return ""
+	}
+
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto != "http" && proto != "https" {
+		return ""
+	}
+
+	return proto
+}
+
+// middlewareApplyTrustedProxies replaces the request's RemoteAddr with the client's
+// address, and sets the request's URL scheme to the scheme the client used, when the
+// request was forwarded by a trusted proxy.
+func middlewareApplyTrustedProxies(next http.Handler, trustedProxies []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proto := forwardedProto(r, trustedProxies)
+		if proto != "" {
+			r.URL.Scheme = proto
+		}
+
+		addr, ok := forwardedClientAddr(r, trustedProxies)
+		if ok {
+			r.RemoteAddr = netip.AddrPortFrom(addr, 0).String()
+		}
+
+		// serve next
+		next.ServeHTTP(w, r)
+	})
+}
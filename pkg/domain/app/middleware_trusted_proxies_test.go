@@ -0,0 +1,90 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTrustedProxies_ForwardedClientAddr(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  []string
+		realIP        string
+		wantAddr      string
+		wantForwarded bool
+	}{
+		{"direct client", "203.0.113.5:4321", []string{"198.51.100.1"}, "", "", false},
+		{"one proxy", "192.168.1.1:4321", []string{"198.51.100.1"}, "", "198.51.100.1", true},
+		{"spoofed left", "10.1.1.1:4321", []string{"1.1.1.1, 198.51.100.1, 10.2.2.2"}, "", "198.51.100.1", true},
+		{"multiple headers", "10.1.1.1:4321", []string{"1.1.1.1", "198.51.100.1"}, "", "198.51.100.1", true},
+		{"all trusted", "10.1.1.1:4321", []string{"10.3.3.3, 10.2.2.2"}, "", "10.3.3.3", true},
+		{"malformed", "10.1.1.1:4321", []string{"198.51.100.1, bogus"}, "", "", false},
+		{"real ip", "10.1.1.1:4321", nil, "198.51.100.1", "198.51.100.1", true},
+		{"no headers", "10.1.1.1:4321", nil, "", "", false},
+		{"mapped peer", "[::ffff:192.168.1.1]:4321", []string{"2001:db8::1"}, "", "2001:db8::1", true},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = test.remoteAddr
+		for _, v := range test.forwardedFor {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if test.realIP != "" {
+			r.Header.Set("X-Real-IP", test.realIP)
+		}
+
+		addr, ok := forwardedClientAddr(r, trusted)
+		if ok != test.wantForwarded {
+			t.Errorf("%s: forwarded %t, want %t", test.name, ok, test.wantForwarded)
+			continue
+		}
+		if ok && addr.String() != test.wantAddr {
+			t.Errorf("%s: got %s, want %s", test.name, addr, test.wantAddr)
+		}
+	}
+
+	_, err = parseTrustedProxies([]string{"not-an-ip"})
+	if err == nil {
+		t.Error("invalid trusted proxy accepted")
+	}
+}
+
+func TestTrustedProxies_ForwardedProto(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      string
+		want       string
+	}{
+		{"direct client", "203.0.113.5:4321", "https", ""},
+		{"trusted proxy", "10.1.1.1:4321", "https", "https"},
+		{"upper case", "10.1.1.1:4321", "HTTP", "http"},
+		{"multiple proxies", "10.1.1.1:4321", "https, http", "https"},
+		{"not reported", "10.1.1.1:4321", "", ""},
+		{"bogus", "10.1.1.1:4321", "ftp", ""},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = test.remoteAddr
+		if test.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", test.proto)
+		}
+
+		if got := forwardedProto(r, trusted); got != test.want {
+			t.Errorf("%s: got '%s', want '%s'", test.name, got, test.want)
+		}
+	}
+}
@@ -41,10 +41,11 @@ func (router *router) handleAPIRouteInsecure(method string, path string, handler
 	router.r.Handler(method, path, httpHandlerFunc)
 }
 
-// handleAPIRouteSecure creates a route on router intended for an authenticated API route
-func (router *router) handleAPIRouteSecure(method string, path string, handlerFunc handlerFunc) {
-	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+// handleAPIRouteSecure creates a route on router intended for an authenticated API route; the
+// user's role must permit role
+func (router *router) handleAPIRouteSecure(method string, path string, role auth.Role, handlerFunc handlerFunc) {
+	// JWT Auth (and role)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, role)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -58,9 +59,9 @@ func (router *router) handleAPIRouteSecure(method string, path string, handlerFu
 
 // handleAPIRouteSecure creates a route on router intended for an authenticated API route WITH
 // enhanced logging to ensure any time these routes are accessed they are explicitly logged
-func (router *router) handleAPIRouteSecureSensitive(method string, path string, handlerFunc handlerFunc) {
-	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+func (router *router) handleAPIRouteSecureSensitive(method string, path string, role auth.Role, handlerFunc handlerFunc) {
+	// JWT Auth (and role)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, role)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -74,9 +75,9 @@ func (router *router) handleAPIRouteSecureSensitive(method string, path string,
 
 // handleAPIRouteSecureDownload creates a route on router intended for downloading files via
 // a logged in (SECURE) user.
-func (router *router) handleAPIRouteSecureDownload(method string, path string, handlerFunc handlerFunc) {
-	// JWT Auth
-	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth)
+func (router *router) handleAPIRouteSecureDownload(method string, path string, role auth.Role, handlerFunc handlerFunc) {
+	// JWT Auth (and role)
+	handlerFunc = middlewareApplyAuthJWT(handlerFunc, router.auth, role)
 
 	// CORS
 	handlerFunc = middlewareApplyCORS(handlerFunc, router.permittedCrossOrigins)
@@ -103,6 +104,21 @@ func (router *router) handleAPIRouteDownloadWithAPIKey(method string, path strin
 	router.r.HandlerFunc(method, path, httpHandlerFunc)
 }
 
+// handleACMEFrontendRoute creates a route on router intended for the ACME server front-end.
+// Clients authenticate with their ACME account (or eab / api key when registering).
+func (router *router) handleACMEFrontendRoute(method string, path string, handlerFunc handlerFunc) {
+	// Auth is done by the ACME front-end pkg (signed requests), not here
+
+	// NO CORS
+	// ACME clients are not browsers
+
+	// Logger / handle custom handler func's error
+	httpHandlerFunc := middlewareApplyReturnValHandling(handlerFunc, false, router.logger, router.output)
+
+	// make handler
+	router.r.HandlerFunc(method, path, httpHandlerFunc)
+}
+
 // handleFrontend creates a route to serve content for the frontend
 func (router *router) handleFrontend(method string, path string, handlerFunc handlerFunc) {
 	// NO CORS
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"certwarden-backend/pkg/domain/app/auth"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -12,6 +13,7 @@ const baseUrlPath = "/certwarden"
 // backend api paths
 const apiUrlPath = baseUrlPath + "/api"
 const apiKeyDownloadUrlPath = apiUrlPath + "/v1/download"
+const acmeFrontendUrlPath = apiUrlPath + "/acme"
 
 // frontend React app path (e.g. Vite config `base`)
 const frontendUrlPath = baseUrlPath + "/app"
@@ -43,113 +45,190 @@ func (app *Application) makeRouterAndRoutes() {
 	router.handleAPIRouteInsecure(http.MethodGet, apiUrlPath+"/v1/app/auth/oidc/callback", app.auth.OIDCGetCallback)
 
 	// app auth - secure
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/auth/changepassword", app.auth.LocalChangePassword)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/auth/logout", app.auth.Logout)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/auth/changepassword", auth.RoleViewer, app.auth.LocalChangePassword)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/auth/logout", auth.RoleViewer, app.auth.Logout)
+
+	// app auth - personal access tokens (of the logged in user)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/tokens", auth.RoleViewer, app.auth.GetMyTokens)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/tokens", auth.RoleViewer, app.auth.PostNewToken)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/tokens/:id", auth.RoleViewer, app.auth.DeleteMyToken)
+
+	// app auth - two-factor authentication (of the logged in local user)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/totp", auth.RoleViewer, app.auth.GetMyTotp)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/totp/enroll", auth.RoleViewer, app.auth.PostMyTotpEnroll)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/totp/verify", auth.RoleViewer, app.auth.PostMyTotpVerify)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/auth/totp/disable", auth.RoleViewer, app.auth.PostMyTotpDisable)
+
+	// app auth - failed local login tracking
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/lockouts", auth.RoleAdmin, app.auth.GetLoginLockouts)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/lockouts", auth.RoleAdmin, app.auth.DeleteLoginLockouts)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/lockouts/:type/:subject", auth.RoleAdmin, app.auth.DeleteLoginLockout)
+
+	// app auth - active sessions
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/auth/sessions", auth.RoleAdmin, app.auth.GetAllSessions)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/auth/sessions/:id", auth.RoleAdmin, app.auth.DeleteSession)
+
+	// app users
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/users", auth.RoleAdmin, app.auth.GetAllUsers)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/users/:id", auth.RoleAdmin, app.auth.GetOneUser)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/users", auth.RoleAdmin, app.auth.PostNewUser)
+	router.handleAPIRouteSecureSensitive(http.MethodPut, apiUrlPath+"/v1/app/users/:id", auth.RoleAdmin, app.auth.PutUserUpdate)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/users/:id", auth.RoleAdmin, app.auth.DeleteUser)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/users/:id/tokens", auth.RoleAdmin, app.auth.GetUserTokens)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/users/:id/tokens/:tokenid", auth.RoleAdmin, app.auth.DeleteUserToken)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/users/:id/totp", auth.RoleAdmin, app.auth.DeleteUserTotp)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/users/:id/sessions", auth.RoleAdmin, app.auth.DeleteUserSessions)
 
 	// status
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/status", app.statusHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/status", auth.RoleViewer, app.statusHandler)
 
 	// app
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/log", app.viewCurrentLogHandler)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/logs", app.downloadLogsHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/log", auth.RoleAdmin, app.viewCurrentLogHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/logs", auth.RoleAdmin, app.downloadLogsHandler)
 
 	// app control
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/shutdown", app.doShutdownHandler)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/restart", app.doRestartHandler)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/shutdown", auth.RoleAdmin, app.doShutdownHandler)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/control/restart", auth.RoleAdmin, app.doRestartHandler)
 
 	// app updater
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/updater/new-version", app.updater.GetNewVersionInfo)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/updater/new-version", app.updater.CheckForNewVersion)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/updater/new-version", auth.RoleViewer, app.updater.GetNewVersionInfo)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/updater/new-version", auth.RoleAdmin, app.updater.CheckForNewVersion)
 
 	// app backup and restore
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/backup/disk", app.backup.ListDiskBackupsHandler)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/backup/disk", auth.RoleAdmin, app.backup.ListDiskBackupsHandler)
 
-	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/backup/disk", app.backup.MakeDiskBackupNowHandler)
-	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/backup/disk/:filename", app.backup.DeleteDiskBackupHandler)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/app/backup/disk", auth.RoleAdmin, app.backup.MakeDiskBackupNowHandler)
+	router.handleAPIRouteSecureSensitive(http.MethodDelete, apiUrlPath+"/v1/app/backup/disk/:filename", auth.RoleAdmin, app.backup.DeleteDiskBackupHandler)
 
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup", app.backup.DownloadBackupNowHandler)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup/disk/:filename", app.backup.DownloadDiskBackupHandler)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup", auth.RoleAdmin, app.backup.DownloadBackupNowHandler)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/app/backup/disk/:filename", auth.RoleAdmin, app.backup.DownloadDiskBackupHandler)
 
 	// challenges: dns aliases
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/domainaliases", app.challenges.GetDomainAliases)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/domainaliases", app.challenges.PostDomainAliases)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/domainaliases", auth.RoleViewer, app.challenges.GetDomainAliases)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/domainaliases", auth.RoleAdmin, app.challenges.PostDomainAliases)
 
 	// challenges: providers
-	// router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/domains", app.challenges.Providers.GetAllDomains)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services", app.challenges.DNSIdentifierProviders.GetAllProviders)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.GetOneProvider)
+	// router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/domains", auth.RoleViewer, app.challenges.Providers.GetAllDomains)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services", auth.RoleViewer, app.challenges.DNSIdentifierProviders.GetAllProviders)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.RoleViewer, app.challenges.DNSIdentifierProviders.GetOneProvider)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/providers/services", app.challenges.DNSIdentifierProviders.CreateProvider)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.ModifyProvider)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/app/challenges/providers/services/:id", app.challenges.DNSIdentifierProviders.DeleteProvider)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/app/challenges/providers/services", auth.RoleAdmin, app.challenges.DNSIdentifierProviders.CreateProvider)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.RoleAdmin, app.challenges.DNSIdentifierProviders.ModifyProvider)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/app/challenges/providers/services/:id", auth.RoleAdmin, app.challenges.DNSIdentifierProviders.DeleteProvider)
 
 	// acme_servers
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers", app.acmeServers.GetAllServers)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.GetOneServer)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers", auth.RoleViewer, app.acmeServers.GetAllServers)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeservers/:id", auth.RoleViewer, app.acmeServers.GetOneServer)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeservers", app.acmeServers.PostNewServer)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.PutServerUpdate)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeservers/:id", app.acmeServers.DeleteServer)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeservers", auth.RoleAdmin, app.acmeServers.PostNewServer)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeservers/:id", auth.RoleAdmin, app.acmeServers.PutServerUpdate)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeservers/:id", auth.RoleAdmin, app.acmeServers.DeleteServer)
 
 	// private_keys
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys", app.keys.GetAllKeys)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id", app.keys.GetOneKey)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/download", app.keys.DownloadOneKey)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys", auth.RoleViewer, app.keys.GetAllKeys)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id", auth.RoleViewer, app.keys.GetOneKey)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/download", auth.RoleOperator, app.keys.DownloadOneKey)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys", app.keys.PostNewKey)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys/:id/apikey", app.keys.StageNewApiKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id/apikey", app.keys.RemoveOldApiKey)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys", auth.RoleAdmin, app.keys.PostNewKey)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatekeys/:id", app.keys.PutKeyUpdate)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatekeys/:id", auth.RoleAdmin, app.keys.PutKeyUpdate)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id", app.keys.DeleteKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id", auth.RoleAdmin, app.keys.DeleteKey)
+
+	// private_keys - api_keys
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/apikeys", auth.RoleViewer, app.apiKeys.GetKeyApiKeys)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatekeys/:id/apikeys", auth.RoleAdmin, app.apiKeys.PostNewKeyApiKey)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatekeys/:id/apikeys/:apikeyid", auth.RoleAdmin, app.apiKeys.PutKeyApiKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatekeys/:id/apikeys/:apikeyid", auth.RoleAdmin, app.apiKeys.DeleteKeyApiKey)
+
+	// private_keys - download log
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatekeys/:id/downloadlog", auth.RoleViewer, app.download.GetKeyDownloadLog)
 
 	// acme_accounts
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts", app.accounts.GetAllAccounts)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.GetOneAccount)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts", auth.RoleViewer, app.accounts.GetAllAccounts)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/acmeaccounts/:id", auth.RoleViewer, app.accounts.GetOneAccount)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts", auth.RoleAdmin, app.accounts.PostNewAccount)
+
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id", auth.RoleAdmin, app.accounts.PutNameDescAccount)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/email", auth.RoleAdmin, app.accounts.ChangeEmail)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/key-change", auth.RoleAdmin, app.accounts.RolloverKey)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/register", auth.RoleAdmin, app.accounts.NewAcmeAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/refresh", auth.RoleAdmin, app.accounts.RefreshAcmeAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/deactivate", auth.RoleAdmin, app.accounts.Deactivate)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/post-as-get", auth.RoleAdmin, app.accounts.PostAsGet)
+
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeaccounts/:id", auth.RoleAdmin, app.accounts.DeleteAccount)
+
+	// private_cas
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatecas", auth.RoleViewer, app.privateCAs.GetAllPrivateCAs)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/privatecas/:id", auth.RoleViewer, app.privateCAs.GetOnePrivateCA)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/privatecas/:id/download", auth.RoleOperator, app.privateCAs.DownloadPrivateCACert)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/privatecas", auth.RoleAdmin, app.privateCAs.PostNewPrivateCA)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts", app.accounts.PostNewAccount)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/privatecas/:id", auth.RoleAdmin, app.privateCAs.PutDetailsPrivateCA)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.PutNameDescAccount)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/email", app.accounts.ChangeEmail)
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/acmeaccounts/:id/key-change", app.accounts.RolloverKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/privatecas/:id", auth.RoleAdmin, app.privateCAs.DeletePrivateCA)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/register", app.accounts.NewAcmeAccount)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/refresh", app.accounts.RefreshAcmeAccount)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/deactivate", app.accounts.Deactivate)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/acmeaccounts/:id/post-as-get", app.accounts.PostAsGet)
+	// maintenance_windows
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/maintenancewindows", auth.RoleViewer, app.maintenanceWindows.GetAllMaintenanceWindows)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/maintenancewindows/:id", auth.RoleViewer, app.maintenanceWindows.GetOneMaintenanceWindow)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/acmeaccounts/:id", app.accounts.DeleteAccount)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/maintenancewindows", auth.RoleAdmin, app.maintenanceWindows.PostNewMaintenanceWindow)
+
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/maintenancewindows/:id", auth.RoleAdmin, app.maintenanceWindows.PutDetailsMaintenanceWindow)
+
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/maintenancewindows/:id", auth.RoleAdmin, app.maintenanceWindows.DeleteMaintenanceWindow)
 
 	// certificates
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates", app.certificates.GetAllCerts)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid", app.certificates.GetOneCert)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates", auth.RoleViewer, app.certificates.GetAllCerts)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid", auth.RoleViewer, app.certificates.GetOneCert)
+
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates", auth.RoleAdmin, app.certificates.PostNewCert)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/clientkey", auth.RoleAdmin, app.certificates.MakeNewClientKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/clientkey", auth.RoleAdmin, app.certificates.DisableClientKey)
+
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certificates/:certid", auth.RoleAdmin, app.certificates.PutDetailsCert)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates", app.certificates.PostNewCert)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/apikey", app.certificates.StageNewApiKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/apikey", app.certificates.RemoveOldApiKey)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/clientkey", app.certificates.MakeNewClientKey)
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/clientkey", app.certificates.DisableClientKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid", auth.RoleAdmin, app.certificates.DeleteCert)
 
-	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certificates/:certid", app.certificates.PutDetailsCert)
+	// certificates - api_keys
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/apikeys", auth.RoleViewer, app.apiKeys.GetCertApiKeys)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/apikeys", auth.RoleAdmin, app.apiKeys.PostNewCertApiKey)
+	router.handleAPIRouteSecure(http.MethodPut, apiUrlPath+"/v1/certificates/:certid/apikeys/:apikeyid", auth.RoleAdmin, app.apiKeys.PutCertApiKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/apikeys/:apikeyid", auth.RoleAdmin, app.apiKeys.DeleteCertApiKey)
 
-	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid", app.certificates.DeleteCert)
+	// certificates - download log
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/downloadlog", auth.RoleViewer, app.download.GetCertDownloadLog)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/staleclients", auth.RoleViewer, app.download.GetCertStaleClients)
 
 	// orders (for certificates)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/currentvalid", app.orders.GetAllValidCurrentOrders)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/fulfilling/status", app.orders.GetFulfillWorkStatus)
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/post-process/status", app.orders.GetPostProcessWorkStatus)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/currentvalid", auth.RoleViewer, app.orders.GetAllValidCurrentOrders)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/fulfilling/status", auth.RoleViewer, app.orders.GetFulfillWorkStatus)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/post-process/status", auth.RoleViewer, app.orders.GetPostProcessWorkStatus)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/renewal-forecast", auth.RoleViewer, app.orders.GetRenewalForecast)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/jobs", auth.RoleViewer, app.orders.GetAllJobs)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/orders/jobs/:id", auth.RoleViewer, app.orders.GetOneJob)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/orders/jobs/:id/cancel", auth.RoleOperator, app.orders.CancelJob)
 
-	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.GetCertOrders)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders", app.orders.NewOrder)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders", auth.RoleViewer, app.orders.GetCertOrders)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders", auth.RoleOperator, app.orders.NewOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/import", auth.RoleOperator, app.orders.ImportCertificate)
 
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/download", app.orders.DownloadCertNewestOrder)
-	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/download", app.orders.DownloadOneOrder)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/download", auth.RoleOperator, app.orders.DownloadCertNewestOrder)
+	router.handleAPIRouteSecureDownload(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/download", auth.RoleOperator, app.orders.DownloadOneOrder)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid", app.orders.FulfillExistingOrder)
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/revoke", app.orders.RevokeOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid", auth.RoleOperator, app.orders.FulfillExistingOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/revoke", auth.RoleAdmin, app.orders.RevokeOrder)
 
-	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/postprocess", app.orders.PostProcessOrder)
+	router.handleAPIRouteSecure(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/orders/:orderid/postprocess", auth.RoleOperator, app.orders.PostProcessOrder)
+
+	// acme front-end (for certificates)
+	router.handleAPIRouteSecure(http.MethodGet, apiUrlPath+"/v1/certificates/:certid/acmefrontend", auth.RoleViewer, app.acmeFrontend.GetCertAcmeFrontend)
+	router.handleAPIRouteSecureSensitive(http.MethodPost, apiUrlPath+"/v1/certificates/:certid/acmefrontend/eab", auth.RoleAdmin, app.acmeFrontend.PostNewCertEabKey)
+	router.handleAPIRouteSecure(http.MethodDelete, apiUrlPath+"/v1/certificates/:certid/acmefrontend", auth.RoleAdmin, app.acmeFrontend.DeleteCertAcmeFrontend)
 
 	// download keys and certs
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/privatekeys/:name", app.download.DownloadKeyViaHeader)
@@ -167,6 +246,25 @@ func (app *Application) makeRouterAndRoutes() {
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/certrootchains/:name/*apiKey", app.download.DownloadCertRootChainViaUrl)
 	router.handleAPIRouteDownloadWithAPIKey(http.MethodGet, apiKeyDownloadUrlPath+"/pfx/:name/*apiKey", app.download.DownloadPfxViaUrl)
 
+	// acme server front-end
+	router.handleACMEFrontendRoute(http.MethodGet, acmeFrontendUrlPath+"/directory", app.acmeFrontend.GetDirectory)
+	router.handleACMEFrontendRoute(http.MethodHead, acmeFrontendUrlPath+"/new-nonce", app.acmeFrontend.NewNonce)
+	router.handleACMEFrontendRoute(http.MethodGet, acmeFrontendUrlPath+"/new-nonce", app.acmeFrontend.NewNonce)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/new-account", app.acmeFrontend.NewAccount)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/account/:id", app.acmeFrontend.PostAccount)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/account/:id/orders", app.acmeFrontend.PostAccountOrders)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/new-order", app.acmeFrontend.NewOrder)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/order/:id", app.acmeFrontend.PostOrder)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/order/:id/finalize", app.acmeFrontend.FinalizeOrder)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/authz/:id", app.acmeFrontend.PostAuthorization)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/cert/:id", app.acmeFrontend.PostCertificate)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/revoke-cert", app.acmeFrontend.RevokeCertificate)
+
+	// acme server front-end - via api key in URL routes (only for api keys that permit use
+	// in the url, since the key may end up in proxy and access logs; eab is preferred)
+	router.handleACMEFrontendRoute(http.MethodGet, acmeFrontendUrlPath+"/certificates/:name/:apikey/directory", app.acmeFrontend.GetApiKeyDirectory)
+	router.handleACMEFrontendRoute(http.MethodPost, acmeFrontendUrlPath+"/certificates/:name/:apikey/new-account", app.acmeFrontend.NewApiKeyAccount)
+
 	// frontend (if enabled)
 	if *app.config.FrontendServe {
 		// log availability
@@ -207,6 +305,10 @@ func (app *Application) makeRouterAndRoutes() {
 	if app.IsHttps() && (app.config.DisableHSTS != nil || !*app.config.DisableHSTS) {
 		appRouter = middlewareApplyHSTS(appRouter)
 	}
+	// client address from trusted proxies (first, so all routes log the client's address)
+	if len(app.trustedProxies) > 0 {
+		appRouter = middlewareApplyTrustedProxies(appRouter, app.trustedProxies)
+	}
 
 	// set app's router
 	app.router = appRouter
@@ -4,6 +4,7 @@ import (
 	"certwarden-backend/pkg/domain/acme_accounts"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,7 @@ type Certificate struct {
 	Description                 string
 	CertificateKey              private_keys.Key
 	CertificateAccount          acme_accounts.Account
+	PrivateCA                   *CertificatePrivateCA
 	Subject                     string
 	SubjectAltNames             []string
 	Organization                string
@@ -26,28 +28,40 @@ type Certificate struct {
 	LastAccess                  time.Time
 	CreatedAt                   time.Time
 	UpdatedAt                   time.Time
-	ApiKey                      string
-	ApiKeyNew                   string
-	ApiKeyViaUrl                bool
 	PostProcessingCommand       string
 	PostProcessingEnvironment   []string
 	PostProcessingClientAddress string
 	PostProcessingClientKeyB64  string
 	Profile                     string
+	RequestedValidityHours      int
+	KeyRotationInterval         int
+	RenewalPolicy               RenewalPolicy
+	MaintenanceWindowID         *int
+	OrderFailures               OrderFailures
+	ExternalCsrPem              string
+}
+
+// CertificatePrivateCA identifies the private CA that issues a certificate; it is
+// nil when the certificate is issued by an ACME Server (via CertificateAccount)
+type CertificatePrivateCA struct {
+	ID   int
+	Name string
 }
 
 // certificateSummaryResponse is a JSON response containing only
 // fields desired for the summary
 type certificateSummaryResponse struct {
-	ID                 int                               `json:"id"`
-	Name               string                            `json:"name"`
-	Description        string                            `json:"description"`
-	CertificateKey     certificateKeySummaryResponse     `json:"private_key"`
-	CertificateAccount certificateAccountSummaryResponse `json:"acme_account"`
-	Subject            string                            `json:"subject"`
-	SubjectAltNames    []string                          `json:"subject_alts"`
-	ApiKeyViaUrl       bool                              `json:"api_key_via_url"`
-	LastAccess         int64                             `json:"last_access"`
+	ID                 int                                  `json:"id"`
+	Name               string                               `json:"name"`
+	Description        string                               `json:"description"`
+	CertificateKey     *certificateKeySummaryResponse       `json:"private_key"`
+	ExternalCsr        bool                                 `json:"external_csr"`
+	CertificateAccount *certificateAccountSummaryResponse   `json:"acme_account,omitempty"`
+	PrivateCA          *certificatePrivateCASummaryResponse `json:"private_ca,omitempty"`
+	Subject            string                               `json:"subject"`
+	SubjectAltNames    []string                             `json:"subject_alts"`
+	LastAccess         int64                                `json:"last_access"`
+	OrderFailureStreak int                                  `json:"order_failure_streak"`
 }
 
 type certificateKeySummaryResponse struct {
@@ -68,17 +82,22 @@ type certificateAccountServerSummaryResponse struct {
 	IsStaging bool   `json:"is_staging"`
 }
 
+type certificatePrivateCASummaryResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 func (cert Certificate) summaryResponse() certificateSummaryResponse {
-	return certificateSummaryResponse{
-		ID:          cert.ID,
-		Name:        cert.Name,
-		Description: cert.Description,
-		CertificateKey: certificateKeySummaryResponse{
-			ID:        cert.CertificateKey.ID,
-			Name:      cert.CertificateKey.Name,
-			Algorithm: cert.CertificateKey.Algorithm,
-		},
-		CertificateAccount: certificateAccountSummaryResponse{
+	// issuer is either a private ca or an acme account
+	var account *certificateAccountSummaryResponse
+	var privateCA *certificatePrivateCASummaryResponse
+	if cert.PrivateCA != nil {
+		privateCA = &certificatePrivateCASummaryResponse{
+			ID:   cert.PrivateCA.ID,
+			Name: cert.PrivateCA.Name,
+		}
+	} else {
+		account = &certificateAccountSummaryResponse{
 			ID:   cert.CertificateAccount.ID,
 			Name: cert.CertificateAccount.Name,
 			CertAccountServer: certificateAccountServerSummaryResponse{
@@ -86,11 +105,31 @@ func (cert Certificate) summaryResponse() certificateSummaryResponse {
 				Name:      cert.CertificateAccount.AcmeServer.Name,
 				IsStaging: cert.CertificateAccount.AcmeServer.IsStaging,
 			},
-		},
-		Subject:         cert.Subject,
-		SubjectAltNames: cert.SubjectAltNames,
-		ApiKeyViaUrl:    cert.ApiKeyViaUrl,
-		LastAccess:      cert.LastAccess.Unix(),
+		}
+	}
+
+	// external csr certs don't have a key
+	var certKey *certificateKeySummaryResponse
+	if !cert.HasExternalCsr() {
+		certKey = &certificateKeySummaryResponse{
+			ID:        cert.CertificateKey.ID,
+			Name:      cert.CertificateKey.Name,
+			Algorithm: cert.CertificateKey.Algorithm,
+		}
+	}
+
+	return certificateSummaryResponse{
+		ID:                 cert.ID,
+		Name:               cert.Name,
+		Description:        cert.Description,
+		CertificateKey:     certKey,
+		ExternalCsr:        cert.HasExternalCsr(),
+		CertificateAccount: account,
+		PrivateCA:          privateCA,
+		Subject:            cert.Subject,
+		SubjectAltNames:    cert.SubjectAltNames,
+		LastAccess:         cert.LastAccess.Unix(),
+		OrderFailureStreak: cert.OrderFailures.Streak,
 	}
 }
 
@@ -98,22 +137,30 @@ func (cert Certificate) summaryResponse() certificateSummaryResponse {
 // fields that can be returned as JSON
 type certificateDetailedResponse struct {
 	certificateSummaryResponse
-	Organization                string              `json:"organization"`
-	OrganizationalUnit          string              `json:"organizational_unit"`
-	Country                     string              `json:"country"`
-	State                       string              `json:"state"`
-	City                        string              `json:"city"`
-	CSRExtraExtensions          []CertExtensionJSON `json:"csr_extra_extensions"`
-	PreferredRootCN             string              `json:"preferred_root_cn"`
-	Profile                     string              `json:"profile"`
-	CreatedAt                   int64               `json:"created_at"`
-	UpdatedAt                   int64               `json:"updated_at"`
-	ApiKey                      string              `json:"api_key"`
-	ApiKeyNew                   string              `json:"api_key_new,omitempty"`
-	PostProcessingCommand       string              `json:"post_processing_command"`
-	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
-	PostProcessingClientAddress string              `json:"post_processing_client_address"`
-	PostProcessingClientKeyB64  string              `json:"post_processing_client_key"`
+	Organization           string                `json:"organization"`
+	OrganizationalUnit     string                `json:"organizational_unit"`
+	Country                string                `json:"country"`
+	State                  string                `json:"state"`
+	City                   string                `json:"city"`
+	CSRExtraExtensions     []CertExtensionJSON   `json:"csr_extra_extensions"`
+	PreferredRootCN        string                `json:"preferred_root_cn"`
+	Profile                string                `json:"profile"`
+	RequestedValidityHours int                   `json:"requested_validity_hours"`
+	KeyRotationInterval    int                   `json:"key_rotation_interval"`
+	RenewalPolicy          RenewalPolicy         `json:"renewal_policy"`
+	MaintenanceWindowID    *int                  `json:"maintenance_window_id"`
+	OrderFailures          orderFailuresResponse `json:"order_failures"`
+	ExternalCsrPem         string                `json:"external_csr_pem,omitempty"`
+	CreatedAt              int64                 `json:"created_at"`
+	UpdatedAt              int64                 `json:"updated_at"`
+	// api keys are only stored hashed, so the plaintext is only populated
+	// in the response to the request that generated the key
+	ApiKey                      string   `json:"api_key,omitempty"`
+	PrivateKeyApiKey            string   `json:"private_key_api_key,omitempty"`
+	PostProcessingCommand       string   `json:"post_processing_command"`
+	PostProcessingEnvironment   []string `json:"post_processing_environment"`
+	PostProcessingClientAddress string   `json:"post_processing_client_address"`
+	PostProcessingClientKeyB64  string   `json:"post_processing_client_key"`
 }
 
 func (cert Certificate) detailedResponse() certificateDetailedResponse {
@@ -134,13 +181,35 @@ func (cert Certificate) detailedResponse() certificateDetailedResponse {
 		CSRExtraExtensions:          extraExtensions,
 		PreferredRootCN:             cert.PreferredRootCN,
 		Profile:                     cert.Profile,
+		RequestedValidityHours:      cert.RequestedValidityHours,
+		KeyRotationInterval:         cert.KeyRotationInterval,
+		RenewalPolicy:               cert.RenewalPolicy,
+		MaintenanceWindowID:         cert.MaintenanceWindowID,
+		OrderFailures:               cert.OrderFailures.response(),
+		ExternalCsrPem:              cert.ExternalCsrPem,
 		CreatedAt:                   cert.CreatedAt.Unix(),
 		UpdatedAt:                   cert.UpdatedAt.Unix(),
-		ApiKey:                      cert.ApiKey,
-		ApiKeyNew:                   cert.ApiKeyNew,
 		PostProcessingCommand:       cert.PostProcessingCommand,
 		PostProcessingEnvironment:   cert.PostProcessingEnvironment,
 		PostProcessingClientAddress: cert.PostProcessingClientAddress,
 		PostProcessingClientKeyB64:  cert.PostProcessingClientKeyB64,
 	}
 }
+
+// redactedValue replaces secrets in responses to users that may not see them
+const redactedValue = "[redacted]"
+
+// redactSecrets removes the post processing secrets (the client key and the values of the
+// environment variables) from the response; it is used for users that aren't admins
+func (response *certificateDetailedResponse) redactSecrets() {
+	environment := make([]string, len(response.PostProcessingEnvironment))
+	for i, variable := range response.PostProcessingEnvironment {
+		name, _, _ := strings.Cut(variable, "=")
+		environment[i] = name + "=" + redactedValue
+	}
+	response.PostProcessingEnvironment = environment
+
+	if response.PostProcessingClientKeyB64 != "" {
+		response.PostProcessingClientKeyB64 = redactedValue
+	}
+}
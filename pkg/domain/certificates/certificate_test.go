@@ -0,0 +1,33 @@
+package certificates
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCertificates_RedactSecrets(t *testing.T) {
+	response := Certificate{
+		PostProcessingCommand:      "deploy.sh",
+		PostProcessingEnvironment:  []string{"HOST=example.com", "TOKEN=secret=value", "EMPTY"},
+		PostProcessingClientKeyB64: "client-key",
+	}.detailedResponse()
+	response.redactSecrets()
+
+	wantEnvironment := []string{"HOST=[redacted]", "TOKEN=[redacted]", "EMPTY=[redacted]"}
+	if !slices.Equal(response.PostProcessingEnvironment, wantEnvironment) {
+		t.Errorf("redacted environment %v, expected %v", response.PostProcessingEnvironment, wantEnvironment)
+	}
+	if response.PostProcessingClientKeyB64 != redactedValue {
+		t.Errorf("redacted client key '%s', expected '%s'", response.PostProcessingClientKeyB64, redactedValue)
+	}
+	if response.PostProcessingCommand != "deploy.sh" {
+		t.Error("command was redacted")
+	}
+
+	// no client key stays blank
+	response = Certificate{}.detailedResponse()
+	response.redactSecrets()
+	if response.PostProcessingClientKeyB64 != "" || len(response.PostProcessingEnvironment) != 0 {
+		t.Error("blank secrets were changed")
+	}
+}
@@ -5,8 +5,27 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"slices"
 )
 
+// CsrDer returns the CSR bytes for ACME to POST to a Finalize URL. If the cert uses
+// an external csr, that csr is returned as-is; otherwise one is generated using the
+// cert's private key.
+func (cert *Certificate) CsrDer() (csr []byte, err error) {
+	if !cert.HasExternalCsr() {
+		return cert.MakeCsrDer()
+	}
+
+	block, _ := pem.Decode([]byte(cert.ExternalCsrPem))
+	if block == nil {
+		return nil, ErrExternalCsrBad
+	}
+
+	return block.Bytes, nil
+}
+
 // MakeCsrDer generates the CSR bytes for ACME to POST To a Finalize URL
 func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 	// omit empty fields
@@ -36,8 +55,15 @@ func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 	}
 
 	// create Subject
+	// an ip address is not a valid CN, so leave it blank (the ip is included in
+	// the IPAddresses extension instead)
+	commonName := cert.Subject
+	if net.ParseIP(cert.Subject) != nil {
+		commonName = ""
+	}
+
 	subj := pkix.Name{
-		CommonName:         cert.Subject,
+		CommonName:         commonName,
 		Organization:       org,
 		OrganizationalUnit: ou,
 		Country:            country,
@@ -54,12 +80,28 @@ func (cert *Certificate) MakeCsrDer() (csr []byte, err error) {
 		extraExts = append(extraExts, cert.CSRExtraExtensions[i].Extension)
 	}
 
+	// separate names into dns names and ip addresses (RFC 8738); names are canonicalized
+	// (in case they were saved before that was done) so they match the order identifiers
+	dnsNames := []string{}
+	ipAddresses := []net.IP{}
+	for _, name := range canonicalNames(append([]string{cert.Subject}, cert.SubjectAltNames...)) {
+		ip := net.ParseIP(name)
+		if ip != nil {
+			if !slices.ContainsFunc(ipAddresses, ip.Equal) {
+				ipAddresses = append(ipAddresses, ip)
+			}
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+
 	// CSR template to create CSR from
 	template := x509.CertificateRequest{
 		SignatureAlgorithm: cert.CertificateKey.Algorithm.CsrSigningAlg(),
 		Subject:            subj,
-		DNSNames:           append([]string{cert.Subject}, cert.SubjectAltNames...),
-		// unused: EmailAddresses, IPAddresses, URIs, Attributes (deprecated)
+		DNSNames:           dnsNames,
+		IPAddresses:        ipAddresses,
+		// unused: EmailAddresses, URIs, Attributes (deprecated)
 		ExtraExtensions: extraExts,
 	}
 
@@ -0,0 +1,87 @@
+package certificates
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"crypto/x509"
+	"slices"
+	"testing"
+)
+
+func TestCertificates_CanonicalIPNames(t *testing.T) {
+	tests := map[string]string{
+		"::ffff:192.0.2.1":     "192.0.2.1",
+		"2001:DB8:0:0:0:0:0:1": "2001:db8::1",
+		"2001:db8::1":          "2001:db8::1",
+		"192.0.2.1":            "192.0.2.1",
+		"www.Example.com":      "www.Example.com",
+	}
+
+	for name, want := range tests {
+		got := canonicalName(name)
+		if got != want {
+			t.Errorf("canonical name of '%s' is '%s', expected '%s'", name, got, want)
+		}
+	}
+
+	if canonicalNames(nil) != nil {
+		t.Error("canonical names of nil is not nil")
+	}
+}
+
+func TestCertificates_MakeCsrDerIPNames(t *testing.T) {
+	alg := key_crypto.AlgorithmByStorageValue("ecdsap256")
+	keyPem, err := alg.GeneratePrivateKeyPem()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// names as a client might send them
+	subject := "::ffff:192.0.2.1"
+	alts := []string{"2001:DB8:0:0:0:0:0:1", "www.example.com"}
+
+	// round trip: names are saved canonicalized, then the csr and order identifiers are made
+	// from the saved names
+	cert := Certificate{
+		Subject:         canonicalName(subject),
+		SubjectAltNames: canonicalNames(alts),
+		CertificateKey:  private_keys.Key{Algorithm: alg, Pem: keyPem},
+	}
+
+	if cert.Subject != "192.0.2.1" || !slices.Equal(cert.SubjectAltNames, []string{"2001:db8::1", "www.example.com"}) {
+		t.Fatalf("names not canonicalized (subject: %s, alts: %v)", cert.Subject, cert.SubjectAltNames)
+	}
+
+	csrDer, err := cert.MakeCsrDer()
+	if err != nil {
+		t.Fatalf("failed to make csr (%s)", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// csr names
+	csrNames := slices.Clone(csr.DNSNames)
+	for _, ip := range csr.IPAddresses {
+		csrNames = append(csrNames, ip.String())
+	}
+
+	// order identifiers
+	identifierNames := []string{}
+	for _, name := range append([]string{cert.Subject}, cert.SubjectAltNames...) {
+		identifierNames = append(identifierNames, acme.NewIdentifier(name).Value)
+	}
+
+	slices.Sort(csrNames)
+	slices.Sort(identifierNames)
+	if !slices.Equal(csrNames, identifierNames) {
+		t.Errorf("csr names %v do not match order identifiers %v", csrNames, identifierNames)
+	}
+
+	// ip subject is not a common name
+	if csr.Subject.CommonName != "" {
+		t.Errorf("csr common name is '%s', expected blank for ip subject", csr.Subject.CommonName)
+	}
+}
@@ -0,0 +1,154 @@
+package certificates
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+	"strings"
+)
+
+// postProcessingKeyPlaceholders are the post processing environment placeholders that
+// are replaced with details of the private key
+var postProcessingKeyPlaceholders = []string{"{{PRIVATE_KEY_NAME}}", "{{PRIVATE_KEY_PEM}}"}
+
+// HasExternalCsr returns true if the cert uses a csr that was uploaded by the client
+// instead of a private key managed by this app
+func (cert *Certificate) HasExternalCsr() bool {
+	return cert.ExternalCsrPem != ""
+}
+
+// parseExternalCsr decodes and parses a pem encoded csr and verifies its signature
+func parseExternalCsr(csrPem string) (*x509.CertificateRequest, error) {
+	block, rest := pem.Decode([]byte(csrPem))
+	if block == nil || (block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST") || strings.TrimSpace(string(rest)) != "" {
+		return nil, ErrExternalCsrBad
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, ErrExternalCsrBad
+	}
+
+	err = csr.CheckSignature()
+	if err != nil {
+		return nil, ErrExternalCsrBad
+	}
+
+	return csr, nil
+}
+
+// externalCsrNames returns the subject and subject alt names contained in a csr. The
+// subject is the common name; if there isn't one, the first dns or ip name is used.
+func externalCsrNames(csr *x509.CertificateRequest) (subject string, subjectAltNames []string) {
+	names := []string{}
+	if csr.Subject.CommonName != "" {
+		names = append(names, csr.Subject.CommonName)
+	}
+	names = append(names, csr.DNSNames...)
+	for _, ip := range csr.IPAddresses {
+		names = append(names, ip.String())
+	}
+
+	subjectAltNames = []string{}
+	for i, name := range names {
+		if i == 0 {
+			subject = name
+			continue
+		}
+
+		if name != subject && !slices.Contains(subjectAltNames, name) {
+			subjectAltNames = append(subjectAltNames, name)
+		}
+	}
+
+	return subject, subjectAltNames
+}
+
+// externalCsrNamesMatch returns true if subject and subjectAltNames are the same names
+// as the csr contains (in any order)
+func externalCsrNamesMatch(csr *x509.CertificateRequest, subject string, subjectAltNames []string) bool {
+	csrSubject, csrAlts := externalCsrNames(csr)
+
+	names := append([]string{subject}, subjectAltNames...)
+	csrNames := append([]string{csrSubject}, csrAlts...)
+
+	// compare as sets
+	for _, name := range names {
+		if !slices.Contains(csrNames, name) {
+			return false
+		}
+	}
+	for _, name := range csrNames {
+		if !slices.Contains(names, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// usesKeyPlaceholder returns true if any of the post processing environment values is a
+// private key placeholder
+func usesKeyPlaceholder(environment []string) bool {
+	for _, param := range environment {
+		upperParam := strings.ToUpper(param)
+		for _, placeholder := range postProcessingKeyPlaceholders {
+			if strings.Contains(upperParam, placeholder) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validateExternalCsrDetailsUpdate returns an error if payload contains an update that
+// is not valid for cert, which uses an external csr
+func validateExternalCsrDetailsUpdate(cert Certificate, payload DetailsUpdatePayload) error {
+	// can't switch to a key
+	if payload.PrivateKeyId != nil {
+		return ErrExternalCsrModeChange
+	}
+
+	// csr (new or current)
+	csrPem := cert.ExternalCsrPem
+	if payload.ExternalCsrPem != nil {
+		csrPem = *payload.ExternalCsrPem
+	}
+	csr, err := parseExternalCsr(csrPem)
+	if err != nil {
+		return err
+	}
+
+	// names (subject can't change, alts are new or current)
+	subjectAltNames := cert.SubjectAltNames
+	if payload.SubjectAltNames != nil {
+		subjectAltNames = payload.SubjectAltNames
+	}
+	if !externalCsrNamesMatch(csr, cert.Subject, subjectAltNames) {
+		return ErrExternalCsrNames
+	}
+
+	// csr fields
+	for _, field := range []*string{payload.Organization, payload.OrganizationalUnit, payload.Country, payload.State, payload.City} {
+		if field != nil && *field != "" {
+			return ErrExternalCsrFields
+		}
+	}
+	if len(payload.CSRExtraExtensions) > 0 {
+		return ErrExternalCsrFields
+	}
+
+	// key rotation and post processing client / placeholders need the private key
+	if payload.KeyRotationInterval != nil && *payload.KeyRotationInterval != 0 {
+		return ErrExternalCsrKeyRotation
+	}
+	if payload.PostProcessingClientAddress != nil && *payload.PostProcessingClientAddress != "" {
+		return ErrExternalCsrClient
+	}
+	if usesKeyPlaceholder(payload.PostProcessingEnvironment) {
+		return ErrExternalCsrPlaceholder
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"net"
+	"testing"
+)
+
+// testCsrPem returns a pem encoded csr for the names, signed by a new key
+func testCsrPem(t *testing.T, commonName string, dnsNames []string, ips []net.IP) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func TestCertificates_ParseExternalCsr(t *testing.T) {
+	csrPem := testCsrPem(t, "example.com", []string{"example.com"}, nil)
+
+	block, _ := pem.Decode([]byte(csrPem))
+
+	// signature no longer matches
+	tamperedDer := bytes.Clone(block.Bytes)
+	tamperedDer[len(tamperedDer)-1] ^= 0x01
+	tampered := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: tamperedDer})
+
+	tests := map[string]struct {
+		csrPem string
+		ok     bool
+	}{
+		"valid":        {csrPem, true},
+		"new type":     {string(pem.EncodeToMemory(&pem.Block{Type: "NEW CERTIFICATE REQUEST", Bytes: block.Bytes})), true},
+		"empty":        {"", false},
+		"not pem":      {"example.com", false},
+		"wrong type":   {string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})), false},
+		"trailing pem": {csrPem + csrPem, false},
+		"bad der":      {string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte("bad")})), false},
+		"tampered":     {string(tampered), false},
+	}
+
+	for name, test := range tests {
+		_, err := parseExternalCsr(test.csrPem)
+		if test.ok && err != nil {
+			t.Errorf("%s: unexpected error (%s)", name, err)
+		}
+		if !test.ok && !errors.Is(err, ErrExternalCsrBad) {
+			t.Errorf("%s: error %v, expected %v", name, err, ErrExternalCsrBad)
+		}
+	}
+}
+
+func TestCertificates_ExternalCsrNamesMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		commonName      string
+		dnsNames        []string
+		ips             []net.IP
+		subject         string
+		subjectAltNames []string
+		match           bool
+	}{
+		{"subject only", "example.com", nil, nil, "example.com", nil, true},
+		{"common name repeated in dns names", "example.com", []string{"example.com", "www.example.com"}, nil, "example.com", []string{"www.example.com"}, true},
+		{"any order", "example.com", []string{"b.example.com", "a.example.com"}, nil, "example.com", []string{"a.example.com", "b.example.com"}, true},
+		{"subject from first dns name", "", []string{"example.com", "www.example.com"}, nil, "example.com", []string{"www.example.com"}, true},
+		{"subject from ip", "", nil, []net.IP{net.ParseIP("192.0.2.1")}, "192.0.2.1", nil, true},
+		{"canonical ipv6", "example.com", nil, []net.IP{net.ParseIP("2001:DB8:0:0:0:0:0:1")}, "example.com", []string{"2001:db8::1"}, true},
+		{"subject is an alt name of the csr", "example.com", []string{"www.example.com"}, nil, "www.example.com", []string{"example.com"}, true},
+		{"missing alt name", "example.com", []string{"www.example.com"}, nil, "example.com", nil, false},
+		{"extra alt name", "example.com", nil, nil, "example.com", []string{"www.example.com"}, false},
+		{"different subject", "example.com", nil, nil, "example.net", nil, false},
+		{"different ip", "", nil, []net.IP{net.ParseIP("192.0.2.1")}, "192.0.2.2", nil, false},
+		{"case differs", "Example.com", nil, nil, "example.com", nil, false},
+	}
+
+	for _, test := range tests {
+		csr, err := parseExternalCsr(testCsrPem(t, test.commonName, test.dnsNames, test.ips))
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if match := externalCsrNamesMatch(csr, test.subject, test.subjectAltNames); match != test.match {
+			t.Errorf("%s: names match is %t, expected %t", test.name, match, test.match)
+		}
+	}
+}
+
+func TestCertificates_UsesKeyPlaceholder(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment []string
+		uses        bool
+	}{
+		{"nil", nil, false},
+		{"no placeholders", []string{"HOST=example.com", "PORT=443"}, false},
+		{"cert placeholders", []string{"CERT={{CERTIFICATE_PEM}}", "NAME={{CERTIFICATE_NAME}}"}, false},
+		{"key pem", []string{"HOST=example.com", "KEY={{PRIVATE_KEY_PEM}}"}, true},
+		{"key name", []string{"KEY_NAME={{PRIVATE_KEY_NAME}}"}, true},
+		{"lower case", []string{"key={{private_key_pem}}"}, true},
+		{"within value", []string{"FILES=cert:{{CERTIFICATE_PEM}};key:{{PRIVATE_KEY_PEM}}"}, true},
+	}
+
+	for _, test := range tests {
+		if uses := usesKeyPlaceholder(test.environment); uses != test.uses {
+			t.Errorf("%s: uses key placeholder is %t, expected %t", test.name, uses, test.uses)
+		}
+	}
+}
+
+func TestCertificates_ValidateExternalCsrDetailsUpdate(t *testing.T) {
+	cert := Certificate{
+		Subject:         "example.com",
+		SubjectAltNames: []string{"www.example.com"},
+		ExternalCsrPem:  testCsrPem(t, "example.com", []string{"www.example.com"}, nil),
+	}
+
+	// csrs for updates
+	addedAltCsr := testCsrPem(t, "example.com", []string{"www.example.com", "api.example.com"}, nil)
+	otherSubjectCsr := testCsrPem(t, "example.net", []string{"www.example.com"}, nil)
+
+	keyId := 1
+	zero := 0
+	rotate := 30
+	empty := ""
+	org := "Example Org"
+	client := "https://client.example.com:5055"
+	bad := "bad"
+
+	tests := []struct {
+		name    string
+		payload DetailsUpdatePayload
+		err     error
+	}{
+		{"no changes", DetailsUpdatePayload{}, nil},
+		{"same alt names", DetailsUpdatePayload{SubjectAltNames: []string{"www.example.com"}}, nil},
+		{"new csr with new alt names", DetailsUpdatePayload{ExternalCsrPem: &addedAltCsr, SubjectAltNames: []string{"www.example.com", "api.example.com"}}, nil},
+		{"blank fields", DetailsUpdatePayload{Organization: &empty, City: &empty}, nil},
+		{"no key rotation", DetailsUpdatePayload{KeyRotationInterval: &zero}, nil},
+		{"blank client", DetailsUpdatePayload{PostProcessingClientAddress: &empty}, nil},
+		{"cert placeholders", DetailsUpdatePayload{PostProcessingEnvironment: []string{"CERT={{CERTIFICATE_PEM}}"}}, nil},
+
+		{"switch to key", DetailsUpdatePayload{PrivateKeyId: &keyId}, ErrExternalCsrModeChange},
+		{"bad csr", DetailsUpdatePayload{ExternalCsrPem: &bad}, ErrExternalCsrBad},
+		{"new csr without new alt names", DetailsUpdatePayload{ExternalCsrPem: &addedAltCsr}, ErrExternalCsrNames},
+		{"new alt names without new csr", DetailsUpdatePayload{SubjectAltNames: []string{"www.example.com", "api.example.com"}}, ErrExternalCsrNames},
+		{"removed alt names", DetailsUpdatePayload{SubjectAltNames: []string{}}, ErrExternalCsrNames},
+		{"new csr with other subject", DetailsUpdatePayload{ExternalCsrPem: &otherSubjectCsr}, ErrExternalCsrNames},
+		{"organization", DetailsUpdatePayload{Organization: &org}, ErrExternalCsrFields},
+		{"extra extensions", DetailsUpdatePayload{CSRExtraExtensions: []CertExtensionJSON{{}}}, ErrExternalCsrFields},
+		{"key rotation", DetailsUpdatePayload{KeyRotationInterval: &rotate}, ErrExternalCsrKeyRotation},
+		{"client", DetailsUpdatePayload{PostProcessingClientAddress: &client}, ErrExternalCsrClient},
+		{"key placeholder", DetailsUpdatePayload{PostProcessingEnvironment: []string{"KEY={{PRIVATE_KEY_PEM}}"}}, ErrExternalCsrPlaceholder},
+	}
+
+	for _, test := range tests {
+		err := validateExternalCsrDetailsUpdate(cert, test.payload)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error %v, expected %v", test.name, err, test.err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package certificates
 
 import (
 	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/domain/private_cas"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
@@ -88,6 +90,10 @@ func (service *Service) GetOneCert(w http.ResponseWriter, r *http.Request) *outp
 	response.StatusCode = http.StatusOK
 	response.Message = "ok"
 	response.Certificate = cert.detailedResponse()
+	// post processing secrets are only for admins
+	if !auth.RequestPermits(r, auth.RoleAdmin) {
+		response.Certificate.redactSecrets()
+	}
 
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
@@ -104,6 +110,7 @@ type newCertOptions struct {
 		AvailableKeys  []private_keys.KeySummaryResponse `json:"private_keys"`
 		KeyAlgorithms  []key_crypto.Algorithm            `json:"key_algorithms"`
 		UsableAccounts []usableAccount                   `json:"acme_accounts"`
+		PrivateCAs     []private_cas.CASummaryResponse   `json:"private_cas"`
 	} `json:"certificate_options"`
 }
 
@@ -164,6 +171,18 @@ func (service *Service) GetNewCertOptions(w http.ResponseWriter, r *http.Request
 		outputAccounts = append(outputAccounts, acct)
 	}
 
+	// usable private cas
+	cas, err := service.privateCAs.GetUsablePrivateCAs()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	outputCAs := []private_cas.CASummaryResponse{}
+	for i := range cas {
+		outputCAs = append(outputCAs, cas[i].SummaryResponse())
+	}
+
 	// write response
 	response := &newCertOptions{}
 	response.StatusCode = http.StatusOK
@@ -171,6 +190,7 @@ func (service *Service) GetNewCertOptions(w http.ResponseWriter, r *http.Request
 	response.CertificateOptions.AvailableKeys = outputKeys
 	response.CertificateOptions.KeyAlgorithms = key_crypto.ListOfAlgorithms()
 	response.CertificateOptions.UsableAccounts = outputAccounts
+	response.CertificateOptions.PrivateCAs = outputCAs
 
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
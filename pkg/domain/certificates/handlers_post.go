@@ -1,11 +1,14 @@
 package certificates
 
 import (
+	"certwarden-backend/pkg/apikeyhash"
+	"certwarden-backend/pkg/domain/acme_accounts"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/randomness"
 	"certwarden-backend/pkg/validation"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,6 +26,7 @@ type NewPayload struct {
 	PrivateKeyID                *int                `json:"private_key_id"`
 	NewKeyAlgorithmValue        *string             `json:"algorithm_value"`
 	AcmeAccountID               *int                `json:"acme_account_id"`
+	PrivateCAID                 *int                `json:"private_ca_id"`
 	Subject                     *string             `json:"subject"`
 	SubjectAltNames             []string            `json:"subject_alts"`
 	Organization                *string             `json:"organization"`
@@ -37,8 +41,12 @@ type NewPayload struct {
 	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
 	PostProcessingClientKeyB64  string              `json:"-"`
 	Profile                     *string             `json:"profile"`
-	ApiKey                      string              `json:"-"`
-	ApiKeyViaUrl                bool                `json:"-"`
+	RequestedValidityHours      *int                `json:"requested_validity_hours"`
+	KeyRotationInterval         *int                `json:"key_rotation_interval"`
+	RenewalPolicy               *RenewalPolicy      `json:"renewal_policy"`
+	MaintenanceWindowID         *int                `json:"maintenance_window_id"`
+	ExternalCsrPem              *string             `json:"external_csr_pem"`
+	ApiKeyHash                  string              `json:"-"`
 	CreatedAt                   int                 `json:"-"`
 	UpdatedAt                   int                 `json:"-"`
 }
@@ -66,16 +74,35 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	if payload.Description == nil {
 		payload.Description = new(string)
 	}
+	// external csr (if blank, not using one)
+	var externalCsr *x509.CertificateRequest
+	if payload.ExternalCsrPem != nil && *payload.ExternalCsrPem == "" {
+		payload.ExternalCsrPem = nil
+	}
+	if payload.ExternalCsrPem != nil {
+		// can't also specify a key
+		if payload.PrivateKeyID != nil || (payload.NewKeyAlgorithmValue != nil && *payload.NewKeyAlgorithmValue != "") {
+			service.logger.Debug(ErrExternalCsrAndKey)
+			return output.JsonErrValidationFailed(ErrExternalCsrAndKey)
+		}
+		externalCsr, err = parseExternalCsr(*payload.ExternalCsrPem)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
 	// private key
-	// if key id not specified
-	if payload.PrivateKeyID == nil {
+	// if key id not specified (and not using external csr)
+	if payload.PrivateKeyID == nil && externalCsr == nil {
 		service.logger.Debug(ErrKeyIdBad)
 		return output.JsonErrValidationFailed(ErrKeyIdBad)
 	}
 	// keep track if new key will be generated and saved
 	generatedKeyPem := ""
-	// if new key id specified
-	if validation.IsIdNew(*payload.PrivateKeyID) {
+	if externalCsr != nil {
+		// no key to validate
+	} else if validation.IsIdNew(*payload.PrivateKeyID) {
+		// new key id specified
 		// confirm algorithm is specified
 		if payload.NewKeyAlgorithmValue == nil || *payload.NewKeyAlgorithmValue == "" {
 			service.logger.Debug(ErrKeyAlgorithmNone)
@@ -105,17 +132,44 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 			return output.JsonErrValidationFailed(ErrKeyIdBad)
 		}
 	}
-	// acme account
-	if payload.AcmeAccountID == nil {
-		err = errors.New("acme account id is unspecified")
+	// issuer: acme account -or- private ca (exactly one)
+	if (payload.AcmeAccountID == nil) == (payload.PrivateCAID == nil) {
+		err = errors.New("must specify exactly one of acme account id or private ca id")
 		service.logger.Debug(err)
 		return output.JsonErrValidationFailed(err)
 	}
-	acctUsable, acct := service.accounts.AccountUsable(*payload.AcmeAccountID)
-	if !acctUsable {
-		err = errors.New("acme account id does not exist or is not usable")
-		service.logger.Debug(err)
-		return output.JsonErrValidationFailed(err)
+	var acct *acme_accounts.Account
+	if payload.AcmeAccountID != nil {
+		var acctUsable bool
+		acctUsable, acct = service.accounts.AccountUsable(*payload.AcmeAccountID)
+		if !acctUsable {
+			err = errors.New("acme account id does not exist or is not usable")
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	} else {
+		caUsable, _ := service.privateCAs.PrivateCAUsable(*payload.PrivateCAID)
+		if !caUsable {
+			err = errors.New("private ca id does not exist or is not usable")
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+	// ip addresses are saved in canonical form so they match the order identifiers
+	if payload.Subject != nil {
+		*payload.Subject = canonicalName(*payload.Subject)
+	}
+	payload.SubjectAltNames = canonicalNames(payload.SubjectAltNames)
+	// external csr names -- if subject isn't specified, use the csr's names; otherwise
+	// they must match the csr
+	if externalCsr != nil {
+		if payload.Subject == nil {
+			payload.Subject = new(string)
+			*payload.Subject, payload.SubjectAltNames = externalCsrNames(externalCsr)
+		} else if !externalCsrNamesMatch(externalCsr, *payload.Subject, payload.SubjectAltNames) {
+			service.logger.Debug(ErrExternalCsrNames)
+			return output.JsonErrValidationFailed(ErrExternalCsrNames)
+		}
 	}
 	// subject
 	if payload.Subject == nil || !subjectValid(*payload.Subject) {
@@ -132,6 +186,12 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	if payload.Profile == nil {
 		payload.Profile = new(string)
 	} else if *payload.Profile != "" {
+		// profiles are an acme feature
+		if acct == nil {
+			service.logger.Debug(ErrProfilePrivateCA)
+			return output.JsonErrValidationFailed(ErrProfilePrivateCA)
+		}
+
 		// specified, validate against acme service
 		acmeService, err := service.acmeServerService.AcmeService(acct.AcmeServer.ID)
 		if err != nil {
@@ -146,6 +206,36 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 		}
 	}
 
+	// requested validity -- issuer default if not specified
+	if payload.RequestedValidityHours == nil {
+		payload.RequestedValidityHours = new(int)
+	} else if !requestedValidityHoursValid(*payload.RequestedValidityHours) {
+		service.logger.Debug(ErrRequestedValidityBad)
+		return output.JsonErrValidationFailed(ErrRequestedValidityBad)
+	}
+	// key rotation -- never if not specified
+	if payload.KeyRotationInterval == nil {
+		payload.KeyRotationInterval = new(int)
+	} else if !keyRotationIntervalValid(*payload.KeyRotationInterval) {
+		service.logger.Debug(ErrKeyRotationIntervalBad)
+		return output.JsonErrValidationFailed(ErrKeyRotationIntervalBad)
+	} else if externalCsr != nil && *payload.KeyRotationInterval != 0 {
+		service.logger.Debug(ErrExternalCsrKeyRotation)
+		return output.JsonErrValidationFailed(ErrExternalCsrKeyRotation)
+	}
+	// renewal policy -- global default for everything if not specified
+	if payload.RenewalPolicy == nil {
+		payload.RenewalPolicy = new(RenewalPolicy)
+	} else if !payload.RenewalPolicy.Valid() {
+		service.logger.Debug(ErrRenewalPolicyBad)
+		return output.JsonErrValidationFailed(ErrRenewalPolicyBad)
+	}
+	// maintenance window -- none if not specified (or 0)
+	if payload.MaintenanceWindowID != nil && *payload.MaintenanceWindowID != 0 && !service.maintenanceWindows.MaintenanceWindowExists(*payload.MaintenanceWindowID) {
+		service.logger.Debug(ErrMaintenanceWindowIdBad)
+		return output.JsonErrValidationFailed(ErrMaintenanceWindowIdBad)
+	}
+
 	// CSR
 	// set to blank if don't exist
 	// TODO: Do any validation of CSR components?
@@ -174,6 +264,13 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 		}
 	}
 
+	// an external csr already contains all of its fields
+	if externalCsr != nil && (*payload.Organization != "" || *payload.OrganizationalUnit != "" || *payload.Country != "" ||
+		*payload.State != "" || *payload.City != "" || len(payload.CSRExtraExtensions) > 0) {
+		service.logger.Debug(ErrExternalCsrFields)
+		return output.JsonErrValidationFailed(ErrExternalCsrFields)
+	}
+
 	if payload.PreferredRootCN == nil {
 		payload.PreferredRootCN = new(string)
 	}
@@ -185,10 +282,19 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	if payload.PostProcessingEnvironment == nil {
 		payload.PostProcessingEnvironment = []string{}
 	}
+	// the key isn't available to post processing if using an external csr
+	if externalCsr != nil && usesKeyPlaceholder(payload.PostProcessingEnvironment) {
+		service.logger.Debug(ErrExternalCsrPlaceholder)
+		return output.JsonErrValidationFailed(ErrExternalCsrPlaceholder)
+	}
 	// post processing address
 	if payload.PostProcessingClientAddress == nil {
 		payload.PostProcessingClientAddress = new(string)
 	} else if *payload.PostProcessingClientAddress != "" {
+		if externalCsr != nil {
+			service.logger.Debug(ErrExternalCsrClient)
+			return output.JsonErrValidationFailed(ErrExternalCsrClient)
+		}
 		valid := validation.DomainValid(*payload.PostProcessingClientAddress, false)
 		if !valid {
 			service.logger.Debug(ErrClientAddressBad)
@@ -198,6 +304,7 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	// end validation
 
 	// if new private key was generated, save it to storage
+	keyApiKey := ""
 	if generatedKeyPem != "" {
 		// create new key payload
 		newKeyPayload := private_keys.NewPayload{
@@ -206,10 +313,14 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 			AlgorithmValue: payload.NewKeyAlgorithmValue,
 			PemContent:     &generatedKeyPem,
 			ApiKeyDisabled: new(bool),
-			ApiKeyViaUrl:   payload.ApiKeyViaUrl,
 		}
 		// set additional new key payload fields
-		newKeyPayload.ApiKey, err = randomness.GenerateApiKey()
+		keyApiKeySecret, err := randomness.GenerateApiKey()
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+		newKeyPayload.ApiKeyHash, err = apikeyhash.Hash(keyApiKeySecret)
 		if err != nil {
 			service.logger.Error(err)
 			return output.JsonErrInternal(err)
@@ -219,21 +330,26 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 		newKeyPayload.UpdatedAt = payload.CreatedAt
 
 		// save new key to storage, and set the cert key id based on returned key's id
-		newKey, err := service.storage.PostNewKey(newKeyPayload)
+		newKey, keyApiKeyId, err := service.storage.PostNewKey(newKeyPayload)
 		if err != nil {
 			service.logger.Error(err)
 			return output.JsonErrStorageGeneric(err)
 		}
 		*payload.PrivateKeyID = newKey.ID
+		keyApiKey = apikeyhash.Format(keyApiKeyId, keyApiKeySecret)
 	}
 
 	// add additional details to the payload before saving
-	payload.ApiKey, err = randomness.GenerateApiKey()
+	apiKeySecret, err := randomness.GenerateApiKey()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+	payload.ApiKeyHash, err = apikeyhash.Hash(apiKeySecret)
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrInternal(err)
 	}
-	payload.ApiKeyViaUrl = false
 	payload.CreatedAt = int(time.Now().Unix())
 	payload.UpdatedAt = payload.CreatedAt
 	// if client address specified, generate key to save (b64 raw url encoded)
@@ -247,7 +363,7 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	}
 
 	// save new cert
-	newCert, err := service.storage.PostNewCert(payload)
+	newCert, apiKeyId, err := service.storage.PostNewCert(payload)
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
@@ -258,61 +374,9 @@ func (service *Service) PostNewCert(w http.ResponseWriter, r *http.Request) *out
 	response.StatusCode = http.StatusCreated
 	response.Message = "created certificate"
 	response.Certificate = newCert.detailedResponse()
-
-	err = service.output.WriteJSON(w, response)
-	if err != nil {
-		service.logger.Errorf("failed to write json (%s)", err)
-		return output.JsonErrWriteJsonError(err)
-	}
-
-	return nil
-}
-
-// StageNewApiKey generates a new API key and places it in the cert api_key_new
-func (service *Service) StageNewApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
-	// get id param
-	idParam := httprouter.ParamsFromContext(r.Context()).ByName("certid")
-	certId, err := strconv.Atoi(idParam)
-	if err != nil {
-		service.logger.Debug(err)
-		return output.JsonErrValidationFailed(err)
-	}
-
-	// validation
-	// get cert (validate exists)
-	cert, outErr := service.GetCertificate(certId)
-	if outErr != nil {
-		return outErr
-	}
-
-	// verify new api key is empty
-	if cert.ApiKeyNew != "" {
-		err = errors.New("new api key already exists")
-		service.logger.Debug(err)
-		return output.JsonErrValidationFailed(err)
-	}
-	// validation -- end
-
-	// generate new api key
-	newApiKey, err := randomness.GenerateApiKey()
-	if err != nil {
-		service.logger.Error(err)
-		return output.JsonErrInternal(err)
-	}
-
-	// update storage
-	err = service.storage.PutCertNewApiKey(certId, newApiKey, int(time.Now().Unix()))
-	if err != nil {
-		service.logger.Error(err)
-		return output.JsonErrStorageGeneric(err)
-	}
-	cert.ApiKeyNew = newApiKey
-
-	// write response
-	response := &certificateResponse{}
-	response.StatusCode = http.StatusCreated
-	response.Message = "certificate new api key created"
-	response.Certificate = cert.detailedResponse()
+	// only time the plaintext api key(s) are available
+	response.Certificate.ApiKey = apikeyhash.Format(apiKeyId, apiKeySecret)
+	response.Certificate.PrivateKeyApiKey = keyApiKey
 
 	err = service.output.WriteJSON(w, response)
 	if err != nil {
@@ -31,9 +31,11 @@ type DetailsUpdatePayload struct {
 	PostProcessingEnvironment   []string            `json:"post_processing_environment"`
 	PostProcessingClientAddress *string             `json:"post_processing_client_address"`
 	Profile                     *string             `json:"profile"`
-	ApiKey                      *string             `json:"api_key"`
-	ApiKeyNew                   *string             `json:"api_key_new"`
-	ApiKeyViaUrl                *bool               `json:"api_key_via_url"`
+	RequestedValidityHours      *int                `json:"requested_validity_hours"`
+	KeyRotationInterval         *int                `json:"key_rotation_interval"`
+	RenewalPolicy               *RenewalPolicy      `json:"renewal_policy"`
+	MaintenanceWindowID         *int                `json:"maintenance_window_id"`
+	ExternalCsrPem              *string             `json:"external_csr_pem"`
 	UpdatedAt                   int                 `json:"-"`
 }
 
@@ -74,6 +76,8 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 		return output.JsonErrValidationFailed(ErrKeyIdBad)
 	}
 	// subject alts (optional)
+	// ip addresses are saved in canonical form so they match the order identifiers
+	payload.SubjectAltNames = canonicalNames(payload.SubjectAltNames)
 	// if new alts are being specified
 	if payload.SubjectAltNames != nil {
 		if !subjectAltsValid(payload.SubjectAltNames) {
@@ -91,6 +95,12 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 	}
 	// profile Extension -- validate if specified
 	if payload.Profile != nil && *payload.Profile != "" {
+		// profiles are an acme feature
+		if cert.PrivateCA != nil {
+			service.logger.Debug(ErrProfilePrivateCA)
+			return output.JsonErrValidationFailed(ErrProfilePrivateCA)
+		}
+
 		// specified, validate against acme service
 		acmeService, err := service.acmeServerService.AcmeService(cert.CertificateAccount.AcmeServer.ID)
 		if err != nil {
@@ -104,15 +114,25 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 			return output.JsonErrValidationFailed(err)
 		}
 	}
-	// api key must be at least 10 characters long
-	if payload.ApiKey != nil && len(*payload.ApiKey) < 10 {
-		service.logger.Debug(ErrApiKeyBad)
-		return output.JsonErrValidationFailed(ErrApiKeyBad)
+	// requested validity (optional)
+	if payload.RequestedValidityHours != nil && !requestedValidityHoursValid(*payload.RequestedValidityHours) {
+		service.logger.Debug(ErrRequestedValidityBad)
+		return output.JsonErrValidationFailed(ErrRequestedValidityBad)
+	}
+	// key rotation (optional)
+	if payload.KeyRotationInterval != nil && !keyRotationIntervalValid(*payload.KeyRotationInterval) {
+		service.logger.Debug(ErrKeyRotationIntervalBad)
+		return output.JsonErrValidationFailed(ErrKeyRotationIntervalBad)
 	}
-	// api key new must be at least 10 characters long
-	if payload.ApiKeyNew != nil && *payload.ApiKeyNew != "" && len(*payload.ApiKeyNew) < 10 {
-		service.logger.Debug(ErrApiKeyNewBad)
-		return output.JsonErrValidationFailed(ErrApiKeyNewBad)
+	// renewal policy (optional)
+	if payload.RenewalPolicy != nil && !payload.RenewalPolicy.Valid() {
+		service.logger.Debug(ErrRenewalPolicyBad)
+		return output.JsonErrValidationFailed(ErrRenewalPolicyBad)
+	}
+	// maintenance window (optional, 0 removes)
+	if payload.MaintenanceWindowID != nil && *payload.MaintenanceWindowID != 0 && !service.maintenanceWindows.MaintenanceWindowExists(*payload.MaintenanceWindowID) {
+		service.logger.Debug(ErrMaintenanceWindowIdBad)
+		return output.JsonErrValidationFailed(ErrMaintenanceWindowIdBad)
 	}
 	// TODO: Do any validation of CSR components?
 
@@ -138,6 +158,21 @@ func (service *Service) PutDetailsCert(w http.ResponseWriter, r *http.Request) *
 		}
 	}
 
+	// external csr (optional)
+	if cert.HasExternalCsr() {
+		err = validateExternalCsrDetailsUpdate(cert, payload)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	} else if payload.ExternalCsrPem != nil && *payload.ExternalCsrPem != "" {
+		service.logger.Debug(ErrExternalCsrModeChange)
+		return output.JsonErrValidationFailed(ErrExternalCsrModeChange)
+	} else {
+		// blank is no change
+		payload.ExternalCsrPem = nil
+	}
+
 	// end validation
 
 	// add additional details to the payload before saving
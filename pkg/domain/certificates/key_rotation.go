@@ -0,0 +1,19 @@
+package certificates
+
+// maxKeyRotationInterval is the largest number of issuances a key rotation interval
+// may specify
+const maxKeyRotationInterval = 100
+
+// keyRotationIntervalValid returns true if interval is 0 (never rotate) or a sane
+// number of issuances
+func keyRotationIntervalValid(interval int) bool {
+	return interval >= 0 && interval <= maxKeyRotationInterval
+}
+
+// KeyRotationDue returns true if the cert's private key should be replaced with a
+// new key before the next issuance. issuedWithKey is the number of valid orders of
+// the cert that were finalized with the current key. A KeyRotationInterval of 0
+// never rotates, 1 rotates for every issuance, and N rotates every N issuances.
+func (cert *Certificate) KeyRotationDue(issuedWithKey int) bool {
+	return cert.KeyRotationInterval > 0 && issuedWithKey >= cert.KeyRotationInterval
+}
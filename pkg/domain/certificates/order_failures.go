@@ -0,0 +1,31 @@
+package certificates
+
+// OrderFailures is a certificate's streak of consecutive failed orders. Failed orders are
+// automatically retried with backoff until an order is valid or the retries are exhausted.
+type OrderFailures struct {
+	// Streak is the number of consecutive failed orders (0 after a valid order)
+	Streak int
+	// LastFailureAt is the unix time of the most recent failure
+	LastFailureAt int
+	// LastError is the error of the most recent failure
+	LastError string
+	// NextRetryAt is the unix time the next retry is scheduled for (nil if none)
+	NextRetryAt *int
+}
+
+// orderFailuresResponse is the JSON response of OrderFailures
+type orderFailuresResponse struct {
+	Streak        int    `json:"streak"`
+	LastFailureAt int    `json:"last_failure_at"`
+	LastError     string `json:"last_error"`
+	NextRetryAt   *int   `json:"next_retry_at"`
+}
+
+func (failures OrderFailures) response() orderFailuresResponse {
+	return orderFailuresResponse{
+		Streak:        failures.Streak,
+		LastFailureAt: failures.LastFailureAt,
+		LastError:     failures.LastError,
+		NextRetryAt:   failures.NextRetryAt,
+	}
+}
@@ -0,0 +1,107 @@
+package certificates
+
+// RenewalPolicy controls when a certificate is automatically renewed. A global default
+// policy is configured for the app and each certificate may override any of its fields;
+// a nil field uses the default's value.
+type RenewalPolicy struct {
+	// AutoRenew enables automatic ordering of the certificate when it is expiring
+	AutoRenew *bool `yaml:"auto_renew" json:"auto_renew"`
+	// TrustARI uses the renewal window suggested by the ACME Server (ARI), when the
+	// server supports it, instead of the window calculated from this policy
+	TrustARI *bool `yaml:"trust_ari" json:"trust_ari"`
+	// DaysBeforeExpiry renews the certificate this many days before it expires. If 0,
+	// the remaining valid fractions are used instead.
+	DaysBeforeExpiry *int `yaml:"days_before_expiry" json:"days_before_expiry"`
+	// RemainingValidFraction renews a certificate when this fraction of its validity
+	// remains
+	RemainingValidFraction *float64 `yaml:"remaining_valid_fraction" json:"remaining_valid_fraction"`
+	// ShortLivedValidityDays is the validity period under which a certificate is
+	// considered short-lived
+	ShortLivedValidityDays *int `yaml:"short_lived_validity_days" json:"short_lived_validity_days"`
+	// ShortLivedRemainingValidFraction is RemainingValidFraction for short-lived
+	// certificates
+	ShortLivedRemainingValidFraction *float64 `yaml:"short_lived_remaining_valid_fraction" json:"short_lived_remaining_valid_fraction"`
+}
+
+const (
+	// maxRenewalDaysBeforeExpiry is the largest number of days before expiry a policy
+	// may specify (10 years)
+	maxRenewalDaysBeforeExpiry = 10 * 365
+	// maxShortLivedValidityDays is the largest short-lived validity period a policy may
+	// specify
+	maxShortLivedValidityDays = 365
+)
+
+// DefaultRenewalPolicy returns the policy used for any field that is not set in the
+// configured default policy or a certificate's policy
+func DefaultRenewalPolicy() RenewalPolicy {
+	autoRenew := true
+	trustARI := true
+	daysBeforeExpiry := 0
+	remainingValidFraction := 0.333
+	shortLivedValidityDays := 10
+	shortLivedRemainingValidFraction := 0.5
+
+	return RenewalPolicy{
+		AutoRenew:                        &autoRenew,
+		TrustARI:                         &trustARI,
+		DaysBeforeExpiry:                 &daysBeforeExpiry,
+		RemainingValidFraction:           &remainingValidFraction,
+		ShortLivedValidityDays:           &shortLivedValidityDays,
+		ShortLivedRemainingValidFraction: &shortLivedRemainingValidFraction,
+	}
+}
+
+// Merge returns a copy of policy with each field that is set in override replaced by
+// the override's value
+func (policy RenewalPolicy) Merge(override RenewalPolicy) RenewalPolicy {
+	if override.AutoRenew != nil {
+		policy.AutoRenew = override.AutoRenew
+	}
+	if override.TrustARI != nil {
+		policy.TrustARI = override.TrustARI
+	}
+	if override.DaysBeforeExpiry != nil {
+		policy.DaysBeforeExpiry = override.DaysBeforeExpiry
+	}
+	if override.RemainingValidFraction != nil {
+		policy.RemainingValidFraction = override.RemainingValidFraction
+	}
+	if override.ShortLivedValidityDays != nil {
+		policy.ShortLivedValidityDays = override.ShortLivedValidityDays
+	}
+	if override.ShortLivedRemainingValidFraction != nil {
+		policy.ShortLivedRemainingValidFraction = override.ShortLivedRemainingValidFraction
+	}
+
+	return policy
+}
+
+// Valid returns true if every field of the policy that is set has a sane value
+func (policy RenewalPolicy) Valid() bool {
+	if policy.DaysBeforeExpiry != nil && (*policy.DaysBeforeExpiry < 0 || *policy.DaysBeforeExpiry > maxRenewalDaysBeforeExpiry) {
+		return false
+	}
+	if policy.RemainingValidFraction != nil && !renewalFractionValid(*policy.RemainingValidFraction) {
+		return false
+	}
+	if policy.ShortLivedValidityDays != nil && (*policy.ShortLivedValidityDays < 0 || *policy.ShortLivedValidityDays > maxShortLivedValidityDays) {
+		return false
+	}
+	if policy.ShortLivedRemainingValidFraction != nil && !renewalFractionValid(*policy.ShortLivedRemainingValidFraction) {
+		return false
+	}
+
+	return true
+}
+
+// renewalFractionValid returns true if fraction is strictly between 0 and 1
+func renewalFractionValid(fraction float64) bool {
+	return fraction > 0 && fraction < 1
+}
+
+// EffectiveRenewalPolicy returns the cert's renewal policy merged onto the specified
+// default policy
+func (cert *Certificate) EffectiveRenewalPolicy(defaultPolicy RenewalPolicy) RenewalPolicy {
+	return defaultPolicy.Merge(cert.RenewalPolicy)
+}
@@ -3,6 +3,8 @@ package certificates
 import (
 	"certwarden-backend/pkg/domain/acme_accounts"
 	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"certwarden-backend/pkg/domain/private_cas"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/pagination_sort"
@@ -21,6 +23,8 @@ type App interface {
 	GetAcmeServerService() *acme_servers.Service
 	GetKeysService() *private_keys.Service
 	GetAcctsService() *acme_accounts.Service
+	GetPrivateCAsService() *private_cas.Service
+	GetMaintenanceWindowsService() *maintenance_windows.Service
 }
 
 // Storage interface for storage functions
@@ -29,26 +33,26 @@ type Storage interface {
 	GetOneCertById(id int) (cert Certificate, err error)
 	GetOneCertByName(name string) (cert Certificate, err error)
 
-	PostNewCert(payload NewPayload) (Certificate, error)
+	PostNewCert(payload NewPayload) (cert Certificate, apiKeyId int, err error)
 
 	PutDetailsCert(payload DetailsUpdatePayload) (Certificate, error)
-	PutCertApiKey(certId int, apiKey string, updateTimeUnix int) (err error)
-	PutCertNewApiKey(certId int, newApiKey string, updateTimeUnix int) (err error)
 	PutCertClientKey(certId int, newClientKeyB64 string, updateTimeUnix int) (err error)
 
 	DeleteCert(id int) (err error)
 
-	PostNewKey(private_keys.NewPayload) (private_keys.Key, error)
+	PostNewKey(private_keys.NewPayload) (key private_keys.Key, apiKeyId int, err error)
 }
 
 // Keys service struct
 type Service struct {
-	logger            *zap.SugaredLogger
-	output            *output.Service
-	storage           Storage
-	acmeServerService *acme_servers.Service
-	keys              *private_keys.Service
-	accounts          *acme_accounts.Service
+	logger             *zap.SugaredLogger
+	output             *output.Service
+	storage            Storage
+	acmeServerService  *acme_servers.Service
+	keys               *private_keys.Service
+	accounts           *acme_accounts.Service
+	privateCAs         *private_cas.Service
+	maintenanceWindows *maintenance_windows.Service
 }
 
 // NewService creates a new service
@@ -91,5 +95,17 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// private ca services
+	service.privateCAs = app.GetPrivateCAsService()
+	if service.privateCAs == nil {
+		return nil, errServiceComponent
+	}
+
+	// maintenance window services
+	service.maintenanceWindows = app.GetMaintenanceWindowsService()
+	if service.maintenanceWindows == nil {
+		return nil, errServiceComponent
+	}
+
 	return service, nil
 }
@@ -1,6 +1,7 @@
 package certificates
 
 import (
+	"certwarden-backend/pkg/acme"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
 	"certwarden-backend/pkg/validation"
@@ -21,13 +22,34 @@ var (
 	// name
 	ErrNameBad = errors.New("certificate name is not valid")
 
-	// api key
-	ErrApiKeyBad    = errors.New("api key is not valid (must be at least 10 chars in length)")
-	ErrApiKeyNewBad = errors.New("api key (new) is not valid (must be at least 10 chars in length)")
+	// profile
+	ErrProfilePrivateCA = errors.New("profiles are not supported for certificates issued by a private ca")
+
+	// validity
+	ErrRequestedValidityBad = errors.New("requested validity hours is not valid (must be 0 for the issuer's default, or between 1 hour and 10 years)")
+
+	// key rotation
+	ErrKeyRotationIntervalBad = errors.New("key rotation interval is not valid (must be 0 to never rotate, 1 to always rotate, or up to 100 issuances)")
+
+	// renewal policy
+	ErrRenewalPolicyBad = errors.New("renewal policy is not valid (days before expiry must be 0 to 3650, short-lived validity days 0 to 365, and remaining valid fractions between 0 and 1)")
+
+	// maintenance window
+	ErrMaintenanceWindowIdBad = errors.New("maintenance window id is invalid (use 0 for none)")
 
 	// domain
-	ErrDomainBad        = errors.New("domain or subject name not valid")
+	ErrDomainBad        = errors.New("domain, ip address, or subject name not valid")
 	ErrClientAddressBad = errors.New("client address is not valid")
+
+	// external csr
+	ErrExternalCsrBad         = errors.New("external csr is not a valid pem encoded certificate request")
+	ErrExternalCsrAndKey      = errors.New("external csr and private key (or key algorithm) both specified")
+	ErrExternalCsrNames       = errors.New("subject and subject alts must match the names in the external csr")
+	ErrExternalCsrFields      = errors.New("csr fields and csr extra extensions cannot be specified for a certificate using an external csr")
+	ErrExternalCsrKeyRotation = errors.New("key rotation is not supported for a certificate using an external csr")
+	ErrExternalCsrClient      = errors.New("post processing client is not supported for a certificate using an external csr (the client requires the private key)")
+	ErrExternalCsrPlaceholder = errors.New("private key placeholders cannot be used in post processing for a certificate using an external csr")
+	ErrExternalCsrModeChange  = errors.New("a certificate cannot be switched between using a private key and an external csr")
 )
 
 // GetCertificate returns the Certificate for the specified id.
@@ -107,14 +129,35 @@ func (service *Service) privateKeyIdValid(keyId int, certId *int) bool {
 	return false
 }
 
-// subjectValid validates domain name
-func subjectValid(domain string) bool {
-	// check domain is valid
-	return validation.DomainValid(domain, true)
+// subjectValid validates domain name or ip address
+func subjectValid(name string) bool {
+	// check domain is valid -or- ip address is valid
+	return validation.DomainValid(name, true) || validation.IPAddressValid(name)
+}
+
+// canonicalName returns the canonical form of an ip address (RFC 5952, with ipv4-mapped
+// addresses unmapped), which is the same form used for the ACME identifier. Any other name
+// is returned unchanged.
+func canonicalName(name string) string {
+	return acme.NewIdentifier(name).Value
+}
+
+// canonicalNames returns a slice of the canonical form of each name (nil if names is nil)
+func canonicalNames(names []string) []string {
+	if names == nil {
+		return nil
+	}
+
+	canonical := make([]string, len(names))
+	for i := range names {
+		canonical[i] = canonicalName(names[i])
+	}
+
+	return canonical
 }
 
-// subjectAltsValid validates each domain contained in the slice
-// of subject alt domain names
+// subjectAltsValid validates each domain or ip address contained in the slice
+// of subject alt names
 func subjectAltsValid(alts []string) bool {
 	for _, altName := range alts {
 		if !subjectValid(altName) {
@@ -0,0 +1,51 @@
+package certificates
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxRequestedValidityHours is the longest validity period a certificate may request
+// (10 years)
+const maxRequestedValidityHours = 10 * 365 * 24
+
+// requestedValidityTolerance is how far an issued certificate's validity period may
+// deviate from the requested period and still be considered a match. Issuers commonly
+// backdate NotBefore and may round the requested times.
+const requestedValidityTolerance = time.Hour
+
+// requestedValidityHoursValid returns true if hours is 0 (issuer default) or a sane
+// validity period
+func requestedValidityHoursValid(hours int) bool {
+	return hours == 0 || (hours >= 1 && hours <= maxRequestedValidityHours)
+}
+
+// RequestedValidity returns the length of the validity period the cert requests of its
+// issuer. If 0, the issuer's default validity is used.
+func (cert *Certificate) RequestedValidity() time.Duration {
+	return time.Duration(cert.RequestedValidityHours) * time.Hour
+}
+
+// CheckIssuedValidity returns an error if the validity period of an issued certificate
+// does not match the cert's requested validity. If requestedNotAfter is not nil, NotAfter
+// must match it; otherwise the length of the issued validity period must match.
+func (cert *Certificate) CheckIssuedValidity(notBefore time.Time, notAfter time.Time, requestedNotAfter *time.Time) error {
+	// issuer default, anything goes
+	if cert.RequestedValidityHours == 0 {
+		return nil
+	}
+
+	if requestedNotAfter != nil {
+		if notAfter.Sub(*requestedNotAfter).Abs() > requestedValidityTolerance {
+			return fmt.Errorf("certificates: issued certificate expires %s but %s was requested", notAfter.UTC().Format(time.RFC3339), requestedNotAfter.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	issuedValidity := notAfter.Sub(notBefore)
+	if (issuedValidity - cert.RequestedValidity()).Abs() > requestedValidityTolerance {
+		return fmt.Errorf("certificates: issued certificate is valid for %s but %s was requested", issuedValidity, cert.RequestedValidity())
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package download
+
+import (
+	"certwarden-backend/pkg/clientip"
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/output"
+	"net/http"
+	"time"
+)
+
+// checkApiKey verifies that apiKey is one of owner's api keys and that the key may be
+// used for request r. On success, the matched key's last use is recorded and the key
+// is returned.
+func (service *Service) checkApiKey(r *http.Request, owner api_keys.Owner, apiKey string, apiKeyViaUrl bool) (api_keys.ApiKey, *output.JsonError) {
+	keys, err := api_keys.Candidates(service.storage, owner, apiKey)
+	if err != nil {
+		service.logger.Error(err)
+		// exclude specific error since not authenticated
+		return api_keys.ApiKey{}, output.JsonErrStorageGeneric(nil)
+	}
+
+	now := time.Now()
+
+	key, err := api_keys.Authenticate(keys, apiKey, apiKeyViaUrl, clientip.Addr(r), now)
+	if err != nil {
+		service.logger.Debugf("download: api key rejected for client %s (%s)", clientip.String(r), err)
+		return api_keys.ApiKey{}, output.JsonErrUnauthorized
+	}
+
+	// record use, dont fail our though if this step fails, just log error
+	err = service.storage.PutApiKeyLastUsed(key.ID, now.Unix(), clientip.String(r))
+	if err != nil {
+		service.logger.Errorf("download: failed to update api key (id: %d) last used (%s)", key.ID, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,91 @@
+package download
+
+import (
+	"certwarden-backend/pkg/clientip"
+	"certwarden-backend/pkg/domain/api_keys"
+	"net/http"
+	"time"
+)
+
+// download output formats (as recorded in the download log)
+const (
+	formatPrivateKey       = "privatekey"
+	formatCertificate      = "certificate"
+	formatPrivateCert      = "privatecert"
+	formatPrivateCertChain = "privatecertchain"
+	formatCertRootChain    = "certrootchain"
+	formatPfx              = "pfx"
+)
+
+// LogEntry is a record of a single download made with an api key. One entry is
+// recorded for each api key used (i.e. downloads that include both a certificate and
+// its private key record an entry for each).
+type LogEntry struct {
+	ID    int
+	Owner api_keys.Owner
+	// ApiKeyID is nil if the api key has since been deleted
+	ApiKeyID      *int
+	ApiKeyName    string
+	ClientAddress string
+	UserAgent     string
+	Format        string
+	// OrderID is the order served (nil for private key only downloads)
+	OrderID   *int
+	CreatedAt time.Time
+}
+
+// logEntryResponse is the JSON response for a download log entry
+type logEntryResponse struct {
+	ID            int    `json:"id"`
+	CertificateID *int   `json:"certificate_id,omitempty"`
+	PrivateKeyID  *int   `json:"private_key_id,omitempty"`
+	ApiKeyID      *int   `json:"api_key_id"`
+	ApiKeyName    string `json:"api_key_name"`
+	ClientAddress string `json:"client_address"`
+	UserAgent     string `json:"user_agent"`
+	Format        string `json:"format"`
+	OrderID       *int   `json:"order_id"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+func (entry LogEntry) response() logEntryResponse {
+	resp := logEntryResponse{
+		ID:            entry.ID,
+		ApiKeyID:      entry.ApiKeyID,
+		ApiKeyName:    entry.ApiKeyName,
+		ClientAddress: entry.ClientAddress,
+		UserAgent:     entry.UserAgent,
+		Format:        entry.Format,
+		OrderID:       entry.OrderID,
+		CreatedAt:     entry.CreatedAt.Unix(),
+	}
+
+	switch entry.Owner.Type {
+	case api_keys.OwnerCertificate:
+		resp.CertificateID = &entry.Owner.ID
+	case api_keys.OwnerPrivateKey:
+		resp.PrivateKeyID = &entry.Owner.ID
+	}
+
+	return resp
+}
+
+// logDownload records that r downloaded format from key's owner. orderId is the order
+// served, if any. Failure to record is logged but does not fail the download.
+func (service *Service) logDownload(r *http.Request, key api_keys.ApiKey, format string, orderId *int, now time.Time) {
+	entry := LogEntry{
+		Owner:         key.Owner,
+		ApiKeyID:      &key.ID,
+		ApiKeyName:    key.Name,
+		ClientAddress: clientip.String(r),
+		UserAgent:     r.UserAgent(),
+		Format:        format,
+		OrderID:       orderId,
+		CreatedAt:     now,
+	}
+
+	err := service.storage.PostDownloadLogEntry(entry)
+	if err != nil {
+		service.logger.Errorf("download: failed to record download log entry for api key (id: %d) (%s)", key.ID, err)
+	}
+}
@@ -1,17 +1,20 @@
 package download
 
 import (
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
 	"errors"
+	"net/http"
 	"time"
 )
 
-// getKey returns the private key if the apiKey matches
-// the requested key. It also checks the apiKeyViaUrl property if
-// the client is making a request with the apiKey in the Url.
-func (service *Service) getKey(keyName string, apiKey string, apiKeyViaUrl bool) (private_keys.Key, *output.JsonError) {
+// getKey returns the private key if the apiKey matches one of
+// the requested key's api keys and that api key permits request r
+// (including when the client is making a request with the apiKey in the Url).
+// The download is recorded in the download log as format.
+func (service *Service) getKey(r *http.Request, keyName string, apiKey string, apiKeyViaUrl bool, format string) (private_keys.Key, *output.JsonError) {
 	// if apiKey is blank, definitely unauthorized
 	if apiKey == "" {
 		service.logger.Debug(errBlankApiKey)
@@ -39,23 +42,19 @@ func (service *Service) getKey(keyName string, apiKey string, apiKeyViaUrl bool)
 		return private_keys.Key{}, output.JsonErrUnauthorized
 	}
 
-	// if apiKey came from URL, and key does not support this, error
-	if apiKeyViaUrl && !key.ApiKeyViaUrl {
-		service.logger.Debug(errApiKeyFromUrlDisallowed)
-		return private_keys.Key{}, output.JsonErrUnauthorized
-	}
-
-	// verify apikey matches private key's apiKey (new or old)
-	if (apiKey != key.ApiKey) && (apiKey != key.ApiKeyNew) {
-		service.logger.Debug(errWrongApiKey)
-		return private_keys.Key{}, output.JsonErrUnauthorized
+	// verify apikey matches one of the private key's api keys
+	apiKeyUsed, outErr := service.checkApiKey(r, api_keys.Owner{Type: api_keys.OwnerPrivateKey, ID: key.ID}, apiKey, apiKeyViaUrl)
+	if outErr != nil {
+		return private_keys.Key{}, outErr
 	}
 
 	// before return, update key last access, dont fail our though if this step fails, just log error
-	err = service.storage.PutKeyLastAccess(key.ID, time.Now().Unix())
+	nowT := time.Now()
+	err = service.storage.PutKeyLastAccess(key.ID, nowT.Unix())
 	if err != nil {
 		service.logger.Errorf("download: failed to update key (id: %d) last access time (%s)", key.ID, err)
 	}
+	service.logDownload(r, apiKeyUsed, format, nil, nowT)
 
 	// return key
 	return key, nil
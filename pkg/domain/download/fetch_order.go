@@ -1,19 +1,22 @@
 package download
 
 import (
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/orders"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/storage"
 	"errors"
+	"net/http"
 	"strings"
 	"time"
 )
 
 // getCertNewestValidOrder returns the most recent valid order for the specified certificate if the
-// apiKey matches the requested cert. It also checks the apiKeyViaUrl property if the client is making
-// a request with the apiKey in the Url. includeKeyPEM controls if the key API key is also checked
-// and sensitive Private Key PEM data is included in the Order.
-func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys string, apiKeyViaUrl bool, includeKeyPEM bool) (orders.Order, *output.JsonError) {
+// apiKey matches one of the requested cert's api keys and that api key permits request r (including
+// when the client is making a request with the apiKey in the Url). includeKeyPEM controls if the key
+// API key is also checked and sensitive Private Key PEM data is included in the Order. The
+// download is recorded in the download log as format.
+func (service *Service) getCertNewestValidOrder(r *http.Request, certName string, apiKeyOrKeys string, apiKeyViaUrl bool, includeKeyPEM bool, format string) (orders.Order, *output.JsonError) {
 	// if apiKeyOrKeys is blank, definitely unauthorized
 	if apiKeyOrKeys == "" {
 		service.logger.Debug(errBlankApiKey)
@@ -41,16 +44,10 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 	// always check cert api key
 	certApiKey := apiKeys[0]
 
-	// if apiKey came from URL, and cert does not support this, error
-	if apiKeyViaUrl && !order.Certificate.ApiKeyViaUrl {
-		service.logger.Debug(errApiKeyFromUrlDisallowed)
-		return orders.Order{}, output.JsonErrUnauthorized
-	}
-
-	// verify cert apikey matches cert's cert apikey (new or old)
-	if (certApiKey != order.Certificate.ApiKey) && (certApiKey != order.Certificate.ApiKeyNew) {
-		service.logger.Debug(errWrongApiKey)
-		return orders.Order{}, output.JsonErrUnauthorized
+	// verify cert apikey matches one of the cert's api keys
+	certApiKeyUsed, outErr := service.checkApiKey(r, api_keys.Owner{Type: api_keys.OwnerCertificate, ID: order.Certificate.ID}, certApiKey, apiKeyViaUrl)
+	if outErr != nil {
+		return orders.Order{}, outErr
 	}
 
 	// pem cant be blank
@@ -68,13 +65,17 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 		}
 
 		// if only checking cert key, nuke key private data as a safety precaution
-		order.FinalizedKey.Pem = ""
+		if order.FinalizedKey != nil {
+			order.FinalizedKey.Pem = ""
+		}
 
 		// before return, update cert last access, dont fail our though if this step fails, just log error
-		err = service.storage.PutCertLastAccess(order.Certificate.ID, time.Now().Unix())
+		nowT := time.Now()
+		err = service.storage.PutCertLastAccess(order.Certificate.ID, nowT.Unix())
 		if err != nil {
 			service.logger.Errorf("download: failed to update cert (id: %d) last access time (%s)", order.Certificate.ID, err)
 		}
+		service.logDownload(r, certApiKeyUsed, format, &order.ID, nowT)
 
 		// return order without private key pem
 		return order, nil
@@ -92,6 +93,12 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 	// check key API key
 	keyApiKey := apiKeys[1]
 
+	// external csr certs don't have a private key to download
+	if order.Certificate.HasExternalCsr() {
+		service.logger.Debug(errExternalCsrNoKey)
+		return orders.Order{}, output.JsonErrNotFound(errExternalCsrNoKey)
+	}
+
 	// confirm the private key is valid
 	if order.FinalizedKey == nil {
 		service.logger.Debug(errFinalizedKeyMissing)
@@ -104,16 +111,10 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 		return orders.Order{}, output.JsonErrUnauthorized
 	}
 
-	// if apiKey came from URL, and key does not support this, error
-	if apiKeyViaUrl && !order.FinalizedKey.ApiKeyViaUrl {
-		service.logger.Debug(errApiKeyFromUrlDisallowed)
-		return orders.Order{}, output.JsonErrUnauthorized
-	}
-
-	// validate the apiKey for the private key is correct (new or old)
-	if (keyApiKey != order.FinalizedKey.ApiKey) && (keyApiKey != order.FinalizedKey.ApiKeyNew) {
-		service.logger.Debug(errWrongApiKey)
-		return orders.Order{}, output.JsonErrUnauthorized
+	// validate the apiKey matches one of the private key's api keys
+	keyApiKeyUsed, outErr := service.checkApiKey(r, api_keys.Owner{Type: api_keys.OwnerPrivateKey, ID: order.FinalizedKey.ID}, keyApiKey, apiKeyViaUrl)
+	if outErr != nil {
+		return orders.Order{}, outErr
 	}
 
 	// before return, update cert AND KEY last access, dont fail our though if this step fails, just log error
@@ -126,6 +127,8 @@ func (service *Service) getCertNewestValidOrder(certName string, apiKeyOrKeys st
 	if err != nil {
 		service.logger.Errorf("download: failed to update key (id: %d) last access time (%s)", order.FinalizedKey.ID, err)
 	}
+	service.logDownload(r, certApiKeyUsed, format, &order.ID, nowT)
+	service.logDownload(r, keyApiKeyUsed, format, &order.ID, nowT)
 
 	// return order
 	return order, nil
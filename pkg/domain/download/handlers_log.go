@@ -0,0 +1,158 @@
+package download
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var errOwnerIdBad = errors.New("id is invalid")
+
+// ownerFromParams returns the Owner of ownerType whose id is in the idParamName param,
+// after confirming the owner exists
+func (service *Service) ownerFromParams(r *http.Request, ownerType api_keys.OwnerType, idParamName string) (api_keys.Owner, *output.JsonError) {
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName(idParamName)
+	id, err := strconv.Atoi(idParam)
+	if err != nil || !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(errOwnerIdBad)
+		return api_keys.Owner{}, output.JsonErrValidationFailed(errOwnerIdBad)
+	}
+
+	switch ownerType {
+	case api_keys.OwnerCertificate:
+		_, err = service.storage.GetOneCertById(id)
+	case api_keys.OwnerPrivateKey:
+		_, err = service.storage.GetOneKeyById(id)
+	default:
+		err = errOwnerIdBad
+	}
+	if err != nil {
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return api_keys.Owner{}, output.JsonErrNotFound(err)
+		}
+		service.logger.Error(err)
+		return api_keys.Owner{}, output.JsonErrStorageGeneric(err)
+	}
+
+	return api_keys.Owner{Type: ownerType, ID: id}, nil
+}
+
+// downloadLogResponse is the JSON response for a portion of a download log
+type downloadLogResponse struct {
+	output.JsonResponse
+	TotalEntries int                `json:"total_records"`
+	Entries      []logEntryResponse `json:"download_log"`
+}
+
+// GetCertDownloadLog returns the download log of a certificate
+func (service *Service) GetCertDownloadLog(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.ownerFromParams(r, api_keys.OwnerCertificate, "certid")
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.getDownloadLog(w, r, owner)
+}
+
+// GetKeyDownloadLog returns the download log of a private key
+func (service *Service) GetKeyDownloadLog(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.ownerFromParams(r, api_keys.OwnerPrivateKey, "id")
+	if outErr != nil {
+		return outErr
+	}
+
+	return service.getDownloadLog(w, r, owner)
+}
+
+// getDownloadLog writes the download log entries of owner to the client
+func (service *Service) getDownloadLog(w http.ResponseWriter, r *http.Request, owner api_keys.Owner) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	entries, totalRows, err := service.storage.GetDownloadLogByOwner(owner, query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &downloadLogResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalEntries = totalRows
+	response.Entries = []logEntryResponse{}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, entry.response())
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// staleClientsResponse is the JSON response listing a certificate's stale clients
+type staleClientsResponse struct {
+	output.JsonResponse
+	NewestValidOrderID *int               `json:"newest_valid_order_id"`
+	StaleClients       []logEntryResponse `json:"stale_clients"`
+}
+
+// GetCertStaleClients returns the clients (by client address) whose most recent download
+// of the certificate served an order other than the certificate's newest valid order. Each
+// client's most recent download log entry is returned.
+func (service *Service) GetCertStaleClients(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	owner, outErr := service.ownerFromParams(r, api_keys.OwnerCertificate, "certid")
+	if outErr != nil {
+		return outErr
+	}
+
+	// newest valid order (if none, no client can be behind)
+	var newestOrderId *int
+	newestOrder, err := service.storage.GetCertNewestValidOrderById(owner.ID)
+	if err != nil && !errors.Is(err, storage.ErrNoRecord) {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	} else if err == nil {
+		newestOrderId = &newestOrder.ID
+	}
+
+	latestEntries, err := service.storage.GetCertLatestDownloadByClient(owner.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &staleClientsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.NewestValidOrderID = newestOrderId
+	response.StaleClients = []logEntryResponse{}
+	if newestOrderId != nil {
+		for _, entry := range latestEntries {
+			if entry.OrderID == nil || *entry.OrderID != *newestOrderId {
+				response.StaleClients = append(response.StaleClients, entry.response())
+			}
+		}
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -18,7 +18,7 @@ func (service *Service) DownloadCertViaHeader(w http.ResponseWriter, r *http.Req
 	apiKey := getApiKeyFromHeader(w, r)
 
 	// fetch the cert's newest order using the apiKey
-	order, err := service.getCertNewestValidOrder(certName, apiKey, false, false)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKey, false, false, formatCertificate)
 	if err != nil {
 		return err
 	}
@@ -40,7 +40,7 @@ func (service *Service) DownloadCertViaUrl(w http.ResponseWriter, r *http.Reques
 	apiKey := getApiKeyFromParams(params)
 
 	// fetch the cert's newest order using the apiKey
-	order, err := service.getCertNewestValidOrder(certName, apiKey, true, false)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKey, true, false, formatCertificate)
 	if err != nil {
 		return err
 	}
@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -16,8 +17,27 @@ import (
 )
 
 // modified Order to allow implementation of custom out functions
-// to properly output the desired content
-type pfxPrivateCertificateChain orders.Order
+// to properly output the desired content; password is the key's api key
+// that was used to download the pfx (api keys are only stored hashed)
+type pfxPrivateCertificateChain struct {
+	orders.Order
+	password string
+}
+
+// newPfxPrivateCertificateChain creates the pfx output of order, using the key
+// api key from apiKeysCombined (cert.key) as the pfx password
+func newPfxPrivateCertificateChain(order orders.Order, apiKeysCombined string) pfxPrivateCertificateChain {
+	password := ""
+	apiKeys := strings.Split(apiKeysCombined, ".")
+	if len(apiKeys) == 2 {
+		password = apiKeys[1]
+	}
+
+	return pfxPrivateCertificateChain{
+		Order:    order,
+		password: password,
+	}
+}
 
 // pfxPrivateCertificateChain Output Methods
 
@@ -26,7 +46,7 @@ func (pfxpcc pfxPrivateCertificateChain) FilenameNoExt() string {
 }
 
 func (pfxpcc pfxPrivateCertificateChain) Modtime() time.Time {
-	return orders.Order(pfxpcc).Modtime()
+	return pfxpcc.Order.Modtime()
 }
 
 // keyPemToKey returns the private key from pemBytes
@@ -127,14 +147,14 @@ func (pfxpcc pfxPrivateCertificateChain) PfxContent(legacy3DES bool) (pfxData []
 	}
 
 	// get cert and chain (if there is a chain)
-	cert, certChain, err := certPemToCerts([]byte(orders.Order(pfxpcc).PemContent()))
+	cert, certChain, err := certPemToCerts([]byte(pfxpcc.Order.PemContent()))
 	if err != nil {
 		return nil, err
 	}
 
 	// encode using legace pkcs12 (3DES)
 	if legacy3DES {
-		pfxData, err = pkcs12.Legacy.Encode(key, cert, certChain, pfxpcc.FinalizedKey.ApiKey)
+		pfxData, err = pkcs12.Legacy.Encode(key, cert, certChain, pfxpcc.password)
 		if err != nil {
 			return nil, err
 		}
@@ -143,7 +163,7 @@ func (pfxpcc pfxPrivateCertificateChain) PfxContent(legacy3DES bool) (pfxData []
 	}
 
 	// encode using modern pkcs12 standard
-	pfxData, err = pkcs12.Modern.Encode(key, cert, certChain, pfxpcc.FinalizedKey.ApiKey)
+	pfxData, err = pkcs12.Modern.Encode(key, cert, certChain, pfxpcc.password)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +183,7 @@ func (service *Service) DownloadPfxViaHeader(w http.ResponseWriter, r *http.Requ
 	apiKeysCombined := getApiKeyFromHeader(w, r)
 
 	// fetch the private cert
-	order, err := service.getCertNewestValidOrder(certName, apiKeysCombined, false, true)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKeysCombined, false, true, formatPfx)
 	if err != nil {
 		return err
 	}
@@ -175,7 +195,7 @@ func (service *Service) DownloadPfxViaHeader(w http.ResponseWriter, r *http.Requ
 	}
 
 	// return pfx file to client
-	pfxPrivCert := pfxPrivateCertificateChain(order)
+	pfxPrivCert := newPfxPrivateCertificateChain(order, apiKeysCombined)
 	service.output.WritePfx(w, r, pfxPrivCert, legacy3DES)
 
 	return nil
@@ -190,7 +210,7 @@ func (service *Service) DownloadPfxViaUrl(w http.ResponseWriter, r *http.Request
 	apiKeysCombined := getApiKeyFromParams(params)
 
 	// fetch the private cert
-	order, outErr := service.getCertNewestValidOrder(certName, apiKeysCombined, true, true)
+	order, outErr := service.getCertNewestValidOrder(r, certName, apiKeysCombined, true, true, formatPfx)
 	if outErr != nil {
 		return outErr
 	}
@@ -202,7 +222,7 @@ func (service *Service) DownloadPfxViaUrl(w http.ResponseWriter, r *http.Request
 	}
 
 	// return pfx file to client
-	pfxPrivCert := pfxPrivateCertificateChain(order)
+	pfxPrivCert := newPfxPrivateCertificateChain(order, apiKeysCombined)
 	err := service.output.WritePfx(w, r, pfxPrivCert, legacy3DES)
 	if err != nil {
 		return output.JsonErrInternal(err)
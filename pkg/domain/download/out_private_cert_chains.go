@@ -47,7 +47,7 @@ func (service *Service) DownloadPrivateCertChainViaHeader(w http.ResponseWriter,
 	apiKeysCombined := getApiKeyFromHeader(w, r)
 
 	// fetch the private cert
-	order, err := service.getCertNewestValidOrder(certName, apiKeysCombined, false, true)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKeysCombined, false, true, formatPrivateCertChain)
 	if err != nil {
 		return err
 	}
@@ -68,7 +68,7 @@ func (service *Service) DownloadPrivateCertChainViaUrl(w http.ResponseWriter, r
 	apiKeysCombined := getApiKeyFromParams(params)
 
 	// fetch the private cert
-	order, err := service.getCertNewestValidOrder(certName, apiKeysCombined, true, true)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKeysCombined, true, true, formatPrivateCertChain)
 	if err != nil {
 		return err
 	}
@@ -45,7 +45,7 @@ func (service *Service) DownloadPrivateCertViaHeader(w http.ResponseWriter, r *h
 	apiKeysCombined := getApiKeyFromHeader(w, r)
 
 	// fetch the private cert
-	order, err := service.getCertNewestValidOrder(certName, apiKeysCombined, false, true)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKeysCombined, false, true, formatPrivateCert)
 	if err != nil {
 		return err
 	}
@@ -66,7 +66,7 @@ func (service *Service) DownloadPrivateCertViaUrl(w http.ResponseWriter, r *http
 	apiKeysCombined := getApiKeyFromParams(params)
 
 	// fetch the private cert
-	order, err := service.getCertNewestValidOrder(certName, apiKeysCombined, true, true)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKeysCombined, true, true, formatPrivateCert)
 	if err != nil {
 		return err
 	}
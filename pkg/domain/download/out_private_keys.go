@@ -18,7 +18,7 @@ func (service *Service) DownloadKeyViaHeader(w http.ResponseWriter, r *http.Requ
 	apiKey := getApiKeyFromHeader(w, r)
 
 	// fetch the key using the apiKey
-	key, err := service.getKey(keyName, apiKey, false)
+	key, err := service.getKey(r, keyName, apiKey, false, formatPrivateKey)
 	if err != nil {
 		return err
 	}
@@ -40,7 +40,7 @@ func (service *Service) DownloadKeyViaUrl(w http.ResponseWriter, r *http.Request
 	apiKey := getApiKeyFromParams(params)
 
 	// fetch the key using the apiKey
-	key, err := service.getKey(keyName, apiKey, true)
+	key, err := service.getKey(r, keyName, apiKey, true, formatPrivateKey)
 	if err != nil {
 		return err
 	}
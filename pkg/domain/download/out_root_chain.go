@@ -45,7 +45,7 @@ func (service *Service) DownloadCertRootChainViaHeader(w http.ResponseWriter, r
 	apiKey := getApiKeyFromHeader(w, r)
 
 	// fetch the cert's newest order using the apiKey, as rootChain type
-	order, err := service.getCertNewestValidOrder(certName, apiKey, false, false)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKey, false, false, formatCertRootChain)
 	if err != nil {
 		return err
 	}
@@ -69,7 +69,7 @@ func (service *Service) DownloadCertRootChainViaUrl(w http.ResponseWriter, r *ht
 	apiKey := getApiKeyFromParams(params)
 
 	// fetch the cert's newest order using the apiKey, as rootChain type
-	order, err := service.getCertNewestValidOrder(certName, apiKey, true, false)
+	order, err := service.getCertNewestValidOrder(r, certName, apiKey, true, false, formatCertRootChain)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,12 @@
 package download
 
 import (
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/domain/orders"
 	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
 	"errors"
 
 	"go.uber.org/zap"
@@ -21,14 +23,25 @@ type App interface {
 
 // Storage interface for storage functions
 type Storage interface {
+	GetOneKeyById(id int) (private_keys.Key, error)
 	GetOneKeyByName(name string) (private_keys.Key, error)
 
+	GetOneCertById(id int) (cert certificates.Certificate, err error)
 	GetOneCertByName(name string) (cert certificates.Certificate, err error)
 
+	GetCertNewestValidOrderById(id int) (order orders.Order, err error)
 	GetCertNewestValidOrderByName(certName string) (order orders.Order, err error)
 
+	GetOneApiKeyById(id int) (api_keys.ApiKey, error)
+	GetApiKeysByOwner(owner api_keys.Owner) ([]api_keys.ApiKey, error)
+	PutApiKeyLastUsed(id int, unixLastUsedTime int64, lastUsedIP string) error
+
 	PutKeyLastAccess(keyId int, unixLastAccessTime int64) (err error)
 	PutCertLastAccess(certId int, unixLastAccessTime int64) (err error)
+
+	PostDownloadLogEntry(entry LogEntry) error
+	GetDownloadLogByOwner(owner api_keys.Owner, q pagination_sort.Query) (entries []LogEntry, totalRowCount int, err error)
+	GetCertLatestDownloadByClient(certId int) ([]LogEntry, error)
 }
 
 // Keys service struct
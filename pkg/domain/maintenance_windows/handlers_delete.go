@@ -0,0 +1,57 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DeleteMaintenanceWindow deletes a maintenance window from storage. Windows that are
+// assigned to certificates can't be deleted.
+func (service *Service) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// verify window exists
+	_, outErr := service.getMaintenanceWindow(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// do not allow delete if there are any certs using the window
+	if service.storage.MaintenanceWindowHasCerts(id) {
+		service.logger.Warn("cannot delete maintenance window (in use)")
+		return output.JsonErrDeleteInUse("maintenance window")
+	}
+	// end validation
+
+	// delete from storage
+	err = service.storage.DeleteMaintenanceWindow(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("deleted maintenance window (id: %d)", id),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
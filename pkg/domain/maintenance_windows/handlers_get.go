@@ -0,0 +1,90 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// maintenanceWindowsResponse provides the json response struct
+// to answer a query for a portion of the maintenance windows
+type maintenanceWindowsResponse struct {
+	output.JsonResponse
+	TotalMaintenanceWindows int                         `json:"total_records"`
+	MaintenanceWindows      []maintenanceWindowResponse `json:"maintenance_windows"`
+}
+
+// GetAllMaintenanceWindows is an http handler that returns all maintenance windows in the form
+// of JSON written to w
+func (service *Service) GetAllMaintenanceWindows(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get all from storage
+	windows, totalRows, err := service.storage.GetAllMaintenanceWindows(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// populate for output
+	outputWindows := []maintenanceWindowResponse{}
+	for i := range windows {
+		outputWindows = append(outputWindows, windows[i].response())
+	}
+
+	// write response
+	response := &maintenanceWindowsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalMaintenanceWindows = totalRows
+	response.MaintenanceWindows = outputWindows
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type maintenanceWindowResponseWrapper struct {
+	output.JsonResponse
+	MaintenanceWindow maintenanceWindowResponse `json:"maintenance_window"`
+}
+
+// GetOneMaintenanceWindow is an http handler that returns one maintenance window based on its
+// unique id in the form of JSON written to w
+func (service *Service) GetOneMaintenanceWindow(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get from storage
+	mw, outErr := service.getMaintenanceWindow(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &maintenanceWindowResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.MaintenanceWindow = mw.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
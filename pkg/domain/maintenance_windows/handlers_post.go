@@ -0,0 +1,87 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// NewPayload is the payload struct for creating a new maintenance window
+type NewPayload struct {
+	Name        *string        `json:"name"`
+	Description *string        `json:"description"`
+	Timezone    *string        `json:"timezone"`
+	Ranges      []WeeklyRange  `json:"ranges"`
+	Freezes     []FreezePeriod `json:"freezes"`
+	CreatedAt   int            `json:"-"`
+	UpdatedAt   int            `json:"-"`
+}
+
+// PostNewMaintenanceWindow is the handler to create a new maintenance window and save it
+// to storage
+func (service *Service) PostNewMaintenanceWindow(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// name
+	if payload.Name == nil || !service.nameValid(*payload.Name, nil) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// description (blank if not specified)
+	if payload.Description == nil {
+		payload.Description = new(string)
+	}
+	// timezone (UTC if not specified)
+	if payload.Timezone == nil {
+		payload.Timezone = new(string)
+		*payload.Timezone = "UTC"
+	} else if !timezoneValid(*payload.Timezone) {
+		service.logger.Debug(ErrTimezoneBad)
+		return output.JsonErrValidationFailed(ErrTimezoneBad)
+	}
+	// ranges (none allows any time)
+	if !rangesValid(payload.Ranges) {
+		service.logger.Debug(ErrRangeBad)
+		return output.JsonErrValidationFailed(ErrRangeBad)
+	}
+	// freezes
+	if !freezesValid(payload.Freezes) {
+		service.logger.Debug(ErrFreezeBad)
+		return output.JsonErrValidationFailed(ErrFreezeBad)
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save to storage
+	newWindow, err := service.storage.PostNewMaintenanceWindow(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &maintenanceWindowResponseWrapper{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created maintenance window"
+	response.MaintenanceWindow = newWindow.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DetailsUpdatePayload is the struct for editing an existing maintenance window. Ranges
+// and freezes replace the existing ones when specified.
+type DetailsUpdatePayload struct {
+	ID          int            `json:"-"`
+	Name        *string        `json:"name"`
+	Description *string        `json:"description"`
+	Timezone    *string        `json:"timezone"`
+	Ranges      []WeeklyRange  `json:"ranges"`
+	Freezes     []FreezePeriod `json:"freezes"`
+	UpdatedAt   int            `json:"-"`
+}
+
+// PutDetailsMaintenanceWindow is a handler that updates a maintenance window
+func (service *Service) PutDetailsMaintenanceWindow(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// payload decoding
+	var payload DetailsUpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	payload.ID, err = strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// id
+	_, outErr := service.getMaintenanceWindow(payload.ID)
+	if outErr != nil {
+		return outErr
+	}
+	// name (optional)
+	if payload.Name != nil && !service.nameValid(*payload.Name, &payload.ID) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+	// timezone (optional)
+	if payload.Timezone != nil && !timezoneValid(*payload.Timezone) {
+		service.logger.Debug(ErrTimezoneBad)
+		return output.JsonErrValidationFailed(ErrTimezoneBad)
+	}
+	// ranges (optional)
+	if !rangesValid(payload.Ranges) {
+		service.logger.Debug(ErrRangeBad)
+		return output.JsonErrValidationFailed(ErrRangeBad)
+	}
+	// freezes (optional)
+	if !freezesValid(payload.Freezes) {
+		service.logger.Debug(ErrFreezeBad)
+		return output.JsonErrValidationFailed(ErrFreezeBad)
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	updatedWindow, err := service.storage.PutDetailsMaintenanceWindow(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &maintenanceWindowResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated maintenance window"
+	response.MaintenanceWindow = updatedWindow.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
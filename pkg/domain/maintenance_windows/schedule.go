@@ -0,0 +1,174 @@
+package maintenance_windows
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	// embed the timezone database so windows work on hosts without one (e.g. Windows)
+	_ "time/tzdata"
+)
+
+// clockLayout is the format of WeeklyRange Start and End
+const clockLayout = "15:04"
+
+// weekdays maps the day names used by WeeklyRange to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Interval is a span of time from Start (inclusive) to End (exclusive)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// location returns the window's timezone (UTC if it is invalid)
+func (mw MaintenanceWindow) location() *time.Location {
+	loc, err := time.LoadLocation(mw.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// startsOn returns true if the range starts on the specified weekday
+func (wr WeeklyRange) startsOn(day time.Weekday) bool {
+	for _, d := range wr.Days {
+		if weekday, ok := weekdays[strings.ToLower(d)]; ok && weekday == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// occurrence returns the interval of the range that starts on the specified day. If
+// the range's times are not valid, false is returned.
+func (wr WeeklyRange) occurrence(day time.Time) (Interval, bool) {
+	startClock, err := time.Parse(clockLayout, wr.Start)
+	if err != nil {
+		return Interval{}, false
+	}
+	endClock, err := time.Parse(clockLayout, wr.End)
+	if err != nil {
+		return Interval{}, false
+	}
+
+	start := time.Date(day.Year(), day.Month(), day.Day(), startClock.Hour(), startClock.Minute(), 0, 0, day.Location())
+	end := time.Date(day.Year(), day.Month(), day.Day(), endClock.Hour(), endClock.Minute(), 0, 0, day.Location())
+	// ends the next day
+	if !end.After(start) {
+		end = time.Date(day.Year(), day.Month(), day.Day()+1, endClock.Hour(), endClock.Minute(), 0, 0, day.Location())
+	}
+
+	return Interval{Start: start, End: end}, true
+}
+
+// AllowedIntervals returns the intervals within from (inclusive) to until (exclusive)
+// during which renewal is allowed, in chronological order
+func (mw MaintenanceWindow) AllowedIntervals(from, until time.Time) []Interval {
+	if !from.Before(until) {
+		return nil
+	}
+
+	// weekly ranges (or all of the time, if there are none)
+	allowed := []Interval{}
+	if len(mw.Ranges) == 0 {
+		allowed = append(allowed, Interval{Start: from, End: until})
+	} else {
+		loc := mw.location()
+		localFrom := from.In(loc)
+
+		// start the day before, in case a range crosses midnight
+		for day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()-1, 0, 0, 0, 0, loc); day.Before(until); day = day.AddDate(0, 0, 1) {
+			for _, wr := range mw.Ranges {
+				if !wr.startsOn(day.Weekday()) {
+					continue
+				}
+
+				occurrence, ok := wr.occurrence(day)
+				if !ok {
+					continue
+				}
+
+				// clip to from and until
+				if occurrence.Start.Before(from) {
+					occurrence.Start = from
+				}
+				if occurrence.End.After(until) {
+					occurrence.End = until
+				}
+				if occurrence.Start.Before(occurrence.End) {
+					allowed = append(allowed, occurrence)
+				}
+			}
+		}
+
+		allowed = mergeIntervals(allowed)
+	}
+
+	// remove freezes
+	for _, freeze := range mw.Freezes {
+		allowed = subtractInterval(allowed, Interval{Start: time.Unix(freeze.Start, 0), End: time.Unix(freeze.End, 0)})
+	}
+
+	return allowed
+}
+
+// Allowed returns true if renewal is allowed at the specified time
+func (mw MaintenanceWindow) Allowed(t time.Time) bool {
+	return len(mw.AllowedIntervals(t, t.Add(time.Nanosecond))) > 0
+}
+
+// mergeIntervals sorts the intervals and combines any that overlap or touch
+func mergeIntervals(intervals []Interval) []Interval {
+	slices.SortFunc(intervals, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	merged := []Interval{}
+	for _, interval := range intervals {
+		last := len(merged) - 1
+		if last >= 0 && !interval.Start.After(merged[last].End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+
+		merged = append(merged, interval)
+	}
+
+	return merged
+}
+
+// subtractInterval removes remove from each of the intervals
+func subtractInterval(intervals []Interval, remove Interval) []Interval {
+	result := []Interval{}
+	for _, interval := range intervals {
+		// no overlap
+		if !remove.Start.Before(interval.End) || !remove.End.After(interval.Start) {
+			result = append(result, interval)
+			continue
+		}
+
+		// part before
+		if interval.Start.Before(remove.Start) {
+			result = append(result, Interval{Start: interval.Start, End: remove.Start})
+		}
+		// part after
+		if remove.End.Before(interval.End) {
+			result = append(result, Interval{Start: remove.End, End: interval.End})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,89 @@
+package maintenance_windows
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowedIntervals(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2025-01-06 is a Monday
+	from := time.Date(2025, 1, 6, 0, 0, 0, 0, ny)
+	until := from.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		want   []Interval
+	}{
+		{
+			name: "tue and thu early morning",
+			window: MaintenanceWindow{
+				Timezone: "America/New_York",
+				Ranges:   []WeeklyRange{{Days: []string{"tue", "Thu"}, Start: "02:00", End: "04:00"}},
+			},
+			want: []Interval{
+				{Start: time.Date(2025, 1, 7, 2, 0, 0, 0, ny), End: time.Date(2025, 1, 7, 4, 0, 0, 0, ny)},
+				{Start: time.Date(2025, 1, 9, 2, 0, 0, 0, ny), End: time.Date(2025, 1, 9, 4, 0, 0, 0, ny)},
+			},
+		},
+		{
+			name: "crosses midnight",
+			window: MaintenanceWindow{
+				Timezone: "America/New_York",
+				Ranges:   []WeeklyRange{{Days: []string{"sun", "fri"}, Start: "23:00", End: "01:00"}},
+			},
+			want: []Interval{
+				// sunday before from
+				{Start: from, End: time.Date(2025, 1, 6, 1, 0, 0, 0, ny)},
+				{Start: time.Date(2025, 1, 10, 23, 0, 0, 0, ny), End: time.Date(2025, 1, 11, 1, 0, 0, 0, ny)},
+				// clipped at until
+				{Start: time.Date(2025, 1, 12, 23, 0, 0, 0, ny), End: until},
+			},
+		},
+		{
+			name: "freeze",
+			window: MaintenanceWindow{
+				Timezone: "America/New_York",
+				Ranges:   []WeeklyRange{{Days: []string{"tue", "thu"}, Start: "02:00", End: "04:00"}},
+				Freezes: []FreezePeriod{
+					{Start: time.Date(2025, 1, 7, 3, 0, 0, 0, ny).Unix(), End: time.Date(2025, 1, 8, 0, 0, 0, 0, ny).Unix()},
+				},
+			},
+			want: []Interval{
+				{Start: time.Date(2025, 1, 7, 2, 0, 0, 0, ny), End: time.Date(2025, 1, 7, 3, 0, 0, 0, ny)},
+				{Start: time.Date(2025, 1, 9, 2, 0, 0, 0, ny), End: time.Date(2025, 1, 9, 4, 0, 0, 0, ny)},
+			},
+		},
+		{
+			name: "no ranges with freeze",
+			window: MaintenanceWindow{
+				Timezone: "UTC",
+				Freezes: []FreezePeriod{
+					{Start: time.Date(2025, 1, 8, 0, 0, 0, 0, ny).Unix(), End: time.Date(2025, 1, 9, 0, 0, 0, 0, ny).Unix()},
+				},
+			},
+			want: []Interval{
+				{Start: from, End: time.Date(2025, 1, 8, 0, 0, 0, 0, ny)},
+				{Start: time.Date(2025, 1, 9, 0, 0, 0, 0, ny), End: until},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := test.window.AllowedIntervals(from, until)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d intervals (%v), want %d", test.name, len(got), got, len(test.want))
+			continue
+		}
+		for i := range got {
+			if !got[i].Start.Equal(test.want[i].Start) || !got[i].End.Equal(test.want[i].End) {
+				t.Errorf("%s: interval %d is %s - %s, want %s - %s", test.name, i, got[i].Start, got[i].End, test.want[i].Start, test.want[i].End)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary maintenance window service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetMaintenanceWindowStorage() Storage
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	GetAllMaintenanceWindows(q pagination_sort.Query) (windows []MaintenanceWindow, totalRows int, err error)
+	GetOneMaintenanceWindowById(id int) (MaintenanceWindow, error)
+	GetOneMaintenanceWindowByName(name string) (MaintenanceWindow, error)
+
+	PostNewMaintenanceWindow(NewPayload) (MaintenanceWindow, error)
+
+	PutDetailsMaintenanceWindow(DetailsUpdatePayload) (MaintenanceWindow, error)
+
+	DeleteMaintenanceWindow(id int) error
+
+	MaintenanceWindowHasCerts(windowId int) (inUse bool)
+}
+
+// MaintenanceWindows service struct
+type Service struct {
+	logger  *zap.SugaredLogger
+	output  *output.Service
+	storage Storage
+}
+
+// NewService creates a new maintenance_windows service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetMaintenanceWindowStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	return service, nil
+}
@@ -0,0 +1,139 @@
+package maintenance_windows
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// id
+	ErrIdBad = errors.New("maintenance window id is invalid")
+
+	// name
+	ErrNameBad = errors.New("maintenance window name is not valid")
+
+	// schedule
+	ErrTimezoneBad = errors.New("timezone is not a valid IANA timezone (e.g. America/New_York)")
+	ErrRangeBad    = errors.New("ranges are not valid (each must have days from sun, mon, tue, wed, thu, fri, sat and a start and end time in HH:MM format)")
+	ErrFreezeBad   = errors.New("freezes are not valid (each must have a start before its end)")
+)
+
+// getMaintenanceWindow returns the maintenance window for the specified id.
+func (service *Service) getMaintenanceWindow(id int) (MaintenanceWindow, *output.JsonError) {
+	// if id is not in valid range, it is definitely not valid
+	if !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrIdBad)
+		return MaintenanceWindow{}, output.JsonErrValidationFailed(ErrIdBad)
+	}
+
+	// get from storage
+	mw, err := service.storage.GetOneMaintenanceWindowById(id)
+	if err != nil {
+		// special error case for no record found
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return MaintenanceWindow{}, output.JsonErrNotFound(fmt.Errorf("maintenance window id %d not found", id))
+		} else {
+			service.logger.Error(err)
+			return MaintenanceWindow{}, output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	return mw, nil
+}
+
+// GetMaintenanceWindow returns the maintenance window for the specified id
+func (service *Service) GetMaintenanceWindow(id int) (MaintenanceWindow, error) {
+	return service.storage.GetOneMaintenanceWindowById(id)
+}
+
+// MaintenanceWindowExists returns true if a maintenance window with the specified id
+// exists
+func (service *Service) MaintenanceWindowExists(id int) bool {
+	if !validation.IsIdExistingValidRange(id) {
+		return false
+	}
+
+	_, err := service.storage.GetOneMaintenanceWindowById(id)
+	return err == nil
+}
+
+// nameValid returns true if the specified maintenance window name is acceptable and
+// false if it is not. This check includes validating specified characters and also
+// confirms the name is not already in use by another maintenance window. If an id is
+// specified, the name will also be accepted if the name is already in use by the
+// specified id.
+func (service *Service) nameValid(name string, windowId *int) bool {
+	// basic check
+	if !validation.NameValid(name) {
+		return false
+	}
+
+	// make sure the name isn't already in use in storage
+	mw, err := service.storage.GetOneMaintenanceWindowByName(name)
+	if errors.Is(err, storage.ErrNoRecord) {
+		// no rows means name is not in use (valid)
+		return true
+	} else if err != nil {
+		// any other error, invalid
+		return false
+	}
+
+	// if the returned window is the window being edited, name valid
+	if windowId != nil && mw.ID == *windowId {
+		return true
+	}
+
+	return false
+}
+
+// timezoneValid returns true if tz is a loadable IANA timezone
+func timezoneValid(tz string) bool {
+	if tz == "" {
+		return false
+	}
+
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
+
+// rangesValid returns true if every range has at least one valid day and valid times
+func rangesValid(ranges []WeeklyRange) bool {
+	for _, wr := range ranges {
+		if len(wr.Days) == 0 {
+			return false
+		}
+		for _, d := range wr.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return false
+			}
+		}
+
+		_, err := time.Parse(clockLayout, wr.Start)
+		if err != nil {
+			return false
+		}
+		_, err = time.Parse(clockLayout, wr.End)
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// freezesValid returns true if every freeze period starts before it ends
+func freezesValid(freezes []FreezePeriod) bool {
+	for _, freeze := range freezes {
+		if freeze.Start <= 0 || freeze.Start >= freeze.End {
+			return false
+		}
+	}
+
+	return true
+}
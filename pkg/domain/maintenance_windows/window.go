@@ -0,0 +1,69 @@
+package maintenance_windows
+
+// MaintenanceWindow is a schedule of when certificates assigned to it may be
+// automatically renewed. Renewal is allowed during any of the weekly ranges (or at
+// any time, if there are none), except during a freeze period.
+type MaintenanceWindow struct {
+	ID          int
+	Name        string
+	Description string
+	Timezone    string
+	Ranges      []WeeklyRange
+	Freezes     []FreezePeriod
+	CreatedAt   int
+	UpdatedAt   int
+}
+
+// WeeklyRange is a time range that recurs on the specified days of the week, in the
+// maintenance window's timezone
+type WeeklyRange struct {
+	// Days are the days the range starts on (sun, mon, tue, wed, thu, fri, sat)
+	Days []string `json:"days"`
+	// Start and End are the time of day (HH:MM). If End is not after Start, the range
+	// ends on the following day.
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// FreezePeriod is a period of time (unix seconds) during which renewal is never allowed
+type FreezePeriod struct {
+	Start  int64  `json:"start"`
+	End    int64  `json:"end"`
+	Reason string `json:"reason"`
+}
+
+// maintenanceWindowResponse is a JSON response containing all
+// fields that can be returned as JSON
+type maintenanceWindowResponse struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Timezone    string         `json:"timezone"`
+	Ranges      []WeeklyRange  `json:"ranges"`
+	Freezes     []FreezePeriod `json:"freezes"`
+	CreatedAt   int            `json:"created_at"`
+	UpdatedAt   int            `json:"updated_at"`
+}
+
+func (mw MaintenanceWindow) response() maintenanceWindowResponse {
+	// always output arrays (not null)
+	ranges := mw.Ranges
+	if ranges == nil {
+		ranges = []WeeklyRange{}
+	}
+	freezes := mw.Freezes
+	if freezes == nil {
+		freezes = []FreezePeriod{}
+	}
+
+	return maintenanceWindowResponse{
+		ID:          mw.ID,
+		Name:        mw.Name,
+		Description: mw.Description,
+		Timezone:    mw.Timezone,
+		Ranges:      ranges,
+		Freezes:     freezes,
+		CreatedAt:   mw.CreatedAt,
+		UpdatedAt:   mw.UpdatedAt,
+	}
+}
@@ -1,23 +1,18 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/randomness"
 	"encoding/json"
 	"time"
 )
 
-// expiringAfterElapsedRatio is the ratio of a certificate's elapsed validity / total validity
-// after which the certificate should be considered expiring
 const (
-	// shortLivedValidityThreshold is the amount of validity time under which this application will consider
-	// a certificate to be "short-lived"
-	shortLivedValidityThreshold = 10 * 24 * time.Hour
-	// expiringRemainingValidFraction is the fraction of validity remaining under which this application will
-	// consider a certificate expiring (for a "regular" (non-"short-lived") certificate)
-	expiringRemainingValidFraction = 0.333
-	// expiringShortLivedRemainingValidFraction is the fraction of validity remaining under which this application will
-	// consider a "short-lived" certificate expiring
-	expiringShortLivedRemainingValidFraction = 0.5
+	// renewalWindowHalfWidth is how far either side of the renewal threshold the locally
+	// calculated renewal window extends
+	renewalWindowHalfWidth = 24 * time.Hour
+	// shortLivedRenewalWindowHalfWidth is renewalWindowHalfWidth for short-lived certificates
+	shortLivedRenewalWindowHalfWidth = 4 * time.Hour
 )
 
 // renewalInfo is a struct to hold information that instructs Cert Warden when renewal
@@ -56,35 +51,48 @@ func UnmarshalRenewalInfo(data []byte) *renewalInfo {
 	return ri
 }
 
-// MakeRenewalInfo returns a renewalInfo struct based on a certificate's validity. This is essentially
-// a local ARI calculation, which will be used when the ACME Server does not implement ARI or is not
-// returning valid ARI responses.
-func MakeRenewalInfo(validFrom, validTo time.Time) *renewalInfo {
+// localRenewalInfoNeeded returns true if the renewal window must be calculated locally (from the
+// renewal policy) instead of using ari, because ari isn't trusted or ari isn't from the ACME Server;
+// locally calculated ari always has a nil RetryAfter
+func localRenewalInfoNeeded(ari *renewalInfo, policy certificates.RenewalPolicy) bool {
+	return !*policy.TrustARI || ari == nil || ari.RetryAfter == nil
+}
+
+// MakeRenewalInfo returns a renewalInfo struct based on a certificate's validity and the renewal
+// policy, which must be complete (i.e., merged onto the default policy). This is essentially a local
+// ARI calculation, which will be used when the ACME Server does not implement ARI, is not returning
+// valid ARI responses, or the policy does not trust ARI.
+func MakeRenewalInfo(validFrom, validTo time.Time, policy certificates.RenewalPolicy) *renewalInfo {
 	// determine if the cert is "short lived"
 	validDuration := validTo.Sub(validFrom)
 	shortLived := false
-	if validTo.Sub(validFrom) < shortLivedValidityThreshold {
+	if validDuration < time.Duration(*policy.ShortLivedValidityDays)*24*time.Hour {
 		shortLived = true
 	}
 
 	// calculate validity threshold (for the approx. midpoint of the renewal window)
 	var validityThreshold time.Time
 	if shortLived {
-		validityThreshold = validTo.Add(-1 * time.Duration(float64(validDuration)*float64(expiringShortLivedRemainingValidFraction)))
+		validityThreshold = validTo.Add(-1 * time.Duration(float64(validDuration)*(*policy.ShortLivedRemainingValidFraction)))
 	} else {
-		validityThreshold = validTo.Add(-1 * time.Duration(float64(validDuration)*float64(expiringRemainingValidFraction)))
+		validityThreshold = validTo.Add(-1 * time.Duration(float64(validDuration)*(*policy.RemainingValidFraction)))
+	}
+
+	// days before expiry replaces the fraction, unless the cert isn't valid for that long
+	if *policy.DaysBeforeExpiry > 0 {
+		daysThreshold := validTo.Add(-1 * time.Duration(*policy.DaysBeforeExpiry) * 24 * time.Hour)
+		if daysThreshold.After(validFrom) {
+			validityThreshold = daysThreshold
+		}
 	}
 
 	// calculate start and end time (includes adding some jitter too)
-	var startT time.Time
-	var endT time.Time
+	halfWidth := renewalWindowHalfWidth
 	if shortLived {
-		startT = validityThreshold.Add(-1 * 4 * time.Hour).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
-		endT = validityThreshold.Add(4 * time.Hour).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
-	} else {
-		startT = validityThreshold.Add(-1 * 24 * time.Hour).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
-		endT = validityThreshold.Add(24 * time.Hour).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
+		halfWidth = shortLivedRenewalWindowHalfWidth
 	}
+	startT := validityThreshold.Add(-1 * halfWidth).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
+	endT := validityThreshold.Add(halfWidth).Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
 
 	// return struct
 	return &renewalInfo{
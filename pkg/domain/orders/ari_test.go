@@ -0,0 +1,92 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"testing"
+	"time"
+)
+
+func TestMakeRenewalInfoPolicy(t *testing.T) {
+	validFrom := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := func(d int) *int { return &d }
+
+	tests := []struct {
+		name      string
+		validity  time.Duration
+		override  certificates.RenewalPolicy
+		threshold time.Time
+		halfWidth time.Duration
+	}{
+		{
+			name:      "default fraction",
+			validity:  90 * 24 * time.Hour,
+			threshold: validFrom.Add(90 * 24 * time.Hour).Add(-1 * time.Duration(float64(90*24*time.Hour)*0.333)),
+			halfWidth: renewalWindowHalfWidth,
+		},
+		{
+			name:      "default short-lived fraction",
+			validity:  6 * 24 * time.Hour,
+			threshold: validFrom.Add(3 * 24 * time.Hour),
+			halfWidth: shortLivedRenewalWindowHalfWidth,
+		},
+		{
+			name:      "days before expiry",
+			validity:  90 * 24 * time.Hour,
+			override:  certificates.RenewalPolicy{DaysBeforeExpiry: days(20)},
+			threshold: validFrom.Add(70 * 24 * time.Hour),
+			halfWidth: renewalWindowHalfWidth,
+		},
+		{
+			name:      "days before expiry longer than validity",
+			validity:  6 * 24 * time.Hour,
+			override:  certificates.RenewalPolicy{DaysBeforeExpiry: days(20)},
+			threshold: validFrom.Add(3 * 24 * time.Hour),
+			halfWidth: shortLivedRenewalWindowHalfWidth,
+		},
+		{
+			name:      "short-lived threshold",
+			validity:  6 * 24 * time.Hour,
+			override:  certificates.RenewalPolicy{ShortLivedValidityDays: days(5)},
+			threshold: validFrom.Add(6 * 24 * time.Hour).Add(-1 * time.Duration(float64(6*24*time.Hour)*0.333)),
+			halfWidth: renewalWindowHalfWidth,
+		},
+	}
+
+	for _, tt := range tests {
+		policy := certificates.DefaultRenewalPolicy().Merge(tt.override)
+		ari := MakeRenewalInfo(validFrom, validFrom.Add(tt.validity), policy)
+
+		// allow for up to a minute of jitter
+		start := ari.SuggestedWindow.Start.Sub(tt.threshold.Add(-1 * tt.halfWidth))
+		end := ari.SuggestedWindow.End.Sub(tt.threshold.Add(tt.halfWidth))
+		if start < 0 || start >= time.Minute || end < 0 || end >= time.Minute {
+			t.Errorf("%s: window %s to %s, expected %s +/- %s", tt.name, ari.SuggestedWindow.Start, ari.SuggestedWindow.End, tt.threshold, tt.halfWidth)
+		}
+	}
+}
+
+func TestRenewalPolicyMerge(t *testing.T) {
+	autoRenew := false
+	fraction := 0.25
+	cert := certificates.Certificate{RenewalPolicy: certificates.RenewalPolicy{AutoRenew: &autoRenew}}
+	defaultPolicy := certificates.DefaultRenewalPolicy().Merge(certificates.RenewalPolicy{RemainingValidFraction: &fraction})
+
+	policy := cert.EffectiveRenewalPolicy(defaultPolicy)
+	if *policy.AutoRenew {
+		t.Error("cert override of auto renew was not applied")
+	}
+	if *policy.RemainingValidFraction != fraction {
+		t.Errorf("remaining valid fraction is %f, expected default %f", *policy.RemainingValidFraction, fraction)
+	}
+	if !*policy.TrustARI {
+		t.Error("unset trust ari did not use the built in default")
+	}
+
+	bad := 1.5
+	if (certificates.RenewalPolicy{ShortLivedRemainingValidFraction: &bad}).Valid() {
+		t.Error("fraction greater than 1 should not be valid")
+	}
+	if !(certificates.RenewalPolicy{}).Valid() {
+		t.Error("empty policy should be valid")
+	}
+}
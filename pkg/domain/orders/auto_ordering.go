@@ -9,17 +9,31 @@ import (
 	"time"
 )
 
-var autoOrderRunInterval = 2 * time.Hour
+const (
+	// DefaultAutoOrderIntervalMinutes is how often the auto order service runs if not
+	// configured
+	DefaultAutoOrderIntervalMinutes = 120
+	// min and max configurable auto order interval
+	minAutoOrderIntervalMinutes = 10
+	maxAutoOrderIntervalMinutes = 24 * 60
+)
 
 // startAutoOrderService starts a go routine that manages certificate renewals. It both completes existing orders
 // that are not yet in a 'valid' or 'invalid' state and also places new orders for expiring certs. A job is run
-// hourly (with some jitter) to check for work to do. This service also polls and saves ARI information for orders
-// on ACME Servers that support ARI.
+// every autoOrderRunInterval (AutoOrderIntervalMinutes, default 120, plus some jitter) to check for work to do. This
+// service also polls and saves ARI information for orders on ACME Servers that support ARI, and resumes saved jobs
+// that didn't finish before the app last stopped.
 func (service *Service) startAutoOrderService(ctx context.Context, wg *sync.WaitGroup) {
 	// log start and update wg
-	service.logger.Infof("orders: starting automatic certificate ordering service; short-lived certificate definition: %d days of validity or less; "+
-		"valid remaining threshold: %.01f%%; short-lived validity threshold: %.01f%%; ACME Servers supporting ARI can override these values",
-		shortLivedValidityThreshold/(24*time.Hour), expiringRemainingValidFraction*100, expiringShortLivedRemainingValidFraction*100)
+	service.logger.Infof("orders: starting automatic certificate ordering service; run interval: %s; default renewal policy: auto renew: %t; "+
+		"days before expiry: %d; short-lived certificate definition: less than %d days of validity; valid remaining threshold: %.01f%%; "+
+		"short-lived validity threshold: %.01f%%; trust ACME Server ARI: %t; certificates can override these values",
+		service.autoOrderRunInterval, *service.renewalPolicy.AutoRenew, *service.renewalPolicy.DaysBeforeExpiry, *service.renewalPolicy.ShortLivedValidityDays,
+		*service.renewalPolicy.RemainingValidFraction*100, *service.renewalPolicy.ShortLivedRemainingValidFraction*100, *service.renewalPolicy.TrustARI)
+
+	// resume jobs that were queued or running when the app stopped (right away, instead of
+	// waiting for the first run)
+	service.resumeJobs()
 
 	// service routine
 	wg.Add(1)
@@ -47,13 +61,22 @@ func (service *Service) startAutoOrderService(ctx context.Context, wg *sync.Wait
 			// complete existing orders that are not 'valid' or 'invalid' (i.e. not completed)
 			service.retryIncompleteOrders()
 
+			// retry failed orders that are due before the next run
+			service.retryFailedOrders()
+
 			// order expiring certificates
 			service.orderExpiringCerts()
 
-			// next run time (add autoOrderRunInterval and some jitter)
+			// delete keys rotated out of certificates that are no longer needed
+			service.deleteUnusedRetiredKeys()
+
+			// delete old finished jobs
+			service.deleteOldJobs()
+
+			// next run time (add the run interval and some jitter)
 			// add random second to runtime, as preferred by Let's Encrypt
 			// see: https://letsencrypt.org/docs/integration-guide/#when-to-renew
-			nextRunTime = time.Now().Add(autoOrderRunInterval)
+			nextRunTime = time.Now().Add(service.autoOrderRunInterval)
 			nextRunTime = nextRunTime.Add(time.Duration(randomness.GenerateInsecureInt(60)) * time.Second)
 		}
 	}()
@@ -74,9 +97,21 @@ func (service *Service) retryIncompleteOrders() {
 		return
 	}
 
+	// orders that already have a stored job that will run (or is running)
+	unfinishedOrderIds, err := service.unfinishedFulfillOrderIDs()
+	if err != nil {
+		service.logger.Errorf("orders: error attempting retry of incomplete orders (%s)", err)
+		return
+	}
+
 	// add all incompletes to the low priority order queue
 	addedCount := 0
 	for _, orderId := range incompleteOrderIds {
+		if _, exists := unfinishedOrderIds[orderId]; exists {
+			service.logger.Debugf("orders: not adding order %d to processing queue (order already has an unfinished job)", orderId)
+			continue
+		}
+
 		err = service.fulfillOrder(orderId, false)
 		if err != nil {
 			if errors.Is(err, job_manager.ErrAddDuplicateJob) {
@@ -1,6 +1,7 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/acme"
 	"certwarden-backend/pkg/pagination_sort"
 	"certwarden-backend/pkg/randomness"
 	"sync"
@@ -8,7 +9,7 @@ import (
 )
 
 // orderExpiringCerts updates ARI information (where available) and then places orders for expiring certificates
-// based either on the ARI information or the thresholds hardcoded above; pending tasks are aborted if the context
+// based either on the ARI information or each certificate's renewal policy; pending tasks are aborted if the context
 // is canceled
 func (service *Service) orderExpiringCerts() {
 	// get slice of all currently valid orders (to evaluate re-order criteria)
@@ -29,23 +30,33 @@ func (service *Service) orderExpiringCerts() {
 		go func() {
 			defer wg.Done()
 
-			// Get relevant ACME Server service
-			acmeService, err := service.acmeServerService.AcmeService(orders[i].Certificate.CertificateAccount.AcmeServer.ID)
-			if err != nil {
-				service.logger.Errorf("orders: auto order failed to get acme service for order %d (%s)", orders[i].ID, err)
-				return // done, failed
+			// renewal policy (cert overrides on top of the default)
+			policy := orders[i].Certificate.EffectiveRenewalPolicy(service.renewalPolicy)
+			if !*policy.AutoRenew {
+				service.logger.Debugf("orders: auto order skipping cert %s (auto renew disabled by renewal policy)", orders[i].Certificate.Name)
+				return // done, nothing to do
+			}
+
+			// Get relevant ACME Server service (private CA orders don't have one)
+			var acmeService *acme.Service
+			var err error
+			if orders[i].Certificate.PrivateCA == nil {
+				acmeService, err = service.acmeServerService.AcmeService(orders[i].Certificate.CertificateAccount.AcmeServer.ID)
+				if err != nil {
+					service.logger.Errorf("orders: auto order failed to get acme service for order %d (%s)", orders[i].ID, err)
+					return // done, failed
+				}
 			}
 
 			// Step 1: Update RenewalInfo (including initial population)
-			// In the event ACME Server does not support ARI, CW will generate its own ARI suggested renewal window
+			// In the event ACME Server does not support ARI (or the cert is issued by a private CA), CW will generate its own ARI suggested renewal window
 			// and use that when deciding when to do renewal
 			ari := orders[i].RenewalInfo
 			newARI := false
 
-			if acmeService.SupportsARIExtension() &&
+			if *policy.TrustARI && acmeService != nil && acmeService.SupportsARIExtension() &&
 				(orders[i].RenewalInfo == nil || orders[i].RenewalInfo.RetryAfter == nil || time.Now().After(*orders[i].RenewalInfo.RetryAfter)) {
 				acmeARI, err := acmeService.GetACMERenewalInfo(*orders[i].Pem)
-				// TODO: Add retry / exponential backoff for a couple attempts ?
 				if err != nil {
 					service.logger.Errorf("orders: auto order failed to fetch new ari info for %d (%s)", orders[i].ID, err)
 				} else {
@@ -65,10 +76,13 @@ func (service *Service) orderExpiringCerts() {
 				}
 			}
 
-			// if didn't fetch (server doesn't support, or fetch failed, whatever), and there is no existing ari, use a sane default
-			if orders[i].RenewalInfo == nil && !newARI {
-				ari = MakeRenewalInfo(*orders[i].ValidFrom, *orders[i].ValidTo)
-				newARI = true
+			// if ari isn't trusted, or there is no ACME Server ari (server doesn't support, fetch failed, whatever), calculate
+			// the window from the renewal policy
+			if localRenewalInfoNeeded(ari, policy) {
+				ari = MakeRenewalInfo(*orders[i].ValidFrom, *orders[i].ValidTo, policy)
+				// only save if there is no existing ari (the window is recalculated every run anyway,
+				// so policy changes take effect immediately)
+				newARI = orders[i].RenewalInfo == nil
 			}
 
 			// update storage if we have new ari
@@ -90,14 +104,53 @@ func (service *Service) orderExpiringCerts() {
 			windowDuration := ari.SuggestedWindow.End.Sub(ari.SuggestedWindow.Start)
 			renewalTime := ari.SuggestedWindow.Start.Add(time.Duration(randomness.GenerateInsecureInt(int(windowDuration.Minutes()))) * time.Minute)
 
-			// If the selected renewalTime is before the approximate next wakeup (now + autoOrderRunInterval),
+			// certs with a maintenance window may only be renewed when the maintenance window allows it, so
+			// instead select a time within the allowed part of the suggested window
+			inMaintenanceWindow := orders[i].Certificate.MaintenanceWindowID != nil
+			if inMaintenanceWindow {
+				mw, err := service.maintenanceWindows.GetMaintenanceWindow(*orders[i].Certificate.MaintenanceWindowID)
+				if err != nil {
+					service.logger.Errorf("orders: auto order failed to get maintenance window for cert %s (%s)", orders[i].Certificate.Name, err)
+					return // done, failed
+				}
+
+				var ok bool
+				renewalTime, ok = maintenanceRenewalTime(mw, ari, *orders[i].ValidTo, time.Now())
+				if !ok {
+					service.logger.Warnf("orders: auto order cannot renew cert %s before it expires at %s (maintenance window %s does not allow renewal before then)",
+						orders[i].Certificate.Name, orders[i].ValidTo, mw.Name)
+					return // done, nothing allowed
+				}
+				if renewalTime.After(ari.SuggestedWindow.End) {
+					service.logger.Infof("orders: auto order renewal of cert %s deferred to %s, after the suggested window ends (maintenance window %s does not allow renewal during it)",
+						orders[i].Certificate.Name, renewalTime, mw.Name)
+				}
+			}
+
+			// If the selected renewalTime is before the approximate next wakeup (now + run interval),
 			// then renew now, otherwise do nothing and see what happens next run
-			if renewalTime.Before(time.Now().Add(autoOrderRunInterval)) {
+			if renewalTime.Before(time.Now().Add(service.autoOrderRunInterval)) {
+				// a cert with failed orders is only ordered again by its (backed off) retries
+				allowed, reason := service.failedOrdersAllowNewOrder(orders[i].Certificate.OrderFailures, time.Now())
+				if !allowed {
+					service.logger.Debugf("orders: auto order skipping cert %s (%s)", orders[i].Certificate.Name, reason)
+					return // done, waiting on retry
+				}
+
+				// a maintenance window cert must wait for the selected (allowed) time
+				if inMaintenanceWindow && renewalTime.After(time.Now()) {
+					service.logger.Debugf("orders: auto order scheduling new order for expiring cert %s at %s (maintenance window)",
+						orders[i].Certificate.Name, renewalTime)
+					service.placeNewOrderAt(orders[i].Certificate.ID, orders[i].Certificate.Name, renewalTime)
+					return // done, scheduled
+				}
+
 				service.logger.Debugf("orders: auto order placing new order for expiring cert %s (window from: %s; to: %s; selected renewal time: %s)",
 					orders[i].Certificate.Name, ari.SuggestedWindow.Start, ari.SuggestedWindow.End, renewalTime)
 				_, outErr := service.placeNewOrderAndFulfill(orders[i].Certificate.ID, false)
 				if outErr != nil {
-					service.logger.Errorf("orders: auto order failed to place new order for cert %s (%s)", orders[i].Certificate.Name, err)
+					service.logger.Errorf("orders: auto order failed to place new order for cert %s (%s)", orders[i].Certificate.Name, outErr.Error())
+					service.updateOrderFailures(orders[i].Certificate, outErr)
 				} else {
 					addedMu.Lock()
 					addedCount++
@@ -0,0 +1,164 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"certwarden-backend/pkg/randomness"
+	"fmt"
+	"time"
+)
+
+// maintenanceRenewalIntervals returns the intervals a cert that has a maintenance window may be
+// renewed during. These are the parts of the remaining (i.e., after now) suggested window that
+// the maintenance window allows. If the maintenance window doesn't allow any of it, a zero length
+// interval at the first allowed time after that is returned instead. If nothing is allowed before
+// the cert expires (validTo), nil is returned.
+func maintenanceRenewalIntervals(mw maintenance_windows.MaintenanceWindow, ari *renewalInfo, validTo time.Time, now time.Time) []maintenance_windows.Interval {
+	from := ari.SuggestedWindow.Start
+	if from.Before(now) {
+		from = now
+	}
+
+	// allowed part of the suggested window
+	allowed := mw.AllowedIntervals(from, ari.SuggestedWindow.End)
+	if len(allowed) > 0 {
+		return allowed
+	}
+
+	// first allowed time before expiry
+	allowed = mw.AllowedIntervals(from, validTo)
+	if len(allowed) > 0 {
+		return []maintenance_windows.Interval{{Start: allowed[0].Start, End: allowed[0].Start}}
+	}
+
+	return nil
+}
+
+// maintenanceRenewalTime selects the renewal time of a cert that has a maintenance window. It is a
+// random time within the maintenanceRenewalIntervals. If nothing is allowed before the cert expires
+// (validTo), false is returned.
+func maintenanceRenewalTime(mw maintenance_windows.MaintenanceWindow, ari *renewalInfo, validTo time.Time, now time.Time) (time.Time, bool) {
+	allowed := maintenanceRenewalIntervals(mw, ari, validTo, now)
+	if len(allowed) == 0 {
+		return time.Time{}, false
+	}
+
+	return randomTimeInIntervals(allowed), true
+}
+
+// intervalsMinutes returns the total duration of the intervals in whole minutes
+func intervalsMinutes(intervals []maintenance_windows.Interval) int {
+	totalMinutes := 0
+	for _, interval := range intervals {
+		totalMinutes += int(interval.End.Sub(interval.Start).Minutes())
+	}
+
+	return totalMinutes
+}
+
+// timeInIntervals returns the time that is offsetMinutes into the intervals, counting only
+// the time within them
+func timeInIntervals(intervals []maintenance_windows.Interval, offsetMinutes int) time.Time {
+	for _, interval := range intervals {
+		intervalMinutes := int(interval.End.Sub(interval.Start).Minutes())
+		if offsetMinutes < intervalMinutes {
+			return interval.Start.Add(time.Duration(offsetMinutes) * time.Minute)
+		}
+		offsetMinutes -= intervalMinutes
+	}
+
+	// offset beyond the intervals (or all intervals less than a minute)
+	return intervals[0].Start
+}
+
+// randomTimeInIntervals returns a random time (to the minute) within the intervals,
+// weighted by their duration
+func randomTimeInIntervals(intervals []maintenance_windows.Interval) time.Time {
+	totalMinutes := intervalsMinutes(intervals)
+
+	// all intervals less than a minute
+	if totalMinutes <= 0 {
+		return intervals[0].Start
+	}
+
+	return timeInIntervals(intervals, randomness.GenerateInsecureInt(totalMinutes))
+}
+
+// placeNewOrderAt places a new order for the cert at the specified time, unless the app
+// shuts down first. It is used for certs with a maintenance window, so the order is placed
+// when renewal is allowed instead of at the time of the auto order run. If a new order is
+// already scheduled for the cert, this is a no-op. The cert is checked again (with
+// autoNewOrderAllowed) before the order is placed, since it, its maintenance window, or the
+// window's freezes may have changed in the meantime.
+func (service *Service) placeNewOrderAt(certId int, certName string, at time.Time) {
+	_, loaded := service.scheduledNewOrders.LoadOrStore(certId, at.Unix())
+	if loaded {
+		return
+	}
+
+	service.shutdownWaitgroup.Add(1)
+	go func() {
+		defer service.shutdownWaitgroup.Done()
+		defer service.scheduledNewOrders.Delete(certId)
+
+		select {
+		case <-service.shutdownContext.Done():
+			// app shutting down, next auto order run after restart will reschedule
+			return
+
+		case <-time.After(time.Until(at)):
+			// proceed
+		}
+
+		// confirm a new order is still allowed (if not, the next auto order run reschedules)
+		cert, outErr := service.certificates.GetCertificate(certId)
+		if outErr != nil {
+			service.logger.Errorf("orders: auto order failed to get cert %s for scheduled new order (%s)", certName, outErr.Error())
+			return
+		}
+		allowed, reason, err := service.autoNewOrderAllowed(cert)
+		if err != nil {
+			service.logger.Errorf("orders: auto order failed to check cert %s for scheduled new order (%s)", certName, err)
+			return
+		}
+		if !allowed {
+			service.logger.Infof("orders: auto order not placing scheduled new order for cert %s (%s)", certName, reason)
+			return
+		}
+
+		_, outErr = service.placeNewOrderAndFulfill(certId, false)
+		if outErr != nil {
+			service.logger.Errorf("orders: auto order failed to place scheduled new order for cert %s (%s)", certName, outErr.Error())
+			service.recordOrderFailure(certId, outErr)
+		}
+	}()
+}
+
+// autoNewOrderAllowed returns true if the auto order service may place a new order for the
+// cert now: its renewal policy auto renews, its failed orders (if any) don't hold back a new
+// order, and its maintenance window (if it has one) allows renewal now. If not allowed, the
+// reason is also returned.
+func (service *Service) autoNewOrderAllowed(cert certificates.Certificate) (allowed bool, reason string, err error) {
+	policy := cert.EffectiveRenewalPolicy(service.renewalPolicy)
+	if !*policy.AutoRenew {
+		return false, "auto renew disabled by renewal policy", nil
+	}
+
+	allowed, reason = service.failedOrdersAllowNewOrder(cert.OrderFailures, time.Now())
+	if !allowed {
+		return false, reason, nil
+	}
+
+	if cert.MaintenanceWindowID != nil {
+		mw, err := service.maintenanceWindows.GetMaintenanceWindow(*cert.MaintenanceWindowID)
+		if err != nil {
+			return false, "", err
+		}
+
+		if !mw.Allowed(time.Now()) {
+			return false, fmt.Sprintf("maintenance window %s does not allow renewal now", mw.Name), nil
+		}
+	}
+
+	return true, "", nil
+}
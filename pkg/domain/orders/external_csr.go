@@ -0,0 +1,248 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/randomness"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	errPrivateCANoRevoke = errors.New("orders: revocation is not supported for certificates issued by a private ca")
+
+	// ErrMaintenanceWindowClosed is returned when a cert's maintenance window doesn't
+	// allow issuance now
+	ErrMaintenanceWindowClosed = errors.New("orders: certificate's maintenance window does not allow issuance now")
+)
+
+// csrIdentifiers returns the identifiers for the names contained in a csr (the
+// common name, if set, followed by any dns and ip alt names)
+func csrIdentifiers(csr *x509.CertificateRequest) acme.IdentifierSlice {
+	var identifiers acme.IdentifierSlice
+
+	add := func(name string) {
+		id := acme.NewIdentifier(name)
+		if !slices.Contains(identifiers, id) {
+			identifiers = append(identifiers, id)
+		}
+	}
+
+	if csr.Subject.CommonName != "" {
+		add(csr.Subject.CommonName)
+	}
+	for _, name := range csr.DNSNames {
+		add(name)
+	}
+	for _, ip := range csr.IPAddresses {
+		add(ip.String())
+	}
+
+	return identifiers
+}
+
+// IssueCertificateForCsr obtains a certificate for a csr that was generated outside
+// of this app, using the issuer of the specified cert (i.e., its ACME account or private
+// CA). Any challenges are solved with the app's configured providers. The caller is
+// responsible for verifying the csr's names are permitted for cert. Nothing is saved
+// to storage since the resulting certificate's key is not managed by this app.
+func (service *Service) IssueCertificateForCsr(ctx context.Context, cert certificates.Certificate, csrDer []byte) (*acme.Certificate, error) {
+	// private ca
+	if cert.PrivateCA != nil {
+		certPem, err := service.privateCAs.IssueCertificate(cert.PrivateCA.ID, csrDer, cert.RequestedValidity())
+		if err != nil {
+			return nil, err
+		}
+
+		return acme.NewCertificateFromPem([]byte(certPem))
+	}
+
+	// acme
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	if err != nil {
+		return nil, fmt.Errorf("orders: failed to parse external csr (%w)", err)
+	}
+
+	key, err := cert.CertificateAccount.AcmeAccountKey()
+	if err != nil {
+		return nil, err
+	}
+
+	acmeService, err := service.acmeServerService.AcmeService(cert.CertificateAccount.AcmeServer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// ACME Profile Extension: only send profile if it isn't blank
+	p := &cert.Profile
+	if cert.Profile == "" {
+		p = nil
+	}
+
+	payload := acme.NewOrderPayload{Identifiers: csrIdentifiers(csr), Profile: p}
+	setRequestedValidity(&payload, cert)
+
+	acmeOrder, err := acmeService.NewOrder(payload, key)
+	if err != nil {
+		return nil, err
+	}
+	orderUrl := acmeOrder.Location
+
+	// exponential backoff for retrying while 'processing'
+	bo := randomness.BackoffACME(ctx)
+
+	// cap loop at 2 hours (same as the order fulfiller)
+	startTime := time.Now()
+	timeoutLength := 2 * time.Hour
+
+	for time.Since(startTime) <= timeoutLength {
+		if acmeOrder.Status != "processing" {
+			bo.Reset()
+		}
+
+		switch acmeOrder.Status {
+		case "pending": // needs to be authed
+			err = service.authorizations.FulfillAuths(acmeOrder.Authorizations, key, acmeService)
+			if err != nil {
+				return nil, err
+			}
+
+		case "ready": // needs to be finalized
+			_, err = acmeService.FinalizeOrder(acmeOrder.Finalize, csrDer, key)
+			if err != nil {
+				return nil, err
+			}
+
+		case "valid": // can be downloaded - final status
+			if acmeOrder.Certificate != nil {
+				issued, err := acmeService.DownloadCertificate(*acmeOrder.Certificate, key, cert.PreferredRootCN)
+				if err != nil {
+					return nil, err
+				}
+
+				// the certificate is usable regardless, so only log a validity mismatch
+				err = checkIssuedValidity(cert, acmeOrder, issued)
+				if err != nil {
+					service.logger.Errorf("orders: external csr order for cert %d: %s", cert.ID, err)
+				}
+
+				return issued, nil
+			}
+
+		case "invalid": // irrecoverable - final status
+			if acmeOrder.Error != nil {
+				return nil, fmt.Errorf("orders: external csr order status invalid (%w)", acmeOrder.Error)
+			}
+			return nil, errors.New("orders: external csr order status invalid")
+
+		case "processing":
+			// wait below
+
+		default:
+			return nil, fmt.Errorf("orders: external csr order status %s unknown", acmeOrder.Status)
+		}
+
+		// sleep a little before refreshing the order (longer while processing)
+		wait := 7 * time.Second
+		if acmeOrder.Status == "processing" {
+			wait = bo.NextBackOff()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("orders: external csr order canceled")
+
+		case <-time.After(wait):
+		}
+
+		acmeOrder, err = acmeService.GetOrder(orderUrl, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("orders: external csr order exhausted retry loop time")
+}
+
+// externalCsrJob issues a certificate for an external csr. It runs as an orderFulfillJob
+// so it shares the order fulfilling workers and queue.
+type externalCsrJob struct {
+	// id uniquely identifies the request (e.g., the acme front-end's order id)
+	id     string
+	cert   certificates.Certificate
+	csrDer []byte
+	// done is called with the result
+	done func(*acme.Certificate, error)
+}
+
+// do issues the certificate and calls done with the result
+func (ej *externalCsrJob) do(service *Service, workerID int) {
+	service.logger.Infof("orders: fulfilling worker %d: issuing external csr %s (certificate name: %s)", workerID, ej.id, ej.cert.Name)
+
+	issued, err := service.IssueCertificateForCsr(service.shutdownContext, ej.cert, ej.csrDer)
+	ej.done(issued, err)
+
+	service.logger.Infof("orders: fulfilling worker %d: external csr %s done", workerID, ej.id)
+}
+
+// QueueCertificateForCsr queues IssueCertificateForCsr as a low priority order fulfilling
+// job, so issuance for external csrs is limited by the same workers and queue as the
+// app's own orders. id must uniquely identify the request, and done is called with the
+// result once the job has run. If cert's maintenance window doesn't allow issuance now,
+// an error wrapping ErrMaintenanceWindowClosed is returned and nothing is queued.
+func (service *Service) QueueCertificateForCsr(id string, cert certificates.Certificate, csrDer []byte, done func(*acme.Certificate, error)) error {
+	if cert.MaintenanceWindowID != nil {
+		mw, err := service.maintenanceWindows.GetMaintenanceWindow(*cert.MaintenanceWindowID)
+		if err != nil {
+			return err
+		}
+
+		if !mw.Allowed(time.Now()) {
+			return fmt.Errorf("%w (maintenance window %s)", ErrMaintenanceWindowClosed, mw.Name)
+		}
+	}
+
+	err := service.orderFulfilling.AddJob(&orderFulfillJob{
+		service: service,
+
+		addedToQueue: time.Now(),
+		highPriority: false,
+		external: &externalCsrJob{
+			id:     id,
+			cert:   cert,
+			csrDer: csrDer,
+			done:   done,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("orders: fulfilling: failed to add external csr %s (%w)", id, err)
+	}
+
+	return nil
+}
+
+// RevokeExternalCertificate revokes a certificate that was obtained with
+// IssueCertificateForCsr, using the ACME account of the specified cert.
+func (service *Service) RevokeExternalCertificate(cert certificates.Certificate, certDer []byte, reasonCode int) error {
+	if cert.PrivateCA != nil {
+		return errPrivateCANoRevoke
+	}
+
+	key, err := cert.CertificateAccount.AcmeAccountKey()
+	if err != nil {
+		return err
+	}
+
+	acmeService, err := service.acmeServerService.AcmeService(cert.CertificateAccount.AcmeServer.ID)
+	if err != nil {
+		return err
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})
+
+	return acmeService.RevokeCertificate(string(certPem), reasonCode, key)
+}
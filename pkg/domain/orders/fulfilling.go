@@ -13,6 +13,13 @@ type orderFulfillJob struct {
 	addedToQueue time.Time
 	highPriority bool
 	orderID      int
+
+	// jobID is the id of the saved job (0 if not saved)
+	jobID int
+
+	// external (if set) makes this a job that issues a certificate for an external csr
+	// instead of fulfilling a saved order
+	external *externalCsrJob
 }
 
 // makeFulfillingJob makes an orderFulfillJob
@@ -40,13 +47,25 @@ func (service *Service) makeFulfillingJob(orderID int, highPriority bool) (*orde
 // Description implements part of the Job interface and returns a string
 // that will be used for logging purposes
 func (j *orderFulfillJob) Description() string {
+	if j.external != nil {
+		return fmt.Sprintf("external csr: %s (certificate name: %s)", j.external.id, j.external.cert.Name)
+	}
+
 	return fmt.Sprintf("order id: %d", j.orderID)
 }
 
 // Equal implements part of the Job interface to determine if two jobs
 // should be considered the same job
 func (j *orderFulfillJob) Equal(j2 *orderFulfillJob) bool {
-	return j != nil && j2 != nil && j.orderID == j2.orderID
+	if j == nil || j2 == nil {
+		return false
+	}
+
+	if j.external != nil || j2.external != nil {
+		return j.external != nil && j2.external != nil && j.external.id == j2.external.id
+	}
+
+	return j.orderID == j2.orderID
 }
 
 // IsHighPriority implements Job interface priority func
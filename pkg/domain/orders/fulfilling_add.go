@@ -1,18 +1,46 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/datatypes/job_manager"
 	"fmt"
 )
 
 // fulfillOrder queues the specified order ID with the specified priority level
 // for fulfillment of that order with the ACME server
 func (service *Service) fulfillOrder(orderID int, isHighPriority bool) (err error) {
+	return service.queueFulfillJob(0, orderID, isHighPriority)
+}
+
+// queueFulfillJob queues the fulfill job. If jobID is 0, a new job is saved to storage,
+// otherwise the saved job is being resumed. Failure to queue a saved job is recorded
+// as the job's error.
+func (service *Service) queueFulfillJob(jobID int, orderID int, isHighPriority bool) (err error) {
+	// record failure of saved job
+	defer func() {
+		if err != nil {
+			service.updateJobStatus(jobID, jobStatusFailed, false, err)
+		}
+	}()
+
 	// make job
 	newJob, err := service.makeFulfillingJob(orderID, isHighPriority)
 	if err != nil {
 		return err
 	}
 
+	// save new job (unless it is a duplicate)
+	if jobID == 0 {
+		if service.orderFulfilling.JobExists(newJob) != nil {
+			return fmt.Errorf("orders: fulfilling: failed to add order id %d (%w)", orderID, job_manager.ErrAddDuplicateJob)
+		}
+
+		jobID, err = service.saveNewJob(jobTypeOrderFulfill, orderID, isHighPriority)
+		if err != nil {
+			return err
+		}
+	}
+	newJob.jobID = jobID
+
 	// add to the Job Manager
 	err = service.orderFulfilling.AddJob(newJob)
 	if err != nil {
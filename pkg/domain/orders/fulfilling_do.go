@@ -4,12 +4,70 @@ import (
 	"certwarden-backend/pkg/acme"
 	"certwarden-backend/pkg/randomness"
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 )
 
-// Do executes the order fulfill job
+// Do executes the order fulfill job and records its outcome
 func (j *orderFulfillJob) Do(workerID int) {
+	if j.external != nil {
+		j.external.do(j.service, workerID)
+		return
+	}
+
+	j.service.jobStarted(j.jobID)
+	j.fulfill(workerID)
+
+	order, err := j.result()
+	j.service.jobFinished(j.jobID, err)
+
+	// update the cert's failure streak (unless order wasn't found or job was interrupted by shutdown);
+	// any valid order clears the streak, but only failures of orders placed by the auto order service
+	// (low priority) are recorded and retried, since a user placing a manual (high priority) order
+	// sees its outcome and can decide whether to try again
+	if order.ID == 0 {
+		return
+	}
+	if err == nil {
+		j.service.updateOrderFailures(order.Certificate, nil)
+		return
+	}
+	if j.highPriority || j.service.shutdownContext.Err() != nil {
+		return
+	}
+
+	// a repeat attempt at the same order (e.g., retrying an incomplete order) is not a new failure
+	if j.service.isRepeatFulfillJob(j.jobID, j.orderID) {
+		j.service.logger.Debugf("orders: not recording failure of order %d again (repeat attempt)", j.orderID)
+		return
+	}
+
+	j.service.updateOrderFailures(order.Certificate, err)
+}
+
+// result returns the job's order and an error if the order did not end up valid
+func (j *orderFulfillJob) result() (Order, error) {
+	order, err := j.service.storage.GetOneOrder(j.orderID)
+	if err != nil {
+		return Order{}, err
+	}
+
+	switch order.Status {
+	case "valid":
+		return order, nil
+	case "invalid":
+		if order.Error != nil {
+			return order, fmt.Errorf("order is invalid (%s)", order.Error)
+		}
+		return order, errors.New("order is invalid")
+	default:
+		return order, fmt.Errorf("order status is %s after fulfilling", order.Status)
+	}
+}
+
+// fulfill fulfills the job's order with the ACME Server (or private CA)
+func (j *orderFulfillJob) fulfill(workerID int) {
 	// log end of Do (regardless of outcome)
 	defer j.service.logger.Infof("orders: fulfilling worker %d: order %d done", workerID, j.orderID)
 
@@ -31,6 +89,12 @@ func (j *orderFulfillJob) Do(workerID int) {
 		}
 	}()
 
+	// private CA orders are issued locally
+	if order.Certificate.PrivateCA != nil {
+		j.doPrivateCA(workerID, order)
+		return
+	}
+
 	// get account key
 	key, err := order.Certificate.CertificateAccount.AcmeAccountKey()
 	if err != nil {
@@ -38,8 +102,8 @@ func (j *orderFulfillJob) Do(workerID int) {
 		return // done, failed
 	}
 
-	// make cert CSR
-	csr, err := order.Certificate.MakeCsrDer()
+	// make cert CSR (or use the cert's external csr)
+	csr, err := order.Certificate.CsrDer()
 	if err != nil {
 		j.service.logger.Errorf("orders: fulfilling worker %d: make csr error: %s", workerID, err)
 		return // done, failed
@@ -101,14 +165,31 @@ fulfillLoop:
 			}
 
 		case "ready": // needs to be finalized
-			// save finalized_key_id in storage (if finalize ACME cmd below fails, this will save any key change
-			// upon next attempt to finalize with ACME; therefore this should always occur BEFORE the ACME finalize
-			// command)
-			err = j.service.storage.UpdateFinalizedKey(order.ID, order.Certificate.CertificateKey.ID)
+			// rotate the cert's key first, if its policy calls for it (the csr must then be
+			// remade with the new key)
+			rotated, err := j.rotateKeyIfDue(workerID, &order.Certificate)
 			if err != nil {
-				j.service.logger.Errorf("orders: fulfilling worker %d: update finalized key error: %s", workerID, err)
+				j.service.logger.Errorf("orders: fulfilling worker %d: key rotation error: %s", workerID, err)
 				return // done, failed
 			}
+			if rotated {
+				csr, err = order.Certificate.MakeCsrDer()
+				if err != nil {
+					j.service.logger.Errorf("orders: fulfilling worker %d: make csr error: %s", workerID, err)
+					return // done, failed
+				}
+			}
+
+			// save finalized_key_id in storage (if finalize ACME cmd below fails, this will save any key change
+			// upon next attempt to finalize with ACME; therefore this should always occur BEFORE the ACME finalize
+			// command); external csr certs don't have a key
+			if !order.Certificate.HasExternalCsr() {
+				err = j.service.storage.UpdateFinalizedKey(order.ID, order.Certificate.CertificateKey.ID)
+				if err != nil {
+					j.service.logger.Errorf("orders: fulfilling worker %d: update finalized key error: %s", workerID, err)
+					return // done, failed
+				}
+			}
 
 			// finalize the order
 			_, err = acmeService.FinalizeOrder(acmeOrder.Finalize, csr, key)
@@ -118,13 +199,13 @@ fulfillLoop:
 			}
 
 			// should be valid on next check (or maybe processing - sleep a little to try and avoid 'processing')
-			time.Sleep(7 * time.Second)
+			time.Sleep(randomness.ACMEPollInterval)
 
 		case "valid": // can be downloaded - final status
 			// nil check (make sure there is a cert URL)
 			if acmeOrder.Certificate == nil {
 				// if cert url is missing (nil), sleep a little and loop again (which will refresh order info)
-				time.Sleep(7 * time.Second)
+				time.Sleep(randomness.ACMEPollInterval)
 				continue
 			}
 
@@ -134,6 +215,12 @@ fulfillLoop:
 				return // done, failed
 			}
 
+			// the certificate is usable regardless, so only log a validity mismatch
+			err = checkIssuedValidity(order.Certificate, acmeOrder, cert)
+			if err != nil {
+				j.service.logger.Errorf("orders: fulfilling worker %d: %s", workerID, err)
+			}
+
 			// get ari, if supported
 			var acmeARI *acme.ACMERenewalInfo
 			if acmeService.SupportsARIExtension() {
@@ -145,7 +232,7 @@ fulfillLoop:
 			}
 
 			// process pem and save to storage
-			err = j.saveAcmeCert(order.ID, cert, acmeARI)
+			err = j.saveAcmeCert(order, cert, acmeARI)
 			if err != nil {
 				j.service.logger.Errorf("orders: fulfilling worker %d: save pem error: %s", workerID, err)
 				return // done, failed
@@ -196,26 +283,46 @@ fulfillLoop:
 
 	// if order valid, do post processing
 	if acmeOrder.Status == "valid" {
-		// send to post-processing queue
-		if order.hasPostProcessingToDo() {
-			err = j.service.postProcess(j.orderID, j.IsHighPriority())
-			if err != nil {
-				j.service.logger.Errorf("orders: fulfilling worker %d: failed to add post process job (%s)")
-			}
+		j.doValidOrderActions(workerID, order)
+	}
+
+	// success
+	j.service.logger.Infof("orders: fulfilling worker %d: order id %d completed with status %s (certificate name: %s, subject: %s)", workerID, order.ID, acmeOrder.Status, order.Certificate.Name, order.Certificate.Subject)
+
+}
+
+// doValidOrderActions performs the actions that follow an order becoming valid (i.e.,
+// queueing post processing, reloading the app's https certificate, completing any key
+// rotation, and deleting any retired keys that are no longer needed)
+func (j *orderFulfillJob) doValidOrderActions(workerID int, order Order) {
+	// send to post-processing queue
+	if order.hasPostProcessingToDo() {
+		err := j.service.postProcess(j.orderID, j.IsHighPriority())
+		if err != nil {
+			j.service.logger.Errorf("orders: fulfilling worker %d: failed to add post process job (%s)", workerID, err)
 		}
+	}
 
-		// also update Server Cert (if this order was for this app)
-		if j.service.serverCertificateName != nil && *j.service.serverCertificateName == order.Certificate.Name {
-			err = j.service.loadHttpsCertificateFunc()
-			if err != nil {
-				j.service.logger.Errorf("orders: fulfilling worker %d: failed to load app's new https certificate (%s)", workerID, err)
-			} else {
-				j.service.logger.Debugf("orders: fulfilling worker %d: new app https certificate loaded", workerID)
-			}
+	// also update Server Cert (if this order was for this app)
+	if j.service.serverCertificateName != nil && *j.service.serverCertificateName == order.Certificate.Name {
+		err := j.service.loadHttpsCertificateFunc()
+		if err != nil {
+			j.service.logger.Errorf("orders: fulfilling worker %d: failed to load app's new https certificate (%s)", workerID, err)
+		} else {
+			j.service.logger.Debugf("orders: fulfilling worker %d: new app https certificate loaded", workerID)
 		}
 	}
 
-	// success
-	j.service.logger.Infof("orders: fulfilling worker %d: order id %d completed with status %s (certificate name: %s, subject: %s)", workerID, order.ID, acmeOrder.Status, order.Certificate.Name, order.Certificate.Subject)
+	// if this order used a rotated key, hand the old key's api access over to it
+	if !order.Certificate.HasExternalCsr() {
+		completed, err := j.service.storage.CompleteCertKeyRotation(order.Certificate.ID)
+		if err != nil {
+			j.service.logger.Errorf("orders: fulfilling worker %d: failed to complete key rotation of certificate %s (%s)", workerID, order.Certificate.Name, err)
+		} else if completed > 0 {
+			j.service.logger.Infof("orders: fulfilling worker %d: completed key rotation of certificate %s (api access of %d retired key(s) moved to the new key)", workerID, order.Certificate.Name, completed)
+		}
+	}
 
+	// a rotation may have made an old key unnecessary
+	j.service.deleteUnusedRetiredKeys()
 }
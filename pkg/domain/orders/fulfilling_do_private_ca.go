@@ -0,0 +1,81 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"time"
+)
+
+// doPrivateCA fulfills an order for a cert that is issued by a private CA. There is
+// nothing to authorize, so the csr is signed by the CA immediately.
+func (j *orderFulfillJob) doPrivateCA(workerID int, order Order) {
+	// private ca orders only have one meaningful state to work from
+	if order.Status != "ready" {
+		j.service.logger.Errorf("orders: fulfilling worker %d: private ca order %d has status %s, nothing to do", workerID, order.ID, order.Status)
+		return // done, failed
+	}
+
+	// rotate the cert's key first, if its policy calls for it
+	_, err := j.rotateKeyIfDue(workerID, &order.Certificate)
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: key rotation error: %s", workerID, err)
+		return // done, failed
+	}
+
+	// save finalized_key_id in storage (external csr certs don't have a key)
+	if !order.Certificate.HasExternalCsr() {
+		err = j.service.storage.UpdateFinalizedKey(order.ID, order.Certificate.CertificateKey.ID)
+		if err != nil {
+			j.service.logger.Errorf("orders: fulfilling worker %d: update finalized key error: %s", workerID, err)
+			return // done, failed
+		}
+	}
+
+	// make cert CSR (or use the cert's external csr)
+	csr, err := order.Certificate.CsrDer()
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: make csr error: %s", workerID, err)
+		return // done, failed
+	}
+
+	// issue (on failure the order remains ready and can be retried)
+	certPem, err := j.service.privateCAs.IssueCertificate(order.Certificate.PrivateCA.ID, csr, order.Certificate.RequestedValidity())
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: private ca issue error: %s", workerID, err)
+		return // done, failed
+	}
+
+	cert, err := acme.NewCertificateFromPem([]byte(certPem))
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: private ca cert error: %s", workerID, err)
+		return // done, failed
+	}
+
+	// process pem and save to storage
+	err = j.saveAcmeCert(order, cert, nil)
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: save pem error: %s", workerID, err)
+		return // done, failed
+	}
+
+	// update order status
+	err = j.service.storage.PutOrderAcme(UpdateAcmeOrderPayload{
+		Status:         "valid",
+		Expires:        order.Expires,
+		DnsIds:         order.DnsIdentifiers,
+		IpIds:          order.IpIdentifiers,
+		Authorizations: []string{},
+		Finalize:       "",
+		Profile:        order.Profile,
+		UpdatedAt:      int(time.Now().Unix()),
+		OrderId:        order.ID,
+	})
+	if err != nil {
+		j.service.logger.Errorf("orders: fulfilling worker %d: update order db error: %s", workerID, err)
+		return // done, failed
+	}
+
+	j.doValidOrderActions(workerID, order)
+
+	// success
+	j.service.logger.Infof("orders: fulfilling worker %d: order id %d completed with status valid (certificate name: %s, subject: %s)", workerID, order.ID, order.Certificate.Name, order.Certificate.Subject)
+}
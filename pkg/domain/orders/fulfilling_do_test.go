@@ -0,0 +1,374 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/acme/acmetest"
+	"certwarden-backend/pkg/challenges"
+	"certwarden-backend/pkg/challenges/dns_checker"
+	"certwarden-backend/pkg/challenges/providers"
+	"certwarden-backend/pkg/challenges/providers/http01internal"
+	"certwarden-backend/pkg/domain/acme_accounts"
+	"certwarden-backend/pkg/domain/acme_servers"
+	"certwarden-backend/pkg/domain/authorizations"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/randomness"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// testApp implements the App interfaces of the services the fulfiller depends on
+type testApp struct {
+	logger            *zap.SugaredLogger
+	output            *output.Service
+	httpClient        *http.Client
+	ctx               context.Context
+	wg                *sync.WaitGroup
+	acmeServerStorage acme_servers.Storage
+	acmeServerService *acme_servers.Service
+	challenges        *challenges.Service
+}
+
+func (app *testApp) GetConfigFilenameWithPath() string           { return "" }
+func (app *testApp) GetLogger() *zap.SugaredLogger               { return app.logger }
+func (app *testApp) GetOutputter() *output.Service               { return app.output }
+func (app *testApp) GetHttpClient() *http.Client                 { return app.httpClient }
+func (app *testApp) GetShutdownContext() context.Context         { return app.ctx }
+func (app *testApp) GetShutdownWaitGroup() *sync.WaitGroup       { return app.wg }
+func (app *testApp) GetAcmeServerStorage() acme_servers.Storage  { return app.acmeServerStorage }
+func (app *testApp) GetAcmeServerService() *acme_servers.Service { return app.acmeServerService }
+func (app *testApp) GetChallengesService() *challenges.Service   { return app.challenges }
+
+// testAcmeServerStorage is acme_servers storage containing only the test server
+type testAcmeServerStorage struct {
+	acme_servers.Storage
+	server acme_servers.Server
+}
+
+func (storage *testAcmeServerStorage) GetAllAcmeServers(q pagination_sort.Query) ([]acme_servers.Server, int, error) {
+	return []acme_servers.Server{storage.server}, 1, nil
+}
+
+// testOrderStorage holds a single order and records the fulfiller's updates to it
+type testOrderStorage struct {
+	Storage
+	order Order
+
+	// issuedWithKey is the number of valid orders finalized with the cert's key
+	issuedWithKey int
+
+	mu           sync.Mutex
+	finalizedKey int
+	certPayload  *CertPayload
+	acmePayload  *UpdateAcmeOrderPayload
+	rotatedKey   *private_keys.Key
+	// rotationCompleted is the finalized key when the rotation was completed
+	rotationCompleted int
+}
+
+func (storage *testOrderStorage) GetOneOrder(orderId int) (Order, error) {
+	if orderId != storage.order.ID {
+		return Order{}, errors.New("order not found")
+	}
+	return storage.order, nil
+}
+
+func (*testOrderStorage) GetOrdersByCert(certId int, q pagination_sort.Query) ([]Order, int, error) {
+	return nil, 0, storage.ErrNoRecord
+}
+
+func (storage *testOrderStorage) UpdateFinalizedKey(orderId int, keyId int) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.finalizedKey = keyId
+	return nil
+}
+
+func (storage *testOrderStorage) UpdateOrderCert(orderId int, payload *CertPayload) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.certPayload = payload
+	return nil
+}
+
+func (storage *testOrderStorage) PutOrderAcme(payload UpdateAcmeOrderPayload) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.acmePayload = &payload
+	return nil
+}
+
+func (storage *testOrderStorage) UpdateCertUpdatedTime(certId int) error {
+	return nil
+}
+
+func (storage *testOrderStorage) CountValidOrdersFinalizedWithKey(certId int, keyId int) (int, error) {
+	return storage.issuedWithKey, nil
+}
+
+func (storage *testOrderStorage) RotateCertKey(certId int, oldKeyId int, newKey private_keys.NewPayload) (private_keys.Key, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	if oldKeyId != storage.order.Certificate.CertificateKey.ID {
+		return private_keys.Key{}, errors.New("certificate key changed")
+	}
+	storage.rotatedKey = &private_keys.Key{
+		ID:        oldKeyId + 100,
+		Name:      *newKey.Name,
+		Algorithm: key_crypto.AlgorithmByStorageValue(*newKey.AlgorithmValue),
+		Pem:       *newKey.PemContent,
+	}
+	return *storage.rotatedKey, nil
+}
+
+func (storage *testOrderStorage) CompleteCertKeyRotation(certId int) (int, error) {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.rotationCompleted = storage.finalizedKey
+	return 1, nil
+}
+
+func (storage *testOrderStorage) DeleteUnusedRetiredKeys() ([]int, error) {
+	return nil, nil
+}
+
+func (storage *testOrderStorage) PutCertOrderFailures(certId int, failures certificates.OrderFailures) error {
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+	storage.order.Certificate.OrderFailures = failures
+	return nil
+}
+
+// testKey generates a private_keys.Key of the specified algorithm
+func testKey(t *testing.T, id int, algValue string) private_keys.Key {
+	t.Helper()
+
+	alg := key_crypto.AlgorithmByStorageValue(algValue)
+	pem, err := alg.GeneratePrivateKeyPem()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return private_keys.Key{ID: id, Algorithm: alg, Pem: pem}
+}
+
+func TestOrders_FulfillJobDo(t *testing.T) {
+	// poll the local acme server quickly
+	pollInterval := randomness.ACMEPollInterval
+	randomness.ACMEPollInterval = 50 * time.Millisecond
+	t.Cleanup(func() { randomness.ACMEPollInterval = pollInterval })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := &testApp{
+		logger: zaptest.NewLogger(t).Sugar(),
+		ctx:    ctx,
+		wg:     new(sync.WaitGroup),
+	}
+	t.Cleanup(func() {
+		cancel()
+		app.wg.Wait()
+	})
+
+	// services the fulfiller depends on
+	var err error
+	app.output, err = output.NewService(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipCheckWait := 0
+	anyPort := 0
+	app.challenges, err = challenges.NewService(app, &challenges.Config{
+		DnsCheckerConfig: dns_checker.Config{SkipCheckWaitSeconds: &skipCheckWait},
+		ProviderConfigs: providers.Config{
+			Http01InternalConfigs: []providers.ConfigManagerHttp01Internal{{
+				InternalConfig: providers.InternalConfig{Domains: []string{"*"}},
+				Config:         &http01internal.Config{Port: &anyPort},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to make challenges service (%s)", err)
+	}
+	http01Provider, err := app.challenges.DNSIdentifierProviders.ProviderFor("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := acmetest.NewServer(t, acmetest.Config{
+		HTTP01Port:      http01Provider.Service.(*http01internal.Service).Addr().(*net.TCPAddr).Port,
+		AlternateChains: 1,
+	})
+	preferredRoot := server.RootCommonNames()[1]
+	app.httpClient = server.Client()
+	app.acmeServerStorage = &testAcmeServerStorage{server: acme_servers.Server{ID: 1, DirectoryURL: server.DirectoryURL()}}
+
+	app.acmeServerService, err = acme_servers.NewService(app)
+	if err != nil {
+		t.Fatalf("failed to make acme servers service (%s)", err)
+	}
+	auths, err := authorizations.NewService(app)
+	if err != nil {
+		t.Fatalf("failed to make authorizations service (%s)", err)
+	}
+
+	acmeService, err := app.acmeServerService.AcmeService(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; acmeService.DirectoryRawResponse() == nil; i++ {
+		if i >= 100 {
+			t.Fatal("acme service directory did not load")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// account and certificate
+	account := acme_accounts.Account{
+		ID:         1,
+		AcmeServer: acme_servers.Server{ID: 1},
+		AccountKey: testKey(t, 1, "ecdsap256"),
+	}
+	accountKey, err := account.AcmeAccountKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct, err := acmeService.NewAccount(acme.NewAccountPayload{TosAgreed: true}, accountKey.Key)
+	if err != nil {
+		t.Fatalf("failed to create account (%s)", err)
+	}
+	account.Kid = *acct.Location
+	accountKey.Kid = account.Kid
+
+	cert := certificates.Certificate{
+		ID:                     1,
+		Name:                   "test",
+		CertificateKey:         testKey(t, 2, "rsa2048"),
+		CertificateAccount:     account,
+		Subject:                "example.com",
+		SubjectAltNames:        []string{"www.example.com", "192.0.2.1"},
+		PreferredRootCN:        preferredRoot,
+		RequestedValidityHours: 24,
+		KeyRotationInterval:    1,
+	}
+
+	// the key was already used once, so it is rotated before finalizing
+	storage := &testOrderStorage{order: Order{ID: 1, Certificate: cert}, issuedWithKey: 1}
+	service := &Service{
+		shutdownContext:   ctx,
+		logger:            app.logger,
+		output:            app.output,
+		storage:           storage,
+		acmeServerService: app.acmeServerService,
+		authorizations:    auths,
+		renewalPolicy:     certificates.DefaultRenewalPolicy(),
+	}
+
+	// new order on the acme server
+	acmeOrder, err := acmeService.NewOrder(service.NewOrderPayload(cert), accountKey)
+	if err != nil {
+		t.Fatalf("failed to create order (%s)", err)
+	}
+	storage.order.Location = acmeOrder.Location
+
+	job := &orderFulfillJob{service: service, orderID: 1}
+	job.Do(0)
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	if storage.acmePayload == nil {
+		t.Fatal("order acme details were not saved")
+	}
+	if storage.acmePayload.Status != "valid" {
+		t.Errorf("order status %s, expected valid", storage.acmePayload.Status)
+	}
+	if storage.acmePayload.CertificateUrl == nil {
+		t.Error("order certificate url was not saved")
+	}
+
+	if storage.rotatedKey == nil {
+		t.Fatal("certificate key was not rotated")
+	}
+	if storage.finalizedKey != storage.rotatedKey.ID {
+		t.Errorf("finalized key id %d, expected rotated key %d", storage.finalizedKey, storage.rotatedKey.ID)
+	}
+	if storage.rotationCompleted != storage.rotatedKey.ID {
+		t.Error("key rotation was not completed after the order was valid")
+	}
+
+	if storage.certPayload == nil {
+		t.Fatal("certificate was not saved")
+	}
+	if storage.certPayload.AcmeCert.ChainRootCN() != preferredRoot {
+		t.Errorf("saved chain root '%s', expected preferred root '%s'", storage.certPayload.AcmeCert.ChainRootCN(), preferredRoot)
+	}
+
+	// issued for the rotated key
+	leafBlock, _ := pem.Decode([]byte(storage.certPayload.AcmeCert.PEM()))
+	if leafBlock == nil {
+		t.Fatal("saved certificate pem is invalid")
+	}
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedKey, err := storage.rotatedKey.CryptoPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leaf.PublicKey.(*rsa.PublicKey).Equal(rotatedKey.(*rsa.PrivateKey).Public()) {
+		t.Error("issued certificate is not for the rotated key")
+	}
+
+	issuedValidity := storage.certPayload.AcmeCert.NotAfter().Sub(storage.certPayload.AcmeCert.NotBefore())
+	if issuedValidity != cert.RequestedValidity() {
+		t.Errorf("issued certificate valid for %s, expected requested %s", issuedValidity, cert.RequestedValidity())
+	}
+
+	// renewal window should come from the server's ari (which starts 2/3 through the validity)
+	notBefore := storage.certPayload.AcmeCert.NotBefore()
+	notAfter := storage.certPayload.AcmeCert.NotAfter()
+	expectedStart := notBefore.Add(notAfter.Sub(notBefore) * 2 / 3)
+	if storage.certPayload.RenewalInfo == nil || storage.certPayload.RenewalInfo.SuggestedWindow.Start.Sub(expectedStart).Abs() > time.Second {
+		t.Errorf("saved renewal info does not match the server's renewal window")
+	}
+}
+
+func TestOrders_FulfillJobEqual(t *testing.T) {
+	order1 := &orderFulfillJob{orderID: 1}
+	external1 := &orderFulfillJob{external: &externalCsrJob{id: "1"}}
+
+	tests := []struct {
+		name string
+		a, b *orderFulfillJob
+		want bool
+	}{
+		{"same order", order1, &orderFulfillJob{orderID: 1}, true},
+		{"other order", order1, &orderFulfillJob{orderID: 2}, false},
+		{"same external", external1, &orderFulfillJob{external: &externalCsrJob{id: "1"}}, true},
+		{"other external", external1, &orderFulfillJob{external: &externalCsrJob{id: "2"}}, false},
+		{"order and external", &orderFulfillJob{}, external1, false},
+		{"zero value", external1, nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Equal(tt.b); got != tt.want {
+			t.Errorf("%s: Equal() = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -17,8 +17,8 @@ type CertPayload struct {
 
 // savePemChain calls a func to determine the valid from and to dates for the issued pem chain
 // and then saves the pem chain and valid dates to storage
-func (j *orderFulfillJob) saveAcmeCert(orderId int, cert *acme.Certificate, acmeARI *acme.ACMERenewalInfo) (err error) {
-	// if acme ARI is available, use it, else make a sane default
+func (j *orderFulfillJob) saveAcmeCert(order Order, cert *acme.Certificate, acmeARI *acme.ACMERenewalInfo) (err error) {
+	// if acme ARI is available, use it, else make a default from the cert's renewal policy
 	var ari *renewalInfo
 	if acmeARI != nil {
 		ari = &renewalInfo{
@@ -33,7 +33,7 @@ func (j *orderFulfillJob) saveAcmeCert(orderId int, cert *acme.Certificate, acme
 			RetryAfter:     &acmeARI.RetryAfter,
 		}
 	} else {
-		ari = MakeRenewalInfo(cert.NotBefore(), cert.NotAfter())
+		ari = MakeRenewalInfo(cert.NotBefore(), cert.NotAfter(), order.Certificate.EffectiveRenewalPolicy(j.service.renewalPolicy))
 	}
 
 	// payload to save
@@ -44,7 +44,7 @@ func (j *orderFulfillJob) saveAcmeCert(orderId int, cert *acme.Certificate, acme
 	}
 
 	// save to storage
-	err = j.service.storage.UpdateOrderCert(orderId, payload)
+	err = j.service.storage.UpdateOrderCert(order.ID, payload)
 	if err != nil {
 		return err
 	}
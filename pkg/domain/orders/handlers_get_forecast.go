@@ -0,0 +1,171 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// default and max number of days in the renewal forecast histogram
+	defaultForecastDays = 30
+	maxForecastDays     = 365
+
+	// forecastDateLayout is the format of the histogram's dates
+	forecastDateLayout = "2006-01-02"
+)
+
+var errForecastDaysBad = errors.New("orders: forecast days is invalid (must be 1 to 365)")
+
+// forecastWindowResponse is a window of time (unix timestamps)
+type forecastWindowResponse struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// certRenewalForecastResponse is the renewal forecast of one certificate
+type certRenewalForecastResponse struct {
+	CertificateID       int                     `json:"certificate_id"`
+	CertificateName     string                  `json:"certificate_name"`
+	OrderID             int                     `json:"order_id"`
+	ValidTo             int                     `json:"valid_to"`
+	SuggestedWindow     forecastWindowResponse  `json:"suggested_window"`
+	RenewalInfoSource   string                  `json:"renewal_info_source"`
+	MaintenanceWindowID *int                    `json:"maintenance_window_id"`
+	RenewalWindow       *forecastWindowResponse `json:"renewal_window"`
+	Reason              string                  `json:"reason"`
+}
+
+// renewalForecastDayResponse is the number of renewals expected on one day (UTC). Since renewal
+// is at a random time within the renewal window, a cert's renewal is spread over the days of its
+// window and the number is fractional.
+type renewalForecastDayResponse struct {
+	Date     string  `json:"date"`
+	Renewals float64 `json:"renewals"`
+}
+
+// renewalForecastResponse is the response to a request for the renewal forecast
+type renewalForecastResponse struct {
+	output.JsonResponse
+	Certificates []certRenewalForecastResponse `json:"certificates"`
+	Histogram    []renewalForecastDayResponse  `json:"histogram"`
+}
+
+// GetRenewalForecast returns the suggested renewal window, the window renewal is projected to
+// happen in (the auto orderer picks a random time within it), and the reason for it for each
+// certificate with a valid current order. It also returns a histogram of the number of expected
+// renewals per day (UTC) for the next 'days' days. The forecast uses stored
+// renewal info and does not fetch new ARI from ACME Servers.
+func (service *Service) GetRenewalForecast(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// number of days for histogram
+	days := defaultForecastDays
+	daysParam := r.URL.Query().Get("days")
+	if daysParam != "" {
+		var err error
+		days, err = strconv.Atoi(daysParam)
+		if err != nil || days < 1 || days > maxForecastDays {
+			service.logger.Debug(errForecastDaysBad)
+			return output.JsonErrValidationFailed(errForecastDaysBad)
+		}
+	}
+
+	// get all currently valid orders (same as auto ordering)
+	orders, _, err := service.storage.GetAllValidCurrentOrders(pagination_sort.Query{})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	now := time.Now()
+
+	// histogram days
+	today := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	histogram := make([]renewalForecastDayResponse, days)
+	for i := range histogram {
+		histogram[i].Date = today.AddDate(0, 0, i).Format(forecastDateLayout)
+	}
+
+	// maintenance windows (by id), to avoid repeat lookups
+	windows := make(map[int]maintenance_windows.MaintenanceWindow)
+
+	certForecasts := []certRenewalForecastResponse{}
+	for i := range orders {
+		if orders[i].ValidFrom == nil || orders[i].ValidTo == nil {
+			continue
+		}
+
+		certForecast := certRenewalForecastResponse{
+			CertificateID:       orders[i].Certificate.ID,
+			CertificateName:     orders[i].Certificate.Name,
+			OrderID:             orders[i].ID,
+			ValidTo:             int(orders[i].ValidTo.Unix()),
+			MaintenanceWindowID: orders[i].Certificate.MaintenanceWindowID,
+		}
+
+		// renewal info, calculated locally the same way auto ordering would
+		policy := orders[i].Certificate.EffectiveRenewalPolicy(service.renewalPolicy)
+		ari := orders[i].RenewalInfo
+		certForecast.RenewalInfoSource = "acme_server"
+		if localRenewalInfoNeeded(ari, policy) {
+			ari = MakeRenewalInfo(*orders[i].ValidFrom, *orders[i].ValidTo, policy)
+			certForecast.RenewalInfoSource = "local"
+		}
+		certForecast.SuggestedWindow.Start = int(ari.SuggestedWindow.Start.Unix())
+		certForecast.SuggestedWindow.End = int(ari.SuggestedWindow.End.Unix())
+
+		// auto renew disabled
+		if !*policy.AutoRenew {
+			certForecast.Reason = forecastReasonAutoRenewDisabled
+			certForecasts = append(certForecasts, certForecast)
+			continue
+		}
+
+		// maintenance window
+		var mw *maintenance_windows.MaintenanceWindow
+		if orders[i].Certificate.MaintenanceWindowID != nil {
+			window, ok := windows[*orders[i].Certificate.MaintenanceWindowID]
+			if !ok {
+				window, err = service.maintenanceWindows.GetMaintenanceWindow(*orders[i].Certificate.MaintenanceWindowID)
+				if err != nil {
+					service.logger.Error(err)
+					return output.JsonErrStorageGeneric(err)
+				}
+				windows[window.ID] = window
+			}
+			mw = &window
+		}
+
+		// projection
+		intervals, reason := projectRenewalIntervals(ari, *orders[i].ValidTo, mw, now)
+		certForecast.Reason = reason
+		if len(intervals) > 0 {
+			certForecast.RenewalWindow = &forecastWindowResponse{
+				Start: int(intervals[0].Start.Unix()),
+				End:   int(intervals[len(intervals)-1].End.Unix()),
+			}
+
+			addToForecastHistogram(histogram, today, intervals)
+		}
+
+		certForecasts = append(certForecasts, certForecast)
+	}
+
+	// write response
+	response := &renewalForecastResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Certificates = certForecasts
+	response.Histogram = histogram
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
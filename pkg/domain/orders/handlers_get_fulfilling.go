@@ -29,13 +29,15 @@ func (service *Service) GetFulfillWorkStatus(w http.ResponseWriter, r *http.Requ
 	// get Order IDs for all jobs (to query db)
 	orderIDs := []int{}
 	for _, mgrWorkingJob := range mgrJobs.WorkingJobs {
-		// only add working if work isn't idle (i.e. it has a job)
-		if mgrWorkingJob != nil {
+		// only add working if work isn't idle (i.e. it has a job) and the job is for an order
+		if mgrWorkingJob != nil && mgrWorkingJob.external == nil {
 			orderIDs = append(orderIDs, mgrWorkingJob.orderID)
 		}
 	}
 	for _, mgrWaitingJob := range mgrJobs.WaitingJobs {
-		orderIDs = append(orderIDs, mgrWaitingJob.orderID)
+		if mgrWaitingJob.external == nil {
+			orderIDs = append(orderIDs, mgrWaitingJob.orderID)
+		}
 	}
 
 	// lookup all orders in db
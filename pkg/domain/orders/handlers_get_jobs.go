@@ -0,0 +1,89 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// jobsResponse provides the json response struct to answer a query for a portion
+// of the jobs
+type jobsResponse struct {
+	output.JsonResponse
+	TotalJobs int           `json:"total_records"`
+	Jobs      []jobResponse `json:"jobs"`
+}
+
+// GetAllJobs is an http handler that returns the order fulfilling and post processing jobs,
+// including those that have finished
+func (service *Service) GetAllJobs(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get from storage
+	jobs, totalRows, err := service.storage.GetAllJobs(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// populate for output
+	outputJobs := []jobResponse{}
+	for i := range jobs {
+		outputJobs = append(outputJobs, jobs[i].response())
+	}
+
+	// write response
+	response := &jobsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalJobs = totalRows
+	response.Jobs = outputJobs
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("orders: failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type jobResponseWrapper struct {
+	output.JsonResponse
+	Job jobResponse `json:"job"`
+}
+
+// GetOneJob is an http handler that returns one job based on its unique id
+func (service *Service) GetOneJob(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get from storage
+	job, outErr := service.getJob(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &jobResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.Job = job.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("orders: failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
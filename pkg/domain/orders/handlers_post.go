@@ -159,28 +159,31 @@ func (service *Service) RevokeOrder(w http.ResponseWriter, r *http.Request) *out
 	}
 	// end validation
 
-	// get account key
-	key, err := order.Certificate.CertificateAccount.AcmeAccountKey()
-	if err != nil {
-		service.logger.Error(err)
-		return output.JsonErrInternal(err)
-	}
-
-	// revoke the certificate with ACME
-	acmeService, err := service.acmeServerService.AcmeService(order.Certificate.CertificateAccount.AcmeServer.ID)
-	if err != nil {
-		service.logger.Error(err)
-		return output.JsonErrInternal(err)
-	}
+	// revoke with ACME (private CA certs are only marked revoked in storage)
+	if order.Certificate.PrivateCA == nil {
+		// get account key
+		key, err := order.Certificate.CertificateAccount.AcmeAccountKey()
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
 
-	err = acmeService.RevokeCertificate(*order.Pem, payload.ReasonCode, key)
-	if err != nil {
-		// fail on any non-ACME error OR fail on ACME error if it is not 'already revoked' error type
-		acmeErr := new(acme.Error)
-		if !errors.As(err, &acmeErr) || acmeErr.Type != "urn:ietf:params:acme:error:alreadyRevoked" {
+		// revoke the certificate with ACME
+		acmeService, err := service.acmeServerService.AcmeService(order.Certificate.CertificateAccount.AcmeServer.ID)
+		if err != nil {
 			service.logger.Error(err)
 			return output.JsonErrInternal(err)
 		}
+
+		err = acmeService.RevokeCertificate(*order.Pem, payload.ReasonCode, key)
+		if err != nil {
+			// fail on any non-ACME error OR fail on ACME error if it is not 'already revoked' error type
+			acmeErr := new(acme.Error)
+			if !errors.As(err, &acmeErr) || acmeErr.Type != "urn:ietf:params:acme:error:alreadyRevoked" {
+				service.logger.Error(err)
+				return output.JsonErrInternal(err)
+			}
+		}
 	}
 
 	// update db
@@ -62,8 +62,10 @@ func (service *Service) PostProcessOrder(w http.ResponseWriter, r *http.Request)
 		return output.JsonErrNotFound(errIdMismatch)
 	}
 
-	// verify valid, not known revoked, not past validTo, and finalized key isn't deleted; else don't post process it
-	if order.Status != "valid" || order.KnownRevoked || order.ValidTo == nil || order.ValidTo.Before(time.Now()) || order.FinalizedKey == nil {
+	// verify valid, not known revoked, not past validTo, and finalized key isn't deleted (unless the cert uses an
+	// external csr); else don't post process it
+	if order.Status != "valid" || order.KnownRevoked || order.ValidTo == nil || order.ValidTo.Before(time.Now()) ||
+		(order.FinalizedKey == nil && !order.Certificate.HasExternalCsr()) {
 		// avoid nil
 		finalKeyName := "[deleted]"
 		if order.FinalizedKey != nil {
@@ -0,0 +1,298 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errImportOptionNone     = errors.New("orders: no certificate to import specified (pem or pkcs12)")
+	errImportOptionMultiple = errors.New("orders: multiple certificates to import specified (pem and pkcs12)")
+	errImportPkcs12Bad      = errors.New("orders: pkcs12 is not valid base64")
+	errImportKeyMismatch    = errors.New("orders: imported certificate is not for the certificate's key (or csr)")
+	errImportNames          = errors.New("orders: imported certificate's names do not match the certificate's subject and alt names")
+	errImportExpired        = errors.New("orders: imported certificate is expired")
+)
+
+// importOrderLocation returns a unique location for an imported order. Imported
+// orders don't exist on any remote server but the location is still used to
+// uniquely identify an order in storage.
+func importOrderLocation() (string, error) {
+	random, err := randomness.GenerateApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("urn:certwarden:import:order:%s", random), nil
+}
+
+// ImportCertificatePayload is the payload to import a certificate (and chain) that
+// was issued outside of this app. Either a pem chain (leaf first) or a base64 encoded
+// pkcs12 (with its passphrase) can be imported.
+type ImportCertificatePayload struct {
+	Pem          *string `json:"pem"`
+	Pkcs12Base64 *string `json:"pkcs12"`
+	Passphrase   *string `json:"passphrase"`
+}
+
+// ImportCertificate saves an existing certificate as a valid order of the specified
+// cert. This allows monitoring (and later renewing) a certificate that was issued
+// before the cert was added to this app. The imported certificate must be for the
+// cert's key (or external csr) and must contain the cert's names.
+// endpoint: /api/v1/certificates/:certid/import
+func (service *Service) ImportCertificate(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// certId param
+	certIdParam := httprouter.ParamsFromContext(r.Context()).ByName("certid")
+	certId, err := strconv.Atoi(certIdParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// decode body into payload
+	var payload ImportCertificatePayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// get certificate (validate exists)
+	cert, outErr := service.certificates.GetCertificate(certId)
+	if outErr != nil {
+		return outErr
+	}
+
+	// certificate chain pem
+	pemSpecified := payload.Pem != nil && *payload.Pem != ""
+	pkcs12Specified := payload.Pkcs12Base64 != nil && *payload.Pkcs12Base64 != ""
+	if !pemSpecified && !pkcs12Specified {
+		service.logger.Debug(errImportOptionNone)
+		return output.JsonErrValidationFailed(errImportOptionNone)
+	}
+	if pemSpecified && pkcs12Specified {
+		service.logger.Debug(errImportOptionMultiple)
+		return output.JsonErrValidationFailed(errImportOptionMultiple)
+	}
+
+	var chainPem string
+	if pemSpecified {
+		chainPem = *payload.Pem
+	} else {
+		pfxData, err := base64.StdEncoding.DecodeString(*payload.Pkcs12Base64)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(errImportPkcs12Bad)
+		}
+
+		passphrase := ""
+		if payload.Passphrase != nil {
+			passphrase = *payload.Passphrase
+		}
+
+		_, chainPem, err = key_crypto.DecodePkcs12(pfxData, passphrase)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
+
+	acmeCert, err := acme.NewCertificateFromPem([]byte(chainPem))
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	if time.Now().After(acmeCert.NotAfter()) {
+		service.logger.Debug(errImportExpired)
+		return output.JsonErrValidationFailed(errImportExpired)
+	}
+
+	// leaf must match the cert
+	err = importedLeafMatchesCert(cert, acmeCert)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+	// end validation
+
+	// save as a valid order of the cert's issuer
+	location, err := importOrderLocation()
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	orderPayload := makeImportOrderPayload(cert, location)
+	orderId, err := service.storage.PostNewOrder(orderPayload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+	service.logger.Debugf("orders: imported order location: %s", location)
+
+	if !cert.HasExternalCsr() {
+		err = service.storage.UpdateFinalizedKey(orderId, cert.CertificateKey.ID)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	err = service.storage.UpdateOrderCert(orderId, &CertPayload{
+		AcmeCert:    acmeCert,
+		RenewalInfo: MakeRenewalInfo(acmeCert.NotBefore(), acmeCert.NotAfter(), cert.EffectiveRenewalPolicy(service.renewalPolicy)),
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// update certificate timestamp
+	err = service.storage.UpdateCertUpdatedTime(cert.ID)
+	if err != nil {
+		service.logger.Error(err)
+		// no return
+	}
+
+	// get new order from db to return
+	order, outErr := service.getOrder(certId, orderId)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &orderResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "imported certificate"
+	response.Order = order.summaryResponse(service)
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("orders: failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// makeImportOrderPayload makes a NewOrderAcmePayload for an imported certificate. The
+// order is already valid since the certificate was issued elsewhere.
+func makeImportOrderPayload(cert certificates.Certificate, location string) NewOrderAcmePayload {
+	// identifiers
+	identifiers := acme.IdentifierSlice{acme.NewIdentifier(cert.Subject)}
+	for _, name := range cert.SubjectAltNames {
+		identifiers = append(identifiers, acme.NewIdentifier(name))
+	}
+
+	payload := NewOrderAcmePayload{
+		CertId:         cert.ID,
+		Status:         "valid",
+		KnownRevoked:   false,
+		DnsIds:         identifiers.DnsIdentifiers(),
+		IpIds:          identifiers.IpIdentifiers(),
+		Authorizations: []string{},
+		Finalize:       "",
+		Location:       location,
+		CreatedAt:      int(time.Now().Unix()),
+		UpdatedAt:      int(time.Now().Unix()),
+	}
+
+	// issuer
+	if cert.PrivateCA != nil {
+		payload.PrivateCAId = &cert.PrivateCA.ID
+	} else {
+		payload.AccountId = &cert.CertificateAccount.ID
+	}
+
+	return payload
+}
+
+// importedLeafMatchesCert returns an error if the leaf of acmeCert is not for the key
+// (or external csr) of cert, or if its names are not the same as cert's names
+func importedLeafMatchesCert(cert certificates.Certificate, acmeCert *acme.Certificate) error {
+	leafBlock, _ := pem.Decode([]byte(acmeCert.PEM()))
+	if leafBlock == nil {
+		return errors.New("orders: failed to decode imported leaf certificate")
+	}
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("orders: failed to parse imported leaf certificate (%w)", err)
+	}
+
+	// public key
+	var publicKey crypto.PublicKey
+	if cert.HasExternalCsr() {
+		csrDer, err := cert.CsrDer()
+		if err != nil {
+			return err
+		}
+		csr, err := x509.ParseCertificateRequest(csrDer)
+		if err != nil {
+			return err
+		}
+		publicKey = csr.PublicKey
+	} else {
+		privateKey, err := cert.CertificateKey.CryptoPrivateKey()
+		if err != nil {
+			return err
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return errImportKeyMismatch
+		}
+		publicKey = signer.Public()
+	}
+
+	leafPublicKey, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !leafPublicKey.Equal(publicKey) {
+		return errImportKeyMismatch
+	}
+
+	// names (compare as sets, the leaf's common name is usually also an alt name)
+	certIds := acme.IdentifierSlice{acme.NewIdentifier(cert.Subject)}
+	for _, name := range cert.SubjectAltNames {
+		certIds = append(certIds, acme.NewIdentifier(name))
+	}
+
+	var leafIds acme.IdentifierSlice
+	if leaf.Subject.CommonName != "" {
+		leafIds = append(leafIds, acme.NewIdentifier(leaf.Subject.CommonName))
+	}
+	for _, name := range leaf.DNSNames {
+		leafIds = append(leafIds, acme.NewIdentifier(name))
+	}
+	for _, ip := range leaf.IPAddresses {
+		leafIds = append(leafIds, acme.NewIdentifier(ip.String()))
+	}
+
+	for _, id := range certIds {
+		if !slices.Contains(leafIds, id) {
+			return errImportNames
+		}
+	}
+	for _, id := range leafIds {
+		if !slices.Contains(certIds, id) {
+			return errImportNames
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/datatypes/job_manager"
+	"certwarden-backend/pkg/output"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// CancelJob is an http handler that cancels a queued job so that it is never worked.
+// Jobs that are running or finished can't be canceled.
+func (service *Service) CancelJob(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	job, outErr := service.getJob(id)
+	if outErr != nil {
+		return outErr
+	}
+	if job.Status != jobStatusQueued {
+		service.logger.Debug(errJobNotQueued)
+		return output.JsonErrValidationFailed(errJobNotQueued)
+	}
+	// end validation
+
+	// remove from the relevant job manager (jobs are Equal by order)
+	switch job.Type {
+	case jobTypeOrderFulfill:
+		err = service.orderFulfilling.RemoveJob(&orderFulfillJob{orderID: job.OrderID})
+	case jobTypePostProcess:
+		err = service.postProcessing.RemoveJob(&postProcessJob{orderID: job.OrderID, certificateID: job.CertificateID})
+	default:
+		err = fmt.Errorf("orders: unknown job type %s", job.Type)
+	}
+	if err != nil {
+		if errors.Is(err, job_manager.ErrRemoveJobNotWaiting) {
+			service.logger.Debug(errJobNotQueued)
+			return output.JsonErrValidationFailed(errJobNotQueued)
+		}
+		service.logger.Error(err)
+		return output.JsonErrInternal(err)
+	}
+
+	// update storage
+	err = service.storage.PutJobStatus(UpdateJobStatusPayload{
+		ID:        job.ID,
+		Status:    jobStatusCanceled,
+		UpdatedAt: int(time.Now().Unix()),
+	})
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	canceledJob, outErr := service.getJob(job.ID)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &jobResponseWrapper{}
+	response.StatusCode = http.StatusOK
+	response.Message = "canceled job"
+	response.Job = canceledJob.response()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("orders: failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
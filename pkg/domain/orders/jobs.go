@@ -0,0 +1,247 @@
+package orders
+
+import (
+	"fmt"
+	"time"
+)
+
+// job types
+const (
+	jobTypeOrderFulfill = "order_fulfill"
+	jobTypePostProcess  = "post_process"
+)
+
+// job statuses
+const (
+	jobStatusQueued    = "queued"
+	jobStatusRunning   = "running"
+	jobStatusSucceeded = "succeeded"
+	jobStatusFailed    = "failed"
+	jobStatusCanceled  = "canceled"
+)
+
+// jobHistoryRetention is how long finished jobs are kept in storage
+const jobHistoryRetention = 30 * 24 * time.Hour
+
+// Job is the stored state of an order fulfilling or post processing job. Jobs are saved
+// when they are queued so that any that haven't finished can be resumed after a restart.
+type Job struct {
+	ID              int
+	Type            string
+	OrderID         int
+	CertificateID   int
+	CertificateName string
+	HighPriority    bool
+	Status          string
+	Attempts        int
+	LastError       string
+	CreatedAt       int
+	UpdatedAt       int
+}
+
+// jobResponse is a JSON response containing all fields that can be returned as JSON
+type jobResponse struct {
+	ID           int                    `json:"id"`
+	Type         string                 `json:"type"`
+	OrderID      int                    `json:"order_id"`
+	Certificate  jobCertificateResponse `json:"certificate"`
+	HighPriority bool                   `json:"high_priority"`
+	Status       string                 `json:"status"`
+	Attempts     int                    `json:"attempts"`
+	LastError    string                 `json:"last_error"`
+	CreatedAt    int                    `json:"created_at"`
+	UpdatedAt    int                    `json:"updated_at"`
+}
+
+type jobCertificateResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (job Job) response() jobResponse {
+	return jobResponse{
+		ID:      job.ID,
+		Type:    job.Type,
+		OrderID: job.OrderID,
+		Certificate: jobCertificateResponse{
+			ID:   job.CertificateID,
+			Name: job.CertificateName,
+		},
+		HighPriority: job.HighPriority,
+		Status:       job.Status,
+		Attempts:     job.Attempts,
+		LastError:    job.LastError,
+		CreatedAt:    job.CreatedAt,
+		UpdatedAt:    job.UpdatedAt,
+	}
+}
+
+// NewJobPayload is the payload to save a newly queued job
+type NewJobPayload struct {
+	Type         string
+	OrderID      int
+	HighPriority bool
+	Status       string
+	CreatedAt    int
+	UpdatedAt    int
+}
+
+// UpdateJobStatusPayload is the payload to update a job's status. If StartAttempt is true,
+// the job's attempts are incremented.
+type UpdateJobStatusPayload struct {
+	ID           int
+	Status       string
+	StartAttempt bool
+	LastError    *string
+	UpdatedAt    int
+}
+
+// saveNewJob saves a job that is about to be queued and returns its id
+func (service *Service) saveNewJob(jobType string, orderID int, highPriority bool) (int, error) {
+	payload := NewJobPayload{
+		Type:         jobType,
+		OrderID:      orderID,
+		HighPriority: highPriority,
+		Status:       jobStatusQueued,
+		CreatedAt:    int(time.Now().Unix()),
+	}
+	payload.UpdatedAt = payload.CreatedAt
+
+	jobID, err := service.storage.PostNewJob(payload)
+	if err != nil {
+		return 0, fmt.Errorf("orders: failed to save %s job for order id %d (%s)", jobType, orderID, err)
+	}
+
+	return jobID, nil
+}
+
+// updateJobStatus updates the stored status of a job and logs any error; jobs that were never
+// saved (id 0) are ignored
+func (service *Service) updateJobStatus(jobID int, status string, startAttempt bool, jobErr error) {
+	if jobID == 0 {
+		return
+	}
+
+	payload := UpdateJobStatusPayload{
+		ID:           jobID,
+		Status:       status,
+		StartAttempt: startAttempt,
+		UpdatedAt:    int(time.Now().Unix()),
+	}
+	if jobErr != nil {
+		lastError := jobErr.Error()
+		payload.LastError = &lastError
+	}
+
+	err := service.storage.PutJobStatus(payload)
+	if err != nil {
+		service.logger.Errorf("orders: failed to update job %d status to %s (%s)", jobID, status, err)
+	}
+}
+
+// jobStarted records that a worker started a job
+func (service *Service) jobStarted(jobID int) {
+	service.updateJobStatus(jobID, jobStatusRunning, true, nil)
+}
+
+// jobFinished records the outcome of a job. If the app is shutting down and the job failed, it
+// was likely interrupted, so it is left 'running' to be resumed on the next start.
+func (service *Service) jobFinished(jobID int, jobErr error) {
+	if jobErr == nil {
+		service.updateJobStatus(jobID, jobStatusSucceeded, false, nil)
+		return
+	}
+
+	if service.shutdownContext.Err() != nil {
+		service.logger.Debugf("orders: job %d interrupted by shutdown, it will resume on next start", jobID)
+		return
+	}
+
+	service.updateJobStatus(jobID, jobStatusFailed, false, jobErr)
+}
+
+// isRepeatFulfillJob returns true if an earlier order fulfilling job was saved for the job's
+// order (i.e., this job is another attempt at fulfilling the same order). Jobs that were never
+// saved (id 0) and errors checking storage are treated as the first attempt.
+func (service *Service) isRepeatFulfillJob(jobID int, orderID int) bool {
+	if jobID == 0 {
+		return false
+	}
+
+	count, err := service.storage.CountEarlierJobs(jobTypeOrderFulfill, orderID, jobID)
+	if err != nil {
+		service.logger.Errorf("orders: failed to check for earlier jobs of order %d (%s)", orderID, err)
+		return false
+	}
+
+	return count > 0
+}
+
+// unfinishedFulfillOrderIDs returns the ids of the orders that have a stored order fulfilling
+// job that is queued or running
+func (service *Service) unfinishedFulfillOrderIDs() (map[int]struct{}, error) {
+	jobs, err := service.storage.GetUnfinishedJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := make(map[int]struct{})
+	for _, job := range jobs {
+		if job.Type == jobTypeOrderFulfill {
+			orderIDs[job.OrderID] = struct{}{}
+		}
+	}
+
+	return orderIDs, nil
+}
+
+// resumeJobs queues all stored jobs that were queued or running when the app last stopped
+func (service *Service) resumeJobs() {
+	jobs, err := service.storage.GetUnfinishedJobs()
+	if err != nil {
+		service.logger.Errorf("orders: failed to get unfinished jobs to resume (%s)", err)
+		return
+	}
+
+	resumedCount := 0
+	for _, job := range jobs {
+		// jobs interrupted while running are queued again
+		if job.Status != jobStatusQueued {
+			service.updateJobStatus(job.ID, jobStatusQueued, false, nil)
+		}
+
+		// queue (failures are saved to the job)
+		switch job.Type {
+		case jobTypeOrderFulfill:
+			err = service.queueFulfillJob(job.ID, job.OrderID, job.HighPriority)
+		case jobTypePostProcess:
+			err = service.queuePostProcessJob(job.ID, job.OrderID, job.HighPriority)
+		default:
+			err = fmt.Errorf("orders: unknown job type %s", job.Type)
+			service.updateJobStatus(job.ID, jobStatusFailed, false, err)
+		}
+		if err != nil {
+			service.logger.Errorf("orders: failed to resume job %d (%s)", job.ID, err)
+			continue
+		}
+
+		resumedCount++
+	}
+
+	if resumedCount > 0 {
+		service.logger.Infof("orders: resumed %d unfinished jobs", resumedCount)
+	}
+}
+
+// deleteOldJobs deletes finished jobs from storage once they are older than the
+// retention period
+func (service *Service) deleteOldJobs() {
+	deletedCount, err := service.storage.DeleteFinishedJobs(int(time.Now().Add(-jobHistoryRetention).Unix()))
+	if err != nil {
+		service.logger.Errorf("orders: failed to delete old jobs (%s)", err)
+		return
+	}
+	if deletedCount > 0 {
+		service.logger.Debugf("orders: deleted %d old job(s)", deletedCount)
+	}
+}
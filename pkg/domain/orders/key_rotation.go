@@ -0,0 +1,73 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"fmt"
+	"time"
+)
+
+// rotateKeyIfDue replaces cert's private key with a newly generated key of the same
+// algorithm if the cert's key rotation policy calls for it. On rotation, cert is
+// updated to use the new key and true is returned.
+func (j *orderFulfillJob) rotateKeyIfDue(workerID int, cert *certificates.Certificate) (rotated bool, err error) {
+	// external csr certs don't have a key to rotate
+	if cert.HasExternalCsr() {
+		return false, nil
+	}
+
+	// check policy
+	issuedWithKey, err := j.service.storage.CountValidOrdersFinalizedWithKey(cert.ID, cert.CertificateKey.ID)
+	if err != nil {
+		return false, err
+	}
+	if !cert.KeyRotationDue(issuedWithKey) {
+		return false, nil
+	}
+
+	// make the new key
+	keyPem, err := cert.CertificateKey.Algorithm.GeneratePrivateKeyPem()
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s_%d", cert.Name, now.Unix())
+	description := fmt.Sprintf("automatically rotated key for certificate %s (replaced key %s)", cert.Name, cert.CertificateKey.Name)
+	algorithmValue := cert.CertificateKey.Algorithm.StorageValue()
+	apiKeyDisabled := cert.CertificateKey.ApiKeyDisabled
+
+	payload := private_keys.NewPayload{
+		Name:           &name,
+		Description:    &description,
+		AlgorithmValue: &algorithmValue,
+		PemContent:     &keyPem,
+		ApiKeyDisabled: &apiKeyDisabled,
+		CreatedAt:      int(now.Unix()),
+		UpdatedAt:      int(now.Unix()),
+	}
+
+	// save and swap it in
+	newKey, err := j.service.storage.RotateCertKey(cert.ID, cert.CertificateKey.ID, payload)
+	if err != nil {
+		return false, err
+	}
+
+	j.service.logger.Infof("orders: fulfilling worker %d: rotated certificate %s private key %d (%s) to new key %d (%s) after %d issuance(s)",
+		workerID, cert.Name, cert.CertificateKey.ID, cert.CertificateKey.Name, newKey.ID, newKey.Name, issuedWithKey)
+
+	cert.CertificateKey = newKey
+	return true, nil
+}
+
+// deleteUnusedRetiredKeys deletes keys that were rotated out of certificates once
+// they are no longer needed by any unexpired valid order
+func (service *Service) deleteUnusedRetiredKeys() {
+	deletedKeyIds, err := service.storage.DeleteUnusedRetiredKeys()
+	if len(deletedKeyIds) > 0 {
+		service.logger.Infof("orders: deleted %d retired private key(s) (ids: %v)", len(deletedKeyIds), deletedKeyIds)
+	}
+	if err != nil {
+		service.logger.Errorf("orders: failed to delete retired private keys (%s)", err)
+	}
+}
@@ -25,6 +25,7 @@ type Order struct {
 	Error          *acme.Error
 	Expires        *int
 	DnsIdentifiers []string
+	IpIdentifiers  []string
 	Authorizations []string
 	Finalize       string
 	FinalizedKey   *private_keys.Key
@@ -49,6 +50,7 @@ type orderSummaryResponse struct {
 	KnownRevoked      bool                            `json:"known_revoked"`
 	Error             *acme.Error                     `json:"error"`
 	DnsIdentifiers    []string                        `json:"dns_identifiers"`
+	IpIdentifiers     []string                        `json:"ip_identifiers"`
 	FinalizedKey      *orderKeySummaryResponse        `json:"finalized_key"`
 	ValidFrom         *int                            `json:"valid_from"`
 	ValidTo           *int                            `json:"valid_to"`
@@ -60,13 +62,13 @@ type orderSummaryResponse struct {
 }
 
 type orderCertificateSummaryResponse struct {
-	ID                 int                                    `json:"id"`
-	Name               string                                 `json:"name"`
-	CertificateAccount orderCertificateAccountSummaryResponse `json:"acme_account"`
-	Subject            string                                 `json:"subject"`
-	SubjectAltNames    []string                               `json:"subject_alts"`
-	ApiKeyViaUrl       bool                                   `json:"api_key_via_url"`
-	LastAccess         int64                                  `json:"last_access"`
+	ID                 int                                       `json:"id"`
+	Name               string                                    `json:"name"`
+	CertificateAccount *orderCertificateAccountSummaryResponse   `json:"acme_account,omitempty"`
+	PrivateCA          *orderCertificatePrivateCASummaryResponse `json:"private_ca,omitempty"`
+	Subject            string                                    `json:"subject"`
+	SubjectAltNames    []string                                  `json:"subject_alts"`
+	LastAccess         int64                                     `json:"last_access"`
 }
 
 type orderCertificateAccountSummaryResponse struct {
@@ -81,6 +83,11 @@ type orderCertificateAccountServerSummaryResponse struct {
 	IsStaging bool   `json:"is_staging"`
 }
 
+type orderCertificatePrivateCASummaryResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
 type orderKeySummaryResponse struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
@@ -113,30 +120,43 @@ func (order Order) summaryResponse(service *Service) orderSummaryResponse {
 		validToUnix = &validToUnixVal
 	}
 
+	// issuer is either a private ca or an acme account
+	var account *orderCertificateAccountSummaryResponse
+	var privateCA *orderCertificatePrivateCASummaryResponse
+	if order.Certificate.PrivateCA != nil {
+		privateCA = &orderCertificatePrivateCASummaryResponse{
+			ID:   order.Certificate.PrivateCA.ID,
+			Name: order.Certificate.PrivateCA.Name,
+		}
+	} else {
+		account = &orderCertificateAccountSummaryResponse{
+			ID:   order.Certificate.CertificateAccount.ID,
+			Name: order.Certificate.CertificateAccount.Name,
+			OrderCertAccountServer: orderCertificateAccountServerSummaryResponse{
+				ID:        order.Certificate.CertificateAccount.AcmeServer.ID,
+				Name:      order.Certificate.CertificateAccount.AcmeServer.Name,
+				IsStaging: order.Certificate.CertificateAccount.AcmeServer.IsStaging,
+			},
+		}
+	}
+
 	return orderSummaryResponse{
 		FulfillmentWorker: fulfillingWorker,
 		ID:                order.ID,
 		Certificate: orderCertificateSummaryResponse{
-			ID:   order.Certificate.ID,
-			Name: order.Certificate.Name,
-			CertificateAccount: orderCertificateAccountSummaryResponse{
-				ID:   order.Certificate.CertificateAccount.ID,
-				Name: order.Certificate.CertificateAccount.Name,
-				OrderCertAccountServer: orderCertificateAccountServerSummaryResponse{
-					ID:        order.Certificate.CertificateAccount.AcmeServer.ID,
-					Name:      order.Certificate.CertificateAccount.AcmeServer.Name,
-					IsStaging: order.Certificate.CertificateAccount.AcmeServer.IsStaging,
-				},
-			},
-			Subject:         order.Certificate.Subject,
-			SubjectAltNames: order.Certificate.SubjectAltNames,
-			ApiKeyViaUrl:    order.Certificate.ApiKeyViaUrl,
-			LastAccess:      order.Certificate.LastAccess.Unix(),
+			ID:                 order.Certificate.ID,
+			Name:               order.Certificate.Name,
+			CertificateAccount: account,
+			PrivateCA:          privateCA,
+			Subject:            order.Certificate.Subject,
+			SubjectAltNames:    order.Certificate.SubjectAltNames,
+			LastAccess:         order.Certificate.LastAccess.Unix(),
 		},
 		Status:         order.Status,
 		KnownRevoked:   order.KnownRevoked,
 		Error:          order.Error,
 		DnsIdentifiers: order.DnsIdentifiers,
+		IpIdentifiers:  order.IpIdentifiers,
 		FinalizedKey:   finalKey,
 		ValidFrom:      validFromUnix,
 		ValidTo:        validToUnix,
@@ -231,13 +251,13 @@ func (service *Service) NewOrderPayload(cert certificates.Certificate) acme.NewO
 	var identifiers []acme.Identifier
 
 	// subject is always required and should be first
-	// dns is the only supported type and is hardcoded
-	identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: cert.Subject})
+	// type is dns unless the name is an ip address (RFC 8738)
+	identifiers = append(identifiers, acme.NewIdentifier(cert.Subject))
 
 	// add alt names if they exist
 	if cert.SubjectAltNames != nil {
 		for _, name := range cert.SubjectAltNames {
-			identifiers = append(identifiers, acme.Identifier{Type: "dns", Value: name})
+			identifiers = append(identifiers, acme.NewIdentifier(name))
 		}
 	}
 
@@ -308,9 +328,12 @@ func (service *Service) NewOrderPayload(cert certificates.Certificate) acme.NewO
 		return r
 	}()
 
-	return acme.NewOrderPayload{
+	payload := acme.NewOrderPayload{
 		Identifiers: identifiers,
 		Profile:     p,
 		Replaces:    replaces,
 	}
+	setRequestedValidity(&payload, cert)
+
+	return payload
 }
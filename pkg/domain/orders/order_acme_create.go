@@ -1,11 +1,12 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/domain/certificates"
 	"certwarden-backend/pkg/output"
 	"errors"
 )
 
-// placeNewOrderAndFulfill creates a new ACME order for the specified Certificate ID,
+// placeNewOrderAndFulfill creates a new order for the specified Certificate ID,
 // and prioritizes the order as specified. It returns the new orderId.
 func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (Order, *output.JsonError) {
 	// get cert
@@ -14,24 +15,62 @@ func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (
 		return Order{}, outErr
 	}
 
+	// create the order with the cert's issuer
+	var orderId int
+	if cert.PrivateCA != nil {
+		orderId, outErr = service.newPrivateCAOrder(cert)
+	} else {
+		orderId, outErr = service.newAcmeOrder(cert)
+	}
+	if outErr != nil {
+		return Order{}, outErr
+	}
+
+	// update certificate timestamp
+	err := service.storage.UpdateCertUpdatedTime(cert.ID)
+	if err != nil {
+		service.logger.Error(err)
+		// no return
+	}
+
+	// kickoff order fulfillment (async)
+	err = service.fulfillOrder(orderId, highPriority)
+	// log error if something strange happened
+	if err != nil {
+		service.logger.Error(err)
+		// no return
+	}
+
+	// get new order from db to return
+	newOrder, outErr := service.getOrder(certId, orderId)
+	if outErr != nil {
+		return Order{}, outErr
+	}
+
+	return newOrder, nil
+}
+
+// newAcmeOrder sends a new-order to the ACME Server of the cert's account and
+// saves the response to storage. It returns the order's id.
+func (service *Service) newAcmeOrder(cert certificates.Certificate) (int, *output.JsonError) {
 	// get account key
 	key, err := cert.CertificateAccount.AcmeAccountKey()
 	if err != nil {
 		service.logger.Error(err)
-		return Order{}, output.JsonErrInternal(err)
+		return -2, output.JsonErrInternal(err)
 	}
 
 	// send the new-order to ACME
 	acmeService, err := service.acmeServerService.AcmeService(cert.CertificateAccount.AcmeServer.ID)
 	if err != nil {
 		service.logger.Error(err)
-		return Order{}, output.JsonErrInternal(err)
+		return -2, output.JsonErrInternal(err)
 	}
 
 	acmeResponse, err := acmeService.NewOrder(service.NewOrderPayload(cert), key)
 	if err != nil {
 		service.logger.Error(err)
-		return Order{}, output.JsonErrInternal(err)
+		return -2, output.JsonErrInternal(err)
 	}
 	service.logger.Debugf("orders: new order location: %s", acmeResponse.Location)
 
@@ -45,33 +84,12 @@ func (service *Service) placeNewOrderAndFulfill(certId int, highPriority bool) (
 		err = service.storage.PutOrderAcme(makeUpdateOrderAcmePayload(orderId, acmeResponse))
 		if err != nil {
 			service.logger.Error(err)
-			return Order{}, output.JsonErrStorageGeneric(err)
+			return -2, output.JsonErrStorageGeneric(err)
 		}
 	} else if err != nil {
 		service.logger.Error(err)
-		return Order{}, output.JsonErrStorageGeneric(err)
-	}
-
-	// update certificate timestamp
-	err = service.storage.UpdateCertUpdatedTime(cert.ID)
-	if err != nil {
-		service.logger.Error(err)
-		// no return
-	}
-
-	// kickoff order fulfillment (async)
-	err = service.fulfillOrder(orderId, highPriority)
-	// log error if something strange happened
-	if err != nil {
-		service.logger.Error(err)
-		// no return
-	}
-
-	// get new order from db to return
-	newOrder, outErr := service.getOrder(certId, orderId)
-	if outErr != nil {
-		return Order{}, outErr
+		return -2, output.JsonErrStorageGeneric(err)
 	}
 
-	return newOrder, nil
+	return orderId, nil
 }
@@ -10,14 +10,17 @@ import (
 var ErrOrderExists = errors.New("order location already in storage")
 
 // NewOrderAcmePayload is a struct for posting new and updated Order info
-// to storage. This is used when ACME returns info about an Order.
+// to storage. This is used when ACME returns info about an Order. Exactly one
+// of AccountId or PrivateCAId is set.
 type NewOrderAcmePayload struct {
 	CertId         int
-	AccountId      int
+	AccountId      *int
+	PrivateCAId    *int
 	Status         string
 	KnownRevoked   bool
 	Expires        *int
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
@@ -37,11 +40,12 @@ func makeNewOrderAcmePayload(cert certificates.Certificate, acmeResponse acme.Or
 
 	payload := NewOrderAcmePayload{
 		CertId:         cert.ID,
-		AccountId:      cert.CertificateAccount.ID,
+		AccountId:      &cert.CertificateAccount.ID,
 		Status:         acmeResponse.Status,
 		KnownRevoked:   false,
 		Expires:        acmeResponse.Expires.ToUnixTime(),
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		Finalize:       acmeResponse.Finalize,
@@ -59,6 +63,7 @@ type UpdateAcmeOrderPayload struct {
 	Status         string
 	Expires        *int
 	DnsIds         []string
+	IpIds          []string
 	Error          *string
 	Authorizations []string
 	Finalize       string
@@ -79,6 +84,7 @@ func makeUpdateOrderAcmePayload(orderId int, acmeResponse acme.Order) UpdateAcme
 		Status:         acmeResponse.Status,
 		Expires:        acmeResponse.Expires.ToUnixTime(),
 		DnsIds:         acmeResponse.Identifiers.DnsIdentifiers(),
+		IpIds:          acmeResponse.Identifiers.IpIdentifiers(),
 		Error:          acmeErr,
 		Authorizations: acmeResponse.Authorizations,
 		Finalize:       acmeResponse.Finalize,
@@ -0,0 +1,66 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/randomness"
+	"fmt"
+	"time"
+)
+
+// privateCAOrderLocation returns a unique location for a private CA order. Private
+// CA orders don't exist on any remote server but the location is still used to
+// uniquely identify an order in storage.
+func privateCAOrderLocation(caId int) (string, error) {
+	random, err := randomness.GenerateApiKey()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("urn:certwarden:private-ca:%d:order:%s", caId, random), nil
+}
+
+// makeNewOrderPrivateCAPayload makes a NewOrderAcmePayload for a cert that is
+// issued by a private CA. Since there is nothing to authorize, the order starts
+// in the ready state.
+func makeNewOrderPrivateCAPayload(cert certificates.Certificate, location string) NewOrderAcmePayload {
+	// identifiers
+	identifiers := acme.IdentifierSlice{acme.NewIdentifier(cert.Subject)}
+	for _, name := range cert.SubjectAltNames {
+		identifiers = append(identifiers, acme.NewIdentifier(name))
+	}
+
+	return NewOrderAcmePayload{
+		CertId:         cert.ID,
+		PrivateCAId:    &cert.PrivateCA.ID,
+		Status:         "ready",
+		KnownRevoked:   false,
+		DnsIds:         identifiers.DnsIdentifiers(),
+		IpIds:          identifiers.IpIdentifiers(),
+		Authorizations: []string{},
+		Finalize:       "",
+		Location:       location,
+		CreatedAt:      int(time.Now().Unix()),
+		UpdatedAt:      int(time.Now().Unix()),
+	}
+}
+
+// newPrivateCAOrder saves a new order for a cert that is issued by a private CA.
+// It returns the order's id.
+func (service *Service) newPrivateCAOrder(cert certificates.Certificate) (int, *output.JsonError) {
+	location, err := privateCAOrderLocation(cert.PrivateCA.ID)
+	if err != nil {
+		service.logger.Error(err)
+		return -2, output.JsonErrInternal(err)
+	}
+
+	orderId, err := service.storage.PostNewOrder(makeNewOrderPrivateCAPayload(cert, location))
+	if err != nil {
+		service.logger.Error(err)
+		return -2, output.JsonErrStorageGeneric(err)
+	}
+	service.logger.Debugf("orders: new private ca order location: %s", location)
+
+	return orderId, nil
+}
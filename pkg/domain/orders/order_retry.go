@@ -0,0 +1,249 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/randomness"
+	"certwarden-backend/pkg/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaults if not configured
+	DefaultFailedOrderMaxAttempts         = 5
+	DefaultFailedOrderInitialDelayMinutes = 10
+	// configurable limits
+	maxFailedOrderMaxAttempts         = 20
+	minFailedOrderInitialDelayMinutes = 1
+	maxFailedOrderInitialDelayMinutes = 24 * 60
+
+	// maxFailedOrderRetryDelay caps the exponential backoff
+	maxFailedOrderRetryDelay = 24 * time.Hour
+)
+
+// failedOrderRetryDelay returns the delay before retrying after the specified number of consecutive
+// failures (streak). The delay doubles with each failure (capped at maxFailedOrderRetryDelay) and
+// is then randomly reduced by up to half (jitter).
+func failedOrderRetryDelay(initialDelay time.Duration, streak int) time.Duration {
+	delay := initialDelay
+	for i := 1; i < streak && delay < maxFailedOrderRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxFailedOrderRetryDelay {
+		delay = maxFailedOrderRetryDelay
+	}
+
+	// jitter
+	halfSeconds := int(delay.Seconds() / 2)
+	if halfSeconds <= 0 {
+		return delay
+	}
+
+	return delay - time.Duration(randomness.GenerateInsecureInt(halfSeconds))*time.Second
+}
+
+// updateOrderFailures updates the cert's order failure streak after one of its orders succeeded
+// (orderErr is nil) or failed. A failure schedules a retry, unless the retries are exhausted or
+// the cert's renewal policy disables auto renew. A success clears the streak. Failures should
+// only be recorded for orders placed by the auto order service (not manual orders).
+func (service *Service) updateOrderFailures(cert certificates.Certificate, orderErr error) {
+	failures := cert.OrderFailures
+
+	// success
+	if orderErr == nil {
+		// nothing to clear
+		if failures.Streak == 0 && failures.NextRetryAt == nil {
+			return
+		}
+
+		failures.Streak = 0
+		failures.NextRetryAt = nil
+		err := service.storage.PutCertOrderFailures(cert.ID, failures)
+		if err != nil {
+			service.logger.Errorf("orders: failed to clear order failures of cert %s (%s)", cert.Name, err)
+			return
+		}
+
+		service.logger.Infof("orders: cert %s has a valid order, cleared failed order streak", cert.Name)
+		return
+	}
+
+	// failure
+	now := time.Now()
+	failures.Streak++
+	failures.LastFailureAt = int(now.Unix())
+	failures.LastError = orderErr.Error()
+	failures.NextRetryAt = nil
+
+	var retryAt time.Time
+	policy := cert.EffectiveRenewalPolicy(service.renewalPolicy)
+	switch {
+	case !*policy.AutoRenew:
+		service.logger.Debugf("orders: not retrying failed order of cert %s (auto renew disabled by renewal policy)", cert.Name)
+
+	case failures.Streak > service.failedOrderMaxAttempts:
+		service.logger.Warnf("orders: not retrying failed order of cert %s (%d consecutive failures)", cert.Name, failures.Streak)
+
+	default:
+		retryAt = now.Add(failedOrderRetryDelay(service.failedOrderInitialDelay, failures.Streak))
+		failures.NextRetryAt = new(int)
+		*failures.NextRetryAt = int(retryAt.Unix())
+		service.logger.Infof("orders: failed order of cert %s will be retried at %s (%d consecutive failures)", cert.Name, retryAt, failures.Streak)
+	}
+
+	err := service.storage.PutCertOrderFailures(cert.ID, failures)
+	if err != nil {
+		service.logger.Errorf("orders: failed to save order failure of cert %s (%s)", cert.Name, err)
+		return
+	}
+
+	if failures.NextRetryAt != nil {
+		service.scheduleOrderRetry(cert.ID, retryAt)
+	}
+}
+
+// failedOrdersAllowNewOrder returns true if the cert's failed orders (failures) allow the auto
+// order service to place a new order for it now. Once a cert has failed orders, new orders are
+// only placed by its scheduled retries (when due), and not at all once the retries are exhausted
+// (until a valid order clears the streak). If retrying is disabled (max attempts 0), failures
+// don't hold back new orders. If not allowed, the reason is also returned.
+func (service *Service) failedOrdersAllowNewOrder(failures certificates.OrderFailures, now time.Time) (allowed bool, reason string) {
+	if failures.Streak == 0 || service.failedOrderMaxAttempts == 0 {
+		return true, ""
+	}
+
+	if failures.Streak > service.failedOrderMaxAttempts {
+		return false, fmt.Sprintf("failed order retries exhausted after %d consecutive failures", failures.Streak)
+	}
+
+	if failures.NextRetryAt != nil {
+		retryAt := time.Unix(int64(*failures.NextRetryAt), 0)
+		if retryAt.After(now) {
+			return false, fmt.Sprintf("failed order retry is not due until %s", retryAt)
+		}
+	}
+
+	return true, ""
+}
+
+// recordOrderFailure gets the cert and updates its order failures with orderErr
+func (service *Service) recordOrderFailure(certId int, orderErr error) {
+	cert, outErr := service.certificates.GetCertificate(certId)
+	if outErr != nil {
+		service.logger.Errorf("orders: failed to record order failure of cert %d (%s)", certId, outErr.Error())
+		return
+	}
+
+	service.updateOrderFailures(cert, orderErr)
+}
+
+// scheduleOrderRetry retries the cert's failed order at the specified time, unless the app shuts
+// down first (in which case the next auto order run after restart reschedules it). If the same
+// retry is already scheduled, this is a no-op.
+func (service *Service) scheduleOrderRetry(certId int, at time.Time) {
+	atUnix := at.Unix()
+	existing, loaded := service.scheduledOrderRetries.Swap(certId, atUnix)
+	if loaded && existing == atUnix {
+		return
+	}
+
+	service.shutdownWaitgroup.Add(1)
+	go func() {
+		defer service.shutdownWaitgroup.Done()
+
+		select {
+		case <-service.shutdownContext.Done():
+			return
+
+		case <-time.After(time.Until(at)):
+			// proceed
+		}
+
+		// only retry if this is still the cert's scheduled retry
+		if !service.scheduledOrderRetries.CompareAndDelete(certId, atUnix) {
+			return
+		}
+
+		service.retryFailedOrder(certId)
+	}()
+}
+
+// retryFailedOrder places a new order for the cert, if its retry is still due. If the cert has
+// a valid order since its last failure, the retry is canceled instead. Retries go through the
+// same checks as other auto orders (autoNewOrderAllowed); if the cert may not be ordered now,
+// the retry is dropped and the cert is left to the regular auto order runs, which respect its
+// renewal policy and maintenance window.
+func (service *Service) retryFailedOrder(certId int) {
+	cert, outErr := service.certificates.GetCertificate(certId)
+	if outErr != nil {
+		service.logger.Errorf("orders: failed to retry order of cert %d (%s)", certId, outErr.Error())
+		return
+	}
+
+	// retry already done (or rescheduled)
+	failures := cert.OrderFailures
+	if failures.NextRetryAt == nil || time.Unix(int64(*failures.NextRetryAt), 0).After(time.Now()) {
+		return
+	}
+
+	// stop retrying if there is a valid order since the last failure
+	validOrder, err := service.storage.GetCertNewestValidOrderById(certId)
+	if err != nil && !errors.Is(err, storage.ErrNoRecord) {
+		service.logger.Errorf("orders: failed to get newest valid order to retry order of cert %s (%s)", cert.Name, err)
+		return
+	}
+	if err == nil && validOrder.CreatedAt.Unix() > int64(failures.LastFailureAt) {
+		service.updateOrderFailures(cert, nil)
+		return
+	}
+
+	// clear scheduled retry, then retry
+	failures.NextRetryAt = nil
+	err = service.storage.PutCertOrderFailures(certId, failures)
+	if err != nil {
+		service.logger.Errorf("orders: failed to update order failures to retry order of cert %s (%s)", cert.Name, err)
+		return
+	}
+	cert.OrderFailures = failures
+
+	allowed, reason, err := service.autoNewOrderAllowed(cert)
+	if err != nil {
+		service.logger.Errorf("orders: failed to check cert %s to retry its order (%s)", cert.Name, err)
+		return
+	}
+	if !allowed {
+		service.logger.Infof("orders: not retrying failed order of cert %s (%s)", cert.Name, reason)
+		return
+	}
+
+	service.logger.Infof("orders: retrying failed order of cert %s (%d consecutive failures)", cert.Name, failures.Streak)
+	_, outErr = service.placeNewOrderAndFulfill(certId, false)
+	if outErr != nil {
+		service.logger.Errorf("orders: failed to place new order to retry order of cert %s (%s)", cert.Name, outErr.Error())
+		service.updateOrderFailures(cert, outErr)
+	}
+}
+
+// retryFailedOrders schedules the retries of failed orders that are due before the next auto
+// order run. This includes retries that were scheduled before the app last stopped.
+func (service *Service) retryFailedOrders() {
+	certIds, err := service.storage.GetCertIdsOrderRetryBefore(int(time.Now().Add(service.autoOrderRunInterval).Unix()))
+	if err != nil {
+		service.logger.Errorf("orders: failed to get certs with failed orders to retry (%s)", err)
+		return
+	}
+
+	for _, certId := range certIds {
+		cert, outErr := service.certificates.GetCertificate(certId)
+		if outErr != nil {
+			service.logger.Errorf("orders: failed to schedule order retry of cert %d (%s)", certId, outErr.Error())
+			continue
+		}
+		if cert.OrderFailures.NextRetryAt == nil {
+			continue
+		}
+
+		service.scheduleOrderRetry(certId, time.Unix(int64(*cert.OrderFailures.NextRetryAt), 0))
+	}
+}
@@ -0,0 +1,60 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/certificates"
+	"testing"
+	"time"
+)
+
+func TestFailedOrderRetryDelay(t *testing.T) {
+	initial := 10 * time.Minute
+
+	tests := []struct {
+		streak int
+		max    time.Duration
+	}{
+		{streak: 1, max: 10 * time.Minute},
+		{streak: 2, max: 20 * time.Minute},
+		{streak: 4, max: 80 * time.Minute},
+		// capped
+		{streak: 10, max: maxFailedOrderRetryDelay},
+		{streak: 1000, max: maxFailedOrderRetryDelay},
+	}
+
+	for _, test := range tests {
+		for range 100 {
+			delay := failedOrderRetryDelay(initial, test.streak)
+			if delay > test.max || delay < test.max/2 {
+				t.Fatalf("streak %d: delay %s, want %s - %s", test.streak, delay, test.max/2, test.max)
+			}
+		}
+	}
+}
+
+func TestFailedOrdersAllowNewOrder(t *testing.T) {
+	now := time.Now()
+	past := int(now.Add(-time.Minute).Unix())
+	future := int(now.Add(time.Minute).Unix())
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    certificates.OrderFailures
+		allowed     bool
+	}{
+		{"no failures", 3, certificates.OrderFailures{}, true},
+		{"retry due", 3, certificates.OrderFailures{Streak: 2, NextRetryAt: &past}, true},
+		{"retry not due", 3, certificates.OrderFailures{Streak: 2, NextRetryAt: &future}, false},
+		{"no retry scheduled", 3, certificates.OrderFailures{Streak: 2}, true},
+		{"exhausted", 3, certificates.OrderFailures{Streak: 4}, false},
+		{"retrying disabled", 0, certificates.OrderFailures{Streak: 4}, true},
+	}
+
+	for _, test := range tests {
+		service := &Service{failedOrderMaxAttempts: test.maxAttempts}
+		allowed, reason := service.failedOrdersAllowNewOrder(test.failures, now)
+		if allowed != test.allowed {
+			t.Errorf("%s: allowed is %t (%s), expected %t", test.name, allowed, reason, test.allowed)
+		}
+	}
+}
@@ -14,6 +14,9 @@ type postProcessJob struct {
 	highPriority  bool
 	orderID       int
 	certificateID int
+
+	// jobID is the id of the saved job (0 if not saved)
+	jobID int
 }
 
 // makeFulfillingJob makes an orderFulfillJob
@@ -1,18 +1,46 @@
 package orders
 
 import (
+	"certwarden-backend/pkg/datatypes/job_manager"
 	"fmt"
 )
 
 // postProcess queues a post processing job for the specified order ID with the specified
 // priority level
 func (service *Service) postProcess(orderID int, isHighPriority bool) (err error) {
+	return service.queuePostProcessJob(0, orderID, isHighPriority)
+}
+
+// queuePostProcessJob queues the post processing job. If jobID is 0, a new job is saved to
+// storage, otherwise the saved job is being resumed. Failure to queue a saved job is recorded
+// as the job's error.
+func (service *Service) queuePostProcessJob(jobID int, orderID int, isHighPriority bool) (err error) {
+	// record failure of saved job
+	defer func() {
+		if err != nil {
+			service.updateJobStatus(jobID, jobStatusFailed, false, err)
+		}
+	}()
+
 	// make job
 	newJob, err := service.makePostProcessJob(orderID, isHighPriority)
 	if err != nil {
 		return err
 	}
 
+	// save new job (unless it is a duplicate)
+	if jobID == 0 {
+		if service.postProcessing.JobExists(newJob) != nil {
+			return fmt.Errorf("orders: post processing: failed to add order id %d (%s)", orderID, job_manager.ErrAddDuplicateJob)
+		}
+
+		jobID, err = service.saveNewJob(jobTypePostProcess, orderID, isHighPriority)
+		if err != nil {
+			return err
+		}
+	}
+	newJob.jobID = jobID
+
 	// add to the Job Manager
 	err = service.postProcessing.AddJob(newJob)
 	if err != nil {
@@ -1,17 +1,27 @@
 package orders
 
-// Do actually runs the post processing task(s)
+import "errors"
+
+// Do actually runs the post processing task(s) and records the outcome
 func (j *postProcessJob) Do(workerID int) {
+	j.service.jobStarted(j.jobID)
+	j.service.jobFinished(j.jobID, j.postProcess(workerID))
+}
+
+// postProcess runs the post processing task(s) and returns any errors
+func (j *postProcessJob) postProcess(workerID int) error {
 	// get order
 	order, err := j.service.storage.GetOneOrder(j.orderID)
 	if err != nil {
-		j.service.logger.Errorf("orders: ost processing worker %d: failed to get order %d from db for post processing (%s)", workerID, j.orderID, err)
-		return // done, failed
+		j.service.logger.Errorf("orders: post processing worker %d: failed to get order %d from db for post processing (%s)", workerID, j.orderID, err)
+		return err // done, failed
 	}
 
 	// run client post processing
-	j.doClientPostProcess(order, workerID)
+	clientErr := j.doClientPostProcess(order, workerID)
 
 	// run command post processing
-	j.doScriptOrBinaryPostProcess(order, workerID)
+	commandErr := j.doScriptOrBinaryPostProcess(order, workerID)
+
+	return errors.Join(clientErr, commandErr)
 }
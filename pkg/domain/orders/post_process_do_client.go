@@ -30,11 +30,18 @@ type postProcessClientPayload struct {
 
 // doClientPostProcess sends a data payload to the client located
 // at certificate's CN, using the encryption key specified on certificate
-func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
+func (j *postProcessJob) doClientPostProcess(order Order, workerID int) error {
 	// no-op if no client key
 	if order.Certificate.PostProcessingClientKeyB64 == "" || order.Certificate.PostProcessingClientAddress == "" {
 		j.service.logger.Debugf("orders: post processing worker %d: order %d: skipping client notify (cert does not have a client address and/or client key) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		return nil
+	}
+
+	// the client installs the key too, so it can't be used with an external csr
+	if order.Certificate.HasExternalCsr() {
+		err := fmt.Errorf("orders: post processing worker %d: order %d: skipping client notify (cert uses an external csr, the private key is not available) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: attempting to notify client (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
@@ -42,14 +49,16 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 	// decode AES key
 	aesKey, err := base64.RawURLEncoding.DecodeString(order.Certificate.PostProcessingClientKeyB64)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: invalid aes key (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: invalid aes key (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// verify pem exists (should never trigger)
 	if order.Pem == nil || order.FinalizedKey == nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: something really weird happened and pem content is nil (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: something really weird happened and pem content is nil (cert: %d, cn: %s, addr: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// make inner payload for client
@@ -59,29 +68,33 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 	}
 	innerPayloadJson, err := json.Marshal(innerPayload)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to marshal inner payload (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to marshal inner payload (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// make AES-GCM for encrypting
 	aes, err := aes.NewCipher(aesKey)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make cipher (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make cipher (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	gcm, err := cipher.NewGCM(aes)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make gcm AEAD (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make gcm AEAD (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// make nonce and encrypt
 	nonce := make([]byte, gcm.NonceSize())
 	_, err = rand.Read(nonce)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make nonce (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to make nonce (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 	// note: dst==nonce on purpose (so nonce is prepended)
 	encryptedInnerData := gcm.Seal(nonce, nonce, innerPayloadJson, nil)
@@ -93,16 +106,18 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 
 	dataPayload, err := json.Marshal(payload)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to marshal outer payload (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to marshal outer payload (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// send post to client
 	postTo := fmt.Sprintf("https://%s:%d%s", order.Certificate.PostProcessingClientAddress, postProcessClientPort, postProcessClientPostRoute)
 	resp, err := j.service.httpClient.Post(postTo, "application/json", bytes.NewBuffer(dataPayload))
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to post to client (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: failed to post to client (%s) (cert: %d, cn: %s, addr: %s)", workerID, order.ID, err, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// ensure body is read and closed
@@ -111,9 +126,12 @@ func (j *postProcessJob) doClientPostProcess(order Order, workerID int) {
 
 	// error if not 200
 	if resp.StatusCode != http.StatusOK {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: notify client failed: post status %d (cert: %d, cn: %s, addr: %s)", workerID, order.ID, resp.StatusCode, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: notify client failed: post status %d (cert: %d, cn: %s, addr: %s)", workerID, order.ID, resp.StatusCode, order.Certificate.ID, order.Certificate.Subject, order.Certificate.PostProcessingClientAddress)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: client notify completed", workerID, order.ID)
+
+	return nil
 }
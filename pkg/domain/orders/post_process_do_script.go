@@ -13,11 +13,11 @@ import (
 
 // doScriptOrBinaryPost executes the certificate's post processing command. if the cert
 // does not have a command, this is a no-op
-func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int) {
+func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int) error {
 	// no-op if no command
 	if order.Certificate.PostProcessingCommand == "" {
 		j.service.logger.Debugf("orders: post processing worker %d: order %d: skipping command (cert does not have a command to run) (cert: %d, cn: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject)
-		return
+		return nil
 	}
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: attempting to run command (cert: %d, cn: %s)", workerID, order.ID, order.Certificate.ID, order.Certificate.Subject)
@@ -26,12 +26,12 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 	if order.Pem == nil {
 		err := fmt.Errorf("orders: post processing worker %d: order %d: command failed: order pem is nil (should never happen)", workerID, order.ID)
 		j.service.logger.Error(err)
-		return
+		return err
 	}
-	if order.FinalizedKey == nil {
+	if order.FinalizedKey == nil && !order.Certificate.HasExternalCsr() {
 		err := fmt.Errorf("orders: post processing worker %d: order %d: command failed: finalized key no longer exists", workerID, order.ID)
 		j.service.logger.Error(err)
-		return
+		return err
 	}
 
 	// user specified environment can have placeholders for certain values (so user can set
@@ -53,10 +53,14 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 	for key, val := range envParams.KeyValMap() {
 		switch upperVal := strings.ToUpper(val); upperVal {
 		case "{{PRIVATE_KEY_NAME}}":
-			val = order.FinalizedKey.Name
+			if order.FinalizedKey != nil {
+				val = order.FinalizedKey.Name
+			}
 
 		case "{{PRIVATE_KEY_PEM}}":
-			val = order.FinalizedKey.Pem
+			if order.FinalizedKey != nil {
+				val = order.FinalizedKey.Pem
+			}
 
 		case "{{CERTIFICATE_NAME}}":
 			val = order.Certificate.Name
@@ -79,15 +83,17 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 	// and also check if the file has a shebang
 	f, err := os.Open(order.Certificate.PostProcessingCommand)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: script/binary failed to open: %s", workerID, order.ID, err)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: script/binary failed to open: %s", workerID, order.ID, err)
+		j.service.logger.Error(err)
+		return err
 	}
 	defer f.Close()
 
 	fInfo, err := f.Stat()
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: script/binary failed to stat: %s", workerID, order.ID, err)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: script/binary failed to stat: %s", workerID, order.ID, err)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	bufLen := 512
@@ -98,8 +104,9 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 
 	_, err = io.ReadFull(f, firstBytes)
 	if err != nil {
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: script/binary failed to read: %s", workerID, order.ID, err)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: script/binary failed to read: %s", workerID, order.ID, err)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	// run binary or shebang file directly
@@ -112,8 +119,9 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 		// try to run as script if it wasn't an octet-stream and didn't have shebang
 		// if app failed to get suitable default shell at startup, post processing will fail
 		if j.service.defaultShellPath == "" {
-			j.service.logger.Errorf("orders: post processing worker %d: order %d: commaind failed to run post processing script (no suitable default shell was found during startup)", workerID, order.ID)
-			return
+			err = fmt.Errorf("orders: post processing worker %d: order %d: commaind failed to run post processing script (no suitable default shell was found during startup)", workerID, order.ID)
+			j.service.logger.Error(err)
+			return err
 		}
 
 		// make args for command
@@ -137,9 +145,12 @@ func (j *postProcessJob) doScriptOrBinaryPostProcess(order Order, workerID int)
 			j.service.logger.Errorf("orders: post processing worker %d: order %d: command std err: %s", workerID, order.ID, exitErr.Stderr)
 		}
 
-		j.service.logger.Errorf("orders: post processing worker %d: order %d: command failed: error: %s", workerID, order.ID, err)
-		return
+		err = fmt.Errorf("orders: post processing worker %d: order %d: command failed: error: %s", workerID, order.ID, err)
+		j.service.logger.Error(err)
+		return err
 	}
 
 	j.service.logger.Infof("orders: post processing worker %d: order %d: command completed", workerID, order.ID)
+
+	return nil
 }
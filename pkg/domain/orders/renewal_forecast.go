@@ -0,0 +1,89 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"time"
+)
+
+// renewal forecast reasons
+const (
+	forecastReasonAutoRenewDisabled  = "auto renew is disabled by the renewal policy"
+	forecastReasonWindowPassed       = "suggested window has passed, renewal is due at the next auto order run"
+	forecastReasonBeforeWindow       = "suggested window has not started, renewal will be at a random time within it"
+	forecastReasonInWindow           = "suggested window has started, renewal will be at a random time within its remainder"
+	forecastReasonMaintenanceWindow  = "renewal will be at a random time within the suggested window's time allowed by the maintenance window"
+	forecastReasonMaintenanceDefer   = "maintenance window does not allow renewal during the suggested window, deferred to the next allowed time"
+	forecastReasonMaintenanceBlocked = "maintenance window does not allow renewal before the certificate expires"
+)
+
+// projectRenewalIntervals returns the intervals a cert is projected to be renewed during and the
+// reason for it. The auto orderer selects a new random time within these every run, so the
+// renewal time itself can't be projected. mw is nil if the cert does not have a maintenance
+// window. If the cert won't be renewed, nil is returned.
+func projectRenewalIntervals(ari *renewalInfo, validTo time.Time, mw *maintenance_windows.MaintenanceWindow, now time.Time) ([]maintenance_windows.Interval, string) {
+	// maintenance window
+	if mw != nil {
+		allowed := maintenanceRenewalIntervals(*mw, ari, validTo, now)
+		if len(allowed) == 0 {
+			return nil, forecastReasonMaintenanceBlocked
+		}
+
+		if !allowed[0].Start.Before(ari.SuggestedWindow.End) {
+			return allowed, forecastReasonMaintenanceDefer
+		}
+
+		return allowed, forecastReasonMaintenanceWindow
+	}
+
+	// no maintenance window
+	if !ari.SuggestedWindow.End.After(now) {
+		return []maintenance_windows.Interval{{Start: now, End: now}}, forecastReasonWindowPassed
+	}
+
+	if ari.SuggestedWindow.Start.After(now) {
+		return []maintenance_windows.Interval{{Start: ari.SuggestedWindow.Start, End: ari.SuggestedWindow.End}}, forecastReasonBeforeWindow
+	}
+
+	return []maintenance_windows.Interval{{Start: now, End: ari.SuggestedWindow.End}}, forecastReasonInWindow
+}
+
+// addToForecastHistogram adds one renewal, spread over the renewal intervals by duration, to the
+// histogram of expected renewals per day (UTC). The histogram's first day starts at 'today'.
+// Renewals outside of the histogram's days are not added.
+func addToForecastHistogram(histogram []renewalForecastDayResponse, today time.Time, intervals []maintenance_windows.Interval) {
+	var total time.Duration
+	for _, interval := range intervals {
+		total += interval.End.Sub(interval.Start)
+	}
+
+	// zero length (renewal at a specific time)
+	if total <= 0 {
+		day := int(intervals[0].Start.UTC().Sub(today) / (24 * time.Hour))
+		if day >= 0 && day < len(histogram) {
+			histogram[day].Renewals++
+		}
+		return
+	}
+
+	for i := range histogram {
+		dayStart := today.Add(time.Duration(i) * 24 * time.Hour)
+		dayEnd := dayStart.Add(24 * time.Hour)
+
+		var overlap time.Duration
+		for _, interval := range intervals {
+			start := interval.Start
+			if start.Before(dayStart) {
+				start = dayStart
+			}
+			end := interval.End
+			if end.After(dayEnd) {
+				end = dayEnd
+			}
+			if end.After(start) {
+				overlap += end.Sub(start)
+			}
+		}
+
+		histogram[i].Renewals += float64(overlap) / float64(total)
+	}
+}
@@ -0,0 +1,128 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"testing"
+	"time"
+)
+
+func TestProjectRenewalIntervals(t *testing.T) {
+	// 2025-01-06 is a Monday
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	window := func(start, end time.Time) *renewalInfo {
+		ari := &renewalInfo{}
+		ari.SuggestedWindow.Start = start
+		ari.SuggestedWindow.End = end
+		return ari
+	}
+	validTo := now.AddDate(0, 0, 30)
+	tueThu := &maintenance_windows.MaintenanceWindow{
+		Timezone: "UTC",
+		Ranges:   []maintenance_windows.WeeklyRange{{Days: []string{"tue", "thu"}, Start: "02:00", End: "04:00"}},
+	}
+
+	tests := []struct {
+		name    string
+		ari     *renewalInfo
+		mw      *maintenance_windows.MaintenanceWindow
+		validTo time.Time
+		start   time.Time
+		end     time.Time
+		reason  string
+		ok      bool
+	}{
+		{
+			name:    "before window",
+			ari:     window(now.Add(24*time.Hour), now.Add(72*time.Hour)),
+			validTo: validTo,
+			start:   now.Add(24 * time.Hour),
+			end:     now.Add(72 * time.Hour),
+			reason:  forecastReasonBeforeWindow,
+			ok:      true,
+		},
+		{
+			name:    "in window",
+			ari:     window(now.Add(-24*time.Hour), now.Add(24*time.Hour)),
+			validTo: validTo,
+			start:   now,
+			end:     now.Add(24 * time.Hour),
+			reason:  forecastReasonInWindow,
+			ok:      true,
+		},
+		{
+			name:    "window passed",
+			ari:     window(now.Add(-48*time.Hour), now.Add(-24*time.Hour)),
+			validTo: validTo,
+			start:   now,
+			end:     now,
+			reason:  forecastReasonWindowPassed,
+			ok:      true,
+		},
+		{
+			name:    "maintenance window allows part",
+			ari:     window(now, now.Add(72*time.Hour)),
+			mw:      tueThu,
+			validTo: validTo,
+			// tue 02:00-04:00 and thu 02:00-04:00
+			start:  time.Date(2025, 1, 7, 2, 0, 0, 0, time.UTC),
+			end:    time.Date(2025, 1, 9, 4, 0, 0, 0, time.UTC),
+			reason: forecastReasonMaintenanceWindow,
+			ok:     true,
+		},
+		{
+			name:    "maintenance window deferred",
+			ari:     window(now, now.Add(12*time.Hour)),
+			mw:      tueThu,
+			validTo: validTo,
+			start:   time.Date(2025, 1, 7, 2, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 1, 7, 2, 0, 0, 0, time.UTC),
+			reason:  forecastReasonMaintenanceDefer,
+			ok:      true,
+		},
+		{
+			name:    "maintenance window blocked",
+			ari:     window(now, now.Add(6*time.Hour)),
+			mw:      tueThu,
+			validTo: now.Add(12 * time.Hour),
+			reason:  forecastReasonMaintenanceBlocked,
+			ok:      false,
+		},
+	}
+
+	for _, test := range tests {
+		intervals, reason := projectRenewalIntervals(test.ari, test.validTo, test.mw, now)
+		ok := len(intervals) > 0
+		if ok != test.ok || reason != test.reason {
+			t.Errorf("%s: got ok %t reason '%s', want ok %t reason '%s'", test.name, ok, reason, test.ok, test.reason)
+			continue
+		}
+		if ok && (!intervals[0].Start.Equal(test.start) || !intervals[len(intervals)-1].End.Equal(test.end)) {
+			t.Errorf("%s: projected %s to %s, want %s to %s", test.name, intervals[0].Start, intervals[len(intervals)-1].End, test.start, test.end)
+		}
+	}
+}
+
+func TestAddToForecastHistogram(t *testing.T) {
+	today := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	histogram := make([]renewalForecastDayResponse, 3)
+
+	// half on day 0, half on day 1
+	addToForecastHistogram(histogram, today, []maintenance_windows.Interval{
+		{Start: today.Add(12 * time.Hour), End: today.Add(36 * time.Hour)},
+	})
+	// specific time on day 2
+	addToForecastHistogram(histogram, today, []maintenance_windows.Interval{
+		{Start: today.Add(50 * time.Hour), End: today.Add(50 * time.Hour)},
+	})
+	// after the histogram
+	addToForecastHistogram(histogram, today, []maintenance_windows.Interval{
+		{Start: today.Add(100 * time.Hour), End: today.Add(110 * time.Hour)},
+	})
+
+	want := []float64{0.5, 0.5, 1}
+	for i := range want {
+		if histogram[i].Renewals != want[i] {
+			t.Errorf("day %d: renewals %f, want %f", i, histogram[i].Renewals, want[i])
+		}
+	}
+}
@@ -5,13 +5,18 @@ import (
 	"certwarden-backend/pkg/domain/acme_servers"
 	"certwarden-backend/pkg/domain/authorizations"
 	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"certwarden-backend/pkg/domain/private_cas"
+	"certwarden-backend/pkg/domain/private_keys"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/pagination_sort"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os/exec"
 	"sync"
+	"time"
 
 	"github.com/scaleway/scaleway-sdk-go/logger"
 	"go.uber.org/zap"
@@ -27,6 +32,8 @@ type App interface {
 	GetOrderStorage() Storage
 	GetAcmeServerService() *acme_servers.Service
 	GetCertificatesService() *certificates.Service
+	GetPrivateCAsService() *private_cas.Service
+	GetMaintenanceWindowsService() *maintenance_windows.Service
 
 	// for fulfiller
 	GetAuthsService() *authorizations.Service
@@ -61,17 +68,39 @@ type Storage interface {
 
 	// certs
 	UpdateCertUpdatedTime(certId int) (err error)
+
+	// jobs
+	GetAllJobs(q pagination_sort.Query) (jobs []Job, totalRows int, err error)
+	GetOneJob(id int) (job Job, err error)
+	GetUnfinishedJobs() (jobs []Job, err error)
+	CountEarlierJobs(jobType string, orderId int, beforeJobId int) (count int, err error)
+	PostNewJob(payload NewJobPayload) (newId int, err error)
+	PutJobStatus(payload UpdateJobStatusPayload) (err error)
+	DeleteFinishedJobs(olderThan int) (deletedCount int, err error)
+
+	// failed order retry
+	PutCertOrderFailures(certId int, failures certificates.OrderFailures) (err error)
+	GetCertIdsOrderRetryBefore(before int) (certIds []int, err error)
+
+	// key rotation
+	CountValidOrdersFinalizedWithKey(certId int, keyId int) (count int, err error)
+	RotateCertKey(certId int, oldKeyId int, newKey private_keys.NewPayload) (private_keys.Key, error)
+	CompleteCertKeyRotation(certId int) (completed int, err error)
+	DeleteUnusedRetiredKeys() (deletedKeyIds []int, err error)
 }
 
 // service struct
 type Service struct {
-	shutdownContext   context.Context
-	logger            *zap.SugaredLogger
-	output            *output.Service
-	storage           Storage
-	acmeServerService *acme_servers.Service
-	authorizations    *authorizations.Service
-	certificates      *certificates.Service
+	shutdownContext    context.Context
+	shutdownWaitgroup  *sync.WaitGroup
+	logger             *zap.SugaredLogger
+	output             *output.Service
+	storage            Storage
+	acmeServerService  *acme_servers.Service
+	authorizations     *authorizations.Service
+	certificates       *certificates.Service
+	privateCAs         *private_cas.Service
+	maintenanceWindows *maintenance_windows.Service
 
 	serverCertificateName    *string
 	loadHttpsCertificateFunc func() error
@@ -80,14 +109,46 @@ type Service struct {
 
 	postProcessing  *job_manager.Manager[*postProcessJob]
 	orderFulfilling *job_manager.Manager[*orderFulfillJob]
+
+	autoOrderRunInterval time.Duration
+	renewalPolicy        certificates.RenewalPolicy
+
+	failedOrderMaxAttempts  int
+	failedOrderInitialDelay time.Duration
+	scheduledOrderRetries   sync.Map // cert id -> scheduled retry time (unix)
+	scheduledNewOrders      sync.Map // cert id -> scheduled new order time (unix)
+}
+
+// Config is the configuration for the orders service
+type Config struct {
+	// AutoOrderIntervalMinutes is how often the auto order service checks for orders
+	// to complete and certificates to renew
+	AutoOrderIntervalMinutes *int `yaml:"auto_order_interval_minutes"`
+	// RenewalPolicy is the default renewal policy of all certificates; each certificate
+	// may override any of its values
+	RenewalPolicy certificates.RenewalPolicy `yaml:"renewal_policy"`
+	// FailedOrderRetry configures automatically retrying failed orders
+	FailedOrderRetry FailedOrderRetryConfig `yaml:"failed_order_retry"`
+}
+
+// FailedOrderRetryConfig is the configuration for retrying failed orders
+type FailedOrderRetryConfig struct {
+	// MaxAttempts is the number of consecutive failures to retry (0 disables retrying)
+	MaxAttempts *int `yaml:"max_attempts"`
+	// InitialDelayMinutes is the delay before the first retry; it doubles after each failure
+	InitialDelayMinutes *int `yaml:"initial_delay_minutes"`
 }
 
 // NewService creates a new private_key service
-func NewService(app App) (*Service, error) {
+func NewService(app App, cfg *Config) (*Service, error) {
 	service := new(Service)
 
-	// shutdown context
+	// shutdown context & wg
 	service.shutdownContext = app.GetShutdownContext()
+	service.shutdownWaitgroup = app.GetShutdownWaitGroup()
+	if service.shutdownContext == nil || service.shutdownWaitgroup == nil {
+		return nil, errServiceComponent
+	}
 
 	// logger
 	service.logger = app.GetLogger()
@@ -125,6 +186,18 @@ func NewService(app App) (*Service, error) {
 		return nil, errServiceComponent
 	}
 
+	// private cas
+	service.privateCAs = app.GetPrivateCAsService()
+	if service.privateCAs == nil {
+		return nil, errServiceComponent
+	}
+
+	// maintenance windows
+	service.maintenanceWindows = app.GetMaintenanceWindowsService()
+	if service.maintenanceWindows == nil {
+		return nil, errServiceComponent
+	}
+
 	// needed to reload App cert on update
 	service.serverCertificateName = app.HttpsCertificateName()
 	service.loadHttpsCertificateFunc = app.LoadHttpsCertificate
@@ -157,6 +230,28 @@ func NewService(app App) (*Service, error) {
 	// httpClient
 	service.httpClient = app.GetHttpClient()
 
+	// auto order interval
+	if cfg.AutoOrderIntervalMinutes == nil || *cfg.AutoOrderIntervalMinutes < minAutoOrderIntervalMinutes || *cfg.AutoOrderIntervalMinutes > maxAutoOrderIntervalMinutes {
+		return nil, fmt.Errorf("orders: auto order interval minutes must be between %d and %d", minAutoOrderIntervalMinutes, maxAutoOrderIntervalMinutes)
+	}
+	service.autoOrderRunInterval = time.Duration(*cfg.AutoOrderIntervalMinutes) * time.Minute
+
+	// default renewal policy (anything not configured uses the built in default)
+	if !cfg.RenewalPolicy.Valid() {
+		return nil, fmt.Errorf("orders: default %s", certificates.ErrRenewalPolicyBad)
+	}
+	service.renewalPolicy = certificates.DefaultRenewalPolicy().Merge(cfg.RenewalPolicy)
+
+	// failed order retry
+	if cfg.FailedOrderRetry.MaxAttempts == nil || *cfg.FailedOrderRetry.MaxAttempts < 0 || *cfg.FailedOrderRetry.MaxAttempts > maxFailedOrderMaxAttempts {
+		return nil, fmt.Errorf("orders: failed order retry max attempts must be between 0 and %d", maxFailedOrderMaxAttempts)
+	}
+	service.failedOrderMaxAttempts = *cfg.FailedOrderRetry.MaxAttempts
+	if cfg.FailedOrderRetry.InitialDelayMinutes == nil || *cfg.FailedOrderRetry.InitialDelayMinutes < minFailedOrderInitialDelayMinutes || *cfg.FailedOrderRetry.InitialDelayMinutes > maxFailedOrderInitialDelayMinutes {
+		return nil, fmt.Errorf("orders: failed order retry initial delay minutes must be between %d and %d", minFailedOrderInitialDelayMinutes, maxFailedOrderInitialDelayMinutes)
+	}
+	service.failedOrderInitialDelay = time.Duration(*cfg.FailedOrderRetry.InitialDelayMinutes) * time.Minute
+
 	// make post process job manager
 	postWorkers := 3
 	service.postProcessing = job_manager.NewManager[*postProcessJob](postWorkers, "post processing", app.GetShutdownContext(), app.GetShutdownWaitGroup(), app.GetLogger())
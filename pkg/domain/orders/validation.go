@@ -16,6 +16,9 @@ var (
 
 	errNoPemContent = errors.New("orders: order doesnt have pem content")
 
+	errJobIdBad     = errors.New("orders: job id is invalid")
+	errJobNotQueued = errors.New("orders: only queued jobs can be canceled")
+
 	errOrderRetryFinal      = errors.New("orders: can't retry an order that is in a final state (valid or invalid)")
 	errOrderRevokeBadReason = errors.New("orders: bad revocation reason code")
 )
@@ -106,3 +109,27 @@ func (service *Service) validRevocationReason(reasonCode int) error {
 
 	return nil
 }
+
+// getJob returns the job with the specified id
+func (service *Service) getJob(jobId int) (Job, *output.JsonError) {
+	// basic check
+	if !validation.IsIdExistingValidRange(jobId) {
+		service.logger.Debug(errJobIdBad)
+		return Job{}, output.JsonErrValidationFailed(errJobIdBad)
+	}
+
+	// get from storage
+	job, err := service.storage.GetOneJob(jobId)
+	if err != nil {
+		// special error case for no record found
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return Job{}, output.JsonErrNotFound(fmt.Errorf("job id %d not found", jobId))
+		} else {
+			service.logger.Error(err)
+			return Job{}, output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	return job, nil
+}
@@ -0,0 +1,31 @@
+package orders
+
+import (
+	"certwarden-backend/pkg/acme"
+	"certwarden-backend/pkg/domain/certificates"
+	"time"
+)
+
+// setRequestedValidity adds the cert's requested validity period (if it has one) to
+// the new order payload. The period starts now.
+func setRequestedValidity(payload *acme.NewOrderPayload, cert certificates.Certificate) {
+	if cert.RequestedValidity() <= 0 {
+		return
+	}
+
+	notBefore := time.Now()
+	payload.SetValidity(notBefore, notBefore.Add(cert.RequestedValidity()))
+}
+
+// checkIssuedValidity returns an error if the certificate issued for acmeOrder does not
+// have the validity period that cert requested. If the ACME server echoed the requested
+// notAfter in the order, the issued certificate is checked against it.
+func checkIssuedValidity(cert certificates.Certificate, acmeOrder acme.Order, issued *acme.Certificate) error {
+	var requestedNotAfter *time.Time
+	if unixTime := acmeOrder.NotAfter.ToUnixTime(); unixTime != nil && *unixTime != 0 {
+		notAfter := time.Unix(int64(*unixTime), 0)
+		requestedNotAfter = &notAfter
+	}
+
+	return cert.CheckIssuedValidity(issued.NotBefore(), issued.NotAfter(), requestedNotAfter)
+}
@@ -0,0 +1,125 @@
+package private_cas
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/domain/private_keys/key_crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errCaCertPemBad = errors.New("private ca certificate pem is not valid")
+
+// CA is a single private certificate authority that can issue certificates
+// without using an ACME server
+type CA struct {
+	ID                  int
+	Name                string
+	Description         string
+	CAKey               private_keys.Key
+	CertificatePem      string
+	ChainPem            string
+	DefaultValidityDays int
+	CreatedAt           int
+	UpdatedAt           int
+}
+
+// CASummaryResponse is a JSON response containing only
+// fields desired for the summary
+type CASummaryResponse struct {
+	ID                  int                  `json:"id"`
+	Name                string               `json:"name"`
+	Description         string               `json:"description"`
+	CAKey               caKeySummaryResponse `json:"private_key"`
+	Subject             string               `json:"subject"`
+	IsRoot              bool                 `json:"is_root"`
+	ValidFrom           int64                `json:"valid_from"`
+	ValidTo             int64                `json:"valid_to"`
+	DefaultValidityDays int                  `json:"default_validity_days"`
+}
+
+type caKeySummaryResponse struct {
+	ID        int                  `json:"id"`
+	Name      string               `json:"name"`
+	Algorithm key_crypto.Algorithm `json:"algorithm"`
+}
+
+func (ca CA) SummaryResponse() CASummaryResponse {
+	resp := CASummaryResponse{
+		ID:          ca.ID,
+		Name:        ca.Name,
+		Description: ca.Description,
+		CAKey: caKeySummaryResponse{
+			ID:        ca.CAKey.ID,
+			Name:      ca.CAKey.Name,
+			Algorithm: ca.CAKey.Algorithm,
+		},
+		DefaultValidityDays: ca.DefaultValidityDays,
+	}
+
+	// fields from the CA cert itself (if it fails to parse, leave them blank)
+	caCert, err := ca.Certificate()
+	if err == nil {
+		resp.Subject = caCert.Subject.String()
+		resp.IsRoot = bytes.Equal(caCert.RawIssuer, caCert.RawSubject) && caCert.CheckSignatureFrom(caCert) == nil
+		resp.ValidFrom = caCert.NotBefore.Unix()
+		resp.ValidTo = caCert.NotAfter.Unix()
+	}
+
+	return resp
+}
+
+// caDetailedResponse is a JSON response containing all
+// fields that can be returned as JSON
+type caDetailedResponse struct {
+	CASummaryResponse
+	CertificatePem string `json:"certificate_pem"`
+	ChainPem       string `json:"chain_pem"`
+	CreatedAt      int    `json:"created_at"`
+	UpdatedAt      int    `json:"updated_at"`
+}
+
+func (ca CA) detailedResponse() caDetailedResponse {
+	return caDetailedResponse{
+		CASummaryResponse: ca.SummaryResponse(),
+		CertificatePem:    ca.CertificatePem,
+		ChainPem:          ca.ChainPem,
+		CreatedAt:         ca.CreatedAt,
+		UpdatedAt:         ca.UpdatedAt,
+	}
+}
+
+// Output Methods
+
+func (ca CA) FilenameNoExt() string {
+	return fmt.Sprintf("%s.ca", ca.Name)
+}
+
+// PemContent returns the CA certificate followed by any chain above it
+func (ca CA) PemContent() string {
+	return ca.CertificatePem + ca.ChainPem
+}
+
+func (ca CA) Modtime() time.Time {
+	return time.Unix(int64(ca.UpdatedAt), 0)
+}
+
+// end Output Methods
+
+// Certificate parses and returns the CA's x509 certificate
+func (ca *CA) Certificate() (*x509.Certificate, error) {
+	return parseCertificatePem(ca.CertificatePem)
+}
+
+// parseCertificatePem parses the first certificate in the pem string
+func parseCertificatePem(certPem string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPem))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errCaCertPemBad
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
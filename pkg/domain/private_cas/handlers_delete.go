@@ -0,0 +1,57 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/output"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DeletePrivateCA deletes a private CA from storage. The CA's private key is not
+// deleted, it becomes available for other uses.
+func (service *Service) DeletePrivateCA(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// verify ca exists
+	_, outErr := service.getPrivateCA(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// do not allow delete if there are any certs using the ca
+	if service.storage.PrivateCAHasCerts(id) {
+		service.logger.Warn("cannot delete private ca (in use)")
+		return output.JsonErrDeleteInUse("private ca")
+	}
+	// end validation
+
+	// delete from storage
+	err = service.storage.DeletePrivateCA(id)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &output.JsonResponse{
+		StatusCode: http.StatusOK,
+		Message:    fmt.Sprintf("deleted private ca (id: %d)", id),
+	}
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// privateCAsResponse provides the json response struct
+// to answer a query for a portion of the private CAs
+type privateCAsResponse struct {
+	output.JsonResponse
+	TotalPrivateCAs int                 `json:"total_records"`
+	PrivateCAs      []CASummaryResponse `json:"private_cas"`
+}
+
+// GetAllPrivateCAs is an http handler that returns all private CAs in the form of JSON written to w
+func (service *Service) GetAllPrivateCAs(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// parse pagination and sorting
+	query := pagination_sort.ParseRequestToQuery(r)
+
+	// get all from storage
+	cas, totalRows, err := service.storage.GetAllPrivateCAs(query)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// populate summaries for output
+	outputCAs := []CASummaryResponse{}
+	for i := range cas {
+		outputCAs = append(outputCAs, cas[i].SummaryResponse())
+	}
+
+	// write response
+	response := &privateCAsResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.TotalPrivateCAs = totalRows
+	response.PrivateCAs = outputCAs
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+type privateCAResponse struct {
+	output.JsonResponse
+	PrivateCA caDetailedResponse `json:"private_ca"`
+}
+
+// GetOnePrivateCA is an http handler that returns one private CA based on its unique id in the
+// form of JSON written to w
+func (service *Service) GetOnePrivateCA(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get from storage
+	ca, outErr := service.getPrivateCA(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// write response
+	response := &privateCAResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "ok"
+	response.PrivateCA = ca.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
+
+// DownloadPrivateCACert returns the pem for a single private CA's certificate (and
+// chain, if any) to the client. This is what clients need to add to their trust store.
+func (service *Service) DownloadPrivateCACert(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// params
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get the ca from storage (and validate id)
+	ca, outErr := service.getPrivateCA(id)
+	if outErr != nil {
+		return outErr
+	}
+
+	// return pem file to client
+	service.output.WritePem(w, r, ca)
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/output"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// NewPayload is the payload struct for creating a new private CA. The CA is either
+// generated (common_name specified) or imported (certificate_pem specified).
+type NewPayload struct {
+	Name                *string `json:"name"`
+	Description         *string `json:"description"`
+	PrivateKeyID        *int    `json:"private_key_id"`
+	DefaultValidityDays *int    `json:"default_validity_days"`
+	// generate
+	CommonName     *string `json:"common_name"`
+	Organization   *string `json:"organization"`
+	Country        *string `json:"country"`
+	CAValidityDays *int    `json:"ca_validity_days"`
+	ParentCAID     *int    `json:"parent_private_ca_id"`
+	// import
+	CertificatePem *string `json:"certificate_pem"`
+	ChainPem       *string `json:"chain_pem"`
+	CreatedAt      int     `json:"-"`
+	UpdatedAt      int     `json:"-"`
+}
+
+// PostNewPrivateCA is the handler to generate or import a new private CA and save it
+// to storage
+func (service *Service) PostNewPrivateCA(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	var payload NewPayload
+
+	// decode body into payload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// name
+	if payload.Name == nil || !service.nameValid(*payload.Name, nil) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+
+	// description (blank if not specified)
+	if payload.Description == nil {
+		payload.Description = new(string)
+	}
+
+	// default validity for issued certs
+	if payload.DefaultValidityDays == nil {
+		payload.DefaultValidityDays = new(int)
+		*payload.DefaultValidityDays = 90
+	} else if !validityDaysValid(*payload.DefaultValidityDays) {
+		service.logger.Debug(ErrValidityBad)
+		return output.JsonErrValidationFailed(ErrValidityBad)
+	}
+
+	// private key
+	if payload.PrivateKeyID == nil || !service.keys.KeyAvailable(*payload.PrivateKeyID) {
+		service.logger.Debug(ErrKeyIdBad)
+		return output.JsonErrValidationFailed(ErrKeyIdBad)
+	}
+	caKey, err := service.storage.GetOneKeyById(*payload.PrivateKeyID)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// generate or import (exactly one)
+	generate := payload.CommonName != nil
+	importing := payload.CertificatePem != nil && *payload.CertificatePem != ""
+	if generate == importing {
+		err = errors.New("must specify exactly one of common_name (generate) or certificate_pem (import)")
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	if importing {
+		// verify the cert is a CA and matches the key
+		caCert, err := parseCertificatePem(*payload.CertificatePem)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+		if !caCert.BasicConstraintsValid || !caCert.IsCA {
+			service.logger.Debug(ErrCertNotCA)
+			return output.JsonErrValidationFailed(ErrCertNotCA)
+		}
+		if !certMatchesKey(caCert, caKey) {
+			service.logger.Debug(ErrCertKeyBad)
+			return output.JsonErrValidationFailed(ErrCertKeyBad)
+		}
+		*payload.CertificatePem = certDerToPem(caCert.Raw)
+
+		// chain (optional)
+		if payload.ChainPem == nil {
+			payload.ChainPem = new(string)
+		}
+		*payload.ChainPem, err = standardizeChainPem(caCert, *payload.ChainPem)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	} else {
+		// chain is always computed when generating
+		if payload.ChainPem != nil && *payload.ChainPem != "" {
+			err = errors.New("chain_pem cannot be specified when generating a ca")
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+
+		// subject
+		if strings.TrimSpace(*payload.CommonName) == "" || len(*payload.CommonName) > 64 {
+			service.logger.Debug(ErrCommonNameBad)
+			return output.JsonErrValidationFailed(ErrCommonNameBad)
+		}
+		subject := pkix.Name{CommonName: *payload.CommonName}
+		if payload.Organization != nil && *payload.Organization != "" {
+			subject.Organization = []string{*payload.Organization}
+		}
+		if payload.Country != nil && *payload.Country != "" {
+			subject.Country = []string{*payload.Country}
+		}
+
+		// ca validity (default 10 years)
+		if payload.CAValidityDays == nil {
+			payload.CAValidityDays = new(int)
+			*payload.CAValidityDays = 3650
+		} else if !validityDaysValid(*payload.CAValidityDays) {
+			service.logger.Debug(ErrValidityBad)
+			return output.JsonErrValidationFailed(ErrValidityBad)
+		}
+
+		// parent (optional, makes this an intermediate)
+		var parent *CA
+		if payload.ParentCAID != nil {
+			var usable bool
+			usable, parent = service.PrivateCAUsable(*payload.ParentCAID)
+			if !usable {
+				service.logger.Debug(ErrParentBad)
+				return output.JsonErrValidationFailed(ErrParentBad)
+			}
+		}
+
+		// make the cert
+		payload.CertificatePem = new(string)
+		payload.ChainPem = new(string)
+		*payload.CertificatePem, *payload.ChainPem, err = makeCACertificate(caKey, subject, *payload.CAValidityDays, parent)
+		if err != nil {
+			service.logger.Error(err)
+			return output.JsonErrInternal(err)
+		}
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
+
+	// save
+	newCA, err := service.storage.PostNewPrivateCA(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &privateCAResponse{}
+	response.StatusCode = http.StatusCreated
+	response.Message = "created private ca"
+	response.PrivateCA = newCA.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/output"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DetailsUpdatePayload is the struct for editing an existing private CA
+type DetailsUpdatePayload struct {
+	ID                  int     `json:"-"`
+	Name                *string `json:"name"`
+	Description         *string `json:"description"`
+	DefaultValidityDays *int    `json:"default_validity_days"`
+	UpdatedAt           int     `json:"-"`
+}
+
+// PutDetailsPrivateCA is a handler that updates the editable details of a private CA
+func (service *Service) PutDetailsPrivateCA(w http.ResponseWriter, r *http.Request) *output.JsonError {
+	// payload decoding
+	var payload DetailsUpdatePayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// get id from param
+	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	payload.ID, err = strconv.Atoi(idParam)
+	if err != nil {
+		service.logger.Debug(err)
+		return output.JsonErrValidationFailed(err)
+	}
+
+	// validation
+	// id
+	_, outErr := service.getPrivateCA(payload.ID)
+	if outErr != nil {
+		return outErr
+	}
+
+	// name (optional)
+	if payload.Name != nil && !service.nameValid(*payload.Name, &payload.ID) {
+		service.logger.Debug(ErrNameBad)
+		return output.JsonErrValidationFailed(ErrNameBad)
+	}
+
+	// default validity (optional)
+	if payload.DefaultValidityDays != nil && !validityDaysValid(*payload.DefaultValidityDays) {
+		service.logger.Debug(ErrValidityBad)
+		return output.JsonErrValidationFailed(ErrValidityBad)
+	}
+	// end validation
+
+	// add additional details to the payload before saving
+	payload.UpdatedAt = int(time.Now().Unix())
+
+	updatedCA, err := service.storage.PutDetailsPrivateCA(payload)
+	if err != nil {
+		service.logger.Error(err)
+		return output.JsonErrStorageGeneric(err)
+	}
+
+	// write response
+	response := &privateCAResponse{}
+	response.StatusCode = http.StatusOK
+	response.Message = "updated private ca"
+	response.PrivateCA = updatedCA.detailedResponse()
+
+	err = service.output.WriteJSON(w, response)
+	if err != nil {
+		service.logger.Errorf("failed to write json (%s)", err)
+		return output.JsonErrWriteJsonError(err)
+	}
+
+	return nil
+}
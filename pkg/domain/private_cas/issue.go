@@ -0,0 +1,265 @@
+package private_cas
+
+import (
+	"bytes"
+	"certwarden-backend/pkg/domain/private_keys"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// backdate is subtracted from the current time when setting NotBefore to tolerate
+// clients with slightly inaccurate clocks
+const backdate = 5 * time.Minute
+
+var (
+	errCaKeyNotSigner = errors.New("private ca key is not usable for signing")
+	errCaExpired      = errors.New("private ca certificate is expired or not yet valid")
+)
+
+// csrAllowedExtensions are the extensions that are copied from the CSR when issuing. All
+// others are either set by the CA or must not be chosen by the requester (e.g., name
+// constraints, key identifiers, policies, AIA, CRL distribution points).
+var csrAllowedExtensions = []asn1.ObjectIdentifier{
+	// TLS Feature (e.g., must staple) (RFC 7633)
+	{1, 3, 6, 1, 5, 5, 7, 1, 24},
+}
+
+// keySigner returns the crypto.Signer for a private key
+func keySigner(key private_keys.Key) (crypto.Signer, error) {
+	cryptoKey, err := key.CryptoPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := cryptoKey.(crypto.Signer)
+	if !ok {
+		return nil, errCaKeyNotSigner
+	}
+
+	return signer, nil
+}
+
+// newSerialNumber returns a random 128 bit serial number
+func newSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// certDerToPem encodes a DER certificate as a pem string
+func certDerToPem(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// makeCACertificate creates a new CA certificate for caKey. If parent is nil, the
+// certificate is self-signed (i.e., a root), otherwise it is an intermediate signed
+// by parent. The returned chain is empty for a root.
+func makeCACertificate(caKey private_keys.Key, subject pkix.Name, validityDays int, parent *CA) (certPem string, chainPem string, err error) {
+	signer, err := keySigner(caKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               subject,
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              now.Add(time.Duration(validityDays) * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SignatureAlgorithm:    caKey.Algorithm.CsrSigningAlg(),
+	}
+
+	// self-signed root
+	issuerCert := template
+	issuerSigner := signer
+
+	// intermediate
+	if parent != nil {
+		issuerCert, err = parent.Certificate()
+		if err != nil {
+			return "", "", err
+		}
+		if now.Before(issuerCert.NotBefore) || now.After(issuerCert.NotAfter) {
+			return "", "", errCaExpired
+		}
+
+		issuerSigner, err = keySigner(parent.CAKey)
+		if err != nil {
+			return "", "", err
+		}
+		template.SignatureAlgorithm = parent.CAKey.Algorithm.CsrSigningAlg()
+
+		// don't outlive the parent
+		if template.NotAfter.After(issuerCert.NotAfter) {
+			template.NotAfter = issuerCert.NotAfter
+		}
+
+		chainPem = parent.CertificatePem + parent.ChainPem
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuerCert, signer.Public(), issuerSigner)
+	if err != nil {
+		return "", "", err
+	}
+
+	return certDerToPem(der), chainPem, nil
+}
+
+// IssueCertificate signs the DER encoded csr using the specified CA. The issued
+// certificate is valid for the specified validity period, or the CA's default validity
+// period if validity is 0 (but never beyond the expiration of the CA itself). The return
+// value is the pem encoded certificate followed by the CA's certificate and chain.
+func (service *Service) IssueCertificate(caId int, csrDer []byte, validity time.Duration) (certChainPem string, err error) {
+	ca, err := service.storage.GetOnePrivateCAById(caId)
+	if err != nil {
+		return "", fmt.Errorf("private_cas: failed to get ca %d (%w)", caId, err)
+	}
+
+	caCert, err := ca.Certificate()
+	if err != nil {
+		return "", fmt.Errorf("private_cas: ca %d certificate invalid (%w)", caId, err)
+	}
+
+	now := time.Now()
+	if now.Before(caCert.NotBefore) || now.After(caCert.NotAfter) {
+		return "", fmt.Errorf("private_cas: ca %d (%w)", caId, errCaExpired)
+	}
+
+	signer, err := keySigner(ca.CAKey)
+	if err != nil {
+		return "", fmt.Errorf("private_cas: ca %d (%w)", caId, err)
+	}
+
+	// parse and verify the csr
+	csr, err := x509.ParseCertificateRequest(csrDer)
+	if err != nil {
+		return "", fmt.Errorf("private_cas: failed to parse csr (%w)", err)
+	}
+	err = csr.CheckSignature()
+	if err != nil {
+		return "", fmt.Errorf("private_cas: csr signature invalid (%w)", err)
+	}
+
+	serial, err := newSerialNumber()
+	if err != nil {
+		return "", err
+	}
+
+	if validity <= 0 {
+		validity = time.Duration(ca.DefaultValidityDays) * 24 * time.Hour
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               csr.Subject,
+		DNSNames:              csr.DNSNames,
+		IPAddresses:           csr.IPAddresses,
+		EmailAddresses:        csr.EmailAddresses,
+		URIs:                  csr.URIs,
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+		SignatureAlgorithm:    ca.CAKey.Algorithm.CsrSigningAlg(),
+	}
+
+	// RSA keys may also be used for key encipherment
+	if _, isRsa := csr.PublicKey.(*rsa.PublicKey); isRsa {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	// don't outlive the CA
+	if template.NotAfter.After(caCert.NotAfter) {
+		template.NotAfter = caCert.NotAfter
+	}
+
+	// copy allowed extensions requested in the csr (e.g., must staple)
+	for _, ext := range csr.Extensions {
+		if slices.ContainsFunc(csrAllowedExtensions, ext.Id.Equal) {
+			template.ExtraExtensions = append(template.ExtraExtensions, ext)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, signer)
+	if err != nil {
+		return "", fmt.Errorf("private_cas: failed to sign certificate (%w)", err)
+	}
+
+	return certDerToPem(der) + ca.CertificatePem + ca.ChainPem, nil
+}
+
+// certMatchesKey returns true if the certificate's public key is the public key of
+// the specified private key
+func certMatchesKey(cert *x509.Certificate, key private_keys.Key) bool {
+	signer, err := keySigner(key)
+	if err != nil {
+		return false
+	}
+
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return false
+	}
+
+	return pub.Equal(cert.PublicKey)
+}
+
+// standardizeChainPem validates that chainPem contains only certificates and
+// re-encodes it (dropping any extraneous text). It also verifies that cert was
+// issued by the first certificate in the chain.
+func standardizeChainPem(cert *x509.Certificate, chainPem string) (string, error) {
+	rest := []byte(chainPem)
+	var out bytes.Buffer
+	var first *x509.Certificate
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return "", errors.New("chain pem contains a non-certificate block")
+		}
+
+		chainCert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("chain pem contains an invalid certificate (%w)", err)
+		}
+		if first == nil {
+			first = chainCert
+		}
+
+		_ = pem.Encode(&out, block)
+	}
+
+	// blank chain is ok
+	if first == nil {
+		return "", nil
+	}
+
+	err := cert.CheckSignatureFrom(first)
+	if err != nil {
+		return "", fmt.Errorf("certificate was not issued by the first certificate in the chain (%w)", err)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,76 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/domain/private_keys"
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errServiceComponent = errors.New("necessary private ca service component is missing")
+
+// App interface is for connecting to the main app
+type App interface {
+	GetLogger() *zap.SugaredLogger
+	GetOutputter() *output.Service
+	GetPrivateCAStorage() Storage
+	GetKeysService() *private_keys.Service
+}
+
+// Storage interface for storage functions
+type Storage interface {
+	GetAllPrivateCAs(q pagination_sort.Query) (cas []CA, totalRows int, err error)
+	GetOnePrivateCAById(id int) (CA, error)
+	GetOnePrivateCAByName(name string) (CA, error)
+
+	PostNewPrivateCA(NewPayload) (CA, error)
+
+	PutDetailsPrivateCA(DetailsUpdatePayload) (CA, error)
+
+	DeletePrivateCA(id int) error
+
+	PrivateCAHasCerts(caId int) (inUse bool)
+
+	GetOneKeyById(id int) (private_keys.Key, error)
+}
+
+// PrivateCAs service struct
+type Service struct {
+	logger  *zap.SugaredLogger
+	output  *output.Service
+	storage Storage
+	keys    *private_keys.Service
+}
+
+// NewService creates a new private_cas service
+func NewService(app App) (*Service, error) {
+	service := new(Service)
+
+	// logger
+	service.logger = app.GetLogger()
+	if service.logger == nil {
+		return nil, errServiceComponent
+	}
+
+	// output service
+	service.output = app.GetOutputter()
+	if service.output == nil {
+		return nil, errServiceComponent
+	}
+
+	// storage
+	service.storage = app.GetPrivateCAStorage()
+	if service.storage == nil {
+		return nil, errServiceComponent
+	}
+
+	// key service
+	service.keys = app.GetKeysService()
+	if service.keys == nil {
+		return nil, errServiceComponent
+	}
+
+	return service, nil
+}
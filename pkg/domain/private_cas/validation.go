@@ -0,0 +1,130 @@
+package private_cas
+
+import (
+	"certwarden-backend/pkg/output"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"certwarden-backend/pkg/validation"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// id
+	ErrIdBad    = errors.New("private ca id is invalid")
+	ErrKeyIdBad = errors.New("private key id is invalid or not available")
+
+	// name
+	ErrNameBad = errors.New("private ca name is not valid")
+
+	// ca cert
+	ErrCommonNameBad = errors.New("private ca common name is not valid")
+	ErrValidityBad   = errors.New("validity days must be between 1 and 36500")
+	ErrCertKeyBad    = errors.New("certificate pem does not match the private key")
+	ErrCertNotCA     = errors.New("certificate pem is not a certificate authority")
+	ErrParentBad     = errors.New("parent private ca id does not exist or is not usable")
+)
+
+// maxValidityDays caps validity periods to avoid nonsensical dates
+const maxValidityDays = 36500
+
+// getPrivateCA returns the CA for the specified id.
+func (service *Service) getPrivateCA(id int) (CA, *output.JsonError) {
+	// if id is not in valid range, it is definitely not valid
+	if !validation.IsIdExistingValidRange(id) {
+		service.logger.Debug(ErrIdBad)
+		return CA{}, output.JsonErrValidationFailed(ErrIdBad)
+	}
+
+	// get from storage
+	ca, err := service.storage.GetOnePrivateCAById(id)
+	if err != nil {
+		// special error case for no record found
+		if errors.Is(err, storage.ErrNoRecord) {
+			service.logger.Debug(err)
+			return CA{}, output.JsonErrNotFound(fmt.Errorf("private ca id %d not found", id))
+		} else {
+			service.logger.Error(err)
+			return CA{}, output.JsonErrStorageGeneric(err)
+		}
+	}
+
+	return ca, nil
+}
+
+// nameValid returns true if the specified CA name is acceptable and
+// false if it is not. This check includes validating specified
+// characters and also confirms the name is not already in use by another
+// CA. If an id is specified, the name will also be accepted if the name
+// is already in use by the specified id.
+func (service *Service) nameValid(caName string, caId *int) bool {
+	// basic check
+	if !validation.NameValid(caName) {
+		return false
+	}
+
+	// make sure the name isn't already in use in storage
+	ca, err := service.storage.GetOnePrivateCAByName(caName)
+	if errors.Is(err, storage.ErrNoRecord) {
+		// no rows means name is not in use (valid)
+		return true
+	} else if err != nil {
+		// any other error, invalid
+		return false
+	}
+
+	// if the returned CA is the CA being edited, name valid
+	if caId != nil && ca.ID == *caId {
+		return true
+	}
+
+	return false
+}
+
+// validityDaysValid returns true if days is a sane validity period
+func validityDaysValid(days int) bool {
+	return days >= 1 && days <= maxValidityDays
+}
+
+// GetUsablePrivateCAs returns a list of CAs whose certificate parses and is
+// currently within its validity period
+func (service *Service) GetUsablePrivateCAs() ([]CA, error) {
+	cas, _, err := service.storage.GetAllPrivateCAs(pagination_sort.Query{})
+	if err != nil {
+		return nil, err
+	}
+
+	// rewrite cas in place with only usable cas
+	newIndex := 0
+	for i := range cas {
+		caCert, err := cas[i].Certificate()
+		if err == nil && time.Now().After(caCert.NotBefore) && time.Now().Before(caCert.NotAfter) {
+			cas[newIndex] = cas[i]
+			newIndex++
+		}
+	}
+	// truncate cas
+	cas = cas[:newIndex]
+
+	return cas, nil
+}
+
+// PrivateCAUsable returns true and the CA if the specified CA exists
+// in storage and it is in the usable CAs list
+func (service *Service) PrivateCAUsable(caId int) (bool, *CA) {
+	// get usable list
+	cas, err := service.GetUsablePrivateCAs()
+	if err != nil {
+		return false, nil
+	}
+
+	// verify specified ca id is usable
+	for i := range cas {
+		if cas[i].ID == caId {
+			return true, &cas[i]
+		}
+	}
+
+	return false, nil
+}
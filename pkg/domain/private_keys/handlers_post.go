@@ -1,16 +1,14 @@
 package private_keys
 
 import (
+	"certwarden-backend/pkg/apikeyhash"
 	"certwarden-backend/pkg/domain/private_keys/key_crypto"
 	"certwarden-backend/pkg/output"
 	"certwarden-backend/pkg/randomness"
+	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"net/http"
-	"strconv"
 	"time"
-
-	"github.com/julienschmidt/httprouter"
 )
 
 // PostPayload is a struct for posting a new key
@@ -19,9 +17,10 @@ type NewPayload struct {
 	Description    *string `json:"description"`
 	AlgorithmValue *string `json:"algorithm_value"`
 	PemContent     *string `json:"pem"`
-	ApiKey         string  `json:"-"`
+	Pkcs12Base64   *string `json:"pkcs12"`
+	Passphrase     *string `json:"passphrase"`
+	ApiKeyHash     string  `json:"-"`
 	ApiKeyDisabled *bool   `json:"api_key_disabled"`
-	ApiKeyViaUrl   bool    `json:"-"`
 	CreatedAt      int     `json:"-"`
 	UpdatedAt      int     `json:"-"`
 }
@@ -48,19 +47,46 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 		payload.Description = new(string)
 	}
 	// key add method
+	algorithmSpecified := payload.AlgorithmValue != nil && *payload.AlgorithmValue != ""
+	pemSpecified := payload.PemContent != nil && *payload.PemContent != ""
+	pkcs12Specified := payload.Pkcs12Base64 != nil && *payload.Pkcs12Base64 != ""
 	// error if no method specified
-	if (payload.AlgorithmValue == nil || *payload.AlgorithmValue == "") && (payload.PemContent == nil || *payload.PemContent == "") {
+	if !algorithmSpecified && !pemSpecified && !pkcs12Specified {
 		service.logger.Debug(ErrKeyOptionNone)
 		return output.JsonErrValidationFailed(ErrKeyOptionNone)
 	}
 	// error if more than one method specified
-	if (payload.AlgorithmValue != nil && *payload.AlgorithmValue != "") && (payload.PemContent != nil && *payload.PemContent != "") {
+	if (algorithmSpecified && pemSpecified) || (algorithmSpecified && pkcs12Specified) || (pemSpecified && pkcs12Specified) {
 		service.logger.Debug(ErrKeyOptionMultiple)
 		return output.JsonErrValidationFailed(ErrKeyOptionMultiple)
 	}
+	// passphrase is only for imported keys
+	passphrase := ""
+	if payload.Passphrase != nil {
+		passphrase = *payload.Passphrase
+	}
+	if algorithmSpecified && passphrase != "" {
+		service.logger.Debug(ErrPassphraseUnused)
+		return output.JsonErrValidationFailed(ErrPassphraseUnused)
+	}
+	// pkcs12 - extract the key and then handle the same as pem
+	if pkcs12Specified {
+		pfxData, err := base64.StdEncoding.DecodeString(*payload.Pkcs12Base64)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(ErrPkcs12Bad)
+		}
+
+		payload.PemContent = new(string)
+		*payload.PemContent, _, err = key_crypto.DecodePkcs12(pfxData, passphrase)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+	}
 	// generate or verify the key
 	// generate with algorithm, error if fails
-	if payload.AlgorithmValue != nil && *payload.AlgorithmValue != "" {
+	if algorithmSpecified {
 		// must initialize to avoid invalid address
 		payload.PemContent = new(string)
 		*payload.PemContent, err = key_crypto.AlgorithmByStorageValue(*payload.AlgorithmValue).GeneratePrivateKeyPem()
@@ -68,8 +94,14 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 			service.logger.Debug(err)
 			return output.JsonErrValidationFailed(err)
 		}
-	} else if payload.PemContent != nil && *payload.PemContent != "" {
-		// pem inputted - verify pem and determine algorithm
+	} else {
+		// pem inputted - decrypt if needed, verify pem and determine algorithm
+		*payload.PemContent, err = key_crypto.DecryptKeyPem(*payload.PemContent, passphrase)
+		if err != nil {
+			service.logger.Debug(err)
+			return output.JsonErrValidationFailed(err)
+		}
+
 		// must initialize to avoid invalid address
 		payload.AlgorithmValue = new(string)
 		var alg key_crypto.Algorithm
@@ -89,83 +121,33 @@ func (service *Service) PostNewKey(w http.ResponseWriter, r *http.Request) *outp
 	// end validation
 
 	// add additional details to the payload before saving
-	payload.ApiKey, err = randomness.GenerateApiKey()
+	apiKeySecret, err := randomness.GenerateApiKey()
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrInternal(err)
 	}
-	payload.ApiKeyViaUrl = false
-	payload.CreatedAt = int(time.Now().Unix())
-	payload.UpdatedAt = payload.CreatedAt
-
-	// save new key to storage, which also returns the new key id
-	newKey, err := service.storage.PostNewKey(payload)
-	if err != nil {
-		service.logger.Error(err)
-		return output.JsonErrStorageGeneric(err)
-	}
-
-	// write response
-	response := &privateKeyResponse{}
-	response.StatusCode = http.StatusOK
-	response.Message = "created private key"
-	response.PrivateKey = newKey.detailedResponse()
-
-	// return response to client
-	err = service.output.WriteJSON(w, response)
-	if err != nil {
-		service.logger.Errorf("failed to write json (%s)", err)
-		return output.JsonErrWriteJsonError(err)
-	}
-
-	return nil
-}
-
-// StageNewApiKey generates a new API key and places it in the keys
-func (service *Service) StageNewApiKey(w http.ResponseWriter, r *http.Request) *output.JsonError {
-	// get id param
-	idParam := httprouter.ParamsFromContext(r.Context()).ByName("id")
-	keyId, err := strconv.Atoi(idParam)
-	if err != nil {
-		service.logger.Debug(err)
-		return output.JsonErrValidationFailed(err)
-	}
-
-	// validation
-	// get key (validate exists)
-	key, outErr := service.getKey(keyId)
-	if outErr != nil {
-		return outErr
-	}
-
-	// verify new api key is empty
-	if key.ApiKeyNew != "" {
-		err = errors.New("new api key already exists")
-		service.logger.Debug(err)
-		return output.JsonErrValidationFailed(err)
-	}
-	// validation -- end
-
-	// generate new api key
-	newApiKey, err := randomness.GenerateApiKey()
+	payload.ApiKeyHash, err = apikeyhash.Hash(apiKeySecret)
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrInternal(err)
 	}
+	payload.CreatedAt = int(time.Now().Unix())
+	payload.UpdatedAt = payload.CreatedAt
 
-	// update storage
-	err = service.storage.PutKeyNewApiKey(keyId, newApiKey, int(time.Now().Unix()))
+	// save new key to storage, which also returns the new key id and its api key id
+	newKey, apiKeyId, err := service.storage.PostNewKey(payload)
 	if err != nil {
 		service.logger.Error(err)
 		return output.JsonErrStorageGeneric(err)
 	}
-	key.ApiKeyNew = newApiKey
 
 	// write response
 	response := &privateKeyResponse{}
 	response.StatusCode = http.StatusOK
-	response.Message = "private key new api key created"
-	response.PrivateKey = key.detailedResponse()
+	response.Message = "created private key"
+	response.PrivateKey = newKey.detailedResponse()
+	// only time the plaintext api key is available
+	response.PrivateKey.ApiKey = apikeyhash.Format(apiKeyId, apiKeySecret)
 
 	// return response to client
 	err = service.output.WriteJSON(w, response)
@@ -16,10 +16,7 @@ type UpdatePayload struct {
 	ID             int     `json:"-"`
 	Name           *string `json:"name"`
 	Description    *string `json:"description"`
-	ApiKey         *string `json:"api_key"`
-	ApiKeyNew      *string `json:"api_key_new"`
 	ApiKeyDisabled *bool   `json:"api_key_disabled"`
-	ApiKeyViaUrl   *bool   `json:"api_key_via_url"`
 	UpdatedAt      int     `json:"-"`
 }
 
@@ -53,17 +50,7 @@ func (service *Service) PutKeyUpdate(w http.ResponseWriter, r *http.Request) *ou
 		service.logger.Debug(ErrNameBad)
 		return output.JsonErrValidationFailed(ErrNameBad)
 	}
-	// api key must be at least 10 characters long
-	if payload.ApiKey != nil && len(*payload.ApiKey) < 10 {
-		service.logger.Debug(ErrApiKeyBad)
-		return output.JsonErrValidationFailed(ErrApiKeyBad)
-	}
-	// api key new must be at least 10 characters long
-	if payload.ApiKeyNew != nil && *payload.ApiKeyNew != "" && len(*payload.ApiKeyNew) < 10 {
-		service.logger.Debug(ErrApiKeyNewBad)
-		return output.JsonErrValidationFailed(ErrApiKeyNewBad)
-	}
-	// Description, ApiKeyDisabled, and ApiKeyViaUrl do not need validation
+	// Description and ApiKeyDisabled do not need validation
 	// end validation
 
 	// add additional details to the payload before saving
@@ -14,10 +14,7 @@ type Key struct {
 	Description    string
 	Algorithm      key_crypto.Algorithm
 	Pem            string
-	ApiKey         string
-	ApiKeyNew      string
 	ApiKeyDisabled bool
-	ApiKeyViaUrl   bool
 	LastAccess     time.Time
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
@@ -31,7 +28,6 @@ type KeySummaryResponse struct {
 	Description    string               `json:"description"`
 	Algorithm      key_crypto.Algorithm `json:"algorithm"`
 	ApiKeyDisabled bool                 `json:"api_key_disabled"`
-	ApiKeyViaUrl   bool                 `json:"api_key_via_url"`
 	LastAccess     int64                `json:"last_access"`
 }
 
@@ -42,7 +38,6 @@ func (key Key) SummaryResponse() KeySummaryResponse {
 		Description:    key.Description,
 		Algorithm:      key.Algorithm,
 		ApiKeyDisabled: key.ApiKeyDisabled,
-		ApiKeyViaUrl:   key.ApiKeyViaUrl,
 		LastAccess:     key.LastAccess.Unix(),
 	}
 }
@@ -51,8 +46,9 @@ func (key Key) SummaryResponse() KeySummaryResponse {
 // fields that can be returned as JSON
 type keyDetailedResponse struct {
 	KeySummaryResponse
-	ApiKey    string `json:"api_key"`
-	ApiKeyNew string `json:"api_key_new,omitempty"`
+	// api keys are only stored hashed, so the plaintext is only populated
+	// in the response to the request that generated the key
+	ApiKey    string `json:"api_key,omitempty"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
 	// exclude PEM
@@ -62,8 +58,6 @@ func (key Key) detailedResponse() keyDetailedResponse {
 	return keyDetailedResponse{
 		KeySummaryResponse: key.SummaryResponse(),
 
-		ApiKey:    key.ApiKey,
-		ApiKeyNew: key.ApiKeyNew,
 		CreatedAt: key.CreatedAt.Unix(),
 		UpdatedAt: key.UpdatedAt.Unix(),
 	}
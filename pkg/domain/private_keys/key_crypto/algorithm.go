@@ -20,6 +20,11 @@ const (
 	rsa4096
 	ecdsap256
 	ecdsap384
+	ecdsap521
+	rsapss2048
+	rsapss3072
+	rsapss4096
+	ed25519Key
 )
 
 // Algorithm custom JSON Marshal (turns the Algorithm into exportable AlgorithmDetails
@@ -64,3 +69,15 @@ func (alg Algorithm) CsrSigningAlg() x509.SignatureAlgorithm {
 func (alg Algorithm) StorageValue() string {
 	return alg.details().storageValue
 }
+
+// sameKey returns true if keys of alg and otherAlg are the same kind of key, even if
+// the Algorithms differ in how they sign (e.g., RSA and RSA-PSS of the same size)
+func (alg Algorithm) sameKey(otherAlg Algorithm) bool {
+	details := alg.details()
+	otherDetails := otherAlg.details()
+
+	return details.keyType != "" &&
+		details.keyType == otherDetails.keyType &&
+		details.bitLen == otherDetails.bitLen &&
+		details.ellipticCurveName == otherDetails.ellipticCurveName
+}
@@ -11,8 +11,9 @@ type algorithmDetails struct {
 	storageValue          string
 	name                  string
 	csrSignatureAlgorithm x509.SignatureAlgorithm
-	keyType               string                // rsa or ecdsa
+	keyType               string                // rsa, ecdsa, or ed25519
 	bitLen                int                   // rsa
+	rsaPss                bool                  // rsa (csr signed with rsa-pss)
 	ellipticCurveName     string                // ecdsa
 	ellipticCurveFunc     func() elliptic.Curve // ecdsa
 }
@@ -42,6 +43,33 @@ var keyAlgorithmDetails = []algorithmDetails{
 		keyType:               "RSA",
 		bitLen:                4096,
 	},
+	{
+		algorithm:             rsapss2048,
+		storageValue:          "rsapss2048",
+		name:                  "RSA-PSS 2048-bit",
+		csrSignatureAlgorithm: x509.SHA256WithRSAPSS,
+		keyType:               "RSA",
+		bitLen:                2048,
+		rsaPss:                true,
+	},
+	{
+		algorithm:             rsapss3072,
+		storageValue:          "rsapss3072",
+		name:                  "RSA-PSS 3072-bit",
+		csrSignatureAlgorithm: x509.SHA256WithRSAPSS,
+		keyType:               "RSA",
+		bitLen:                3072,
+		rsaPss:                true,
+	},
+	{
+		algorithm:             rsapss4096,
+		storageValue:          "rsapss4096",
+		name:                  "RSA-PSS 4096-bit",
+		csrSignatureAlgorithm: x509.SHA256WithRSAPSS,
+		keyType:               "RSA",
+		bitLen:                4096,
+		rsaPss:                true,
+	},
 	{
 		algorithm:             ecdsap256,
 		storageValue:          "ecdsap256",
@@ -60,6 +88,22 @@ var keyAlgorithmDetails = []algorithmDetails{
 		ellipticCurveName:     "P-384",
 		ellipticCurveFunc:     elliptic.P384,
 	},
+	{
+		algorithm:             ecdsap521,
+		storageValue:          "ecdsap521",
+		name:                  "ECDSA P-521",
+		csrSignatureAlgorithm: x509.ECDSAWithSHA512,
+		keyType:               "EC",
+		ellipticCurveName:     "P-521",
+		ellipticCurveFunc:     elliptic.P521,
+	},
+	{
+		algorithm:             ed25519Key,
+		storageValue:          "ed25519",
+		name:                  "Ed25519",
+		csrSignatureAlgorithm: x509.PureEd25519,
+		keyType:               "Ed25519",
+	},
 }
 
 // ListOfAlgorithms() returns a slice of all Algorithms
@@ -84,10 +128,11 @@ func AlgorithmByStorageValue(value string) Algorithm {
 }
 
 // rsaAlgorithmByBits returns the Algorithm corresponding to an RSA
-// key of the specified bit length.
+// key of the specified bit length. RSA-PSS keys are ordinary RSA keys, so
+// the (non-PSS) RSA Algorithm is always returned.
 func rsaAlgorithmByBits(bits int) Algorithm {
 	for i := range keyAlgorithmDetails {
-		if (keyAlgorithmDetails[i].keyType == "RSA") && (keyAlgorithmDetails[i].bitLen == bits) {
+		if (keyAlgorithmDetails[i].keyType == "RSA") && (keyAlgorithmDetails[i].bitLen == bits) && !keyAlgorithmDetails[i].rsaPss {
 			return keyAlgorithmDetails[i].algorithm
 		}
 	}
@@ -106,3 +151,15 @@ func ecdsaAlgorithmByCurve(curveName string) Algorithm {
 
 	return UnknownAlgorithm
 }
+
+// algorithmByKeyType returns the Algorithm corresponding to a key type that
+// has only one Algorithm (e.g., Ed25519)
+func algorithmByKeyType(keyType string) Algorithm {
+	for i := range keyAlgorithmDetails {
+		if keyAlgorithmDetails[i].keyType == keyType {
+			return keyAlgorithmDetails[i].algorithm
+		}
+	}
+
+	return UnknownAlgorithm
+}
@@ -0,0 +1,68 @@
+package key_crypto
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func TestKeyCrypto_GenerateAndSignCsr(t *testing.T) {
+	for _, alg := range ListOfAlgorithms() {
+		t.Run(alg.StorageValue(), func(t *testing.T) {
+			keyPem, err := alg.GeneratePrivateKeyPem()
+			if err != nil {
+				t.Fatalf("failed to generate key (%s)", err)
+			}
+
+			// decodes as the same algorithm
+			key, err := PemStringToKey(keyPem, alg)
+			if err != nil {
+				t.Fatalf("failed to decode generated key (%s)", err)
+			}
+
+			// uploaded pem is identified as the same kind of key
+			_, identifiedAlg, err := ValidateAndStandardizeKeyPem(keyPem)
+			if err != nil {
+				t.Fatalf("failed to validate generated key (%s)", err)
+			}
+			if !identifiedAlg.sameKey(alg) {
+				t.Errorf("generated key identified as %s", identifiedAlg.StorageValue())
+			}
+
+			// csr signs and verifies with the algorithm's signature algorithm
+			template := &x509.CertificateRequest{
+				SignatureAlgorithm: alg.CsrSigningAlg(),
+				Subject:            pkix.Name{CommonName: "example.com"},
+				DNSNames:           []string{"example.com"},
+			}
+			csrDer, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+			if err != nil {
+				t.Fatalf("failed to create csr (%s)", err)
+			}
+			csr, err := x509.ParseCertificateRequest(csrDer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if csr.SignatureAlgorithm != alg.CsrSigningAlg() {
+				t.Errorf("csr signature algorithm %s, expected %s", csr.SignatureAlgorithm, alg.CsrSigningAlg())
+			}
+			err = csr.CheckSignature()
+			if err != nil {
+				t.Errorf("csr signature invalid (%s)", err)
+			}
+		})
+	}
+
+	// an rsa key doesn't decode as a different size or type
+	keyPem, err := AlgorithmByStorageValue("rsa2048").GeneratePrivateKeyPem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range []string{"rsa3072", "rsapss4096", "ecdsap256", "ed25519"} {
+		_, err = PemStringToKey(keyPem, AlgorithmByStorageValue(value))
+		if err == nil {
+			t.Errorf("rsa2048 key decoded as %s", value)
+		}
+	}
+}
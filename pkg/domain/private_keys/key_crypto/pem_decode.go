@@ -3,6 +3,7 @@ package key_crypto
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -191,6 +192,16 @@ func pemStringDecode(keyPem string, alg Algorithm) (privKey crypto.PrivateKey, i
 			// success!
 			privKey = pkcs8Key
 
+		case ed25519.PrivateKey:
+			// find algorithm in list of supported algorithms
+			identifiedAlg = algorithmByKeyType("Ed25519")
+			if identifiedAlg == UnknownAlgorithm {
+				return nil, UnknownAlgorithm, errUnsupportedPem
+			}
+
+			// success!
+			privKey = pkcs8Key
+
 		default:
 			return nil, UnknownAlgorithm, errUnsupportedPem
 		}
@@ -199,9 +210,13 @@ func pemStringDecode(keyPem string, alg Algorithm) (privKey crypto.PrivateKey, i
 		return nil, UnknownAlgorithm, errUnsupportedPem
 	}
 
-	// if an alg was specified in function call, verify the pem matches
+	// if an alg was specified in function call, verify the pem matches (an RSA-PSS
+	// key is identified as RSA since the key itself is the same)
 	if alg != UnknownAlgorithm && alg != identifiedAlg {
-		return nil, UnknownAlgorithm, errMismatchAlgorithm
+		if !alg.sameKey(identifiedAlg) {
+			return nil, UnknownAlgorithm, errMismatchAlgorithm
+		}
+		identifiedAlg = alg
 	}
 
 	return privKey, identifiedAlg, nil
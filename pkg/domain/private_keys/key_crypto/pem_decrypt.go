@@ -0,0 +1,241 @@
+package key_crypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/pbkdf2"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"strings"
+)
+
+var (
+	ErrPassphraseRequired  = errors.New("private key is encrypted and requires a passphrase")
+	ErrPassphraseIncorrect = errors.New("private key passphrase is incorrect (or the key is corrupt)")
+
+	errUnsupportedEncryption = errors.New("unsupported private key encryption (only PBES2 with PBKDF2 and AES-CBC or DES-EDE3-CBC is supported)")
+)
+
+// encrypted pkcs8 oids (RFC 8018)
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// encryptedPrivateKeyInfo is the outer structure of an encrypted pkcs8 key (RFC 5958)
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// pbes2Params are the parameters of the PBES2 encryption scheme
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of the PBKDF2 key derivation function. If Prf
+// is omitted, the default is hmacWithSHA1.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	Prf            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// DecryptKeyPem decrypts an encrypted private key pem using passphrase and returns
+// the unencrypted key pem. Encrypted pkcs8 ("ENCRYPTED PRIVATE KEY") and legacy
+// encrypted pem (a "Proc-Type: 4,ENCRYPTED" header) are supported. If the pem is not
+// encrypted, it is returned unchanged and the passphrase is ignored.
+func DecryptKeyPem(keyPem string, passphrase string) (string, error) {
+	pemBlock, _ := pem.Decode([]byte(strings.TrimSpace(keyPem)))
+	if pemBlock == nil {
+		return "", errUnsupportedPem
+	}
+
+	var keyBlock *pem.Block
+	switch {
+	case pemBlock.Type == "ENCRYPTED PRIVATE KEY":
+		if passphrase == "" {
+			return "", ErrPassphraseRequired
+		}
+
+		der, err := decryptPKCS8(pemBlock.Bytes, passphrase)
+		if err != nil {
+			return "", err
+		}
+		keyBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	// legacy encryption is deprecated (insecure) but still common, so it is only read
+	case x509.IsEncryptedPEMBlock(pemBlock):
+		if passphrase == "" {
+			return "", ErrPassphraseRequired
+		}
+
+		der, err := x509.DecryptPEMBlock(pemBlock, []byte(passphrase))
+		if err != nil {
+			return "", ErrPassphraseIncorrect
+		}
+		keyBlock = &pem.Block{Type: pemBlock.Type, Bytes: der}
+
+	default:
+		// not encrypted
+		return keyPem, nil
+	}
+
+	// confirm the decrypted key is usable (a wrong passphrase can occasionally
+	// produce valid padding)
+	keyPem = string(pem.EncodeToMemory(keyBlock))
+	_, _, err := pemStringDecode(keyPem, UnknownAlgorithm)
+	if err != nil {
+		return "", ErrPassphraseIncorrect
+	}
+
+	return keyPem, nil
+}
+
+// decryptPKCS8 decrypts the der of an encrypted pkcs8 key and returns the der of the
+// unencrypted pkcs8 key
+func decryptPKCS8(der []byte, passphrase string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil || len(rest) != 0 {
+		return nil, errUnsupportedPem
+	}
+
+	// only PBES2 is supported (PBES1 uses obsolete ciphers)
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errUnsupportedEncryption
+	}
+	var params pbes2Params
+	_, err = asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, &params)
+	if err != nil {
+		return nil, errUnsupportedEncryption
+	}
+
+	// cipher
+	var newCipher func([]byte) (cipher.Block, error)
+	var keyLen int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		newCipher, keyLen = aes.NewCipher, 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		newCipher, keyLen = aes.NewCipher, 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		newCipher, keyLen = aes.NewCipher, 32
+	case params.EncryptionScheme.Algorithm.Equal(oidDESEDE3CBC):
+		newCipher, keyLen = des.NewTripleDESCipher, 24
+	default:
+		return nil, errUnsupportedEncryption
+	}
+	var iv []byte
+	_, err = asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv)
+	if err != nil {
+		return nil, errUnsupportedEncryption
+	}
+
+	// key derivation
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errUnsupportedEncryption
+	}
+	var kdfParams pbkdf2Params
+	_, err = asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams)
+	if err != nil || kdfParams.IterationCount < 1 {
+		return nil, errUnsupportedEncryption
+	}
+	if kdfParams.KeyLength != 0 && kdfParams.KeyLength != keyLen {
+		return nil, errUnsupportedEncryption
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.Prf.Algorithm) == 0 || kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA384):
+		prf = sha512.New384
+	case kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, errUnsupportedEncryption
+	}
+
+	key, err := pbkdf2.Key(prf, passphrase, kdfParams.Salt, kdfParams.IterationCount, keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	// decrypt
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errUnsupportedPem
+	}
+
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// remove and verify padding (RFC 8018 s 6.1.1), bad padding means wrong passphrase
+	padLen := int(plain[len(plain)-1])
+	if padLen < 1 || padLen > block.BlockSize() || !bytes.Equal(plain[len(plain)-padLen:], bytes.Repeat([]byte{byte(padLen)}, padLen)) {
+		return nil, ErrPassphraseIncorrect
+	}
+
+	return plain[:len(plain)-padLen], nil
+}
+
+// keyToPem returns the pem of a private key, using the same format as keys that are
+// generated by this app
+func keyToPem(privateKey crypto.PrivateKey) (string, error) {
+	var block *pem.Block
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}
+
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+
+	case ed25519.PrivateKey:
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+
+	default:
+		return "", errUnsupportedPem
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
@@ -0,0 +1,64 @@
+package key_crypto
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var errPkcs12NoCert = errors.New("pkcs12 does not contain a certificate")
+
+// DecodePkcs12 decodes pkcs12 (pfx) data using passphrase. It returns the pem of the
+// private key (in the same format as keys generated by this app) and the pem of the
+// certificate chain, ordered leaf first and then each issuer that is present.
+func DecodePkcs12(pfxData []byte, passphrase string) (keyPem string, certChainPem string, err error) {
+	privateKey, leaf, caCerts, err := pkcs12.DecodeChain(pfxData, passphrase)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return "", "", ErrPassphraseIncorrect
+		}
+		return "", "", err
+	}
+	if leaf == nil {
+		return "", "", errPkcs12NoCert
+	}
+
+	keyPem, err = keyToPem(privateKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	// chain order isn't specified in pkcs12, so follow issuers from the leaf
+	chain := []*x509.Certificate{leaf}
+	remaining := caCerts
+	for len(remaining) > 0 {
+		current := chain[len(chain)-1]
+		if bytes.Equal(current.RawIssuer, current.RawSubject) {
+			// self signed (root)
+			break
+		}
+
+		found := false
+		for i, ca := range remaining {
+			if bytes.Equal(ca.RawSubject, current.RawIssuer) {
+				chain = append(chain, ca)
+				remaining = append(remaining[:i:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	certChainPem = ""
+	for _, cert := range chain {
+		certChainPem += string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+
+	return keyPem, certChainPem, nil
+}
@@ -23,11 +23,9 @@ type Storage interface {
 	GetOneKeyById(id int) (Key, error)
 	GetOneKeyByName(name string) (Key, error)
 
-	PostNewKey(NewPayload) (Key, error)
+	PostNewKey(NewPayload) (key Key, apiKeyId int, err error)
 
 	PutKeyUpdate(UpdatePayload) (Key, error)
-	PutKeyApiKey(keyId int, apiKey string, updateTimeUnix int) (err error)
-	PutKeyNewApiKey(keyId int, newApiKey string, updateTimeUnix int) error
 
 	DeleteKey(int) error
 
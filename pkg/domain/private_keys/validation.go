@@ -12,11 +12,10 @@ var (
 	ErrIdBad   = errors.New("key id is invalid")
 	ErrNameBad = errors.New("private key name is not valid")
 
-	ErrApiKeyBad    = errors.New("api key is not valid (must be at least 10 chars in length)")
-	ErrApiKeyNewBad = errors.New("api key (new) is not valid (must be at least 10 chars in length)")
-
 	ErrKeyOptionNone     = errors.New("no key option method specified")
 	ErrKeyOptionMultiple = errors.New("multiple key option methods specified")
+	ErrPassphraseUnused  = errors.New("passphrase is only used when importing a pem or pkcs12 key")
+	ErrPkcs12Bad         = errors.New("pkcs12 is not valid base64")
 )
 
 // getKey returns the Key for the specified id or an
@@ -74,7 +73,7 @@ func (service *Service) NameValid(keyName string, keyId *int) bool {
 }
 
 // GetAvailableKeys returns a list of all available keys; storage should
-// return keys that exist but are not already in use by an account or a
+// return keys that exist but are not already in use by an account, a private ca, or a
 // certificate
 // TODO: Maybe move business logic here instead of in storage
 func (service *Service) AvailableKeys() (keys []Key, err error) {
@@ -82,7 +81,7 @@ func (service *Service) AvailableKeys() (keys []Key, err error) {
 }
 
 // KeyAvailable returns true if the specified keyId is available for
-// use (i.e. not already in use by an account or a certificate)
+// use (i.e. not already in use by an account, a certificate, or a private ca)
 func (service *Service) KeyAvailable(keyId int) bool {
 	// get available keys list
 	keys, err := service.AvailableKeys()
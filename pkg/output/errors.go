@@ -39,6 +39,10 @@ func JsonErrInternal(err error) *JsonError {
 
 var JsonErrUnauthorized = &JsonError{StatusCode: 401, Message: "unauthorized"}
 
+var JsonErrForbidden = &JsonError{StatusCode: 403, Message: "forbidden"}
+
+var JsonErrTooManyRequests = &JsonError{StatusCode: 429, Message: "too many requests"}
+
 // storage
 func JsonErrStorageGeneric(err error) *JsonError {
 	return &JsonError{
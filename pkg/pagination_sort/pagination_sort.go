@@ -56,6 +56,9 @@ var validFieldNames = []string{
 	"subject",
 	"valid_to",
 	"last_access",
+	"username",
+	"role",
+	"user_type",
 }
 
 // ParseRequestToQuery returns pagination and sorting params
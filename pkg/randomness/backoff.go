@@ -7,13 +7,16 @@ import (
 	"github.com/cenkalti/backoff/v4"
 )
 
+// ACMEPollInterval is the initial wait between checks of a task the remote ACME service
+// is working on. RFC8555 mentions 5 - 10 seconds when discussing waiting on challenges,
+// so use 7 seconds. It is a var so tests against a local ACME server can shorten it.
+var ACMEPollInterval = 7 * time.Second
+
 // BackoffACME returns a backoff to be used for ACME operations while waiting for a
-// remote ACME service to finish working on some task
-// RFC8555 mentions 5 - 10 seconds when discussing waiting on challenges, so use 7
-// seconds as the starting point
+// remote ACME service to finish working on some task, starting at ACMEPollInterval
 func BackoffACME(shutdownCtx context.Context) backoff.BackOffContext {
 	bo := backoff.NewExponentialBackOff()
-	bo.InitialInterval = 7 * time.Second
+	bo.InitialInterval = ACMEPollInterval
 	bo.RandomizationFactor = 0.4
 	bo.Multiplier = 1.4
 	bo.MaxInterval = 60 * time.Second
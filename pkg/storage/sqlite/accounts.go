@@ -20,18 +20,23 @@ type accountDb struct {
 	kid             string
 }
 
-func (acct accountDb) toAccount() acme_accounts.Account {
+func (acct accountDb) toAccount(ke *keyEncryption) (acme_accounts.Account, error) {
+	accountKey, err := acct.accountKeyDb.toKey(ke)
+	if err != nil {
+		return acme_accounts.Account{}, err
+	}
+
 	return acme_accounts.Account{
 		ID:          acct.id,
 		Name:        acct.name,
 		Description: acct.description,
 		AcmeServer:  acct.accountServerDb.toServer(),
-		AccountKey:  acct.accountKeyDb.toKey(),
+		AccountKey:  accountKey,
 		Status:      acct.status,
 		Email:       acct.email,
 		AcceptedTos: acct.acceptedTos,
 		CreatedAt:   acct.createdAt,
 		UpdatedAt:   acct.updatedAt,
 		Kid:         acct.kid,
-	}
+	}, nil
 }
@@ -54,8 +54,8 @@ func (store *Storage) GetAllAccounts(q pagination_sort.Query) (accounts []acme_a
 		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
 		aserv.updated_at,
 
-		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
-		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at,
+		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, 
+		pk.api_key_disabled, pk.last_access, pk.created_at, pk.updated_at,
 
 		count(*) OVER() AS full_count
 	FROM
@@ -109,10 +109,7 @@ func (store *Storage) GetAllAccounts(q pagination_sort.Query) (accounts []acme_a
 			&oneAccount.accountKeyDb.description,
 			&oneAccount.accountKeyDb.algorithmValue,
 			&oneAccount.accountKeyDb.pem,
-			&oneAccount.accountKeyDb.apiKey,
-			&oneAccount.accountKeyDb.apiKeyNew,
 			&oneAccount.accountKeyDb.apiKeyDisabled,
-			&oneAccount.accountKeyDb.apiKeyViaUrl,
 			&oneAccount.accountKeyDb.lastAccess,
 			&oneAccount.accountKeyDb.createdAt,
 			&oneAccount.accountKeyDb.updatedAt,
@@ -124,7 +121,10 @@ func (store *Storage) GetAllAccounts(q pagination_sort.Query) (accounts []acme_a
 		}
 
 		// convert to Account
-		convertedAccount := oneAccount.toAccount()
+		convertedAccount, err := oneAccount.toAccount(store.keyEncryption)
+		if err != nil {
+			return nil, 0, err
+		}
 
 		allAccounts = append(allAccounts, convertedAccount)
 	}
@@ -155,8 +155,8 @@ func (store *Storage) getOneAccount(id int, name string) (acme_accounts.Account,
 		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
 		aserv.updated_at,
 
-		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new, 
-		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at
+		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, 
+		pk.api_key_disabled, pk.last_access, pk.created_at, pk.updated_at
 	FROM
 		acme_accounts aa
 		LEFT JOIN acme_servers aserv on (aa.acme_server_id = aserv.id)
@@ -192,10 +192,7 @@ func (store *Storage) getOneAccount(id int, name string) (acme_accounts.Account,
 		&oneAccount.accountKeyDb.description,
 		&oneAccount.accountKeyDb.algorithmValue,
 		&oneAccount.accountKeyDb.pem,
-		&oneAccount.accountKeyDb.apiKey,
-		&oneAccount.accountKeyDb.apiKeyNew,
 		&oneAccount.accountKeyDb.apiKeyDisabled,
-		&oneAccount.accountKeyDb.apiKeyViaUrl,
 		&oneAccount.accountKeyDb.lastAccess,
 		&oneAccount.accountKeyDb.createdAt,
 		&oneAccount.accountKeyDb.updatedAt,
@@ -209,5 +206,5 @@ func (store *Storage) getOneAccount(id int, name string) (acme_accounts.Account,
 		return acme_accounts.Account{}, err
 	}
 
-	return oneAccount.toAccount(), nil
+	return oneAccount.toAccount(store.keyEncryption)
 }
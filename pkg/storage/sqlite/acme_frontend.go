@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/acme_frontend"
+)
+
+// acmeFrontendEabKeyDb is a single acme front-end eab key, as database table fields
+// corresponds to acme_frontend.EabKey
+type acmeFrontendEabKeyDb struct {
+	id            int
+	certificateId int
+	keyId         string
+	hmacKey       string // encrypted if key encryption is enabled
+	createdAt     int
+}
+
+// toEabKey maps the database eab key to the acme_frontend EabKey, decrypting the hmac
+// key with ke if it is encrypted
+func (eab acmeFrontendEabKeyDb) toEabKey(ke *keyEncryption) (acme_frontend.EabKey, error) {
+	hmacKey, err := ke.decrypt(secretEabHmacKey, eab.id, eab.hmacKey)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	return acme_frontend.EabKey{
+		ID:            eab.id,
+		CertificateID: eab.certificateId,
+		KeyID:         eab.keyId,
+		HmacKey:       hmacKey,
+		CreatedAt:     eab.createdAt,
+	}, nil
+}
+
+// acmeFrontendAccountDb is a single acme front-end account, as database table fields
+// corresponds to acme_frontend.Account
+type acmeFrontendAccountDb struct {
+	id            int
+	certificateId int
+	jwk           string
+	jwkThumbprint string
+	status        string
+	contact       jsonStringSlice // stored as json array
+	createdAt     int
+	updatedAt     int
+}
+
+func (acct acmeFrontendAccountDb) toAccount() acme_frontend.Account {
+	return acme_frontend.Account{
+		ID:            acct.id,
+		CertificateID: acct.certificateId,
+		Jwk:           acct.jwk,
+		JwkThumbprint: acct.jwkThumbprint,
+		Status:        acct.status,
+		Contact:       acct.contact.toSlice(),
+		CreatedAt:     acct.createdAt,
+		UpdatedAt:     acct.updatedAt,
+	}
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// DeleteAcmeFrontendByCert deletes the eab key and all of the acme front-end accounts
+// of the specified certificate
+func (store *Storage) DeleteAcmeFrontendByCert(certId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// eab key
+	query := `
+	DELETE FROM
+		acme_frontend_eab_keys
+	WHERE
+		certificate_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, certId)
+	if err != nil {
+		return err
+	}
+
+	// accounts
+	query = `
+	DELETE FROM
+		acme_frontend_accounts
+	WHERE
+		certificate_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, certId)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
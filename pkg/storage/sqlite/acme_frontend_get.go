@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/acme_frontend"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetAcmeFrontendEabKeyByCert returns the eab key for the specified certificate
+func (store *Storage) GetAcmeFrontendEabKeyByCert(certId int) (acme_frontend.EabKey, error) {
+	return store.getOneAcmeFrontendEabKey(certId, "")
+}
+
+// GetAcmeFrontendEabKeyByKeyId returns the eab key with the specified key id
+func (store *Storage) GetAcmeFrontendEabKeyByKeyId(keyId string) (acme_frontend.EabKey, error) {
+	return store.getOneAcmeFrontendEabKey(-1, keyId)
+}
+
+// getOneAcmeFrontendEabKey returns an eab key based on either its certificate id or
+// its unique key id
+func (store *Storage) getOneAcmeFrontendEabKey(certId int, keyId string) (acme_frontend.EabKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, certificate_id, key_id, hmac_key, created_at
+	FROM
+		acme_frontend_eab_keys
+	WHERE certificate_id = $1 OR key_id = $2
+	ORDER BY id`
+
+	row := store.db.QueryRowContext(ctx, query, certId, keyId)
+
+	var oneKey acmeFrontendEabKeyDb
+
+	err := row.Scan(
+		&oneKey.id,
+		&oneKey.certificateId,
+		&oneKey.keyId,
+		&oneKey.hmacKey,
+		&oneKey.createdAt,
+	)
+
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return acme_frontend.EabKey{}, err
+	}
+
+	return oneKey.toEabKey(store.keyEncryption)
+}
+
+// GetAcmeFrontendAccountById returns an acme front-end account based on its unique id
+func (store *Storage) GetAcmeFrontendAccountById(id int) (acme_frontend.Account, error) {
+	return store.getOneAcmeFrontendAccount(id, "")
+}
+
+// GetAcmeFrontendAccountByThumbprint returns an acme front-end account based on the unique
+// thumbprint of its jwk
+func (store *Storage) GetAcmeFrontendAccountByThumbprint(thumbprint string) (acme_frontend.Account, error) {
+	return store.getOneAcmeFrontendAccount(-1, thumbprint)
+}
+
+// getOneAcmeFrontendAccount returns an acme front-end account based on either its unique
+// id or its unique jwk thumbprint
+func (store *Storage) getOneAcmeFrontendAccount(id int, thumbprint string) (acme_frontend.Account, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, certificate_id, jwk, jwk_thumbprint, status, contact, created_at, updated_at
+	FROM
+		acme_frontend_accounts
+	WHERE id = $1 OR jwk_thumbprint = $2
+	ORDER BY id`
+
+	row := store.db.QueryRowContext(ctx, query, id, thumbprint)
+
+	var oneAcct acmeFrontendAccountDb
+
+	err := row.Scan(
+		&oneAcct.id,
+		&oneAcct.certificateId,
+		&oneAcct.jwk,
+		&oneAcct.jwkThumbprint,
+		&oneAcct.status,
+		&oneAcct.contact,
+		&oneAcct.createdAt,
+		&oneAcct.updatedAt,
+	)
+
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return acme_frontend.Account{}, err
+	}
+
+	return oneAcct.toAccount(), nil
+}
+
+// GetAcmeFrontendAccountsByCert returns all of the acme front-end accounts bound to the
+// specified certificate
+func (store *Storage) GetAcmeFrontendAccountsByCert(certId int) ([]acme_frontend.Account, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, certificate_id, jwk, jwk_thumbprint, status, contact, created_at, updated_at
+	FROM
+		acme_frontend_accounts
+	WHERE certificate_id = $1
+	ORDER BY id`
+
+	rows, err := store.db.QueryContext(ctx, query, certId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []acme_frontend.Account
+	for rows.Next() {
+		var oneAcct acmeFrontendAccountDb
+		err = rows.Scan(
+			&oneAcct.id,
+			&oneAcct.certificateId,
+			&oneAcct.jwk,
+			&oneAcct.jwkThumbprint,
+			&oneAcct.status,
+			&oneAcct.contact,
+			&oneAcct.createdAt,
+			&oneAcct.updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, oneAcct.toAccount())
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/acme_frontend"
+	"context"
+)
+
+// PostAcmeFrontendEabKey saves an eab key for a certificate, replacing the certificate's
+// existing key (if any)
+func (store *Storage) PostAcmeFrontendEabKey(payload acme_frontend.EabKey) (acme_frontend.EabKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+	defer tx.Rollback()
+
+	// remove old key
+	query := `
+	DELETE FROM
+		acme_frontend_eab_keys
+	WHERE
+		certificate_id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, payload.CertificateID)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	// insert the new key (hmac key is saved once the id is known)
+	query = `
+	INSERT INTO acme_frontend_eab_keys (certificate_id, key_id, hmac_key, created_at)
+	VALUES ($1, $2, '', $3)
+	RETURNING id
+	`
+
+	id := -1
+	err = tx.QueryRowContext(ctx, query,
+		payload.CertificateID,
+		payload.KeyID,
+		payload.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	// hmac key (encrypted, if enabled)
+	err = putSecretTx(ctx, tx, store.keyEncryption, secretEabHmacKey, id, payload.HmacKey)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	// get new key to return
+	newKey, err := store.GetAcmeFrontendEabKeyByCert(payload.CertificateID)
+	if err != nil {
+		return acme_frontend.EabKey{}, err
+	}
+
+	return newKey, nil
+}
+
+// PostNewAcmeFrontendAccount inserts a new acme front-end account into the db
+func (store *Storage) PostNewAcmeFrontendAccount(payload acme_frontend.NewAccountPayload) (acme_frontend.Account, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO acme_frontend_accounts (certificate_id, jwk, jwk_thumbprint, status, contact,
+		created_at, updated_at)
+	VALUES ($1, $2, $3, 'valid', $4, $5, $6)
+	RETURNING id
+	`
+
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		payload.CertificateID,
+		payload.Jwk,
+		payload.JwkThumbprint,
+		makeJsonStringSlice(payload.Contact),
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return acme_frontend.Account{}, err
+	}
+
+	// get new account to return
+	newAcct, err := store.GetAcmeFrontendAccountById(id)
+	if err != nil {
+		return acme_frontend.Account{}, err
+	}
+
+	return newAcct, nil
+}
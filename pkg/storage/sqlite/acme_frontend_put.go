@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/acme_frontend"
+	"context"
+)
+
+// PutAcmeFrontendAccount updates the status and/or contact of an acme front-end account
+func (store *Storage) PutAcmeFrontendAccount(payload acme_frontend.AccountUpdatePayload) (acme_frontend.Account, error) {
+	// convert contact to storage form
+	var contact *jsonStringSlice
+	if payload.Contact != nil {
+		jss := makeJsonStringSlice(*payload.Contact)
+		contact = &jss
+	}
+
+	// database update
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		acme_frontend_accounts
+	SET
+		status = case when $1 is null then status else $1 end,
+		contact = case when $2 is null then contact else $2 end,
+		updated_at = $3
+	WHERE
+		id = $4
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		payload.Status,
+		contact,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+
+	if err != nil {
+		return acme_frontend.Account{}, err
+	}
+
+	// get updated account to return
+	updatedAcct, err := store.GetAcmeFrontendAccountById(payload.ID)
+	if err != nil {
+		return acme_frontend.Account{}, err
+	}
+
+	return updatedAcct, nil
+}
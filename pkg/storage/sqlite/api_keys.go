@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"database/sql"
+	"net/netip"
+	"time"
+)
+
+// apiKeyDb is a single download api key, as database table fields
+// corresponds to api_keys.ApiKey
+type apiKeyDb struct {
+	id             int
+	certificateId  sql.NullInt32
+	privateKeyId   sql.NullInt32
+	name           string
+	keyHash        string
+	viaUrl         bool
+	allowedSources jsonStringSlice // stored as json array
+	expiresAt      sql.NullInt64
+	lastUsedAt     int64
+	lastUsedIP     string
+	createdAt      int64
+	updatedAt      int64
+}
+
+func (key apiKeyDb) toApiKey() (api_keys.ApiKey, error) {
+	// owner is exactly one of cert or private key
+	owner := api_keys.Owner{
+		Type: api_keys.OwnerCertificate,
+		ID:   int(key.certificateId.Int32),
+	}
+	if key.privateKeyId.Valid {
+		owner = api_keys.Owner{
+			Type: api_keys.OwnerPrivateKey,
+			ID:   int(key.privateKeyId.Int32),
+		}
+	}
+
+	allowedSources := []netip.Prefix{}
+	for _, source := range key.allowedSources.toSlice() {
+		prefix, err := netip.ParsePrefix(source)
+		if err != nil {
+			return api_keys.ApiKey{}, err
+		}
+		allowedSources = append(allowedSources, prefix)
+	}
+
+	var expiresAt *time.Time
+	if key.expiresAt.Valid {
+		t := time.Unix(key.expiresAt.Int64, 0)
+		expiresAt = &t
+	}
+
+	return api_keys.ApiKey{
+		ID:             key.id,
+		Owner:          owner,
+		Name:           key.name,
+		KeyHash:        key.keyHash,
+		ViaUrl:         key.viaUrl,
+		AllowedSources: allowedSources,
+		ExpiresAt:      expiresAt,
+		LastUsedAt:     time.Unix(key.lastUsedAt, 0),
+		LastUsedIP:     key.lastUsedIP,
+		CreatedAt:      time.Unix(key.createdAt, 0),
+		UpdatedAt:      time.Unix(key.updatedAt, 0),
+	}, nil
+}
+
+// ownerColumns returns the certificate_id and private_key_id values for owner
+func ownerColumns(owner api_keys.Owner) (certificateId sql.NullInt32, privateKeyId sql.NullInt32) {
+	switch owner.Type {
+	case api_keys.OwnerCertificate:
+		certificateId = sql.NullInt32{Int32: int32(owner.ID), Valid: true}
+	case api_keys.OwnerPrivateKey:
+		privateKeyId = sql.NullInt32{Int32: int32(owner.ID), Valid: true}
+	}
+
+	return certificateId, privateKeyId
+}
+
+// expiresAtColumn returns the expires_at value for an expiration unix time (0 = never)
+func expiresAtColumn(expiresAt int) sql.NullInt64 {
+	if expiresAt <= 0 {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: int64(expiresAt), Valid: true}
+}
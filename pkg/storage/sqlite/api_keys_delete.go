@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// DeleteApiKey deletes the specified api key
+func (store *Storage) DeleteApiKey(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		api_keys
+	WHERE
+		id = $1
+	`
+
+	_, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
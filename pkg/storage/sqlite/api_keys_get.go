@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetApiKeysByOwner returns all of the api keys of the specified owner
+func (store *Storage) GetApiKeysByOwner(owner api_keys.Owner) ([]api_keys.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	certificateId, privateKeyId := ownerColumns(owner)
+
+	query := `
+	SELECT
+		id, certificate_id, private_key_id, name, key_hash, via_url, allowed_sources,
+		expires_at, last_used_at, last_used_ip, created_at, updated_at
+	FROM
+		api_keys
+	WHERE
+		certificate_id = $1
+		OR
+		private_key_id = $2
+	ORDER BY
+		name
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, certificateId, privateKeyId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []api_keys.ApiKey{}
+	for rows.Next() {
+		var oneKey apiKeyDb
+		err = rows.Scan(
+			&oneKey.id,
+			&oneKey.certificateId,
+			&oneKey.privateKeyId,
+			&oneKey.name,
+			&oneKey.keyHash,
+			&oneKey.viaUrl,
+			&oneKey.allowedSources,
+			&oneKey.expiresAt,
+			&oneKey.lastUsedAt,
+			&oneKey.lastUsedIP,
+			&oneKey.createdAt,
+			&oneKey.updatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		convertedKey, err := oneKey.toApiKey()
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, convertedKey)
+	}
+
+	return keys, nil
+}
+
+// GetOneApiKeyById returns the api key with the specified id
+func (store *Storage) GetOneApiKeyById(id int) (api_keys.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, certificate_id, private_key_id, name, key_hash, via_url, allowed_sources,
+		expires_at, last_used_at, last_used_ip, created_at, updated_at
+	FROM
+		api_keys
+	WHERE
+		id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, id)
+
+	var oneKey apiKeyDb
+	err := row.Scan(
+		&oneKey.id,
+		&oneKey.certificateId,
+		&oneKey.privateKeyId,
+		&oneKey.name,
+		&oneKey.keyHash,
+		&oneKey.viaUrl,
+		&oneKey.allowedSources,
+		&oneKey.expiresAt,
+		&oneKey.lastUsedAt,
+		&oneKey.lastUsedIP,
+		&oneKey.createdAt,
+		&oneKey.updatedAt,
+	)
+
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return api_keys.ApiKey{}, err
+	}
+
+	return oneKey.toApiKey()
+}
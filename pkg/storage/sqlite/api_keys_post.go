@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"context"
+	"database/sql"
+)
+
+// PostNewApiKey saves a new api key to the db
+func (store *Storage) PostNewApiKey(payload api_keys.NewPayload) (api_keys.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	certificateId, privateKeyId := ownerColumns(payload.Owner)
+
+	expiresAt := 0
+	if payload.ExpiresAt != nil {
+		expiresAt = *payload.ExpiresAt
+	}
+
+	query := `
+	INSERT INTO api_keys (certificate_id, private_key_id, name, key_hash, via_url, allowed_sources,
+		expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id
+	`
+
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		certificateId,
+		privateKeyId,
+		payload.Name,
+		payload.KeyHash,
+		payload.ViaUrl,
+		makeJsonStringSlice(*payload.AllowedSources),
+		expiresAtColumn(expiresAt),
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+	if err != nil {
+		return api_keys.ApiKey{}, err
+	}
+
+	return store.GetOneApiKeyById(id)
+}
+
+// insertInitialApiKeyTx adds the initial api key (named default) of a new owner and
+// returns its id; if keyHash is blank, the owner is not given an api key (and the id
+// is -1)
+func insertInitialApiKeyTx(ctx context.Context, tx *sql.Tx, owner api_keys.Owner, keyHash string, createdAt int) (int, error) {
+	if keyHash == "" {
+		return -1, nil
+	}
+
+	certificateId, privateKeyId := ownerColumns(owner)
+
+	query := `
+	INSERT INTO api_keys (certificate_id, private_key_id, name, key_hash, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	id := -1
+	err := tx.QueryRowContext(ctx, query,
+		certificateId,
+		privateKeyId,
+		"default",
+		keyHash,
+		createdAt,
+		createdAt,
+	).Scan(&id)
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"context"
+)
+
+// PutApiKeyUpdate updates an existing api key in the db using any non-null
+// fields specified in the UpdatePayload.
+func (store *Storage) PutApiKeyUpdate(payload api_keys.UpdatePayload) (api_keys.ApiKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	var allowedSources *jsonStringSlice
+	if payload.AllowedSources != nil {
+		allowedSources = new(jsonStringSlice)
+		*allowedSources = makeJsonStringSlice(*payload.AllowedSources)
+	}
+
+	// expiration is only changed if specified; 0 clears it
+	updateExpiresAt := payload.ExpiresAt != nil
+	expiresAt := 0
+	if updateExpiresAt {
+		expiresAt = *payload.ExpiresAt
+	}
+
+	query := `
+	UPDATE
+		api_keys
+	SET
+		name = case when $1 is null then name else $1 end,
+		via_url = case when $2 is null then via_url else $2 end,
+		allowed_sources = case when $3 is null then allowed_sources else $3 end,
+		expires_at = case when $4 then $5 else expires_at end,
+		updated_at = $6
+	WHERE
+		id = $7
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		payload.Name,
+		payload.ViaUrl,
+		allowedSources,
+		updateExpiresAt,
+		expiresAtColumn(expiresAt),
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return api_keys.ApiKey{}, err
+	}
+
+	return store.GetOneApiKeyById(payload.ID)
+}
+
+// PutApiKeyLastUsed sets an api key's last used time and client ip
+func (store *Storage) PutApiKeyLastUsed(id int, unixLastUsedTime int64, lastUsedIP string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		api_keys
+	SET
+		last_used_at = $1,
+		last_used_ip = $2
+	WHERE
+		id = $3
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		unixLastUsedTime,
+		lastUsedIP,
+		id,
+	)
+
+	return err
+}
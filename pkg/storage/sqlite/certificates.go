@@ -2,6 +2,8 @@ package sqlite
 
 import (
 	"certwarden-backend/pkg/domain/certificates"
+	"certwarden-backend/pkg/domain/private_keys"
+	"database/sql"
 	"time"
 )
 
@@ -25,28 +27,88 @@ type certificateDb struct {
 	lastAccess                  int64
 	createdAt                   int64
 	updatedAt                   int64
-	apiKey                      string
-	apiKeyNew                   string
-	apiKeyViaUrl                bool
 	postProcessingCommand       string
 	postProcessingEnvironment   jsonStringSlice // stored as json array
 	postProcessingClientAddress string
-	postProcessingClientKeyB64  string // base64 raw url encoded AES 256 key
+	postProcessingClientKeyB64  string // base64 raw url encoded AES 256 key (encrypted if key encryption is enabled)
 	profile                     string
+	requestedValidityHours      int
+	keyRotationInterval         int
+	renewalPolicy               jsonRenewalPolicy
+	maintenanceWindowId         sql.NullInt32
+	orderFailureStreak          int
+	orderFailureLastAt          int
+	orderFailureLastError       string
+	orderRetryAt                sql.NullInt64
+	privateCAId                 sql.NullInt32
+	privateCAName               sql.NullString
+	csrPem                      sql.NullString
 }
 
-func (cert certificateDb) toCertificate() (certificates.Certificate, error) {
+func (cert certificateDb) toCertificate(ke *keyEncryption) (certificates.Certificate, error) {
 	certExt, err := cert.csrExtraExtensions.toCertExtensionSlice()
 	if err != nil {
 		return certificates.Certificate{}, err
 	}
 
+	// private ca (if cert is issued by one)
+	var privateCA *certificates.CertificatePrivateCA
+	if cert.privateCAId.Valid {
+		privateCA = &certificates.CertificatePrivateCA{
+			ID:   int(cert.privateCAId.Int32),
+			Name: cert.privateCAName.String,
+		}
+	}
+
+	// maintenance window (if assigned)
+	var maintenanceWindowId *int
+	if cert.maintenanceWindowId.Valid {
+		maintenanceWindowId = new(int)
+		*maintenanceWindowId = int(cert.maintenanceWindowId.Int32)
+	}
+
+	// order failures (retry time only set if a retry is scheduled)
+	orderFailures := certificates.OrderFailures{
+		Streak:        cert.orderFailureStreak,
+		LastFailureAt: cert.orderFailureLastAt,
+		LastError:     cert.orderFailureLastError,
+	}
+	if cert.orderRetryAt.Valid {
+		orderFailures.NextRetryAt = new(int)
+		*orderFailures.NextRetryAt = int(cert.orderRetryAt.Int64)
+	}
+
+	// external csr certs don't have a key
+	var certKey private_keys.Key
+	if !cert.csrPem.Valid {
+		certKey, err = cert.certificateKeyDb.toKey(ke)
+		if err != nil {
+			return certificates.Certificate{}, err
+		}
+	}
+
+	renewalPolicy, err := cert.renewalPolicy.toRenewalPolicy()
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
+	account, err := cert.certificateAccountDb.toAccount(ke)
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
+	clientKey, err := ke.decrypt(secretCertificateClientKey, cert.id, cert.postProcessingClientKeyB64)
+	if err != nil {
+		return certificates.Certificate{}, err
+	}
+
 	return certificates.Certificate{
 		ID:                          cert.id,
 		Name:                        cert.name,
 		Description:                 cert.description,
-		CertificateKey:              cert.certificateKeyDb.toKey(),
-		CertificateAccount:          cert.certificateAccountDb.toAccount(),
+		CertificateKey:              certKey,
+		CertificateAccount:          account,
+		PrivateCA:                   privateCA,
 		Subject:                     cert.subject,
 		SubjectAltNames:             cert.subjectAltNames.toSlice(),
 		Organization:                cert.organization,
@@ -59,13 +121,16 @@ func (cert certificateDb) toCertificate() (certificates.Certificate, error) {
 		LastAccess:                  time.Unix(cert.lastAccess, 0),
 		CreatedAt:                   time.Unix(cert.createdAt, 0),
 		UpdatedAt:                   time.Unix(cert.updatedAt, 0),
-		ApiKey:                      cert.apiKey,
-		ApiKeyNew:                   cert.apiKeyNew,
-		ApiKeyViaUrl:                cert.apiKeyViaUrl,
 		PostProcessingCommand:       cert.postProcessingCommand,
 		PostProcessingEnvironment:   cert.postProcessingEnvironment.toSlice(),
 		PostProcessingClientAddress: cert.postProcessingClientAddress,
-		PostProcessingClientKeyB64:  cert.postProcessingClientKeyB64,
+		PostProcessingClientKeyB64:  clientKey,
 		Profile:                     cert.profile,
+		RequestedValidityHours:      cert.requestedValidityHours,
+		KeyRotationInterval:         cert.keyRotationInterval,
+		RenewalPolicy:               renewalPolicy,
+		MaintenanceWindowID:         maintenanceWindowId,
+		OrderFailures:               orderFailures,
+		ExternalCsrPem:              cert.csrPem.String,
 	}, nil
 }
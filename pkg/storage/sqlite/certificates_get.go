@@ -49,20 +49,25 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 		c.id, c.name, c.description, c.subject, c.subject_alts, 
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.post_processing_command, c.post_processing_environment,
+		c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
-		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
-		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at,
+		COALESCE(pk.id, -2), COALESCE(pk.name, 'null'), COALESCE(pk.description, 'null'), COALESCE(pk.algorithm, 'null'),
+		COALESCE(pk.pem, 'null'), COALESCE(pk.api_key_disabled, false),
+		COALESCE(pk.last_access, -2), COALESCE(pk.created_at, -2), COALESCE(pk.updated_at, -2),
 
-		aa.id, aa.name, aa.description, aa.status, aa.email, aa.accepted_tos,
-		aa.created_at, aa.updated_at, aa.kid,
+		COALESCE(aa.id, -2), COALESCE(aa.name, 'null'), COALESCE(aa.description, 'null'), COALESCE(aa.status, 'null'),
+		COALESCE(aa.email, 'null'), COALESCE(aa.accepted_tos, false), COALESCE(aa.created_at, -2), COALESCE(aa.updated_at, -2),
+		COALESCE(aa.kid, 'null'),
 
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new,
-		ak.api_key_disabled, ak.api_key_via_url, ak.last_access, ak.created_at, ak.updated_at,
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2),
 
 		count(*) OVER() AS full_count
 	FROM
@@ -71,6 +76,7 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 		LEFT JOIN acme_accounts aa on (c.acme_account_id = aa.id)
 		LEFT JOIN acme_servers aserv on (aa.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (aa.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 	ORDER BY
 		%s
 	LIMIT
@@ -110,24 +116,29 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 			&oneCert.lastAccess,
 			&oneCert.createdAt,
 			&oneCert.updatedAt,
-			&oneCert.apiKey,
-			&oneCert.apiKeyNew,
-			&oneCert.apiKeyViaUrl,
 			&oneCert.postProcessingCommand,
 			&oneCert.postProcessingEnvironment,
 			&oneCert.postProcessingClientAddress,
 			&oneCert.postProcessingClientKeyB64,
 			&oneCert.profile,
+			&oneCert.requestedValidityHours,
+			&oneCert.keyRotationInterval,
+			&oneCert.renewalPolicy,
+			&oneCert.maintenanceWindowId,
+			&oneCert.orderFailureStreak,
+			&oneCert.orderFailureLastAt,
+			&oneCert.orderFailureLastError,
+			&oneCert.orderRetryAt,
+			&oneCert.privateCAId,
+			&oneCert.privateCAName,
+			&oneCert.csrPem,
 
 			&oneCert.certificateKeyDb.id,
 			&oneCert.certificateKeyDb.name,
 			&oneCert.certificateKeyDb.description,
 			&oneCert.certificateKeyDb.algorithmValue,
 			&oneCert.certificateKeyDb.pem,
-			&oneCert.certificateKeyDb.apiKey,
-			&oneCert.certificateKeyDb.apiKeyNew,
 			&oneCert.certificateKeyDb.apiKeyDisabled,
-			&oneCert.certificateKeyDb.apiKeyViaUrl,
 			&oneCert.certificateKeyDb.lastAccess,
 			&oneCert.certificateKeyDb.createdAt,
 			&oneCert.certificateKeyDb.updatedAt,
@@ -155,10 +166,7 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 			&oneCert.certificateAccountDb.accountKeyDb.description,
 			&oneCert.certificateAccountDb.accountKeyDb.algorithmValue,
 			&oneCert.certificateAccountDb.accountKeyDb.pem,
-			&oneCert.certificateAccountDb.accountKeyDb.apiKey,
-			&oneCert.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneCert.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-			&oneCert.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 			&oneCert.certificateAccountDb.accountKeyDb.lastAccess,
 			&oneCert.certificateAccountDb.accountKeyDb.createdAt,
 			&oneCert.certificateAccountDb.accountKeyDb.updatedAt,
@@ -170,7 +178,7 @@ func (store *Storage) GetAllCerts(q pagination_sort.Query) (certs []certificates
 		}
 
 		// convert and append
-		oneCertConverted, err := oneCert.toCertificate()
+		oneCertConverted, err := oneCert.toCertificate(store.keyEncryption)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -201,26 +209,32 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.post_processing_command, c.post_processing_environment,
+		c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
-		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
-		pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at,
+		COALESCE(pk.id, -2), COALESCE(pk.name, 'null'), COALESCE(pk.description, 'null'), COALESCE(pk.algorithm, 'null'),
+		COALESCE(pk.pem, 'null'), COALESCE(pk.api_key_disabled, false),
+		COALESCE(pk.last_access, -2), COALESCE(pk.created_at, -2), COALESCE(pk.updated_at, -2),
 
-		aa.id, aa.name, aa.description, aa.status, aa.email, aa.accepted_tos,
-		aa.created_at, aa.updated_at, aa.kid,
+		COALESCE(aa.id, -2), COALESCE(aa.name, 'null'), COALESCE(aa.description, 'null'), COALESCE(aa.status, 'null'),
+		COALESCE(aa.email, 'null'), COALESCE(aa.accepted_tos, false), COALESCE(aa.created_at, -2), COALESCE(aa.updated_at, -2),
+		COALESCE(aa.kid, 'null'),
 
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new, 
-		ak.api_key_disabled, ak.api_key_via_url, ak.last_access, ak.created_at, ak.updated_at
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2)
 	FROM
 		certificates c
 		LEFT JOIN private_keys pk on (c.private_key_id = pk.id)
 		LEFT JOIN acme_accounts aa on (c.acme_account_id = aa.id)
 		LEFT JOIN acme_servers aserv on (aa.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (aa.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 	WHERE 
 		c.id = $1 OR c.name = $2
 	ORDER BY c.name
@@ -246,24 +260,29 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		&oneCert.lastAccess,
 		&oneCert.createdAt,
 		&oneCert.updatedAt,
-		&oneCert.apiKey,
-		&oneCert.apiKeyNew,
-		&oneCert.apiKeyViaUrl,
 		&oneCert.postProcessingCommand,
 		&oneCert.postProcessingEnvironment,
 		&oneCert.postProcessingClientAddress,
 		&oneCert.postProcessingClientKeyB64,
 		&oneCert.profile,
+		&oneCert.requestedValidityHours,
+		&oneCert.keyRotationInterval,
+		&oneCert.renewalPolicy,
+		&oneCert.maintenanceWindowId,
+		&oneCert.orderFailureStreak,
+		&oneCert.orderFailureLastAt,
+		&oneCert.orderFailureLastError,
+		&oneCert.orderRetryAt,
+		&oneCert.privateCAId,
+		&oneCert.privateCAName,
+		&oneCert.csrPem,
 
 		&oneCert.certificateKeyDb.id,
 		&oneCert.certificateKeyDb.name,
 		&oneCert.certificateKeyDb.description,
 		&oneCert.certificateKeyDb.algorithmValue,
 		&oneCert.certificateKeyDb.pem,
-		&oneCert.certificateKeyDb.apiKey,
-		&oneCert.certificateKeyDb.apiKeyNew,
 		&oneCert.certificateKeyDb.apiKeyDisabled,
-		&oneCert.certificateKeyDb.apiKeyViaUrl,
 		&oneCert.certificateKeyDb.lastAccess,
 		&oneCert.certificateKeyDb.createdAt,
 		&oneCert.certificateKeyDb.updatedAt,
@@ -291,10 +310,7 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 		&oneCert.certificateAccountDb.accountKeyDb.description,
 		&oneCert.certificateAccountDb.accountKeyDb.algorithmValue,
 		&oneCert.certificateAccountDb.accountKeyDb.pem,
-		&oneCert.certificateAccountDb.accountKeyDb.apiKey,
-		&oneCert.certificateAccountDb.accountKeyDb.apiKeyNew,
 		&oneCert.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-		&oneCert.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 		&oneCert.certificateAccountDb.accountKeyDb.lastAccess,
 		&oneCert.certificateAccountDb.accountKeyDb.createdAt,
 		&oneCert.certificateAccountDb.accountKeyDb.updatedAt,
@@ -309,10 +325,49 @@ func (store *Storage) getOneCert(id int, name string) (cert certificates.Certifi
 	}
 
 	// convert and return
-	oneCertConverted, err := oneCert.toCertificate()
+	oneCertConverted, err := oneCert.toCertificate(store.keyEncryption)
 	if err != nil {
 		return certificates.Certificate{}, err
 	}
 
 	return oneCertConverted, nil
 }
+
+// GetCertIdsOrderRetryBefore returns the ids of all certs that have a failed order retry
+// scheduled before the specified unix time
+func (store *Storage) GetCertIdsOrderRetryBefore(before int) (certIds []int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id
+	FROM
+		certificates
+	WHERE
+		order_retry_at IS NOT NULL
+		AND
+		order_retry_at < $1
+	ORDER BY
+		order_retry_at
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certIds = []int{}
+	for rows.Next() {
+		var certId int
+		err = rows.Scan(&certId)
+		if err != nil {
+			return nil, err
+		}
+
+		certIds = append(certIds, certId)
+	}
+
+	return certIds, nil
+}
@@ -1,12 +1,14 @@
 package sqlite
 
 import (
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/certificates"
 	"context"
 )
 
-// PostNewAccount inserts a new cert into the db
-func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates.Certificate, error) {
+// PostNewCert inserts a new cert into the db, along with its initial api key, and
+// returns the cert and the id of its initial api key
+func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates.Certificate, int, error) {
 	// database update
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
@@ -14,19 +16,27 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates
 	// don't check for in use in storage. main app business logic should
 	// take care of it
 
+	// transaction so the cert and its api key are saved together
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+	defer tx.Rollback()
+
 	// insert the new cert
 	query := `
 	INSERT INTO certificates (name, description, private_key_id, acme_account_id, subject, subject_alts, 
 		csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn, 
-		created_at, updated_at, api_key, api_key_via_url,
-		post_processing_command, post_processing_environment, post_processing_client_address, 
-		post_processing_client_key, profile)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		created_at, updated_at, post_processing_command, post_processing_environment, post_processing_client_address, 
+		post_processing_client_key, profile, private_ca_id, requested_validity_hours,
+		key_rotation_interval, renewal_policy, maintenance_window_id, csr_pem)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+		NULLIF($25, 0), $26)
 	RETURNING id
 	`
 
 	id := -1
-	err := store.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		payload.Name,
 		payload.Description,
 		payload.PrivateKeyID,
@@ -42,24 +52,45 @@ func (store *Storage) PostNewCert(payload certificates.NewPayload) (certificates
 		payload.PreferredRootCN,
 		payload.CreatedAt,
 		payload.UpdatedAt,
-		payload.ApiKey,
-		payload.ApiKeyViaUrl,
 		payload.PostProcessingCommand,
 		makeJsonStringSlice(payload.PostProcessingEnvironment),
-		payload.PostProcessingClientKeyB64,
 		payload.PostProcessingClientAddress,
+		"",
 		payload.Profile,
+		payload.PrivateCAID,
+		payload.RequestedValidityHours,
+		payload.KeyRotationInterval,
+		makeJsonRenewalPolicy(payload.RenewalPolicy),
+		payload.MaintenanceWindowID,
+		payload.ExternalCsrPem,
 	).Scan(&id)
 
 	if err != nil {
-		return certificates.Certificate{}, err
+		return certificates.Certificate{}, -1, err
+	}
+
+	// client key (encrypted, if enabled)
+	err = putSecretTx(ctx, tx, store.keyEncryption, secretCertificateClientKey, id, payload.PostProcessingClientKeyB64)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	// initial api key
+	apiKeyId, err := insertInitialApiKeyTx(ctx, tx, api_keys.Owner{Type: api_keys.OwnerCertificate, ID: id}, payload.ApiKeyHash, payload.CreatedAt)
+	if err != nil {
+		return certificates.Certificate{}, -1, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return certificates.Certificate{}, -1, err
 	}
 
 	// get updated to return
 	newCert, err := store.GetOneCertById(id)
 	if err != nil {
-		return certificates.Certificate{}, err
+		return certificates.Certificate{}, -1, err
 	}
 
-	return newCert, nil
+	return newCert, apiKeyId, nil
 }
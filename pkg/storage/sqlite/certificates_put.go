@@ -28,16 +28,18 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 			csr_city = case when $9 is null then csr_city else $9 end,
 			csr_extra_extensions = case when $10 is null then csr_city else $10 end,
 			preferred_root_cn = case when $11 is null then preferred_root_cn else $11 end,
-			api_key = case when $12 is null then api_key else $12 end,
-			api_key_new = case when $13 is null then api_key_new else $13 end,
-			api_key_via_url = case when $14 is null then api_key_via_url else $14 end,
-			post_processing_command = case when $15 is null then post_processing_command else $15 end,
-			post_processing_environment = case when $16 is null then post_processing_environment else $16 end,
-			post_processing_client_address = case when $17 is null then post_processing_client_address else $17 end,
-			profile = case when $18 is null then profile else $18 end,
-			updated_at = $19
+			post_processing_command = case when $12 is null then post_processing_command else $12 end,
+			post_processing_environment = case when $13 is null then post_processing_environment else $13 end,
+			post_processing_client_address = case when $14 is null then post_processing_client_address else $14 end,
+			profile = case when $15 is null then profile else $15 end,
+			requested_validity_hours = case when $16 is null then requested_validity_hours else $16 end,
+			key_rotation_interval = case when $17 is null then key_rotation_interval else $17 end,
+			renewal_policy = case when $18 is null then renewal_policy else $18 end,
+			maintenance_window_id = case when $19 is null then maintenance_window_id else NULLIF($19, 0) end,
+			csr_pem = case when $20 is null then csr_pem else $20 end,
+			updated_at = $21
 		WHERE
-			id = $20
+			id = $22
 		`
 
 	_, err := store.db.ExecContext(ctx, query,
@@ -52,13 +54,15 @@ func (store *Storage) PutDetailsCert(payload certificates.DetailsUpdatePayload)
 		payload.City,
 		makeJsonCertExtensionSlice(payload.CSRExtraExtensions),
 		payload.PreferredRootCN,
-		payload.ApiKey,
-		payload.ApiKeyNew,
-		payload.ApiKeyViaUrl,
 		payload.PostProcessingCommand,
 		makeJsonStringSlice(payload.PostProcessingEnvironment),
 		payload.PostProcessingClientAddress,
 		payload.Profile,
+		payload.RequestedValidityHours,
+		payload.KeyRotationInterval,
+		makeJsonRenewalPolicy(payload.RenewalPolicy),
+		payload.MaintenanceWindowID,
+		payload.ExternalCsrPem,
 		payload.UpdatedAt,
 		payload.ID,
 	)
@@ -103,37 +107,14 @@ func (store *Storage) UpdateCertUpdatedTime(certId int) (err error) {
 	return nil
 }
 
-// PutCertNewApiKey sets a cert's new api key and updates the updated at time
-func (store *Storage) PutCertNewApiKey(certId int, newApiKey string, updateTimeUnix int) (err error) {
-	// database action
-	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
-	defer cancel()
-
-	query := `
-	UPDATE
-		certificates
-	SET
-		api_key_new = $1,
-		updated_at = $2
-	WHERE
-		id = $3
-	`
-
-	_, err = store.db.ExecContext(ctx, query,
-		newApiKey,
-		updateTimeUnix,
-		certId,
-	)
-
+// PutCertClientKey sets a cert's client key and updates the updated at time
+func (store *Storage) PutCertClientKey(certId int, newClientKeyB64 string, updateTimeUnix int) (err error) {
+	// encrypt client key, if enabled
+	clientKey, err := store.keyEncryption.encrypt(secretCertificateClientKey, certId, newClientKeyB64)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// PutCertApiKey sets a cert's api key and updates the updated at time
-func (store *Storage) PutCertApiKey(certId int, apiKey string, updateTimeUnix int) (err error) {
 	// database action
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
@@ -142,14 +123,14 @@ func (store *Storage) PutCertApiKey(certId int, apiKey string, updateTimeUnix in
 	UPDATE
 		certificates
 	SET
-		api_key = $1,
+		post_processing_client_key = $1,
 		updated_at = $2
 	WHERE
 		id = $3
 	`
 
 	_, err = store.db.ExecContext(ctx, query,
-		apiKey,
+		clientKey,
 		updateTimeUnix,
 		certId,
 	)
@@ -161,8 +142,8 @@ func (store *Storage) PutCertApiKey(certId int, apiKey string, updateTimeUnix in
 	return nil
 }
 
-// PutCertClientKey sets a cert's client key and updates the updated at time
-func (store *Storage) PutCertClientKey(certId int, newClientKeyB64 string, updateTimeUnix int) (err error) {
+// PutCertLastAccess sets a cert's last access time
+func (store *Storage) PutCertLastAccess(certId int, unixLastAccessTime int64) (err error) {
 	// database action
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
@@ -171,15 +152,13 @@ func (store *Storage) PutCertClientKey(certId int, newClientKeyB64 string, updat
 	UPDATE
 		certificates
 	SET
-		post_processing_client_key = $1,
-		updated_at = $2
+		last_access = $1
 	WHERE
-		id = $3
+		id = $2
 	`
 
 	_, err = store.db.ExecContext(ctx, query,
-		newClientKeyB64,
-		updateTimeUnix,
+		unixLastAccessTime,
 		certId,
 	)
 
@@ -190,9 +169,9 @@ func (store *Storage) PutCertClientKey(certId int, newClientKeyB64 string, updat
 	return nil
 }
 
-// PutCertLastAccess sets a cert's last access time
-func (store *Storage) PutCertLastAccess(certId int, unixLastAccessTime int64) (err error) {
-	// database action
+// PutCertOrderFailures sets a cert's order failure streak (this does not change the
+// cert's updated at time)
+func (store *Storage) PutCertOrderFailures(certId int, failures certificates.OrderFailures) (err error) {
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
 
@@ -200,16 +179,21 @@ func (store *Storage) PutCertLastAccess(certId int, unixLastAccessTime int64) (e
 	UPDATE
 		certificates
 	SET
-		last_access = $1
+		order_failure_streak = $1,
+		order_failure_last_at = $2,
+		order_failure_last_error = $3,
+		order_retry_at = $4
 	WHERE
-		id = $2
+		id = $5
 	`
 
 	_, err = store.db.ExecContext(ctx, query,
-		unixLastAccessTime,
+		failures.Streak,
+		failures.LastFailureAt,
+		failures.LastError,
+		failures.NextRetryAt,
 		certId,
 	)
-
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/domain/download"
+	"database/sql"
+	"time"
+)
+
+// downloadLogEntryDb is a single download log entry, as database table fields
+// corresponds to download.LogEntry
+type downloadLogEntryDb struct {
+	id            int
+	certificateId sql.NullInt32
+	privateKeyId  sql.NullInt32
+	apiKeyId      sql.NullInt32
+	apiKeyName    string
+	clientAddress string
+	userAgent     string
+	format        string
+	orderId       sql.NullInt32
+	createdAt     int64
+}
+
+func (entry downloadLogEntryDb) toLogEntry() download.LogEntry {
+	// owner is exactly one of cert or private key
+	owner := api_keys.Owner{
+		Type: api_keys.OwnerCertificate,
+		ID:   int(entry.certificateId.Int32),
+	}
+	if entry.privateKeyId.Valid {
+		owner = api_keys.Owner{
+			Type: api_keys.OwnerPrivateKey,
+			ID:   int(entry.privateKeyId.Int32),
+		}
+	}
+
+	return download.LogEntry{
+		ID:            entry.id,
+		Owner:         owner,
+		ApiKeyID:      nullInt32ToInt(entry.apiKeyId),
+		ApiKeyName:    entry.apiKeyName,
+		ClientAddress: entry.clientAddress,
+		UserAgent:     entry.userAgent,
+		Format:        entry.format,
+		OrderID:       nullInt32ToInt(entry.orderId),
+		CreatedAt:     time.Unix(entry.createdAt, 0),
+	}
+}
@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/api_keys"
+	"certwarden-backend/pkg/domain/download"
+	"certwarden-backend/pkg/pagination_sort"
+	"context"
+	"fmt"
+)
+
+// GetDownloadLogByOwner returns a portion of the download log entries of the specified
+// owner. By default, the newest entries are first.
+func (store *Storage) GetDownloadLogByOwner(owner api_keys.Owner, q pagination_sort.Query) (entries []download.LogEntry, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+	sortDirection := q.SortDirection()
+	switch sortField {
+	// allow these
+	case "id":
+	case "created_at":
+	// default if not in allowed list
+	default:
+		sortField = "id"
+		sortDirection = "desc"
+	}
+
+	sort := sortField + " " + sortDirection
+
+	// do query
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	certificateId, privateKeyId := ownerColumns(owner)
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		id, certificate_id, private_key_id, api_key_id, api_key_name, client_address,
+		user_agent, format, order_id, created_at,
+
+		count(*) OVER() AS full_count
+	FROM
+		download_log
+	WHERE
+		certificate_id = $1
+		OR
+		private_key_id = $2
+	ORDER BY
+		%s
+	LIMIT
+		$3
+	OFFSET
+		$4
+	`, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		certificateId,
+		privateKeyId,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	entries = []download.LogEntry{}
+	for rows.Next() {
+		var oneEntry downloadLogEntryDb
+		err = rows.Scan(
+			&oneEntry.id,
+			&oneEntry.certificateId,
+			&oneEntry.privateKeyId,
+			&oneEntry.apiKeyId,
+			&oneEntry.apiKeyName,
+			&oneEntry.clientAddress,
+			&oneEntry.userAgent,
+			&oneEntry.format,
+			&oneEntry.orderId,
+			&oneEntry.createdAt,
+
+			&totalRows,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		entries = append(entries, oneEntry.toLogEntry())
+	}
+
+	return entries, totalRows, nil
+}
+
+// GetCertLatestDownloadByClient returns the most recent download log entry of the
+// specified certificate for each client address that has downloaded it
+func (store *Storage) GetCertLatestDownloadByClient(certId int) ([]download.LogEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, certificate_id, private_key_id, api_key_id, api_key_name, client_address,
+		user_agent, format, order_id, created_at
+	FROM (
+		SELECT
+			*,
+			row_number() OVER (PARTITION BY client_address ORDER BY id DESC) AS client_row
+		FROM
+			download_log
+		WHERE
+			certificate_id = $1
+	)
+	WHERE
+		client_row = 1
+	ORDER BY
+		client_address
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, certId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []download.LogEntry{}
+	for rows.Next() {
+		var oneEntry downloadLogEntryDb
+		err = rows.Scan(
+			&oneEntry.id,
+			&oneEntry.certificateId,
+			&oneEntry.privateKeyId,
+			&oneEntry.apiKeyId,
+			&oneEntry.apiKeyName,
+			&oneEntry.clientAddress,
+			&oneEntry.userAgent,
+			&oneEntry.format,
+			&oneEntry.orderId,
+			&oneEntry.createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, oneEntry.toLogEntry())
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/download"
+	"context"
+)
+
+// PostDownloadLogEntry saves a new download log entry to the db
+func (store *Storage) PostDownloadLogEntry(entry download.LogEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	certificateId, privateKeyId := ownerColumns(entry.Owner)
+
+	query := `
+	INSERT INTO download_log (certificate_id, private_key_id, api_key_id, api_key_name, client_address,
+		user_agent, format, order_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		certificateId,
+		privateKeyId,
+		entry.ApiKeyID,
+		entry.ApiKeyName,
+		entry.ClientAddress,
+		entry.UserAgent,
+		entry.Format,
+		entry.OrderID,
+		entry.CreatedAt.Unix(),
+	)
+
+	return err
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import "certwarden-backend/pkg/domain/orders"
+
+// jobDb is a single order fulfilling or post processing job, as database table fields
+// corresponds to orders.Job
+type jobDb struct {
+	id              int
+	jobType         string
+	orderId         int
+	certificateId   int
+	certificateName string
+	highPriority    bool
+	status          string
+	attempts        int
+	lastError       string
+	createdAt       int
+	updatedAt       int
+}
+
+func (job jobDb) toJob() orders.Job {
+	return orders.Job{
+		ID:              job.id,
+		Type:            job.jobType,
+		OrderID:         job.orderId,
+		CertificateID:   job.certificateId,
+		CertificateName: job.certificateName,
+		HighPriority:    job.highPriority,
+		Status:          job.status,
+		Attempts:        job.attempts,
+		LastError:       job.lastError,
+		CreatedAt:       job.createdAt,
+		UpdatedAt:       job.updatedAt,
+	}
+}
+
+// jobSelect is the select and join clause for jobs and the certificates of their orders;
+// the columns correspond to the scan in scanJob
+const jobSelect = `
+	SELECT
+		j.id, j.type, j.order_id, ao.certificate_id, c.name, j.high_priority, j.status, j.attempts,
+		j.last_error, j.created_at, j.updated_at
+	FROM
+		jobs j
+		JOIN acme_orders ao on (j.order_id = ao.id)
+		JOIN certificates c on (ao.certificate_id = c.id)
+	`
+
+// scanJob scans a row selected with jobSelect (plus any additional dest) into a jobDb
+func scanJob(scanner interface{ Scan(dest ...any) error }, additionalDest ...any) (jobDb, error) {
+	var job jobDb
+	dest := append([]any{
+		&job.id,
+		&job.jobType,
+		&job.orderId,
+		&job.certificateId,
+		&job.certificateName,
+		&job.highPriority,
+		&job.status,
+		&job.attempts,
+		&job.lastError,
+		&job.createdAt,
+		&job.updatedAt,
+	}, additionalDest...)
+
+	err := scanner.Scan(dest...)
+	return job, err
+}
@@ -0,0 +1,33 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// DeleteFinishedJobs deletes jobs that finished (i.e., are not queued or running) and
+// have not been updated since olderThan (unix time)
+func (store *Storage) DeleteFinishedJobs(olderThan int) (deletedCount int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		jobs
+	WHERE
+		status NOT IN ('queued', 'running')
+		AND
+		updated_at < $1
+	`
+
+	result, err := store.db.ExecContext(ctx, query, olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
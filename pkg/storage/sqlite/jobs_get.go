@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/orders"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// GetAllJobs returns a slice of all of the order fulfilling and post processing jobs in the database
+func (store *Storage) GetAllJobs(q pagination_sort.Query) (jobs []orders.Job, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "id"
+	case "status":
+		sortField = "status"
+	case "created_at":
+		sortField = "created_at"
+	// default if not in allowed list
+	default:
+		sortField = "id"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		*, count(*) OVER() AS full_count
+	FROM (%s)
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, jobSelect, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	allJobs := []orders.Job{}
+	for rows.Next() {
+		oneJob, err := scanJob(rows, &totalRows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allJobs = append(allJobs, oneJob.toJob())
+	}
+
+	return allJobs, totalRows, nil
+}
+
+// GetOneJob returns a job based on its unique id
+func (store *Storage) GetOneJob(id int) (orders.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := jobSelect + `
+	WHERE j.id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, id)
+
+	oneJob, err := scanJob(row)
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return orders.Job{}, err
+	}
+
+	return oneJob.toJob(), nil
+}
+
+// GetUnfinishedJobs returns all jobs that are queued or running, in the order they were created
+func (store *Storage) GetUnfinishedJobs() ([]orders.Job, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := jobSelect + `
+	WHERE j.status IN ('queued', 'running')
+	ORDER BY j.id
+	`
+
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []orders.Job{}
+	for rows.Next() {
+		oneJob, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, oneJob.toJob())
+	}
+
+	return jobs, nil
+}
+
+// CountEarlierJobs returns the number of jobs of the specified type for the order that were
+// saved before the specified job
+func (store *Storage) CountEarlierJobs(jobType string, orderId int, beforeJobId int) (count int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		COUNT(*)
+	FROM
+		jobs
+	WHERE
+		type = $1
+		AND
+		order_id = $2
+		AND
+		id < $3
+	`
+
+	err = store.db.QueryRowContext(ctx, query, jobType, orderId, beforeJobId).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
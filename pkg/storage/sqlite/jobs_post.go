@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/orders"
+	"context"
+)
+
+// PostNewJob inserts a new order fulfilling or post processing job into the db and
+// returns its id
+func (store *Storage) PostNewJob(payload orders.NewJobPayload) (id int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO jobs (type, order_id, high_priority, status, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id
+	`
+
+	id = -1
+	err = store.db.QueryRowContext(ctx, query,
+		payload.Type,
+		payload.OrderID,
+		payload.HighPriority,
+		payload.Status,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return -2, err
+	}
+
+	return id, nil
+}
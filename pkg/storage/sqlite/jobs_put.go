@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/orders"
+	"context"
+)
+
+// PutJobStatus updates a job's status (and last error, if provided). If the payload
+// starts an attempt, the job's attempts are incremented.
+func (store *Storage) PutJobStatus(payload orders.UpdateJobStatusPayload) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		jobs
+	SET
+		status = $1,
+		attempts = case when $2 then attempts + 1 else attempts end,
+		last_error = case when $3 is null then last_error else $3 end,
+		updated_at = $4
+	WHERE
+		id = $5
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		payload.Status,
+		payload.StartAttempt,
+		payload.LastError,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,595 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Secrets (private key pems, including ACME account and private CA keys, as well as EAB
+// hmac keys, TOTP secrets, session extra data, and post processing client keys) can be
+// encrypted at rest using envelope encryption. A random data encryption key (dek)
+// encrypts the secrets. The dek is stored in the key_encryption table, wrapped
+// (encrypted) by a key encryption key (kek) that is derived from a secret that is never
+// stored in the db. Rotating the kek only requires re-wrapping the dek.
+
+const (
+	// encryptedPrefix marks a column value that is encrypted and bound to its table,
+	// column, and row
+	encryptedPrefix = "cwenc:v2:"
+
+	// legacyEncryptedPrefix marks a value encrypted by an older version (not bound to
+	// its row); these are re-encrypted on startup
+	legacyEncryptedPrefix = "cwenc:v1:"
+
+	// kekIterations is the pbkdf2 iteration count used to derive the kek from its secret
+	kekIterations = 600_000
+	kekSaltLen    = 16
+
+	// dek is made of a 32 byte aes-256 key and a 32 byte key used for blind indexes
+	dekLen = 64
+)
+
+var (
+	errKeyEncryptionMultipleSources = errors.New("storage: key encryption key must be configured from only one source (key_file, key_env, or passphrase)")
+	errKeyEncryptionKeyMissing      = errors.New("storage: database secrets are encrypted but no key encryption key is configured")
+	errKeyEncryptionKeyIncorrect    = errors.New("storage: configured key encryption key can't decrypt the database's data encryption key")
+	errKeyEncryptionDisabled        = errors.New("storage: value is encrypted but key encryption is not enabled")
+	errKeyEncryptionCiphertextBad   = errors.New("storage: encrypted value is malformed")
+)
+
+// KeyEncryptionConfig configures encryption of secrets (e.g., private key pems) at rest. To rotate the
+// key encryption key, configure the new key and set Previous to the old key. The old
+// key is only used to unwrap the data encryption key so it can be re-wrapped with the
+// new key; once rotated, Previous can be removed.
+type KeyEncryptionConfig struct {
+	KeyEncryptionKeySource `yaml:",inline"`
+	Previous               *KeyEncryptionKeySource `yaml:"previous"`
+}
+
+// KeyEncryptionKeySource specifies where the secret that the key encryption key is
+// derived from comes from. At most one source should be set.
+type KeyEncryptionKeySource struct {
+	KeyFile    string `yaml:"key_file"`
+	KeyEnv     string `yaml:"key_env"`
+	Passphrase string `yaml:"passphrase"`
+}
+
+// secret returns the secret of the source, or blank if no source is configured
+func (src KeyEncryptionKeySource) secret() (string, error) {
+	count := 0
+	for _, s := range []string{src.KeyFile, src.KeyEnv, src.Passphrase} {
+		if s != "" {
+			count++
+		}
+	}
+	if count > 1 {
+		return "", errKeyEncryptionMultipleSources
+	}
+
+	switch {
+	case src.KeyFile != "":
+		data, err := os.ReadFile(src.KeyFile)
+		if err != nil {
+			return "", fmt.Errorf("storage: failed to read key encryption key file (%w)", err)
+		}
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("storage: key encryption key file %s is empty", src.KeyFile)
+		}
+		return secret, nil
+
+	case src.KeyEnv != "":
+		secret := strings.TrimSpace(os.Getenv(src.KeyEnv))
+		if secret == "" {
+			return "", fmt.Errorf("storage: key encryption key environment variable %s is empty", src.KeyEnv)
+		}
+		return secret, nil
+
+	default:
+		return src.Passphrase, nil
+	}
+}
+
+// keyEncryption encrypts and decrypts secrets using the dek
+type keyEncryption struct {
+	aead     cipher.AEAD
+	indexKey []byte
+}
+
+// newKeyEncryption returns a keyEncryption using the (unwrapped) dek
+func newKeyEncryption(dek []byte) (*keyEncryption, error) {
+	if len(dek) != dekLen {
+		return nil, errKeyEncryptionCiphertextBad
+	}
+
+	block, err := aes.NewCipher(dek[:32])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyEncryption{aead: aead, indexKey: dek[32:]}, nil
+}
+
+// secretColumn is a column that holds a secret, which is encrypted when key encryption
+// is enabled. Each table's primary key is id.
+type secretColumn struct {
+	table  string
+	column string
+	// hashColumn (if any) holds a blind index of the secret, so the secret column can
+	// still be unique once encrypted
+	hashColumn string
+}
+
+var (
+	secretPrivateKeyPem        = secretColumn{"private_keys", "pem", "pem_hash"}
+	secretEabHmacKey           = secretColumn{"acme_frontend_eab_keys", "hmac_key", ""}
+	secretUserTotp             = secretColumn{"users", "totp_secret", ""}
+	secretSessionExtraData     = secretColumn{"sessions", "extra_data", ""}
+	secretCertificateClientKey = secretColumn{"certificates", "post_processing_client_key", ""}
+)
+
+// encryptedColumns are all of the columns that hold secrets
+var encryptedColumns = []secretColumn{
+	secretPrivateKeyPem,
+	secretEabHmacKey,
+	secretUserTotp,
+	secretSessionExtraData,
+	secretCertificateClientKey,
+}
+
+// additionalData returns the aead additional data that binds an encrypted value to
+// its table, column, and row
+func (col secretColumn) additionalData(id any) []byte {
+	return []byte(encryptedPrefix + col.table + "\x00" + col.column + "\x00" + fmt.Sprint(id))
+}
+
+// encrypt encrypts a secret (e.g., a private key pem) for storage in the column of the
+// row with id. The value can only be decrypted for that same table, column, and row,
+// so it can't be moved to another row undetected. If ke is nil, the secret is returned
+// unchanged.
+func (ke *keyEncryption) encrypt(col secretColumn, id any, secret string) (string, error) {
+	if ke == nil || secret == "" {
+		return secret, nil
+	}
+
+	nonce := make([]byte, ke.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := ke.aead.Seal(nonce, nonce, []byte(secret), col.additionalData(id))
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt returns the secret of an encrypted value of the column of the row with id.
+// Values that aren't encrypted are returned unchanged.
+func (ke *keyEncryption) decrypt(col secretColumn, id any, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		if strings.HasPrefix(value, legacyEncryptedPrefix) {
+			return "", errKeyEncryptionCiphertextBad
+		}
+		return value, nil
+	}
+	if ke == nil {
+		return "", errKeyEncryptionDisabled
+	}
+
+	return ke.open(strings.TrimPrefix(value, encryptedPrefix), col.additionalData(id))
+}
+
+// decryptLegacy returns the secret of a value encrypted by an older version, which
+// was only bound to its prefix
+func (ke *keyEncryption) decryptLegacy(value string) (string, error) {
+	return ke.open(strings.TrimPrefix(value, legacyEncryptedPrefix), []byte(legacyEncryptedPrefix))
+}
+
+// open decodes and decrypts sealed (the encoded nonce and ciphertext)
+func (ke *keyEncryption) open(sealedB64 string, additionalData []byte) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(sealedB64)
+	if err != nil || len(sealed) < ke.aead.NonceSize() {
+		return "", errKeyEncryptionCiphertextBad
+	}
+
+	nonce, ciphertext := sealed[:ke.aead.NonceSize()], sealed[ke.aead.NonceSize():]
+	secret, err := ke.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return "", errKeyEncryptionCiphertextBad
+	}
+
+	return string(secret), nil
+}
+
+// blindIndex returns a keyed hash of the secret for the column's hashColumn, or nil if
+// ke is nil (unencrypted secrets are compared directly)
+func (ke *keyEncryption) blindIndex(col secretColumn, secret string) any {
+	if ke == nil || secret == "" {
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, ke.indexKey)
+	mac.Write([]byte(col.table + "\x00" + col.column + "\x00" + secret))
+
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// putSecretTx saves secret to the column of the row with id, encrypting it first if
+// ke is enabled. Rows are inserted with a blank secret and then this is used, since
+// the encrypted value depends on the row's id.
+func putSecretTx(ctx context.Context, tx *sql.Tx, ke *keyEncryption, col secretColumn, id any, secret string) error {
+	value, err := ke.encrypt(col, id, secret)
+	if err != nil {
+		return err
+	}
+
+	if col.hashColumn == "" {
+		query := fmt.Sprintf(`
+		UPDATE
+			%[1]s
+		SET
+			%[2]s = $1
+		WHERE
+			id = $2
+		`, col.table, col.column)
+
+		_, err = tx.ExecContext(ctx, query, value, id)
+		return err
+	}
+
+	query := fmt.Sprintf(`
+	UPDATE
+		%[1]s
+	SET
+		%[2]s = $1,
+		%[3]s = $2
+	WHERE
+		id = $3
+	`, col.table, col.column, col.hashColumn)
+
+	_, err = tx.ExecContext(ctx, query, value, ke.blindIndex(col, secret), id)
+	return err
+}
+
+// deriveKek derives the kek from secret and salt
+func deriveKek(secret string, salt []byte) (cipher.AEAD, error) {
+	kek, err := pbkdf2.Key(sha256.New, secret, salt, kekIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// wrapDek derives a kek from secret (with a new random salt) and uses it to encrypt
+// the dek. The encoded salt and wrapped dek are returned.
+func wrapDek(secret string, dek []byte) (saltB64 string, wrappedDekB64 string, err error) {
+	salt := make([]byte, kekSaltLen)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return "", "", err
+	}
+
+	kek, err := deriveKek(secret, salt)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce := make([]byte, kek.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", "", err
+	}
+	wrapped := kek.Seal(nonce, nonce, dek, nil)
+
+	return base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(wrapped), nil
+}
+
+// unwrapDek derives the kek from secret and the encoded salt and uses it to decrypt
+// the wrapped dek
+func unwrapDek(secret string, saltB64 string, wrappedDekB64 string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(saltB64)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(wrappedDekB64)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := deriveKek(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < kek.NonceSize() {
+		return nil, errKeyEncryptionCiphertextBad
+	}
+
+	dek, err := kek.Open(nil, wrapped[:kek.NonceSize()], wrapped[kek.NonceSize():], nil)
+	if err != nil {
+		return nil, errKeyEncryptionKeyIncorrect
+	}
+
+	return dek, nil
+}
+
+// configureKeyEncryption sets up encryption of secrets based on cfg. If a kek is
+// configured and the db isn't encrypted yet, a dek is created and all existing secrets
+// are encrypted. If the configured kek can't unwrap the dek but the previous kek can,
+// the kek is rotated.
+func (store *Storage) configureKeyEncryption(cfg *Config) error {
+	secret, err := cfg.KeyEncryption.secret()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// existing dek
+	query := `
+	SELECT
+		kek_salt, wrapped_dek
+	FROM
+		key_encryption
+	WHERE
+		id = 1
+	`
+
+	var saltB64, wrappedDekB64 string
+	err = store.db.QueryRowContext(ctx, query).Scan(&saltB64, &wrappedDekB64)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	dbEncrypted := err == nil
+
+	// not configured
+	if secret == "" {
+		if dbEncrypted {
+			return errKeyEncryptionKeyMissing
+		}
+		store.logger.Warn("storage: secret (private key) encryption at rest is not configured")
+		return nil
+	}
+
+	// first time enabled
+	if !dbEncrypted {
+		return store.enableKeyEncryption(secret)
+	}
+
+	// unwrap with the configured kek
+	dek, err := unwrapDek(secret, saltB64, wrappedDekB64)
+	if errors.Is(err, errKeyEncryptionKeyIncorrect) && cfg.KeyEncryption.Previous != nil {
+		// try previous kek and rotate if it works
+		var previousSecret string
+		previousSecret, err = cfg.KeyEncryption.Previous.secret()
+		if err != nil {
+			return err
+		}
+		if previousSecret == "" {
+			return errKeyEncryptionKeyIncorrect
+		}
+
+		dek, err = unwrapDek(previousSecret, saltB64, wrappedDekB64)
+		if err != nil {
+			return err
+		}
+
+		err = store.rewrapDek(dek, secret)
+		if err != nil {
+			return err
+		}
+		store.logger.Info("storage: secret encryption key rotated (the previous key is no longer needed)")
+	} else if err != nil {
+		return err
+	}
+
+	store.keyEncryption, err = newKeyEncryption(dek)
+	if err != nil {
+		return err
+	}
+
+	// encrypt anything that isn't already (e.g., written before a failed enable or
+	// before this version encrypted it)
+	return store.encryptExisting()
+}
+
+// enableKeyEncryption creates a new dek, wraps it with the kek derived from secret, and
+// encrypts all existing secrets, all in one transaction
+func (store *Storage) enableKeyEncryption(secret string) error {
+	dek := make([]byte, dekLen)
+	_, err := rand.Read(dek)
+	if err != nil {
+		return err
+	}
+
+	saltB64, wrappedDekB64, err := wrapDek(secret, dek)
+	if err != nil {
+		return err
+	}
+
+	ke, err := newKeyEncryption(dek)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO key_encryption (id, kek_salt, wrapped_dek, created_at, updated_at)
+	VALUES (1, $1, $2, $3, $3)
+	`
+
+	_, err = tx.ExecContext(ctx, query, saltB64, wrappedDekB64, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	count, err := encryptSecretsTx(ctx, tx, ke)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	store.keyEncryption = ke
+	store.logger.Infof("storage: secret encryption at rest enabled (%d existing secrets encrypted)", count)
+
+	return nil
+}
+
+// encryptExisting encrypts any secrets that are not yet encrypted
+func (store *Storage) encryptExisting() error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	count, err := encryptSecretsTx(ctx, tx, store.keyEncryption)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		store.logger.Infof("storage: encrypted %d unencrypted secrets", count)
+	}
+
+	return nil
+}
+
+// encryptSecretsTx encrypts all unencrypted (and legacy encrypted) secrets using ke
+// and returns the number of secrets that were encrypted
+func encryptSecretsTx(ctx context.Context, tx *sql.Tx, ke *keyEncryption) (int, error) {
+	count := 0
+	for _, col := range encryptedColumns {
+		plain, err := unencryptedSecretsTx(ctx, tx, ke, col)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, one := range plain {
+			err = putSecretTx(ctx, tx, ke, col, one.id, one.secret)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		count += len(plain)
+	}
+
+	return count, nil
+}
+
+// idSecret is the id of a row and its secret
+type idSecret struct {
+	id     any
+	secret string
+}
+
+// unencryptedSecretsTx returns the secrets of col that aren't encrypted in the current
+// format. Legacy encrypted secrets are decrypted.
+func unencryptedSecretsTx(ctx context.Context, tx *sql.Tx, ke *keyEncryption, col secretColumn) ([]idSecret, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		id, %[2]s
+	FROM
+		%[1]s
+	WHERE
+		%[2]s != '' AND %[2]s NOT LIKE $1
+	`, col.table, col.column)
+
+	rows, err := tx.QueryContext(ctx, query, encryptedPrefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plain []idSecret
+	for rows.Next() {
+		var one idSecret
+		err = rows.Scan(&one.id, &one.secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(one.secret, legacyEncryptedPrefix) {
+			one.secret, err = ke.decryptLegacy(one.secret)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		plain = append(plain, one)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return plain, nil
+}
+
+// rewrapDek wraps dek with a kek derived from newSecret and saves it
+func (store *Storage) rewrapDek(dek []byte, newSecret string) error {
+	saltB64, wrappedDekB64, err := wrapDek(newSecret, dek)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		key_encryption
+	SET
+		kek_salt = $1,
+		wrapped_dek = $2,
+		updated_at = $3
+	WHERE
+		id = 1
+	`
+
+	_, err = store.db.ExecContext(ctx, query, saltB64, wrappedDekB64, time.Now().Unix())
+	return err
+}
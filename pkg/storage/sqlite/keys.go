@@ -14,30 +14,29 @@ type keyDb struct {
 	description    string
 	algorithmValue string
 	pem            string
-	apiKey         string
-	apiKeyNew      string
 	apiKeyDisabled bool
-	apiKeyViaUrl   bool
 	lastAccess     int64
 	createdAt      int64
 	updatedAt      int64
 }
 
 // toKey maps the database key info to the private_keys Key
-// object
-func (key keyDb) toKey() private_keys.Key {
+// object, decrypting the pem with ke if it is encrypted
+func (key keyDb) toKey(ke *keyEncryption) (private_keys.Key, error) {
+	pem, err := ke.decrypt(secretPrivateKeyPem, key.id, key.pem)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+
 	return private_keys.Key{
 		ID:             key.id,
 		Name:           key.name,
 		Description:    key.description,
 		Algorithm:      key_crypto.AlgorithmByStorageValue(key.algorithmValue),
-		Pem:            key.pem,
-		ApiKey:         key.apiKey,
-		ApiKeyNew:      key.apiKeyNew,
+		Pem:            pem,
 		ApiKeyDisabled: key.apiKeyDisabled,
-		ApiKeyViaUrl:   key.apiKeyViaUrl,
 		LastAccess:     time.Unix(key.lastAccess, 0),
 		CreatedAt:      time.Unix(key.createdAt, 0),
 		UpdatedAt:      time.Unix(key.updatedAt, 0),
-	}
+	}, nil
 }
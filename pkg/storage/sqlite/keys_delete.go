@@ -57,6 +57,21 @@ func (store *Storage) KeyInUse(id int) (inUse bool, err error) {
 		return true, nil
 	}
 
+	// check not in use in private cas
+	// if scan in succeeds, record exists in private_cas
+	query = `
+	SELECT id
+	FROM private_cas
+	WHERE private_key_id = $1
+	`
+
+	row = store.db.QueryRowContext(ctx, query, id)
+	temp = -2
+	row.Scan(&temp)
+	if temp != -2 {
+		return true, nil
+	}
+
 	// check not in use by valid order on an existing cert_id with longest dated expiration
 	// query groups valid orders by cert_id and then returns a result if the
 	// order has the max valid_to for a particular key (the one being deleted)
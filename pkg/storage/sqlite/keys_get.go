@@ -36,8 +36,8 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 	// validated prior to this query being assembled!
 	query := fmt.Sprintf(`
 	SELECT
-		id, name, description, algorithm, pem, api_key, api_key_new, api_key_disabled,
-		api_key_via_url, last_access, created_at, updated_at,
+		id, name, description, algorithm, pem, api_key_disabled, last_access, created_at,
+		updated_at,
 
 		count(*) OVER() AS full_count
 	FROM
@@ -71,10 +71,7 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 			&oneKeyDb.description,
 			&oneKeyDb.algorithmValue,
 			&oneKeyDb.pem,
-			&oneKeyDb.apiKey,
-			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
-			&oneKeyDb.apiKeyViaUrl,
 			&oneKeyDb.lastAccess,
 			&oneKeyDb.createdAt,
 			&oneKeyDb.updatedAt,
@@ -85,7 +82,10 @@ func (store Storage) GetAllKeys(q pagination_sort.Query) (keys []private_keys.Ke
 			return nil, 0, err
 		}
 
-		convertedKey := oneKeyDb.toKey()
+		convertedKey, err := oneKeyDb.toKey(store.keyEncryption)
+		if err != nil {
+			return nil, 0, err
+		}
 
 		allKeys = append(allKeys, convertedKey)
 	}
@@ -110,8 +110,8 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 
 	query := `
 	SELECT
-		id, name, description, algorithm, pem, api_key, api_key_new, api_key_disabled,
-		api_key_via_url, last_access, created_at, updated_at
+		id, name, description, algorithm, pem, api_key_disabled, last_access, created_at,
+		updated_at
 	FROM
 		private_keys
 	WHERE
@@ -129,10 +129,7 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 		&oneKeyDb.description,
 		&oneKeyDb.algorithmValue,
 		&oneKeyDb.pem,
-		&oneKeyDb.apiKey,
-		&oneKeyDb.apiKeyNew,
 		&oneKeyDb.apiKeyDisabled,
-		&oneKeyDb.apiKeyViaUrl,
 		&oneKeyDb.lastAccess,
 		&oneKeyDb.createdAt,
 		&oneKeyDb.updatedAt,
@@ -147,21 +144,19 @@ func (store Storage) getOneKey(id int, name string) (private_keys.Key, error) {
 	}
 
 	// convert to KeyExtended
-	oneKeyExt := oneKeyDb.toKey()
-
-	return oneKeyExt, nil
+	return oneKeyDb.toKey(store.keyEncryption)
 }
 
 // GetAvailableKeys returns a slice of private keys that exist but are not already associated
-// with a known ACME account or certificate
+// with a known ACME account, certificate, or private CA
 func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
 
 	query := `
 		SELECT
-			pk.id, pk.name, pk.description, pk.algorithm, pk.pem, pk.api_key, pk.api_key_new,
-			pk.api_key_disabled, pk.api_key_via_url, pk.last_access, pk.created_at, pk.updated_at
+			pk.id, pk.name, pk.description, pk.algorithm, pk.pem, 
+			pk.api_key_disabled, pk.last_access, pk.created_at, pk.updated_at
 		FROM
 		  private_keys pk
 		WHERE
@@ -182,6 +177,15 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 				WHERE
 					pk.id = c.private_key_id
 			)
+			AND
+			NOT EXISTS(
+				SELECT
+					pca.private_key_id
+				FROM
+					private_cas pca
+				WHERE
+					pk.id = pca.private_key_id
+			)
 		ORDER BY name
 	`
 
@@ -201,10 +205,7 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 			&oneKeyDb.description,
 			&oneKeyDb.algorithmValue,
 			&oneKeyDb.pem,
-			&oneKeyDb.apiKey,
-			&oneKeyDb.apiKeyNew,
 			&oneKeyDb.apiKeyDisabled,
-			&oneKeyDb.apiKeyViaUrl,
 			&oneKeyDb.lastAccess,
 			&oneKeyDb.createdAt,
 			&oneKeyDb.updatedAt,
@@ -213,7 +214,10 @@ func (store *Storage) GetAvailableKeys() ([]private_keys.Key, error) {
 			return nil, err
 		}
 
-		oneKeyExt := oneKeyDb.toKey()
+		oneKeyExt, err := oneKeyDb.toKey(store.keyEncryption)
+		if err != nil {
+			return nil, err
+		}
 
 		availableKeys = append(availableKeys, oneKeyExt)
 	}
@@ -1,45 +1,69 @@
 package sqlite
 
 import (
+	"certwarden-backend/pkg/domain/api_keys"
 	"certwarden-backend/pkg/domain/private_keys"
 	"context"
 )
 
-// PostNewKey saves the KeyExtended to the db as a new key
-func (store *Storage) PostNewKey(payload private_keys.NewPayload) (private_keys.Key, error) {
+// PostNewKey saves the KeyExtended to the db as a new key, along with its initial
+// api key, and returns the key and the id of its initial api key
+func (store *Storage) PostNewKey(payload private_keys.NewPayload) (private_keys.Key, int, error) {
 	// database action
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
 
+	// transaction so the key and its api key are saved together
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return private_keys.Key{}, -1, err
+	}
+	defer tx.Rollback()
+
 	query := `
-	INSERT INTO private_keys (name, description, algorithm, pem, api_key, api_key_disabled, api_key_via_url, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	INSERT INTO private_keys (name, description, algorithm, pem, api_key_disabled, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
 	RETURNING id
 	`
 
-	// insert and scan the new id
+	// insert and scan the new id (pem is saved once the id is known)
 	id := -1
-	err := store.db.QueryRowContext(ctx, query,
+	err = tx.QueryRowContext(ctx, query,
 		payload.Name,
 		payload.Description,
 		payload.AlgorithmValue,
-		payload.PemContent,
-		payload.ApiKey,
+		"",
 		payload.ApiKeyDisabled,
-		payload.ApiKeyViaUrl,
 		payload.CreatedAt,
 		payload.UpdatedAt,
 	).Scan(&id)
 
 	if err != nil {
-		return private_keys.Key{}, err
+		return private_keys.Key{}, -1, err
+	}
+
+	// pem (encrypted, if enabled)
+	err = putSecretTx(ctx, tx, store.keyEncryption, secretPrivateKeyPem, id, *payload.PemContent)
+	if err != nil {
+		return private_keys.Key{}, -1, err
+	}
+
+	// initial api key
+	apiKeyId, err := insertInitialApiKeyTx(ctx, tx, api_keys.Owner{Type: api_keys.OwnerPrivateKey, ID: id}, payload.ApiKeyHash, payload.CreatedAt)
+	if err != nil {
+		return private_keys.Key{}, -1, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return private_keys.Key{}, -1, err
 	}
 
 	// get updated key to return
 	updatedKey, err := store.GetOneKeyById(id)
 	if err != nil {
-		return private_keys.Key{}, err
+		return private_keys.Key{}, -1, err
 	}
 
-	return updatedKey, nil
+	return updatedKey, apiKeyId, nil
 }
@@ -0,0 +1,334 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/private_keys"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var errRotateKeyChanged = errors.New("certificate private key changed during rotation")
+
+// CountValidOrdersFinalizedWithKey returns the number of valid orders of the specified
+// cert that were finalized with the specified key
+func (store *Storage) CountValidOrdersFinalizedWithKey(certId int, keyId int) (count int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		COUNT(*)
+	FROM
+		acme_orders
+	WHERE
+		certificate_id = $1
+		AND
+		finalized_key_id = $2
+		AND
+		status = 'valid'
+	`
+
+	err = store.db.QueryRowContext(ctx, query, certId, keyId).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// RotateCertKey saves newKey and replaces the specified cert's key (oldKeyId) with it. The
+// old key is recorded as retired so it can be deleted once it is no longer needed; it stays
+// fully usable (including its api keys) until CompleteCertKeyRotation runs. If the cert's key
+// is no longer oldKeyId, nothing is changed and an error is returned.
+func (store *Storage) RotateCertKey(certId int, oldKeyId int, newKey private_keys.NewPayload) (private_keys.Key, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// transaction so the swap is all or nothing
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+	defer tx.Rollback()
+
+	// insert the new key (pem is saved once the id is known)
+	query := `
+	INSERT INTO private_keys (name, description, algorithm, pem, api_key_disabled, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`
+
+	newKeyId := -1
+	err = tx.QueryRowContext(ctx, query,
+		newKey.Name,
+		newKey.Description,
+		newKey.AlgorithmValue,
+		"",
+		newKey.ApiKeyDisabled,
+		newKey.CreatedAt,
+		newKey.UpdatedAt,
+	).Scan(&newKeyId)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+
+	// pem (encrypted, if enabled)
+	err = putSecretTx(ctx, tx, store.keyEncryption, secretPrivateKeyPem, newKeyId, *newKey.PemContent)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+
+	// swap the cert's key
+	query = `
+	UPDATE
+		certificates
+	SET
+		private_key_id = $1,
+		updated_at = $2
+	WHERE
+		id = $3
+		AND
+		private_key_id = $4
+	`
+
+	result, err := tx.ExecContext(ctx, query,
+		newKeyId,
+		newKey.UpdatedAt,
+		certId,
+		oldKeyId,
+	)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+	if rows != 1 {
+		return private_keys.Key{}, errRotateKeyChanged
+	}
+
+	// record the old key as retired (its api access is handed over to the new key by
+	// CompleteCertKeyRotation once an order finalized with the new key is valid)
+	query = `
+	INSERT INTO retired_private_keys (private_key_id, certificate_id, retired_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (private_key_id) DO NOTHING
+	`
+
+	_, err = tx.ExecContext(ctx, query,
+		oldKeyId,
+		certId,
+		newKey.UpdatedAt,
+	)
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return private_keys.Key{}, err
+	}
+
+	// get new key to return
+	return store.GetOneKeyById(newKeyId)
+}
+
+// CompleteCertKeyRotation finishes the rotations of the specified cert's key once the cert
+// has a valid order finalized with its current key: the api keys of the cert's retired keys
+// are moved to the current key and the retired keys' api access is disabled. Until then, the
+// newest valid order still uses a retired key, so downloads of it must keep working. The
+// number of retired keys that were updated is returned.
+func (store *Storage) CompleteCertKeyRotation(certId int) (completed int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// cert's current key, if it has been used to finalize a valid order
+	query := `
+	SELECT
+		c.private_key_id
+	FROM
+		certificates c
+	WHERE
+		c.id = $1
+		AND
+		c.private_key_id IS NOT NULL
+		AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				acme_orders ao
+			WHERE
+				ao.certificate_id = c.id
+				AND
+				ao.finalized_key_id = c.private_key_id
+				AND
+				ao.status = 'valid'
+		)
+	`
+
+	currentKeyId := -1
+	err = tx.QueryRowContext(ctx, query, certId).Scan(&currentKeyId)
+	if err != nil {
+		// no valid order with the current key yet, nothing to do
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	// move the retired keys' api keys to the current key
+	query = `
+	UPDATE
+		api_keys
+	SET
+		private_key_id = $1
+	WHERE
+		private_key_id IN (
+			SELECT
+				private_key_id
+			FROM
+				retired_private_keys
+			WHERE
+				certificate_id = $2
+				AND
+				private_key_id != $1
+		)
+	`
+
+	result, err := tx.ExecContext(ctx, query, currentKeyId, certId)
+	if err != nil {
+		return 0, err
+	}
+	movedApiKeys, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// disable the retired keys' api access
+	query = `
+	UPDATE
+		private_keys
+	SET
+		api_key_disabled = 1,
+		updated_at = $1
+	WHERE
+		api_key_disabled = 0
+		AND
+		id IN (
+			SELECT
+				private_key_id
+			FROM
+				retired_private_keys
+			WHERE
+				certificate_id = $2
+				AND
+				private_key_id != $3
+		)
+	`
+
+	result, err = tx.ExecContext(ctx, query, time.Now().Unix(), certId, currentKeyId)
+	if err != nil {
+		return 0, err
+	}
+	disabledKeys, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(max(movedApiKeys, disabledKeys)), nil
+}
+
+// DeleteUnusedRetiredKeys deletes retired keys that are no longer in use and that were
+// not used to finalize any valid order whose certificate has not yet expired. Keys that
+// still have api keys (i.e., their rotation hasn't been completed) are kept. The ids of
+// the deleted keys are returned.
+func (store *Storage) DeleteUnusedRetiredKeys() (deletedKeyIds []int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		rpk.private_key_id
+	FROM
+		retired_private_keys rpk
+	WHERE
+		NOT EXISTS (
+			SELECT
+				1
+			FROM
+				acme_orders ao
+			WHERE
+				ao.finalized_key_id = rpk.private_key_id
+				AND
+				ao.status = 'valid'
+				AND
+				ao.known_revoked = 0
+				AND
+				ao.valid_to > $1
+		)
+		AND
+		NOT EXISTS (
+			SELECT
+				1
+			FROM
+				api_keys ak
+			WHERE
+				ak.private_key_id = rpk.private_key_id
+		)
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keyIds := []int{}
+	for rows.Next() {
+		keyId := -1
+		err = rows.Scan(&keyId)
+		if err != nil {
+			return nil, err
+		}
+		keyIds = append(keyIds, keyId)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// delete each key that isn't otherwise in use (DeleteKey checks)
+	deletedKeyIds = []int{}
+	for _, keyId := range keyIds {
+		inUse, err := store.KeyInUse(keyId)
+		if err != nil {
+			return deletedKeyIds, err
+		}
+		if inUse {
+			continue
+		}
+
+		err = store.DeleteKey(keyId)
+		if err != nil {
+			return deletedKeyIds, err
+		}
+		deletedKeyIds = append(deletedKeyIds, keyId)
+	}
+
+	return deletedKeyIds, nil
+}
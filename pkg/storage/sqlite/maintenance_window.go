@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"encoding/json"
+)
+
+// maintenanceWindowDb is a single maintenance window, as database table fields
+// corresponds to maintenance_windows.MaintenanceWindow
+type maintenanceWindowDb struct {
+	id          int
+	name        string
+	description string
+	timezone    string
+	ranges      string // stored as json array
+	freezes     string // stored as json array
+	createdAt   int
+	updatedAt   int
+}
+
+func (mw maintenanceWindowDb) toMaintenanceWindow() (maintenance_windows.MaintenanceWindow, error) {
+	ranges := []maintenance_windows.WeeklyRange{}
+	err := json.Unmarshal([]byte(mw.ranges), &ranges)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	freezes := []maintenance_windows.FreezePeriod{}
+	err = json.Unmarshal([]byte(mw.freezes), &freezes)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	return maintenance_windows.MaintenanceWindow{
+		ID:          mw.id,
+		Name:        mw.name,
+		Description: mw.description,
+		Timezone:    mw.timezone,
+		Ranges:      ranges,
+		Freezes:     freezes,
+		CreatedAt:   mw.createdAt,
+		UpdatedAt:   mw.updatedAt,
+	}, nil
+}
+
+// makeJsonArray marshals a slice to a json array string; if the slice is nil, nil is
+// returned (so updates leave the stored value unchanged)
+func makeJsonArray[T any](slice []T) (*string, error) {
+	if slice == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(slice)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonArray := string(data)
+	return &jsonArray, nil
+}
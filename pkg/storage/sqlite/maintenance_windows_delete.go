@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/storage"
+	"context"
+)
+
+// MaintenanceWindowHasCerts returns true if the specified windowId is assigned to
+// any of the certificates in the db
+func (store *Storage) MaintenanceWindowHasCerts(windowId int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// don't check window exists, business logic in app should do this
+
+	// check window id is not in use in certificates
+	query := `
+	SELECT id
+	FROM certificates
+	WHERE maintenance_window_id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, windowId)
+	temp := -2
+
+	err := row.Scan(&temp)
+	// error means no certs for the window (includes error no rows)
+	return err == nil
+}
+
+// DeleteMaintenanceWindow deletes a maintenance window from the database
+func (store *Storage) DeleteMaintenanceWindow(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// check window exists
+	// if scan in succeeds, window exists
+	query := `
+	SELECT id
+	FROM maintenance_windows
+	WHERE id = $1
+	`
+
+	row := tx.QueryRowContext(ctx, query, id)
+	temp := -2
+	row.Scan(&temp)
+	if temp == -2 {
+		return storage.ErrNoRecord
+	}
+
+	// check not in use in certs
+	// if scan in succeeds, record exists in certificates
+	query = `
+	SELECT id
+	FROM certificates
+	WHERE maintenance_window_id = $1
+	`
+
+	row = tx.QueryRowContext(ctx, query, id)
+	temp = -2
+	row.Scan(&temp)
+	if temp != -2 {
+		return storage.ErrInUse
+	}
+
+	// delete
+	query = `
+	DELETE FROM
+		maintenance_windows
+	WHERE
+		id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// GetAllMaintenanceWindows returns a slice of all of the maintenance windows in the database
+func (store *Storage) GetAllMaintenanceWindows(q pagination_sort.Query) (windows []maintenance_windows.MaintenanceWindow, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "id"
+	case "name":
+		sortField = "name"
+	case "description":
+		sortField = "description"
+	case "timezone":
+		sortField = "timezone"
+	// default if not in allowed list
+	default:
+		sortField = "name"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		id, name, description, timezone, ranges, freezes, created_at, updated_at,
+		count(*) OVER() AS full_count
+	FROM
+		maintenance_windows
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	allWindows := []maintenance_windows.MaintenanceWindow{}
+	for rows.Next() {
+		var oneWindow maintenanceWindowDb
+		err = rows.Scan(
+			&oneWindow.id,
+			&oneWindow.name,
+			&oneWindow.description,
+			&oneWindow.timezone,
+			&oneWindow.ranges,
+			&oneWindow.freezes,
+			&oneWindow.createdAt,
+			&oneWindow.updatedAt,
+
+			&totalRows,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		convertedWindow, err := oneWindow.toMaintenanceWindow()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allWindows = append(allWindows, convertedWindow)
+	}
+
+	return allWindows, totalRows, nil
+}
+
+// GetOneMaintenanceWindowById returns a maintenance window based on its unique id
+func (store *Storage) GetOneMaintenanceWindowById(id int) (maintenance_windows.MaintenanceWindow, error) {
+	return store.getOneMaintenanceWindow(id, "")
+}
+
+// GetOneMaintenanceWindowByName returns a maintenance window based on its unique name
+func (store *Storage) GetOneMaintenanceWindowByName(name string) (maintenance_windows.MaintenanceWindow, error) {
+	return store.getOneMaintenanceWindow(-1, name)
+}
+
+// getOneMaintenanceWindow returns a maintenance window based on either its unique id or its
+// unique name
+func (store *Storage) getOneMaintenanceWindow(id int, name string) (maintenance_windows.MaintenanceWindow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, name, description, timezone, ranges, freezes, created_at, updated_at
+	FROM
+		maintenance_windows
+	WHERE id = $1 OR name = $2
+	ORDER BY id`
+
+	row := store.db.QueryRowContext(ctx, query, id, name)
+
+	var oneWindow maintenanceWindowDb
+
+	err := row.Scan(
+		&oneWindow.id,
+		&oneWindow.name,
+		&oneWindow.description,
+		&oneWindow.timezone,
+		&oneWindow.ranges,
+		&oneWindow.freezes,
+		&oneWindow.createdAt,
+		&oneWindow.updatedAt,
+	)
+
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	return oneWindow.toMaintenanceWindow()
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"context"
+)
+
+// PostNewMaintenanceWindow inserts a new maintenance window into the db
+func (store *Storage) PostNewMaintenanceWindow(payload maintenance_windows.NewPayload) (maintenance_windows.MaintenanceWindow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// don't check for in use in storage. main app business logic should
+	// take care of it
+
+	// always store arrays
+	if payload.Ranges == nil {
+		payload.Ranges = []maintenance_windows.WeeklyRange{}
+	}
+	ranges, err := makeJsonArray(payload.Ranges)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+	if payload.Freezes == nil {
+		payload.Freezes = []maintenance_windows.FreezePeriod{}
+	}
+	freezes, err := makeJsonArray(payload.Freezes)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	// insert the new window
+	query := `
+	INSERT INTO maintenance_windows (name, description, timezone, ranges, freezes, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`
+
+	id := -1
+	err = store.db.QueryRowContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.Timezone,
+		ranges,
+		freezes,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	// get new window to return
+	newWindow, err := store.GetOneMaintenanceWindowById(id)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	return newWindow, nil
+}
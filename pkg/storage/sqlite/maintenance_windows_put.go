@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/maintenance_windows"
+	"context"
+)
+
+// PutDetailsMaintenanceWindow updates a maintenance window in the database. It only
+// updates the details which are provided.
+func (store *Storage) PutDetailsMaintenanceWindow(payload maintenance_windows.DetailsUpdatePayload) (maintenance_windows.MaintenanceWindow, error) {
+	ranges, err := makeJsonArray(payload.Ranges)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+	freezes, err := makeJsonArray(payload.Freezes)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	// database update
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		maintenance_windows
+	SET
+		name = case when $1 is null then name else $1 end,
+		description = case when $2 is null then description else $2 end,
+		timezone = case when $3 is null then timezone else $3 end,
+		ranges = case when $4 is null then ranges else $4 end,
+		freezes = case when $5 is null then freezes else $5 end,
+		updated_at = $6
+	WHERE
+		id = $7
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.Timezone,
+		ranges,
+		freezes,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	// get updated to return
+	updatedWindow, err := store.GetOneMaintenanceWindowById(payload.ID)
+	if err != nil {
+		return maintenance_windows.MaintenanceWindow{}, err
+	}
+
+	return updatedWindow, nil
+}
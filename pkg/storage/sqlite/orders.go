@@ -19,6 +19,7 @@ type orderDb struct {
 	err            sql.NullString // stored as json object
 	expires        sql.NullInt32
 	dnsIdentifiers jsonStringSlice // stored as json array
+	ipIdentifiers  jsonStringSlice // stored as json array
 	authorizations jsonStringSlice // stored as json array
 	finalize       string
 	finalizedKey   keyDb
@@ -33,12 +34,15 @@ type orderDb struct {
 	renewalInfo    sql.NullString
 }
 
-func (order orderDb) toOrder() (orders.Order, error) {
+func (order orderDb) toOrder(ke *keyEncryption) (orders.Order, error) {
 	// handle if key is not null (id value would not be okay from coalesce if null)
 	var key *private_keys.Key
 	if order.finalizedKey.id >= 0 {
-		key = new(private_keys.Key)
-		*key = order.finalizedKey.toKey()
+		finalizedKey, err := order.finalizedKey.toKey(ke)
+		if err != nil {
+			return orders.Order{}, err
+		}
+		key = &finalizedKey
 	}
 
 	// handle acme Error
@@ -48,7 +52,7 @@ func (order orderDb) toOrder() (orders.Order, error) {
 	}
 
 	// convert cert
-	cert, err := order.certificate.toCertificate()
+	cert, err := order.certificate.toCertificate(ke)
 	if err != nil {
 		return orders.Order{}, err
 	}
@@ -68,6 +72,7 @@ func (order orderDb) toOrder() (orders.Order, error) {
 		Error:          acmeErr,
 		Expires:        nullInt32ToInt(order.expires),
 		DnsIdentifiers: order.dnsIdentifiers.toSlice(),
+		IpIdentifiers:  order.ipIdentifiers.toSlice(),
 		Authorizations: order.authorizations.toSlice(),
 		Finalize:       order.finalize,
 		FinalizedKey:   key,
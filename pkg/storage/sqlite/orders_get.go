@@ -44,37 +44,41 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
 		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
 
 		/* order's cert */
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
-		c.last_access, c.created_at, c.updated_at, c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, 
-		c.post_processing_environment, c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.last_access, c.created_at, c.updated_at, c.post_processing_command, 
+		c.post_processing_environment, c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
 		/* cert's key */
-		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new,
-		ck.api_key_disabled, ck.api_key_via_url, ck.last_access, ck.created_at, ck.updated_at,
+		COALESCE(ck.id, -2), COALESCE(ck.name, 'null'), COALESCE(ck.description, 'null'), COALESCE(ck.algorithm, 'null'),
+		COALESCE(ck.pem, 'null'), COALESCE(ck.api_key_disabled, false),
+		COALESCE(ck.last_access, -2), COALESCE(ck.created_at, -2), COALESCE(ck.updated_at, -2),
 
 		/* cert's account */
-		ca.id, ca.name, ca.description, ca.status, ca.email, ca.accepted_tos,
-		ca.created_at, ca.updated_at, ca.kid,
+		COALESCE(ca.id, -2), COALESCE(ca.name, 'null'), COALESCE(ca.description, 'null'), COALESCE(ca.status, 'null'),
+		COALESCE(ca.email, 'null'), COALESCE(ca.accepted_tos, false), COALESCE(ca.created_at, -2), COALESCE(ca.updated_at, -2),
+		COALESCE(ca.kid, 'null'),
 
 		/* cert's account's server */
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
 		/* cert's account's key */
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new,
-		ak.api_key_disabled, ak.api_key_via_url, ak.last_access, ak.created_at, ak.updated_at,
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2),
 
 		/* finalized key */
 		COALESCE(fk.id, -2), COALESCE(fk.name, 'null'), COALESCE(fk.description, 'null'), 
-		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key, 'null'), 
-		COALESCE(fk.api_key_new, 'null'), COALESCE(fk.api_key_disabled, false),
-		COALESCE(fk.api_key_via_url, false), COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2),
+		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key_disabled, false),
+		COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2),
 		
 		count(*) OVER() AS full_count
 	FROM
@@ -84,6 +88,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 		LEFT JOIN acme_accounts ca on (c.acme_account_id = ca.id)
 		LEFT JOIN acme_servers aserv on (ca.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (ca.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 		LEFT JOIN private_keys fk on (ao.finalized_key_id = fk.id)
 	WHERE 
 		ao.status = "valid"
@@ -133,6 +138,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -160,24 +166,29 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.certificate.lastAccess,
 			&oneOrder.certificate.createdAt,
 			&oneOrder.certificate.updatedAt,
-			&oneOrder.certificate.apiKey,
-			&oneOrder.certificate.apiKeyNew,
-			&oneOrder.certificate.apiKeyViaUrl,
 			&oneOrder.certificate.postProcessingCommand,
 			&oneOrder.certificate.postProcessingEnvironment,
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.requestedValidityHours,
+			&oneOrder.certificate.keyRotationInterval,
+			&oneOrder.certificate.renewalPolicy,
+			&oneOrder.certificate.maintenanceWindowId,
+			&oneOrder.certificate.orderFailureStreak,
+			&oneOrder.certificate.orderFailureLastAt,
+			&oneOrder.certificate.orderFailureLastError,
+			&oneOrder.certificate.orderRetryAt,
+			&oneOrder.certificate.privateCAId,
+			&oneOrder.certificate.privateCAName,
+			&oneOrder.certificate.csrPem,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateKeyDb.pem,
-			&oneOrder.certificate.certificateKeyDb.apiKey,
-			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateKeyDb.lastAccess,
 			&oneOrder.certificate.certificateKeyDb.createdAt,
 			&oneOrder.certificate.certificateKeyDb.updatedAt,
@@ -205,10 +216,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.lastAccess,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.createdAt,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.updatedAt,
@@ -218,10 +226,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
 			&oneOrder.finalizedKey.pem,
-			&oneOrder.finalizedKey.apiKey,
-			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
-			&oneOrder.finalizedKey.apiKeyViaUrl,
 			&oneOrder.finalizedKey.lastAccess,
 			&oneOrder.finalizedKey.createdAt,
 			&oneOrder.finalizedKey.updatedAt,
@@ -233,7 +238,7 @@ func (store *Storage) GetAllValidCurrentOrders(q pagination_sort.Query) (orders
 		}
 
 		// convert and append
-		oneOrderConvert, err := oneOrder.toOrder()
+		oneOrderConvert, err := oneOrder.toOrder(store.keyEncryption)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -277,7 +282,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
 		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
 
@@ -285,30 +290,34 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.post_processing_command, c.post_processing_environment,
+		c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
 		/* cert's key */
-		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ck.api_key_new, ck.api_key_disabled,
-		ck.api_key_via_url,	ck.last_access, ck.created_at, ck.updated_at,
+		COALESCE(ck.id, -2), COALESCE(ck.name, 'null'), COALESCE(ck.description, 'null'), COALESCE(ck.algorithm, 'null'),
+		COALESCE(ck.pem, 'null'), COALESCE(ck.api_key_disabled, false),
+		COALESCE(ck.last_access, -2), COALESCE(ck.created_at, -2), COALESCE(ck.updated_at, -2),
 
 		/* cert's account */
-		ca.id, ca.name, ca.description, ca.status, ca.email, ca.accepted_tos,
-		ca.created_at, ca.updated_at, ca.kid,
+		COALESCE(ca.id, -2), COALESCE(ca.name, 'null'), COALESCE(ca.description, 'null'), COALESCE(ca.status, 'null'),
+		COALESCE(ca.email, 'null'), COALESCE(ca.accepted_tos, false), COALESCE(ca.created_at, -2), COALESCE(ca.updated_at, -2),
+		COALESCE(ca.kid, 'null'),
 
 		/* cert's account's server */
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
 		/* cert's account's key */
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new, ak.api_key_disabled,
-		ak.api_key_via_url,	ak.last_access, ak.created_at, ak.updated_at,
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2),
 
 		/* finalized key */
 		COALESCE(fk.id, -2), COALESCE(fk.name, 'null'), COALESCE(fk.description, 'null'), 
-		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key, 'null'),
-		COALESCE(fk.api_key_new, 'null'), COALESCE(fk.api_key_disabled, false),
-		COALESCE(fk.api_key_via_url, false), COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2),
+		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key_disabled, false),
+		COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2),
 
 		count(*) OVER() AS full_count
 	FROM
@@ -318,6 +327,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 		LEFT JOIN acme_accounts ca on (c.acme_account_id = ca.id)
 		LEFT JOIN acme_servers aserv on (ca.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (ca.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 		LEFT JOIN private_keys fk on (ao.finalized_key_id = fk.id)
 	WHERE
 		ao.certificate_id = $1
@@ -353,6 +363,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -380,24 +391,29 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.certificate.lastAccess,
 			&oneOrder.certificate.createdAt,
 			&oneOrder.certificate.updatedAt,
-			&oneOrder.certificate.apiKey,
-			&oneOrder.certificate.apiKeyNew,
-			&oneOrder.certificate.apiKeyViaUrl,
 			&oneOrder.certificate.postProcessingCommand,
 			&oneOrder.certificate.postProcessingEnvironment,
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.requestedValidityHours,
+			&oneOrder.certificate.keyRotationInterval,
+			&oneOrder.certificate.renewalPolicy,
+			&oneOrder.certificate.maintenanceWindowId,
+			&oneOrder.certificate.orderFailureStreak,
+			&oneOrder.certificate.orderFailureLastAt,
+			&oneOrder.certificate.orderFailureLastError,
+			&oneOrder.certificate.orderRetryAt,
+			&oneOrder.certificate.privateCAId,
+			&oneOrder.certificate.privateCAName,
+			&oneOrder.certificate.csrPem,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateKeyDb.pem,
-			&oneOrder.certificate.certificateKeyDb.apiKey,
-			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateKeyDb.lastAccess,
 			&oneOrder.certificate.certificateKeyDb.createdAt,
 			&oneOrder.certificate.certificateKeyDb.updatedAt,
@@ -425,10 +441,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.lastAccess,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.createdAt,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.updatedAt,
@@ -438,10 +451,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
 			&oneOrder.finalizedKey.pem,
-			&oneOrder.finalizedKey.apiKey,
-			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
-			&oneOrder.finalizedKey.apiKeyViaUrl,
 			&oneOrder.finalizedKey.lastAccess,
 			&oneOrder.finalizedKey.createdAt,
 			&oneOrder.finalizedKey.updatedAt,
@@ -453,7 +463,7 @@ func (store *Storage) GetOrdersByCert(certId int, q pagination_sort.Query) (orde
 		}
 
 		// convert and append
-		oneOrderConvert, err := oneOrder.toOrder()
+		oneOrderConvert, err := oneOrder.toOrder(store.keyEncryption)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -566,7 +576,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 	query := fmt.Sprintf(`
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
 		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
 
@@ -574,29 +584,33 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile, 
+		c.post_processing_command, c.post_processing_environment,
+		c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
 		/* cert's key */
-		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ak.api_key_new, ck.api_key_disabled,
-		ck.api_key_via_url,	ck.last_access, ck.created_at, ck.updated_at,
+		COALESCE(ck.id, -2), COALESCE(ck.name, 'null'), COALESCE(ck.description, 'null'), COALESCE(ck.algorithm, 'null'),
+		COALESCE(ck.pem, 'null'), COALESCE(ck.api_key_disabled, false),
+		COALESCE(ck.last_access, -2), COALESCE(ck.created_at, -2), COALESCE(ck.updated_at, -2),
 
 		/* cert's account */
-		ca.id, ca.name, ca.description, ca.status, ca.email, ca.accepted_tos,
-		ca.created_at, ca.updated_at, ca.kid,
+		COALESCE(ca.id, -2), COALESCE(ca.name, 'null'), COALESCE(ca.description, 'null'), COALESCE(ca.status, 'null'),
+		COALESCE(ca.email, 'null'), COALESCE(ca.accepted_tos, false), COALESCE(ca.created_at, -2), COALESCE(ca.updated_at, -2),
+		COALESCE(ca.kid, 'null'),
 
 		/* cert's account's server */
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
 		/* cert's account's key */
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new, ak.api_key_disabled,
-		ak.api_key_via_url,	ak.last_access, ak.created_at, ak.updated_at,
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2),
 
 		/* finalized key */
 		COALESCE(fk.id, -2), COALESCE(fk.name, 'null'), COALESCE(fk.description, 'null'), 
-		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key, 'null'),
-		COALESCE(fk.api_key_new, 'null'), COALESCE(fk.api_key_disabled, false), COALESCE(fk.api_key_via_url, false),
+		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key_disabled, false), 
 		COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2)
 	FROM
 		acme_orders ao
@@ -605,6 +619,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 		LEFT JOIN acme_accounts ca on (c.acme_account_id = ca.id)
 		LEFT JOIN acme_servers aserv on (ca.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (ca.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 		LEFT JOIN private_keys fk on (ao.finalized_key_id = fk.id)
 	WHERE
 		ao.id IN (%s)
@@ -628,6 +643,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.err,
 			&oneOrder.expires,
 			&oneOrder.dnsIdentifiers,
+			&oneOrder.ipIdentifiers,
 			&oneOrder.authorizations,
 			&oneOrder.finalize,
 			&oneOrder.certificateUrl,
@@ -655,24 +671,29 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.certificate.lastAccess,
 			&oneOrder.certificate.createdAt,
 			&oneOrder.certificate.updatedAt,
-			&oneOrder.certificate.apiKey,
-			&oneOrder.certificate.apiKeyNew,
-			&oneOrder.certificate.apiKeyViaUrl,
 			&oneOrder.certificate.postProcessingCommand,
 			&oneOrder.certificate.postProcessingEnvironment,
 			&oneOrder.certificate.postProcessingClientAddress,
 			&oneOrder.certificate.postProcessingClientKeyB64,
 			&oneOrder.certificate.profile,
+			&oneOrder.certificate.requestedValidityHours,
+			&oneOrder.certificate.keyRotationInterval,
+			&oneOrder.certificate.renewalPolicy,
+			&oneOrder.certificate.maintenanceWindowId,
+			&oneOrder.certificate.orderFailureStreak,
+			&oneOrder.certificate.orderFailureLastAt,
+			&oneOrder.certificate.orderFailureLastError,
+			&oneOrder.certificate.orderRetryAt,
+			&oneOrder.certificate.privateCAId,
+			&oneOrder.certificate.privateCAName,
+			&oneOrder.certificate.csrPem,
 
 			&oneOrder.certificate.certificateKeyDb.id,
 			&oneOrder.certificate.certificateKeyDb.name,
 			&oneOrder.certificate.certificateKeyDb.description,
 			&oneOrder.certificate.certificateKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateKeyDb.pem,
-			&oneOrder.certificate.certificateKeyDb.apiKey,
-			&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateKeyDb.lastAccess,
 			&oneOrder.certificate.certificateKeyDb.createdAt,
 			&oneOrder.certificate.certificateKeyDb.updatedAt,
@@ -700,10 +721,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-			&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.lastAccess,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.createdAt,
 			&oneOrder.certificate.certificateAccountDb.accountKeyDb.updatedAt,
@@ -713,10 +731,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 			&oneOrder.finalizedKey.description,
 			&oneOrder.finalizedKey.algorithmValue,
 			&oneOrder.finalizedKey.pem,
-			&oneOrder.finalizedKey.apiKey,
-			&oneOrder.finalizedKey.apiKeyNew,
 			&oneOrder.finalizedKey.apiKeyDisabled,
-			&oneOrder.finalizedKey.apiKeyViaUrl,
 			&oneOrder.finalizedKey.lastAccess,
 			&oneOrder.finalizedKey.createdAt,
 			&oneOrder.finalizedKey.updatedAt,
@@ -730,7 +745,7 @@ func (store *Storage) GetOrders(orderIDs []int) (orders []orders.Order, err erro
 		}
 
 		// convert and append
-		oneOrderConvert, err := oneOrder.toOrder()
+		oneOrderConvert, err := oneOrder.toOrder(store.keyEncryption)
 		if err != nil {
 			return nil, err
 		}
@@ -774,7 +789,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 	query := `
 	SELECT
 		/* order */
-		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, 
+		ao.id, ao.acme_location, ao.status, ao.known_revoked, ao.error, ao.expires, ao.dns_identifiers, ao.ip_identifiers,
 		ao.authorizations, ao.finalize, ao.certificate_url, ao.pem, ao.valid_from, ao.valid_to, ao.chain_root_cn,
 		ao.profile, ao.renewal_info, ao.created_at, ao.updated_at, 
 
@@ -782,29 +797,33 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		c.id, c.name, c.description, c.subject, c.subject_alts,
 		c.csr_org, c.csr_ou, c.csr_country, c.csr_state, c.csr_city, c.csr_extra_extensions, c.preferred_root_cn,
 		c.last_access, c.created_at, c.updated_at,
-		c.api_key, c.api_key_new, c.api_key_via_url, c.post_processing_command, c.post_processing_environment,
-		c.post_processing_client_address, c.post_processing_client_key, c.profile,
+		c.post_processing_command, c.post_processing_environment,
+		c.post_processing_client_address, c.post_processing_client_key, c.profile, c.requested_validity_hours, c.key_rotation_interval, c.renewal_policy, c.maintenance_window_id,
+		c.order_failure_streak, c.order_failure_last_at, c.order_failure_last_error, c.order_retry_at,
+		c.private_ca_id, pca.name, c.csr_pem,
 		
 		/* cert's key */
-		ck.id, ck.name, ck.description, ck.algorithm, ck.pem, ck.api_key, ak.api_key_new, ck.api_key_disabled,
-		ck.api_key_via_url,	ck.last_access, ck.created_at, ck.updated_at,
+		COALESCE(ck.id, -2), COALESCE(ck.name, 'null'), COALESCE(ck.description, 'null'), COALESCE(ck.algorithm, 'null'),
+		COALESCE(ck.pem, 'null'), COALESCE(ck.api_key_disabled, false),
+		COALESCE(ck.last_access, -2), COALESCE(ck.created_at, -2), COALESCE(ck.updated_at, -2),
 
 		/* cert's account */
-		ca.id, ca.name, ca.description, ca.status, ca.email, ca.accepted_tos,
-		ca.created_at, ca.updated_at, ca.kid,
+		COALESCE(ca.id, -2), COALESCE(ca.name, 'null'), COALESCE(ca.description, 'null'), COALESCE(ca.status, 'null'),
+		COALESCE(ca.email, 'null'), COALESCE(ca.accepted_tos, false), COALESCE(ca.created_at, -2), COALESCE(ca.updated_at, -2),
+		COALESCE(ca.kid, 'null'),
 
 		/* cert's account's server */
-		aserv.id, aserv.name, aserv.description, aserv.directory_url, aserv.is_staging, aserv.created_at,
-		aserv.updated_at,
+		COALESCE(aserv.id, -2), COALESCE(aserv.name, 'null'), COALESCE(aserv.description, 'null'), COALESCE(aserv.directory_url, 'null'),
+		COALESCE(aserv.is_staging, false), COALESCE(aserv.created_at, -2), COALESCE(aserv.updated_at, -2),
 
 		/* cert's account's key */
-		ak.id, ak.name, ak.description, ak.algorithm, ak.pem, ak.api_key, ak.api_key_new, ak.api_key_disabled,
-		ak.api_key_via_url,	ak.last_access, ak.created_at, ak.updated_at,
+		COALESCE(ak.id, -2), COALESCE(ak.name, 'null'), COALESCE(ak.description, 'null'), COALESCE(ak.algorithm, 'null'),
+		COALESCE(ak.pem, 'null'), COALESCE(ak.api_key_disabled, false),
+		COALESCE(ak.last_access, -2), COALESCE(ak.created_at, -2), COALESCE(ak.updated_at, -2),
 
 		/* finalized key */
 		COALESCE(fk.id, -2), COALESCE(fk.name, 'null'), COALESCE(fk.description, 'null'), 
-		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key, 'null'),
-		COALESCE(fk.api_key_new, 'null'), COALESCE(fk.api_key_disabled, false), COALESCE(fk.api_key_via_url, false),
+		COALESCE(fk.algorithm, 'null'), COALESCE(fk.pem, 'null'), COALESCE(fk.api_key_disabled, false), 
 		COALESCE(fk.last_access, -2), COALESCE(fk.created_at, -2), COALESCE(fk.updated_at, -2)
 	FROM
 		acme_orders ao
@@ -813,6 +832,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		LEFT JOIN acme_accounts ca on (c.acme_account_id = ca.id)
 		LEFT JOIN acme_servers aserv on (ca.acme_server_id = aserv.id)
 		LEFT JOIN private_keys ak on (ca.private_key_id = ak.id)
+		LEFT JOIN private_cas pca on (c.private_ca_id = pca.id)
 		LEFT JOIN private_keys fk on (ao.finalized_key_id = fk.id)
 
 	WHERE
@@ -851,6 +871,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.err,
 		&oneOrder.expires,
 		&oneOrder.dnsIdentifiers,
+		&oneOrder.ipIdentifiers,
 		&oneOrder.authorizations,
 		&oneOrder.finalize,
 		&oneOrder.certificateUrl,
@@ -878,24 +899,29 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.certificate.lastAccess,
 		&oneOrder.certificate.createdAt,
 		&oneOrder.certificate.updatedAt,
-		&oneOrder.certificate.apiKey,
-		&oneOrder.certificate.apiKeyNew,
-		&oneOrder.certificate.apiKeyViaUrl,
 		&oneOrder.certificate.postProcessingCommand,
 		&oneOrder.certificate.postProcessingEnvironment,
 		&oneOrder.certificate.postProcessingClientAddress,
 		&oneOrder.certificate.postProcessingClientKeyB64,
 		&oneOrder.certificate.profile,
+		&oneOrder.certificate.requestedValidityHours,
+		&oneOrder.certificate.keyRotationInterval,
+		&oneOrder.certificate.renewalPolicy,
+		&oneOrder.certificate.maintenanceWindowId,
+		&oneOrder.certificate.orderFailureStreak,
+		&oneOrder.certificate.orderFailureLastAt,
+		&oneOrder.certificate.orderFailureLastError,
+		&oneOrder.certificate.orderRetryAt,
+		&oneOrder.certificate.privateCAId,
+		&oneOrder.certificate.privateCAName,
+		&oneOrder.certificate.csrPem,
 
 		&oneOrder.certificate.certificateKeyDb.id,
 		&oneOrder.certificate.certificateKeyDb.name,
 		&oneOrder.certificate.certificateKeyDb.description,
 		&oneOrder.certificate.certificateKeyDb.algorithmValue,
 		&oneOrder.certificate.certificateKeyDb.pem,
-		&oneOrder.certificate.certificateKeyDb.apiKey,
-		&oneOrder.certificate.certificateKeyDb.apiKeyNew,
 		&oneOrder.certificate.certificateKeyDb.apiKeyDisabled,
-		&oneOrder.certificate.certificateKeyDb.apiKeyViaUrl,
 		&oneOrder.certificate.certificateKeyDb.lastAccess,
 		&oneOrder.certificate.certificateKeyDb.createdAt,
 		&oneOrder.certificate.certificateKeyDb.updatedAt,
@@ -923,10 +949,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.description,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.algorithmValue,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.pem,
-		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKey,
-		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyNew,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyDisabled,
-		&oneOrder.certificate.certificateAccountDb.accountKeyDb.apiKeyViaUrl,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.lastAccess,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.createdAt,
 		&oneOrder.certificate.certificateAccountDb.accountKeyDb.updatedAt,
@@ -936,10 +959,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		&oneOrder.finalizedKey.description,
 		&oneOrder.finalizedKey.algorithmValue,
 		&oneOrder.finalizedKey.pem,
-		&oneOrder.finalizedKey.apiKey,
-		&oneOrder.finalizedKey.apiKeyNew,
 		&oneOrder.finalizedKey.apiKeyDisabled,
-		&oneOrder.finalizedKey.apiKeyViaUrl,
 		&oneOrder.finalizedKey.lastAccess,
 		&oneOrder.finalizedKey.createdAt,
 		&oneOrder.finalizedKey.updatedAt,
@@ -952,7 +972,7 @@ func (store *Storage) getCertNewestValidOrder(certId int, certName string) (orde
 		return orders.Order{}, err
 	}
 
-	order, err = oneOrder.toOrder()
+	order, err = oneOrder.toOrder(store.keyEncryption)
 	if err != nil {
 		return orders.Order{}, err
 	}
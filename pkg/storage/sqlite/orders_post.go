@@ -50,13 +50,15 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				known_revoked,
 				expires,
 				dns_identifiers,
+				ip_identifiers,
 				error,
 				authorizations,
 				finalize,
 				profile,
 				acme_location,
 				created_at,
-				updated_at
+				updated_at,
+				private_ca_id
 			)
 	VALUES
 			(
@@ -72,7 +74,9 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 				$10,
 				$11,
 				$12,
-				$13
+				$13,
+				$14,
+				$15
 			)
 	RETURNING
 		id
@@ -85,6 +89,7 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 		payload.KnownRevoked,
 		payload.Expires,
 		makeJsonStringSlice(payload.DnsIds),
+		makeJsonStringSlice(payload.IpIds),
 		payload.Error,
 		makeJsonStringSlice(payload.Authorizations),
 		payload.Finalize,
@@ -92,6 +97,7 @@ func (store *Storage) PostNewOrder(payload orders.NewOrderAcmePayload) (newId in
 		payload.Location,
 		payload.CreatedAt,
 		payload.UpdatedAt,
+		payload.PrivateCAId,
 	).Scan(&newId)
 
 	err = tx.Commit()
@@ -21,20 +21,22 @@ func (store *Storage) PutOrderAcme(payload orders.UpdateAcmeOrderPayload) (err e
 			status = $1,
 			expires = $2,
 			dns_identifiers = $3,
-			error = $4,
-			authorizations = $5,
-			finalize = $6,
-			profile = $7,
-			certificate_url = $8,
-			updated_at = $9
+			ip_identifiers = $4,
+			error = $5,
+			authorizations = $6,
+			finalize = $7,
+			profile = $8,
+			certificate_url = $9,
+			updated_at = $10
 		WHERE
-			id = $10
+			id = $11
 		`
 
 	_, err = store.db.ExecContext(ctx, query,
 		payload.Status,
 		payload.Expires,
 		makeJsonStringSlice(payload.DnsIds),
+		makeJsonStringSlice(payload.IpIds),
 		payload.Error,
 		makeJsonStringSlice(payload.Authorizations),
 		payload.Finalize,
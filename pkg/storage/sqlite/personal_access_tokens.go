@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"database/sql"
+)
+
+// personalAccessTokenDb is a single personal access token, as database table fields
+// corresponds to auth.Token
+type personalAccessTokenDb struct {
+	id         int
+	userId     int
+	name       string
+	tokenHash  string
+	role       string
+	expiresAt  sql.NullInt64
+	lastUsedAt int
+	lastUsedIP string
+	createdAt  int
+}
+
+func (token personalAccessTokenDb) toToken() auth.Token {
+	var expiresAt *int
+	if token.expiresAt.Valid {
+		expiresAt = new(int)
+		*expiresAt = int(token.expiresAt.Int64)
+	}
+
+	return auth.Token{
+		ID:         token.id,
+		UserID:     token.userId,
+		Name:       token.name,
+		TokenHash:  token.tokenHash,
+		Role:       auth.Role(token.role),
+		ExpiresAt:  expiresAt,
+		LastUsedAt: token.lastUsedAt,
+		LastUsedIP: token.lastUsedIP,
+		CreatedAt:  token.createdAt,
+	}
+}
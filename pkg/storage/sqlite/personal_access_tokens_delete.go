@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// DeleteToken deletes the specified personal access token
+func (store *Storage) DeleteToken(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		personal_access_tokens
+	WHERE
+		id = $1
+	`
+
+	_, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetUserTokens returns all of the personal access tokens of the specified user
+func (store *Storage) GetUserTokens(userId int) ([]auth.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, user_id, name, token_hash, role, expires_at, last_used_at, last_used_ip, created_at
+	FROM
+		personal_access_tokens
+	WHERE
+		user_id = $1
+	ORDER BY
+		name
+	`
+
+	rows, err := store.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []auth.Token{}
+	for rows.Next() {
+		var oneToken personalAccessTokenDb
+		err = rows.Scan(
+			&oneToken.id,
+			&oneToken.userId,
+			&oneToken.name,
+			&oneToken.tokenHash,
+			&oneToken.role,
+			&oneToken.expiresAt,
+			&oneToken.lastUsedAt,
+			&oneToken.lastUsedIP,
+			&oneToken.createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, oneToken.toToken())
+	}
+
+	return tokens, nil
+}
+
+// GetOneTokenById returns the specified personal access token
+func (store *Storage) GetOneTokenById(id int) (auth.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, user_id, name, token_hash, role, expires_at, last_used_at, last_used_ip, created_at
+	FROM
+		personal_access_tokens
+	WHERE
+		id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, id)
+
+	var token personalAccessTokenDb
+	err := row.Scan(
+		&token.id,
+		&token.userId,
+		&token.name,
+		&token.tokenHash,
+		&token.role,
+		&token.expiresAt,
+		&token.lastUsedAt,
+		&token.lastUsedIP,
+		&token.createdAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return auth.Token{}, err
+	}
+
+	return token.toToken(), nil
+}
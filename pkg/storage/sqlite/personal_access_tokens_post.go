@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"context"
+)
+
+// PostNewToken saves a new personal access token and returns it
+func (store *Storage) PostNewToken(payload auth.NewTokenPayload) (auth.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO personal_access_tokens (user_id, name, token_hash, role, expires_at, last_used_at, last_used_ip,
+		created_at)
+	VALUES ($1, $2, $3, $4, $5, 0, '', $6)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		payload.UserID,
+		payload.Name,
+		payload.TokenHash,
+		payload.Role,
+		payload.ExpiresAt,
+		payload.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return auth.Token{}, err
+	}
+
+	return store.GetOneTokenById(id)
+}
@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// PutTokenLastUsed records the time and client ip of a personal access token's latest use
+func (store *Storage) PutTokenLastUsed(id int, lastUsedAt int, lastUsedIP string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		personal_access_tokens
+	SET
+		last_used_at = $1,
+		last_used_ip = $2
+	WHERE
+		id = $3
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		lastUsedAt,
+		lastUsedIP,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
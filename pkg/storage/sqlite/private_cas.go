@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/private_cas"
+)
+
+// privateCADb is a single private ca, as database table fields
+// corresponds to private_cas.CA
+type privateCADb struct {
+	id                  int
+	name                string
+	description         string
+	caKeyDb             keyDb
+	certificatePem      string
+	chainPem            string
+	defaultValidityDays int
+	createdAt           int
+	updatedAt           int
+}
+
+func (ca privateCADb) toCA(ke *keyEncryption) (private_cas.CA, error) {
+	caKey, err := ca.caKeyDb.toKey(ke)
+	if err != nil {
+		return private_cas.CA{}, err
+	}
+
+	return private_cas.CA{
+		ID:                  ca.id,
+		Name:                ca.name,
+		Description:         ca.description,
+		CAKey:               caKey,
+		CertificatePem:      ca.certificatePem,
+		ChainPem:            ca.chainPem,
+		DefaultValidityDays: ca.defaultValidityDays,
+		CreatedAt:           ca.createdAt,
+		UpdatedAt:           ca.updatedAt,
+	}, nil
+}
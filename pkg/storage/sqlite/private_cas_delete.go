@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/storage"
+	"context"
+)
+
+// PrivateCAHasCerts returns true if the specified caId matches
+// any of the certificates in the db
+func (store *Storage) PrivateCAHasCerts(caId int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// don't check ca exists, business logic in app should do this
+
+	// check ca id is not in use in certificates
+	query := `
+	SELECT id
+	FROM certificates
+	WHERE private_ca_id = $1
+	`
+
+	row := store.db.QueryRowContext(ctx, query, caId)
+	temp := -2
+
+	err := row.Scan(&temp)
+	// error means no certs for the ca (includes error no rows)
+	return err == nil
+}
+
+// DeletePrivateCA deletes a private CA from the database
+func (store *Storage) DeletePrivateCA(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// check ca exists
+	// if scan in succeeds, ca exists
+	query := `
+	SELECT id
+	FROM private_cas
+	WHERE id = $1
+	`
+
+	row := tx.QueryRowContext(ctx, query, id)
+	temp := -2
+	row.Scan(&temp)
+	if temp == -2 {
+		return storage.ErrNoRecord
+	}
+
+	// check not in use in certs
+	// if scan in succeeds, record exists in certificates
+	query = `
+	SELECT id
+	FROM certificates
+	WHERE private_ca_id = $1
+	`
+
+	row = tx.QueryRowContext(ctx, query, id)
+	temp = -2
+	row.Scan(&temp)
+	if temp != -2 {
+		return storage.ErrInUse
+	}
+
+	// delete
+	query = `
+	DELETE FROM
+		private_cas
+	WHERE
+		id = $1
+	`
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
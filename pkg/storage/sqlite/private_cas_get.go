@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/private_cas"
+	"certwarden-backend/pkg/pagination_sort"
+	"certwarden-backend/pkg/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// GetAllPrivateCAs returns a slice of all of the private CAs in the database
+func (store *Storage) GetAllPrivateCAs(q pagination_sort.Query) (cas []private_cas.CA, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "pca.id"
+	case "name":
+		sortField = "pca.name"
+	case "description":
+		sortField = "pca.description"
+	case "keyname":
+		sortField = "pk.name"
+	// default if not in allowed list
+	default:
+		sortField = "pca.name"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		pca.id, pca.name, pca.description, pca.certificate_pem, pca.chain_pem, pca.default_validity_days,
+		pca.created_at, pca.updated_at,
+
+		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, 
+		pk.api_key_disabled, pk.last_access, pk.created_at, pk.updated_at,
+
+		count(*) OVER() AS full_count
+	FROM
+		private_cas pca
+		LEFT JOIN private_keys pk on (pca.private_key_id = pk.id)
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	var allCAs []private_cas.CA
+	for rows.Next() {
+		var oneCA privateCADb
+		err = rows.Scan(
+			&oneCA.id,
+			&oneCA.name,
+			&oneCA.description,
+			&oneCA.certificatePem,
+			&oneCA.chainPem,
+			&oneCA.defaultValidityDays,
+			&oneCA.createdAt,
+			&oneCA.updatedAt,
+
+			&oneCA.caKeyDb.id,
+			&oneCA.caKeyDb.name,
+			&oneCA.caKeyDb.description,
+			&oneCA.caKeyDb.algorithmValue,
+			&oneCA.caKeyDb.pem,
+			&oneCA.caKeyDb.apiKeyDisabled,
+			&oneCA.caKeyDb.lastAccess,
+			&oneCA.caKeyDb.createdAt,
+			&oneCA.caKeyDb.updatedAt,
+
+			&totalRows,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		convertedCA, err := oneCA.toCA(store.keyEncryption)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allCAs = append(allCAs, convertedCA)
+	}
+
+	return allCAs, totalRows, nil
+}
+
+// GetOnePrivateCAById returns a private CA based on its unique id
+func (store *Storage) GetOnePrivateCAById(id int) (private_cas.CA, error) {
+	return store.getOnePrivateCA(id, "")
+}
+
+// GetOnePrivateCAByName returns a private CA based on its unique name
+func (store *Storage) GetOnePrivateCAByName(name string) (private_cas.CA, error) {
+	return store.getOnePrivateCA(-1, name)
+}
+
+// getOnePrivateCA returns a private CA based on either its unique id or its unique name
+func (store *Storage) getOnePrivateCA(id int, name string) (private_cas.CA, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		pca.id, pca.name, pca.description, pca.certificate_pem, pca.chain_pem, pca.default_validity_days,
+		pca.created_at, pca.updated_at,
+
+		pk.id, pk.name, pk.description, pk.algorithm, pk.pem, 
+		pk.api_key_disabled, pk.last_access, pk.created_at, pk.updated_at
+	FROM
+		private_cas pca
+		LEFT JOIN private_keys pk on (pca.private_key_id = pk.id)
+	WHERE pca.id = $1 OR pca.name = $2
+	ORDER BY pca.id`
+
+	row := store.db.QueryRowContext(ctx, query, id, name)
+
+	var oneCA privateCADb
+
+	err := row.Scan(
+		&oneCA.id,
+		&oneCA.name,
+		&oneCA.description,
+		&oneCA.certificatePem,
+		&oneCA.chainPem,
+		&oneCA.defaultValidityDays,
+		&oneCA.createdAt,
+		&oneCA.updatedAt,
+
+		&oneCA.caKeyDb.id,
+		&oneCA.caKeyDb.name,
+		&oneCA.caKeyDb.description,
+		&oneCA.caKeyDb.algorithmValue,
+		&oneCA.caKeyDb.pem,
+		&oneCA.caKeyDb.apiKeyDisabled,
+		&oneCA.caKeyDb.lastAccess,
+		&oneCA.caKeyDb.createdAt,
+		&oneCA.caKeyDb.updatedAt,
+	)
+
+	if err != nil {
+		// if no record exists
+		if errors.Is(err, sql.ErrNoRows) {
+			err = storage.ErrNoRecord
+		}
+		return private_cas.CA{}, err
+	}
+
+	return oneCA.toCA(store.keyEncryption)
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/private_cas"
+	"context"
+)
+
+// PostNewPrivateCA inserts a new private CA into the db
+func (store *Storage) PostNewPrivateCA(payload private_cas.NewPayload) (private_cas.CA, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// don't check for in use in storage. main app business logic should
+	// take care of it
+
+	// insert the new ca
+	query := `
+	INSERT INTO private_cas (name, description, private_key_id, certificate_pem, chain_pem,
+		default_validity_days, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id
+	`
+
+	id := -1
+	err := store.db.QueryRowContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.PrivateKeyID,
+		payload.CertificatePem,
+		payload.ChainPem,
+		payload.DefaultValidityDays,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&id)
+
+	if err != nil {
+		return private_cas.CA{}, err
+	}
+
+	// get new ca to return
+	newCA, err := store.GetOnePrivateCAById(id)
+	if err != nil {
+		return private_cas.CA{}, err
+	}
+
+	return newCA, nil
+}
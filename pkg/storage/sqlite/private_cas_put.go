@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/private_cas"
+	"context"
+)
+
+// PutDetailsPrivateCA updates the editable details of a private CA in the database
+func (store *Storage) PutDetailsPrivateCA(payload private_cas.DetailsUpdatePayload) (private_cas.CA, error) {
+	// database update
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		private_cas
+	SET
+		name = case when $1 is null then name else $1 end,
+		description = case when $2 is null then description else $2 end,
+		default_validity_days = case when $3 is null then default_validity_days else $3 end,
+		updated_at = $4
+	WHERE
+		id = $5
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		payload.Name,
+		payload.Description,
+		payload.DefaultValidityDays,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+
+	if err != nil {
+		return private_cas.CA{}, err
+	}
+
+	// get updated ca to return
+	updatedCA, err := store.GetOnePrivateCAById(payload.ID)
+	if err != nil {
+		return private_cas.CA{}, err
+	}
+
+	return updatedCA, nil
+}
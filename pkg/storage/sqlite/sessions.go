@@ -0,0 +1,46 @@
+package sqlite
+
+import "certwarden-backend/pkg/domain/app/auth/session_manager"
+
+// sessionDb is a single saved login session, as database table fields
+// corresponds to session_manager.StoredSession
+type sessionDb struct {
+	id                    string
+	userType              string
+	username              string
+	role                  string
+	accessTokenHash       string
+	accessTokenExpiration int
+	sessionTokenHash      string
+	sessionExpiration     int
+	clientIP              string
+	userAgent             string
+	extraData             string // encrypted if key encryption is enabled
+	createdAt             int
+	lastRefreshAt         int
+}
+
+// toStoredSession maps the database session to the session_manager StoredSession,
+// decrypting the extra data with ke if it is encrypted
+func (session sessionDb) toStoredSession(ke *keyEncryption) (session_manager.StoredSession, error) {
+	extraData, err := ke.decrypt(secretSessionExtraData, session.id, session.extraData)
+	if err != nil {
+		return session_manager.StoredSession{}, err
+	}
+
+	return session_manager.StoredSession{
+		ID:                    session.id,
+		UserType:              session.userType,
+		Username:              session.username,
+		Role:                  session.role,
+		AccessTokenHash:       session.accessTokenHash,
+		AccessTokenExpiration: session.accessTokenExpiration,
+		SessionTokenHash:      session.sessionTokenHash,
+		SessionExpiration:     session.sessionExpiration,
+		ClientIP:              session.clientIP,
+		UserAgent:             session.userAgent,
+		ExtraData:             extraData,
+		CreatedAt:             session.createdAt,
+		LastRefreshAt:         session.lastRefreshAt,
+	}, nil
+}
@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"context"
+)
+
+// DeleteSession deletes the specified saved login session
+func (store *Storage) DeleteSession(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		sessions
+	WHERE
+		id = $1
+	`
+
+	_, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
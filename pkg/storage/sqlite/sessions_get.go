@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"context"
+)
+
+// GetAllSessions returns all of the saved login sessions
+func (store *Storage) GetAllSessions() ([]session_manager.StoredSession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		id, user_type, username, role, access_token_hash, access_token_expiration,
+		session_token_hash, session_expiration, client_ip, user_agent, extra_data, created_at,
+		last_refresh_at
+	FROM
+		sessions
+	`
+
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []session_manager.StoredSession{}
+	for rows.Next() {
+		var oneSession sessionDb
+		err = rows.Scan(
+			&oneSession.id,
+			&oneSession.userType,
+			&oneSession.username,
+			&oneSession.role,
+			&oneSession.accessTokenHash,
+			&oneSession.accessTokenExpiration,
+			&oneSession.sessionTokenHash,
+			&oneSession.sessionExpiration,
+			&oneSession.clientIP,
+			&oneSession.userAgent,
+			&oneSession.extraData,
+			&oneSession.createdAt,
+			&oneSession.lastRefreshAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		storedSession, err := oneSession.toStoredSession(store.keyEncryption)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, storedSession)
+	}
+
+	return sessions, nil
+}
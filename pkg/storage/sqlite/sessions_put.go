@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"context"
+)
+
+// PutSession saves a login session, replacing the saved session with the same id if
+// there is one
+func (store *Storage) PutSession(session session_manager.StoredSession) error {
+	// encrypt extra data (e.g., idp refresh token), if enabled
+	extraData, err := store.keyEncryption.encrypt(secretSessionExtraData, session.ID, session.ExtraData)
+	if err != nil {
+		return err
+	}
+
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO sessions (id, user_type, username, role, access_token_hash, access_token_expiration,
+		session_token_hash, session_expiration, client_ip, user_agent, extra_data, created_at,
+		last_refresh_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	ON CONFLICT (id) DO UPDATE SET
+		role = excluded.role,
+		access_token_hash = excluded.access_token_hash,
+		access_token_expiration = excluded.access_token_expiration,
+		session_token_hash = excluded.session_token_hash,
+		session_expiration = excluded.session_expiration,
+		client_ip = excluded.client_ip,
+		user_agent = excluded.user_agent,
+		extra_data = excluded.extra_data,
+		last_refresh_at = excluded.last_refresh_at
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserType,
+		session.Username,
+		session.Role,
+		session.AccessTokenHash,
+		session.AccessTokenExpiration,
+		session.SessionTokenHash,
+		session.SessionExpiration,
+		session.ClientIP,
+		session.UserAgent,
+		extraData,
+		session.CreatedAt,
+		session.LastRefreshAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testExtraFuncs implements session_manager.ExtraFuncs
+type testExtraFuncs struct{}
+
+func (ef testExtraFuncs) RefreshCheck() error          { return nil }
+func (ef testExtraFuncs) SessionData() (string, error) { return "extra", nil }
+
+// loadTestSessionManager returns a SessionManager with the sessions saved in store
+func loadTestSessionManager(t *testing.T, store *Storage) *session_manager.SessionManager {
+	t.Helper()
+
+	sm := session_manager.NewSessionManager(true, false, zap.NewNop().Sugar(), store)
+	err := sm.LoadSessions(func(session_manager.StoredSession) (session_manager.ExtraFuncs, error) { return testExtraFuncs{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sm
+}
+
+// sessionAuthenticates returns if the access token is valid in sm
+func sessionAuthenticates(sm *session_manager.SessionManager, accessToken string) bool {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", accessToken)
+
+	_, err := sm.ValidateAuthHeader(r, httptest.NewRecorder(), "")
+	return err == nil
+}
+
+func TestSessions_Storage(t *testing.T) {
+	dataPath := t.TempDir()
+
+	store, err := openTestStorage(t, dataPath, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm := loadTestSessionManager(t, store)
+
+	// log in two users
+	r := httptest.NewRequest(http.MethodPost, "/login", nil)
+	kept, err := sm.NewSession(r, "kept", session_manager.UserTypeLocal, "admin", testExtraFuncs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := sm.NewSession(r, "revoked", session_manager.UserTypeLocal, "admin", testExtraFuncs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only hashes of the tokens are stored
+	w := httptest.NewRecorder()
+	kept.WriteSessionCookie(w)
+	sessionToken := w.Result().Cookies()[0].Value
+
+	var id, accessTokenHash, sessionTokenHash string
+	err = store.db.QueryRow("SELECT id, access_token_hash, session_token_hash FROM sessions WHERE username = 'kept'").Scan(&id, &accessTokenHash, &sessionTokenHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []struct {
+		name, value, token string
+	}{{"access_token_hash", accessTokenHash, kept.AccessToken}, {"session_token_hash", sessionTokenHash, sessionToken}} {
+		hash := sha256.Sum256([]byte(column.token))
+		if column.value != hex.EncodeToString(hash[:]) {
+			t.Errorf("stored %s is not the hash of the token", column.name)
+		}
+	}
+	var row string
+	err = store.db.QueryRow("SELECT id || user_type || username || role || access_token_hash || session_token_hash || client_ip || user_agent || extra_data FROM sessions WHERE id = $1", id).Scan(&row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(row, kept.AccessToken) || strings.Contains(row, sessionToken) {
+		t.Error("token is stored")
+	}
+
+	// revoke and restart
+	if deleted := sm.DeleteUserSessions(session_manager.UserTypeLocal, "revoked"); deleted != 1 {
+		t.Fatalf("deleted %d sessions, expected 1", deleted)
+	}
+	_ = store.Close()
+
+	store, err = openTestStorage(t, dataPath, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm = loadTestSessionManager(t, store)
+
+	if !sessionAuthenticates(sm, kept.AccessToken) {
+		t.Error("restored session doesn't authenticate")
+	}
+	if sessionAuthenticates(sm, accessTokenHash) {
+		t.Error("stored access token hash authenticates")
+	}
+	if sessionAuthenticates(sm, revoked.AccessToken) {
+		t.Error("revoked session authenticates")
+	}
+
+	sessions, err := store.GetAllSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].Username != "kept" || sessions[0].ExtraData != "extra" {
+		t.Errorf("stored sessions %v (%v), expected only the kept session", sessions, err)
+	}
+}
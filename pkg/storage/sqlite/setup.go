@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
 	"context"
 	"database/sql"
 	"errors"
@@ -19,7 +20,7 @@ import (
 // config for DB
 const dbTimeout = time.Duration(5 * time.Second)
 const DbFilename = "appdata.db"
-const DbCurrentUserVersion = 11
+const DbCurrentUserVersion = 31
 const dbFileMode = 0600
 
 var dbOptions = url.Values{
@@ -28,6 +29,11 @@ var dbOptions = url.Values{
 
 var errServiceComponent = errors.New("necessary storage service component is missing")
 
+// Config is the configuration for storage
+type Config struct {
+	KeyEncryption KeyEncryptionConfig `yaml:"key_encryption"`
+}
+
 type App interface {
 	GetDataStorageAppDataPath() string
 	GetLogger() *zap.SugaredLogger
@@ -36,14 +42,16 @@ type App interface {
 
 // Storage is the struct that holds data about the connection
 type Storage struct {
-	logger  *zap.SugaredLogger
-	db      *sql.DB
-	timeout time.Duration
+	logger        *zap.SugaredLogger
+	db            *sql.DB
+	timeout       time.Duration
+	keyEncryption *keyEncryption
 }
 
 // OpenStorage opens an existing sqlite database or creates a new one if needed.
-// It also creates tables. It then returns Storage.
-func OpenStorage(app App) (*Storage, error) {
+// It also creates tables and configures private key encryption. It then returns
+// Storage.
+func OpenStorage(app App, cfg *Config) (*Storage, error) {
 	store := new(Storage)
 	var err error
 
@@ -114,9 +122,12 @@ func OpenStorage(app App) (*Storage, error) {
 	}
 
 	// check and do db schema upgrades, if needed
-	// get db file user_version
+	// get db file user_version (new context, populating a new db may have used up the ping's)
+	versionCtx, versionCancel := context.WithTimeout(context.Background(), store.timeout)
+	defer versionCancel()
+
 	query := `PRAGMA user_version`
-	row := store.db.QueryRowContext(ctx, query)
+	row := store.db.QueryRowContext(versionCtx, query)
 	fileUserVersion := -1
 	err = row.Scan(
 		&fileUserVersion,
@@ -221,11 +232,176 @@ func OpenStorage(app App) (*Storage, error) {
 		}
 	}
 
+	// upgrade if schema 11
+	if fileUserVersion == 11 {
+		fileUserVersion, err = store.migrateV11toV12()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 12
+	if fileUserVersion == 12 {
+		fileUserVersion, err = store.migrateV12toV13()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 13
+	if fileUserVersion == 13 {
+		fileUserVersion, err = store.migrateV13toV14()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 14
+	if fileUserVersion == 14 {
+		fileUserVersion, err = store.migrateV14toV15()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 15
+	if fileUserVersion == 15 {
+		fileUserVersion, err = store.migrateV15toV16()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 16
+	if fileUserVersion == 16 {
+		fileUserVersion, err = store.migrateV16toV17()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 17
+	if fileUserVersion == 17 {
+		fileUserVersion, err = store.migrateV17toV18()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 18
+	if fileUserVersion == 18 {
+		fileUserVersion, err = store.migrateV18toV19()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 19
+	if fileUserVersion == 19 {
+		fileUserVersion, err = store.migrateV19toV20()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 20
+	if fileUserVersion == 20 {
+		fileUserVersion, err = store.migrateV20toV21()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 21
+	if fileUserVersion == 21 {
+		fileUserVersion, err = store.migrateV21toV22()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 22
+	if fileUserVersion == 22 {
+		fileUserVersion, err = store.migrateV22toV23()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 23
+	if fileUserVersion == 23 {
+		fileUserVersion, err = store.migrateV23toV24()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 24
+	if fileUserVersion == 24 {
+		fileUserVersion, err = store.migrateV24toV25()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 25
+	if fileUserVersion == 25 {
+		fileUserVersion, err = store.migrateV25toV26()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 26
+	if fileUserVersion == 26 {
+		fileUserVersion, err = store.migrateV26toV27()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 27
+	if fileUserVersion == 27 {
+		fileUserVersion, err = store.migrateV27toV28()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 28
+	if fileUserVersion == 28 {
+		fileUserVersion, err = store.migrateV28toV29()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// upgrade if schema 29
+	if fileUserVersion == 29 {
+		fileUserVersion, err = store.migrateV29toV30()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fileUserVersion == 30 {
+		fileUserVersion, err = store.migrateV30toV31()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// fail if still not correct
 	if fileUserVersion != DbCurrentUserVersion {
 		return nil, fmt.Errorf("db schema user_version is %d (expected %d) and automatic migration failed", fileUserVersion, DbCurrentUserVersion)
 	}
 
+	// private key encryption at rest
+	err = store.configureKeyEncryption(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	return store, nil
 }
 
@@ -241,6 +417,13 @@ func (store *Storage) Close() error {
 
 // populateNewDb creates the tables in the db file and sets the db version
 func (store *Storage) populateNewDb() error {
+	// hash the default user's password before starting the timed transaction (bcrypt is
+	// intentionally slow)
+	defaultHashedPw, err := bcrypt.GenerateFromPassword([]byte(defaultPassword), auth.BcryptCost)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
 
@@ -261,7 +444,7 @@ func (store *Storage) populateNewDb() error {
 	}
 
 	// create tables
-	err = createDBTablesV11(tx)
+	err = createDBTablesV31(tx)
 	if err != nil {
 		return err
 	}
@@ -273,7 +456,7 @@ func (store *Storage) populateNewDb() error {
 	}
 
 	// insert default user
-	err = insertDefaultUser(tx)
+	err = insertDefaultUser(tx, defaultHashedPw)
 	if err != nil {
 		return err
 	}
@@ -287,34 +470,35 @@ func (store *Storage) populateNewDb() error {
 	return nil
 }
 
-// insertDefaultUser inserts the default user with the default password
-func insertDefaultUser(tx *sql.Tx) error {
-	// add default admin user
-	// default username and password
-	defaultUsername := "admin"
-	defaultPassword := "password"
-
-	// generate password hash
-	defaultHashedPw, err := bcrypt.GenerateFromPassword([]byte(defaultPassword), auth.BcryptCost)
-	if err != nil {
-		return err
-	}
+// default user's username and password
+const (
+	defaultUsername = "admin"
+	defaultPassword = "password"
+)
 
+// insertDefaultUser inserts the default user with the hash of the default password
+func insertDefaultUser(tx *sql.Tx, defaultHashedPw []byte) error {
 	// insert
 	query := `
 	INSERT INTO
-		users (username, password_hash, created_at, updated_at)
+		users (user_type, username, display_name, password_hash, role, last_login_at, created_at, updated_at)
 	VALUES (
 		$1,
 		$2,
+		'',
 		$3,
-		$4
+		$4,
+		0,
+		$5,
+		$6
 	)
 	`
 
-	_, err = tx.Exec(query,
+	_, err := tx.Exec(query,
+		session_manager.UserTypeLocal,
 		defaultUsername,
 		defaultHashedPw,
+		auth.RoleAdmin,
 		time.Now().Unix(),
 		time.Now().Unix(),
 	)
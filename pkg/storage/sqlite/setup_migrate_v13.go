@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CHANGES v12 to v13:
+// - private_cas:
+//		 - New table for built-in private certificate authorities
+// - certificates:
+//		 - 'acme_account_id' is now nullable
+//		 - Add 'private_ca_id' field/column (exactly one of the two must be set)
+// - orders:
+//		 - 'acme_account_id' is now nullable
+//		 - Add 'private_ca_id' field/column
+
+// createDBTablesV13 creates a fresh set of tables in the db using schema version specified
+func createDBTablesV13(tx *sql.Tx) error {
+	// acme_servers
+	query := `CREATE TABLE IF NOT EXISTS acme_servers (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		directory_url text NOT NULL UNIQUE,
+		is_staging integer NOT NULL DEFAULT 0 CHECK(is_staging IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err := tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_keys
+	query = `CREATE TABLE IF NOT EXISTS private_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		algorithm text NOT NULL,
+		pem text NOT NULL UNIQUE,
+		api_key text NOT NULL,
+		api_key_new text NOT NULL DEFAULT '',
+		api_key_disabled integer NOT NULL DEFAULT 0 CHECK(api_key_disabled IN (0,1)),
+		api_key_via_url integer NOT NULL DEFAULT 0 CHECK(api_key_via_url IN (0,1)),
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// acme_accounts
+	query = `CREATE TABLE IF NOT EXISTS acme_accounts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		private_key_id integer NOT NULL UNIQUE,
+		description text NOT NULL,
+		status text NOT NULL DEFAULT 'unknown',
+		email text NOT NULL,
+		accepted_tos integer NOT NULL DEFAULT 0 CHECK(accepted_tos IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		kid text NOT NULL,
+		acme_server_id integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_server_id)
+			REFERENCES acme_servers (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_cas
+	query = `CREATE TABLE IF NOT EXISTS private_cas (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		private_key_id integer NOT NULL UNIQUE,
+		certificate_pem text NOT NULL,
+		chain_pem text NOT NULL DEFAULT "",
+		default_validity_days integer NOT NULL DEFAULT 90,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// certificates
+	query = `CREATE TABLE IF NOT EXISTS certificates (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		private_key_id integer NOT NULL UNIQUE,
+		acme_account_id integer,
+		private_ca_id integer,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		subject text NOT NULL,
+		subject_alts text NOT NULL,
+		csr_org text NOT NULL,
+		csr_ou text NOT NULL,
+		csr_country text NOT NULL,
+		csr_state text NOT NULL,
+		csr_city text NOT NULL,
+		csr_extra_extensions text NOT NULL DEFAULT "[]",
+		preferred_root_cn text NOT NULL DEFAULT "",
+		api_key text NOT NULL,
+		api_key_new text NOT NULL DEFAULT '',
+		api_key_via_url integer NOT NULL DEFAULT 0 CHECK(api_key_via_url IN (0,1)),
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		post_processing_command text NOT NULL DEFAULT "",
+		post_processing_environment text NOT NULL DEFAULT "[]",
+		post_processing_client_address text NOT NULL DEFAULT "",
+		post_processing_client_key text NOT NULL DEFAULT "",
+		profile text NOT NULL DEFAULT "",
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_account_id)
+			REFERENCES acme_accounts (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_ca_id)
+			REFERENCES private_cas (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		CHECK((acme_account_id IS NULL) != (private_ca_id IS NULL))
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// ACME orders
+	query = `CREATE TABLE IF NOT EXISTS acme_orders (
+			id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+			acme_account_id integer,
+			private_ca_id integer,
+			certificate_id integer NOT NULL,
+			acme_location text NOT NULL UNIQUE,
+			status text NOT NULL,
+			known_revoked integer NOT NULL DEFAULT 0 CHECK(known_revoked IN (0,1)),
+			error text,
+			expires integer,
+			dns_identifiers text NOT NULL,
+			ip_identifiers text NOT NULL DEFAULT "[]",
+			authorizations text NOT NULL,
+			finalize text NOT NULL,
+			finalized_key_id integer,
+			certificate_url text,
+			pem text,
+			valid_from integer,
+			valid_to integer,
+			chain_root_cn text,
+			created_at integer NOT NULL,
+			updated_at integer NOT NULL,
+			profile text DEFAULT NULL,
+			renewal_info text DEFAULT NULL,
+			FOREIGN KEY (acme_account_id)
+				REFERENCES acme_accounts (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (private_ca_id)
+				REFERENCES private_cas (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (finalized_key_id)
+				REFERENCES private_keys (id)
+					ON DELETE SET NULL
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (certificate_id)
+				REFERENCES certificates (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION
+		)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// users (for login to app)
+	query = `CREATE TABLE IF NOT EXISTS users (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		username text NOT NULL UNIQUE,
+		password_hash NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// migrateV12toV13 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV12toV13() (int, error) {
+	oldSchemaVer := 12
+	newSchemaVer := 13
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// rename certificates and orders tables (they are rebuilt to change the issuer columns)
+	query = `
+		ALTER TABLE acme_orders RENAME TO acme_orders_old;
+		ALTER TABLE certificates RENAME TO certificates_old;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// create new tables (only those that don't exist are created)
+	err = createDBTablesV13(tx)
+	if err != nil {
+		return -1, err
+	}
+
+	// copy data from _old tables to new tables
+	query = `
+		INSERT INTO certificates (id, private_key_id, acme_account_id, name, description, subject, subject_alts,
+			csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn, api_key,
+			api_key_new, api_key_via_url, last_access, created_at, updated_at, post_processing_command,
+			post_processing_environment, post_processing_client_address, post_processing_client_key, profile)
+		SELECT id, private_key_id, acme_account_id, name, description, subject, subject_alts,
+			csr_org, csr_ou, csr_country, csr_state, csr_city, csr_extra_extensions, preferred_root_cn, api_key,
+			api_key_new, api_key_via_url, last_access, created_at, updated_at, post_processing_command,
+			post_processing_environment, post_processing_client_address, post_processing_client_key, profile
+		FROM certificates_old;
+
+		INSERT INTO acme_orders (id, acme_account_id, certificate_id, acme_location, status, known_revoked, error,
+			expires, dns_identifiers, ip_identifiers, authorizations, finalize, finalized_key_id, certificate_url, pem,
+			valid_from, valid_to, chain_root_cn, created_at, updated_at, profile, renewal_info)
+		SELECT id, acme_account_id, certificate_id, acme_location, status, known_revoked, error,
+			expires, dns_identifiers, ip_identifiers, authorizations, finalize, finalized_key_id, certificate_url, pem,
+			valid_from, valid_to, chain_root_cn, created_at, updated_at, profile, renewal_info
+		FROM acme_orders_old;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// remove _old tables
+	query = `
+		DROP TABLE acme_orders_old;
+		DROP TABLE certificates_old;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
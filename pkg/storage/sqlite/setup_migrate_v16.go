@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v15 to v16:
+// - certificates:
+//		 - Add key_rotation_interval (the number of issuances a private key may be
+//		   used for before it is automatically rotated, 0 = never rotate)
+// - retired_private_keys:
+//		 - New table tracking keys that were rotated out of a certificate and
+//		   should be deleted once they are no longer needed
+
+// migrateV15toV16 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV15toV16() (int, error) {
+	oldSchemaVer := 15
+	newSchemaVer := 16
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add key rotation interval to certificates
+	query = `
+		ALTER TABLE certificates
+		ADD key_rotation_interval integer NOT NULL DEFAULT 0
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// add retired private keys table
+	query = `
+		CREATE TABLE IF NOT EXISTS retired_private_keys (
+			private_key_id integer PRIMARY KEY NOT NULL UNIQUE,
+			certificate_id integer,
+			retired_at integer NOT NULL,
+			FOREIGN KEY (private_key_id)
+				REFERENCES private_keys (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (certificate_id)
+				REFERENCES certificates (id)
+					ON DELETE SET NULL
+					ON UPDATE NO ACTION
+		)
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
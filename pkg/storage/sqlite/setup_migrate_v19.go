@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/apikeyhash"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CHANGES v18 to v19:
+// - private_keys & certificates:
+//		 - api_key and api_key_new now hold a salted hash of the api key instead of the
+//		   plaintext api key. Existing api keys are hashed (the schema is unchanged).
+
+// migrateV18toV19 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV18toV19() (int, error) {
+	oldSchemaVer := 18
+	newSchemaVer := 19
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// hash existing api keys
+	for _, table := range []string{"private_keys", "certificates"} {
+		err = hashApiKeysTx(ctx, tx, table)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
+
+// hashApiKeysTx replaces the plaintext api_key and api_key_new values of all rows of
+// table with their salted hash. Blank and already hashed values are left unchanged.
+func hashApiKeysTx(ctx context.Context, tx *sql.Tx, table string) error {
+	// No injection protection since table isn't user editable
+	query := fmt.Sprintf(`
+	SELECT
+		id, api_key, api_key_new
+	FROM
+		%s
+	`, table)
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type idApiKeys struct {
+		id        int
+		apiKey    string
+		apiKeyNew string
+	}
+	var all []idApiKeys
+	for rows.Next() {
+		var one idApiKeys
+		err = rows.Scan(&one.id, &one.apiKey, &one.apiKeyNew)
+		if err != nil {
+			return err
+		}
+		all = append(all, one)
+	}
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	query = fmt.Sprintf(`
+	UPDATE
+		%s
+	SET
+		api_key = $1,
+		api_key_new = $2
+	WHERE
+		id = $3
+	`, table)
+
+	for _, one := range all {
+		apiKeyHash, err := hashApiKeyForMigration(one.apiKey)
+		if err != nil {
+			return err
+		}
+		apiKeyNewHash, err := hashApiKeyForMigration(one.apiKeyNew)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, apiKeyHash, apiKeyNewHash, one.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashApiKeyForMigration returns the hash of apiKey, unless it is blank or already
+// hashed in which case it is returned unchanged
+func hashApiKeyForMigration(apiKey string) (string, error) {
+	if apiKey == "" || apikeyhash.IsHash(apiKey) {
+		return apiKey, nil
+	}
+
+	return apikeyhash.Hash(apiKey)
+}
@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v19 to v20:
+// - api_keys:
+//		 - New table of named download api keys; each belongs to exactly one certificate
+//		   or private key and has an optional expiration, source ip allowlist, url use
+//		   toggle, and last use info. Existing api keys (and staged new api keys) are
+//		   moved into it.
+// - certificates & private_keys:
+//		 - Remove api_key, api_key_new, and api_key_via_url (replaced by api_keys)
+
+// migrateV19toV20 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV19toV20() (int, error) {
+	oldSchemaVer := 19
+	newSchemaVer := 20
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add api_keys
+	query = `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer,
+		private_key_id integer,
+		name text NOT NULL COLLATE NOCASE,
+		key_hash text NOT NULL,
+		via_url integer NOT NULL DEFAULT 0 CHECK(via_url IN (0,1)),
+		allowed_sources text NOT NULL DEFAULT "[]",
+		expires_at integer,
+		last_used_at integer NOT NULL DEFAULT 0,
+		last_used_ip text NOT NULL DEFAULT "",
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		CHECK((certificate_id IS NULL) != (private_key_id IS NULL)),
+		UNIQUE(certificate_id, name),
+		UNIQUE(private_key_id, name)
+	)
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// move each existing api key (and staged new api key) into api_keys
+	query = `
+	INSERT INTO api_keys (certificate_id, name, key_hash, via_url, last_used_at, created_at, updated_at)
+		SELECT id, 'default', api_key, api_key_via_url, last_access, created_at, updated_at
+		FROM certificates
+		WHERE api_key != '';
+
+	INSERT INTO api_keys (certificate_id, name, key_hash, via_url, created_at, updated_at)
+		SELECT id, 'new', api_key_new, api_key_via_url, updated_at, updated_at
+		FROM certificates
+		WHERE api_key_new != '';
+
+	INSERT INTO api_keys (private_key_id, name, key_hash, via_url, last_used_at, created_at, updated_at)
+		SELECT id, 'default', api_key, api_key_via_url, last_access, created_at, updated_at
+		FROM private_keys
+		WHERE api_key != '';
+
+	INSERT INTO api_keys (private_key_id, name, key_hash, via_url, created_at, updated_at)
+		SELECT id, 'new', api_key_new, api_key_via_url, updated_at, updated_at
+		FROM private_keys
+		WHERE api_key_new != '';
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// drop the old api key columns
+	query = `
+	ALTER TABLE certificates DROP COLUMN api_key;
+	ALTER TABLE certificates DROP COLUMN api_key_new;
+	ALTER TABLE certificates DROP COLUMN api_key_via_url;
+
+	ALTER TABLE private_keys DROP COLUMN api_key;
+	ALTER TABLE private_keys DROP COLUMN api_key_new;
+	ALTER TABLE private_keys DROP COLUMN api_key_via_url;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v20 to v21:
+// - download_log:
+//		 - New table recording each download made with an api key (the api key used,
+//		   client address, user agent, output format, and the order served)
+
+// migrateV20toV21 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV20toV21() (int, error) {
+	oldSchemaVer := 20
+	newSchemaVer := 21
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add download_log
+	query = `
+	CREATE TABLE IF NOT EXISTS download_log (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer,
+		private_key_id integer,
+		api_key_id integer,
+		api_key_name text NOT NULL,
+		client_address text NOT NULL,
+		user_agent text NOT NULL,
+		format text NOT NULL,
+		order_id integer,
+		created_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (api_key_id)
+			REFERENCES api_keys (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION,
+		CHECK((certificate_id IS NULL) != (private_key_id IS NULL))
+	);
+
+	CREATE INDEX IF NOT EXISTS download_log_certificate_id ON download_log (certificate_id);
+	CREATE INDEX IF NOT EXISTS download_log_private_key_id ON download_log (private_key_id);
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v22 to v23:
+// - users:
+//		 - Add user_type (local or oidc) so oidc users can be recorded when they log in;
+//		   username is now only unique per user_type
+//		 - Add display_name and last_login_at
+
+// migrateV22toV23 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV22toV23() (int, error) {
+	oldSchemaVer := 22
+	newSchemaVer := 23
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// recreate users (to change the unique constraint); all existing users are local
+	query = `
+	ALTER TABLE users RENAME TO users_old;
+
+	CREATE TABLE IF NOT EXISTS users (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		user_type text NOT NULL CHECK(user_type IN ('local','oidc')),
+		username text NOT NULL,
+		display_name text NOT NULL,
+		password_hash NOT NULL,
+		role text NOT NULL CHECK(role IN ('admin','operator','viewer')),
+		last_login_at integer NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		UNIQUE(user_type, username)
+	);
+
+	INSERT INTO users (id, user_type, username, display_name, password_hash, role, last_login_at, created_at, updated_at)
+	SELECT id, 'local', username, '', password_hash, role, 0, created_at, updated_at
+	FROM users_old;
+
+	DROP TABLE users_old;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v23 to v24:
+// - personal_access_tokens:
+//		 - New table of users' (hashed) personal access tokens for the management api
+
+// migrateV23toV24 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV23toV24() (int, error) {
+	oldSchemaVer := 23
+	newSchemaVer := 24
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add personal_access_tokens
+	query = `
+	CREATE TABLE IF NOT EXISTS personal_access_tokens (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		user_id integer NOT NULL,
+		name text NOT NULL,
+		token_hash text NOT NULL,
+		role text NOT NULL CHECK(role IN ('admin','operator','viewer')),
+		expires_at integer,
+		last_used_at integer NOT NULL,
+		last_used_ip text NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (user_id)
+			REFERENCES users (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		UNIQUE(user_id, name)
+	)
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
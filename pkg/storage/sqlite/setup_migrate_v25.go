@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v24 to v25:
+// - users:
+//		 - Add totp_secret, totp_enabled, totp_recovery_codes, and totp_last_counter
+//		   for local users' two-factor authentication
+
+// migrateV24toV25 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV24toV25() (int, error) {
+	oldSchemaVer := 24
+	newSchemaVer := 25
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add users totp columns
+	query = `
+	ALTER TABLE users
+	ADD COLUMN totp_secret text NOT NULL DEFAULT '';
+
+	ALTER TABLE users
+	ADD COLUMN totp_enabled integer NOT NULL DEFAULT 0 CHECK(totp_enabled IN (0,1));
+
+	ALTER TABLE users
+	ADD COLUMN totp_recovery_codes text NOT NULL DEFAULT '[]';
+
+	ALTER TABLE users
+	ADD COLUMN totp_last_counter integer NOT NULL DEFAULT 0;
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
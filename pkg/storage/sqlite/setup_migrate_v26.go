@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v25 to v26:
+// - sessions:
+//		 - New table of active login sessions, so sessions survive an app restart
+
+// migrateV25toV26 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV25toV26() (int, error) {
+	oldSchemaVer := 25
+	newSchemaVer := 26
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add sessions
+	query = `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id text PRIMARY KEY NOT NULL,
+		user_type text NOT NULL,
+		username text NOT NULL,
+		role text NOT NULL,
+		access_token_hash text NOT NULL,
+		access_token_expiration integer NOT NULL,
+		session_token_hash text NOT NULL,
+		session_expiration integer NOT NULL,
+		client_ip text NOT NULL,
+		user_agent text NOT NULL,
+		extra_data text NOT NULL,
+		created_at integer NOT NULL,
+		last_refresh_at integer NOT NULL
+	)
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
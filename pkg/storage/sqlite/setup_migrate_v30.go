@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// CHANGES v29 to v30:
+// - certificates:
+//		 - Add order_failure_streak, order_failure_last_at, order_failure_last_error, and
+//			 order_retry_at (to automatically retry failed orders)
+
+// migrateV29toV30 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV29toV30() (int, error) {
+	oldSchemaVer := 29
+	newSchemaVer := 30
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add certificates order failure columns
+	for _, column := range []string{
+		`order_failure_streak integer NOT NULL DEFAULT 0`,
+		`order_failure_last_at integer NOT NULL DEFAULT 0`,
+		`order_failure_last_error text NOT NULL DEFAULT ""`,
+		`order_retry_at integer`,
+	} {
+		query = `
+		ALTER TABLE certificates
+		ADD COLUMN ` + column
+
+		_, err = tx.Exec(query)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
@@ -0,0 +1,525 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CHANGES v30 to v31:
+// - private_keys:
+//		 - Add pem_hash (unique blind index of the pem, since encrypted pems are bound
+//			 to their row and no longer compare equal)
+
+// createDBTablesV31 creates a fresh set of tables in the db using schema version specified
+func createDBTablesV31(tx *sql.Tx) error {
+	// acme_servers
+	query := `CREATE TABLE IF NOT EXISTS acme_servers (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		directory_url text NOT NULL UNIQUE,
+		is_staging integer NOT NULL DEFAULT 0 CHECK(is_staging IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err := tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_keys
+	query = `CREATE TABLE IF NOT EXISTS private_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		algorithm text NOT NULL,
+		pem text NOT NULL UNIQUE,
+		pem_hash text,
+		api_key_disabled integer NOT NULL DEFAULT 0 CHECK(api_key_disabled IN (0,1)),
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS private_keys_pem_hash ON private_keys (pem_hash);
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// acme_accounts
+	query = `CREATE TABLE IF NOT EXISTS acme_accounts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		private_key_id integer NOT NULL UNIQUE,
+		description text NOT NULL,
+		status text NOT NULL DEFAULT 'unknown',
+		email text NOT NULL,
+		accepted_tos integer NOT NULL DEFAULT 0 CHECK(accepted_tos IN (0,1)),
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		kid text NOT NULL,
+		acme_server_id integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_server_id)
+			REFERENCES acme_servers (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private_cas
+	query = `CREATE TABLE IF NOT EXISTS private_cas (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		private_key_id integer NOT NULL UNIQUE,
+		certificate_pem text NOT NULL,
+		chain_pem text NOT NULL DEFAULT "",
+		default_validity_days integer NOT NULL DEFAULT 90,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// maintenance windows
+	query = `CREATE TABLE IF NOT EXISTS maintenance_windows (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		timezone text NOT NULL DEFAULT 'UTC',
+		ranges text NOT NULL DEFAULT '[]',
+		freezes text NOT NULL DEFAULT '[]',
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// certificates
+	query = `CREATE TABLE IF NOT EXISTS certificates (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		private_key_id integer UNIQUE,
+		acme_account_id integer,
+		private_ca_id integer,
+		name text NOT NULL UNIQUE COLLATE NOCASE,
+		description text NOT NULL,
+		subject text NOT NULL,
+		subject_alts text NOT NULL,
+		csr_org text NOT NULL,
+		csr_ou text NOT NULL,
+		csr_country text NOT NULL,
+		csr_state text NOT NULL,
+		csr_city text NOT NULL,
+		csr_extra_extensions text NOT NULL DEFAULT "[]",
+		preferred_root_cn text NOT NULL DEFAULT "",
+		last_access integer NOT NULL DEFAULT 0,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		post_processing_command text NOT NULL DEFAULT "",
+		post_processing_environment text NOT NULL DEFAULT "[]",
+		post_processing_client_address text NOT NULL DEFAULT "",
+		post_processing_client_key text NOT NULL DEFAULT "",
+		profile text NOT NULL DEFAULT "",
+		requested_validity_hours integer NOT NULL DEFAULT 0,
+		key_rotation_interval integer NOT NULL DEFAULT 0,
+		renewal_policy text NOT NULL DEFAULT "{}",
+		maintenance_window_id integer,
+		order_failure_streak integer NOT NULL DEFAULT 0,
+		order_failure_last_at integer NOT NULL DEFAULT 0,
+		order_failure_last_error text NOT NULL DEFAULT "",
+		order_retry_at integer,
+		csr_pem text,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (acme_account_id)
+			REFERENCES acme_accounts (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_ca_id)
+			REFERENCES private_cas (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (maintenance_window_id)
+			REFERENCES maintenance_windows (id)
+				ON DELETE RESTRICT
+				ON UPDATE NO ACTION,
+		CHECK((acme_account_id IS NULL) != (private_ca_id IS NULL)),
+		CHECK((private_key_id IS NULL) != (csr_pem IS NULL))
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// ACME orders
+	query = `CREATE TABLE IF NOT EXISTS acme_orders (
+			id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+			acme_account_id integer,
+			private_ca_id integer,
+			certificate_id integer NOT NULL,
+			acme_location text NOT NULL UNIQUE,
+			status text NOT NULL,
+			known_revoked integer NOT NULL DEFAULT 0 CHECK(known_revoked IN (0,1)),
+			error text,
+			expires integer,
+			dns_identifiers text NOT NULL,
+			ip_identifiers text NOT NULL DEFAULT "[]",
+			authorizations text NOT NULL,
+			finalize text NOT NULL,
+			finalized_key_id integer,
+			certificate_url text,
+			pem text,
+			valid_from integer,
+			valid_to integer,
+			chain_root_cn text,
+			created_at integer NOT NULL,
+			updated_at integer NOT NULL,
+			profile text DEFAULT NULL,
+			renewal_info text DEFAULT NULL,
+			FOREIGN KEY (acme_account_id)
+				REFERENCES acme_accounts (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (private_ca_id)
+				REFERENCES private_cas (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (finalized_key_id)
+				REFERENCES private_keys (id)
+					ON DELETE SET NULL
+					ON UPDATE NO ACTION,
+			FOREIGN KEY (certificate_id)
+				REFERENCES certificates (id)
+					ON DELETE CASCADE
+					ON UPDATE NO ACTION
+		)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// private keys rotated out of certificates
+	query = `CREATE TABLE IF NOT EXISTS retired_private_keys (
+		private_key_id integer PRIMARY KEY NOT NULL UNIQUE,
+		certificate_id integer,
+		retired_at integer NOT NULL,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// order fulfilling and post processing jobs
+	query = `CREATE TABLE IF NOT EXISTS jobs (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		type text NOT NULL,
+		order_id integer NOT NULL,
+		high_priority integer NOT NULL DEFAULT 0 CHECK(high_priority IN (0,1)),
+		status text NOT NULL,
+		attempts integer NOT NULL DEFAULT 0,
+		last_error text NOT NULL DEFAULT '',
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (order_id)
+			REFERENCES acme_orders (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// acme front-end eab keys
+	query = `CREATE TABLE IF NOT EXISTS acme_frontend_eab_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer NOT NULL UNIQUE,
+		key_id text NOT NULL UNIQUE,
+		hmac_key text NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// acme front-end accounts
+	query = `CREATE TABLE IF NOT EXISTS acme_frontend_accounts (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer NOT NULL,
+		jwk text NOT NULL,
+		jwk_thumbprint text NOT NULL UNIQUE,
+		status text NOT NULL,
+		contact text NOT NULL DEFAULT "[]",
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// download api keys
+	query = `CREATE TABLE IF NOT EXISTS api_keys (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer,
+		private_key_id integer,
+		name text NOT NULL COLLATE NOCASE,
+		key_hash text NOT NULL,
+		via_url integer NOT NULL DEFAULT 0 CHECK(via_url IN (0,1)),
+		allowed_sources text NOT NULL DEFAULT "[]",
+		expires_at integer,
+		last_used_at integer NOT NULL DEFAULT 0,
+		last_used_ip text NOT NULL DEFAULT "",
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		CHECK((certificate_id IS NULL) != (private_key_id IS NULL)),
+		UNIQUE(certificate_id, name),
+		UNIQUE(private_key_id, name)
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// download_log
+	query = `CREATE TABLE IF NOT EXISTS download_log (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		certificate_id integer,
+		private_key_id integer,
+		api_key_id integer,
+		api_key_name text NOT NULL,
+		client_address text NOT NULL,
+		user_agent text NOT NULL,
+		format text NOT NULL,
+		order_id integer,
+		created_at integer NOT NULL,
+		FOREIGN KEY (certificate_id)
+			REFERENCES certificates (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (private_key_id)
+			REFERENCES private_keys (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		FOREIGN KEY (api_key_id)
+			REFERENCES api_keys (id)
+				ON DELETE SET NULL
+				ON UPDATE NO ACTION,
+		CHECK((certificate_id IS NULL) != (private_key_id IS NULL))
+	);
+
+	CREATE INDEX IF NOT EXISTS download_log_certificate_id ON download_log (certificate_id);
+	CREATE INDEX IF NOT EXISTS download_log_private_key_id ON download_log (private_key_id);
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// users (for login to app)
+	query = `CREATE TABLE IF NOT EXISTS users (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		user_type text NOT NULL CHECK(user_type IN ('local','oidc')),
+		username text NOT NULL,
+		display_name text NOT NULL,
+		password_hash NOT NULL,
+		role text NOT NULL CHECK(role IN ('admin','operator','viewer')),
+		totp_secret text NOT NULL DEFAULT '',
+		totp_enabled integer NOT NULL DEFAULT 0 CHECK(totp_enabled IN (0,1)),
+		totp_recovery_codes text NOT NULL DEFAULT '[]',
+		totp_last_counter integer NOT NULL DEFAULT 0,
+		last_login_at integer NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL,
+		UNIQUE(user_type, username)
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// personal_access_tokens (for users to access the api without a session)
+	query = `CREATE TABLE IF NOT EXISTS personal_access_tokens (
+		id integer PRIMARY KEY AUTOINCREMENT NOT NULL UNIQUE,
+		user_id integer NOT NULL,
+		name text NOT NULL,
+		token_hash text NOT NULL,
+		role text NOT NULL CHECK(role IN ('admin','operator','viewer')),
+		expires_at integer,
+		last_used_at integer NOT NULL,
+		last_used_ip text NOT NULL,
+		created_at integer NOT NULL,
+		FOREIGN KEY (user_id)
+			REFERENCES users (id)
+				ON DELETE CASCADE
+				ON UPDATE NO ACTION,
+		UNIQUE(user_id, name)
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// sessions (active logins, so they survive a restart)
+	query = `CREATE TABLE IF NOT EXISTS sessions (
+		id text PRIMARY KEY NOT NULL,
+		user_type text NOT NULL,
+		username text NOT NULL,
+		role text NOT NULL,
+		access_token_hash text NOT NULL,
+		access_token_expiration integer NOT NULL,
+		session_token_hash text NOT NULL,
+		session_expiration integer NOT NULL,
+		client_ip text NOT NULL,
+		user_agent text NOT NULL,
+		extra_data text NOT NULL,
+		created_at integer NOT NULL,
+		last_refresh_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	// key_encryption
+	query = `CREATE TABLE IF NOT EXISTS key_encryption (
+		id integer PRIMARY KEY NOT NULL CHECK(id = 1),
+		kek_salt text NOT NULL,
+		wrapped_dek text NOT NULL,
+		created_at integer NOT NULL,
+		updated_at integer NOT NULL
+	)`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// migrateV30toV31 modifies the db to the specified schema, if it cannot
+// do so, an error is returned and modification is aborted
+func (store *Storage) migrateV30toV31() (int, error) {
+	oldSchemaVer := 30
+	newSchemaVer := 31
+
+	store.logger.Infof("updating database user_version from %d to %d", oldSchemaVer, newSchemaVer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// create sql transaction to roll back in the event an error occurs
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	// verify correct current ver
+	query := `PRAGMA user_version`
+	row := tx.QueryRowContext(ctx, query)
+	fileUserVersion := -1
+	err = row.Scan(
+		&fileUserVersion,
+	)
+	if err != nil {
+		return -1, err
+	}
+	if fileUserVersion != oldSchemaVer {
+		return -1, fmt.Errorf("cannot update db schema, current version %d (expected %d)", fileUserVersion, oldSchemaVer)
+	}
+
+	// add private_keys pem_hash (populated when existing pems are encrypted)
+	query = `
+	ALTER TABLE private_keys
+	ADD COLUMN pem_hash text;
+
+	CREATE UNIQUE INDEX IF NOT EXISTS private_keys_pem_hash ON private_keys (pem_hash);
+	`
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// update user_version
+	query = fmt.Sprintf(`
+		PRAGMA user_version = %d
+	`, newSchemaVer)
+
+	_, err = tx.Exec(query)
+	if err != nil {
+		return -1, err
+	}
+
+	// no errors, commit transaction
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+
+	store.logger.Infof("database user_version successfully upgraded from %d to %d", oldSchemaVer, newSchemaVer)
+	return newSchemaVer, nil
+}
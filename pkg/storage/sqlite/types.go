@@ -81,3 +81,37 @@ func makeJsonCertExtensionSlice(extensionSlice []certificates.CertExtensionJSON)
 
 	return jsonCertExtensionSlice(jpes)
 }
+
+// jsonRenewalPolicy is a json formatted string that is a certificates.RenewalPolicy
+type jsonRenewalPolicy string
+
+// transform JRP into a RenewalPolicy
+func (jrp jsonRenewalPolicy) toRenewalPolicy() (certificates.RenewalPolicy, error) {
+	policy := certificates.RenewalPolicy{}
+	if jrp == "" {
+		return policy, nil
+	}
+
+	err := json.Unmarshal([]byte(jrp), &policy)
+	if err != nil {
+		return certificates.RenewalPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// makeJsonRenewalPolicy creates a JRP from a RenewalPolicy; if policy is nil, nil is
+// returned (so updates leave the stored value unchanged)
+func makeJsonRenewalPolicy(policy *certificates.RenewalPolicy) *jsonRenewalPolicy {
+	if policy == nil {
+		return nil
+	}
+
+	jrp := jsonRenewalPolicy("{}")
+	data, err := json.Marshal(policy)
+	if err == nil {
+		jrp = jsonRenewalPolicy(data)
+	}
+
+	return &jrp
+}
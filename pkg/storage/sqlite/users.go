@@ -3,8 +3,16 @@ package sqlite
 // userDb represents how users are stored in the db
 type userDb struct {
 	id           int
+	userType     string
 	username     string
+	displayName  string
 	passwordHash string
+	role         string
+	totpSecret   string // encrypted if key encryption is enabled
+	totpEnabled  bool
+	totpRecovery jsonStringSlice // stored as json array
+	totpCounter  int
+	lastLoginAt  int
 	createdAt    int
 	updatedAt    int
 }
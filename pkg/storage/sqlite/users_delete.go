@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/storage"
+	"context"
+)
+
+// DeleteUser deletes a user from the database
+func (store *Storage) DeleteUser(id int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM
+		users
+	WHERE
+		id = $1
+	`
+
+	result, err := store.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	// confirm a user was deleted
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return storage.ErrNoRecord
+	}
+
+	return nil
+}
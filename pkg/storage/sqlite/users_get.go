@@ -2,45 +2,176 @@ package sqlite
 
 import (
 	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/pagination_sort"
 	"certwarden-backend/pkg/storage"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 )
 
-// dbToUser converts the user db object to app object
-func (userDb *userDb) dbToUser() (user auth.User) {
+// dbToUser converts the user db object to app object, decrypting the totp secret with
+// ke if it is encrypted
+func (userDb *userDb) dbToUser(ke *keyEncryption) (auth.User, error) {
+	totpSecret, err := ke.decrypt(secretUserTotp, userDb.id, userDb.totpSecret)
+	if err != nil {
+		return auth.User{}, err
+	}
+
 	return auth.User{
 		ID:           userDb.id,
+		Type:         userDb.userType,
 		Username:     userDb.username,
+		DisplayName:  userDb.displayName,
 		PasswordHash: userDb.passwordHash,
-		CreatedAt:    userDb.createdAt,
-		UpdatedAt:    userDb.updatedAt,
+		Role:         auth.Role(userDb.role),
+		Totp: auth.UserTotp{
+			Secret:             totpSecret,
+			Enabled:            userDb.totpEnabled,
+			RecoveryCodeHashes: userDb.totpRecovery.toSlice(),
+			LastCounter:        userDb.totpCounter,
+		},
+		LastLoginAt: userDb.lastLoginAt,
+		CreatedAt:   userDb.createdAt,
+		UpdatedAt:   userDb.updatedAt,
+	}, nil
+}
+
+// GetAllUsers returns a slice of all of the users in the database
+func (store *Storage) GetAllUsers(q pagination_sort.Query) (users []auth.User, totalRowCount int, err error) {
+	// validate and set sort
+	sortField := q.SortField()
+
+	switch sortField {
+	// allow these
+	case "id":
+		sortField = "id"
+	case "username":
+		sortField = "username"
+	case "user_type":
+		sortField = "user_type"
+	case "role":
+		sortField = "role"
+	// default if not in allowed list
+	default:
+		sortField = "username"
+	}
+
+	sort := sortField + " " + q.SortDirection()
+
+	// do query
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	// WARNING: SQL Injection is possible if the variables are not properly
+	// validated prior to this query being assembled!
+	query := fmt.Sprintf(`
+	SELECT
+		id, user_type, username, display_name, password_hash, role, totp_secret, totp_enabled,
+		totp_recovery_codes, totp_last_counter, last_login_at, created_at, updated_at,
+
+		count(*) OVER() AS full_count
+	FROM
+		users
+	ORDER BY
+		%s
+	LIMIT
+		$1
+	OFFSET
+		$2
+	`, sort)
+
+	rows, err := store.db.QueryContext(ctx, query,
+		q.Limit(),
+		q.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer rows.Close()
+
+	// for total row count
+	var totalRows int
+
+	var allUsers []auth.User
+	for rows.Next() {
+		var oneUser userDb
+		err = rows.Scan(
+			&oneUser.id,
+			&oneUser.userType,
+			&oneUser.username,
+			&oneUser.displayName,
+			&oneUser.passwordHash,
+			&oneUser.role,
+			&oneUser.totpSecret,
+			&oneUser.totpEnabled,
+			&oneUser.totpRecovery,
+			&oneUser.totpCounter,
+			&oneUser.lastLoginAt,
+			&oneUser.createdAt,
+			&oneUser.updatedAt,
+
+			&totalRows,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		convertedUser, err := oneUser.dbToUser(store.keyEncryption)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		allUsers = append(allUsers, convertedUser)
+	}
+
+	return allUsers, totalRows, nil
+}
+
+// GetOneUserById returns a user from the db based on id
+func (store *Storage) GetOneUserById(id int) (auth.User, error) {
+	return store.getOneUser(id, "", "")
+}
+
+// GetOneUserByTypeAndName returns a user from the db based on user type
+// and username
+func (store *Storage) GetOneUserByTypeAndName(userType string, username string) (auth.User, error) {
+	return store.getOneUser(-1, userType, username)
 }
 
-// GetOneUserByName returns a user from the db based on
-// username
-func (store Storage) GetOneUserByName(username string) (auth.User, error) {
+// getOneUser returns a user from the db based on either its id or its user type
+// and username
+func (store *Storage) getOneUser(id int, userType string, username string) (auth.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
 	defer cancel()
 
 	query := `
 	SELECT
-		id, username, password_hash, created_at, updated_at
+		id, user_type, username, display_name, password_hash, role, totp_secret, totp_enabled,
+		totp_recovery_codes, totp_last_counter, last_login_at, created_at, updated_at
 	FROM
 		users
 	WHERE
-		username = $1
+		id = $1
+		OR
+		(user_type = $2 AND username = $3)
 	`
 
-	row := store.db.QueryRowContext(ctx, query, username)
+	row := store.db.QueryRowContext(ctx, query, id, userType, username)
 
 	var user userDb
 	err := row.Scan(
 		&user.id,
+		&user.userType,
 		&user.username,
+		&user.displayName,
 		&user.passwordHash,
+		&user.role,
+		&user.totpSecret,
+		&user.totpEnabled,
+		&user.totpRecovery,
+		&user.totpCounter,
+		&user.lastLoginAt,
 		&user.createdAt,
 		&user.updatedAt,
 	)
@@ -53,7 +184,33 @@ func (store Storage) GetOneUserByName(username string) (auth.User, error) {
 		return auth.User{}, err
 	}
 
-	convertedUser := user.dbToUser()
+	convertedUser, err := user.dbToUser(store.keyEncryption)
+	if err != nil {
+		return auth.User{}, err
+	}
 
 	return convertedUser, nil
 }
+
+// CountUsersWithRole returns how many users have the specified role
+func (store *Storage) CountUsersWithRole(role auth.Role) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	SELECT
+		count(*)
+	FROM
+		users
+	WHERE
+		role = $1
+	`
+
+	count := 0
+	err := store.db.QueryRowContext(ctx, query, string(role)).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"context"
+)
+
+// PostNewUser saves a new local user to the db and returns it
+func (store *Storage) PostNewUser(payload auth.NewUserPayload) (auth.User, error) {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO users (user_type, username, display_name, password_hash, role, last_login_at, created_at, updated_at)
+	VALUES ($1, $2, '', $3, $4, 0, $5, $6)
+	RETURNING id
+	`
+
+	// insert and scan the new id
+	userId := -1
+	err := store.db.QueryRowContext(ctx, query,
+		session_manager.UserTypeLocal,
+		payload.Username,
+		payload.PasswordHash,
+		payload.Role,
+		payload.CreatedAt,
+		payload.UpdatedAt,
+	).Scan(&userId)
+
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	// get new user to return
+	newUser, err := store.GetOneUserById(userId)
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	return newUser, nil
+}
+
+// PutOIDCUserLogin records the login of an oidc user, updating their display name and role
+// to the current values from the idp. If the user doesn't exist yet, they are created.
+func (store *Storage) PutOIDCUserLogin(payload auth.OIDCUserLoginPayload) (auth.User, error) {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO users (user_type, username, display_name, password_hash, role, last_login_at, created_at, updated_at)
+	VALUES ($1, $2, $3, '', $4, $5, $5, $5)
+	ON CONFLICT (user_type, username) DO UPDATE SET
+		display_name = excluded.display_name,
+		role = excluded.role,
+		last_login_at = excluded.last_login_at,
+		updated_at = excluded.updated_at
+	RETURNING id
+	`
+
+	// insert/update and scan the id
+	userId := -1
+	err := store.db.QueryRowContext(ctx, query,
+		session_manager.UserTypeOIDC,
+		payload.Username,
+		payload.DisplayName,
+		payload.Role,
+		payload.LoginAt,
+	).Scan(&userId)
+
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	// get user to return
+	user, err := store.GetOneUserById(userId)
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	return user, nil
+}
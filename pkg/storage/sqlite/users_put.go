@@ -1,8 +1,12 @@
 package sqlite
 
-import "context"
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"certwarden-backend/pkg/domain/app/auth/session_manager"
+	"context"
+)
 
-// UpdateUserPassword updates the specified user's password hash to the specified
+// UpdateUserPassword updates the specified local user's password hash to the specified
 // hash.
 func (store *Storage) UpdateUserPassword(username string, newPasswordHash string) (userId int, err error) {
 	// database action
@@ -16,7 +20,9 @@ func (store *Storage) UpdateUserPassword(username string, newPasswordHash string
 		password_hash = $1,
 		updated_at = $2
 	WHERE
-		username = $3
+		user_type = $3
+		AND
+		username = $4
 	RETURNING
 		id
 	`
@@ -25,6 +31,7 @@ func (store *Storage) UpdateUserPassword(username string, newPasswordHash string
 	err = store.db.QueryRowContext(ctx, query,
 		newPasswordHash,
 		timeNow(),
+		session_manager.UserTypeLocal,
 		username,
 	).Scan(&userId)
 
@@ -34,3 +41,194 @@ func (store *Storage) UpdateUserPassword(username string, newPasswordHash string
 
 	return userId, nil
 }
+
+// PutUserUpdate updates the role and/or password hash of an existing user. Only
+// non-nil fields are updated.
+func (store *Storage) PutUserUpdate(payload auth.UpdateUserPayload) (auth.User, error) {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		password_hash = case when $1 is null then password_hash else $1 end,
+		role = case when $2 is null then role else $2 end,
+		updated_at = $3
+	WHERE
+		id = $4
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		payload.PasswordHash,
+		payload.Role,
+		payload.UpdatedAt,
+		payload.ID,
+	)
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	// get updated user to return
+	updatedUser, err := store.GetOneUserById(payload.ID)
+	if err != nil {
+		return auth.User{}, err
+	}
+
+	return updatedUser, nil
+}
+
+// PutUserLastLogin updates the specified user's last login time
+func (store *Storage) PutUserLastLogin(id int, lastLoginAt int) error {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		last_login_at = $1
+	WHERE
+		id = $2
+	`
+
+	_, err := store.db.ExecContext(ctx, query,
+		lastLoginAt,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutUserTotp replaces the specified user's totp config
+func (store *Storage) PutUserTotp(id int, userTotp auth.UserTotp) error {
+	// encrypt secret, if enabled
+	totpSecret, err := store.keyEncryption.encrypt(secretUserTotp, id, userTotp.Secret)
+	if err != nil {
+		return err
+	}
+
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		totp_secret = $1,
+		totp_enabled = $2,
+		totp_recovery_codes = $3,
+		totp_last_counter = $4,
+		updated_at = $5
+	WHERE
+		id = $6
+	`
+
+	_, err = store.db.ExecContext(ctx, query,
+		totpSecret,
+		userTotp.Enabled,
+		makeJsonStringSlice(userTotp.RecoveryCodeHashes),
+		userTotp.LastCounter,
+		timeNow(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutUserTotpCounterUsed saves counter as the time step of the specified user's last
+// accepted totp code. It returns false if the saved time step isn't before counter
+// (i.e., the code was already used, e.g. by a parallel login).
+func (store *Storage) PutUserTotpCounterUsed(id int, counter int) (bool, error) {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		totp_last_counter = $1
+	WHERE
+		id = $2
+		AND
+		totp_enabled = 1
+		AND
+		totp_last_counter < $1
+	`
+
+	result, err := store.db.ExecContext(ctx, query,
+		counter,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+// DeleteUserTotpRecoveryCode removes the recovery code hash from the specified user's
+// unused recovery codes. It returns false if the hash isn't one of them (i.e., the code
+// was already used, e.g. by a parallel login).
+func (store *Storage) DeleteUserTotpRecoveryCode(id int, recoveryCodeHash string) (bool, error) {
+	// database action
+	ctx, cancel := context.WithTimeout(context.Background(), store.timeout)
+	defer cancel()
+
+	query := `
+	UPDATE
+		users
+	SET
+		totp_recovery_codes = (
+			SELECT
+				json_group_array(value)
+			FROM
+				json_each(users.totp_recovery_codes)
+			WHERE
+				value != $1
+		)
+	WHERE
+		id = $2
+		AND
+		totp_enabled = 1
+		AND
+		EXISTS (
+			SELECT
+				1
+			FROM
+				json_each(users.totp_recovery_codes)
+			WHERE
+				value = $1
+		)
+	`
+
+	result, err := store.db.ExecContext(ctx, query,
+		recoveryCodeHash,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"certwarden-backend/pkg/domain/app/auth"
+	"slices"
+	"testing"
+)
+
+func TestUsers_TotpCodeUsedOnce(t *testing.T) {
+	store, err := openTestStorage(t, t.TempDir(), &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.PutUserTotp(1, auth.UserTotp{Secret: "TOTPSECRET", Enabled: true, RecoveryCodeHashes: []string{"hash-a", "hash-b"}, LastCounter: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// totp code time steps (only a later one is accepted, once)
+	for _, tt := range []struct {
+		counter int
+		want    bool
+	}{{5, false}, {6, true}, {6, false}, {4, false}, {8, true}} {
+		saved, err := store.PutUserTotpCounterUsed(1, tt.counter)
+		if err != nil || saved != tt.want {
+			t.Errorf("using time step %d saved %t (%v), expected %t", tt.counter, saved, err, tt.want)
+		}
+	}
+
+	// recovery codes (each accepted once)
+	for _, tt := range []struct {
+		hash string
+		want bool
+	}{{"hash-a", true}, {"hash-a", false}, {"hash-c", false}} {
+		saved, err := store.DeleteUserTotpRecoveryCode(1, tt.hash)
+		if err != nil || saved != tt.want {
+			t.Errorf("using recovery code %s saved %t (%v), expected %t", tt.hash, saved, err, tt.want)
+		}
+	}
+
+	user, err := store.GetOneUserById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Totp.LastCounter != 8 || !slices.Equal(user.Totp.RecoveryCodeHashes, []string{"hash-b"}) {
+		t.Errorf("saved totp counter %d and recovery codes %v, expected 8 and [hash-b]", user.Totp.LastCounter, user.Totp.RecoveryCodeHashes)
+	}
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"net/netip"
+)
+
+// IPAddressValid returns true if the string is a validly formatted IPv4
+// or IPv6 address that could be used as an ACME ip identifier (RFC 8738).
+// IPv6 zones and the unspecified addresses are not valid.
+func IPAddressValid(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	// zones are a local concept and cannot be in a certificate
+	if addr.Zone() != "" {
+		return false
+	}
+
+	return !addr.IsUnspecified()
+}
@@ -0,0 +1,49 @@
+package validation
+
+import "testing"
+
+// valid ip addresses
+var validIPAddresses = []string{
+	"192.0.2.1",
+	"10.0.0.254",
+	"127.0.0.1",
+	"2001:db8::1",
+	"2001:DB8:0:0:0:0:0:1",
+	"fe80::1",
+	"::ffff:192.0.2.1",
+}
+
+// invalid ip addresses
+var invalidIPAddresses = []string{
+	"",
+	" ",
+	"0.0.0.0",
+	"::",
+	"192.0.2",
+	"192.0.2.256",
+	"192.0.2.1 ",
+	" 192.0.2.1",
+	"192.0.2.1/24",
+	"2001:db8::1::1",
+	"fe80::1%eth0",
+	"[2001:db8::1]",
+	"example.com",
+}
+
+func TestValidation_IPAddressValid(t *testing.T) {
+	// test valid ip addresses
+	for _, ip := range validIPAddresses {
+		valid := IPAddressValid(ip)
+		if !valid {
+			t.Errorf("valid ip address test case '%s' returned invalid", ip)
+		}
+	}
+
+	// test invalid ip addresses
+	for _, ip := range invalidIPAddresses {
+		valid := IPAddressValid(ip)
+		if valid {
+			t.Errorf("invalid ip address test case '%s' returned valid", ip)
+		}
+	}
+}